@@ -5,29 +5,39 @@
 package opcua
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
 	"expvar"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"net/url"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/stats"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacert"
 	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
 	"github.com/zzylovesll/myOpcUa/uasc"
 )
 
 // GetEndpoints returns the available endpoint descriptions for the server.
+// The secure channel to the server is opened and closed again. Use
+// Client.GetEndpoints to get the endpoints over an open connection.
 func GetEndpoints(ctx context.Context, endpoint string, opts ...Option) ([]*ua.EndpointDescription, error) {
 	opts = append(opts, AutoReconnect(false))
 	c := NewClient(endpoint, opts...)
@@ -35,54 +45,374 @@ func GetEndpoints(ctx context.Context, endpoint string, opts ...Option) ([]*ua.E
 		return nil, err
 	}
 	defer c.CloseWithContext(ctx)
-	res, err := c.GetEndpointsWithContext(ctx)
+	res, err := c.GetEndpoints(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return res.Endpoints, nil
 }
 
-// SelectEndpoint returns the endpoint with the highest security level which matches
+// FindServers returns the servers which are registered with the discovery
+// server, e.g. a Local Discovery Server (LDS), including the discovery
+// server itself. The secure channel to the discovery server is opened
+// with security policy None and closed again.
+//
+// Servers can have several discovery urls, e.g. one for every network
+// interface. Use GetServerEndpoints to get the endpoints of a server from
+// the first discovery url which can be reached.
+func FindServers(ctx context.Context, discoveryURL string, opts ...Option) ([]*ua.ApplicationDescription, error) {
+	opts = append(opts, AutoReconnect(false))
+	c := NewClient(discoveryURL, opts...)
+	if err := c.Dial(ctx); err != nil {
+		return nil, err
+	}
+	defer c.CloseWithContext(ctx)
+	res, err := c.FindServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res.Servers, nil
+}
+
+// FindServersOnNetwork returns the servers which a Local Discovery Server
+// with multicast extension (LDS-ME) has found on the network, starting with
+// the record id startingRecordID. Only servers with all capabilities of
+// the capabilityFilter are returned, e.g. "DA" or "HD". The secure channel
+// to the discovery server is opened with security policy None and closed
+// again.
+//
+// The records are requested in pages of maxRecords records until all of
+// them have been read. If maxRecords is 0 the discovery server returns all
+// records at once. If the discovery server resets its record ids while the
+// pages are read, the records are read again from startingRecordID.
+func FindServersOnNetwork(ctx context.Context, discoveryURL string, startingRecordID, maxRecords uint32, capabilityFilter []string, opts ...Option) ([]*ua.ServerOnNetwork, error) {
+	opts = append(opts, AutoReconnect(false))
+	c := NewClient(discoveryURL, opts...)
+	if err := c.Dial(ctx); err != nil {
+		return nil, err
+	}
+	defer c.CloseWithContext(ctx)
+	return findServersOnNetwork(startingRecordID, maxRecords, func(start uint32) (*ua.FindServersOnNetworkResponse, error) {
+		return c.FindServersOnNetwork(ctx, start, maxRecords, capabilityFilter)
+	})
+}
+
+// maxRecordCounterResets is the number of times FindServersOnNetwork
+// reads the records again after the discovery server has reset its
+// record ids.
+const maxRecordCounterResets = 3
+
+// findServersOnNetwork reads the pages of server records with find, which
+// returns the records starting with a record id, and continues after the
+// record id of the last record of every page.
+func findServersOnNetwork(start, max uint32, find func(start uint32) (*ua.FindServersOnNetworkResponse, error)) ([]*ua.ServerOnNetwork, error) {
+	var servers []*ua.ServerOnNetwork
+	var resetTime time.Time
+	next, resets := start, 0
+	for {
+		res, err := find(next)
+		if err != nil {
+			return nil, err
+		}
+
+		// the record ids are only valid until the counter is reset
+		switch {
+		case next == start && len(servers) == 0:
+			resetTime = res.LastCounterResetTime
+		case !res.LastCounterResetTime.Equal(resetTime):
+			if resets++; resets > maxRecordCounterResets {
+				return nil, errors.Errorf("discovery server has reset its record ids %d times", resets)
+			}
+			servers, next = nil, start
+			continue
+		}
+
+		servers = append(servers, res.Servers...)
+		if len(res.Servers) == 0 || max == 0 || uint32(len(res.Servers)) < max {
+			return servers, nil
+		}
+		last := res.Servers[len(res.Servers)-1]
+		if last == nil || last.RecordID < next {
+			return servers, nil
+		}
+		next = last.RecordID + 1
+	}
+}
+
+// DiscoverEndpoint returns the endpoint of the first server which is
+// known to the discovery server and has an endpoint with the security
+// policy and the security mode. It combines FindServers,
+// GetServerEndpoints and FindEndpoint so that a client which only knows
+// the url of the discovery server gets an endpoint it can connect to.
+// Discovery servers and clients in the list of servers are skipped.
+func DiscoverEndpoint(ctx context.Context, discoveryURL, policy string, mode ua.MessageSecurityMode, opts ...Option) (*ua.EndpointDescription, error) {
+	servers, err := FindServers(ctx, discoveryURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	log := logger.OrDefault(ApplyConfig(opts...).logger)
+	err = errors.Errorf("no servers found at %s", discoveryURL)
+	for _, srv := range servers {
+		switch srv.ApplicationType {
+		case ua.ApplicationTypeClient, ua.ApplicationTypeDiscoveryServer:
+			continue
+		}
+		var eps []*ua.EndpointDescription
+		if eps, err = GetServerEndpoints(ctx, srv, opts...); err != nil {
+			log.Warn("client: discovery: cannot get server endpoints", "server_uri", srv.ApplicationURI, "err", err)
+			continue
+		}
+		var ep *ua.EndpointDescription
+		if ep, err = FindEndpoint(eps, policy, mode); err == nil {
+			return ep, nil
+		}
+	}
+	return nil, err
+}
+
+// GetServerEndpoints returns the endpoints of a server which has been
+// returned by FindServers. The discovery urls of the server are tried in
+// order until one of them returns the endpoints. Discovery urls which do
+// not use the opc.tcp protocol are skipped.
+func GetServerEndpoints(ctx context.Context, server *ua.ApplicationDescription, opts ...Option) ([]*ua.EndpointDescription, error) {
+	log := logger.OrDefault(ApplyConfig(opts...).logger)
+	err := errors.Errorf("server %s has no opc.tcp discovery url", server.ApplicationURI)
+	for _, u := range server.DiscoveryURLs {
+		if !strings.HasPrefix(u, "opc.tcp://") {
+			continue
+		}
+		var eps []*ua.EndpointDescription
+		if eps, err = GetEndpoints(ctx, u, opts...); err == nil {
+			return eps, nil
+		}
+		log.Warn("client: discovery: cannot get endpoints", "url", u, "err", err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// SelectEndpoint returns the endpoint with the strongest security which matches
 // security policy and security mode. policy and mode can be omitted so that
 // only one of them has to match.
+//
+// Endpoints are ordered by the strength of their security policy, then by
+// their security mode and then by the security level the server assigned to
+// them. If the policy is omitted only endpoints with a security policy which
+// is supported by the client are considered.
+//
+// Use FindEndpoint to also match the user token type and to get an error
+// which lists the available endpoints if none of them matches.
 // todo(fs): should this function return an error?
 func SelectEndpoint(endpoints []*ua.EndpointDescription, policy string, mode ua.MessageSecurityMode) *ua.EndpointDescription {
 	if len(endpoints) == 0 {
 		return nil
 	}
 
-	sort.Sort(sort.Reverse(bySecurityLevel(endpoints)))
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return strongerEndpoint(endpoints[i], endpoints[j])
+	})
 	policy = ua.FormatSecurityPolicyURI(policy)
 
-	// don't care -> return highest security level
-	if policy == "" && mode == ua.MessageSecurityModeInvalid {
-		return endpoints[0]
-	}
-
 	for _, p := range endpoints {
-		// match only security mode
-		if policy == "" && p.SecurityMode == mode {
+		if matchEndpoint(p, policy, mode) {
 			return p
 		}
+	}
+	return nil
+}
 
-		// match only security policy
-		if p.SecurityPolicyURI == policy && mode == ua.MessageSecurityModeInvalid {
-			return p
+// matchEndpoint returns true if the endpoint has the security policy and
+// the security mode. An omitted policy matches all policies which are
+// supported by the client and an omitted mode matches all modes.
+func matchEndpoint(ep *ua.EndpointDescription, policy string, mode ua.MessageSecurityMode) bool {
+	// skip policies the client cannot use
+	if policy == "" && securityPolicyRank(ep.SecurityPolicyURI) < 0 {
+		return false
+	}
+	if policy != "" && ep.SecurityPolicyURI != policy {
+		return false
+	}
+	return mode == ua.MessageSecurityModeInvalid || ep.SecurityMode == mode
+}
+
+// FindEndpoint returns the endpoint with the strongest security which
+// matches the security policy and the security mode like SelectEndpoint
+// and which offers one of the user token types. The token types are
+// tried in order so that FindEndpoint(eps, "", 0, ua.UserTokenTypeUserName,
+// ua.UserTokenTypeAnonymous) prefers endpoints with user name
+// authentication. If no token type is given the user tokens of the
+// endpoints are not checked.
+//
+// Unlike SelectEndpoint it does not reorder the endpoints and returns an
+// error which lists the available endpoints if none of them matches.
+func FindEndpoint(endpoints []*ua.EndpointDescription, policy string, mode ua.MessageSecurityMode, tokenTypes ...ua.UserTokenType) (*ua.EndpointDescription, error) {
+	eps := make([]*ua.EndpointDescription, len(endpoints))
+	copy(eps, endpoints)
+	sort.SliceStable(eps, func(i, j int) bool {
+		return strongerEndpoint(eps[i], eps[j])
+	})
+	policy = ua.FormatSecurityPolicyURI(policy)
+
+	if len(tokenTypes) == 0 {
+		for _, ep := range eps {
+			if matchEndpoint(ep, policy, mode) {
+				return ep, nil
+			}
+		}
+	}
+	for _, typ := range tokenTypes {
+		for _, ep := range eps {
+			if matchEndpoint(ep, policy, mode) && ep.HasUserTokenType(typ) {
+				return ep, nil
+			}
 		}
+	}
 
-		// match both
-		if p.SecurityPolicyURI == policy && p.SecurityMode == mode {
-			return p
+	want := []string{"security policy " + orAny(shortPolicyName(policy)), "mode " + orAny(shortModeName(mode))}
+	if len(tokenTypes) > 0 {
+		var types []string
+		for _, typ := range tokenTypes {
+			types = append(types, shortTokenTypeName(typ))
 		}
+		want = append(want, "user token "+strings.Join(types, " or "))
 	}
-	return nil
+	var avail []string
+	for _, ep := range endpoints {
+		avail = append(avail, endpointSummary(ep))
+	}
+	if len(avail) == 0 {
+		avail = []string{"none"}
+	}
+	return nil, errors.Errorf("no endpoint with %s. available endpoints: %s", strings.Join(want, ", "), strings.Join(avail, ", "))
+}
+
+// endpointSummary returns the security policy, the security mode and the
+// user token types of the endpoint, e.g. "Basic256Sha256/Sign (Anonymous,
+// UserName)".
+func endpointSummary(ep *ua.EndpointDescription) string {
+	var types []string
+	for _, t := range ep.UserIdentityTokens {
+		if t != nil {
+			types = append(types, shortTokenTypeName(t.TokenType))
+		}
+	}
+	return fmt.Sprintf("%s/%s (%s)", shortPolicyName(ep.SecurityPolicyURI), shortModeName(ep.SecurityMode), strings.Join(types, ", "))
+}
+
+func shortPolicyName(uri string) string {
+	return strings.TrimPrefix(uri, "http://opcfoundation.org/UA/SecurityPolicy#")
+}
+
+func shortModeName(mode ua.MessageSecurityMode) string {
+	if mode == ua.MessageSecurityModeInvalid {
+		return ""
+	}
+	return strings.TrimPrefix(mode.String(), "MessageSecurityMode")
+}
+
+func shortTokenTypeName(typ ua.UserTokenType) string {
+	return strings.TrimPrefix(typ.String(), "UserTokenType")
+}
+
+func orAny(s string) string {
+	if s == "" {
+		return "any"
+	}
+	return s
+}
+
+// securityPolicyRanks orders the supported security policies by their
+// strength.
+var securityPolicyRanks = map[string]int{
+	ua.SecurityPolicyURINone:                0,
+	ua.SecurityPolicyURIBasic128Rsa15:       1,
+	ua.SecurityPolicyURIBasic256:            2,
+	ua.SecurityPolicyURIBasic256Sha256:      3,
+	ua.SecurityPolicyURIAes128Sha256RsaOaep: 4,
+	ua.SecurityPolicyURIAes256Sha256RsaPss:  5,
+}
+
+// securityPolicyRank returns the strength of the security policy or -1
+// if the policy is not supported.
+func securityPolicyRank(uri string) int {
+	if r, ok := securityPolicyRanks[uri]; ok {
+		return r
+	}
+	return -1
 }
 
-type bySecurityLevel []*ua.EndpointDescription
+// strongerEndpoint returns true if endpoint a has a stronger security
+// policy than b, then a stronger security mode and then a higher
+// security level.
+func strongerEndpoint(a, b *ua.EndpointDescription) bool {
+	if ra, rb := securityPolicyRank(a.SecurityPolicyURI), securityPolicyRank(b.SecurityPolicyURI); ra != rb {
+		return ra > rb
+	}
+	if a.SecurityMode != b.SecurityMode {
+		return a.SecurityMode > b.SecurityMode
+	}
+	return a.SecurityLevel > b.SecurityLevel
+}
+
+// orderEndpoints returns the opc.tcp endpoints with a supported security
+// policy which match the filter in the order of the selection policy.
+// The endpoints slice is not modified.
+func orderEndpoints(endpoints []*ua.EndpointDescription, policy EndpointSelectionPolicy, filter func(*ua.EndpointDescription) bool) []*ua.EndpointDescription {
+	var eps []*ua.EndpointDescription
+	for _, ep := range endpoints {
+		if !strings.HasPrefix(ep.EndpointURL, "opc.tcp://") || securityPolicyRank(ep.SecurityPolicyURI) < 0 {
+			continue
+		}
+		if filter != nil && !filter(ep) {
+			continue
+		}
+		eps = append(eps, ep)
+	}
+
+	switch policy {
+	case EndpointSelectionStrongest:
+		sort.SliceStable(eps, func(i, j int) bool {
+			return strongerEndpoint(eps[i], eps[j])
+		})
+	case EndpointSelectionSecurityLevel:
+		sort.SliceStable(eps, func(i, j int) bool {
+			return eps[i].SecurityLevel > eps[j].SecurityLevel
+		})
+	}
+	return eps
+}
+
+// fallbackEndpointURL returns the endpoint url with the host and port of
+// the discovery url. It returns an empty string if both urls have the
+// same host or one of them is invalid.
+func fallbackEndpointURL(endpointURL, discoveryURL string) string {
+	e, err := url.Parse(endpointURL)
+	if err != nil {
+		return ""
+	}
+	d, err := url.Parse(discoveryURL)
+	if err != nil || d.Host == "" || e.Host == d.Host {
+		return ""
+	}
+	e.Host = d.Host
+	return e.String()
+}
+
+// ErrReconnecting is returned for requests which could not be completed
+// because the connection to the server was lost and the client is trying
+// to restore it. The request can be retried once the client is connected
+// again.
+var ErrReconnecting = errors.New("connection lost: reconnecting")
 
-func (a bySecurityLevel) Len() int           { return len(a) }
-func (a bySecurityLevel) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a bySecurityLevel) Less(i, j int) bool { return a[i].SecurityLevel < a[j].SecurityLevel }
+// ErrResponseLost is returned for requests which have been sent to the
+// server when the connection was lost before the response was received.
+// They are not sent again after the connection has been restored since
+// the server may have executed them already. Only read-only services
+// like Read and Browse are sent again and fail with ErrReconnecting.
+var ErrResponseLost = errors.New("connection lost: response of a sent request is unknown")
 
 // Client is a high-level client for an OPC/UA server.
 // It establishes a secure channel and a session.
@@ -90,9 +420,20 @@ type Client struct {
 	// endpointURL is the endpoint URL the client connects to.
 	endpointURL string
 
+	// discoveryURL is the endpoint URL the client was created with.
+	// The endpoints of the server are discovered with this URL if the
+	// endpoint selection is enabled.
+	discoveryURL string
+
 	// cfg is the configuration for the client.
 	cfg *Config
 
+	// log logs the events of the client.
+	log logger.Logger
+
+	// stats contains the cumulative statistics of the client.
+	stats clientStats
+
 	// conn is the open connection
 	conn *uacp.Conn
 
@@ -103,7 +444,8 @@ type Client struct {
 	// atomicSession is the active atomicSession.
 	atomicSession atomic.Value // *Session
 
-	// subMux guards subs and pendingAcks.
+	// subMux guards subs, pendingAcks and the state of the publish
+	// requests.
 	subMux sync.RWMutex
 
 	// subs is the set of active subscriptions by id.
@@ -113,6 +455,14 @@ type Client struct {
 	// for all active subscriptions.
 	pendingAcks []*ua.SubscriptionAcknowledgement
 
+	// publishSeq is the ticket of the last publish request and
+	// publishing contains the tickets of the outstanding requests.
+	publishSeq uint64
+	publishing map[uint64]bool
+
+	// publishStats contains the statistics of the publish requests.
+	publishStats PublishStats
+
 	// pausech pauses the subscription publish loop
 	pausech chan struct{}
 
@@ -128,12 +478,51 @@ type Client struct {
 	// atomicState of the client
 	atomicState atomic.Value // ConnState
 
+	// atomicServerStatus is the last status of the server which the
+	// session keepalive has read.
+	atomicServerStatus atomic.Value // ServerStatus
+
+	// stateMu guards stateChanged.
+	stateMu sync.Mutex
+
+	// stateChanged is closed and replaced on every state change.
+	stateChanged chan struct{}
+
 	// list of cached atomicNamespaces on the server
 	atomicNamespaces atomic.Value // []string
 
+	// atomicMaxNodesPerRead is the MaxNodesPerRead operation limit
+	// of the server. It is 0 if the limit is not known.
+	atomicMaxNodesPerRead uint32
+
+	// atomicMaxNodesPerWrite is the MaxNodesPerWrite operation limit
+	// of the server. It is 0 if the limit is not known.
+	atomicMaxNodesPerWrite uint32
+
+	// methodArgs caches the arguments of methods by method id.
+	methodArgs sync.Map // map[string]*methodArguments
+
+	// dataTypes caches the data types of nodes for WriteValue.
+	dataTypes *dataTypeCache
+
+	// registeredMu guards registered and readCounts.
+	registeredMu sync.Mutex
+
+	// registered contains the nodes registered with RegisterNodeIDs
+	// by the original node id.
+	registered map[string]*registeredNode
+
+	// readCounts counts the reads of nodes which are not registered
+	// when AutoRegisterNodes is enabled.
+	readCounts map[string]int
+
 	// monitorOnce ensures only one connection monitor is running
 	monitorOnce sync.Once
 
+	// issuedTokenMu guards the unencrypted issued token of the session
+	// config which is replaced by the token refresh.
+	issuedTokenMu sync.Mutex
+
 	// cfgerr contains an error that was captured in ApplyConfig.
 	// Since the API does not allow to bubble the error up in NewClient
 	// and we don't want to break existing code right away we carry the
@@ -160,14 +549,34 @@ type Client struct {
 func NewClient(endpoint string, opts ...Option) *Client {
 	cfg := ApplyConfig(opts...)
 	c := Client{
-		endpointURL: endpoint,
-		cfg:         cfg,
-		sechanErr:   make(chan error, 1),
-		subs:        make(map[uint32]*Subscription),
-		pendingAcks: make([]*ua.SubscriptionAcknowledgement, 0),
-		pausech:     make(chan struct{}, 2),
-		resumech:    make(chan struct{}, 2),
-		cfgerr:      cfg.Error(), // todo(fs): remove with v0.5.0 and return the error
+		endpointURL:  endpoint,
+		discoveryURL: endpoint,
+		cfg:          cfg,
+		log:          logger.OrDefault(cfg.logger),
+		sechanErr:    make(chan error, 1),
+		subs:         make(map[uint32]*Subscription),
+		pendingAcks:  make([]*ua.SubscriptionAcknowledgement, 0),
+		publishing:   make(map[uint64]bool),
+		pausech:      make(chan struct{}, 2),
+		resumech:     make(chan struct{}, 2),
+		stateChanged: make(chan struct{}),
+		dataTypes:    newDataTypeCache(cfg.dataTypeCacheSize),
+		cfgerr:       cfg.Error(), // todo(fs): remove with v0.5.0 and return the error
+	}
+	// the extension objects in the responses are decoded with the
+	// namespace array of the server.
+	if cfg.sechan.Namespaces == nil {
+		cfg.sechan.Namespaces = c.Namespaces
+	}
+
+	// the client records the statistics of all requests before the
+	// request tracer of the config is called.
+	tracer := cfg.sechan.RequestTracer
+	cfg.sechan.RequestTracer = func(info uasc.RequestInfo) {
+		c.recordRequest(info)
+		if tracer != nil {
+			tracer(info)
+		}
 	}
 	c.pauseSubscriptions(context.Background())
 	c.setPublishTimeout(uasc.MaxTimeout)
@@ -203,8 +612,24 @@ func (c *Client) Connect(ctx context.Context) (err error) {
 		return errors.Errorf("already connected")
 	}
 
+	var tokenExpiry time.Time
+	if c.cfg.issuedTokenFunc != nil {
+		tok, expiry, err := c.cfg.issuedTokenFunc(ctx)
+		if err != nil {
+			stats.RecordError(err)
+
+			return err
+		}
+		c.setIssuedToken(tok)
+		tokenExpiry = expiry
+	}
+
 	c.setState(Connecting)
-	if err := c.Dial(ctx); err != nil {
+	dial := c.Dial
+	if c.cfg.endpointSelection != EndpointSelectionOff {
+		dial = c.dialEndpoints
+	}
+	if err := dial(ctx); err != nil {
 		stats.RecordError(err)
 
 		return err
@@ -231,6 +656,12 @@ func (c *Client) Connect(ctx context.Context) (err error) {
 	c.monitorOnce.Do(func() {
 		go c.monitor(mctx)
 		go c.monitorSubscriptions(mctx)
+		if c.cfg.issuedTokenFunc != nil {
+			go c.monitorIssuedToken(mctx, tokenExpiry)
+		}
+		if c.cfg.keepaliveInterval > 0 {
+			go c.monitorKeepalive(mctx)
+		}
 	})
 
 	// todo(fs): we might need to guard this with an option in case of a broken
@@ -244,15 +675,41 @@ func (c *Client) Connect(ctx context.Context) (err error) {
 		return err
 	}
 
+	// the operation limits are optional and the client
+	// works without them. Therefore, errors are ignored.
+	if c.cfg.fetchOperationLimits {
+		c.updateOperationLimits(ctx)
+	}
+
 	return nil
 }
 
+// ctxKey is the type for context keys of this package.
+type ctxKey int
+
+// reconnectCtxKey marks the context of requests sent
+// by the reconnect logic.
+const reconnectCtxKey ctxKey = 0
+
+// nextReconnectInterval returns the interval for the next reconnection
+// attempt after an attempt with interval d failed.
+func (c *Client) nextReconnectInterval(d time.Duration) time.Duration {
+	max := c.cfg.sechan.ReconnectMaxInterval
+	if max <= c.cfg.sechan.ReconnectInterval {
+		return c.cfg.sechan.ReconnectInterval
+	}
+	if d *= 2; d > max {
+		return max
+	}
+	return d
+}
+
 // monitor manages connection alteration
 func (c *Client) monitor(ctx context.Context) {
-	dlog := debug.NewPrefixLogger("client: monitor: ")
+	ctx = context.WithValue(ctx, reconnectCtxKey, true)
 
-	dlog.Printf("start")
-	defer dlog.Printf("done")
+	c.log.Debug("client: monitor: start")
+	defer c.log.Debug("client: monitor: done")
 
 	defer c.mcancel()
 	defer c.setState(Closed)
@@ -268,22 +725,22 @@ func (c *Client) monitor(ctx context.Context) {
 
 			// return if channel or connection is closed
 			if !ok || err == io.EOF && c.State() == Closed {
-				dlog.Print("closed")
+				c.log.Debug("client: monitor: closed")
 				return
 			}
 
 			// tell the handler the connection is disconnected
 			c.setState(Disconnected)
-			dlog.Print("disconnected")
+			c.log.Debug("client: monitor: disconnected")
 
 			if !c.cfg.sechan.AutoReconnect {
 				// the connection is closed and should not be restored
 				action = abortReconnect
-				dlog.Print("auto-reconnect disabled")
+				c.log.Debug("client: monitor: auto-reconnect disabled")
 				return
 			}
 
-			dlog.Print("auto-reconnecting")
+			c.log.Info("client: monitor: auto-reconnecting", "err", err)
 
 			switch {
 			case errors.Is(err, io.EOF):
@@ -335,7 +792,7 @@ func (c *Client) monitor(ctx context.Context) {
 					switch action {
 
 					case createSecureChannel:
-						dlog.Printf("action: createSecureChannel")
+						c.log.Debug("client: monitor: action: createSecureChannel")
 
 						// recreate a secure channel by brute forcing
 						// a reconnection to the server
@@ -357,24 +814,29 @@ func (c *Client) monitor(ctx context.Context) {
 
 						c.setState(Reconnecting)
 
-						dlog.Printf("trying to recreate secure channel")
+						// the server may have changed while we were disconnected
+						c.dataTypes.clear()
+
+						c.log.Debug("client: monitor: trying to recreate secure channel")
+						interval := c.cfg.sechan.ReconnectInterval
 						for {
 							if err := c.Dial(ctx); err != nil {
 								select {
 								case <-ctx.Done():
 									return
-								case <-time.After(c.cfg.sechan.ReconnectInterval):
-									dlog.Printf("trying to recreate secure channel")
+								case <-time.After(interval):
+									interval = c.nextReconnectInterval(interval)
+									c.log.Debug("client: monitor: trying to recreate secure channel")
 									continue
 								}
 							}
 							break
 						}
-						dlog.Printf("secure channel recreated")
+						c.log.Info("client: monitor: secure channel recreated")
 						action = restoreSession
 
 					case restoreSession:
-						dlog.Printf("action: restoreSession")
+						c.log.Debug("client: monitor: action: restoreSession")
 
 						// try to reactivate the session,
 						// This only works if the session is still open on the server
@@ -382,81 +844,107 @@ func (c *Client) monitor(ctx context.Context) {
 
 						s := c.Session()
 						if s == nil {
-							dlog.Printf("no session to restore")
+							c.log.Debug("client: monitor: no session to restore")
 							action = recreateSession
 							continue
 						}
 
-						dlog.Printf("trying to restore session")
+						c.log.Debug("client: monitor: trying to restore session")
 						if err := c.ActivateSessionWithContext(ctx, s); err != nil {
-							dlog.Printf("restore session failed: %v", err)
+							c.log.Warn("client: monitor: restore session failed", "err", err)
 							action = recreateSession
 							continue
 						}
-						dlog.Printf("session restored")
+						c.log.Info("client: monitor: session restored")
 
 						// todo(fs): see comment about guarding this with an option in Connect()
-						dlog.Printf("trying to update namespaces")
+						c.log.Debug("client: monitor: trying to update namespaces")
 						if err := c.UpdateNamespacesWithContext(ctx); err != nil {
-							dlog.Printf("updating namespaces failed: %v", err)
+							c.log.Warn("client: monitor: updating namespaces failed", "err", err)
 							action = createSecureChannel
 							continue
 						}
-						dlog.Printf("namespaces updated")
+						c.log.Debug("client: monitor: namespaces updated")
+
+						// the subscriptions are still bound to the session.
+						// Republish the notifications which may have been lost.
+						subsToRepublish = c.SubscriptionIDs()
+						subsToRecreate = nil
+						availableSeqs = nil
 
 						action = restoreSubscriptions
 
 					case recreateSession:
-						dlog.Printf("action: recreateSession")
+						c.log.Debug("client: monitor: action: recreateSession")
 
 						// create a new session to replace the previous one
 
-						dlog.Printf("trying to recreate session")
+						c.log.Debug("client: monitor: trying to recreate session")
 						s, err := c.CreateSessionWithContext(ctx, c.cfg.session)
 						if err != nil {
-							dlog.Printf("recreate session failed: %v", err)
+							c.log.Warn("client: monitor: recreate session failed", "err", err)
 							action = createSecureChannel
 							continue
 						}
 						if err := c.ActivateSessionWithContext(ctx, s); err != nil {
-							dlog.Printf("reactivate session failed: %v", err)
+							c.log.Warn("client: monitor: reactivate session failed", "err", err)
 							action = createSecureChannel
 							continue
 						}
-						dlog.Print("session recreated")
+						c.log.Info("client: monitor: session recreated")
 
 						// todo(fs): see comment about guarding this with an option in Connect()
-						dlog.Printf("trying to update namespaces")
+						c.log.Debug("client: monitor: trying to update namespaces")
 						if err := c.UpdateNamespacesWithContext(ctx); err != nil {
-							dlog.Printf("updating namespaces failed: %v", err)
+							c.log.Warn("client: monitor: updating namespaces failed", "err", err)
+							action = createSecureChannel
+							continue
+						}
+						c.log.Debug("client: monitor: namespaces updated")
+
+						// registered nodes are only valid for the lifetime of the session
+						c.log.Debug("client: monitor: trying to register nodes")
+						if err := c.reregisterNodes(ctx); err != nil {
+							c.log.Warn("client: monitor: registering nodes failed", "err", err)
 							action = createSecureChannel
 							continue
 						}
-						dlog.Printf("namespaces updated")
+						c.log.Debug("client: monitor: nodes registered")
 
 						action = transferSubscriptions
 
 					case transferSubscriptions:
-						dlog.Printf("action: transferSubscriptions")
+						c.log.Debug("client: monitor: action: transferSubscriptions")
 
 						// transfer subscriptions from the old to the new session
 						// and try to republish the subscriptions.
 						// Restore the subscriptions where republishing fails.
 
-						subIDs := c.SubscriptionIDs()
+						// subscriptions which have the transfer disabled are always recreated.
+						subIDs, noTransferIDs := c.transferableSubscriptionIDs()
 
 						availableSeqs = map[uint32][]uint32{}
-						subsToRecreate = nil
+						subsToRecreate = noTransferIDs
 						subsToRepublish = nil
 
+						if len(subIDs) == 0 {
+							action = restoreSubscriptions
+							continue
+						}
+
 						// try to transfer all subscriptions to the new session and
 						// recreate them all if that fails.
 						res, err := c.transferSubscriptions(ctx, subIDs)
 						switch {
 						case err != nil:
-							dlog.Printf("transfer subscriptions failed. Recreating all subscriptions: %v", err)
+							c.log.Warn("client: monitor: transfer subscriptions failed. Recreating all subscriptions", "err", err)
 							subsToRepublish = nil
-							subsToRecreate = subIDs
+							subsToRecreate = append(subsToRecreate, subIDs...)
+
+						case len(res.Results) != len(subIDs):
+							c.log.Warn("client: monitor: transfer subscriptions returned wrong number of results. Recreating all subscriptions", "results", len(res.Results), "subscriptions", len(subIDs))
+							subsToRepublish = nil
+							subsToRecreate = append(subsToRecreate, subIDs...)
 
 						default:
 							// otherwise, try a republish for the subscriptions that were transferred
@@ -464,13 +952,15 @@ func (c *Client) monitor(ctx context.Context) {
 							for i := range res.Results {
 								transferResult := res.Results[i]
 								switch transferResult.StatusCode {
-								case ua.StatusBadSubscriptionIDInvalid:
-									dlog.Printf("sub %d: transfer subscription failed", subIDs[i])
-									subsToRecreate = append(subsToRecreate, subIDs[i])
-
-								default:
+								case ua.StatusOK:
 									subsToRepublish = append(subsToRepublish, subIDs[i])
 									availableSeqs[subIDs[i]] = transferResult.AvailableSequenceNumbers
+
+								default:
+									// StatusBadSubscriptionIDInvalid or any other error means that
+									// the subscription is gone and needs to be recreated.
+									c.log.Warn("client: monitor: transfer subscription failed", "sub_id", subIDs[i], "status", transferResult.StatusCode)
+									subsToRecreate = append(subsToRecreate, subIDs[i])
 								}
 							}
 						}
@@ -478,7 +968,7 @@ func (c *Client) monitor(ctx context.Context) {
 						action = restoreSubscriptions
 
 					case restoreSubscriptions:
-						dlog.Printf("action: restoreSubscriptions")
+						c.log.Debug("client: monitor: action: restoreSubscriptions")
 
 						// try to republish the previous subscriptions from the server
 						// otherwise restore them.
@@ -487,30 +977,34 @@ func (c *Client) monitor(ctx context.Context) {
 
 						for _, id := range subsToRepublish {
 							if err := c.republishSubscription(ctx, id, availableSeqs[id]); err != nil {
-								dlog.Printf("republish of subscription %d failed", id)
+								c.log.Warn("client: monitor: republish of subscription failed", "sub_id", id, "err", err)
 								subsToRecreate = append(subsToRecreate, id)
 							}
 						}
 
+						action = none
 						for _, id := range subsToRecreate {
 							if err := c.recreateSubscription(ctx, id); err != nil {
-								dlog.Printf("recreate subscripitions failed: %v", err)
+								c.log.Warn("client: monitor: recreate subscriptions failed", "sub_id", id, "err", err)
 								action = recreateSession
-								continue
+								break
 							}
 						}
+						if action != none {
+							continue
+						}
 
+						c.recordReconnect()
 						c.setState(Connected)
-						action = none
 
 					case abortReconnect:
-						dlog.Printf("action: abortReconnect")
+						c.log.Debug("client: monitor: action: abortReconnect")
 
 						// non recoverable disconnection
 						// stop the client
 
 						// todo(unknownet): should we store the error?
-						dlog.Printf("reconnection not recoverable")
+						c.log.Error("client: monitor: reconnection not recoverable")
 						return
 					}
 				}
@@ -521,9 +1015,17 @@ func (c *Client) monitor(ctx context.Context) {
 				<-c.sechanErr
 			}
 
-			dlog.Printf("resuming subscriptions")
-			c.resumeSubscriptions(ctx)
-			dlog.Printf("resumed subscriptions")
+			// the publish loop stays paused without subscriptions since
+			// servers without subscription support reject the publish
+			// requests.
+			c.subMux.RLock()
+			hasSubs := len(c.subs) > 0
+			c.subMux.RUnlock()
+			if hasSubs {
+				c.log.Debug("client: monitor: resuming subscriptions")
+				c.resumeSubscriptions(ctx)
+				c.log.Debug("client: monitor: resumed subscriptions")
+			}
 		}
 	}
 }
@@ -543,7 +1045,16 @@ func (c *Client) Dial(ctx context.Context) error {
 
 	var err error
 	var d = NewDialer(c.cfg)
-	c.conn, err = d.Dial(ctx, c.endpointURL)
+	if d.Logger == nil && c.cfg.logger != nil {
+		dd := *d
+		dd.Logger = c.cfg.logger
+		d = &dd
+	}
+	if l := c.cfg.reverseListener; l != nil {
+		c.conn, err = l.dial(ctx, d, c.cfg.reverseServerURI, c.endpointURL)
+	} else {
+		c.conn, err = d.Dial(ctx, c.endpointURL)
+	}
 	if err != nil {
 		return err
 	}
@@ -563,6 +1074,46 @@ func (c *Client) Dial(ctx context.Context) error {
 	return nil
 }
 
+// dialEndpoints discovers the endpoints of the server and establishes a
+// secure channel with the first endpoint in the order of the endpoint
+// selection policy which accepts the connection. Endpoint urls which
+// cannot be dialed are retried with the host and port of the discovery
+// url.
+func (c *Client) dialEndpoints(ctx context.Context) error {
+	endpoints, err := GetEndpoints(ctx, c.discoveryURL, Dialer(NewDialer(c.cfg)))
+	if err != nil {
+		return err
+	}
+	eps := orderEndpoints(endpoints, c.cfg.endpointSelection, c.cfg.endpointFilter)
+	if len(eps) == 0 {
+		return errors.Errorf("no matching endpoint at %s", c.discoveryURL)
+	}
+
+	for _, ep := range eps {
+		c.cfg.sechan.SecurityPolicyURI = ep.SecurityPolicyURI
+		c.cfg.sechan.SecurityMode = ep.SecurityMode
+		c.cfg.sechan.RemoteCertificate = ep.ServerCertificate
+		c.cfg.sechan.Thumbprint = uapolicy.Thumbprint(ep.ServerCertificate)
+
+		urls := []string{ep.EndpointURL}
+		if u := fallbackEndpointURL(ep.EndpointURL, c.discoveryURL); u != "" {
+			urls = append(urls, u)
+		}
+		for _, u := range urls {
+			c.endpointURL = u
+			if err = c.Dial(ctx); err == nil {
+				c.log.Info("client: connected to endpoint", "endpoint", u, "policy", ep.SecurityPolicyURI, "mode", ep.SecurityMode)
+				return nil
+			}
+			c.log.Warn("client: cannot connect to endpoint", "endpoint", u, "policy", ep.SecurityPolicyURI, "mode", ep.SecurityMode, "err", err)
+			if ctx.Err() != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
 // Close closes the session and the secure channel.
 //
 // Note: Starting with v0.5 this method will require a context
@@ -610,12 +1161,62 @@ func (c *Client) State() ConnState {
 }
 
 func (c *Client) setState(s ConnState) {
+	c.stateMu.Lock()
+	prev, _ := c.atomicState.Load().(ConnState)
 	c.atomicState.Store(s)
+	if prev != s {
+		close(c.stateChanged)
+		c.stateChanged = make(chan struct{})
+	}
+	c.stateMu.Unlock()
+
+	if prev != s {
+		c.log.Info("client: connection state changed", "from", prev, "to", s)
+		if c.cfg.stateFunc != nil {
+			c.cfg.stateFunc(prev, s)
+		}
+		if c.cfg.stateCh != nil {
+			// do not block the connection handling on slow receivers
+			select {
+			case c.cfg.stateCh <- s:
+			default:
+			}
+		}
+	}
 	n := new(expvar.Int)
 	n.Set(int64(s))
 	stats.Client().Set("State", n)
 }
 
+// waitForReconnect blocks until a lost connection has been restored. It
+// returns an error if the client was closed or the context is done.
+//
+// If lost is not nil the connection has been restored once the secure
+// channel lost has been replaced since the client might not have noticed
+// the lost connection yet.
+func (c *Client) waitForReconnect(ctx context.Context, lost *uasc.SecureChannel) error {
+	for {
+		c.stateMu.Lock()
+		s, ch := c.State(), c.stateChanged
+		c.stateMu.Unlock()
+
+		switch s {
+		case Connected:
+			if lost == nil || c.SecureChannel() != lost {
+				return nil
+			}
+		case Closed:
+			return ua.StatusBadServerNotConnected
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+}
+
 // Namespaces returns the currently cached list of namespaces.
 func (c *Client) Namespaces() []string {
 	return c.atomicNamespaces.Load().([]string)
@@ -638,6 +1239,18 @@ func (c *Client) SecureChannel() *uasc.SecureChannel {
 	return c.atomicSechan.Load().(*uasc.SecureChannel)
 }
 
+// SecureChannelLifetime returns the lifetime of the current security
+// token of the secure channel or 0 if the client is not connected.
+// It is the smaller of the requested lifetime and the lifetime
+// granted by the server.
+func (c *Client) SecureChannelLifetime() time.Duration {
+	sc := c.SecureChannel()
+	if sc == nil {
+		return 0
+	}
+	return sc.Lifetime()
+}
+
 func (c *Client) setSecureChannel(sc *uasc.SecureChannel) {
 	c.atomicSechan.Store(sc)
 	stats.Client().Add("SecureChannel", 1)
@@ -727,9 +1340,13 @@ func (c *Client) CreateSessionWithContext(ctx context.Context, cfg *uasc.Session
 			return err
 		}
 
+		if err := c.verifyServerCertificate(res); err != nil {
+			return err
+		}
+
 		err := c.SecureChannel().VerifySessionSignature(res.ServerCertificate, nonce, res.ServerSignature.Signature)
 		if err != nil {
-			log.Printf("error verifying session signature: %s", err)
+			c.log.Warn("client: cannot verify session signature", "err", err)
 			return nil
 		}
 
@@ -738,9 +1355,13 @@ func (c *Client) CreateSessionWithContext(ctx context.Context, cfg *uasc.Session
 			opt := AuthAnonymous()
 			opt(c.cfg)
 
-			p := anonymousPolicyID(res.ServerEndpoints)
-			opt = AuthPolicyID(p)
-			opt(c.cfg)
+			if err := c.selectUserTokenPolicy(res.ServerEndpoints); err != nil {
+				p := anonymousPolicyID(res.ServerEndpoints)
+				opt = AuthPolicyID(p)
+				opt(c.cfg)
+			}
+		} else if err := c.selectUserTokenPolicy(res.ServerEndpoints); err != nil {
+			return err
 		}
 
 		s = &Session{
@@ -749,31 +1370,175 @@ func (c *Client) CreateSessionWithContext(ctx context.Context, cfg *uasc.Session
 			serverNonce:       res.ServerNonce,
 			serverCertificate: res.ServerCertificate,
 		}
+		c.log.Info("client: session created", "session_id", res.SessionID, "timeout", time.Duration(res.RevisedSessionTimeout*float64(time.Millisecond)))
 
 		return nil
 	})
 	return s, err
 }
 
-const defaultAnonymousPolicyID = "Anonymous"
+// verifyServerCertificate validates the server certificate with the
+// configured verifier. The application uri is taken from the server
+// endpoints since they describe the server which has the certificate.
+// It returns an error if the certificate is not the certificate of the
+// secure channel.
+func (c *Client) verifyServerCertificate(res *ua.CreateSessionResponse) error {
+	if c.cfg.insecureSkipVerify {
+		return nil
+	}
+	// the session must be created with the certificate of the secure
+	// channel. Otherwise, a relay could present a trusted certificate
+	// while it runs the secure channel with its own key.
+	if c.cfg.sechan.SecurityMode != ua.MessageSecurityModeNone && !bytes.Equal(res.ServerCertificate, c.cfg.sechan.RemoteCertificate) {
+		return &uacert.VerifyError{Err: uacert.ErrCertInvalid, Reason: "server certificate differs from the certificate of the secure channel"}
+	}
+	if c.cfg.certVerifier == nil {
+		return nil
+	}
+	if len(res.ServerCertificate) == 0 {
+		if c.cfg.sechan.SecurityMode == ua.MessageSecurityModeNone {
+			return nil
+		}
+		return &uacert.VerifyError{Err: uacert.ErrCertUntrusted, Reason: "server did not send a certificate"}
+	}
 
-func anonymousPolicyID(endpoints []*ua.EndpointDescription) string {
-	for _, e := range endpoints {
-		if e.SecurityMode != ua.MessageSecurityModeNone || e.SecurityPolicyURI != ua.SecurityPolicyURINone {
+	opts := uacert.VerifyOptions{ExtKeyUsage: x509.ExtKeyUsageServerAuth}
+	for _, e := range res.ServerEndpoints {
+		if e.Server == nil || e.Server.ApplicationURI == "" {
 			continue
 		}
+		if opts.ApplicationURI == "" || bytes.Equal(e.ServerCertificate, res.ServerCertificate) {
+			opts.ApplicationURI = e.Server.ApplicationURI
+		}
+	}
+	if opts.ApplicationURI == "" {
+		return &uacert.VerifyError{Err: uacert.ErrURIMismatch, Reason: "server did not send an application uri"}
+	}
+	if c.cfg.verifyHostname {
+		u, err := url.Parse(c.endpointURL)
+		if err != nil {
+			return err
+		}
+		opts.Hostname = u.Hostname()
+	}
+	return c.cfg.certVerifier.Verify(res.ServerCertificate, opts)
+}
 
-		for _, t := range e.UserIdentityTokens {
-			if t.TokenType == ua.UserTokenTypeAnonymous {
-				return t.PolicyID
+// selectUserTokenPolicy sets the policy id and the security policy of the
+// user identity token from the user token policies of the endpoints which
+// match the secure channel. If the token already has a policy id only the
+// security policy is set. It returns an error if the endpoints have no
+// matching user token policy.
+//
+// Specification: Part 4, 5.6.3.2
+func (c *Client) selectUserTokenPolicy(endpoints []*ua.EndpointDescription) error {
+	tok := c.cfg.session.UserIdentityToken
+	typ, id := tokenType(tok), policyID(tok)
+
+	var found bool
+	for _, e := range endpoints {
+		if e.SecurityPolicyURI != c.cfg.sechan.SecurityPolicyURI || e.SecurityMode != c.cfg.sechan.SecurityMode {
+			continue
+		}
+		found = true
+		for _, p := range e.UserIdentityTokens {
+			if p.TokenType != typ || (id != "" && p.PolicyID != id) {
+				continue
 			}
+			if typ == ua.UserTokenTypeIssuedToken && c.cfg.issuedTokenType != "" && p.IssuedTokenType != c.cfg.issuedTokenType {
+				continue
+			}
+			setPolicyID(tok, p.PolicyID)
+			if c.cfg.session.AuthPolicyURI == "" {
+				c.cfg.session.AuthPolicyURI = p.SecurityPolicyURI
+			}
+			return nil
 		}
 	}
 
-	return defaultAnonymousPolicyID
+	// keep a policy id which was set explicitly if the server did not
+	// return the endpoint of the secure channel.
+	if !found && id != "" {
+		return nil
+	}
+	if id != "" {
+		return errors.Errorf("no user token policy %q for %s on endpoint %s %s", id, typ, c.cfg.sechan.SecurityPolicyURI, c.cfg.sechan.SecurityMode)
+	}
+	return errors.Errorf("no user token policy for %s on endpoint %s %s", typ, c.cfg.sechan.SecurityPolicyURI, c.cfg.sechan.SecurityMode)
 }
 
-// ActivateSession activates the session and associates it with the client. If
+const defaultAnonymousPolicyID = "Anonymous"
+
+func anonymousPolicyID(endpoints []*ua.EndpointDescription) string {
+	for _, e := range endpoints {
+		if e.SecurityMode != ua.MessageSecurityModeNone || e.SecurityPolicyURI != ua.SecurityPolicyURINone {
+			continue
+		}
+
+		for _, t := range e.UserIdentityTokens {
+			if t.TokenType == ua.UserTokenTypeAnonymous {
+				return t.PolicyID
+			}
+		}
+	}
+
+	return defaultAnonymousPolicyID
+}
+
+func (c *Client) setIssuedToken(tok []byte) {
+	c.issuedTokenMu.Lock()
+	c.cfg.session.AuthIssuedToken = tok
+	c.issuedTokenMu.Unlock()
+}
+
+// minIssuedTokenRefresh is the minimum delay between two token refreshes.
+const minIssuedTokenRefresh = time.Second
+
+// issuedTokenRefreshDelay returns the time until the token which expires
+// at expiry should be refreshed. Tokens are refreshed after three quarters
+// of their remaining lifetime.
+func issuedTokenRefreshDelay(now, expiry time.Time) time.Duration {
+	d := expiry.Sub(now) * 3 / 4
+	if d < minIssuedTokenRefresh {
+		return minIssuedTokenRefresh
+	}
+	return d
+}
+
+// monitorIssuedToken fetches a new issued token before the current token
+// expires and activates the session with it until ctx is cancelled. Tokens
+// without an expiry time are not refreshed.
+func (c *Client) monitorIssuedToken(ctx context.Context, expiry time.Time) {
+	for !expiry.IsZero() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(issuedTokenRefreshDelay(time.Now(), expiry)):
+		}
+
+		tok, next, err := c.cfg.issuedTokenFunc(ctx)
+		if err != nil {
+			c.log.Warn("client: issued token refresh failed", "err", err)
+			stats.RecordError(err)
+			continue
+		}
+		c.setIssuedToken(tok)
+
+		// a session which is re-created by the connection monitor is
+		// activated with the new token.
+		if s := c.Session(); s != nil && c.State() == Connected {
+			if err := c.ActivateSessionWithContext(ctx, s); err != nil {
+				c.log.Warn("client: activate session with refreshed token failed", "err", err)
+				stats.RecordError(err)
+				continue
+			}
+		}
+		stats.Client().Add("IssuedTokenRefresh", 1)
+		expiry = next
+	}
+}
+
+// ActivateSession activates the session and associates it with the client. If
 // the client already has a session it will be closed. To retain the current
 // session call DetachSession.
 //
@@ -793,27 +1558,32 @@ func (c *Client) ActivateSessionWithContext(ctx context.Context, s *Session) err
 	stats.Client().Add("ActivateSession", 1)
 	sig, sigAlg, err := c.SecureChannel().NewSessionSignature(s.serverCertificate, s.serverNonce)
 	if err != nil {
-		log.Printf("error creating session signature: %s", err)
+		c.log.Warn("client: cannot create session signature", "err", err)
 		return nil
 	}
 
-	switch tok := s.cfg.UserIdentityToken.(type) {
+	userToken := s.cfg.UserIdentityToken
+	switch tok := userToken.(type) {
 	case *ua.AnonymousIdentityToken:
 		// nothing to do
 
 	case *ua.UserNameIdentityToken:
 		pass, passAlg, err := c.SecureChannel().EncryptUserPassword(s.cfg.AuthPolicyURI, s.cfg.AuthPassword, s.serverCertificate, s.serverNonce)
 		if err != nil {
-			log.Printf("error encrypting user password: %s", err)
+			c.log.Warn("client: cannot encrypt user password", "err", err)
 			return err
 		}
 		tok.Password = pass
 		tok.EncryptionAlgorithm = passAlg
 
 	case *ua.X509IdentityToken:
-		tokSig, tokSigAlg, err := c.SecureChannel().NewUserTokenSignature(s.cfg.AuthPolicyURI, s.serverCertificate, s.serverNonce)
+		key, err := c.userTokenKey(tok, s.cfg.AuthPrivateKey)
 		if err != nil {
-			log.Printf("error creating session signature: %s", err)
+			return err
+		}
+		tokSig, tokSigAlg, err := c.SecureChannel().NewUserTokenSignatureWithKey(s.cfg.AuthPolicyURI, key, s.serverCertificate, s.serverNonce)
+		if err != nil {
+			c.log.Warn("client: cannot create user token signature", "err", err)
 			return err
 		}
 		s.cfg.UserTokenSignature = &ua.SignatureData{
@@ -822,7 +1592,24 @@ func (c *Client) ActivateSessionWithContext(ctx context.Context, s *Session) err
 		}
 
 	case *ua.IssuedIdentityToken:
-		tok.EncryptionAlgorithm = ""
+		// the token refresh replaces the raw token concurrently.
+		// Therefore, the encrypted token is sent with a copy of the
+		// identity token.
+		c.issuedTokenMu.Lock()
+		raw := s.cfg.AuthIssuedToken
+		c.issuedTokenMu.Unlock()
+		if raw != nil {
+			data, alg, err := c.SecureChannel().EncryptUserTokenData(s.cfg.AuthPolicyURI, raw, s.serverCertificate, s.serverNonce)
+			if err != nil {
+				c.log.Warn("client: cannot encrypt issued token", "err", err)
+				return err
+			}
+			userToken = &ua.IssuedIdentityToken{
+				PolicyID:            tok.PolicyID,
+				TokenData:           data,
+				EncryptionAlgorithm: alg,
+			}
+		}
 	}
 
 	req := &ua.ActivateSessionRequest{
@@ -832,7 +1619,7 @@ func (c *Client) ActivateSessionWithContext(ctx context.Context, s *Session) err
 		},
 		ClientSoftwareCertificates: nil,
 		LocaleIDs:                  s.cfg.LocaleIDs,
-		UserIdentityToken:          ua.NewExtensionObject(s.cfg.UserIdentityToken),
+		UserIdentityToken:          ua.NewExtensionObject(userToken),
 		UserTokenSignature:         s.cfg.UserTokenSignature,
 	}
 	return c.SecureChannel().SendRequestWithContext(ctx, req, s.resp.AuthenticationToken, func(v interface{}) error {
@@ -851,13 +1638,43 @@ func (c *Client) ActivateSessionWithContext(ctx context.Context, s *Session) err
 		// We decided not to check the error of CloseSession() since we
 		// can't do much about it anyway and it creates a race in the
 		// re-connection logic.
-		c.CloseSession()
+		//
+		// A session which is activated again, e.g. with a fresh
+		// issued token, is kept.
+		if c.Session() != s {
+			c.CloseSession()
+		}
 
 		c.setSession(s)
+		c.log.Info("client: session activated", "session_id", s.resp.SessionID)
 		return nil
 	})
 }
 
+// userTokenKey returns the private key of the certificate of an X509 user
+// token which signs the token. The private key of the application
+// instance certificate is only used if key is nil and the user
+// certificate is the application instance certificate.
+func (c *Client) userTokenKey(tok *ua.X509IdentityToken, key *rsa.PrivateKey) (*rsa.PrivateKey, error) {
+	if key == nil {
+		if !bytes.Equal(tok.CertificateData, c.cfg.sechan.Certificate) {
+			return nil, errors.New("missing private key of the user certificate: use the AuthPrivateKey option")
+		}
+		key = c.cfg.sechan.LocalKey
+	}
+	if key == nil {
+		return nil, errors.New("missing private key of the user certificate")
+	}
+	cert, err := x509.ParseCertificate(tok.CertificateData)
+	if err != nil {
+		return nil, errors.Errorf("invalid user certificate: %s", err)
+	}
+	if pub, ok := cert.PublicKey.(*rsa.PublicKey); !ok || !pub.Equal(&key.PublicKey) {
+		return nil, errors.New("private key does not match the user certificate")
+	}
+	return key, nil
+}
+
 // CloseSession closes the current session.
 //
 // # See Part 4, 5.6.4
@@ -922,24 +1739,91 @@ func (c *Client) Send(req ua.Request, h func(interface{}) error) error {
 func (c *Client) SendWithContext(ctx context.Context, req ua.Request, h func(interface{}) error) error {
 	stats.Client().Add("Send", 1)
 
-	err := c.sendWithTimeout(ctx, req, c.cfg.sechan.RequestTimeout, h)
+	sc := c.SecureChannel()
+	err := c.sendWithTimeout(ctx, req, c.requestTimeout(ctx), h)
+
+	// requests from the reconnect logic must not wait for themselves
+	for err == ErrReconnecting && c.cfg.waitReconnect && ctx.Value(reconnectCtxKey) == nil {
+		if err = c.waitForReconnect(ctx, sc); err != nil {
+			break
+		}
+		sc = c.SecureChannel()
+		err = c.sendWithTimeout(ctx, req, c.requestTimeout(ctx), h)
+	}
 	stats.RecordError(err)
 
 	return err
 }
 
+// requestTimeout returns the timeout of a request which is sent with ctx.
+// The deadline of ctx is the timeout of the request, even if it is longer
+// than the configured RequestTimeout, so that the caller decides how long
+// to wait. Requests with a context without a deadline use RequestTimeout.
+func (c *Client) requestTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return c.cfg.sechan.RequestTimeout
+}
+
 // sendWithTimeout sends the request via the secure channel with a custom timeout and registers a handler for
 // the response. If the client has an active session it injects the
 // authentication token.
+//
+// If the connection is lost while auto-reconnect is enabled the request
+// fails with ErrReconnecting if it has not been sent or is read-only.
+// Other requests which have been sent fail with ErrResponseLost since the
+// server may have executed them.
 func (c *Client) sendWithTimeout(ctx context.Context, req ua.Request, timeout time.Duration, h func(interface{}) error) error {
-	if c.SecureChannel() == nil {
+	sc := c.SecureChannel()
+	if sc == nil {
+		if c.reconnecting() {
+			return ErrReconnecting
+		}
 		return ua.StatusBadServerNotConnected
 	}
 	var authToken *ua.NodeID
 	if s := c.Session(); s != nil {
 		authToken = s.resp.AuthenticationToken
 	}
-	return c.SecureChannel().SendRequestWithTimeoutWithContext(ctx, req, authToken, timeout, h)
+	err := sc.SendRequestWithTimeoutWithContext(ctx, req, authToken, timeout, h)
+	if err == io.EOF && c.cfg.sechan.AutoReconnect && c.State() != Closed {
+		if readOnly(req) {
+			return ErrReconnecting
+		}
+		return ErrResponseLost
+	}
+	return err
+}
+
+// readOnly returns true if the request does not change the state of the
+// server so that it can be sent again if its response was lost.
+func readOnly(req ua.Request) bool {
+	switch r := req.(type) {
+	case *ua.ReadRequest, *ua.BrowseRequest, *ua.TranslateBrowsePathsToNodeIDsRequest,
+		*ua.GetEndpointsRequest, *ua.FindServersRequest:
+		return true
+	case *ua.BrowseNextRequest:
+		return !r.ReleaseContinuationPoints
+	case *ua.HistoryReadRequest:
+		return !r.ReleaseContinuationPoints
+	default:
+		return false
+	}
+}
+
+// reconnecting returns true if the connection was lost and the client
+// is trying to restore it.
+func (c *Client) reconnecting() bool {
+	if !c.cfg.sechan.AutoReconnect {
+		return false
+	}
+	switch c.State() {
+	case Disconnected, Reconnecting:
+		return true
+	default:
+		return false
+	}
 }
 
 // Node returns a node object which accesses its attributes
@@ -948,20 +1832,45 @@ func (c *Client) Node(id *ua.NodeID) *Node {
 	return &Node{ID: id, c: c}
 }
 
-// GetEndpoints returns the list of available endpoints of the server.
-//
-// Note: Starting with v0.5 this method will require a context
-// and the corresponding XXXWithContext(ctx) method will be removed.
-func (c *Client) GetEndpoints() (*ua.GetEndpointsResponse, error) {
-	return c.GetEndpointsWithContext(context.Background())
+// GetEndpointsOption configures the GetEndpointsRequest of
+// Client.GetEndpoints.
+type GetEndpointsOption func(*ua.GetEndpointsRequest)
+
+// GetEndpointsLocaleIDs sets the locales in priority order for the
+// localized strings of the endpoints, e.g. the application name. The
+// default are the locales of the session configuration.
+func GetEndpointsLocaleIDs(ids ...string) GetEndpointsOption {
+	return func(req *ua.GetEndpointsRequest) {
+		req.LocaleIDs = ids
+	}
 }
 
-// Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
-func (c *Client) GetEndpointsWithContext(ctx context.Context) (*ua.GetEndpointsResponse, error) {
+// GetEndpointsProfileURIs limits the endpoints to the transport
+// profiles, e.g. ua.TransportProfileURIUATCPBinary. The default returns
+// the endpoints of all transport profiles.
+func GetEndpointsProfileURIs(uris ...string) GetEndpointsOption {
+	return func(req *ua.GetEndpointsRequest) {
+		req.ProfileURIs = uris
+	}
+}
+
+// GetEndpoints returns the list of available endpoints of the server.
+//
+// The request is sent over the open secure channel of the client and
+// does not require a session. Use this method instead of the package
+// function GetEndpoints to get the endpoints from a server which the
+// client is already connected to.
+//
+// See Part 4, 5.4.4
+func (c *Client) GetEndpoints(ctx context.Context, opts ...GetEndpointsOption) (*ua.GetEndpointsResponse, error) {
 	stats.Client().Add("GetEndpoints", 1)
 
 	req := &ua.GetEndpointsRequest{
 		EndpointURL: c.endpointURL,
+		LocaleIDs:   c.cfg.session.LocaleIDs,
+	}
+	for _, opt := range opts {
+		opt(req)
 	}
 	var res *ua.GetEndpointsResponse
 	err := c.SendWithContext(ctx, req, func(v interface{}) error {
@@ -970,6 +1879,54 @@ func (c *Client) GetEndpointsWithContext(ctx context.Context) (*ua.GetEndpointsR
 	return res, err
 }
 
+// GetEndpointsWithContext returns the list of available endpoints of the
+// server.
+//
+// Deprecated: Use GetEndpoints.
+func (c *Client) GetEndpointsWithContext(ctx context.Context) (*ua.GetEndpointsResponse, error) {
+	return c.GetEndpoints(ctx)
+}
+
+// FindServers returns the servers which are known to the server or the
+// discovery server.
+//
+// See Part 4, 5.4.2
+func (c *Client) FindServers(ctx context.Context) (*ua.FindServersResponse, error) {
+	stats.Client().Add("FindServers", 1)
+
+	req := &ua.FindServersRequest{
+		EndpointURL: c.endpointURL,
+		LocaleIDs:   c.cfg.session.LocaleIDs,
+	}
+	var res *ua.FindServersResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	return res, err
+}
+
+// FindServersOnNetwork returns up to maxRecords records of the servers
+// which the discovery server has found on the network, starting with the
+// record id startingRecordID. Only servers with all capabilities of the
+// capabilityFilter are returned. If maxRecords is 0 all records are
+// returned.
+//
+// See Part 4, 5.4.3
+func (c *Client) FindServersOnNetwork(ctx context.Context, startingRecordID, maxRecords uint32, capabilityFilter []string) (*ua.FindServersOnNetworkResponse, error) {
+	stats.Client().Add("FindServersOnNetwork", 1)
+
+	req := &ua.FindServersOnNetworkRequest{
+		StartingRecordID:       startingRecordID,
+		MaxRecordsToReturn:     maxRecords,
+		ServerCapabilityFilter: capabilityFilter,
+	}
+	var res *ua.FindServersOnNetworkResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	return res, err
+}
+
 func cloneReadRequest(req *ua.ReadRequest) *ua.ReadRequest {
 	rvs := make([]*ua.ReadValueID, len(req.NodesToRead))
 	for i, rv := range req.NodesToRead {
@@ -1009,23 +1966,43 @@ func (c *Client) ReadWithContext(ctx context.Context, req *ua.ReadRequest) (*ua.
 	// clone the request and the ReadValueIDs to set defaults without
 	// manipulating them in-place.
 	req = cloneReadRequest(req)
+	c.useRegisteredNodes(ctx, req)
+
+	n := c.maxNodesPerRead()
+	if n == 0 || len(req.NodesToRead) <= n {
+		res, err := c.read(ctx, req)
+		if err != ua.StatusBadTooManyOperations {
+			return res, err
+		}
+
+		// the server has a lower limit than we know of.
+		// Fetch the limit and try again in smaller chunks.
+		n = c.updateMaxNodesPerRead(ctx)
+		if n == 0 || len(req.NodesToRead) <= n {
+			return res, err
+		}
+	}
+	return c.readChunked(ctx, req, n)
+}
 
+// read sends a single read request.
+func (c *Client) read(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
 	var res *ua.ReadResponse
 	err := c.SendWithContext(ctx, req, func(v interface{}) error {
 		err := safeAssign(v, &res)
 
 		// If the client cannot decode an extension object then its
-		// value will be nil. However, since the EO was known to the
-		// server the StatusCode for that data value will be OK. We
-		// therefore check for extension objects with nil values and set
-		// the status code to StatusBadDataTypeIDUnknown.
+		// value is the undecoded body. However, since the EO was known
+		// to the server the StatusCode for that data value will be OK.
+		// We therefore check for extension objects with undecoded
+		// values and set the status code to StatusBadDataTypeIDUnknown.
 		if err == nil {
 			for _, dv := range res.Results {
 				if dv.Value == nil {
 					continue
 				}
 				val := dv.Value.Value()
-				if eo, ok := val.(*ua.ExtensionObject); ok && eo.Value == nil {
+				if eo, ok := val.(*ua.ExtensionObject); ok && isUndecoded(eo) {
 					dv.Status = ua.StatusBadDataTypeIDUnknown
 				}
 			}
@@ -1036,8 +2013,515 @@ func (c *Client) ReadWithContext(ctx context.Context, req *ua.ReadRequest) (*ua.
 	return res, err
 }
 
+// readChunked splits the read request into requests with at most n nodes
+// and merges the results in the original order.
+func (c *Client) readChunked(ctx context.Context, req *ua.ReadRequest, n int) (*ua.ReadResponse, error) {
+	stats.Client().Add("ReadChunked", 1)
+
+	reqs := splitReadRequest(req, n)
+	resps := make([]*ua.ReadResponse, len(reqs))
+	err := c.runBatches(ctx, len(reqs), func(ctx context.Context, i int) error {
+		res, err := c.read(ctx, reqs[i])
+		if err != nil {
+			return err
+		}
+		if len(res.Results) != len(reqs[i].NodesToRead) {
+			return ua.StatusBadUnexpectedError
+		}
+		resps[i] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ua.ReadResponse{Results: make([]*ua.DataValue, 0, len(req.NodesToRead))}
+	var diags []*ua.DiagnosticInfo
+	for i, cres := range resps {
+		res.ResponseHeader = cres.ResponseHeader
+		res.Results = append(res.Results, cres.Results...)
+
+		// diagnostic infos are only useful if we can map them to the nodes
+		if len(cres.DiagnosticInfos) == len(reqs[i].NodesToRead) {
+			diags = append(diags, cres.DiagnosticInfos...)
+		}
+	}
+	if len(diags) == len(req.NodesToRead) {
+		res.DiagnosticInfos = diags
+	}
+	return res, nil
+}
+
+// splitReadRequest splits the read request into requests
+// with at most n nodes to read.
+func splitReadRequest(req *ua.ReadRequest, n int) []*ua.ReadRequest {
+	var reqs []*ua.ReadRequest
+	for i := 0; i < len(req.NodesToRead); i += n {
+		j := i + n
+		if j > len(req.NodesToRead) {
+			j = len(req.NodesToRead)
+		}
+		reqs = append(reqs, &ua.ReadRequest{
+			MaxAge:             req.MaxAge,
+			TimestampsToReturn: req.TimestampsToReturn,
+			NodesToRead:        req.NodesToRead[i:j],
+		})
+	}
+	return reqs
+}
+
+// maxNodesPerRead returns the maximum number of nodes per read request
+// which is the lower of the configured limit and the server limit.
+// It returns 0 if there is no limit.
+func (c *Client) maxNodesPerRead() int {
+	n := int(c.cfg.maxNodesPerRead)
+	if srv := int(atomic.LoadUint32(&c.atomicMaxNodesPerRead)); srv > 0 && (n == 0 || srv < n) {
+		n = srv
+	}
+	return n
+}
+
+// updateMaxNodesPerRead reads the MaxNodesPerRead operation limit from
+// the server and returns the new effective limit.
+func (c *Client) updateMaxNodesPerRead(ctx context.Context) int {
+	c.updateOperationLimits(ctx)
+	return c.maxNodesPerRead()
+}
+
+// maxNodesPerWrite returns the maximum number of nodes per write request
+// which is the lower of the configured limit and the server limit.
+// It returns 0 if there is no limit.
+func (c *Client) maxNodesPerWrite() int {
+	n := int(c.cfg.maxNodesPerWrite)
+	if srv := int(atomic.LoadUint32(&c.atomicMaxNodesPerWrite)); srv > 0 && (n == 0 || srv < n) {
+		n = srv
+	}
+	return n
+}
+
+// updateOperationLimits reads the MaxNodesPerRead and MaxNodesPerWrite
+// operation limits from the server. Limits which cannot be read are
+// left unchanged.
+func (c *Client) updateOperationLimits(ctx context.Context) {
+	limits := []struct {
+		id uint32
+		v  *uint32
+	}{
+		{id.Server_ServerCapabilities_OperationLimits_MaxNodesPerRead, &c.atomicMaxNodesPerRead},
+		{id.Server_ServerCapabilities_OperationLimits_MaxNodesPerWrite, &c.atomicMaxNodesPerWrite},
+	}
+
+	req := &ua.ReadRequest{}
+	for _, l := range limits {
+		req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{
+			NodeID:       ua.NewNumericNodeID(0, l.id),
+			AttributeID:  ua.AttributeIDValue,
+			DataEncoding: &ua.QualifiedName{},
+		})
+	}
+	res, err := c.read(ctx, req)
+	if err != nil || len(res.Results) != len(limits) {
+		c.log.Warn("client: cannot read operation limits", "err", err)
+		return
+	}
+	for i, l := range limits {
+		dv := res.Results[i]
+		if dv.Status != ua.StatusOK || dv.Value == nil {
+			c.log.Warn("client: cannot read operation limit", "node_id", l.id, "status", dv.Status)
+			continue
+		}
+		if n, ok := dv.Value.Value().(uint32); ok {
+			atomic.StoreUint32(l.v, n)
+		}
+	}
+}
+
+// DefaultBatchSize is the default number of nodes per request for
+// ReadBatched and WriteBatched if there are no operation limits.
+const DefaultBatchSize = 100
+
+// timestampsToReturn returns the timestamps of the values which the
+// nodes of the client read.
+func (c *Client) timestampsToReturn() ua.TimestampsToReturn {
+	if ts := c.cfg.timestampsToReturn; ts != nil {
+		return *ts
+	}
+	return ua.TimestampsToReturnBoth
+}
+
+// batchSize returns the number of nodes per request for ReadBatched and
+// WriteBatched if neither the client nor the server has a limit.
+func (c *Client) batchSize() int {
+	if c.cfg.batchSize > 0 {
+		return c.cfg.batchSize
+	}
+	return DefaultBatchSize
+}
+
+// runBatches calls fn for the batches 0..n-1 with up to BatchConcurrency
+// calls in parallel. It stops at the first error and returns it.
+func (c *Client) runBatches(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	workers := c.cfg.batchConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers == 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(ctx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		err     error
+		next    = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if e := fn(ctx, i); e != nil {
+					errOnce.Do(func() {
+						err = e
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// ReadBatched reads the nodes of the request with as many read requests
+// as necessary to stay within the MaxNodesPerRead limit of the client and
+// the server. If neither has a limit the request is split into chunks of
+// BatchSize nodes. The chunks are read with up to BatchConcurrency requests
+// in parallel and the results are returned in the order of the request.
+//
+// If the server rejects a chunk with StatusBadTooManyOperations the client
+// reads the operation limits of the server and tries again.
+func (c *Client) ReadBatched(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	stats.Client().Add("ReadBatched", 1)
+	stats.Client().Add("NodesToRead", int64(len(req.NodesToRead)))
+
+	req = cloneReadRequest(req)
+
+	n := c.maxNodesPerRead()
+	if n == 0 {
+		n = c.batchSize()
+	}
+	res, err := c.readChunked(ctx, req, n)
+	if err != ua.StatusBadTooManyOperations {
+		return res, err
+	}
+	m := c.updateMaxNodesPerRead(ctx)
+	if m == 0 || m >= n {
+		return res, err
+	}
+	return c.readChunked(ctx, req, m)
+}
+
+// WriteBatched writes the nodes of the request like ReadBatched reads them
+// using the MaxNodesPerWrite limits of the client and the server.
+func (c *Client) WriteBatched(ctx context.Context, req *ua.WriteRequest) (*ua.WriteResponse, error) {
+	stats.Client().Add("WriteBatched", 1)
+	stats.Client().Add("NodesToWrite", int64(len(req.NodesToWrite)))
+
+	n := c.maxNodesPerWrite()
+	if n == 0 {
+		n = c.batchSize()
+	}
+	res, err := c.writeChunked(ctx, req, n)
+	if err != ua.StatusBadTooManyOperations {
+		return res, err
+	}
+	c.updateOperationLimits(ctx)
+	m := c.maxNodesPerWrite()
+	if m == 0 || m >= n {
+		return res, err
+	}
+	return c.writeChunked(ctx, req, m)
+}
+
+// writeChunked writes the nodes in chunks of n nodes.
+func (c *Client) writeChunked(ctx context.Context, req *ua.WriteRequest, n int) (*ua.WriteResponse, error) {
+	reqs := splitWriteRequest(req, n)
+	resps := make([]*ua.WriteResponse, len(reqs))
+	err := c.runBatches(ctx, len(reqs), func(ctx context.Context, i int) error {
+		var res *ua.WriteResponse
+		err := c.SendWithContext(ctx, reqs[i], func(v interface{}) error {
+			return safeAssign(v, &res)
+		})
+		if err != nil {
+			return err
+		}
+		if len(res.Results) != len(reqs[i].NodesToWrite) {
+			return ua.StatusBadUnexpectedError
+		}
+		resps[i] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ua.WriteResponse{Results: make([]ua.StatusCode, 0, len(req.NodesToWrite))}
+	var diags []*ua.DiagnosticInfo
+	for i, cres := range resps {
+		res.ResponseHeader = cres.ResponseHeader
+		res.Results = append(res.Results, cres.Results...)
+
+		// diagnostic infos are only useful if we can map them to the nodes
+		if len(cres.DiagnosticInfos) == len(reqs[i].NodesToWrite) {
+			diags = append(diags, cres.DiagnosticInfos...)
+		}
+	}
+	if len(diags) == len(req.NodesToWrite) {
+		res.DiagnosticInfos = diags
+	}
+	return res, nil
+}
+
+// splitWriteRequest splits the write request into requests
+// with at most n nodes.
+func splitWriteRequest(req *ua.WriteRequest, n int) []*ua.WriteRequest {
+	var reqs []*ua.WriteRequest
+	for i := 0; i < len(req.NodesToWrite); i += n {
+		j := i + n
+		if j > len(req.NodesToWrite) {
+			j = len(req.NodesToWrite)
+		}
+		reqs = append(reqs, &ua.WriteRequest{
+			NodesToWrite: req.NodesToWrite[i:j],
+		})
+	}
+	return reqs
+}
+
+// ReadBool reads the value of a node which must be a scalar Boolean.
+func (c *Client) ReadBool(ctx context.Context, id *ua.NodeID) (bool, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDBoolean)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// ReadInt32 reads the value of a node which must be a scalar Int32.
+func (c *Client) ReadInt32(ctx context.Context, id *ua.NodeID) (int32, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDInt32)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int32), nil
+}
+
+// ReadInt64 reads the value of a node which must be a scalar Int64.
+func (c *Client) ReadInt64(ctx context.Context, id *ua.NodeID) (int64, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDInt64)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// ReadUint32 reads the value of a node which must be a scalar UInt32.
+func (c *Client) ReadUint32(ctx context.Context, id *ua.NodeID) (uint32, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDUint32)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint32), nil
+}
+
+// ReadFloat32 reads the value of a node which must be a scalar Float.
+func (c *Client) ReadFloat32(ctx context.Context, id *ua.NodeID) (float32, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDFloat)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float32), nil
+}
+
+// ReadFloat64 reads the value of a node which must be a scalar Double.
+func (c *Client) ReadFloat64(ctx context.Context, id *ua.NodeID) (float64, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDDouble)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// ReadString reads the value of a node which must be a scalar String.
+func (c *Client) ReadString(ctx context.Context, id *ua.NodeID) (string, error) {
+	v, err := c.readScalar(ctx, id, ua.TypeIDString)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// ReadValue reads the value of a node and assigns it to dest which must
+// be a non-nil pointer to a scalar or slice type which can be stored in a
+// Variant, e.g. *float64, *string, *time.Time or *[]int32. A pointer to
+// an empty interface accepts any value.
+//
+// If the value does not have the type of dest the error is an
+// *InvalidValueTypeError. Values are not converted.
+func (c *Client) ReadValue(ctx context.Context, id *ua.NodeID, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.Errorf("invalid destination: got %T want non-nil pointer", dest)
+	}
+	if _, _, err := destValueType(rv.Elem().Type()); err != nil {
+		return err
+	}
+
+	dv, err := c.readValue(ctx, id)
+	if err != nil {
+		return err
+	}
+	return assignValue(id, dv, rv.Elem())
+}
+
+// destValueType returns the type id of the values which can be assigned
+// to a value of type t and whether they are arrays.
+func destValueType(t reflect.Type) (ua.TypeID, bool, error) {
+	if t.Kind() == reflect.Interface {
+		return ua.TypeIDNull, false, nil
+	}
+
+	array := false
+	elem := t
+	if t.Kind() == reflect.Slice && t != reflect.TypeOf([]byte{}) {
+		array = true
+		elem = t.Elem()
+	}
+	v, err := ua.NewVariant(reflect.Zero(elem).Interface())
+	if err != nil {
+		return 0, false, errors.Errorf("invalid destination: unsupported type %s", t)
+	}
+	return v.Type(), array, nil
+}
+
+// assignValue assigns the value of dv to dest if it has a good status
+// code and the value has the type of dest.
+func assignValue(id *ua.NodeID, dv *ua.DataValue, dest reflect.Value) error {
+	if dv.Status != ua.StatusOK {
+		return &NodeStatusError{NodeID: id, Status: dv.Status}
+	}
+
+	want, wantArray, err := destValueType(dest.Type())
+	if err != nil {
+		return err
+	}
+
+	typeErr := func() error {
+		err := &InvalidValueTypeError{NodeID: id, Want: want, WantArray: wantArray}
+		if dv.Value != nil {
+			err.Got = dv.Value.Type()
+			err.Array = dv.Value.Has(ua.VariantArrayValues)
+		}
+		return err
+	}
+
+	if dv.Value == nil || dv.Value.Value() == nil {
+		if dest.Kind() == reflect.Interface {
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
+		}
+		return typeErr()
+	}
+	v := reflect.ValueOf(dv.Value.Value())
+	switch {
+	case v.Type().AssignableTo(dest.Type()):
+		dest.Set(v)
+	case v.Kind() == reflect.Slice && dest.Kind() == reflect.Slice && v.Type().ConvertibleTo(dest.Type()):
+		// e.g. ua.ByteArray to []byte
+		dest.Set(v.Convert(dest.Type()))
+	default:
+		return typeErr()
+	}
+	return nil
+}
+
+// readScalar reads the value attribute of a single node and verifies
+// that it is a scalar of the given type.
+func (c *Client) readScalar(ctx context.Context, id *ua.NodeID, want ua.TypeID) (interface{}, error) {
+	dv, err := c.readValue(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return scalarValue(id, dv, want)
+}
+
+// readValue reads the value attribute of a single node.
+func (c *Client) readValue(ctx context.Context, id *ua.NodeID) (*ua.DataValue, error) {
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{
+			{NodeID: id, AttributeID: ua.AttributeIDValue},
+		},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	}
+	res, err := c.ReadWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != 1 {
+		// see #188 in Node.AttributeWithContext
+		return nil, ua.StatusBadUnexpectedError
+	}
+	return res.Results[0], nil
+}
+
+// scalarValue returns the value of dv if it has a good status code and
+// contains a scalar value of the given type.
+func scalarValue(id *ua.NodeID, dv *ua.DataValue, want ua.TypeID) (interface{}, error) {
+	if dv.Status != ua.StatusOK {
+		return nil, &NodeStatusError{NodeID: id, Status: dv.Status}
+	}
+	if dv.Value == nil || dv.Value.Type() != want || dv.Value.Has(ua.VariantArrayValues) {
+		err := &InvalidValueTypeError{NodeID: id, Want: want}
+		if dv.Value != nil {
+			err.Got = dv.Value.Type()
+			err.Array = dv.Value.Has(ua.VariantArrayValues)
+		}
+		return nil, err
+	}
+	return dv.Value.Value(), nil
+}
+
 // Write executes a synchronous write request.
 //
+// Use ua.CombineStatusCodes to get an error for the failed writes of
+// the results.
+//
 // Note: Starting with v0.5 this method will require a context
 // and the corresponding XXXWithContext(ctx) method will be removed.
 func (c *Client) Write(req *ua.WriteRequest) (*ua.WriteResponse, error) {
@@ -1195,13 +2679,10 @@ func (c *Client) UnregisterNodesWithContext(ctx context.Context, req *ua.Unregis
 	return res, err
 }
 
-// Note: Starting with v0.5 this method will require a context
-// and the corresponding XXXWithContext(ctx) method will be removed.
-func (c *Client) HistoryReadRawModified(nodes []*ua.HistoryReadValueID, details *ua.ReadRawModifiedDetails) (*ua.HistoryReadResponse, error) {
-	return c.HistoryReadRawModifiedWithContext(context.Background(), nodes, details)
-}
-
-// Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
+// HistoryReadRawModifiedWithContext sends a single history read request
+// for the raw values of the nodes. It does not follow the continuation
+// points. Use HistoryReadRawModified or HistoryReadRaw to read all values
+// of a node.
 func (c *Client) HistoryReadRawModifiedWithContext(ctx context.Context, nodes []*ua.HistoryReadValueID, details *ua.ReadRawModifiedDetails) (*ua.HistoryReadResponse, error) {
 	stats.Client().Add("HistoryReadRawModified", 1)
 	stats.Client().Add("HistoryReadValueID", int64(len(nodes)))
@@ -1225,7 +2706,8 @@ func (c *Client) HistoryReadRawModifiedWithContext(ctx context.Context, nodes []
 	return res, err
 }
 
-// NamespaceArray returns the list of namespaces registered on the server.
+// NamespaceArray returns the list of namespaces registered on the server
+// and updates the list of cached namespaces returned by Namespaces.
 //
 // Note: Starting with v0.5 this method will require a context
 // and the corresponding XXXWithContext(ctx) method will be removed.
@@ -1246,6 +2728,7 @@ func (c *Client) NamespaceArrayWithContext(ctx context.Context) ([]string, error
 	if !ok {
 		return nil, errors.Errorf("error fetching namespace array. id=%d, type=%T", v.Type(), v.Value())
 	}
+	c.setNamespaces(ns)
 	return ns, nil
 }
 
@@ -1273,6 +2756,9 @@ func (c *Client) FindNamespaceWithContext(ctx context.Context, name string) (uin
 }
 
 // UpdateNamespaces updates the list of cached namespaces from the server.
+// The client updates the list automatically when it connects and after
+// it had to create a new session since the server may have changed the
+// namespace indexes.
 //
 // Note: Starting with v0.5 this method will require a context
 // and the corresponding XXXWithContext(ctx) method will be removed.
@@ -1283,12 +2769,138 @@ func (c *Client) UpdateNamespaces() error {
 // Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
 func (c *Client) UpdateNamespacesWithContext(ctx context.Context) error {
 	stats.Client().Add("UpdateNamespaces", 1)
-	ns, err := c.NamespaceArrayWithContext(ctx)
+	_, err := c.NamespaceArrayWithContext(ctx)
+	return err
+}
+
+// NamespaceIndex returns the index of the namespace with the given uri
+// from the list of cached namespaces.
+func (c *Client) NamespaceIndex(uri string) (uint16, error) {
+	for i, ns := range c.Namespaces() {
+		if ns == uri {
+			return uint16(i), nil
+		}
+	}
+	return 0, errors.Errorf("namespace not found. uri=%s", uri)
+}
+
+// NamespaceURI returns the uri of the namespace with the given index
+// from the list of cached namespaces.
+func (c *Client) NamespaceURI(index uint16) (string, error) {
+	ns := c.Namespaces()
+	if int(index) >= len(ns) {
+		return "", errors.Errorf("namespace not found. index=%d", index)
+	}
+	return ns[index], nil
+}
+
+// NodeIDFromURI returns the node id with the identifier in the namespace
+// with the given uri. The namespace index is looked up in the list of
+// cached namespaces.
+//
+// The identifier determines the type of the node id. Integers create
+// numeric node ids, strings create string node ids, byte slices create
+// opaque node ids and *ua.GUID values create GUID node ids.
+func (c *Client) NodeIDFromURI(uri string, id interface{}) (*ua.NodeID, error) {
+	ns, err := c.NamespaceIndex(uri)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	c.setNamespaces(ns)
-	return nil
+	return newNodeID(ns, id)
+}
+
+// ResolveExpandedNodeID returns the node id for the expanded node id.
+// If the expanded node id has a namespace uri the namespace index is
+// looked up in the list of cached namespaces. Node ids on other servers
+// cannot be resolved.
+func (c *Client) ResolveExpandedNodeID(eid *ua.ExpandedNodeID) (*ua.NodeID, error) {
+	if eid == nil || eid.NodeID == nil {
+		return nil, errors.Errorf("invalid expanded node id")
+	}
+	if eid.ServerIndex != 0 {
+		return nil, errors.Errorf("node %s is on server %d", eid, eid.ServerIndex)
+	}
+	if eid.NamespaceURI == "" {
+		return eid.NodeID, nil
+	}
+
+	ns, err := c.NamespaceIndex(eid.NamespaceURI)
+	if err != nil {
+		return nil, err
+	}
+
+	n := eid.NodeID
+	switch n.Type() {
+	case ua.NodeIDTypeTwoByte, ua.NodeIDTypeFourByte, ua.NodeIDTypeNumeric:
+		return ua.NewNumericNodeID(ns, n.IntID()), nil
+	case ua.NodeIDTypeString:
+		return ua.NewStringNodeID(ns, n.StringID()), nil
+	case ua.NodeIDTypeGUID:
+		return ua.NewGUIDNodeID(ns, n.StringID()), nil
+	case ua.NodeIDTypeByteString:
+		b, err := base64.StdEncoding.DecodeString(n.StringID())
+		if err != nil {
+			return nil, err
+		}
+		return ua.NewByteStringNodeID(ns, b), nil
+	default:
+		return nil, errors.Errorf("invalid node id type %d", n.Type())
+	}
+}
+
+// newNodeID returns a node id for the identifier in the namespace.
+func newNodeID(ns uint16, id interface{}) (*ua.NodeID, error) {
+	switch v := id.(type) {
+	case string:
+		return ua.NewStringNodeID(ns, v), nil
+	case []byte:
+		return ua.NewByteStringNodeID(ns, v), nil
+	case *ua.GUID:
+		return ua.NewGUIDNodeID(ns, v.String()), nil
+	}
+
+	var n int64
+	switch v := id.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint:
+		n = int64(v)
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint64:
+		if v > math.MaxUint32 {
+			return nil, errors.Errorf("numeric identifier out of range: %d", v)
+		}
+		n = int64(v)
+	default:
+		return nil, errors.Errorf("invalid identifier type %T", id)
+	}
+	if n < 0 || n > math.MaxUint32 {
+		return nil, errors.Errorf("numeric identifier out of range: %d", n)
+	}
+	return ua.NewNumericNodeID(ns, uint32(n)), nil
+}
+
+// isUndecoded returns true if the client could not decode the body of
+// the extension object since its type is unknown.
+func isUndecoded(eo *ua.ExtensionObject) bool {
+	switch eo.Value.(type) {
+	case nil, ua.ExtensionObjectBody:
+		return true
+	}
+	return false
 }
 
 // safeAssign implements a type-safe assign from T to *T.
@@ -1318,3 +2930,41 @@ type InvalidResponseTypeError struct {
 func (e InvalidResponseTypeError) Error() string {
 	return fmt.Sprintf("invalid response: got %T want %T", e.got, e.want)
 }
+
+// NodeStatusError is returned when the server reports a bad
+// status code for an operation on a single node.
+type NodeStatusError struct {
+	NodeID *ua.NodeID
+	Status ua.StatusCode
+}
+
+func (e *NodeStatusError) Error() string {
+	return fmt.Sprintf("opcua: node %s: %s", e.NodeID, e.Status)
+}
+
+// Unwrap returns the status code so that errors.Is can be used to
+// check for a specific status code.
+func (e *NodeStatusError) Unwrap() error {
+	return e.Status
+}
+
+// InvalidValueTypeError is returned when the value of a node does not
+// have the expected type.
+type InvalidValueTypeError struct {
+	NodeID    *ua.NodeID
+	Got       ua.TypeID
+	Want      ua.TypeID
+	Array     bool
+	WantArray bool
+}
+
+func (e *InvalidValueTypeError) Error() string {
+	got, want := e.Got.String(), e.Want.String()
+	if e.Array {
+		got = "array of " + got
+	}
+	if e.WantArray {
+		want = "array of " + want
+	}
+	return fmt.Sprintf("opcua: node %s: invalid value type: got %s want %s", e.NodeID, got, want)
+}
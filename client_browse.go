@@ -0,0 +1,527 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// DefaultBrowseBatchSize is the default number of nodes browsed with a
+// single BrowseRequest by BrowseRecursive.
+const DefaultBrowseBatchSize = 100
+
+// SkipChildren can be returned by a BrowseFunc to skip the references of
+// the current node. It is not returned as an error by BrowseRecursive.
+var SkipChildren = errors.New("skip children")
+
+// BrowseFunc is called by BrowseRecursive for every node found. parent is
+// the id of the browsed node, ref is the reference to the found node and
+// depth is the distance of the found node from the start node, starting
+// with 1.
+//
+// If the function returns SkipChildren the references of the found node
+// are not browsed. Any other error stops BrowseRecursive and is returned.
+type BrowseFunc func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error
+
+// BrowseOption configures BrowseRecursive.
+type BrowseOption func(*browseConfig)
+
+type browseConfig struct {
+	refType         *ua.NodeID
+	includeSubtypes bool
+	nodeClassMask   ua.NodeClass
+	maxDepth        int
+	batchSize       int
+
+	// log logs the errors which are not returned to the caller.
+	log logger.Logger
+}
+
+func defaultBrowseConfig() *browseConfig {
+	return &browseConfig{
+		refType:         ua.NewNumericNodeID(0, id.HierarchicalReferences),
+		includeSubtypes: true,
+		nodeClassMask:   ua.NodeClassAll,
+		batchSize:       DefaultBrowseBatchSize,
+		log:             logger.Default,
+	}
+}
+
+// BrowseReferenceType sets the type of the references to follow and
+// whether to follow the subtypes of the reference type as well.
+// The default is to follow HierarchicalReferences and all its subtypes.
+func BrowseReferenceType(refType *ua.NodeID, includeSubtypes bool) BrowseOption {
+	return func(cfg *browseConfig) {
+		cfg.refType = refType
+		cfg.includeSubtypes = includeSubtypes
+	}
+}
+
+// BrowseNodeClassMask limits the nodes returned to the given node classes.
+// Nodes of other classes are neither reported nor browsed.
+func BrowseNodeClassMask(mask ua.NodeClass) BrowseOption {
+	return func(cfg *browseConfig) {
+		cfg.nodeClassMask = mask
+	}
+}
+
+// BrowseMaxDepth limits the depth of the browse. A depth of 1 only
+// reports the direct children of the start node. The default of 0
+// browses the whole tree.
+func BrowseMaxDepth(n int) BrowseOption {
+	return func(cfg *browseConfig) {
+		cfg.maxDepth = n
+	}
+}
+
+// BrowseBatchSize sets the number of nodes which are browsed with a
+// single BrowseRequest. The default is DefaultBrowseBatchSize.
+func BrowseBatchSize(n int) BrowseOption {
+	return func(cfg *browseConfig) {
+		if n > 0 {
+			cfg.batchSize = n
+		}
+	}
+}
+
+// browser is the part of the client which is used by BrowseRecursive.
+type browser interface {
+	BrowseWithContext(ctx context.Context, req *ua.BrowseRequest) (*ua.BrowseResponse, error)
+	BrowseNextWithContext(ctx context.Context, req *ua.BrowseNextRequest) (*ua.BrowseNextResponse, error)
+}
+
+// BrowseRecursive walks the address space breadth-first starting at the
+// start node and calls fn for every node found in forward direction.
+// Continuation points are followed transparently with BrowseNext.
+//
+// Every node is reported only once, for the first reference which leads
+// to it, so that circular references do not cause an endless loop.
+// Nodes on other servers are reported but not browsed.
+//
+// If the server returns a bad status code for a node BrowseRecursive
+// stops and returns a *NodeStatusError.
+func (c *Client) BrowseRecursive(ctx context.Context, start *ua.NodeID, fn BrowseFunc, opts ...BrowseOption) error {
+	stats.Client().Add("BrowseRecursive", 1)
+
+	cfg := defaultBrowseConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.log = c.log
+	return browseRecursive(ctx, c, start, fn, cfg)
+}
+
+func browseRecursive(ctx context.Context, b browser, start *ua.NodeID, fn BrowseFunc, cfg *browseConfig) error {
+	type node struct {
+		id    *ua.NodeID
+		depth int
+	}
+
+	visited := map[string]bool{start.String(): true}
+	queue := []node{{id: start}}
+
+	for len(queue) > 0 {
+		n := cfg.batchSize
+		if n > len(queue) {
+			n = len(queue)
+		}
+		batch := queue[:n]
+		queue = queue[n:]
+
+		req := &ua.BrowseRequest{
+			View:          &ua.ViewDescription{ViewID: ua.NewTwoByteNodeID(0)},
+			NodesToBrowse: make([]*ua.BrowseDescription, len(batch)),
+		}
+		for i, nd := range batch {
+			req.NodesToBrowse[i] = &ua.BrowseDescription{
+				NodeID:          nd.id,
+				BrowseDirection: ua.BrowseDirectionForward,
+				ReferenceTypeID: cfg.refType,
+				IncludeSubtypes: cfg.includeSubtypes,
+				NodeClassMask:   uint32(cfg.nodeClassMask),
+				ResultMask:      uint32(ua.BrowseResultMaskAll),
+			}
+		}
+
+		res, err := b.BrowseWithContext(ctx, req)
+		if err != nil {
+			return err
+		}
+		if len(res.Results) != len(batch) {
+			return ua.StatusBadUnexpectedError
+		}
+
+		for i, r := range res.Results {
+			parent := batch[i]
+			if r.StatusCode != ua.StatusOK {
+				releaseBrowseContinuationPoints(b, cfg.log, res.Results[i:])
+				return &NodeStatusError{NodeID: parent.id, Status: r.StatusCode}
+			}
+
+			err := browseResult(ctx, b, cfg.log, r, func(ref *ua.ReferenceDescription) error {
+				if ref.NodeID == nil || ref.NodeID.NodeID == nil {
+					return nil
+				}
+				key := ref.NodeID.NodeID.String()
+				if visited[key] {
+					return nil
+				}
+				visited[key] = true
+
+				depth := parent.depth + 1
+				switch err := fn(parent.id, ref, depth); {
+				case err == SkipChildren:
+					return nil
+				case err != nil:
+					return err
+				}
+
+				if ref.NodeID.ServerIndex != 0 || (cfg.maxDepth > 0 && depth >= cfg.maxDepth) {
+					return nil
+				}
+				queue = append(queue, node{id: ref.NodeID.NodeID, depth: depth})
+				return nil
+			})
+			if err != nil {
+				releaseBrowseContinuationPoints(b, cfg.log, res.Results[i+1:])
+				if ns, ok := err.(*NodeStatusError); ok && ns.NodeID == nil {
+					ns.NodeID = parent.id
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BrowsedReference is a reference found by BrowseAll.
+type BrowsedReference struct {
+	*ua.ReferenceDescription
+
+	// ParentID is the id of the node which has the reference.
+	ParentID *ua.NodeID
+
+	// ParentPath contains the browse names of the nodes from the start
+	// node to the parent node. It is empty for references of the start
+	// node.
+	ParentPath []*ua.QualifiedName
+}
+
+// BrowseAll walks the address space depth-first starting at the start
+// node and returns all references found in forward direction. The
+// references are returned in pre-order, i.e. every reference is followed
+// by the references of its target node. BrowseAll supports the same
+// options as BrowseRecursive except BrowseBatchSize and reports every
+// node only once.
+//
+// BrowseAll keeps all references in memory. Use BrowseRecursive for
+// large address spaces.
+func (c *Client) BrowseAll(ctx context.Context, start *ua.NodeID, opts ...BrowseOption) ([]*BrowsedReference, error) {
+	stats.Client().Add("BrowseAll", 1)
+
+	cfg := defaultBrowseConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.log = c.log
+	return browseAll(ctx, c, start, cfg)
+}
+
+func browseAll(ctx context.Context, b browser, start *ua.NodeID, cfg *browseConfig) ([]*BrowsedReference, error) {
+	var refs []*BrowsedReference
+	visited := map[string]bool{start.String(): true}
+
+	var walk func(parent *ua.NodeID, path []*ua.QualifiedName) error
+	walk = func(parent *ua.NodeID, path []*ua.QualifiedName) error {
+		req := &ua.BrowseRequest{
+			View: &ua.ViewDescription{ViewID: ua.NewTwoByteNodeID(0)},
+			NodesToBrowse: []*ua.BrowseDescription{
+				{
+					NodeID:          parent,
+					BrowseDirection: ua.BrowseDirectionForward,
+					ReferenceTypeID: cfg.refType,
+					IncludeSubtypes: cfg.includeSubtypes,
+					NodeClassMask:   uint32(cfg.nodeClassMask),
+					ResultMask:      uint32(ua.BrowseResultMaskAll),
+				},
+			},
+		}
+		res, err := b.BrowseWithContext(ctx, req)
+		if err != nil {
+			return err
+		}
+		if len(res.Results) != 1 {
+			return ua.StatusBadUnexpectedError
+		}
+		if res.Results[0].StatusCode != ua.StatusOK {
+			return &NodeStatusError{NodeID: parent, Status: res.Results[0].StatusCode}
+		}
+
+		// collect the references of the node before descending so that
+		// the continuation point is not held while browsing the children.
+		var children []*ua.ReferenceDescription
+		err = browseResult(ctx, b, cfg.log, res.Results[0], func(ref *ua.ReferenceDescription) error {
+			if ref.NodeID == nil || ref.NodeID.NodeID == nil {
+				return nil
+			}
+			key := ref.NodeID.NodeID.String()
+			if visited[key] {
+				return nil
+			}
+			visited[key] = true
+			children = append(children, ref)
+			return nil
+		})
+		if err != nil {
+			if ns, ok := err.(*NodeStatusError); ok && ns.NodeID == nil {
+				ns.NodeID = parent
+			}
+			return err
+		}
+
+		for _, ref := range children {
+			refs = append(refs, &BrowsedReference{ReferenceDescription: ref, ParentID: parent, ParentPath: path})
+			if ref.NodeID.ServerIndex != 0 || (cfg.maxDepth > 0 && len(path)+1 >= cfg.maxDepth) {
+				continue
+			}
+			childPath := make([]*ua.QualifiedName, len(path)+1)
+			copy(childPath, path)
+			childPath[len(path)] = ref.BrowseName
+			if err := walk(ref.NodeID.NodeID, childPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(start, []*ua.QualifiedName{}); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// browseResult calls fn for all references of the result and follows the
+// continuation point. The continuation point is released if fn returns an
+// error.
+func browseResult(ctx context.Context, b browser, log logger.Logger, r *ua.BrowseResult, fn func(*ua.ReferenceDescription) error) error {
+	for {
+		for _, ref := range r.References {
+			if err := fn(ref); err != nil {
+				releaseBrowseContinuationPoints(b, log, []*ua.BrowseResult{r})
+				return err
+			}
+		}
+		if len(r.ContinuationPoint) == 0 {
+			return nil
+		}
+
+		res, err := b.BrowseNextWithContext(ctx, &ua.BrowseNextRequest{
+			ContinuationPoints: [][]byte{r.ContinuationPoint},
+		})
+		if err != nil {
+			releaseBrowseContinuationPoints(b, log, []*ua.BrowseResult{r})
+			return err
+		}
+		if len(res.Results) != 1 {
+			return ua.StatusBadUnexpectedError
+		}
+		r = res.Results[0]
+		if r.StatusCode != ua.StatusOK {
+			return &NodeStatusError{Status: r.StatusCode}
+		}
+	}
+}
+
+// releaseBrowseContinuationPoints releases the continuation points of the
+// results on the server. Errors are ignored since there is nothing left
+// to do for the caller.
+func releaseBrowseContinuationPoints(b browser, log logger.Logger, results []*ua.BrowseResult) {
+	var cps [][]byte
+	for _, r := range results {
+		if r != nil && len(r.ContinuationPoint) > 0 {
+			cps = append(cps, r.ContinuationPoint)
+		}
+	}
+	if len(cps) == 0 {
+		return
+	}
+
+	// use a new context since the original context may be done
+	_, err := b.BrowseNextWithContext(context.Background(), &ua.BrowseNextRequest{
+		ReleaseContinuationPoints: true,
+		ContinuationPoints:        cps,
+	})
+	if err != nil {
+		log.Warn("client: browse: releasing continuation points failed", "err", err)
+	}
+}
+
+// PathError is returned when a browse path cannot be resolved.
+type PathError struct {
+	Path   string
+	Status ua.StatusCode
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("opcua: path %q: %s", e.Path, e.Status)
+}
+
+// Unwrap returns the status code so that errors.Is can be used to
+// check for a specific status code.
+func (e *PathError) Unwrap() error {
+	return e.Status
+}
+
+// AmbiguousPathError is returned when a browse path resolves to more
+// than one node.
+type AmbiguousPathError struct {
+	Path    string
+	Targets []*ua.NodeID
+}
+
+func (e *AmbiguousPathError) Error() string {
+	return fmt.Sprintf("opcua: path %q matches %d nodes", e.Path, len(e.Targets))
+}
+
+// TranslateBrowsePaths returns the ids of the nodes at the path relative
+// to the start node. The path is a list of browse names separated by '/'
+// which are followed along hierarchical references, e.g.
+// "/Objects/2:MyDevice/2:Temperature". If start is nil the path is
+// relative to the root folder. See ua.ParseBrowsePath for the syntax.
+//
+// If the path does not match any node the error is a *PathError. If it
+// matches more than one node the error is an *AmbiguousPathError and the
+// ids of all matching nodes are returned.
+//
+// Part 4, 5.8.4
+func (c *Client) TranslateBrowsePaths(ctx context.Context, start *ua.NodeID, path string) ([]*ua.NodeID, error) {
+	stats.Client().Add("TranslateBrowsePaths", 1)
+
+	if start == nil {
+		start = ua.NewNumericNodeID(0, id.RootFolder)
+	}
+
+	names, err := ua.ParseBrowsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ua.TranslateBrowsePathsToNodeIDsRequest{
+		BrowsePaths: []*ua.BrowsePath{ua.NewBrowsePath(start, names)},
+	}
+	var res *ua.TranslateBrowsePathsToNodeIDsResponse
+	err = c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != 1 {
+		return nil, ua.StatusBadUnexpectedError
+	}
+	return pathTargets(path, res.Results[0])
+}
+
+// pathTargets returns the ids of the targets of a browse path result
+// which must have exactly one target.
+func pathTargets(path string, r *ua.BrowsePathResult) ([]*ua.NodeID, error) {
+	if r.StatusCode != ua.StatusOK {
+		return nil, &PathError{Path: path, Status: r.StatusCode}
+	}
+
+	var ids []*ua.NodeID
+	for _, t := range r.Targets {
+		if t.TargetID != nil {
+			ids = append(ids, t.TargetID.NodeID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return nil, &PathError{Path: path, Status: ua.StatusBadNoMatch}
+	case 1:
+		return ids, nil
+	default:
+		return ids, &AmbiguousPathError{Path: path, Targets: ids}
+	}
+}
+
+// ResolvePath returns the id of the node at the path relative to the start
+// node. The path has the form "ns:name/ns:name", e.g.
+// "Objects/2:DeviceSet/3:PLC1". If start is nil the path is relative to
+// the root folder. See ua.ParseBrowsePath for the syntax.
+//
+// If the path matches more than one node the first one is returned.
+func (c *Client) ResolvePath(ctx context.Context, start *ua.NodeID, path string) (*ua.NodeID, error) {
+	ids, err := c.ResolvePaths(ctx, start, path)
+	if err != nil {
+		return nil, err
+	}
+	return ids[0], nil
+}
+
+// ResolvePaths resolves multiple paths relative to the start node with a
+// single TranslateBrowsePathsToNodeIDs request. See ResolvePath.
+//
+// If one or more paths cannot be resolved the error is a *PathError for
+// the first failed path and the ids of the failed paths are nil.
+func (c *Client) ResolvePaths(ctx context.Context, start *ua.NodeID, paths ...string) ([]*ua.NodeID, error) {
+	stats.Client().Add("ResolvePaths", 1)
+
+	if start == nil {
+		start = ua.NewNumericNodeID(0, id.RootFolder)
+	}
+
+	req := &ua.TranslateBrowsePathsToNodeIDsRequest{
+		BrowsePaths: make([]*ua.BrowsePath, len(paths)),
+	}
+	for i, p := range paths {
+		names, err := ua.ParseBrowsePath(p)
+		if err != nil {
+			return nil, err
+		}
+		req.BrowsePaths[i] = ua.NewBrowsePath(start, names)
+	}
+
+	var res *ua.TranslateBrowsePathsToNodeIDsResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolvedPaths(paths, res.Results)
+}
+
+// resolvedPaths returns the first target of every result.
+func resolvedPaths(paths []string, results []*ua.BrowsePathResult) ([]*ua.NodeID, error) {
+	if len(results) != len(paths) {
+		return nil, ua.StatusBadUnexpectedError
+	}
+
+	var err error
+	ids := make([]*ua.NodeID, len(paths))
+	for i, r := range results {
+		switch {
+		case r.StatusCode != ua.StatusOK:
+			if err == nil {
+				err = &PathError{Path: paths[i], Status: r.StatusCode}
+			}
+		case len(r.Targets) == 0 || r.Targets[0].TargetID == nil:
+			if err == nil {
+				err = &PathError{Path: paths[i], Status: ua.StatusBadNoMatch}
+			}
+		default:
+			ids[i] = r.Targets[0].TargetID.NodeID
+		}
+	}
+	return ids, err
+}
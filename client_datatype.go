@@ -0,0 +1,207 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// dataTypeReader is the part of the client which is used by
+// LoadDataTypeDictionary.
+type dataTypeReader interface {
+	browser
+	ReadBatched(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error)
+}
+
+// LoadDataTypeDictionary reads the data type hierarchy of the server
+// and registers the definitions of all structured data types with
+// ua.RegisterStructureDefinition. Enumerations and other data types
+// which are encoded as a built-in type are registered with
+// ua.RegisterSimpleDataType.
+//
+// Afterwards extension objects of structured data types without a
+// registered Go type are decoded into a *ua.Structure, including nested
+// structures, optional fields and arrays. Types registered with
+// ua.RegisterExtensionObject still take precedence.
+//
+// The server must support the DataTypeDefinition attribute which was
+// introduced with OPC UA 1.04. Data types without a definition are
+// skipped.
+func (c *Client) LoadDataTypeDictionary(ctx context.Context) error {
+	stats.Client().Add("LoadDataTypeDictionary", 1)
+	return loadDataTypeDictionary(ctx, c, c.log)
+}
+
+func loadDataTypeDictionary(ctx context.Context, r dataTypeReader, log logger.Logger) error {
+	structs, err := browseDataTypes(ctx, r, log)
+	if err != nil {
+		return err
+	}
+	_, err = readStructureDefinitions(ctx, r, log, structs)
+	return err
+}
+
+// LoadTypeDefinitions reads the definitions of the custom structured
+// data types of the server, i.e. the structured data types which are not
+// in namespace 0, and registers them like LoadDataTypeDictionary.
+// Enumerations and other data types which are encoded as a built-in type
+// are registered with ua.RegisterSimpleDataType.
+//
+// The definitions of the data types of the fields which are not known
+// yet are read recursively, e.g. of nested custom structures which are
+// not in the data type hierarchy or of structures in namespace 0. Fields
+// of a structured data type with a Go type which is registered for its
+// encoding are decoded into the Go type.
+//
+// Unlike LoadDataTypeDictionary it does not read the definitions of all
+// structured data types of namespace 0.
+func (c *Client) LoadTypeDefinitions(ctx context.Context) error {
+	stats.Client().Add("LoadTypeDefinitions", 1)
+	return loadTypeDefinitions(ctx, c, c.log)
+}
+
+func loadTypeDefinitions(ctx context.Context, r dataTypeReader, log logger.Logger) error {
+	structs, err := browseDataTypes(ctx, r, log)
+	if err != nil {
+		return err
+	}
+
+	var next []*ua.NodeID
+	for _, n := range structs {
+		if n.Namespace() != 0 {
+			next = append(next, n)
+		}
+	}
+
+	seen := map[string]bool{}
+	for len(next) > 0 {
+		for _, n := range next {
+			seen[n.String()] = true
+		}
+		defs, err := readStructureDefinitions(ctx, r, log, next)
+		if err != nil {
+			return err
+		}
+
+		// resolve the data types of the fields which are not known
+		next = nil
+		for _, def := range defs {
+			for _, f := range def.Fields {
+				dt := f.DataType
+				if dt == nil || seen[dt.String()] || knownDataType(dt) {
+					continue
+				}
+				seen[dt.String()] = true
+				next = append(next, dt)
+			}
+		}
+	}
+	return nil
+}
+
+// knownDataType returns true if values of the data type can be decoded.
+func knownDataType(dataType *ua.NodeID) bool {
+	if ua.LookupStructureDefinition(dataType) != nil {
+		return true
+	}
+	_, ok := ua.LookupSimpleDataType(dataType)
+	return ok
+}
+
+// browseDataTypes browses the data type hierarchy of the server, registers
+// the data types which are encoded as a built-in type and returns the
+// structured data types.
+func browseDataTypes(ctx context.Context, r dataTypeReader, log logger.Logger) ([]*ua.NodeID, error) {
+	root := ua.NewNumericNodeID(0, id.BaseDataType)
+
+	// builtin maps a data type to the built-in data type or the
+	// enumeration it is derived from.
+	builtin := map[string]uint32{root.String(): id.BaseDataType}
+	var structs []*ua.NodeID
+
+	cfg := defaultBrowseConfig()
+	cfg.refType = ua.NewNumericNodeID(0, id.HasSubtype)
+	cfg.includeSubtypes = false
+	cfg.nodeClassMask = ua.NodeClassDataType
+	cfg.log = log
+
+	// browseRecursive is breadth-first so that the parent of a data
+	// type is always visited before the data type.
+	err := browseRecursive(ctx, r, root, func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error {
+		n := ref.NodeID.NodeID
+		base := builtin[parent.String()]
+		if n.Namespace() == 0 && (n.IntID() <= uint32(ua.TypeIDDiagnosticInfo) || n.IntID() == id.Enumeration) {
+			base = n.IntID()
+		}
+		builtin[n.String()] = base
+
+		switch base {
+		case id.Structure:
+			if n.Namespace() != 0 || n.IntID() != id.Structure {
+				structs = append(structs, n)
+			}
+		case id.Enumeration:
+			ua.RegisterSimpleDataType(n, ua.TypeIDInt32)
+		default:
+			if n.Namespace() != 0 || n.IntID() > uint32(ua.TypeIDDiagnosticInfo) {
+				ua.RegisterSimpleDataType(n, ua.TypeID(base))
+			}
+		}
+		return nil
+	}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return structs, nil
+}
+
+// readStructureDefinitions reads the DataTypeDefinition attributes of the
+// data types, registers the definitions and returns the definitions of
+// the structured data types. Enumerations are registered as Int32.
+func readStructureDefinitions(ctx context.Context, r dataTypeReader, log logger.Logger, dataTypes []*ua.NodeID) ([]*ua.StructureDefinition, error) {
+	if len(dataTypes) == 0 {
+		return nil, nil
+	}
+
+	req := &ua.ReadRequest{
+		NodesToRead:        make([]*ua.ReadValueID, len(dataTypes)),
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	}
+	for i, n := range dataTypes {
+		req.NodesToRead[i] = &ua.ReadValueID{NodeID: n, AttributeID: ua.AttributeIDDataTypeDefinition}
+	}
+	res, err := r.ReadBatched(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != len(dataTypes) {
+		return nil, ua.StatusBadUnexpectedError
+	}
+
+	var defs []*ua.StructureDefinition
+	for i, dv := range res.Results {
+		if dv.Status != ua.StatusOK || dv.Value == nil {
+			log.Debug("client: no data type definition", "data_type", dataTypes[i], "status", dv.Status)
+			continue
+		}
+		eo, ok := dv.Value.Value().(*ua.ExtensionObject)
+		if !ok {
+			continue
+		}
+		switch def := eo.Value.(type) {
+		case *ua.StructureDefinition:
+			ua.RegisterStructureDefinition(dataTypes[i], def)
+			defs = append(defs, def)
+		case *ua.EnumDefinition:
+			ua.RegisterSimpleDataType(dataTypes[i], ua.TypeIDInt32)
+		}
+	}
+	return defs, nil
+}
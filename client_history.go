@@ -0,0 +1,223 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// HistoryReadOption configures a history read.
+type HistoryReadOption func(*historyReadConfig)
+
+type historyReadConfig struct {
+	numValues       uint32
+	returnBounds    bool
+	useSimpleBounds bool
+	timestamps      ua.TimestampsToReturn
+}
+
+func defaultHistoryReadConfig() *historyReadConfig {
+	return &historyReadConfig{
+		timestamps: ua.TimestampsToReturnBoth,
+	}
+}
+
+// HistoryNumValues limits the number of values the server returns per
+// call of HistoryReadRaw. The continuation points are followed until all
+// values have been read. The default of 0 lets the server decide.
+func HistoryNumValues(n uint32) HistoryReadOption {
+	return func(cfg *historyReadConfig) {
+		cfg.numValues = n
+	}
+}
+
+// HistoryReturnBounds requests the bounding values of the time range in
+// HistoryReadRaw, i.e. the values at or before the start time and at or
+// after the end time.
+func HistoryReturnBounds(b bool) HistoryReadOption {
+	return func(cfg *historyReadConfig) {
+		cfg.returnBounds = b
+	}
+}
+
+// HistoryUseSimpleBounds makes the server use simple bounding values to
+// interpolate the values in HistoryReadAtTime.
+//
+// Part 11, 6.4.5
+func HistoryUseSimpleBounds(b bool) HistoryReadOption {
+	return func(cfg *historyReadConfig) {
+		cfg.useSimpleBounds = b
+	}
+}
+
+// HistoryTimestamps sets the timestamps to return with the values.
+// The default is ua.TimestampsToReturnBoth.
+func HistoryTimestamps(ts ua.TimestampsToReturn) HistoryReadOption {
+	return func(cfg *historyReadConfig) {
+		cfg.timestamps = ts
+	}
+}
+
+// HistoryReadRaw reads the raw historical values of a node between start
+// and end and follows the continuation points until all values have been
+// read.
+//
+// If ctx is cancelled or the server returns an error while there are more
+// values to read, the continuation point is released on the server.
+//
+// Part 11, 6.4.3
+func (c *Client) HistoryReadRaw(ctx context.Context, nodeID *ua.NodeID, start, end time.Time, opts ...HistoryReadOption) ([]*ua.DataValue, error) {
+	stats.Client().Add("HistoryReadRaw", 1)
+
+	cfg := applyHistoryReadOptions(opts)
+	req := newHistoryReadRequest(nodeID, cfg, &ua.ReadRawModifiedDetails{
+		StartTime:        start,
+		EndTime:          end,
+		NumValuesPerNode: cfg.numValues,
+		ReturnBounds:     cfg.returnBounds,
+	})
+	return historyReadAll(ctx, c.log, c.historyRead, req)
+}
+
+// HistoryReadRawModified reads the raw historical values of a node
+// between start and end like HistoryReadRaw. The server returns at most
+// numValues values per call and 0 lets the server decide.
+func (c *Client) HistoryReadRawModified(ctx context.Context, nodeID *ua.NodeID, start, end time.Time, numValues uint32) ([]*ua.DataValue, error) {
+	return c.HistoryReadRaw(ctx, nodeID, start, end, HistoryNumValues(numValues))
+}
+
+// HistoryReadProcessed reads the aggregated historical values of a node
+// between start and end. aggregateType is the node id of the aggregate
+// function, e.g. id.AggregateFunction_Average, id.AggregateFunction_Minimum
+// or id.AggregateFunction_Maximum, and interval the length of the intervals
+// for which the aggregate is computed. The server uses its default
+// aggregate configuration.
+//
+// The continuation points are handled as in HistoryReadRaw.
+//
+// Part 11, 6.4.4
+func (c *Client) HistoryReadProcessed(ctx context.Context, nodeID *ua.NodeID, start, end time.Time, aggregateType *ua.NodeID, interval time.Duration, opts ...HistoryReadOption) ([]*ua.DataValue, error) {
+	stats.Client().Add("HistoryReadProcessed", 1)
+
+	cfg := applyHistoryReadOptions(opts)
+	req := newHistoryReadRequest(nodeID, cfg, &ua.ReadProcessedDetails{
+		StartTime:          start,
+		EndTime:            end,
+		ProcessingInterval: float64(interval) / float64(time.Millisecond),
+		AggregateType:      []*ua.NodeID{aggregateType},
+		AggregateConfiguration: &ua.AggregateConfiguration{
+			UseServerCapabilitiesDefaults: true,
+		},
+	})
+	return historyReadAll(ctx, c.log, c.historyRead, req)
+}
+
+// HistoryReadAtTime reads the historical values of a node at the given
+// timestamps. The server interpolates the values for timestamps without
+// a raw value. The result contains one value per timestamp.
+//
+// The continuation points are handled as in HistoryReadRaw.
+//
+// Part 11, 6.4.5
+func (c *Client) HistoryReadAtTime(ctx context.Context, nodeID *ua.NodeID, times []time.Time, opts ...HistoryReadOption) ([]*ua.DataValue, error) {
+	stats.Client().Add("HistoryReadAtTime", 1)
+
+	cfg := applyHistoryReadOptions(opts)
+	req := newHistoryReadRequest(nodeID, cfg, &ua.ReadAtTimeDetails{
+		ReqTimes:        times,
+		UseSimpleBounds: cfg.useSimpleBounds,
+	})
+	return historyReadAll(ctx, c.log, c.historyRead, req)
+}
+
+func applyHistoryReadOptions(opts []HistoryReadOption) *historyReadConfig {
+	cfg := defaultHistoryReadConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// newHistoryReadRequest returns a history read request for a single node.
+func newHistoryReadRequest(nodeID *ua.NodeID, cfg *historyReadConfig, details interface{}) *ua.HistoryReadRequest {
+	return &ua.HistoryReadRequest{
+		TimestampsToReturn: cfg.timestamps,
+		NodesToRead: []*ua.HistoryReadValueID{
+			{NodeID: nodeID, DataEncoding: &ua.QualifiedName{}},
+		},
+		HistoryReadDetails: ua.NewExtensionObject(details),
+	}
+}
+
+// historyRead sends a single history read request.
+func (c *Client) historyRead(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+	var res *ua.HistoryReadResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	return res, err
+}
+
+// historyReadAll sends the history read request for a single node and
+// follows the continuation points until all values have been read.
+func historyReadAll(ctx context.Context, log logger.Logger, read func(context.Context, *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error), req *ua.HistoryReadRequest) ([]*ua.DataValue, error) {
+	node := req.NodesToRead[0]
+
+	// release tells the server to free the continuation point when we stop
+	// before all values have been read. Use a new context since ctx may
+	// already be done.
+	release := func() {
+		if len(node.ContinuationPoint) == 0 {
+			return
+		}
+		rreq := *req
+		rreq.ReleaseContinuationPoints = true
+		if _, err := read(context.Background(), &rreq); err != nil {
+			log.Warn("client: history read: releasing continuation point failed", "node_id", node.NodeID, "err", err)
+		}
+	}
+
+	var values []*ua.DataValue
+	for {
+		res, err := read(ctx, req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		if len(res.Results) != 1 {
+			release()
+			return nil, ua.StatusBadUnexpectedError
+		}
+
+		r := res.Results[0]
+
+		// bad status codes have the severity bit set
+		if uint32(r.StatusCode)&0x80000000 != 0 {
+			release()
+			return nil, r.StatusCode
+		}
+
+		if r.HistoryData != nil {
+			if data, ok := r.HistoryData.Value.(*ua.HistoryData); ok {
+				values = append(values, data.DataValues...)
+			}
+		}
+
+		node.ContinuationPoint = r.ContinuationPoint
+		if len(node.ContinuationPoint) == 0 {
+			return values, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			release()
+			return nil, err
+		}
+	}
+}
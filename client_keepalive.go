@@ -0,0 +1,187 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// keepaliveMaxFailures is the number of consecutive failed keepalive
+// requests after which the client reconnects.
+const keepaliveMaxFailures = 3
+
+// ServerStatus is the last known status of the server which the session
+// keepalive has read.
+type ServerStatus struct {
+	// State is the state of the server.
+	State ua.ServerState
+
+	// SecondsTillShutdown is the time until the server shuts down if the
+	// State is Shutdown. It is 0 if the server does not provide it.
+	SecondsTillShutdown uint32
+
+	// ShutdownReason is the reason of the shutdown if the server
+	// provides it.
+	ShutdownReason string
+
+	// UpdatedAt is the time when the status was read. It is zero if
+	// the status is not known.
+	UpdatedAt time.Time
+}
+
+// ServerStatus returns the last known status of the server. The status
+// is only read by the session keepalive and UpdatedAt is zero if it is
+// disabled or has not read the status yet.
+//
+// See SessionKeepalive.
+func (c *Client) ServerStatus() ServerStatus {
+	s, _ := c.atomicServerStatus.Load().(ServerStatus)
+	return s
+}
+
+// idleTime returns the time since the last successful request.
+func (c *Client) idleTime() time.Duration {
+	t := c.stats.lastSuccess.Load()
+	if t == 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Since(time.Unix(0, t))
+}
+
+// monitorKeepalive reads the status of the server when no request has
+// succeeded for the keepalive interval until ctx is cancelled. The
+// connection monitor reconnects after keepaliveMaxFailures consecutive
+// failed keepalive requests.
+func (c *Client) monitorKeepalive(ctx context.Context) {
+	d := c.cfg.keepaliveInterval
+
+	c.log.Debug("client: keepalive: start", "interval", d)
+	defer c.log.Debug("client: keepalive: done")
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		// other requests keep the session alive
+		if idle := c.idleTime(); idle < d {
+			t.Reset(d - idle)
+			continue
+		}
+		t.Reset(d)
+
+		if c.State() != Connected {
+			failures = 0
+			continue
+		}
+
+		err := c.updateServerStatus(ctx)
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		c.log.Warn("client: keepalive failed", "err", err, "failures", failures)
+		stats.RecordError(err)
+		if failures < keepaliveMaxFailures {
+			continue
+		}
+		failures = 0
+
+		// do not block if the connection monitor has a pending error
+		select {
+		case c.sechanErr <- errors.Wrapf(err, "keepalive failed %d times", keepaliveMaxFailures):
+		default:
+		}
+	}
+}
+
+// updateServerStatus reads the status of the server and stores it. The
+// SecondsTillShutdown and ShutdownReason are optional.
+func (c *Client) updateServerStatus(ctx context.Context) error {
+	if d := c.cfg.sechan.RequestTimeout; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{
+			{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue},
+			{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_SecondsTillShutdown), AttributeID: ua.AttributeIDValue},
+			{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_ShutdownReason), AttributeID: ua.AttributeIDValue},
+		},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	}
+	res, err := c.ReadWithContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	if len(res.Results) != len(req.NodesToRead) {
+		return ua.StatusBadUnexpectedError
+	}
+
+	var s ServerStatus
+	dv := res.Results[0]
+	if dv.Status != ua.StatusOK {
+		return dv.Status
+	}
+	if dv.Value == nil {
+		return ua.StatusBadTypeMismatch
+	}
+	switch v := dv.Value.Value().(type) {
+	case int32:
+		s.State = ua.ServerState(v)
+	case uint32:
+		s.State = ua.ServerState(v)
+	default:
+		return ua.StatusBadTypeMismatch
+	}
+	if dv := res.Results[1]; dv.Status == ua.StatusOK && dv.Value != nil {
+		s.SecondsTillShutdown, _ = dv.Value.Value().(uint32)
+	}
+	if dv := res.Results[2]; dv.Status == ua.StatusOK && dv.Value != nil {
+		if lt, ok := dv.Value.Value().(*ua.LocalizedText); ok && lt != nil {
+			s.ShutdownReason = lt.Text
+		}
+	}
+	s.UpdatedAt = time.Now()
+
+	prev := c.ServerStatus()
+	c.atomicServerStatus.Store(s)
+
+	changed := prev.UpdatedAt.IsZero() ||
+		prev.State != s.State ||
+		prev.SecondsTillShutdown != s.SecondsTillShutdown ||
+		prev.ShutdownReason != s.ShutdownReason
+	if !changed {
+		return nil
+	}
+	if s.State != ua.ServerStateRunning {
+		c.log.Warn("client: server state changed", "from", prev.State, "to", s.State, "seconds_till_shutdown", s.SecondsTillShutdown, "reason", s.ShutdownReason)
+	} else {
+		c.log.Info("client: server state changed", "from", prev.State, "to", s.State)
+	}
+	if f := c.cfg.serverStatusFunc; f != nil {
+		f(s)
+	}
+	return nil
+}
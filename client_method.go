@@ -0,0 +1,271 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// methodArguments contains the InputArguments and OutputArguments
+// properties of a method.
+type methodArguments struct {
+	in, out []*ua.Argument
+}
+
+// MethodResult contains the output arguments of a method call.
+type MethodResult struct {
+	// Values contains the values of the output arguments.
+	Values []interface{}
+
+	// Names contains the names of the output arguments from the
+	// OutputArguments property of the method. It is empty if the
+	// method has no OutputArguments property.
+	Names []string
+}
+
+// Value returns the value of the output argument with the given name
+// or nil if there is no such argument.
+func (r *MethodResult) Value(name string) interface{} {
+	for i, n := range r.Names {
+		if n == name && i < len(r.Values) {
+			return r.Values[i]
+		}
+	}
+	return nil
+}
+
+// ArgumentError is returned when an input argument of a method call
+// is invalid.
+type ArgumentError struct {
+	Index int
+	Name  string
+	Err   error
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("opcua: argument %d (%s): %s", e.Index, e.Name, trimPrefix(e.Err))
+}
+
+// Unwrap returns the underlying error.
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// MethodError is returned by CallMethod when the server rejects the
+// method call.
+type MethodError struct {
+	// Status is the status code of the method call.
+	Status ua.StatusCode
+
+	// Arguments contains an error for every input argument which the
+	// server rejected with its InputArgumentResults.
+	Arguments []*ArgumentError
+}
+
+func (e *MethodError) Error() string {
+	msg := "opcua: method call failed: " + trimPrefix(e.Status)
+	for _, a := range e.Arguments {
+		msg += "; " + trimPrefix(a)
+	}
+	return msg
+}
+
+// Unwrap returns the status code of the method call.
+func (e *MethodError) Unwrap() error {
+	return e.Status
+}
+
+// CallMethod calls the method of the object with the given arguments.
+//
+// The values are converted to the data types of the InputArguments
+// property of the method before the call. The properties are read on
+// the first call and cached per method. If a value cannot be converted
+// the error is an *ArgumentError. If the method has no InputArguments
+// property the values are passed without conversion.
+//
+// If the server rejects the call the error is a *MethodError which
+// contains the rejected arguments.
+//
+// With StrictMethodArguments the values are not converted but must
+// already have the data types of the arguments and methods without an
+// InputArguments property cannot be called with arguments.
+//
+// Values of data types which are not built-in types are passed as is.
+func (c *Client) CallMethod(ctx context.Context, objectID, methodID *ua.NodeID, args ...interface{}) (*MethodResult, error) {
+	stats.Client().Add("CallMethod", 1)
+
+	margs, err := c.methodArguments(ctx, methodID)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := methodInputArguments(margs.in, args, c.cfg.strictMethodArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.CallWithContext(ctx, &ua.CallMethodRequest{
+		ObjectID:       objectID,
+		MethodID:       methodID,
+		InputArguments: in,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return methodResult(margs, res)
+}
+
+// methodResult returns the output arguments of a method call or a
+// *MethodError if the call failed.
+func methodResult(margs *methodArguments, res *ua.CallMethodResult) (*MethodResult, error) {
+	var argErrs []*ArgumentError
+	for i, status := range res.InputArgumentResults {
+		if status != ua.StatusOK {
+			argErrs = append(argErrs, &ArgumentError{Index: i, Name: argumentName(margs.in, i), Err: status})
+		}
+	}
+	if res.StatusCode != ua.StatusOK || len(argErrs) > 0 {
+		status := res.StatusCode
+		if status == ua.StatusOK {
+			status = ua.StatusBadInvalidArgument
+		}
+		return nil, &MethodError{Status: status, Arguments: argErrs}
+	}
+
+	r := &MethodResult{Values: make([]interface{}, len(res.OutputArguments))}
+	for i, v := range res.OutputArguments {
+		r.Values[i] = v.Value()
+	}
+	for _, arg := range margs.out {
+		r.Names = append(r.Names, arg.Name)
+	}
+	return r, nil
+}
+
+// MethodArguments returns the input and output arguments of the method
+// from its InputArguments and OutputArguments properties. The slices are
+// empty if the method has no arguments. The properties are cached per
+// method like for CallMethod.
+func (c *Client) MethodArguments(ctx context.Context, methodID *ua.NodeID) (inputs, outputs []*ua.Argument, err error) {
+	stats.Client().Add("MethodArguments", 1)
+
+	margs, err := c.methodArguments(ctx, methodID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]*ua.Argument{}, margs.in...), append([]*ua.Argument{}, margs.out...), nil
+}
+
+// methodArguments returns the cached arguments of the method or reads the
+// InputArguments and OutputArguments properties.
+func (c *Client) methodArguments(ctx context.Context, methodID *ua.NodeID) (*methodArguments, error) {
+	key := methodID.String()
+	if v, ok := c.methodArgs.Load(key); ok {
+		return v.(*methodArguments), nil
+	}
+
+	// the properties are optional. Methods without arguments
+	// do not need to have them.
+	ids, err := c.ResolvePaths(ctx, methodID, "InputArguments", "OutputArguments")
+	if err != nil {
+		if perr, ok := err.(*PathError); !ok || perr.Status != ua.StatusBadNoMatch {
+			return nil, err
+		}
+	}
+
+	margs := &methodArguments{}
+	for i, nid := range ids {
+		if nid == nil {
+			continue
+		}
+		v, err := c.Node(nid).ValueWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args, err := decodeArguments(v)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			margs.in = args
+		} else {
+			margs.out = args
+		}
+	}
+
+	c.methodArgs.Store(key, margs)
+	return margs, nil
+}
+
+// decodeArguments returns the arguments of an InputArguments or
+// OutputArguments property.
+func decodeArguments(v *ua.Variant) ([]*ua.Argument, error) {
+	if v == nil || v.Value() == nil {
+		return nil, nil
+	}
+	eos, ok := v.Value().([]*ua.ExtensionObject)
+	if !ok {
+		return nil, errors.Errorf("invalid method arguments: got %T want []*ua.ExtensionObject", v.Value())
+	}
+	args := make([]*ua.Argument, len(eos))
+	for i, eo := range eos {
+		arg, ok := eo.Value.(*ua.Argument)
+		if !ok {
+			return nil, errors.Errorf("invalid method argument %d: got %T want *ua.Argument", i, eo.Value)
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// methodInputArguments converts the values to the data types of the
+// arguments. If args is nil the method has no InputArguments property
+// and the values are not converted. In strict mode the values must
+// already have the data types of the arguments.
+func methodInputArguments(args []*ua.Argument, values []interface{}, strict bool) ([]*ua.Variant, error) {
+	if args == nil && !strict {
+		in := make([]*ua.Variant, len(values))
+		for i, val := range values {
+			v, err := ua.NewVariant(val)
+			if err != nil {
+				return nil, &ArgumentError{Index: i, Err: err}
+			}
+			in[i] = v
+		}
+		return in, nil
+	}
+	if len(values) != len(args) {
+		return nil, errors.Errorf("got %d arguments want %d", len(values), len(args))
+	}
+
+	in := make([]*ua.Variant, len(values))
+	for i, val := range values {
+		v, err := ua.NewVariant(val)
+		if err != nil {
+			return nil, &ArgumentError{Index: i, Name: args[i].Name, Err: err}
+		}
+		cv, err := convertValue(args[i].DataType, args[i].ValueRank, v)
+		if err != nil {
+			return nil, &ArgumentError{Index: i, Name: args[i].Name, Err: err}
+		}
+		if strict && cv.Type() != v.Type() {
+			return nil, &ArgumentError{Index: i, Name: args[i].Name, Err: errors.Errorf("got %s want %s", v.Type(), cv.Type())}
+		}
+		in[i] = cv
+	}
+	return in, nil
+}
+
+func argumentName(args []*ua.Argument, i int) string {
+	if i < len(args) {
+		return args[i].Name
+	}
+	return ""
+}
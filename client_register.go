@@ -0,0 +1,159 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// registeredNode is a node registered with RegisterNodeIDs.
+type registeredNode struct {
+	id    *ua.NodeID
+	alias *ua.NodeID
+}
+
+// RegisterNodeIDs registers the node ids with the server for repeated
+// access and returns the registered node ids in the same order. The
+// registered node ids can be used instead of the original ones in Read
+// and Write requests and may allow the server to access the nodes more
+// efficiently.
+//
+// Registered node ids are only valid for the lifetime of the session.
+// The client registers the nodes again when it has to create a new
+// session after a reconnect. Since the server can return different node
+// ids for the new session, applications which keep the registered ids
+// should look them up with RegisteredNodeID after a reconnect.
+//
+// Part 4, 5.8.5
+func (c *Client) RegisterNodeIDs(ctx context.Context, ids []*ua.NodeID) ([]*ua.NodeID, error) {
+	res, err := c.RegisterNodesWithContext(ctx, &ua.RegisterNodesRequest{NodesToRegister: ids})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.RegisteredNodeIDs) != len(ids) {
+		return nil, ua.StatusBadUnexpectedError
+	}
+
+	c.registeredMu.Lock()
+	if c.registered == nil {
+		c.registered = map[string]*registeredNode{}
+	}
+	for i, id := range ids {
+		c.registered[id.String()] = &registeredNode{id: id, alias: res.RegisteredNodeIDs[i]}
+	}
+	c.registeredMu.Unlock()
+
+	return res.RegisteredNodeIDs, nil
+}
+
+// UnregisterNodeIDs unregisters node ids previously registered with
+// RegisterNodeIDs. ids are the original node ids, not the registered ones.
+//
+// Part 4, 5.8.6
+func (c *Client) UnregisterNodeIDs(ctx context.Context, ids []*ua.NodeID) error {
+	c.registeredMu.Lock()
+	aliases := make([]*ua.NodeID, 0, len(ids))
+	for _, id := range ids {
+		key := id.String()
+		if n, ok := c.registered[key]; ok {
+			aliases = append(aliases, n.alias)
+			delete(c.registered, key)
+		}
+	}
+	c.registeredMu.Unlock()
+
+	if len(aliases) == 0 {
+		return nil
+	}
+	_, err := c.UnregisterNodesWithContext(ctx, &ua.UnregisterNodesRequest{NodesToUnregister: aliases})
+	return err
+}
+
+// RegisteredNodeID returns the registered node id for a node id
+// registered with RegisterNodeIDs or nil if the node is not registered.
+func (c *Client) RegisteredNodeID(id *ua.NodeID) *ua.NodeID {
+	c.registeredMu.Lock()
+	defer c.registeredMu.Unlock()
+	if n, ok := c.registered[id.String()]; ok {
+		return n.alias
+	}
+	return nil
+}
+
+// registeredNodeIDs returns the original node ids of all registered nodes.
+func (c *Client) registeredNodeIDs() []*ua.NodeID {
+	c.registeredMu.Lock()
+	defer c.registeredMu.Unlock()
+
+	ids := make([]*ua.NodeID, 0, len(c.registered))
+	for _, n := range c.registered {
+		ids = append(ids, n.id)
+	}
+	return ids
+}
+
+// reregisterNodes registers all registered nodes again with a new session.
+func (c *Client) reregisterNodes(ctx context.Context) error {
+	ids := c.registeredNodeIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := c.RegisterNodeIDs(ctx, ids)
+	return err
+}
+
+// useRegisteredNodes replaces the node ids in the read request with the
+// registered node ids when AutoRegisterNodes is enabled. Nodes which
+// have been read more often than the threshold are registered first.
+// Registration errors are not fatal since the original node ids can
+// still be read.
+func (c *Client) useRegisteredNodes(ctx context.Context, req *ua.ReadRequest) {
+	threshold := c.cfg.autoRegisterThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	var hot []*ua.NodeID
+	c.registeredMu.Lock()
+	if c.readCounts == nil {
+		c.readCounts = map[string]int{}
+	}
+	for _, rv := range req.NodesToRead {
+		key := rv.NodeID.String()
+		if _, ok := c.registered[key]; ok {
+			continue
+		}
+		c.readCounts[key]++
+		if c.readCounts[key] == threshold+1 {
+			hot = append(hot, rv.NodeID)
+		}
+	}
+	c.registeredMu.Unlock()
+
+	if len(hot) > 0 {
+		_, err := c.RegisterNodeIDs(ctx, hot)
+		if err != nil {
+			c.log.Warn("client: registering nodes failed", "nodes", len(hot), "err", err)
+		}
+
+		// start counting again either way so that failed
+		// registrations are retried after another threshold reads.
+		c.registeredMu.Lock()
+		for _, id := range hot {
+			delete(c.readCounts, id.String())
+		}
+		c.registeredMu.Unlock()
+	}
+
+	c.registeredMu.Lock()
+	defer c.registeredMu.Unlock()
+	for _, rv := range req.NodesToRead {
+		if n, ok := c.registered[rv.NodeID.String()]; ok {
+			rv.NodeID = n.alias
+		}
+	}
+}
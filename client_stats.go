@@ -0,0 +1,250 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uasc"
+)
+
+// ClientStats is a snapshot of the statistics of a client. The counters
+// are cumulative over the lifetime of the client, including reconnects.
+type ClientStats struct {
+	// InFlight is the number of requests which wait for a response.
+	InFlight int
+
+	// Requests is the number of service calls by service, e.g. "Read".
+	Requests map[string]uint64
+
+	// Errors is the number of failed service calls by status code.
+	Errors map[ua.StatusCode]uint64
+
+	// Reconnects is the number of times the client has restored a lost
+	// connection.
+	Reconnects uint64
+
+	// BytesSent and BytesReceived are the number of bytes of the
+	// requests and the responses.
+	BytesSent     uint64
+	BytesReceived uint64
+
+	// LastSuccess is the time of the last successful service call.
+	LastSuccess time.Time
+
+	// Notifications is the number of notification messages which the
+	// client has received for all subscriptions.
+	Notifications uint64
+
+	// Publish contains the statistics of the publish requests.
+	Publish PublishStats
+}
+
+// SubscriptionStats is a snapshot of the statistics of a subscription.
+// The counters are cumulative, including recreations of the subscription
+// after a reconnect.
+type SubscriptionStats struct {
+	// Notifications is the number of notification messages received.
+	Notifications uint64
+
+	// KeepAlives is the number of keep-alive messages received.
+	KeepAlives uint64
+
+	// Late is the number of notification messages which arrived after
+	// they had been republished or skipped.
+	Late uint64
+
+	// Republished is the number of notification messages which have been
+	// recovered with a republish request.
+	Republished uint64
+
+	// Dropped is the number of notification messages which have been
+	// lost.
+	Dropped uint64
+}
+
+// MetricsHook receives the events which update the statistics of a
+// client, e.g. to update Prometheus metrics. The methods are called
+// synchronously from the request and publish paths and must not block.
+//
+// Embed NopMetricsHook to implement only some of the methods.
+type MetricsHook interface {
+	// ServiceCall is called after every service call with the name of the
+	// service, e.g. "Read", its status code, duration and the size of the
+	// request and the response.
+	ServiceCall(service string, status ua.StatusCode, d time.Duration, bytesSent, bytesReceived int)
+
+	// Reconnected is called when the client has restored a lost
+	// connection.
+	Reconnected()
+
+	// Notification is called for every notification message of a
+	// subscription.
+	Notification(subID uint32)
+
+	// NotificationLate is called for a notification message which
+	// arrived after it had been republished or skipped.
+	NotificationLate(subID uint32)
+
+	// NotificationsDropped is called when n notification messages of a
+	// subscription have been lost.
+	NotificationsDropped(subID uint32, n int)
+}
+
+// NopMetricsHook is a MetricsHook which ignores all events.
+type NopMetricsHook struct{}
+
+func (NopMetricsHook) ServiceCall(string, ua.StatusCode, time.Duration, int, int) {}
+func (NopMetricsHook) Reconnected()                                               {}
+func (NopMetricsHook) Notification(uint32)                                        {}
+func (NopMetricsHook) NotificationLate(uint32)                                    {}
+func (NopMetricsHook) NotificationsDropped(uint32, int)                           {}
+
+// clientStats contains the counters of a client.
+type clientStats struct {
+	requests      counterMap // by service name
+	errors        counterMap // by status code
+	reconnects    atomic.Uint64
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+	notifications atomic.Uint64
+	lastSuccess   atomic.Int64 // unix nano
+}
+
+// subscriptionStats contains the counters of a subscription.
+type subscriptionStats struct {
+	notifications atomic.Uint64
+	keepAlives    atomic.Uint64
+	late          atomic.Uint64
+	republished   atomic.Uint64
+	dropped       atomic.Uint64
+}
+
+// counterMap is a set of counters by key which can be updated
+// concurrently without a lock once the key exists.
+type counterMap struct {
+	m sync.Map // map[interface{}]*atomic.Uint64
+}
+
+func (m *counterMap) add(key interface{}, n uint64) {
+	v, ok := m.m.Load(key)
+	if !ok {
+		v, _ = m.m.LoadOrStore(key, new(atomic.Uint64))
+	}
+	v.(*atomic.Uint64).Add(n)
+}
+
+func (m *counterMap) each(f func(key interface{}, n uint64)) {
+	m.m.Range(func(k, v interface{}) bool {
+		f(k, v.(*atomic.Uint64).Load())
+		return true
+	})
+}
+
+// Stats returns a snapshot of the statistics of the client.
+func (c *Client) Stats() ClientStats {
+	s := ClientStats{
+		Requests:      map[string]uint64{},
+		Errors:        map[ua.StatusCode]uint64{},
+		Reconnects:    c.stats.reconnects.Load(),
+		BytesSent:     c.stats.bytesSent.Load(),
+		BytesReceived: c.stats.bytesReceived.Load(),
+		Notifications: c.stats.notifications.Load(),
+		Publish:       c.PublishStats(),
+	}
+	if sc := c.SecureChannel(); sc != nil {
+		s.InFlight = sc.PendingRequests()
+	}
+	c.stats.requests.each(func(k interface{}, n uint64) { s.Requests[k.(string)] = n })
+	c.stats.errors.each(func(k interface{}, n uint64) { s.Errors[k.(ua.StatusCode)] = n })
+	if t := c.stats.lastSuccess.Load(); t != 0 {
+		s.LastSuccess = time.Unix(0, t)
+	}
+	return s
+}
+
+// recordRequest updates the statistics with a service call. It is the
+// request tracer of the secure channel.
+func (c *Client) recordRequest(info uasc.RequestInfo) {
+	svc := info.Service()
+	c.stats.requests.add(svc, 1)
+	c.stats.bytesSent.Add(uint64(info.BytesSent))
+	c.stats.bytesReceived.Add(uint64(info.BytesReceived))
+	if info.Status == ua.StatusOK {
+		c.stats.lastSuccess.Store(time.Now().UnixNano())
+	} else {
+		c.stats.errors.add(info.Status, 1)
+	}
+	if h := c.cfg.metricsHook; h != nil {
+		h.ServiceCall(svc, info.Status, info.Duration, info.BytesSent, info.BytesReceived)
+	}
+}
+
+// recordReconnect updates the statistics with a restored connection.
+func (c *Client) recordReconnect() {
+	c.stats.reconnects.Add(1)
+	if h := c.cfg.metricsHook; h != nil {
+		h.Reconnected()
+	}
+}
+
+// NotificationStats returns a snapshot of the statistics of the
+// notification messages of the subscription which the client has
+// received. Stats returns the diagnostics of the subscription on the
+// server.
+func (s *Subscription) NotificationStats() SubscriptionStats {
+	return SubscriptionStats{
+		Notifications: s.stats.notifications.Load(),
+		KeepAlives:    s.stats.keepAlives.Load(),
+		Late:          s.stats.late.Load(),
+		Republished:   s.stats.republished.Load(),
+		Dropped:       s.stats.dropped.Load(),
+	}
+}
+
+// metricsHook returns the metrics hook of the client of the subscription
+// or nil.
+func (s *Subscription) metricsHook() MetricsHook {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.cfg.metricsHook
+}
+
+// recordNotification updates the statistics with a received notification
+// or keep-alive message.
+func (s *Subscription) recordNotification(keepAlive bool) {
+	if keepAlive {
+		s.stats.keepAlives.Add(1)
+		return
+	}
+	s.stats.notifications.Add(1)
+	if s.c != nil {
+		s.c.stats.notifications.Add(1)
+	}
+	if h := s.metricsHook(); h != nil {
+		h.Notification(s.SubscriptionID)
+	}
+}
+
+// recordLate updates the statistics with a notification message which
+// arrived after it had been republished or skipped.
+func (s *Subscription) recordLate() {
+	s.stats.late.Add(1)
+	if h := s.metricsHook(); h != nil {
+		h.NotificationLate(s.SubscriptionID)
+	}
+}
+
+// recordDropped updates the statistics with n lost notification messages.
+func (s *Subscription) recordDropped(n int) {
+	s.stats.dropped.Add(uint64(n))
+	if h := s.metricsHook(); h != nil {
+		h.NotificationsDropped(s.SubscriptionID, n)
+	}
+}
@@ -3,10 +3,8 @@ package opcua
 import (
 	"context"
 	"io"
-	"log"
 	"time"
 
-	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/stats"
 	"github.com/zzylovesll/myOpcUa/ua"
@@ -95,6 +93,23 @@ func (c *Client) SubscriptionIDs() []uint32 {
 	return ids
 }
 
+// transferableSubscriptionIDs returns the ids of the subscriptions which
+// can be transferred to a new session and the ids of the subscriptions
+// which have the transfer disabled.
+func (c *Client) transferableSubscriptionIDs() (transfer, recreate []uint32) {
+	c.subMux.RLock()
+	defer c.subMux.RUnlock()
+
+	for id, sub := range c.subs {
+		if sub.params.DisableTransfer {
+			recreate = append(recreate, id)
+			continue
+		}
+		transfer = append(transfer, id)
+	}
+	return transfer, recreate
+}
+
 // recreateSubscriptions creates new subscriptions
 // with the same parameters to replace the previous ones
 func (c *Client) recreateSubscription(ctx context.Context, id uint32) error {
@@ -133,14 +148,14 @@ func (c *Client) republishSubscription(ctx context.Context, id uint32, available
 		return errors.Errorf("invalid subscription id %d", id)
 	}
 
-	debug.Printf("republishing subscription %d", sub.SubscriptionID)
+	c.log.Debug("client: republishing subscription", "sub_id", sub.SubscriptionID)
 	if err := c.sendRepublishRequests(ctx, sub, availableSeq); err != nil {
 		switch {
 		case errors.Is(err, ua.StatusBadSessionIDInvalid):
 			return nil
 		case errors.Is(err, ua.StatusBadSubscriptionIDInvalid):
 			// todo(fs): do we need to forget the subscription id in this case?
-			debug.Printf("republish failed since subscription %d is invalid", sub.SubscriptionID)
+			c.log.Warn("client: republish failed since subscription is invalid", "sub_id", sub.SubscriptionID)
 			return errors.Errorf("republish failed since subscription %d is invalid", sub.SubscriptionID)
 		default:
 			return err
@@ -157,7 +172,7 @@ func (c *Client) sendRepublishRequests(ctx context.Context, sub *Subscription, a
 	// todo(fs): if not then we need to decide whether we fail b/c of data loss
 	// todo(fs): or whether we log it and continue.
 	if len(availableSeq) > 0 && !uint32SliceContains(sub.nextSeq, availableSeq) {
-		log.Printf("sub %d: next sequence number %d not in retransmission buffer %v", sub.SubscriptionID, sub.nextSeq, availableSeq)
+		c.log.Warn("client: next sequence number not in retransmission buffer", "sub_id", sub.SubscriptionID, "seq", sub.nextSeq, "available", availableSeq)
 	}
 
 	for {
@@ -166,31 +181,26 @@ func (c *Client) sendRepublishRequests(ctx context.Context, sub *Subscription, a
 			RetransmitSequenceNumber: sub.nextSeq,
 		}
 
-		debug.Printf("Republishing subscription %d and sequence number %d",
-			req.SubscriptionID,
-			req.RetransmitSequenceNumber,
-		)
+		c.log.Debug("client: republishing", "sub_id", req.SubscriptionID, "seq", req.RetransmitSequenceNumber)
 
 		if c.sessionClosed() {
-			debug.Printf("Republishing subscription %d aborted", req.SubscriptionID)
+			c.log.Debug("client: republishing aborted", "sub_id", req.SubscriptionID)
 			return ua.StatusBadSessionClosed
 		}
 
-		debug.Printf("RepublishRequest: req=%s", debug.ToJSON(req))
 		var res *ua.RepublishResponse
 		err := c.SecureChannel().SendRequestWithContext(ctx, req, c.Session().resp.AuthenticationToken, func(v interface{}) error {
 			return safeAssign(v, &res)
 		})
-		debug.Printf("RepublishResponse: res=%s err=%v", debug.ToJSON(res), err)
 
 		switch {
 		case err == ua.StatusBadMessageNotAvailable:
 			// No more message to restore
-			debug.Printf("Republishing subscription %d OK", req.SubscriptionID)
+			c.log.Debug("client: republishing done", "sub_id", req.SubscriptionID)
 			return nil
 
 		case err != nil:
-			debug.Printf("Republishing subscription %d failed: %v", req.SubscriptionID, err)
+			c.log.Warn("client: republishing failed", "sub_id", req.SubscriptionID, "err", err)
 			return err
 
 		default:
@@ -200,7 +210,7 @@ func (c *Client) sendRepublishRequests(ctx context.Context, sub *Subscription, a
 			}
 
 			if status != ua.StatusOK {
-				debug.Printf("Republishing subscription %d failed: %v", req.SubscriptionID, status)
+				c.log.Warn("client: republishing failed", "sub_id", req.SubscriptionID, "status", status)
 				return status
 			}
 		}
@@ -299,12 +309,18 @@ func (c *Client) notifySubscription(ctx context.Context, sub *Subscription, noti
 			continue
 		}
 
-		switch data.Value.(type) {
-		// Part 4, 7.20.2 DataChangeNotification parameter
+		switch x := data.Value.(type) {
 		// Part 4, 7.20.3 EventNotificationList parameter
+		case *ua.EventNotificationList:
+			sub.notify(ctx, &PublishNotificationData{
+				SubscriptionID: sub.SubscriptionID,
+				Value:          data.Value,
+				Events:         sub.decodeEvents(x),
+			})
+
+		// Part 4, 7.20.2 DataChangeNotification parameter
 		// Part 4, 7.20.4 StatusChangeNotification parameter
 		case *ua.DataChangeNotification,
-			*ua.EventNotificationList,
 			*ua.StatusChangeNotification:
 			sub.notify(ctx, &PublishNotificationData{
 				SubscriptionID: sub.SubscriptionID,
@@ -340,84 +356,183 @@ func (c *Client) resumeSubscriptions(ctx context.Context) {
 }
 
 // monitorSubscriptions sends publish requests and handles publish responses
-// for all active subscriptions.
+// for all active subscriptions. It keeps up to maxPublishRequests publish
+// requests outstanding.
 func (c *Client) monitorSubscriptions(ctx context.Context) {
-	dlog := debug.NewPrefixLogger("sub: ")
-	defer dlog.Print("done")
+	c.log.Debug("client: publish loop: start")
+	defer c.log.Debug("client: publish loop: done")
+
+	// done receives a signal for every publish request which has been
+	// handled.
+	done := make(chan struct{})
+	outstanding := 0
+
+	// ready is selected when another publish request can be sent.
+	ready := make(chan struct{})
+	close(ready)
+
+	// pause waits until the publish loop is resumed and returns false
+	// if ctx is done.
+	pause := func() bool {
+		c.log.Debug("client: publish loop: pause")
+		for {
+			select {
+			case <-ctx.Done():
+				c.log.Debug("client: publish loop: pause: ctx.Done()")
+				return false
+
+			case <-done:
+				outstanding--
+
+			case <-c.resumech:
+				c.log.Debug("client: publish loop: pause: resume")
+				return true
+
+			case <-c.pausech:
+				c.log.Debug("client: publish loop: pause: pause")
+				// ignore since already paused
+			}
+		}
+	}
 
-publish:
 	for {
+		// pause and resume take precedence over sending the next
+		// publish request.
 		select {
 		case <-ctx.Done():
-			dlog.Println("ctx.Done()")
+			c.log.Debug("client: publish loop: ctx.Done()")
 			return
 
 		case <-c.resumech:
-			dlog.Print("resume")
+			c.log.Debug("client: publish loop: resume")
 			// ignore since not paused
+			continue
 
 		case <-c.pausech:
-			dlog.Print("pause")
-			for {
-				select {
-				case <-ctx.Done():
-					dlog.Print("pause: ctx.Done()")
-					return
-
-				case <-c.resumech:
-					dlog.Print("pause: resume")
-					continue publish
-
-				case <-c.pausech:
-					dlog.Print("pause: pause")
-					// ignore since already paused
-				}
+			if !pause() {
+				return
 			}
+			continue
 
 		default:
-			// send publish request and handle response
-			if err := c.publish(ctx); err != nil {
-				dlog.Print("error: ", err.Error())
-				c.pauseSubscriptions(ctx)
+		}
+
+		var next <-chan struct{}
+		if outstanding < c.maxPublishRequests() {
+			next = ready
+		}
+
+		select {
+		case <-ctx.Done():
+			c.log.Debug("client: publish loop: ctx.Done()")
+			return
+
+		case <-done:
+			outstanding--
+
+		case <-c.resumech:
+			c.log.Debug("client: publish loop: resume")
+			// ignore since not paused
+
+		case <-c.pausech:
+			if !pause() {
+				return
 			}
+
+		case <-next:
+			// send publish request and handle response
+			outstanding++
+			go func() {
+				defer func() {
+					select {
+					case <-ctx.Done():
+					case done <- struct{}{}:
+					}
+				}()
+				if err := c.publish(ctx); err != nil {
+					c.log.Warn("client: publish loop: pausing", "err", err)
+					c.pauseSubscriptions(ctx)
+				}
+			}()
 		}
 	}
 }
 
+// maxPublishRequests returns the number of publish requests which the
+// client keeps outstanding.
+func (c *Client) maxPublishRequests() int {
+	if n := c.cfg.publishRequests; n > 0 {
+		return n
+	}
+	c.subMux.RLock()
+	defer c.subMux.RUnlock()
+	return len(c.subs) + 1
+}
+
 // publish sends a publish request and handles the response.
 func (c *Client) publish(ctx context.Context) error {
-	dlog := debug.NewPrefixLogger("publish: ")
-
-	c.subMux.RLock()
-	dlog.Printf("pendingAcks=%s", debug.ToJSON(c.pendingAcks))
-	c.subMux.RUnlock()
+	// the request takes the pending acks which are queued again
+	// if they cannot be acknowledged.
+	c.subMux.Lock()
+	ticket, acks := c.startPublish_NeedsSubMuxLock()
+	c.subMux.Unlock()
 
 	// send the next publish request
 	// note that res contains data even if an error was returned
-	res, err := c.sendPublishRequest(ctx)
+	start := time.Now()
+	res, err := c.sendPublishRequest(ctx, acks)
+	latency := time.Since(start)
 	stats.RecordError(err)
+
+	c.subMux.Lock()
+	if err == nil {
+		// handle pending acks for all subscriptions
+		c.handleAcks_NeedsSubMuxLock(acks, res.Results)
+
+		// handle the publish response for a specific subscription
+		if sub, ok := c.subs[res.SubscriptionID]; ok {
+			c.handleNotification_NeedsSubMuxLock(sub, res)
+		} else {
+			// todo(fs): should we return an error here?
+			c.log.Warn("client: publish: unknown subscription", "sub_id", res.SubscriptionID)
+		}
+	} else {
+		c.pendingAcks = append(acks, c.pendingAcks...)
+	}
+	c.finishPublish_NeedsSubMuxLock(ticket, latency, err == nil)
+	batches := c.sequenceNotifications_NeedsSubMuxLock()
+	c.subMux.Unlock()
+
+	// deliver the notifications which are in sequence now. This
+	// includes the notifications of other subscriptions which have
+	// waited for the response of this request.
+	c.deliverNotifications(ctx, batches)
+
 	switch {
-	case err == io.EOF:
-		dlog.Printf("eof: pausing publish loop")
+	case err == nil:
+		c.log.Debug("client: publish: notification", "sub_id", res.SubscriptionID, "seq", res.NotificationMessage.SequenceNumber)
+
+	case err == io.EOF, err == ErrResponseLost:
+		c.log.Warn("client: publish: connection closed. pausing publish loop")
 		return err
 
 	case err == ua.StatusBadSessionNotActivated:
-		dlog.Printf("error: session not active. pausing publish loop")
+		c.log.Warn("client: publish: session not active. pausing publish loop")
 		return err
 
 	case err == ua.StatusBadServerNotConnected:
-		dlog.Printf("error: no connection. pausing publish loop")
+		c.log.Warn("client: publish: no connection. pausing publish loop")
 		return err
 
 	case err == ua.StatusBadSequenceNumberUnknown:
 		// todo(fs): this should only happen per in the status codes
 		// todo(fs): lets log this here to see
-		dlog.Printf("error: this should only happen when ACK'ing results: %s", err)
+		c.log.Warn("client: publish: this should only happen when ACK'ing results", "err", err)
 
 	case err == ua.StatusBadTooManyPublishRequests:
 		// todo(fs): we have sent too many publish requests
 		// todo(fs): we need to slow down
-		dlog.Printf("error: sleeping for one second: %s", err)
+		c.log.Warn("client: publish: sleeping for one second", "err", err)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -426,15 +541,15 @@ func (c *Client) publish(ctx context.Context) error {
 
 	case err == ua.StatusBadTimeout:
 		// ignore and continue the loop
-		dlog.Printf("error: ignoring: %s", err)
+		c.log.Debug("client: publish: ignoring", "err", err)
 
 	case err == ua.StatusBadNoSubscription:
 		// All subscriptions have been deleted, but the publishing loop is still running
 		// We should pause publishing until a subscription has been created
-		dlog.Printf("error: no subscriptions but the publishing loop is still running: %s", err)
+		c.log.Warn("client: publish: no subscriptions but the publishing loop is still running", "err", err)
 		return err
 
-	case err != nil && res != nil:
+	case res != nil:
 		// irrecoverable error
 		// todo(fs): do we need to stop and forget the subscription?
 		if res.SubscriptionID == 0 {
@@ -442,110 +557,344 @@ func (c *Client) publish(ctx context.Context) error {
 		} else {
 			c.notifySubscriptionOfError(ctx, res.SubscriptionID, err)
 		}
-		dlog.Printf("error: %s", err)
+		c.log.Error("client: publish failed", "sub_id", res.SubscriptionID, "err", err)
 		return err
 
-	case err != nil:
-		dlog.Printf("error: unexpected error. Do we need to stop the publish loop?: %s", err)
+	default:
+		c.log.Error("client: publish: unexpected error", "err", err)
 		return err
+	}
 
-	default:
-		c.subMux.Lock()
-		// handle pending acks for all subscriptions
-		c.handleAcks_NeedsSubMuxLock(res.Results)
+	return nil
+}
 
-		sub, ok := c.subs[res.SubscriptionID]
-		if !ok {
-			c.subMux.Unlock()
-			// todo(fs): should we return an error here?
-			dlog.Printf("error: unknown subscription %d", res.SubscriptionID)
-			return nil
-		}
+// startPublish_NeedsSubMuxLock registers a new publish request and
+// returns its ticket and the pending acks which the request sends.
+func (c *Client) startPublish_NeedsSubMuxLock() (uint64, []*ua.SubscriptionAcknowledgement) {
+	if c.publishing == nil {
+		c.publishing = make(map[uint64]bool)
+	}
+	c.publishSeq++
+	c.publishing[c.publishSeq] = true
+	c.publishStats.Outstanding = len(c.publishing)
 
-		// handle the publish response for a specific subscription
-		c.handleNotification_NeedsSubMuxLock(ctx, sub, res)
-		c.subMux.Unlock()
+	acks := c.pendingAcks
+	c.pendingAcks = []*ua.SubscriptionAcknowledgement{}
+	return c.publishSeq, acks
+}
 
-		c.notifySubscription(ctx, sub, res.NotificationMessage)
-		dlog.Printf("notif: %d", res.NotificationMessage.SequenceNumber)
+// finishPublish_NeedsSubMuxLock removes the publish request with the
+// ticket from the outstanding requests and records the latency if the
+// request was successful.
+func (c *Client) finishPublish_NeedsSubMuxLock(ticket uint64, latency time.Duration, ok bool) {
+	delete(c.publishing, ticket)
+	c.publishStats.Outstanding = len(c.publishing)
+	if !ok {
+		return
 	}
 
-	return nil
+	s := &c.publishStats
+	s.Responses++
+	s.LastLatency = latency
+	s.MeanLatency += (latency - s.MeanLatency) / time.Duration(s.Responses)
+	if latency > s.MaxLatency {
+		s.MaxLatency = latency
+	}
 }
 
-func (c *Client) handleAcks_NeedsSubMuxLock(res []ua.StatusCode) {
-	dlog := debug.NewPrefixLogger("publish: ")
-
-	// we assume that the number of results in the response match
-	// the number of pending acks from the previous PublishRequest.
-	if len(c.pendingAcks) != len(res) {
-		dlog.Printf("error: got %d results for pending ACKs but want %d", len(res), len(c.pendingAcks))
-		c.pendingAcks = []*ua.SubscriptionAcknowledgement{}
+// handleAcks_NeedsSubMuxLock handles the results for the acks which a
+// publish request has sent and queues the acks again which should be
+// retried.
+func (c *Client) handleAcks_NeedsSubMuxLock(acks []*ua.SubscriptionAcknowledgement, res []ua.StatusCode) {
+	// the response contains a result for every ack of the request.
+	if len(acks) != len(res) {
+		c.log.Warn("client: publish: wrong number of results for pending ACKs", "results", len(res), "acks", len(acks))
+		return
 	}
 
 	// find the messages which we have received but which we have not acked.
 	var notAcked []*ua.SubscriptionAcknowledgement
-	for i, ack := range c.pendingAcks {
+	for i, ack := range acks {
 		err := res[i]
 		switch err {
 		case ua.StatusOK:
 			// message ack'ed
 		case ua.StatusBadSubscriptionIDInvalid:
 			// old subscription id -> skip
-			dlog.Printf("error: subscription id invalid. skipping: %s", err)
+			c.log.Debug("client: publish: subscription id invalid. skipping", "sub_id", ack.SubscriptionID, "status", err)
 		case ua.StatusBadSequenceNumberUnknown:
 			// server does not have the message in its retransmission queue anymore
-			dlog.Printf("error: notif %d/%d not on server anymore: %s", ack.SubscriptionID, ack.SequenceNumber, err)
+			c.log.Warn("client: publish: notification not on server anymore", "sub_id", ack.SubscriptionID, "seq", ack.SequenceNumber, "status", err)
 		default:
 			// otherwise, we try to ack again
 			notAcked = append(notAcked, ack)
-			dlog.Printf("retrying to ACK notif %d/%d: %s", ack.SubscriptionID, ack.SequenceNumber, err)
+			c.log.Debug("client: publish: retrying to ACK notification", "sub_id", ack.SubscriptionID, "seq", ack.SequenceNumber, "status", err)
 		}
 	}
-	c.pendingAcks = notAcked
-	dlog.Printf("notAcked=%v", notAcked)
+	c.pendingAcks = append(c.pendingAcks, notAcked...)
+}
+
+// pendingNotif is a notification message which has been received before
+// all messages with lower sequence numbers. The message is nil for a
+// keep-alive which announces the next sequence number.
+type pendingNotif struct {
+	msg *ua.NotificationMessage
+
+	// ticket is the last publish request which had been sent when the
+	// message was received. Messages with lower sequence numbers can
+	// only arrive in the responses of the requests up to the ticket.
+	ticket uint64
 }
 
-func (c *Client) handleNotification_NeedsSubMuxLock(ctx context.Context, sub *Subscription, res *ua.PublishResponse) {
-	dlog := debug.NewPrefixLogger("publish: sub %d: ", res.SubscriptionID)
+// handleNotification_NeedsSubMuxLock acknowledges the notification message
+// of the publish response and queues it for the delivery in sequence order.
+func (c *Client) handleNotification_NeedsSubMuxLock(sub *Subscription, res *ua.PublishResponse) {
+	msg := res.NotificationMessage
+	seq := msg.SequenceNumber
+
+	// a keep-alive message contains the next sequence number
+	keepAlive := len(msg.NotificationData) == 0
+	sub.recordNotification(keepAlive)
+	if keepAlive {
+		msg = nil
+	} else {
+		c.pendingAcks = append(c.pendingAcks, &ua.SubscriptionAcknowledgement{
+			SubscriptionID: res.SubscriptionID,
+			SequenceNumber: seq,
+		})
+	}
 
-	// keep-alive message
-	if len(res.NotificationMessage.NotificationData) == 0 {
-		// todo(fs): do we care about the next sequence number?
-		sub.nextSeq = res.NotificationMessage.SequenceNumber
+	switch {
+	case seq < sub.nextSeq && sub.nextSeq-seq > maxRepublishGap:
+		c.log.Warn("client: publish: unexpected notification. Data loss?", "sub_id", res.SubscriptionID, "seq", seq, "want", sub.nextSeq)
+		sub.pending = nil
+		sub.nextSeq = seq
+
+	case seq < sub.nextSeq:
+		// the message has been delivered or republished already
+		if !keepAlive {
+			sub.recordLate()
+		}
+		return
+
+	case keepAlive && seq == sub.nextSeq:
 		return
 	}
 
-	if res.NotificationMessage.SequenceNumber != sub.nextSeq {
-		dlog.Printf("error: got notif %d but was expecting notif %d. Data loss?", res.NotificationMessage.SequenceNumber, sub.nextSeq)
+	if p, ok := sub.pending[seq]; ok && p.msg != nil {
+		return
+	}
+	if seq != sub.nextSeq {
+		c.log.Debug("client: publish: notification out of order", "sub_id", res.SubscriptionID, "seq", seq, "want", sub.nextSeq)
+	}
+	if sub.pending == nil {
+		sub.pending = make(map[uint32]*pendingNotif)
+	}
+	sub.pending[seq] = &pendingNotif{msg: msg, ticket: c.publishSeq}
+}
+
+// seqNotif is a notification message with its sequence number. The
+// message is nil if it has been lost and needs to be republished.
+type seqNotif struct {
+	seq uint32
+	msg *ua.NotificationMessage
+}
+
+// notifBatch contains notification messages of a subscription which are
+// delivered after the previous batch of the subscription is done.
+type notifBatch struct {
+	sub    *Subscription
+	notifs []*seqNotif
+	prev   <-chan struct{}
+	done   chan struct{}
+}
+
+// sequenceNotifications_NeedsSubMuxLock returns the notification messages
+// of all subscriptions which can be delivered in sequence order.
+func (c *Client) sequenceNotifications_NeedsSubMuxLock() []*notifBatch {
+	// the first publish request which has not been handled
+	first := c.publishSeq + 1
+	for t := range c.publishing {
+		if t < first {
+			first = t
+		}
+	}
+
+	var batches []*notifBatch
+	for _, sub := range c.subs {
+		notifs := sub.sequence_NeedsSubMuxLock(first)
+		if len(notifs) == 0 {
+			continue
+		}
+		b := &notifBatch{sub: sub, notifs: notifs, prev: sub.delivered, done: make(chan struct{})}
+		sub.delivered = b.done
+		batches = append(batches, b)
+	}
+	return batches
+}
+
+// sequence_NeedsSubMuxLock removes the pending notification messages which
+// follow the last delivered message and returns them in sequence order.
+// The messages before a gap are lost and need to be republished once the
+// publish requests which could still contain them have been handled,
+// i.e. when the ticket of the message after the gap is lower than the
+// first outstanding publish request.
+func (s *Subscription) sequence_NeedsSubMuxLock(first uint64) []*seqNotif {
+	var notifs []*seqNotif
+	for len(s.pending) > 0 {
+		if p, ok := s.pending[s.nextSeq]; ok {
+			delete(s.pending, s.nextSeq)
+			if p.msg != nil {
+				notifs = append(notifs, &seqNotif{seq: s.nextSeq, msg: p.msg})
+				s.lastSeq = s.nextSeq
+				s.nextSeq++
+			}
+			continue
+		}
+
+		next := uint32(0)
+		for seq := range s.pending {
+			if next == 0 || seq < next {
+				next = seq
+			}
+		}
+		if s.pending[next].ticket >= first {
+			break
+		}
+
+		missing := missingSequenceNumbers(s.nextSeq, next)
+		if len(missing) == 0 {
+			s.log().Warn("client: publish: unexpected notification. Data loss?", "sub_id", s.SubscriptionID, "seq", next, "want", s.nextSeq)
+			if next > s.nextSeq {
+				s.recordDropped(int(next - s.nextSeq))
+			}
+		} else {
+			s.log().Info("client: publish: republishing missing notifications", "sub_id", s.SubscriptionID, "seq", next, "want", s.nextSeq, "missing", missing)
+		}
+		for _, seq := range missing {
+			notifs = append(notifs, &seqNotif{seq: seq})
+		}
+		s.lastSeq = next - 1
+		s.nextSeq = next
+	}
+	return notifs
+}
+
+// deliverNotifications delivers the notification messages of the batches
+// and republishes the lost messages. Every batch waits until the previous
+// batch of the subscription has been delivered.
+func (c *Client) deliverNotifications(ctx context.Context, batches []*notifBatch) {
+	for _, b := range batches {
+		if b.prev != nil {
+			<-b.prev
+		}
+		for _, n := range b.notifs {
+			msg := n.msg
+			if msg == nil {
+				if msg = c.republish(ctx, b.sub, n.seq); msg == nil {
+					continue
+				}
+			}
+			c.notifySubscription(ctx, b.sub, msg)
+		}
+		close(b.done)
+	}
+}
+
+// maxRepublishGap is the maximum number of notification messages which
+// are republished for a single gap in the sequence numbers. Larger gaps
+// are usually caused by a server restart and cannot be recovered.
+const maxRepublishGap = 100
+
+// missingSequenceNumbers returns the sequence numbers from next up to
+// but not including seq.
+func missingSequenceNumbers(next, seq uint32) []uint32 {
+	if seq <= next || seq-next > maxRepublishGap {
+		return nil
+	}
+	var missing []uint32
+	for n := next; n < seq; n++ {
+		missing = append(missing, n)
+	}
+	return missing
+}
+
+// republish requests a notification message which has been skipped from
+// the retransmission queue of the server. It returns nil if the message
+// is no longer available and is lost.
+//
+// Specification: Part 4, 5.13.6
+func (c *Client) republish(ctx context.Context, sub *Subscription, seq uint32) *ua.NotificationMessage {
+	req := &ua.RepublishRequest{
+		SubscriptionID:           sub.SubscriptionID,
+		RetransmitSequenceNumber: seq,
+	}
+	var res *ua.RepublishResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	switch {
+	case err == ua.StatusBadMessageNotAvailable:
+		stats.Subscription().Add("RepublishNotAvailable", 1)
+		c.log.Warn("client: publish: notification is not available for republishing", "sub_id", sub.SubscriptionID, "seq", seq)
+		sub.recordDropped(1)
+		return nil
+	case err != nil:
+		c.log.Warn("client: publish: republishing notification failed", "sub_id", sub.SubscriptionID, "seq", seq, "err", err)
+		sub.recordDropped(1)
+		return nil
 	}
 
-	sub.lastSeq = res.NotificationMessage.SequenceNumber
-	sub.nextSeq = sub.lastSeq + 1
+	stats.Subscription().Add("Republished", 1)
+	sub.stats.republished.Add(1)
+	c.subMux.Lock()
 	c.pendingAcks = append(c.pendingAcks, &ua.SubscriptionAcknowledgement{
-		SubscriptionID: res.SubscriptionID,
-		SequenceNumber: res.NotificationMessage.SequenceNumber,
+		SubscriptionID: sub.SubscriptionID,
+		SequenceNumber: seq,
 	})
+	c.subMux.Unlock()
+	c.log.Debug("client: publish: republished notification", "sub_id", sub.SubscriptionID, "seq", seq)
+	return res.NotificationMessage
 }
 
-func (c *Client) sendPublishRequest(ctx context.Context) (*ua.PublishResponse, error) {
-	dlog := debug.NewPrefixLogger("publish: ")
-
-	c.subMux.RLock()
+func (c *Client) sendPublishRequest(ctx context.Context, acks []*ua.SubscriptionAcknowledgement) (*ua.PublishResponse, error) {
 	req := &ua.PublishRequest{
-		SubscriptionAcknowledgements: c.pendingAcks,
+		SubscriptionAcknowledgements: acks,
 	}
 	if req.SubscriptionAcknowledgements == nil {
 		req.SubscriptionAcknowledgements = []*ua.SubscriptionAcknowledgement{}
 	}
-	c.subMux.RUnlock()
 
-	dlog.Printf("PublishRequest: %s", debug.ToJSON(req))
 	var res *ua.PublishResponse
 	err := c.sendWithTimeout(ctx, req, c.publishTimeout(), func(v interface{}) error {
 		return safeAssign(v, &res)
 	})
 	stats.RecordError(err)
-	dlog.Printf("PublishResponse: %s", debug.ToJSON(res))
 	return res, err
 }
+
+// PublishStats contains the statistics of the publish requests of a
+// client.
+type PublishStats struct {
+	// Outstanding is the number of publish requests which wait for a
+	// response.
+	Outstanding int
+
+	// Responses is the number of successful publish responses.
+	Responses uint64
+
+	// LastLatency, MeanLatency and MaxLatency are the times between
+	// sending a publish request and receiving its response. They
+	// include the time the server holds the request until it has a
+	// notification or a keep-alive to send.
+	LastLatency time.Duration
+	MeanLatency time.Duration
+	MaxLatency  time.Duration
+}
+
+// PublishStats returns the statistics of the publish requests.
+func (c *Client) PublishStats() PublishStats {
+	c.subMux.RLock()
+	defer c.subMux.RUnlock()
+	return c.publishStats
+}
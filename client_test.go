@@ -2,13 +2,220 @@ package opcua
 
 import (
 	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pascaldekloe/goe/verify"
 	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/server"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacert"
+	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
+	"github.com/zzylovesll/myOpcUa/uasc"
 )
 
+func TestSelectEndpoint(t *testing.T) {
+	ep := func(policy string, mode ua.MessageSecurityMode, level uint8) *ua.EndpointDescription {
+		return &ua.EndpointDescription{SecurityPolicyURI: policy, SecurityMode: mode, SecurityLevel: level}
+	}
+	var (
+		none   = ep(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone, 0)
+		basic  = ep(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSignAndEncrypt, 10)
+		aes128 = ep(ua.SecurityPolicyURIAes128Sha256RsaOaep, ua.MessageSecurityModeSignAndEncrypt, 3)
+		pss    = ep(ua.SecurityPolicyURIAes256Sha256RsaPss, ua.MessageSecurityModeSign, 1)
+		pssEnc = ep(ua.SecurityPolicyURIAes256Sha256RsaPss, ua.MessageSecurityModeSignAndEncrypt, 1)
+		pubsub = ep("http://opcfoundation.org/UA/SecurityPolicy#PubSub-Aes256-CTR", ua.MessageSecurityModeSignAndEncrypt, 20)
+	)
+
+	tests := []struct {
+		name   string
+		eps    []*ua.EndpointDescription
+		policy string
+		mode   ua.MessageSecurityMode
+		want   *ua.EndpointDescription
+	}{
+		{name: "strongest", eps: []*ua.EndpointDescription{none, basic, pss, pssEnc, aes128, pubsub}, want: pssEnc},
+		{name: "mode", eps: []*ua.EndpointDescription{none, basic, pss, pssEnc, aes128}, mode: ua.MessageSecurityModeSign, want: pss},
+		{name: "policy", eps: []*ua.EndpointDescription{none, basic, pss, pssEnc, aes128}, policy: "Basic256Sha256", want: basic},
+		{name: "policy and mode", eps: []*ua.EndpointDescription{none, basic, pss, pssEnc, aes128}, policy: "Aes256Sha256RsaPss", mode: ua.MessageSecurityModeSign, want: pss},
+		{name: "unsupported policy", eps: []*ua.EndpointDescription{pubsub}, want: nil},
+		{name: "no match", eps: []*ua.EndpointDescription{none, basic}, policy: "Aes128Sha256RsaOaep", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify.Values(t, "", SelectEndpoint(tt.eps, tt.policy, tt.mode), tt.want)
+		})
+	}
+
+	for _, uri := range uapolicy.SupportedPolicies() {
+		if securityPolicyRank(uri) < 0 {
+			t.Errorf("no rank for security policy %s", uri)
+		}
+	}
+}
+
+func TestFindServersOnNetwork(t *testing.T) {
+	// records 1..5 of the discovery server whose record ids are
+	// reset once after the first page.
+	records := func(ids ...uint32) []*ua.ServerOnNetwork {
+		var s []*ua.ServerOnNetwork
+		for _, id := range ids {
+			s = append(s, &ua.ServerOnNetwork{RecordID: id})
+		}
+		return s
+	}
+	t0, t1 := time.Unix(0, 0), time.Unix(1, 0)
+
+	var starts []uint32
+	reset := false
+	find := func(start uint32) (*ua.FindServersOnNetworkResponse, error) {
+		starts = append(starts, start)
+		res := &ua.FindServersOnNetworkResponse{LastCounterResetTime: t1}
+		if !reset {
+			reset = true
+			res.LastCounterResetTime = t0
+		}
+		var ids []uint32
+		for id := start; id <= 5 && len(ids) < 2; id++ {
+			ids = append(ids, id)
+		}
+		res.Servers = records(ids...)
+		return res, nil
+	}
+
+	servers, err := findServersOnNetwork(1, 2, find)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "servers", servers, records(1, 2, 3, 4, 5))
+	verify.Values(t, "starts", starts, []uint32{1, 3, 1, 3, 5})
+
+	// all records at once
+	starts, reset = nil, true
+	servers, err = findServersOnNetwork(4, 0, find)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "all servers", servers, records(4, 5))
+	verify.Values(t, "all starts", starts, []uint32{4})
+}
+
+func TestFindEndpoint(t *testing.T) {
+	ep := func(policy string, mode ua.MessageSecurityMode, tokens ...ua.UserTokenType) *ua.EndpointDescription {
+		e := &ua.EndpointDescription{SecurityPolicyURI: policy, SecurityMode: mode}
+		for _, typ := range tokens {
+			e.UserIdentityTokens = append(e.UserIdentityTokens, &ua.UserTokenPolicy{TokenType: typ})
+		}
+		return e
+	}
+	var (
+		none  = ep(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone, ua.UserTokenTypeAnonymous)
+		sign  = ep(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSign, ua.UserTokenTypeAnonymous, ua.UserTokenTypeUserName)
+		enc   = ep(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSignAndEncrypt, ua.UserTokenTypeCertificate)
+		eps   = []*ua.EndpointDescription{none, sign, enc}
+		anon  = ua.UserTokenTypeAnonymous
+		uname = ua.UserTokenTypeUserName
+	)
+
+	tests := []struct {
+		name   string
+		policy string
+		mode   ua.MessageSecurityMode
+		types  []ua.UserTokenType
+		want   *ua.EndpointDescription
+		err    string
+	}{
+		{name: "strongest", want: enc},
+		{name: "token type", types: []ua.UserTokenType{anon}, want: sign},
+		{name: "preferred token type", mode: ua.MessageSecurityModeNone, types: []ua.UserTokenType{uname, anon}, want: none},
+		{name: "policy and token type", policy: "None", types: []ua.UserTokenType{uname}, err: "opcua: no endpoint with security policy None, mode any, user token UserName. available endpoints: None/None (Anonymous), Basic256Sha256/Sign (Anonymous, UserName), Basic256Sha256/SignAndEncrypt (Certificate)"},
+		{name: "no match", policy: "Aes128Sha256RsaOaep", mode: ua.MessageSecurityModeSign, err: "opcua: no endpoint with security policy Aes128_Sha256_RsaOaep, mode Sign. available endpoints: None/None (Anonymous), Basic256Sha256/Sign (Anonymous, UserName), Basic256Sha256/SignAndEncrypt (Certificate)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindEndpoint(eps, tt.policy, tt.mode, tt.types...)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "", got, tt.want)
+			verify.Values(t, "endpoints modified", eps, []*ua.EndpointDescription{none, sign, enc})
+		})
+	}
+}
+
+func TestOrderEndpoints(t *testing.T) {
+	ep := func(policy string, mode ua.MessageSecurityMode, level uint8) *ua.EndpointDescription {
+		return &ua.EndpointDescription{EndpointURL: "opc.tcp://server:4840", SecurityPolicyURI: policy, SecurityMode: mode, SecurityLevel: level}
+	}
+	var (
+		none   = ep(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone, 0)
+		basic  = ep(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSignAndEncrypt, 10)
+		pss    = ep(ua.SecurityPolicyURIAes256Sha256RsaPss, ua.MessageSecurityModeSign, 1)
+		pubsub = ep("http://opcfoundation.org/UA/SecurityPolicy#PubSub-Aes256-CTR", ua.MessageSecurityModeSignAndEncrypt, 20)
+		https  = &ua.EndpointDescription{EndpointURL: "https://server:443", SecurityPolicyURI: ua.SecurityPolicyURINone, SecurityMode: ua.MessageSecurityModeNone}
+		eps    = []*ua.EndpointDescription{none, basic, pss, pubsub, https}
+	)
+
+	tests := []struct {
+		name   string
+		policy EndpointSelectionPolicy
+		filter func(*ua.EndpointDescription) bool
+		want   []*ua.EndpointDescription
+	}{
+		{name: "strongest", policy: EndpointSelectionStrongest, want: []*ua.EndpointDescription{pss, basic, none}},
+		{name: "security level", policy: EndpointSelectionSecurityLevel, want: []*ua.EndpointDescription{basic, pss, none}},
+		{name: "server order", policy: EndpointSelectionServerOrder, want: []*ua.EndpointDescription{none, basic, pss}},
+		{
+			name:   "filter",
+			policy: EndpointSelectionStrongest,
+			filter: func(ep *ua.EndpointDescription) bool { return ep.SecurityMode != ua.MessageSecurityModeSign },
+			want:   []*ua.EndpointDescription{basic, none},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify.Values(t, "", orderEndpoints(eps, tt.policy, tt.filter), tt.want)
+			verify.Values(t, "endpoints modified", eps, []*ua.EndpointDescription{none, basic, pss, pubsub, https})
+		})
+	}
+}
+
+func TestFallbackEndpointURL(t *testing.T) {
+	tests := []struct {
+		endpoint, discovery, want string
+	}{
+		{"opc.tcp://internal-host:4840/server", "opc.tcp://10.0.0.1:48400", "opc.tcp://10.0.0.1:48400/server"},
+		{"opc.tcp://internal-host:4840", "opc.tcp://10.0.0.1:4840/path", "opc.tcp://10.0.0.1:4840"},
+		{"opc.tcp://10.0.0.1:4840/server", "opc.tcp://10.0.0.1:4840", ""},
+		{"opc.tcp://internal-host:4840", "10.0.0.1:4840", ""},
+		{"opc.tcp://internal-host:4840/%zz", "opc.tcp://10.0.0.1:4840", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			verify.Values(t, "", fallbackEndpointURL(tt.endpoint, tt.discovery), tt.want)
+		})
+	}
+}
+
 func TestClient_Send_DoesNotPanicWhenDisconnected(t *testing.T) {
 	c := NewClient("opc.tcp://example.com:4840")
 	err := c.SendWithContext(context.Background(), &ua.ReadRequest{}, func(i interface{}) error {
@@ -181,3 +388,1830 @@ func TestCloneBrowseRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestScalarValue(t *testing.T) {
+	id := ua.NewStringNodeID(2, "foo")
+	tests := []struct {
+		name string
+		dv   *ua.DataValue
+		want ua.TypeID
+		v    interface{}
+		err  error
+	}{
+		{
+			name: "ok",
+			dv:   &ua.DataValue{Value: ua.MustVariant(int32(5))},
+			want: ua.TypeIDInt32,
+			v:    int32(5),
+		},
+		{
+			name: "bad status",
+			dv:   &ua.DataValue{Status: ua.StatusBadNodeIDUnknown},
+			want: ua.TypeIDInt32,
+			err:  &NodeStatusError{NodeID: id, Status: ua.StatusBadNodeIDUnknown},
+		},
+		{
+			name: "type mismatch",
+			dv:   &ua.DataValue{Value: ua.MustVariant("abc")},
+			want: ua.TypeIDInt32,
+			err:  &InvalidValueTypeError{NodeID: id, Got: ua.TypeIDString, Want: ua.TypeIDInt32},
+		},
+		{
+			name: "array",
+			dv:   &ua.DataValue{Value: ua.MustVariant([]int32{1, 2})},
+			want: ua.TypeIDInt32,
+			err:  &InvalidValueTypeError{NodeID: id, Got: ua.TypeIDInt32, Want: ua.TypeIDInt32, Array: true},
+		},
+		{
+			name: "no value",
+			dv:   &ua.DataValue{},
+			want: ua.TypeIDBoolean,
+			err:  &InvalidValueTypeError{NodeID: id, Want: ua.TypeIDBoolean},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := scalarValue(id, tt.dv, tt.want)
+			verify.Values(t, "value", v, tt.v)
+			verify.Values(t, "err", err, tt.err)
+		})
+	}
+}
+
+func TestClient_StateChangedCh(t *testing.T) {
+	ch := make(chan ConnState, 5)
+	c := NewClient("opc.tcp://example.com:4840", StateChangedCh(ch))
+	c.setState(Connecting)
+	c.setState(Connected)
+	c.setState(Connected)
+	c.setState(Disconnected)
+	close(ch)
+
+	var got []ConnState
+	for s := range ch {
+		got = append(got, s)
+	}
+	verify.Values(t, "", got, []ConnState{Connecting, Connected, Disconnected})
+}
+
+func TestClient_Send_ReturnsErrReconnecting(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.setState(Reconnecting)
+	err := c.SendWithContext(context.Background(), &ua.ReadRequest{}, func(i interface{}) error {
+		return nil
+	})
+	verify.Values(t, "", err, ErrReconnecting)
+
+	c = NewClient("opc.tcp://example.com:4840", AutoReconnect(false))
+	c.setState(Reconnecting)
+	err = c.SendWithContext(context.Background(), &ua.ReadRequest{}, func(i interface{}) error {
+		return nil
+	})
+	verify.Values(t, "", err, ua.StatusBadServerNotConnected)
+}
+
+func TestClient_NextReconnectInterval(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840", ReconnectInterval(time.Second), ReconnectBackoff(5*time.Second))
+	var got []time.Duration
+	d := time.Second
+	for i := 0; i < 4; i++ {
+		d = c.nextReconnectInterval(d)
+		got = append(got, d)
+	}
+	verify.Values(t, "backoff", got, []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second})
+
+	c = NewClient("opc.tcp://example.com:4840", ReconnectInterval(time.Second))
+	verify.Values(t, "constant", c.nextReconnectInterval(time.Second), time.Second)
+}
+
+func TestClient_WaitForReconnect(t *testing.T) {
+	var transitions [][2]ConnState
+	c := NewClient("opc.tcp://example.com:4840", StateChangedFunc(func(from, to ConnState) {
+		transitions = append(transitions, [2]ConnState{from, to})
+	}))
+	c.setState(Reconnecting)
+
+	done := make(chan error)
+	go func() {
+		done <- c.waitForReconnect(context.Background(), nil)
+	}()
+	c.setState(Connected)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "transitions", transitions, [][2]ConnState{{Closed, Reconnecting}, {Reconnecting, Connected}})
+
+	c.setState(Reconnecting)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	verify.Values(t, "canceled", c.waitForReconnect(ctx, nil), context.Canceled)
+
+	c.setState(Closed)
+	verify.Values(t, "closed", c.waitForReconnect(context.Background(), nil), ua.StatusBadServerNotConnected)
+}
+
+func TestSplitReadRequest(t *testing.T) {
+	var nodes []*ua.ReadValueID
+	for i := 0; i < 5; i++ {
+		nodes = append(nodes, &ua.ReadValueID{NodeID: ua.NewNumericNodeID(0, uint32(i))})
+	}
+	req := &ua.ReadRequest{MaxAge: 1, TimestampsToReturn: ua.TimestampsToReturnBoth, NodesToRead: nodes}
+
+	want := []*ua.ReadRequest{
+		{MaxAge: 1, TimestampsToReturn: ua.TimestampsToReturnBoth, NodesToRead: nodes[0:2]},
+		{MaxAge: 1, TimestampsToReturn: ua.TimestampsToReturnBoth, NodesToRead: nodes[2:4]},
+		{MaxAge: 1, TimestampsToReturn: ua.TimestampsToReturnBoth, NodesToRead: nodes[4:5]},
+	}
+	verify.Values(t, "", splitReadRequest(req, 2), want)
+}
+
+func TestClient_MaxNodesPerRead(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840", MaxNodesPerRead(100))
+	verify.Values(t, "configured", c.maxNodesPerRead(), 100)
+
+	c.atomicMaxNodesPerRead = 50
+	verify.Values(t, "server lower", c.maxNodesPerRead(), 50)
+
+	c.atomicMaxNodesPerRead = 500
+	verify.Values(t, "server higher", c.maxNodesPerRead(), 100)
+
+	c = NewClient("opc.tcp://example.com:4840")
+	verify.Values(t, "no limit", c.maxNodesPerRead(), 0)
+	c.atomicMaxNodesPerRead = 50
+	verify.Values(t, "server only", c.maxNodesPerRead(), 50)
+}
+
+func TestClient_MaxNodesPerWrite(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840", MaxNodesPerWrite(100))
+	verify.Values(t, "configured", c.maxNodesPerWrite(), 100)
+
+	c.atomicMaxNodesPerWrite = 50
+	verify.Values(t, "server lower", c.maxNodesPerWrite(), 50)
+
+	verify.Values(t, "default batch size", c.batchSize(), DefaultBatchSize)
+	c = NewClient("opc.tcp://example.com:4840", BatchSize(20))
+	verify.Values(t, "batch size", c.batchSize(), 20)
+}
+
+func TestSplitWriteRequest(t *testing.T) {
+	req := &ua.WriteRequest{}
+	for i := 0; i < 5; i++ {
+		req.NodesToWrite = append(req.NodesToWrite, &ua.WriteValue{NodeID: ua.NewNumericNodeID(0, uint32(i))})
+	}
+	reqs := splitWriteRequest(req, 2)
+	verify.Values(t, "", len(reqs), 3)
+	verify.Values(t, "", reqs[2].NodesToWrite, req.NodesToWrite[4:])
+}
+
+func TestClient_RunBatches(t *testing.T) {
+	t.Run("parallel", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", BatchConcurrency(3))
+
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		done := make([]bool, 10)
+		err := c.runBatches(context.Background(), len(done), func(ctx context.Context, i int) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			done[i] = true
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, d := range done {
+			if !d {
+				t.Fatalf("batch %d not done", i)
+			}
+		}
+		if maxRunning > 3 {
+			t.Fatalf("got %d parallel batches want at most 3", maxRunning)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for _, n := range []int{1, 4} {
+			c := NewClient("opc.tcp://example.com:4840", BatchConcurrency(n))
+			err := c.runBatches(context.Background(), 10, func(ctx context.Context, i int) error {
+				if i == 2 {
+					return ua.StatusBadTooManyOperations
+				}
+				return nil
+			})
+			verify.Values(t, "", err, ua.StatusBadTooManyOperations)
+		}
+	})
+}
+
+func TestHistoryReadAll(t *testing.T) {
+	values := func(v ...int32) *ua.ExtensionObject {
+		var dvs []*ua.DataValue
+		for _, x := range v {
+			dvs = append(dvs, &ua.DataValue{Value: ua.MustVariant(x)})
+		}
+		return &ua.ExtensionObject{Value: &ua.HistoryData{DataValues: dvs}}
+	}
+
+	newReq := func() *ua.HistoryReadRequest {
+		return &ua.HistoryReadRequest{
+			NodesToRead: []*ua.HistoryReadValueID{{NodeID: ua.NewNumericNodeID(1, 1)}},
+		}
+	}
+
+	t.Run("continuation", func(t *testing.T) {
+		var cps [][]byte
+		read := func(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+			cps = append(cps, req.NodesToRead[0].ContinuationPoint)
+			res := &ua.HistoryReadResponse{Results: []*ua.HistoryReadResult{{HistoryData: values(3)}}}
+			if len(cps) == 1 {
+				res.Results[0].HistoryData = values(1, 2)
+				res.Results[0].ContinuationPoint = []byte{0xca, 0xfe}
+			}
+			return res, nil
+		}
+
+		dvs, err := historyReadAll(context.Background(), logger.Nop, read, newReq())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int32
+		for _, dv := range dvs {
+			got = append(got, dv.Value.Value().(int32))
+		}
+		verify.Values(t, "values", got, []int32{1, 2, 3})
+		verify.Values(t, "continuation points", cps, [][]byte{nil, {0xca, 0xfe}})
+	})
+
+	t.Run("release on error", func(t *testing.T) {
+		var released []byte
+		calls := 0
+		read := func(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+			calls++
+			if req.ReleaseContinuationPoints {
+				released = req.NodesToRead[0].ContinuationPoint
+				return &ua.HistoryReadResponse{}, nil
+			}
+			if calls > 1 {
+				return nil, ua.StatusBadTimeout
+			}
+			return &ua.HistoryReadResponse{Results: []*ua.HistoryReadResult{
+				{HistoryData: values(1), ContinuationPoint: []byte{0x01}},
+			}}, nil
+		}
+
+		_, err := historyReadAll(context.Background(), logger.Nop, read, newReq())
+		verify.Values(t, "error", err, ua.StatusBadTimeout)
+		verify.Values(t, "released", released, []byte{0x01})
+	})
+
+	t.Run("release on cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var released []byte
+		read := func(ctx context.Context, req *ua.HistoryReadRequest) (*ua.HistoryReadResponse, error) {
+			if req.ReleaseContinuationPoints {
+				released = req.NodesToRead[0].ContinuationPoint
+				return &ua.HistoryReadResponse{}, nil
+			}
+			cancel()
+			return &ua.HistoryReadResponse{Results: []*ua.HistoryReadResult{
+				{HistoryData: values(1), ContinuationPoint: []byte{0x02}},
+			}}, nil
+		}
+
+		_, err := historyReadAll(ctx, logger.Nop, read, newReq())
+		verify.Values(t, "error", err, context.Canceled)
+		verify.Values(t, "released", released, []byte{0x02})
+	})
+}
+
+// fakeBrowser serves a static address space and returns at most two
+// references per call to force the use of continuation points.
+type fakeBrowser struct {
+	refs     map[uint32][]uint32
+	pending  map[string][]uint32
+	released int
+
+	// namespaces contains the namespace of the nodes which are not in
+	// namespace 0.
+	namespaces map[uint32]uint16
+}
+
+func (b *fakeBrowser) result(ids []uint32) *ua.BrowseResult {
+	r := &ua.BrowseResult{}
+	n := len(ids)
+	if n > 2 {
+		n = 2
+		cp := fmt.Sprintf("cp%d", len(b.pending))
+		b.pending[cp] = ids[2:]
+		r.ContinuationPoint = []byte(cp)
+	}
+	for _, x := range ids[:n] {
+		r.References = append(r.References, &ua.ReferenceDescription{
+			NodeID:     ua.NewNumericExpandedNodeID(b.namespaces[x], x),
+			BrowseName: &ua.QualifiedName{Name: fmt.Sprintf("n%d", x)},
+		})
+	}
+	return r
+}
+
+func (b *fakeBrowser) BrowseWithContext(ctx context.Context, req *ua.BrowseRequest) (*ua.BrowseResponse, error) {
+	res := &ua.BrowseResponse{}
+	for _, d := range req.NodesToBrowse {
+		ids, ok := b.refs[d.NodeID.IntID()]
+		if !ok {
+			res.Results = append(res.Results, &ua.BrowseResult{StatusCode: ua.StatusBadNodeIDUnknown})
+			continue
+		}
+		res.Results = append(res.Results, b.result(ids))
+	}
+	return res, nil
+}
+
+func (b *fakeBrowser) BrowseNextWithContext(ctx context.Context, req *ua.BrowseNextRequest) (*ua.BrowseNextResponse, error) {
+	res := &ua.BrowseNextResponse{}
+	for _, cp := range req.ContinuationPoints {
+		ids := b.pending[string(cp)]
+		delete(b.pending, string(cp))
+		if req.ReleaseContinuationPoints {
+			b.released++
+			continue
+		}
+		res.Results = append(res.Results, b.result(ids))
+	}
+	return res, nil
+}
+
+func TestBrowseRecursive(t *testing.T) {
+	newBrowser := func() *fakeBrowser {
+		return &fakeBrowser{
+			refs: map[uint32][]uint32{
+				1: {2, 3, 4, 5},
+				2: {6},
+				3: {1, 7}, // cycle
+				4: {},
+				5: {},
+				6: {},
+				7: {},
+			},
+			pending: map[string][]uint32{},
+		}
+	}
+
+	walk := func(b *fakeBrowser, fn BrowseFunc, opts ...BrowseOption) ([]string, error) {
+		cfg := defaultBrowseConfig()
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		var got []string
+		err := browseRecursive(context.Background(), b, ua.NewNumericNodeID(0, 1), func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error {
+			got = append(got, fmt.Sprintf("%d>%d@%d", parent.IntID(), ref.NodeID.NodeID.IntID(), depth))
+			if fn != nil {
+				return fn(parent, ref, depth)
+			}
+			return nil
+		}, cfg)
+		return got, err
+	}
+
+	t.Run("all", func(t *testing.T) {
+		b := newBrowser()
+		got, err := walk(b, nil, BrowseBatchSize(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", got, []string{"1>2@1", "1>3@1", "1>4@1", "1>5@1", "2>6@2", "3>7@2"})
+		verify.Values(t, "pending", len(b.pending), 0)
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		got, err := walk(newBrowser(), nil, BrowseMaxDepth(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", got, []string{"1>2@1", "1>3@1", "1>4@1", "1>5@1"})
+	})
+
+	t.Run("skip children", func(t *testing.T) {
+		got, err := walk(newBrowser(), func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error {
+			if ref.NodeID.NodeID.IntID() == 2 {
+				return SkipChildren
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", got, []string{"1>2@1", "1>3@1", "1>4@1", "1>5@1", "3>7@2"})
+	})
+
+	t.Run("stop releases continuation point", func(t *testing.T) {
+		b := newBrowser()
+		stop := errors.New("stop")
+		_, err := walk(b, func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error {
+			return stop
+		})
+		verify.Values(t, "error", err, stop)
+		verify.Values(t, "released", b.released, 1)
+	})
+
+	t.Run("bad status", func(t *testing.T) {
+		b := newBrowser()
+		delete(b.refs, 6)
+		_, err := walk(b, nil)
+		verify.Values(t, "", err, &NodeStatusError{NodeID: ua.NewNumericNodeID(0, 6), Status: ua.StatusBadNodeIDUnknown})
+	})
+}
+
+func TestBrowseAll(t *testing.T) {
+	b := &fakeBrowser{
+		refs: map[uint32][]uint32{
+			1: {2, 3, 4},
+			2: {5, 6, 7},
+			3: {1, 8}, // cycle
+			4: {}, 5: {}, 6: {}, 7: {}, 8: {},
+		},
+		pending: map[string][]uint32{},
+	}
+
+	format := func(refs []*BrowsedReference) []string {
+		var s []string
+		for _, r := range refs {
+			var names []string
+			for _, qn := range r.ParentPath {
+				names = append(names, qn.Name)
+			}
+			s = append(s, fmt.Sprintf("%s>%s", names, r.BrowseName.Name))
+		}
+		return s
+	}
+
+	refs, err := browseAll(context.Background(), b, ua.NewNumericNodeID(0, 1), defaultBrowseConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "all", format(refs), []string{
+		"[]>n2", "[n2]>n5", "[n2]>n6", "[n2]>n7",
+		"[]>n3", "[n3]>n8",
+		"[]>n4",
+	})
+	verify.Values(t, "parent", refs[1].ParentID, ua.NewNumericNodeID(0, 2))
+	verify.Values(t, "pending", len(b.pending), 0)
+
+	cfg := defaultBrowseConfig()
+	BrowseMaxDepth(1)(cfg)
+	refs, err = browseAll(context.Background(), b, ua.NewNumericNodeID(0, 1), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "max depth", format(refs), []string{"[]>n2", "[]>n3", "[]>n4"})
+}
+
+func TestResolvedPaths(t *testing.T) {
+	target := func(n uint32) []*ua.BrowsePathTarget {
+		return []*ua.BrowsePathTarget{{TargetID: ua.NewNumericExpandedNodeID(2, n)}}
+	}
+	paths := []string{"a", "b", "c"}
+	results := []*ua.BrowsePathResult{
+		{StatusCode: ua.StatusOK, Targets: target(1)},
+		{StatusCode: ua.StatusBadNoMatch},
+		{StatusCode: ua.StatusOK, Targets: target(3)},
+	}
+
+	ids, err := resolvedPaths(paths, results)
+	verify.Values(t, "error", err, &PathError{Path: "b", Status: ua.StatusBadNoMatch})
+	verify.Values(t, "ids", ids, []*ua.NodeID{ua.NewNumericNodeID(2, 1), nil, ua.NewNumericNodeID(2, 3)})
+
+	if _, err := resolvedPaths(paths, results[:1]); err != ua.StatusBadUnexpectedError {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadUnexpectedError)
+	}
+}
+
+func TestClient_UseRegisteredNodes(t *testing.T) {
+	a, b := ua.NewStringNodeID(2, "a"), ua.NewStringNodeID(2, "b")
+	alias := ua.NewNumericNodeID(2, 1)
+	read := func(c *Client) []*ua.NodeID {
+		req := cloneReadRequest(&ua.ReadRequest{NodesToRead: []*ua.ReadValueID{{NodeID: a}, {NodeID: b}}})
+		c.useRegisteredNodes(context.Background(), req)
+		return []*ua.NodeID{req.NodesToRead[0].NodeID, req.NodesToRead[1].NodeID}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840")
+		c.registered = map[string]*registeredNode{a.String(): {id: a, alias: alias}}
+		verify.Values(t, "", read(c), []*ua.NodeID{a, b})
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AutoRegisterNodes(2))
+		c.registered = map[string]*registeredNode{a.String(): {id: a, alias: alias}}
+		verify.Values(t, "first", read(c), []*ua.NodeID{alias, b})
+		verify.Values(t, "second", read(c), []*ua.NodeID{alias, b})
+		verify.Values(t, "count", c.readCounts[b.String()], 2)
+
+		// the registration fails since the client is not connected
+		verify.Values(t, "third", read(c), []*ua.NodeID{alias, b})
+		verify.Values(t, "count reset", c.readCounts[b.String()], 0)
+	})
+}
+
+func TestClient_NodeIDFromURI(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.setNamespaces([]string{"http://opcfoundation.org/UA/", "urn:a", "urn:b"})
+
+	guid := ua.MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63")
+	tests := []struct {
+		uri  string
+		id   interface{}
+		want *ua.NodeID
+		err  error
+	}{
+		{uri: "urn:b", id: 5, want: ua.NewNumericNodeID(2, 5)},
+		{uri: "urn:a", id: uint64(7), want: ua.NewNumericNodeID(1, 7)},
+		{uri: "urn:a", id: "foo", want: ua.NewStringNodeID(1, "foo")},
+		{uri: "urn:a", id: []byte{1, 2}, want: ua.NewByteStringNodeID(1, []byte{1, 2})},
+		{uri: "urn:a", id: guid, want: ua.NewGUIDNodeID(1, guid.String())},
+		{uri: "urn:a", id: -1, err: errors.New("opcua: numeric identifier out of range: -1")},
+		{uri: "urn:a", id: 1.5, err: errors.New("opcua: invalid identifier type float64")},
+		{uri: "urn:c", id: 1, err: errors.New("opcua: namespace not found. uri=urn:c")},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s;%v", tt.uri, tt.id), func(t *testing.T) {
+			got, err := c.NodeIDFromURI(tt.uri, tt.id)
+			if fmt.Sprint(err) != fmt.Sprint(tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+			verify.Values(t, "", got, tt.want)
+		})
+	}
+}
+
+func TestClient_NamespaceIndex(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.setNamespaces([]string{"http://opcfoundation.org/UA/", "urn:a"})
+
+	idx, err := c.NamespaceIndex("urn:a")
+	verify.Values(t, "index", idx, uint16(1))
+	verify.Values(t, "index err", err, nil)
+	if _, err := c.NamespaceIndex("urn:b"); err == nil {
+		t.Fatal("got nil error for unknown uri")
+	}
+
+	uri, err := c.NamespaceURI(1)
+	verify.Values(t, "uri", uri, "urn:a")
+	verify.Values(t, "uri err", err, nil)
+	if _, err := c.NamespaceURI(2); err == nil {
+		t.Fatal("got nil error for unknown index")
+	}
+}
+
+func TestClient_ResolveExpandedNodeID(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.setNamespaces([]string{"http://opcfoundation.org/UA/", "urn:a", "urn:b"})
+
+	tests := []struct {
+		name string
+		eid  *ua.ExpandedNodeID
+		want *ua.NodeID
+		err  bool
+	}{
+		{
+			name: "no uri",
+			eid:  ua.NewNumericExpandedNodeID(3, 5),
+			want: ua.NewNumericNodeID(3, 5),
+		},
+		{
+			name: "numeric",
+			eid:  ua.NewExpandedNodeID(ua.NewTwoByteNodeID(5), "urn:b", 0),
+			want: ua.NewNumericNodeID(2, 5),
+		},
+		{
+			name: "string",
+			eid:  ua.NewExpandedNodeID(ua.NewStringNodeID(0, "foo"), "urn:a", 0),
+			want: ua.NewStringNodeID(1, "foo"),
+		},
+		{
+			name: "opaque",
+			eid:  ua.NewExpandedNodeID(ua.NewByteStringNodeID(0, []byte{1, 2}), "urn:a", 0),
+			want: ua.NewByteStringNodeID(1, []byte{1, 2}),
+		},
+		{
+			name: "unknown uri",
+			eid:  ua.NewExpandedNodeID(ua.NewTwoByteNodeID(5), "urn:c", 0),
+			err:  true,
+		},
+		{
+			name: "other server",
+			eid:  ua.NewExpandedNodeID(ua.NewTwoByteNodeID(5), "", 1),
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ResolveExpandedNodeID(tt.eid)
+			if got, want := err != nil, tt.err; got != want {
+				t.Fatalf("got error %v want error %v", err, want)
+			}
+			verify.Values(t, "", got, tt.want)
+		})
+	}
+}
+
+func TestPathTargets(t *testing.T) {
+	target := func(n uint32) *ua.BrowsePathTarget {
+		return &ua.BrowsePathTarget{TargetID: ua.NewNumericExpandedNodeID(2, n)}
+	}
+
+	tests := []struct {
+		name string
+		r    *ua.BrowsePathResult
+		ids  []*ua.NodeID
+		err  error
+	}{
+		{
+			name: "one target",
+			r:    &ua.BrowsePathResult{Targets: []*ua.BrowsePathTarget{target(1)}},
+			ids:  []*ua.NodeID{ua.NewNumericNodeID(2, 1)},
+		},
+		{
+			name: "bad status",
+			r:    &ua.BrowsePathResult{StatusCode: ua.StatusBadNodeIDUnknown},
+			err:  &PathError{Path: "/a", Status: ua.StatusBadNodeIDUnknown},
+		},
+		{
+			name: "no target",
+			r:    &ua.BrowsePathResult{},
+			err:  &PathError{Path: "/a", Status: ua.StatusBadNoMatch},
+		},
+		{
+			name: "multiple targets",
+			r:    &ua.BrowsePathResult{Targets: []*ua.BrowsePathTarget{target(1), target(2)}},
+			ids:  []*ua.NodeID{ua.NewNumericNodeID(2, 1), ua.NewNumericNodeID(2, 2)},
+			err: &AmbiguousPathError{
+				Path:    "/a",
+				Targets: []*ua.NodeID{ua.NewNumericNodeID(2, 1), ua.NewNumericNodeID(2, 2)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := pathTargets("/a", tt.r)
+			verify.Values(t, "ids", ids, tt.ids)
+			verify.Values(t, "err", err, tt.err)
+		})
+	}
+}
+
+func TestMethodInputArguments(t *testing.T) {
+	arg := func(name string, dt uint32, rank int32) *ua.Argument {
+		return &ua.Argument{Name: name, DataType: ua.NewNumericNodeID(0, dt), ValueRank: rank}
+	}
+	args := []*ua.Argument{
+		arg("a", id.Double, -1),
+		arg("b", id.UInt16, 1),
+		arg("c", id.BaseDataType, -2),
+	}
+
+	t.Run("convert", func(t *testing.T) {
+		in, err := methodInputArguments(args, []interface{}{int32(3), []int64{1, 2}, "x"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", in, []*ua.Variant{
+			ua.MustVariant(float64(3)),
+			ua.MustVariant([]uint16{1, 2}),
+			ua.MustVariant("x"),
+		})
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := methodInputArguments(args, []interface{}{int32(3), []int64{1, -2}, "x"}, false)
+		verify.Values(t, "", err.Error(), "opcua: argument 1 (b): element [1]: value -2 out of range for TypeIDUint16")
+	})
+
+	t.Run("scalar for array", func(t *testing.T) {
+		_, err := methodInputArguments(args, []interface{}{int32(3), int64(1), "x"}, false)
+		verify.Values(t, "", err.Error(), "opcua: argument 1 (b): got scalar want array")
+	})
+
+	t.Run("wrong number", func(t *testing.T) {
+		_, err := methodInputArguments(args, []interface{}{int32(3)}, false)
+		verify.Values(t, "", err.Error(), "opcua: got 1 arguments want 3")
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		in, err := methodInputArguments(args, []interface{}{float64(3), []uint16{1, 2}, "x"}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", in[0], ua.MustVariant(float64(3)))
+
+		_, err = methodInputArguments(args, []interface{}{int32(3), []uint16{1, 2}, "x"}, true)
+		verify.Values(t, "", err.Error(), "opcua: argument 0 (a): got TypeIDInt32 want TypeIDDouble")
+
+		_, err = methodInputArguments(nil, []interface{}{int32(3)}, true)
+		verify.Values(t, "", err.Error(), "opcua: got 1 arguments want 0")
+	})
+
+	t.Run("no input arguments", func(t *testing.T) {
+		in, err := methodInputArguments(nil, []interface{}{int32(3), "x"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", in, []*ua.Variant{ua.MustVariant(int32(3)), ua.MustVariant("x")})
+	})
+}
+
+func TestMethodResultError(t *testing.T) {
+	margs := &methodArguments{in: []*ua.Argument{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+
+	t.Run("rejected arguments", func(t *testing.T) {
+		_, err := methodResult(margs, &ua.CallMethodResult{
+			StatusCode:           ua.StatusBadInvalidArgument,
+			InputArgumentResults: []ua.StatusCode{ua.StatusBadTypeMismatch, ua.StatusOK, ua.StatusBadOutOfRange},
+		})
+		var merr *MethodError
+		if !errors.As(err, &merr) {
+			t.Fatalf("got %T want *MethodError", err)
+		}
+		verify.Values(t, "", merr.Arguments, []*ArgumentError{
+			{Index: 0, Name: "a", Err: ua.StatusBadTypeMismatch},
+			{Index: 2, Name: "c", Err: ua.StatusBadOutOfRange},
+		})
+		verify.Values(t, "", errors.Is(err, ua.StatusBadInvalidArgument), true)
+	})
+
+	t.Run("bad status", func(t *testing.T) {
+		_, err := methodResult(margs, &ua.CallMethodResult{StatusCode: ua.StatusBadUserAccessDenied})
+		verify.Values(t, "", err, &MethodError{Status: ua.StatusBadUserAccessDenied})
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		r, err := methodResult(margs, &ua.CallMethodResult{OutputArguments: []*ua.Variant{ua.MustVariant(int32(1))}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", r.Values, []interface{}{int32(1)})
+	})
+}
+
+func TestMethodArguments(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	in := []*ua.Argument{{Name: "a", DataType: ua.NewNumericNodeID(0, id.Double), ValueRank: -1}}
+	c.methodArgs.Store("i=1", &methodArguments{in: in})
+
+	inputs, outputs, err := c.MethodArguments(context.Background(), ua.NewNumericNodeID(0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", inputs, in)
+	verify.Values(t, "", outputs, []*ua.Argument{})
+}
+
+func TestMethodResultValue(t *testing.T) {
+	r := &MethodResult{Values: []interface{}{int32(1), "ok"}, Names: []string{"Code", "Text"}}
+	verify.Values(t, "", r.Value("Text"), "ok")
+	verify.Values(t, "", r.Value("Unknown"), nil)
+}
+
+func TestAssignValue(t *testing.T) {
+	nid := ua.NewNumericNodeID(1, 1)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	dv := func(v interface{}) *ua.DataValue {
+		return &ua.DataValue{Value: ua.MustVariant(v)}
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		var f float64
+		var s string
+		var ts time.Time
+		var i interface{}
+		if err := assignValue(nid, dv(1.5), reflect.ValueOf(&f).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		if err := assignValue(nid, dv("x"), reflect.ValueOf(&s).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		if err := assignValue(nid, dv(now), reflect.ValueOf(&ts).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		if err := assignValue(nid, dv(int16(7)), reflect.ValueOf(&i).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", []interface{}{f, s, ts, i}, []interface{}{1.5, "x", now, int16(7)})
+	})
+
+	t.Run("slices", func(t *testing.T) {
+		var a []int32
+		var b []byte
+		if err := assignValue(nid, dv([]int32{1, 2}), reflect.ValueOf(&a).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		if err := assignValue(nid, dv([]byte{3, 4}), reflect.ValueOf(&b).Elem()); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", a, []int32{1, 2})
+		verify.Values(t, "", b, []byte{3, 4})
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var a []float64
+		err := assignValue(nid, dv(float64(1)), reflect.ValueOf(&a).Elem())
+		verify.Values(t, "", err, &InvalidValueTypeError{NodeID: nid, Got: ua.TypeIDDouble, Want: ua.TypeIDDouble, WantArray: true})
+	})
+
+	t.Run("bad status", func(t *testing.T) {
+		var f float64
+		err := assignValue(nid, &ua.DataValue{Status: ua.StatusBadNodeIDUnknown}, reflect.ValueOf(&f).Elem())
+		verify.Values(t, "", err, &NodeStatusError{NodeID: nid, Status: ua.StatusBadNodeIDUnknown})
+	})
+
+	t.Run("unsupported destination", func(t *testing.T) {
+		var i int
+		err := assignValue(nid, dv(int32(1)), reflect.ValueOf(&i).Elem())
+		verify.Values(t, "", err.Error(), "opcua: invalid destination: unsupported type int")
+	})
+}
+
+func TestClient_WriteValue_RejectsInvalidValues(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	nid := ua.NewNumericNodeID(1, 1)
+
+	err := c.WriteValue(context.Background(), nid, []int{1})
+	verify.Values(t, "[]int", err.Error(), "opcua: cannot write value of type []int to node ns=1;i=1: trying to create a variant from a type that it is not supported: []int")
+
+	// valid values need the data type of the node
+	err = c.WriteValue(context.Background(), nid, []float64{1, 2})
+	verify.Values(t, "[]float64", err, ua.StatusBadServerNotConnected)
+
+	// the cached data type is used for the conversion
+	c.dataTypes.put(nid, &nodeDataType{dataType: ua.NewNumericNodeID(0, id.Double), valueRank: -1})
+	err = c.WriteValue(context.Background(), nid, []float64{1, 2})
+	verify.Values(t, "cached", err.Error(), "opcua: cannot write value to node ns=1;i=1: got array want scalar")
+}
+
+func TestWriteStatus(t *testing.T) {
+	nid := ua.NewNumericNodeID(1, 1)
+	verify.Values(t, "ok", writeStatus(nid, []ua.StatusCode{ua.StatusOK}), nil)
+	verify.Values(t, "good", writeStatus(nid, []ua.StatusCode{ua.StatusGoodCompletesAsynchronously}), nil)
+	verify.Values(t, "bad", writeStatus(nid, []ua.StatusCode{ua.StatusBadTypeMismatch}), &NodeStatusError{NodeID: nid, Status: ua.StatusBadTypeMismatch})
+	verify.Values(t, "empty", writeStatus(nid, nil), ua.StatusBadUnexpectedError)
+}
+
+func TestClient_HistoryReadRawModified(t *testing.T) {
+	_, endpoint := startTestServer(t)
+
+	var (
+		mu  sync.Mutex
+		req *ua.HistoryReadRequest
+	)
+	c := NewClient(endpoint, AutoReconnect(false), RequestTracer(func(info uasc.RequestInfo) {
+		if r, ok := info.Request.(*ua.HistoryReadRequest); ok {
+			mu.Lock()
+			req = r
+			mu.Unlock()
+		}
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the test server has no history so that only the request is checked.
+	nid := ua.NewNumericNodeID(1, 1)
+	start, end := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := c.HistoryReadRawModified(ctx, nid, start, end, 10); err == nil {
+		t.Fatal("got nil want error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if req == nil {
+		t.Fatal("no history read request")
+	}
+	verify.Values(t, "node", req.NodesToRead[0].NodeID, nid)
+	verify.Values(t, "details", req.HistoryReadDetails.Value, &ua.ReadRawModifiedDetails{
+		StartTime:        start,
+		EndTime:          end,
+		NumValuesPerNode: 10,
+	})
+}
+
+func TestNewHistoryReadRequest(t *testing.T) {
+	nid := ua.NewNumericNodeID(1, 1)
+	cfg := defaultHistoryReadConfig()
+	for _, opt := range []HistoryReadOption{HistoryNumValues(10), HistoryReturnBounds(true), HistoryTimestamps(ua.TimestampsToReturnSource)} {
+		opt(cfg)
+	}
+	details := &ua.ReadRawModifiedDetails{NumValuesPerNode: cfg.numValues, ReturnBounds: cfg.returnBounds}
+	req := newHistoryReadRequest(nid, cfg, details)
+
+	verify.Values(t, "timestamps", req.TimestampsToReturn, ua.TimestampsToReturnSource)
+	verify.Values(t, "node", req.NodesToRead[0].NodeID, nid)
+	verify.Values(t, "details", req.HistoryReadDetails.Value, details)
+	verify.Values(t, "type id", req.HistoryReadDetails.TypeID.NodeID.IntID(), uint32(id.ReadRawModifiedDetails_Encoding_DefaultBinary))
+
+	req = newHistoryReadRequest(nid, defaultHistoryReadConfig(), &ua.ReadAtTimeDetails{})
+	verify.Values(t, "at time type id", req.HistoryReadDetails.TypeID.NodeID.IntID(), uint32(id.ReadAtTimeDetails_Encoding_DefaultBinary))
+}
+
+func TestClient_RegisteredNodeIDs(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	nid, alias := ua.NewStringNodeID(2, "Tag1"), ua.NewNumericNodeID(2, 1000)
+	c.registered = map[string]*registeredNode{nid.String(): {id: nid, alias: alias}}
+
+	verify.Values(t, "alias", c.RegisteredNodeID(ua.NewStringNodeID(2, "Tag1")), alias)
+	verify.Values(t, "unknown", c.RegisteredNodeID(ua.NewStringNodeID(2, "Tag2")), (*ua.NodeID)(nil))
+	verify.Values(t, "ids", c.registeredNodeIDs(), []*ua.NodeID{nid})
+
+	// unknown node ids are not sent to the server
+	if err := c.UnregisterNodeIDs(context.Background(), []*ua.NodeID{ua.NewStringNodeID(2, "Tag2")}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.UnregisterNodeIDs(context.Background(), []*ua.NodeID{nid})
+	verify.Values(t, "unregister", err, ua.StatusBadServerNotConnected)
+	verify.Values(t, "registered", len(c.registered), 0)
+}
+
+func TestDataTypeCache(t *testing.T) {
+	id := func(n uint32) *ua.NodeID { return ua.NewNumericNodeID(1, n) }
+	dt := &nodeDataType{dataType: ua.NewNumericNodeID(0, 4), valueRank: -1}
+
+	c := newDataTypeCache(2)
+	c.put(id(1), dt)
+	c.put(id(2), dt)
+	c.get(id(1)) // 2 is now the least recently used entry
+	c.put(id(3), dt)
+
+	verify.Values(t, "len", c.len(), 2)
+	verify.Values(t, "1", c.get(id(1)), dt)
+	verify.Values(t, "2", c.get(id(2)), (*nodeDataType)(nil))
+	verify.Values(t, "3", c.get(id(3)), dt)
+
+	c.clear()
+	verify.Values(t, "cleared", c.len(), 0)
+
+	c = newDataTypeCache(-1)
+	c.put(id(1), dt)
+	verify.Values(t, "disabled", c.len(), 0)
+}
+
+func TestConvertValue(t *testing.T) {
+	dt := func(n uint32) *ua.NodeID { return ua.NewNumericNodeID(0, n) }
+	tests := []struct {
+		name string
+		dt   *ua.NodeID
+		rank int32
+		v    interface{}
+		want interface{}
+		err  string
+	}{
+		{name: "int16", dt: dt(id.Int16), rank: -1, v: int64(7), want: int16(7)},
+		{name: "double array", dt: dt(id.Double), rank: 1, v: []int32{1, 2}, want: []float64{1, 2}},
+		{name: "datetime", dt: dt(id.DateTime), rank: -1, v: time.Time{}, want: time.Time{}},
+		{name: "abstract", dt: dt(id.Number), rank: -1, v: uint8(1), want: uint8(1)},
+		{name: "custom", dt: ua.NewNumericNodeID(2, 3000), rank: -2, v: "x", want: "x"},
+		{name: "overflow", dt: dt(id.Byte), rank: -1, v: int32(256), err: "opcua: value 256 out of range for TypeIDByte"},
+		{name: "mismatch", dt: dt(id.DateTime), rank: -1, v: "x", err: "opcua: got TypeIDString want TypeIDDateTime"},
+		{name: "scalar for array", dt: dt(id.Double), rank: 1, v: 1.0, err: "opcua: got scalar want array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := convertValue(tt.dt, tt.rank, ua.MustVariant(tt.v))
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("got error %v want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "", v.Value(), tt.want)
+		})
+	}
+}
+
+// fakeDataTypeReader serves a data type hierarchy and the definitions
+// of its structured data types.
+type fakeDataTypeReader struct {
+	*fakeBrowser
+	defs map[uint32]*ua.StructureDefinition
+	read []uint32
+}
+
+func (r *fakeDataTypeReader) ReadBatched(ctx context.Context, req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	res := &ua.ReadResponse{}
+	for _, rv := range req.NodesToRead {
+		r.read = append(r.read, rv.NodeID.IntID())
+		def, ok := r.defs[rv.NodeID.IntID()]
+		if !ok || rv.AttributeID != ua.AttributeIDDataTypeDefinition {
+			res.Results = append(res.Results, &ua.DataValue{Status: ua.StatusBadAttributeIDInvalid})
+			continue
+		}
+		res.Results = append(res.Results, &ua.DataValue{Value: ua.MustVariant(ua.NewExtensionObject(def))})
+	}
+	return res, nil
+}
+
+func TestLoadDataTypeDictionary(t *testing.T) {
+	// 5001 is a structure with an enumeration and a duration field.
+	def := &ua.StructureDefinition{
+		DefaultEncodingID: ua.NewNumericNodeID(0, 5002),
+		StructureType:     ua.StructureTypeStructure,
+		Fields: []*ua.StructureField{
+			{Name: "Mode", DataType: ua.NewNumericNodeID(0, 6001), ValueRank: -1},
+			{Name: "Timeout", DataType: ua.NewNumericNodeID(0, 6101), ValueRank: -1},
+		},
+	}
+	r := &fakeDataTypeReader{
+		fakeBrowser: &fakeBrowser{
+			refs: map[uint32][]uint32{
+				id.BaseDataType: {id.Structure, id.Enumeration, id.Double},
+				id.Structure:    {5001, 5003},
+				id.Enumeration:  {6001},
+				id.Double:       {6100},
+				6100:            {6101}, // derived from a derived type
+				5001:            {},
+				5003:            {},
+				6001:            {},
+				6101:            {},
+			},
+			pending: map[string][]uint32{},
+		},
+		defs: map[uint32]*ua.StructureDefinition{5001: def},
+	}
+
+	if err := loadDataTypeDictionary(context.Background(), r, logger.Nop); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "read", r.read, []uint32{5001, 5003})
+	verify.Values(t, "definition", ua.LookupStructureDefinition(ua.NewNumericNodeID(0, 5001)), def)
+	if ua.LookupStructureDefinition(ua.NewNumericNodeID(0, 5003)) != nil {
+		t.Fatal("got definition for a data type without definition")
+	}
+
+	// the body contains Mode=2 and Timeout=1.5
+	b := []byte{
+		0x01, 0x00, 0x8a, 0x13, 0x01, 0x0c, 0x00, 0x00, 0x00,
+		0x02, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f,
+	}
+	eo := new(ua.ExtensionObject)
+	if _, err := eo.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := eo.Value.(*ua.Structure)
+	if !ok {
+		t.Fatalf("got %T want *ua.Structure", eo.Value)
+	}
+	verify.Values(t, "fields", s.Fields, map[string]interface{}{"Mode": int32(2), "Timeout": 1.5})
+}
+
+func TestLoadTypeDefinitions(t *testing.T) {
+	ns2 := func(n uint32) *ua.NodeID { return ua.NewNumericNodeID(2, n) }
+
+	// 8001 is a custom structure with a nested custom structure which
+	// is not in the hierarchy, a structure of namespace 0 with a Go type
+	// and a custom enumeration.
+	outer := &ua.StructureDefinition{
+		DefaultEncodingID: ns2(8002),
+		StructureType:     ua.StructureTypeStructure,
+		Fields: []*ua.StructureField{
+			{Name: "Inner", DataType: ns2(8101), ValueRank: -1},
+			{Name: "Unit", DataType: ua.NewNumericNodeID(0, id.EUInformation), ValueRank: -1},
+			{Name: "Mode", DataType: ns2(8201), ValueRank: -1},
+		},
+	}
+	inner := &ua.StructureDefinition{
+		DefaultEncodingID: ns2(8102),
+		StructureType:     ua.StructureTypeStructure,
+		Fields: []*ua.StructureField{
+			{Name: "Value", DataType: ua.NewNumericNodeID(0, id.Double), ValueRank: -1},
+			{Name: "Outer", DataType: ns2(8001), ValueRank: 1},
+		},
+	}
+	unit := &ua.StructureDefinition{
+		DefaultEncodingID: ua.NewNumericNodeID(0, id.EUInformation_Encoding_DefaultBinary),
+		StructureType:     ua.StructureTypeStructure,
+	}
+	r := &fakeDataTypeReader{
+		fakeBrowser: &fakeBrowser{
+			refs: map[uint32][]uint32{
+				id.BaseDataType: {id.Structure, id.Enumeration},
+				id.Structure:    {8001, 8003},
+				id.Enumeration:  {8201},
+				8001:            {},
+				8003:            {},
+				8201:            {},
+			},
+			pending:    map[string][]uint32{},
+			namespaces: map[uint32]uint16{8001: 2, 8201: 2},
+		},
+		defs: map[uint32]*ua.StructureDefinition{
+			8001:             outer,
+			8101:             inner,
+			id.EUInformation: unit,
+		},
+	}
+
+	if err := loadTypeDefinitions(context.Background(), r, logger.Nop); err != nil {
+		t.Fatal(err)
+	}
+	// 8003 in namespace 0 is not read and the recursive field
+	// of 8101 is not read again.
+	verify.Values(t, "read", r.read, []uint32{8001, 8101, id.EUInformation})
+	verify.Values(t, "inner", ua.LookupStructureDefinition(ns2(8101)), inner)
+
+	body := []byte{}
+	appendValue := func(v interface{}) {
+		b, err := ua.Encode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = append(body, b...)
+	}
+	eu := &ua.EUInformation{
+		NamespaceURI: "urn:units",
+		UnitID:       1,
+		DisplayName:  ua.NewLocalizedText("m"),
+		Description:  ua.NewLocalizedText("meter"),
+	}
+	appendValue(1.5)
+	appendValue(int32(-1)) // Outer
+	appendValue(eu)
+	appendValue(int32(2))
+
+	b, err := ua.Encode(&ua.ExtensionObject{
+		TypeID:       ua.NewNumericExpandedNodeID(2, 8002),
+		EncodingMask: ua.ExtensionObjectBinary,
+		Value:        ua.ExtensionObjectBody(body),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eo := new(ua.ExtensionObject)
+	if _, err := eo.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := eo.Value.(*ua.Structure)
+	if !ok {
+		t.Fatalf("got %T want *ua.Structure", eo.Value)
+	}
+	verify.Values(t, "fields", s.Fields, map[string]interface{}{
+		"Inner": &ua.Structure{
+			TypeID:     ns2(8101),
+			Definition: inner,
+			Fields:     map[string]interface{}{"Value": 1.5, "Outer": []*ua.Structure(nil)},
+		},
+		"Unit": eu,
+		"Mode": int32(2),
+	})
+
+	// the structure is encoded again with the Go type of the field
+	got, err := ua.Encode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "encoded", got, body)
+}
+
+type recordingVerifier struct {
+	opts uacert.VerifyOptions
+	err  error
+}
+
+func (v *recordingVerifier) Verify(cert []byte, opts uacert.VerifyOptions) error {
+	v.opts = opts
+	return v.err
+}
+
+func TestVerifyServerCertificate(t *testing.T) {
+	res := &ua.CreateSessionResponse{
+		ServerCertificate: []byte("cert"),
+		ServerEndpoints: []*ua.EndpointDescription{
+			{ServerCertificate: []byte("other"), Server: &ua.ApplicationDescription{ApplicationURI: "urn:other"}},
+			{ServerCertificate: []byte("cert"), Server: &ua.ApplicationDescription{ApplicationURI: "urn:server"}},
+		},
+	}
+
+	t.Run("options", func(t *testing.T) {
+		v := &recordingVerifier{}
+		c := NewClient("opc.tcp://example.com:4840", ServerCertificateVerifier(v), VerifyServerHostname(true))
+		if err := c.verifyServerCertificate(res); err != nil {
+			t.Fatal(err)
+		}
+		want := uacert.VerifyOptions{ApplicationURI: "urn:server", Hostname: "example.com", ExtKeyUsage: x509.ExtKeyUsageServerAuth}
+		verify.Values(t, "", v.opts, want)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		v := &recordingVerifier{err: uacert.ErrCertUntrusted}
+		c := NewClient("opc.tcp://example.com:4840", ServerCertificateVerifier(v))
+		if err := c.verifyServerCertificate(res); !errors.Is(err, uacert.ErrCertUntrusted) {
+			t.Fatalf("got error %v want %v", err, uacert.ErrCertUntrusted)
+		}
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		v := &recordingVerifier{err: uacert.ErrCertUntrusted}
+		c := NewClient("opc.tcp://example.com:4840", ServerCertificateVerifier(v), InsecureSkipVerify())
+		if err := c.verifyServerCertificate(res); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("channel certificate", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", SecurityMode(ua.MessageSecurityModeSign), RemoteCertificate([]byte("relay")))
+		if err := c.verifyServerCertificate(res); !errors.Is(err, uacert.ErrCertInvalid) {
+			t.Fatalf("got error %v want %v", err, uacert.ErrCertInvalid)
+		}
+		c.cfg.sechan.RemoteCertificate = []byte("cert")
+		if err := c.verifyServerCertificate(res); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("missing application uri", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", ServerCertificateVerifier(&recordingVerifier{}))
+		err := c.verifyServerCertificate(&ua.CreateSessionResponse{ServerCertificate: []byte("cert")})
+		if !errors.Is(err, uacert.ErrURIMismatch) {
+			t.Fatalf("got error %v want %v", err, uacert.ErrURIMismatch)
+		}
+	})
+}
+
+func TestSelectUserTokenPolicy(t *testing.T) {
+	endpoints := []*ua.EndpointDescription{
+		{
+			SecurityPolicyURI: ua.SecurityPolicyURINone,
+			SecurityMode:      ua.MessageSecurityModeNone,
+			UserIdentityTokens: []*ua.UserTokenPolicy{
+				{PolicyID: "anon", TokenType: ua.UserTokenTypeAnonymous},
+				{PolicyID: "user", TokenType: ua.UserTokenTypeUserName, SecurityPolicyURI: ua.SecurityPolicyURIBasic256Sha256},
+				{PolicyID: "saml", TokenType: ua.UserTokenTypeIssuedToken, IssuedTokenType: "urn:saml"},
+				{PolicyID: "jwt", TokenType: ua.UserTokenTypeIssuedToken, IssuedTokenType: ua.IssuedTokenTypeJWT, SecurityPolicyURI: ua.SecurityPolicyURIBasic256Sha256},
+			},
+		},
+		{
+			SecurityPolicyURI: ua.SecurityPolicyURIBasic256Sha256,
+			SecurityMode:      ua.MessageSecurityModeSignAndEncrypt,
+			UserIdentityTokens: []*ua.UserTokenPolicy{
+				{PolicyID: "cert", TokenType: ua.UserTokenTypeCertificate},
+			},
+		},
+	}
+
+	t.Run("username", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AuthUsername("user", "pass"))
+		if err := c.selectUserTokenPolicy(endpoints); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "policy id", policyID(c.cfg.session.UserIdentityToken), "user")
+		verify.Values(t, "policy uri", c.cfg.session.AuthPolicyURI, ua.SecurityPolicyURIBasic256Sha256)
+	})
+
+	t.Run("certificate", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840",
+			SecurityPolicy("Basic256Sha256"),
+			SecurityMode(ua.MessageSecurityModeSignAndEncrypt),
+			AuthCertificate([]byte("cert")),
+		)
+		if err := c.selectUserTokenPolicy(endpoints); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "policy id", policyID(c.cfg.session.UserIdentityToken), "cert")
+		verify.Values(t, "policy uri", c.cfg.session.AuthPolicyURI, "")
+	})
+
+	t.Run("issued token type", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AuthIssuedToken([]byte("token"), ""), AuthIssuedTokenType(ua.IssuedTokenTypeJWT))
+		if err := c.selectUserTokenPolicy(endpoints); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "policy id", policyID(c.cfg.session.UserIdentityToken), "jwt")
+		verify.Values(t, "policy uri", c.cfg.session.AuthPolicyURI, ua.SecurityPolicyURIBasic256Sha256)
+	})
+
+	t.Run("unknown issued token type", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AuthIssuedToken([]byte("token"), ""), AuthIssuedTokenType("urn:kerberos"))
+		if err := c.selectUserTokenPolicy(endpoints); err == nil {
+			t.Fatal("got nil want error")
+		}
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AuthCertificate([]byte("cert")))
+		if err := c.selectUserTokenPolicy(endpoints); err == nil {
+			t.Fatal("got nil want error")
+		}
+	})
+
+	t.Run("unknown policy id", func(t *testing.T) {
+		c := NewClient("opc.tcp://example.com:4840", AuthUsername("user", "pass"), AuthPolicyID("other"))
+		if err := c.selectUserTokenPolicy(endpoints); err == nil {
+			t.Fatal("got nil want error")
+		}
+		if err := c.selectUserTokenPolicy(nil); err != nil {
+			t.Fatalf("got error %v want nil without endpoints", err)
+		}
+	})
+}
+
+func TestIssuedTokenRefreshDelay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		expiry time.Time
+		d      time.Duration
+	}{
+		{"lifetime", now.Add(time.Hour), 45 * time.Minute},
+		{"short lifetime", now.Add(time.Second), minIssuedTokenRefresh},
+		{"expired", now.Add(-time.Minute), minIssuedTokenRefresh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify.Values(t, "", issuedTokenRefreshDelay(now, tt.expiry), tt.d)
+		})
+	}
+}
+
+// testCert returns a self-signed certificate for the application uri
+// and its private key.
+func testCert(t *testing.T, uri string) ([]byte, *rsa.PrivateKey) {
+	t.Helper()
+	certPEM, keyPEM, err := uacert.GenerateCert(uri, []string{"localhost"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := pem.Decode(certPEM)
+	key, _ := pem.Decode(keyPEM)
+	pk, err := x509.ParsePKCS1PrivateKey(key.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Bytes, pk
+}
+
+// startTestServer starts a server on a free port and returns it with its
+// endpoint url.
+func startTestServer(t *testing.T, opts ...server.Option) (*server.Server, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := fmt.Sprintf("opc.tcp://%s", l.Addr())
+	l.Close()
+
+	srv := server.New(endpoint, opts...)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv, endpoint
+}
+
+func TestUserTokenKey(t *testing.T) {
+	appCert, appKey := testCert(t, "urn:gopcua:client")
+	userCert, userKey := testCert(t, "urn:gopcua:user")
+
+	c := NewClient("opc.tcp://example.com:4840", Certificate(appCert), PrivateKey(appKey))
+
+	tests := []struct {
+		name string
+		cert []byte
+		key  *rsa.PrivateKey
+		want *rsa.PrivateKey
+	}{
+		{"user key", userCert, userKey, userKey},
+		{"application certificate", appCert, nil, appKey},
+		{"missing user key", userCert, nil, nil},
+		{"wrong user key", userCert, appKey, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := c.userTokenKey(&ua.X509IdentityToken{CertificateData: tt.cert}, tt.key)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatal("got nil want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != tt.want {
+				t.Fatal("got the wrong key")
+			}
+		})
+	}
+}
+
+// TestCreateSessionChannelCertificate checks that the session is refused
+// if the server certificate of the session is not the certificate of the
+// secure channel like for a relay which runs the secure channel with its
+// own key.
+func TestCreateSessionChannelCertificate(t *testing.T) {
+	serverCert, serverKey := testCert(t, "urn:gopcua:server")
+	clientCert, clientKey := testCert(t, "urn:gopcua:client")
+	relayCert, _ := testCert(t, "urn:gopcua:relay")
+
+	_, endpoint := startTestServer(t,
+		server.Certificate(serverCert),
+		server.PrivateKey(serverKey),
+		server.EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	eps, err := GetEndpoints(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := SelectEndpoint(eps, "Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt)
+	if ep == nil {
+		t.Fatal("no endpoint")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		channel []byte
+		err     error
+	}{
+		{"same certificate", serverCert, nil},
+		{"relay certificate", relayCert, uacert.ErrCertInvalid},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(endpoint,
+				Certificate(clientCert),
+				PrivateKey(clientKey),
+				SecurityFromEndpoint(ep, ua.UserTokenTypeAnonymous),
+				ServerCertificateVerifier(&recordingVerifier{}),
+				AutoReconnect(false),
+			)
+			if err := c.Dial(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			// the certificate of the secure channel is the sender
+			// certificate of the open secure channel response.
+			verify.Values(t, "channel certificate", c.cfg.sechan.RemoteCertificate, serverCert)
+			c.cfg.sechan.RemoteCertificate = tt.channel
+
+			_, err := c.CreateSessionWithContext(ctx, c.cfg.session)
+			if tt.err == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// dropProxy forwards the connections of a client to a server. It drops
+// the connection instead of forwarding the response of a request
+// whose type has been marked with dropResponse. It only works for
+// unencrypted messages.
+type dropProxy struct {
+	endpoint string
+
+	mu   sync.Mutex
+	drop map[uint16]bool
+	sent map[uint16]int
+}
+
+// newDropProxy starts a proxy for the server with the endpoint url and
+// returns it with its own endpoint url.
+func newDropProxy(t *testing.T, endpoint string) (*dropProxy, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	p := &dropProxy{
+		endpoint: endpoint,
+		drop:     map[uint16]bool{},
+		sent:     map[uint16]int{},
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.forward(conn)
+		}
+	}()
+	return p, "opc.tcp://" + l.Addr().String()
+}
+
+// dropResponse drops the connection when the server responds to the
+// next request with the encoding id typeID.
+func (p *dropProxy) dropResponse(typeID uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.drop[typeID] = true
+}
+
+// count returns how often a request with the encoding id typeID has
+// been sent to the server.
+func (p *dropProxy) count(typeID uint16) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sent[typeID]
+}
+
+func (p *dropProxy) forward(client net.Conn) {
+	srv, err := net.Dial("tcp", strings.TrimPrefix(p.endpoint, "opc.tcp://"))
+	if err != nil {
+		client.Close()
+		return
+	}
+	closeAll := func() {
+		client.Close()
+		srv.Close()
+	}
+
+	dropped := make(chan struct{})
+	var once sync.Once
+	go func() {
+		defer closeAll()
+		for {
+			b, err := readTestChunk(srv)
+			if err != nil {
+				return
+			}
+			select {
+			case <-dropped:
+				return
+			default:
+			}
+			if _, err := client.Write(b); err != nil {
+				return
+			}
+		}
+	}()
+
+	defer closeAll()
+	for {
+		b, err := readTestChunk(client)
+		if err != nil {
+			return
+		}
+		// the server only accepts its own endpoint url in the hello
+		// message which follows five uint32 fields.
+		if string(b[:4]) == "HELF" && len(b) >= 28 {
+			b = append(b[:28:28], make([]byte, 4+len(p.endpoint))...)
+			binary.LittleEndian.PutUint32(b[28:], uint32(len(p.endpoint)))
+			copy(b[32:], p.endpoint)
+			binary.LittleEndian.PutUint32(b[4:], uint32(len(b)))
+		}
+		// MSG chunks have the encoding id of the request as a four
+		// byte node id after the message and sequence headers.
+		if string(b[:3]) == "MSG" && len(b) >= 28 && b[24] == 0x01 {
+			typeID := binary.LittleEndian.Uint16(b[26:28])
+			p.mu.Lock()
+			p.sent[typeID]++
+			if p.drop[typeID] {
+				delete(p.drop, typeID)
+				once.Do(func() { close(dropped) })
+			}
+			p.mu.Unlock()
+		}
+		if _, err := srv.Write(b); err != nil {
+			return
+		}
+	}
+}
+
+// readTestChunk reads a message chunk with its header from r.
+func readTestChunk(r io.Reader) ([]byte, error) {
+	hdr := make([]byte, 8)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(hdr[4:])
+	if n < 8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	copy(b, hdr)
+	if _, err := io.ReadFull(r, b[8:]); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// TestClient_SendResponseLost checks that a request whose response is
+// lost with the connection is only sent again if it is read-only since
+// the server may have executed it already.
+func TestClient_SendResponseLost(t *testing.T) {
+	srv, endpoint := startTestServer(t)
+	nodeID, err := srv.AddVariable(ua.NewNumericNodeID(0, id.ObjectsFolder), "Value", ua.MustVariant(int32(0)), server.Writable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, proxy := newDropProxy(t, endpoint)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := NewClient(proxy,
+		AutoReconnect(true),
+		WaitForReconnect(true),
+		ReconnectInterval(10*time.Millisecond),
+	)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	sc := c.SecureChannel()
+	p.dropResponse(id.WriteRequest_Encoding_DefaultBinary)
+	_, err = c.WriteWithContext(ctx, &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{{
+			NodeID:      nodeID,
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(int32(42))},
+		}},
+	})
+	verify.Values(t, "write error", err, ErrResponseLost)
+
+	// the client reads the namespaces while it restores the connection
+	if err := c.waitForReconnect(ctx, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	// the read is sent again on the restored connection and returns the
+	// value of the write which the server has executed once.
+	p.dropResponse(id.ReadRequest_Encoding_DefaultBinary)
+	v, err := c.ReadInt32(ctx, nodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "value", v, int32(42))
+	verify.Values(t, "writes", p.count(id.WriteRequest_Encoding_DefaultBinary), 1)
+	verify.Values(t, "reads", p.count(id.ReadRequest_Encoding_DefaultBinary) >= 2, true)
+}
+
+// startFakeServer starts a server without security which answers the
+// requests of a client with h and returns its endpoint url. Requests for
+// which h returns nil get the response of fakeResponse.
+func startFakeServer(t *testing.T, h func(req ua.Request) ua.Response) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "opc.tcp://" + l.Addr().String()
+	l.Close()
+
+	ln, err := uacp.Listen(endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	serve := func(c *uacp.Conn) {
+		defer c.Close()
+
+		var mu sync.Mutex
+		var seq uint32
+		send := func(typ string, reqID uint32, res interface{}, typeID uint16) {
+			mu.Lock()
+			defer mu.Unlock()
+			seq++
+			hdr := &uasc.MessageHeader{
+				Header:                  uasc.NewHeader(typ, uasc.ChunkTypeFinal, 1),
+				SymmetricSecurityHeader: uasc.NewSymmetricSecurityHeader(1),
+				SequenceHeader:          uasc.NewSequenceHeader(seq, reqID),
+			}
+			if typ == uasc.MessageTypeOpenSecureChannel {
+				hdr.SymmetricSecurityHeader = nil
+				hdr.AsymmetricSecurityHeader = uasc.NewAsymmetricSecurityHeader(ua.SecurityPolicyURINone, nil, nil)
+			}
+			b, err := (&uasc.Message{MessageHeader: hdr, TypeID: ua.NewFourByteExpandedNodeID(0, typeID), Service: res}).Encode()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			c.Write(b)
+		}
+
+		for {
+			b, err := c.Receive()
+			if err != nil {
+				return
+			}
+			m := new(uasc.Message)
+			if _, err := m.Decode(b); err != nil {
+				continue
+			}
+			reqID := m.SequenceHeader.RequestID
+			switch req := m.Service.(type) {
+			case *ua.OpenSecureChannelRequest:
+				send(uasc.MessageTypeOpenSecureChannel, reqID, &ua.OpenSecureChannelResponse{
+					ResponseHeader: fakeResponseHeader(req.RequestHeader),
+					SecurityToken:  &ua.ChannelSecurityToken{ChannelID: 1, TokenID: 1, CreatedAt: time.Now(), RevisedLifetime: req.RequestedLifetime},
+					ServerNonce:    []byte{},
+				}, id.OpenSecureChannelResponse_Encoding_DefaultBinary)
+			case *ua.CloseSecureChannelRequest:
+				return
+			case ua.Request:
+				go func() {
+					res := h(req)
+					if res == nil {
+						res = fakeResponse(req)
+					}
+					// the request stays unanswered
+					if res == nil {
+						return
+					}
+					send(uasc.MessageTypeMessage, reqID, res, ua.ServiceTypeID(res))
+				}()
+			}
+		}
+	}
+
+	go func() {
+		for {
+			c, err := ln.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go serve(c)
+		}
+	}()
+	return endpoint
+}
+
+// fakeResponse returns the response of the fake server for the requests
+// of the session and for a read of the namespace array. Publish requests
+// are not answered and all other requests fail with
+// StatusBadServiceUnsupported.
+func fakeResponse(req ua.Request) ua.Response {
+	h := fakeResponseHeader(req.Header())
+	switch req := req.(type) {
+	case *ua.CreateSessionRequest:
+		return &ua.CreateSessionResponse{
+			ResponseHeader:        h,
+			SessionID:             ua.NewNumericNodeID(1, 1),
+			AuthenticationToken:   ua.NewNumericNodeID(1, 2),
+			RevisedSessionTimeout: req.RequestedSessionTimeout,
+			ServerNonce:           make([]byte, 32),
+			ServerEndpoints: []*ua.EndpointDescription{{
+				EndpointURL:       req.EndpointURL,
+				Server:            &ua.ApplicationDescription{ApplicationName: ua.NewLocalizedText("fake"), ApplicationType: ua.ApplicationTypeServer},
+				SecurityMode:      ua.MessageSecurityModeNone,
+				SecurityPolicyURI: ua.SecurityPolicyURINone,
+				UserIdentityTokens: []*ua.UserTokenPolicy{
+					{PolicyID: "anonymous", TokenType: ua.UserTokenTypeAnonymous},
+				},
+			}},
+			ServerSignature: &ua.SignatureData{},
+		}
+	case *ua.ActivateSessionRequest:
+		return &ua.ActivateSessionResponse{ResponseHeader: h, ServerNonce: make([]byte, 32)}
+	case *ua.CloseSessionRequest:
+		return &ua.CloseSessionResponse{ResponseHeader: h}
+	case *ua.ReadRequest:
+		res := &ua.ReadResponse{ResponseHeader: h}
+		for range req.NodesToRead {
+			res.Results = append(res.Results, &ua.DataValue{
+				EncodingMask: ua.DataValueValue,
+				Value:        ua.MustVariant([]string{"http://opcfoundation.org/UA/"}),
+			})
+		}
+		return res
+	case *ua.PublishRequest:
+		return nil
+	default:
+		h.ServiceResult = ua.StatusBadServiceUnsupported
+		return &ua.ServiceFault{ResponseHeader: h}
+	}
+}
+
+func fakeResponseHeader(req *ua.RequestHeader) *ua.ResponseHeader {
+	return &ua.ResponseHeader{
+		Timestamp:          time.Now(),
+		RequestHandle:      req.RequestHandle,
+		ServiceDiagnostics: &ua.DiagnosticInfo{},
+		AdditionalHeader:   ua.NewExtensionObject(nil),
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// DefaultDataTypeCacheSize is the default number of nodes for which
+// WriteValue caches the data type.
+const DefaultDataTypeCacheSize = 1000
+
+// WriteValue writes the value to the value attribute of a node.
+//
+// The value is converted to the data type and value rank of the node
+// which are read from the server on the first write and cached. Numeric,
+// Boolean and String values are converted as long as the value fits
+// into the data type of the node, e.g. an int can be written to an Int16
+// node. Slices are written as arrays. Values of other data types must
+// have the Go type of the data type. The type of values for nodes with
+// an abstract or non built-in data type is inferred from the Go type.
+//
+// A nil value writes a null value with a bad status code.
+//
+// If the server reports a bad status code for the node the error is a
+// *NodeStatusError.
+func (c *Client) WriteValue(ctx context.Context, id *ua.NodeID, value interface{}) error {
+	dv := &ua.DataValue{
+		EncodingMask: ua.DataValueValue | ua.DataValueStatusCode,
+		Value:        ua.MustVariant(nil),
+		Status:       ua.StatusBad,
+	}
+
+	if value != nil {
+		v, err := ua.NewVariant(value)
+		if err != nil {
+			return errors.Errorf("cannot write value of type %T to node %s: %s", value, id, err)
+		}
+
+		dt, err := c.dataType(ctx, id)
+		if err != nil {
+			return err
+		}
+		if v, err = convertValue(dt.dataType, dt.valueRank, v); err != nil {
+			return errors.Errorf("cannot write value to node %s: %s", id, trimPrefix(err))
+		}
+
+		dv = &ua.DataValue{
+			EncodingMask: ua.DataValueValue,
+			Value:        v,
+		}
+	}
+
+	req := &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{
+			{
+				NodeID:      id,
+				AttributeID: ua.AttributeIDValue,
+				Value:       dv,
+			},
+		},
+	}
+	res, err := c.WriteWithContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	return writeStatus(id, res.Results)
+}
+
+// writeStatus returns an error if the status code of the single
+// write result is bad.
+func writeStatus(id *ua.NodeID, results []ua.StatusCode) error {
+	if len(results) != 1 {
+		return ua.StatusBadUnexpectedError
+	}
+	if results[0].IsBad() {
+		return &NodeStatusError{NodeID: id, Status: results[0]}
+	}
+	return nil
+}
+
+// nodeDataType contains the DataType and ValueRank attributes of a node.
+type nodeDataType struct {
+	dataType  *ua.NodeID
+	valueRank int32
+}
+
+// dataType returns the cached data type of the node or reads the
+// DataType and ValueRank attributes.
+func (c *Client) dataType(ctx context.Context, id *ua.NodeID) (*nodeDataType, error) {
+	if dt := c.dataTypes.get(id); dt != nil {
+		return dt, nil
+	}
+
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{
+			{NodeID: id, AttributeID: ua.AttributeIDDataType},
+			{NodeID: id, AttributeID: ua.AttributeIDValueRank},
+		},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	}
+	res, err := c.ReadWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != 2 {
+		return nil, ua.StatusBadUnexpectedError
+	}
+
+	dt, err := scalarValue(id, res.Results[0], ua.TypeIDNodeID)
+	if err != nil {
+		return nil, err
+	}
+	vr, err := scalarValue(id, res.Results[1], ua.TypeIDInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	ndt := &nodeDataType{dataType: dt.(*ua.NodeID), valueRank: vr.(int32)}
+	c.dataTypes.put(id, ndt)
+	return ndt, nil
+}
+
+// convertValue converts the value to the data type and value rank of a
+// node or method argument. Values for abstract and non built-in data types
+// are not converted.
+func convertValue(dataType *ua.NodeID, valueRank int32, v *ua.Variant) (*ua.Variant, error) {
+	array := v.Has(ua.VariantArrayValues)
+	switch {
+	case valueRank == -1 && array:
+		return nil, errors.Errorf("got array want scalar")
+	case valueRank >= 0 && !array:
+		return nil, errors.Errorf("got scalar want array")
+	}
+
+	dt := dataType
+	if dt == nil || dt.Namespace() != 0 || dt.Type() != ua.NodeIDTypeFourByte && dt.Type() != ua.NodeIDTypeTwoByte && dt.Type() != ua.NodeIDTypeNumeric {
+		return v, nil
+	}
+
+	switch t := dt.IntID(); t {
+	case id.BaseDataType, id.Number, id.Integer, id.UInteger:
+		// abstract types accept any matching value
+		return v, nil
+	case id.Boolean, id.SByte, id.Byte, id.Int16, id.UInt16, id.Int32, id.UInt32,
+		id.Int64, id.UInt64, id.Float, id.Double, id.String:
+		if v.Type() == ua.TypeID(t) {
+			return v, nil
+		}
+		return v.ConvertTo(ua.TypeID(t))
+	default:
+		if t <= id.DiagnosticInfo && v.Type() != ua.TypeID(t) {
+			return nil, errors.Errorf("got %s want %s", v.Type(), ua.TypeID(t))
+		}
+		return v, nil
+	}
+}
+
+// dataTypeCache is a size-bounded cache for the data types of nodes
+// which evicts the least recently used entries.
+type dataTypeCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type dataTypeCacheEntry struct {
+	key string
+	dt  *nodeDataType
+}
+
+// newDataTypeCache returns a cache for the given number of nodes.
+// A size of 0 uses DefaultDataTypeCacheSize and a negative size
+// disables the cache.
+func newDataTypeCache(size int) *dataTypeCache {
+	if size == 0 {
+		size = DefaultDataTypeCacheSize
+	}
+	return &dataTypeCache{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+func (c *dataTypeCache) get(id *ua.NodeID) *nodeDataType {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[id.String()]
+	if !ok {
+		return nil
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*dataTypeCacheEntry).dt
+}
+
+func (c *dataTypeCache) put(id *ua.NodeID, dt *nodeDataType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+
+	key := id.String()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*dataTypeCacheEntry).dt = dt
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&dataTypeCacheEntry{key: key, dt: dt})
+	for c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*dataTypeCacheEntry).key)
+	}
+}
+
+func (c *dataTypeCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+}
+
+func (c *dataTypeCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// trimPrefix removes the error prefix of the errors package from the
+// error message so that the message can be embedded in another error.
+func trimPrefix(err error) string {
+	return strings.TrimPrefix(err.Error(), errors.Prefix)
+}
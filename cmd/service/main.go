@@ -9,6 +9,7 @@ import (
 	"log"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -16,25 +17,57 @@ import (
 	"github.com/zzylovesll/myOpcUa/errors"
 )
 
-var in, out, pkg string
+var in, nodeset, out, pkg, prefix string
+
+// standard is true if the standard types are generated. Otherwise, the
+// generated types refer to the types of the ua package.
+var standard bool
 
 func main() {
 	log.SetFlags(0)
 
-	flag.StringVar(&in, "in", "schema/Opc.Ua.Types.bsd", "Path to Opc.Ua.Types.bsd file")
+	flag.StringVar(&in, "in", "schema/Opc.Ua.Types.bsd", "Comma-separated paths to Opc.Ua.Types.bsd and the binary schemas of companion specifications")
+	flag.StringVar(&nodeset, "nodeset", "", "Path to a NodeSet2 XML file to generate the data types of instead of the standard types")
 	flag.StringVar(&out, "out", "ua", "Path to output directory")
 	flag.StringVar(&pkg, "pkg", "ua", "Go package name")
+	flag.StringVar(&prefix, "prefix", "", "Prefix of the names of the generated types which are not registered with their ids")
 	flag.Parse()
 
-	dict, err := ReadTypes(in)
+	dict, err := ReadTypes(strings.Split(in, ",")...)
 	if err != nil {
 		log.Fatalf("Failed to read type definitions: %s", err)
 	}
 
-	writeEnums(Enums(dict))
-	writeServiceRegister(ExtObjects(dict))
-	writeExtObjects(ExtObjects(dict))
-	writeRegisterExtObjects(ExtObjects(dict))
+	if nodeset != "" {
+		ns, err := ReadNodeSet(nodeset)
+		if err != nil {
+			log.Fatalf("Failed to read nodeset: %s", err)
+		}
+		enums, objs, err := NodeSetTypes(ns, dict)
+		if err != nil {
+			log.Fatalf("Failed to read nodeset types: %s", err)
+		}
+		AddPrefix(prefix, enums, objs)
+		if len(enums) > 0 {
+			writeEnums(enums)
+		}
+		if len(objs) > 0 {
+			writeNodeSetExtObjects(objs)
+		}
+		return
+	}
+
+	// the types of companion specifications in another package refer
+	// to the standard types instead of generating them again.
+	standard = pkg == "ua" || !dict.HasCompanionTypes()
+
+	enums, objs := Enums(dict), ExtObjects(dict)
+	AddPrefix(prefix, enums, objs)
+
+	writeEnums(enums)
+	writeServiceRegister(objs)
+	writeExtObjects(objs)
+	writeRegisterExtObjects(objs)
 }
 
 func writeEnums(enums []Type) {
@@ -47,13 +80,31 @@ func writeEnums(enums []Type) {
 
 func writeExtObjects(objs []Type) {
 	var b bytes.Buffer
-	if err := tmplReqResp.Execute(&b, nil); err != nil {
+	if standard {
+		if err := tmplReqResp.Execute(&b, prefix); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := FormatTypes(&b, objs); err != nil {
 		log.Fatal(err)
 	}
+	write(b.Bytes(), path.Join(out, "extobjs_gen.go"))
+}
+
+// writeNodeSetExtObjects writes the extension objects of a nodeset and
+// registers them with their binary encoding ids.
+func writeNodeSetExtObjects(objs []Type) {
+	var b bytes.Buffer
 	if err := FormatTypes(&b, objs); err != nil {
 		log.Fatal(err)
 	}
 	write(b.Bytes(), path.Join(out, "extobjs_gen.go"))
+
+	b.Reset()
+	if err := tmplRegNodeSet.Execute(&b, objs); err != nil {
+		log.Fatal(err)
+	}
+	write(b.Bytes(), path.Join(out, "register_extobjs_gen.go"))
 }
 
 func writeRegisterExtObjects(objs []Type) {
@@ -101,16 +152,40 @@ var tmplHeader = template.Must(template.New("").Parse(`
 
 package {{.}}
 
-import "time"
+import (
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	{{- if ne . "ua"}}
+	"github.com/zzylovesll/myOpcUa/ua"
+	{{- end}}
+)
 
 `))
 
+// optionSets are the enums which are bit masks but are not marked as
+// option sets in the schema.
+var optionSets = map[string]bool{
+	"BrowseResultMask":             true,
+	"ModelChangeStructureVerbMask": true,
+	"NodeAttributesMask":           true,
+	"NodeClass":                    true,
+	"OpenFileMode":                 true,
+	"TrustListMasks":               true,
+}
+
 func Enums(dict *TypeDictionary) []Type {
 	var enums []Type
 	for _, t := range dict.Enums {
+		if t.Namespace == "ua" && !standard {
+			continue
+		}
 		e := Type{
-			Name: goname.Format(t.Name),
-			Kind: KindEnum,
+			Name:      goname.Format(t.Name),
+			Doc:       t.Doc,
+			Kind:      KindEnum,
+			OptionSet: t.IsOptionSet || optionSets[t.Name],
+			Standard:  t.Namespace == "ua",
 		}
 
 		switch {
@@ -129,8 +204,12 @@ func Enums(dict *TypeDictionary) []Type {
 				Name:      goname.Format(e.Name + val.Name),
 				ShortName: val.Name,
 				Value:     val.Value,
+				Doc:       val.Doc,
 			}
 			e.Values = append(e.Values, v)
+			if e.OptionSet && v.Value > 0 && v.Value&(v.Value-1) == 0 {
+				e.Bits = append(e.Bits, v)
+			}
 		}
 		enums = append(enums, e)
 	}
@@ -142,51 +221,142 @@ func ExtObjects(dict *TypeDictionary) []Type {
 		// Extensionobject is the base class for all extension objects.
 		"ua:ExtensionObject": &Type{Name: "ExtensionObject"},
 
+		// Union is the base class of the unions which select one of their
+		// fields with the SwitchField.
+		"ua:Union": &Type{Name: "Union"},
+
 		// DataTypeDefinition is referenced in Opc.Ua.Types.bsd but not defined there
 		// From what I can tell it is an abstract base class without any fields.
 		// We define it here to be able to generate code for derived classes.
-		"tns:DataTypeDefinition": &Type{Name: "DataTypeDefinition"},
+		"ua:DataTypeDefinition": &Type{Name: "DataTypeDefinition"},
+	}
+
+	// resolve the types until no more types are derived from
+	// ExtensionObject since the base type can be declared after the
+	// derived type or in another dictionary.
+	objs := map[*StructType]*Type{}
+	for progress := true; progress; {
+		progress = false
+		for _, t := range dict.Types {
+			if objs[t] != nil {
+				continue
+			}
+
+			// check if the base type is derived from ExtensionObject
+			baseType := baseTypes[t.BaseType]
+			if baseType == nil {
+				continue
+			}
+
+			// register it as derived from ExtensionObject
+			o := extObject(t, baseType)
+			baseTypes[t.QName()] = o
+			objs[t] = o
+			progress = true
+		}
 	}
 
 	var objects []Type
+	unregistered := 0
 	for _, t := range dict.Types {
-		// check if the base type is derived from ExtensionObject
-		baseType := baseTypes[t.BaseType]
-		if baseType == nil {
+		o := objs[t]
+		if o == nil || (o.Standard && !standard) {
 			continue
 		}
+		if !o.Standard {
+			unregistered++
+		}
+		objects = append(objects, *o)
+	}
+	if unregistered > 0 {
+		log.Printf("%d types of other namespaces are not registered since their ids are unknown", unregistered)
+	}
+	return objects
+}
+
+func extObject(t *StructType, baseType *Type) *Type {
+	o := &Type{
+		Name:     goname.Format(t.Name),
+		Doc:      t.Doc,
+		Kind:     KindExtensionObject,
+		Base:     baseType,
+		Standard: t.Namespace == "ua",
+	}
 
-		o := Type{
-			Name: goname.Format(t.Name),
-			Kind: KindExtensionObject,
-			Base: baseType,
+	// bits are the positions of the bit fields which mark optional
+	// fields as present. They are combined into the EncodingMask.
+	bits, nbits := map[string]int{}, 0
+	for _, f := range t.Fields {
+		// skip fields containing the length of an array since
+		// we create an array type
+		if t.IsLengthField(f) {
+			continue
 		}
 
-		for _, f := range t.Fields {
-			// skip fields containing the length of an array since
-			// we create an array type
-			if t.IsLengthField(f) {
-				continue
+		if f.Type == "opc:Bit" {
+			if nbits == 0 {
+				o.Fields = append(o.Fields, Field{Name: "EncodingMask", Type: "uint32"})
 			}
+			bits[f.Name] = nbits
+			nbits += f.BitLength()
+			continue
+		}
 
-			of := Field{
-				Name: goname.Format(f.Name),
-				Type: goFieldType(f),
+		of := Field{
+			Name: goname.Format(f.Name),
+			Type: goFieldType(f),
+			Doc:  f.Doc,
+		}
+		if of.Name == "AttributeID" {
+			of.Type = qualify("AttributeID")
+		}
+		if bit, ok := bits[f.SwitchField]; ok {
+			of.SwitchField, of.SwitchBit, of.IsOptional = "EncodingMask", bit, true
+		} else if f.SwitchField != "" {
+			of.SwitchField = goname.Format(f.SwitchField)
+			of.SwitchValue, _ = strconv.Atoi(f.SwitchValue)
+		}
+		o.Fields = append(o.Fields, of)
+	}
+	if nbits > 32 {
+		log.Fatalf("%s has %d bit fields", t.Name, nbits)
+	}
+	return o
+}
+
+// AddPrefix adds the prefix to the names of the types and their values
+// and to the field types which refer to them. The names have already been
+// formatted so that the prefix does not change their capitalization.
+func AddPrefix(prefix string, types ...[]Type) {
+	names := map[string]bool{}
+	for _, tt := range types {
+		for i := range tt {
+			tt[i].IDName = tt[i].Name
+			names[tt[i].Name] = true
+		}
+	}
+	if prefix == "" {
+		return
+	}
+	for _, tt := range types {
+		for i := range tt {
+			t := &tt[i]
+			t.Name = prefix + t.Name
+			for j := range t.Values {
+				t.Values[j].Name = prefix + t.Values[j].Name
+			}
+			for j := range t.Bits {
+				t.Bits[j].Name = prefix + t.Bits[j].Name
 			}
-			if of.Name == "AttributeID" {
-				of.Type = "AttributeID"
+			for j := range t.Fields {
+				f := &t.Fields[j]
+				name := strings.TrimLeft(f.Type, "[]*")
+				if names[name] {
+					f.Type = f.Type[:len(f.Type)-len(name)] + prefix + name
+				}
 			}
-			o.Fields = append(o.Fields, of)
 		}
-
-		// register it as derived from ExtensionObject
-		// we need to register it with target namespace 'tns:' since t.Name only contains the
-		// base name.
-		baseTypes["tns:"+t.Name] = &o
-
-		objects = append(objects, o)
 	}
-	return objects
 }
 
 type Kind int
@@ -200,6 +370,17 @@ type Type struct {
 	// Name is the Go name of the OPC/UA type.
 	Name string
 
+	// IDName is the Go name of the OPC/UA type without the prefix which
+	// is the name of its ids in the id package.
+	IDName string
+
+	// Standard is true for the types of the standard namespace which
+	// have ids in the id package.
+	Standard bool
+
+	// Doc is the documentation of the OPC/UA type.
+	Doc string
+
 	// Type is the Go type of the OPC/UA type.
 	Type string
 
@@ -214,25 +395,155 @@ type Type struct {
 
 	// Values is the list of enum values.
 	Values []Value
+
+	// OptionSet is true if the enum is a bit mask.
+	OptionSet bool
+
+	// Bits is the list of the enum values of an option set which have
+	// a single bit set.
+	Bits []Value
+
+	// NamespaceURI and EncodingID are the binary encoding id of a type
+	// from a nodeset. EncodingID is 0 if the type has no binary encoding.
+	NamespaceURI string
+	EncodingID   uint32
+}
+
+// BitsVar returns the name of the variable with the bits of an option
+// set.
+func (t Type) BitsVar() string {
+	return strings.ToLower(t.Name[:1]) + t.Name[1:] + "Bits"
+}
+
+// Registered returns true if the type is registered with the id of its
+// binary encoding. Types with a prefix are not registered since the ids
+// are already registered for the types without the prefix.
+func (t Type) Registered() bool {
+	return t.Standard && t.Name == t.IDName
 }
 
 func (t Type) IsRequest() bool {
-	return len(t.Fields) > 0 && t.Fields[0].Type == "*RequestHeader"
+	return len(t.Fields) > 0 && t.Fields[0].Name == "RequestHeader"
 }
 
 func (t Type) IsResponse() bool {
-	return len(t.Fields) > 0 && t.Fields[0].Type == "*ResponseHeader"
+	return len(t.Fields) > 0 && t.Fields[0].Name == "ResponseHeader"
+}
+
+// HeaderType returns the Go type of the header of a request or a
+// response.
+func (t Type) HeaderType() string {
+	return t.Fields[0].Type
+}
+
+// RequiredFields returns the pointer fields which must not be nil when
+// the type is encoded. Extension objects are encoded as null values when
+// they are nil and optional fields are not encoded.
+func (t Type) RequiredFields() []Field {
+	var fields []Field
+	for _, f := range t.Fields {
+		if strings.HasPrefix(f.Type, "*") && !strings.HasSuffix(f.Type, "ExtensionObject") && f.SwitchField == "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
 }
 
 type Value struct {
 	Name      string
 	ShortName string
 	Value     int
+	Doc       string
 }
 
 type Field struct {
 	Name string
 	Type string
+	Doc  string
+
+	// SwitchField is the name of the field which determines whether the
+	// field is encoded. An optional field is encoded if the bit
+	// SwitchBit of the switch field is set and a field of a union if
+	// the switch field has the SwitchValue.
+	SwitchField string
+	SwitchBit   int
+	SwitchValue int
+	IsOptional  bool
+}
+
+// Tag returns the value of the opcua struct tag of the field.
+func (f Field) Tag() string {
+	switch {
+	case f.SwitchField == "":
+		return ""
+	case f.IsOptional:
+		return "switch=" + f.SwitchField + ",bit=" + strconv.Itoa(f.SwitchBit)
+	default:
+		return "switch=" + f.SwitchField + ",value=" + strconv.Itoa(f.SwitchValue)
+	}
+}
+
+// docWidth is the maximum width of the text of a doc comment line.
+const docWidth = 72
+
+// docComment returns the doc comment for the documentation string of the
+// type, field or value with the Go name. The comment starts with the name
+// and is wrapped at docWidth. It returns an empty string if there is no
+// documentation.
+//
+// The documentation is turned into a sentence about the name:
+//
+//	"A request header."                -> "Name is a request header."
+//	"The header of a request."         -> "Name describes the header of a request."
+//	"This abstract type is the base."  -> "Name is the base."
+//	"Creates a session."               -> "Name creates a session."
+//	"Browse the nodes."                -> "Name browses the nodes."
+func docComment(name, doc string) string {
+	words := strings.Fields(doc)
+	if len(words) == 0 {
+		return ""
+	}
+	lower := func(s string) string { return strings.ToLower(s[:1]) + s[1:] }
+	switch first := words[0]; first {
+	case "A", "An":
+		words = append([]string{name, "is", lower(first)}, words[1:]...)
+	case "The":
+		if len(words) > 3 && words[1] == "description" && words[2] == "of" {
+			words = append([]string{name, "describes"}, words[3:]...)
+			break
+		}
+		words = append([]string{name, "describes", "the"}, words[1:]...)
+	case "This":
+		// replace the subject up to the verb with the name
+		for i, w := range words {
+			if w == "is" {
+				words = append([]string{name}, words[i:]...)
+				break
+			}
+		}
+		if words[0] != name {
+			words = append([]string{name, "describes", "this"}, words[1:]...)
+		}
+	default:
+		if !strings.HasSuffix(first, "s") {
+			first += "s"
+		}
+		words = append([]string{name, lower(first)}, words[1:]...)
+	}
+
+	var b, line strings.Builder
+	for _, w := range words {
+		if line.Len() > 0 && line.Len()+1+len(w) > docWidth {
+			b.WriteString("// " + line.String() + "\n")
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteString(" ")
+		}
+		line.WriteString(w)
+	}
+	b.WriteString("// " + line.String() + "\n")
+	return b.String()
 }
 
 func FormatTypes(w io.Writer, types []Type) error {
@@ -255,20 +566,60 @@ func FormatType(w io.Writer, t Type) error {
 	}
 }
 
-var tmplEnum = template.Must(template.New("").Parse(`
-type {{.Name}} {{.Type}}
+var tmplEnum = template.Must(template.New("").Funcs(funcs).Parse(`
+{{doc .Name .Doc}}type {{.Name}} {{.Type}}
 
-func {{.Name}}FromString(s string) {{.Name}} {
-	switch s {
-		{{range $i, $v := .Values}}case "{{.ShortName}}": return {{$v.Value}}
+// {{.Name}}FromString returns the {{.Name}} with the case-insensitive name s.
+func {{.Name}}FromString(s string) ({{.Name}}, error) {
+	switch strings.ToLower(s) {
+		{{range $i, $v := .Values}}case "{{lower .ShortName}}": return {{$v.Value}}, nil
 		{{end}}default:
-		return 0
+		return 0, errors.Errorf("invalid {{.Name}} %q", s)
 	}
 }
 
+{{if .OptionSet -}}
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+{{- else -}}
+// String returns the name of the value or its number if it has no name.
+{{- end}}
+func (v {{.Name}}) String() string {
+	switch v {
+		{{range $i, $v := .Values}}case {{$v.Value}}: return "{{.ShortName}}"
+		{{end}}default:
+		{{if .OptionSet}}return {{ua}}FormatOptionSet(uint64(v), {{.BitsVar}}){{else}}return strconv.FormatUint(uint64(v), 10){{end}}
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v {{.Name}}) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *{{.Name}}) UnmarshalText(b []byte) error {
+	n, err := {{ua}}ParseEnumText(string(b), {{.OptionSet}}, func(s string) (uint64, error) {
+		x, err := {{.Name}}FromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = {{.Name}}(n)
+	return nil
+}
+{{- if .OptionSet}}
+
+var {{.BitsVar}} = []{{ua}}OptionSetBit{
+	{{range $i, $v := .Bits}}{Mask: {{$v.Value}}, Name: "{{.ShortName}}"},
+	{{end}}
+}
+{{- end}}
+
 const (
 	{{$Name := .Name}}
-	{{range $i, $v := .Values}}{{$v.Name}} {{$Name}} = {{$v.Value}}
+	{{range $i, $v := .Values}}{{doc $v.Name $v.Doc}}{{$v.Name}} {{$Name}} = {{$v.Value}}
 	{{end}}
 )
 `))
@@ -280,56 +631,110 @@ import (
 
 func init() {
 	{{- range $i, $v := . -}}
-		RegisterExtensionObject(NewNumericNodeID(0, id.{{$v.Name}}_Encoding_DefaultBinary), new({{$v.Name}}))
+		{{- if $v.Registered -}}
+		RegisterExtensionObject(NewNumericNodeID(0, id.{{$v.IDName}}_Encoding_DefaultBinary), new({{$v.Name}}))
+		{{end -}}
 	{{end -}}
 }
 `))
 
+var tmplRegNodeSet = template.Must(template.New("").Funcs(funcs).Parse(`
+func init() {
+	{{- range $i, $v := . -}}
+		{{- if not $v.EncodingID}}{{continue}}{{end}}
+		{{if $v.NamespaceURI -}}
+			{{ua}}RegisterExtensionObjectURI({{quote $v.NamespaceURI}}, {{$v.EncodingID}}, new({{$v.Name}}))
+		{{- else -}}
+			{{ua}}RegisterExtensionObject({{ua}}NewNumericNodeID(0, {{$v.EncodingID}}), new({{$v.Name}}))
+		{{- end}}
+	{{- end}}
+}
+`))
+
 var tmplReqResp = template.Must(template.New("").Parse(`
-type Request interface {
-	Header() *RequestHeader
-	SetHeader(*RequestHeader)
+type {{.}}Request interface {
+	Header() *{{.}}RequestHeader
+	SetHeader(*{{.}}RequestHeader)
 }
 
-type Response interface {
-	Header() *ResponseHeader
-	SetHeader(*ResponseHeader)
+type {{.}}Response interface {
+	Header() *{{.}}ResponseHeader
+	SetHeader(*{{.}}ResponseHeader)
 }
 `))
 
-var tmplExtObject = template.Must(template.New("").Parse(`
-type {{.Name}} struct {
+var tmplExtObject = template.Must(template.New("").Funcs(funcs).Parse(`
+{{doc .Name .Doc}}type {{.Name}} struct {
 	{{- if .Fields}}
-		{{range $i, $v := .Fields}}{{$v.Name}} {{$v.Type}}
+		{{range $i, $v := .Fields}}{{doc $v.Name $v.Doc}}{{$v.Name}} {{$v.Type}}{{with $v.Tag}} {{tag .}}{{end}}
 		{{end}}
 	{{end -}}
 }
 {{- if .IsRequest}}
 
-func (t *{{.Name}}) Header() *RequestHeader {
+func (t *{{.Name}}) Header() {{.HeaderType}} {
 	return t.RequestHeader
 }
 
-func (t *{{.Name}}) SetHeader(h *RequestHeader) {
+func (t *{{.Name}}) SetHeader(h {{.HeaderType}}) {
 	t.RequestHeader = h
 }
 {{- end}}
 {{- if .IsResponse}}
 
-func (t *{{.Name}}) Header() *ResponseHeader {
+func (t *{{.Name}}) Header() {{.HeaderType}} {
 	return t.ResponseHeader
 }
 
-func (t *{{.Name}}) SetHeader(h *ResponseHeader) {
+func (t *{{.Name}}) SetHeader(h {{.HeaderType}}) {
 	t.ResponseHeader = h
 }
 {{- end}}
+{{- range .Fields}}
+{{- if .SwitchField}}
+
+{{if .IsOptional -}}
+// Set{{.Name}} sets {{.Name}} and marks it as present in the {{.SwitchField}}.
+{{- else -}}
+// Set{{.Name}} sets {{.Name}} and selects it in the {{.SwitchField}}.
+{{- end}}
+func (t *{{$.Name}}) Set{{.Name}}(v {{.Type}}) {
+	{{if .IsOptional}}t.{{.SwitchField}} |= 1 << {{.SwitchBit}}{{else}}t.{{.SwitchField}} = {{.SwitchValue}}{{end}}
+	t.{{.Name}} = v
+}
+{{- end}}
+{{- end}}
+{{- if or .IsRequest .IsResponse}}
+
+// Validate returns an error if a required field is nil.
+func (t *{{.Name}}) Validate() error {
+	{{- range .RequiredFields}}
+	if t.{{.Name}} == nil {
+		return errors.New("{{$.Name}}.{{.Name}} is nil")
+	}
+	{{- end}}
+	return nil
+}
+{{- end}}
 `))
 
 var funcs = template.FuncMap{
+	"doc":   docComment,
+	"quote": strconv.Quote,
+	"tag": func(s string) string {
+		return "`opcua:" + strconv.Quote(s) + "`"
+	},
+	// ua returns the qualifier of the names of the ua package.
+	"ua": func() string {
+		if pkg == "ua" {
+			return ""
+		}
+		return "ua."
+	},
 	"isService": func(s string) bool {
 		return strings.HasSuffix(s, "Request") || strings.HasSuffix(s, "Response") || s == "ServiceFault"
 	},
+	"lower": strings.ToLower,
 }
 
 var tmplRegister = template.Must(template.New("").Funcs(funcs).Parse(`
@@ -338,8 +743,8 @@ import "github.com/zzylovesll/myOpcUa/id"
 
 func init() {
 	{{- range $i, $v := . -}}
-		{{- if isService $v.Name -}}
-			RegisterService(id.{{$v.Name}}_Encoding_DefaultBinary, new({{$v.Name}}))
+		{{- if and $v.Registered (isService $v.Name) -}}
+			RegisterService(id.{{$v.IDName}}_Encoding_DefaultBinary, new({{$v.Name}}))
 		{{end -}}
 	{{end -}}
 }
@@ -364,11 +769,13 @@ var builtins = map[string]string{
 	"opc:Guid":       "*GUID",
 }
 
+// goFieldType returns the Go type of the field. The built-in types and
+// the standard types which are not generated are qualified with the ua
+// package for other packages.
 func goFieldType(f *StructField) string {
 	t, builtin := builtins[f.Type]
 	if t == "" {
-		prefix := strings.NewReplacer("ua:", "", "tns:", "")
-		t = goname.Format(prefix.Replace(f.Type))
+		t = goname.Format(f.Type[strings.Index(f.Type, ":")+1:])
 	}
 	if !f.IsEnum && !builtin {
 		t = "*" + t
@@ -376,5 +783,8 @@ func goFieldType(f *StructField) string {
 	if f.IsSlice() {
 		t = "[]" + t
 	}
+	if builtin || (strings.HasPrefix(f.Type, "ua:") && !standard) {
+		t = qualify(t)
+	}
 	return t
 }
@@ -0,0 +1,120 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPrefix generates the standard types with a prefix, adds them to a
+// copy of the ua package next to the types without the prefix and runs
+// a test of the copy to check that it compiles and initializes.
+func TestPrefix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the generator test in short mode")
+	}
+	for _, cmd := range []string{"go", "goimports"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			t.Skipf("%s is not installed", cmd)
+		}
+	}
+
+	gen, err := ioutil.TempDir("", "prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gen)
+	run(t, "../..", "go", "run", "./cmd/service", "-out", gen, "-prefix", "X")
+
+	// the copy must be in the module to import its other packages.
+	pkg, err := ioutil.TempDir(".", "prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(pkg)
+
+	files, err := filepath.Glob("../../ua/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			copyFile(t, f, filepath.Join(pkg, filepath.Base(f)))
+		}
+	}
+	gens, err := filepath.Glob(filepath.Join(gen, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gens {
+		copyFile(t, f, filepath.Join(pkg, "x_"+filepath.Base(f)))
+	}
+	src := []byte("package ua\n\nimport \"testing\"\n\nfunc TestInit(t *testing.T) {}\n")
+	if err := ioutil.WriteFile(filepath.Join(pkg, "init_test.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	run(t, ".", "go", "test", "./"+filepath.Base(pkg))
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dst, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func run(t *testing.T, dir, name string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s %v: %v\n%s", name, args, err, out)
+	}
+}
+
+func TestEnumString(t *testing.T) {
+	typ := Type{
+		Name: "NamingRuleType",
+		Type: "uint32",
+		Kind: KindEnum,
+		Values: []Value{
+			{Name: "NamingRuleTypeMandatory", ShortName: "Mandatory", Value: 1},
+			{Name: "NamingRuleTypeOptional", ShortName: "Optional", Value: 2},
+		},
+	}
+	var b bytes.Buffer
+	if err := FormatType(&b, typ); err != nil {
+		t.Fatal(err)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		t.Fatalf("%s\n%s", err, b.Bytes())
+	}
+	want := `func (v NamingRuleType) String() string {
+	switch v {
+	case 1:
+		return "Mandatory"
+	case 2:
+		return "Optional"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+`
+	if !strings.Contains(string(src), want) {
+		t.Fatalf("got\n%s\nwant String method\n%s", src, want)
+	}
+}
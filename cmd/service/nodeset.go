@@ -0,0 +1,470 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/cmd/service/goname"
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+// uaNamespaceURI is the URI of the namespace of the standard types.
+const uaNamespaceURI = "http://opcfoundation.org/UA/"
+
+// NodeSet contains the data types of a UANodeSet XML file.
+//
+// Specification: Part 6, Annex F
+type NodeSet struct {
+	XMLName       xml.Name        `xml:"UANodeSet"`
+	NamespaceURIs []string        `xml:"NamespaceUris>Uri"`
+	Aliases       []*NodeSetAlias `xml:"Aliases>Alias"`
+	DataTypes     []*NodeSetNode  `xml:"UADataType"`
+	Objects       []*NodeSetNode  `xml:"UAObject"`
+}
+
+type NodeSetAlias struct {
+	Alias  string `xml:",attr"`
+	NodeID string `xml:",chardata"`
+}
+
+type NodeSetNode struct {
+	NodeID     string              `xml:"NodeId,attr"`
+	BrowseName string              `xml:",attr"`
+	IsAbstract bool                `xml:",attr"`
+	Doc        string              `xml:"Documentation"`
+	References []*NodeSetReference `xml:"References>Reference"`
+	Definition *NodeSetDefinition  `xml:"Definition"`
+}
+
+type NodeSetReference struct {
+	ReferenceType string `xml:",attr"`
+	IsForward     string `xml:",attr"`
+	Target        string `xml:",chardata"`
+}
+
+func (r *NodeSetReference) Forward() bool {
+	return r.IsForward != "false"
+}
+
+type NodeSetDefinition struct {
+	Name        string          `xml:",attr"`
+	IsUnion     bool            `xml:",attr"`
+	IsOptionSet bool            `xml:",attr"`
+	Fields      []*NodeSetField `xml:"Field"`
+}
+
+type NodeSetField struct {
+	Name       string `xml:",attr"`
+	DataType   string `xml:",attr"`
+	ValueRank  string `xml:",attr"`
+	IsOptional bool   `xml:",attr"`
+	Value      int    `xml:",attr"`
+	Doc        string `xml:"Description"`
+}
+
+func ReadNodeSet(filename string) (*NodeSet, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ns := new(NodeSet)
+	if err := xml.NewDecoder(f).Decode(ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// nodeSetID is a node id of a nodeset with the URI of its namespace
+// instead of the index in the nodeset.
+type nodeSetID struct {
+	uri string
+	id  string // e.g. "i=5001"
+}
+
+// nodeSetTypes converts the data types of a nodeset.
+type nodeSetTypes struct {
+	ns      *NodeSet
+	aliases map[string]string
+	nodes   map[nodeSetID]*NodeSetNode
+	types   map[nodeSetID]*Type
+
+	// enums are the names of the standard enums.
+	enums map[string]bool
+}
+
+// NodeSetTypes returns the enums and the extension objects of the data
+// types of the nodeset which have a definition. The node ids are mapped
+// to the URIs of the NamespaceUris of the nodeset so that the types can
+// be registered independently of the namespace indexes of a server.
+//
+// Fields of standard types are resolved with the binary schema. Fields of
+// types from other nodesets are not supported.
+func NodeSetTypes(ns *NodeSet, dict *TypeDictionary) (enums, objs []Type, err error) {
+	g := &nodeSetTypes{
+		ns:      ns,
+		aliases: map[string]string{},
+		nodes:   map[nodeSetID]*NodeSetNode{},
+		types:   map[nodeSetID]*Type{},
+		enums:   map[string]bool{},
+	}
+	for _, a := range ns.Aliases {
+		g.aliases[a.Alias] = strings.TrimSpace(a.NodeID)
+	}
+	for _, e := range dict.Enums {
+		if e.Namespace == "ua" {
+			g.enums[e.Name] = true
+		}
+	}
+
+	var defs []*NodeSetNode
+	for _, n := range append(ns.DataTypes, ns.Objects...) {
+		nid, err := g.nodeID(n.NodeID)
+		if err != nil {
+			return nil, nil, err
+		}
+		g.nodes[nid] = n
+	}
+
+	// declare all types first since fields can refer to later types
+	for _, n := range ns.DataTypes {
+		if n.Definition == nil {
+			continue
+		}
+		nid, _ := g.nodeID(n.NodeID)
+		t := &Type{
+			Name: goname.Format(stripNamespaceIndex(n.BrowseName)),
+			Doc:  n.Doc,
+			Kind: KindExtensionObject,
+		}
+		base, err := g.base(n)
+		if err != nil {
+			return nil, nil, errors.Errorf("%s: %s", n.BrowseName, err)
+		}
+		if base.uri == uaNamespaceURI {
+			switch base.id {
+			case "i=29": // Enumeration
+				t.Kind, t.Type = KindEnum, "uint32"
+			case "i=3", "i=5", "i=7", "i=9": // Byte, UInt16, UInt32, UInt64
+				if n.Definition.IsOptionSet {
+					t.Kind, t.Type, t.OptionSet = KindEnum, builtins["opc:"+id.Name(nodeSetNumericID(base))], true
+				}
+			}
+		}
+		g.types[nid] = t
+		defs = append(defs, n)
+	}
+
+	for _, n := range defs {
+		nid, _ := g.nodeID(n.NodeID)
+		t := g.types[nid]
+		switch {
+		case t.Kind == KindEnum:
+			g.enumValues(t, n.Definition)
+			enums = append(enums, *t)
+
+		case n.IsAbstract:
+			log.Printf("Skipping abstract data type %s", n.BrowseName)
+
+		default:
+			if err := g.fields(t, n.Definition); err != nil {
+				return nil, nil, errors.Errorf("%s: %s", n.BrowseName, err)
+			}
+			enc, err := g.binaryEncoding(n)
+			if err != nil {
+				return nil, nil, errors.Errorf("%s: %s", n.BrowseName, err)
+			}
+			if enc.id == "" {
+				log.Printf("Data type %s has no binary encoding", n.BrowseName)
+			} else {
+				t.EncodingID = nodeSetNumericID(enc)
+				if t.EncodingID == 0 {
+					return nil, nil, errors.Errorf("%s: unsupported binary encoding id %s", n.BrowseName, enc.id)
+				}
+				if enc.uri != uaNamespaceURI {
+					t.NamespaceURI = enc.uri
+				}
+			}
+			objs = append(objs, *t)
+		}
+	}
+	return enums, objs, nil
+}
+
+// nodeID resolves an alias and returns the node id with the URI of its
+// namespace.
+func (g *nodeSetTypes) nodeID(s string) (nodeSetID, error) {
+	s = strings.TrimSpace(s)
+	if a, ok := g.aliases[s]; ok {
+		s = a
+	}
+	if !strings.HasPrefix(s, "ns=") {
+		return nodeSetID{uaNamespaceURI, s}, nil
+	}
+	p := strings.SplitN(s[len("ns="):], ";", 2)
+	if len(p) != 2 {
+		return nodeSetID{}, errors.Errorf("invalid node id %q", s)
+	}
+	idx, err := strconv.Atoi(p[0])
+	if err != nil || idx < 0 || idx > len(g.ns.NamespaceURIs) {
+		return nodeSetID{}, errors.Errorf("invalid namespace index in node id %q", s)
+	}
+	if idx == 0 {
+		return nodeSetID{uaNamespaceURI, p[1]}, nil
+	}
+	return nodeSetID{g.ns.NamespaceURIs[idx-1], p[1]}, nil
+}
+
+// nodeSetNumericID returns the numeric identifier of the node id or 0.
+func nodeSetNumericID(nid nodeSetID) uint32 {
+	if !strings.HasPrefix(nid.id, "i=") {
+		return 0
+	}
+	n, err := strconv.ParseUint(nid.id[len("i="):], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(n)
+}
+
+// stripNamespaceIndex returns the name of a browse name like "1:Name".
+func stripNamespaceIndex(s string) string {
+	if i := strings.Index(s, ":"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// base returns the data type the data type is a subtype of.
+func (g *nodeSetTypes) base(n *NodeSetNode) (nodeSetID, error) {
+	for _, r := range n.References {
+		if g.referenceType(r) == "i=45" && !r.Forward() { // HasSubtype
+			return g.nodeID(r.Target)
+		}
+	}
+	return nodeSetID{}, errors.New("missing supertype")
+}
+
+// binaryEncoding returns the id of the "Default Binary" encoding of the
+// data type or an empty id.
+func (g *nodeSetTypes) binaryEncoding(n *NodeSetNode) (nodeSetID, error) {
+	for _, r := range n.References {
+		if g.referenceType(r) != "i=38" || !r.Forward() { // HasEncoding
+			continue
+		}
+		nid, err := g.nodeID(r.Target)
+		if err != nil {
+			return nodeSetID{}, err
+		}
+		if enc := g.nodes[nid]; enc != nil && stripNamespaceIndex(enc.BrowseName) == "Default Binary" {
+			return nid, nil
+		}
+	}
+
+	// the reference can also be declared by the encoding object only
+	dt, err := g.nodeID(n.NodeID)
+	if err != nil {
+		return nodeSetID{}, err
+	}
+	for _, o := range g.ns.Objects {
+		if stripNamespaceIndex(o.BrowseName) != "Default Binary" {
+			continue
+		}
+		for _, r := range o.References {
+			if g.referenceType(r) != "i=38" || r.Forward() {
+				continue
+			}
+			if nid, err := g.nodeID(r.Target); err == nil && nid == dt {
+				return g.nodeID(o.NodeID)
+			}
+		}
+	}
+	return nodeSetID{}, nil
+}
+
+// referenceType returns the node id of the type of the reference.
+func (g *nodeSetTypes) referenceType(r *NodeSetReference) string {
+	switch r.ReferenceType {
+	case "HasSubtype":
+		return "i=45"
+	case "HasEncoding":
+		return "i=38"
+	}
+	nid, err := g.nodeID(r.ReferenceType)
+	if err != nil || nid.uri != uaNamespaceURI {
+		return ""
+	}
+	return nid.id
+}
+
+// enumValues adds the values of the enum. The values of the fields of an
+// option set are the numbers of the bits.
+func (g *nodeSetTypes) enumValues(t *Type, def *NodeSetDefinition) {
+	for _, f := range def.Fields {
+		v := Value{
+			Name:      goname.Format(t.Name + f.Name),
+			ShortName: f.Name,
+			Value:     f.Value,
+			Doc:       f.Doc,
+		}
+		if t.OptionSet {
+			v.Value = 1 << uint(f.Value)
+			t.Bits = append(t.Bits, v)
+		}
+		t.Values = append(t.Values, v)
+	}
+}
+
+// fields adds the fields of the structure. Optional fields are encoded
+// if their bit in the EncodingMask is set and the fields of a union if
+// the SwitchField has their position.
+//
+// Specification: Part 6, 5.2.7
+func (g *nodeSetTypes) fields(t *Type, def *NodeSetDefinition) error {
+	// the fields of an option set which is a structure are its bits
+	if def.IsOptionSet {
+		t.Fields = append(t.Fields, Field{Name: "Value", Type: "[]byte"}, Field{Name: "ValidBits", Type: "[]byte"})
+		return nil
+	}
+
+	optional := 0
+	for _, f := range def.Fields {
+		if f.IsOptional {
+			optional++
+		}
+	}
+	switch {
+	case def.IsUnion:
+		t.Fields = append(t.Fields, Field{Name: "SwitchField", Type: "uint32"})
+	case optional > 0:
+		t.Fields = append(t.Fields, Field{Name: "EncodingMask", Type: "uint32"})
+	}
+
+	bit := 0
+	for i, f := range def.Fields {
+		typ, err := g.fieldType(f)
+		if err != nil {
+			return errors.Errorf("field %s: %s", f.Name, err)
+		}
+		of := Field{
+			Name: goname.Format(f.Name),
+			Type: typ,
+			Doc:  f.Doc,
+		}
+		switch {
+		case def.IsUnion:
+			of.SwitchField, of.SwitchValue = "SwitchField", i+1
+		case f.IsOptional:
+			of.SwitchField, of.SwitchBit, of.IsOptional = "EncodingMask", bit, true
+			bit++
+		}
+		t.Fields = append(t.Fields, of)
+	}
+	return nil
+}
+
+// nodeSetSimpleTypes are the standard data types without a structure
+// definition and the built-in types they are encoded as.
+var nodeSetSimpleTypes = map[string]string{
+	"ApplicationInstanceCertificate": "ByteString",
+	"AudioDataType":                  "ByteString",
+	"BaseDataType":                   "Variant",
+	"ContinuationPoint":              "ByteString",
+	"Counter":                        "UInt32",
+	"Date":                           "DateTime",
+	"DateString":                     "String",
+	"DecimalString":                  "String",
+	"Duration":                       "Double",
+	"DurationString":                 "String",
+	"Enumeration":                    "Int32",
+	"Image":                          "ByteString",
+	"ImageBMP":                       "ByteString",
+	"ImageGIF":                       "ByteString",
+	"ImageJPG":                       "ByteString",
+	"ImagePNG":                       "ByteString",
+	"Index":                          "UInt32",
+	"IntegerId":                      "UInt32",
+	"Integer":                        "Variant",
+	"LocaleId":                       "String",
+	"NormalizedString":               "String",
+	"Number":                         "Variant",
+	"NumericRange":                   "String",
+	"SemanticVersionString":          "String",
+	"Structure":                      "ExtensionObject",
+	"Time":                           "String",
+	"TimeString":                     "String",
+	"UInteger":                       "Variant",
+	"UriString":                      "String",
+	"UtcTime":                        "DateTime",
+	"VersionTime":                    "UInt32",
+}
+
+// fieldType returns the Go type of the field.
+func (g *nodeSetTypes) fieldType(f *NodeSetField) (string, error) {
+	dt, err := g.nodeID(f.DataType)
+	if err != nil {
+		return "", err
+	}
+
+	var typ string
+	switch t := g.types[dt]; {
+	case t == nil && dt.uri == uaNamespaceURI:
+		n := nodeSetNumericID(dt)
+		if n == 0 {
+			return "", errors.Errorf("unsupported data type %s", dt.id)
+		}
+		name := id.Name(n)
+		if s, ok := nodeSetSimpleTypes[name]; ok {
+			name = s
+		}
+		sf := &StructField{Type: "ua:" + name, IsEnum: g.enums[name]}
+		if _, ok := builtins["opc:"+name]; ok {
+			sf.Type = "opc:" + name
+		}
+		typ = goFieldType(sf)
+
+	case t == nil:
+		return "", errors.Errorf("unknown data type %s in namespace %s", dt.id, dt.uri)
+
+	case t.Kind == KindEnum:
+		typ = t.Name
+
+	case g.nodes[dt].IsAbstract:
+		// subtypes are only known at runtime
+		typ = qualify("*ExtensionObject")
+
+	default:
+		typ = "*" + t.Name
+	}
+
+	switch f.ValueRank {
+	case "", "-1":
+		return typ, nil
+	case "1":
+		return "[]" + typ, nil
+	default:
+		return "", errors.Errorf("unsupported value rank %s", f.ValueRank)
+	}
+}
+
+// qualify returns the Go type of a standard type with the qualifier of
+// the ua package, e.g. "*ua.NodeID".
+func qualify(typ string) string {
+	if pkg == "ua" {
+		return typ
+	}
+	name := strings.TrimLeft(typ, "[]*")
+	if strings.Contains(name, ".") || strings.ToLower(name[:1]) == name[:1] {
+		return typ
+	}
+	return typ[:len(typ)-len(name)] + "ua." + name
+}
@@ -7,33 +7,59 @@ package main
 import (
 	"encoding/xml"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
 )
 
 type TypeDictionary struct {
-	XMLName xml.Name      `xml:"TypeDictionary"`
-	Types   []*StructType `xml:"StructuredType"`
-	Enums   []*EnumType   `xml:"EnumeratedType"`
+	XMLName         xml.Name      `xml:"TypeDictionary"`
+	TargetNamespace string        `xml:",attr"`
+	Attrs           []xml.Attr    `xml:",any,attr"`
+	Types           []*StructType `xml:"StructuredType"`
+	Enums           []*EnumType   `xml:"EnumeratedType"`
 }
 
 type EnumType struct {
-	Name   string       `xml:",attr"`
-	Bits   int          `xml:"LengthInBits,attr"`
-	Doc    string       `xml:"Documentation"`
-	Values []*EnumValue `xml:"EnumeratedValue"`
+	// Namespace is the prefix of the namespace of the type which is
+	// the same for all dictionaries. See ReadTypes.
+	Namespace string
+
+	Name        string       `xml:",attr"`
+	Bits        int          `xml:"LengthInBits,attr"`
+	IsOptionSet bool         `xml:",attr"`
+	Doc         string       `xml:"Documentation"`
+	Values      []*EnumValue `xml:"EnumeratedValue"`
 }
 
 type EnumValue struct {
 	Name  string `xml:",attr"`
 	Value int    `xml:",attr"`
+	Doc   string `xml:"Documentation"`
+}
+
+// QName returns the qualified name of the enum, e.g. "ua:NodeClass".
+func (e *EnumType) QName() string {
+	return e.Namespace + ":" + e.Name
 }
 
 type StructType struct {
+	// Namespace is the prefix of the namespace of the type which is
+	// the same for all dictionaries. See ReadTypes.
+	Namespace string
+
 	Name     string         `xml:",attr"`
 	BaseType string         `xml:"BaseType,attr"`
 	Doc      string         `xml:"Documentation"`
 	Fields   []*StructField `xml:"Field"`
 }
 
+// QName returns the qualified name of the type, e.g. "ua:ReadRequest".
+func (s *StructType) QName() string {
+	return s.Namespace + ":" + s.Name
+}
+
 func (s *StructType) IsLengthField(f *StructField) bool {
 	for _, ff := range s.Fields {
 		if f.Name == ff.LengthField {
@@ -46,9 +72,11 @@ func (s *StructType) IsLengthField(f *StructField) bool {
 type StructField struct {
 	Name        string `xml:",attr"`
 	Type        string `xml:"TypeName,attr"`
+	Length      int    `xml:",attr"`
 	LengthField string `xml:",attr"`
 	SwitchField string `xml:",attr"`
 	SwitchValue string `xml:",attr"`
+	Doc         string `xml:"Documentation"`
 	IsEnum      bool
 }
 
@@ -56,27 +84,128 @@ func (f *StructField) IsSlice() bool {
 	return f.LengthField != ""
 }
 
-func ReadTypes(filename string) (*TypeDictionary, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// BitLength returns the number of bits of an opc:Bit field.
+func (f *StructField) BitLength() int {
+	if f.Length > 0 {
+		return f.Length
 	}
-	defer f.Close()
+	return 1
+}
 
-	d := new(TypeDictionary)
-	if err := xml.NewDecoder(f).Decode(&d); err != nil {
-		return nil, err
+// Namespace URIs with a fixed prefix.
+const (
+	binarySchemaURI = "http://opcfoundation.org/BinarySchema/"
+	uaURI           = "http://opcfoundation.org/UA/"
+)
+
+// ReadTypes reads the dictionaries and merges them, e.g. the standard
+// types and the types of a companion specification which refer to them.
+//
+// The prefixes of the type names depend on the namespace declarations
+// of a dictionary. They are replaced with prefixes which are the same
+// for all dictionaries: "opc" for the built-in types, "ua" for the
+// standard types and "ns1", "ns2", ... for other namespaces in the order
+// in which they are found.
+func ReadTypes(filenames ...string) (*TypeDictionary, error) {
+	prefixes := map[string]string{binarySchemaURI: "opc", uaURI: "ua"}
+	prefix := func(uri string) string {
+		p, ok := prefixes[uri]
+		if !ok {
+			p = "ns" + strconv.Itoa(len(prefixes)-1)
+			prefixes[uri] = p
+		}
+		return p
+	}
+
+	merged := new(TypeDictionary)
+	for _, filename := range filenames {
+		d, err := readTypes(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// namespaces are the namespace URIs by the prefixes of the file
+		namespaces := map[string]string{}
+		for _, a := range d.Attrs {
+			if a.Name.Space == "xmlns" {
+				namespaces[a.Name.Local] = a.Value
+			}
+		}
+		var qerr error
+		canonical := func(name string) string {
+			if name == "" {
+				return ""
+			}
+			i := strings.Index(name, ":")
+			if i < 0 {
+				qerr = errors.Errorf("%s: missing namespace prefix of %s", filename, name)
+				return name
+			}
+			uri, ok := namespaces[name[:i]]
+			if !ok {
+				qerr = errors.Errorf("%s: unknown namespace prefix of %s", filename, name)
+				return name
+			}
+			return prefix(uri) + name[i:]
+		}
+
+		ns := prefix(d.TargetNamespace)
+		for _, e := range d.Enums {
+			e.Namespace = ns
+		}
+		for _, t := range d.Types {
+			t.Namespace = ns
+			t.BaseType = canonical(t.BaseType)
+			for _, f := range t.Fields {
+				f.Type = canonical(f.Type)
+			}
+		}
+		if qerr != nil {
+			return nil, qerr
+		}
+		merged.Types = append(merged.Types, d.Types...)
+		merged.Enums = append(merged.Enums, d.Enums...)
 	}
 
 	enums := map[string]bool{}
-	for _, e := range d.Enums {
-		enums["tns:"+e.Name] = true
+	for _, e := range merged.Enums {
+		enums[e.QName()] = true
 	}
 
-	for _, t := range d.Types {
+	for _, t := range merged.Types {
 		for _, f := range t.Fields {
 			f.IsEnum = enums[f.Type]
 		}
 	}
+	return merged, nil
+}
+
+// HasCompanionTypes returns true if the dictionary contains types which
+// are not standard types.
+func (d *TypeDictionary) HasCompanionTypes() bool {
+	for _, e := range d.Enums {
+		if e.Namespace != "ua" {
+			return true
+		}
+	}
+	for _, t := range d.Types {
+		if t.Namespace != "ua" {
+			return true
+		}
+	}
+	return false
+}
+
+func readTypes(filename string) (*TypeDictionary, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := new(TypeDictionary)
+	if err := xml.NewDecoder(f).Decode(&d); err != nil {
+		return nil, err
+	}
 	return d, nil
 }
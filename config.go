@@ -5,6 +5,7 @@
 package opcua
 
 import (
+	"context"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -12,11 +13,14 @@ import (
 	"log"
 	"math/rand"
 	"net"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacert"
 	"github.com/zzylovesll/myOpcUa/uacp"
 	"github.com/zzylovesll/myOpcUa/uapolicy"
 	"github.com/zzylovesll/myOpcUa/uasc"
@@ -56,7 +60,123 @@ type Config struct {
 	dialer  *uacp.Dialer
 	sechan  *uasc.Config
 	session *uasc.SessionConfig
-	err     error
+	stateCh chan<- ConnState
+
+	// stateFunc is called for every connection state change.
+	stateFunc func(from, to ConnState)
+
+	// waitReconnect makes requests wait for a lost connection
+	// to be restored instead of failing with ErrReconnecting.
+	waitReconnect bool
+
+	// maxNodesPerRead limits the number of nodes in a single read request.
+	maxNodesPerRead uint32
+
+	// maxNodesPerWrite limits the number of nodes in a single write
+	// request of WriteBatched.
+	maxNodesPerWrite uint32
+
+	// fetchOperationLimits reads the operation limits of the server
+	// when the client connects.
+	fetchOperationLimits bool
+
+	// batchSize is the number of nodes per request for ReadBatched and
+	// WriteBatched if there are no limits.
+	batchSize int
+
+	// batchConcurrency is the maximum number of requests that are sent
+	// in parallel for a split request.
+	batchConcurrency int
+
+	// dataTypeCacheSize is the maximum number of nodes for which
+	// WriteValue caches the data type.
+	dataTypeCacheSize int
+
+	// autoRegisterThreshold is the number of reads after which a node
+	// is registered automatically. 0 disables automatic registration.
+	autoRegisterThreshold int
+
+	// strictMethodArgs disables the conversion of method arguments.
+	strictMethodArgs bool
+
+	// certVerifier validates the server certificate when the session
+	// is created. The certificate is not validated if it is nil.
+	certVerifier uacert.Verifier
+
+	// verifyHostname checks that the server certificate is valid for
+	// the host of the endpoint url.
+	verifyHostname bool
+
+	// insecureSkipVerify disables the validation of the server
+	// certificate even if a verifier is configured.
+	insecureSkipVerify bool
+
+	// issuedTokenType is the IssuedTokenType of the user token policy
+	// for an issued token. Any issued token policy matches if it is
+	// empty.
+	issuedTokenType string
+
+	// issuedTokenFunc returns a fresh issued token before the current
+	// token expires.
+	issuedTokenFunc IssuedTokenFunc
+
+	// reverseListener provides the connections of servers which
+	// connect to the client. The client dials the endpoint if it is nil.
+	reverseListener *ReverseListener
+
+	// reverseServerURI is the ServerURI of the server which connects
+	// to the client.
+	reverseServerURI string
+
+	// endpointSelection orders the endpoints of the server which the
+	// client tries when it connects. The endpoints are not discovered
+	// if it is EndpointSelectionOff.
+	endpointSelection EndpointSelectionPolicy
+
+	// endpointFilter selects the endpoints which the client may use.
+	// All endpoints with a supported security policy are used if it
+	// is nil.
+	endpointFilter func(*ua.EndpointDescription) bool
+
+	// publishRequests is the number of PublishRequests which the
+	// client keeps outstanding. The number of subscriptions + 1 is
+	// used if it is 0.
+	publishRequests int
+
+	// timestampsToReturn selects the timestamps of the values which
+	// the nodes of the client read. ua.TimestampsToReturnBoth is used
+	// if it is nil.
+	timestampsToReturn *ua.TimestampsToReturn
+
+	// logger logs the events of the client, the secure channel and the
+	// connection. logger.Default is used if it is nil.
+	logger logger.Logger
+
+	// metricsHook receives the events which update the statistics of
+	// the client.
+	metricsHook MetricsHook
+
+	// keepaliveInterval is the time without a successful request after
+	// which the client reads the status of the server. The keepalive is
+	// disabled if it is 0.
+	keepaliveInterval time.Duration
+
+	// serverStatusFunc is called when the status of the server which
+	// the keepalive has read changes.
+	serverStatusFunc func(ServerStatus)
+
+	// autoCert generates the application instance certificate after
+	// all options have been applied. No certificate is generated if it
+	// is nil.
+	autoCert *autoCertConfig
+
+	err error
+}
+
+// autoCertConfig contains the options of AutoGenerateCert.
+type autoCertConfig struct {
+	certFile, keyFile string
+	keySize           int
 }
 
 func (cfg *Config) setError(err error) {
@@ -90,6 +210,11 @@ func ApplyConfig(opts ...Option) *Config {
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	// the certificate is generated for the application uri of all
+	// options independent of their order.
+	if cfg.autoCert != nil {
+		cfg.generateCert()
+	}
 	return cfg
 }
 
@@ -124,13 +249,176 @@ func ReconnectInterval(d time.Duration) Option {
 	}
 }
 
+// StateChangedCh sets the channel for receiving client connection state
+// changes. The client does not block when sending to the channel and drops
+// the state change if the channel is full.
+func StateChangedCh(ch chan<- ConnState) Option {
+	return func(cfg *Config) {
+		cfg.stateCh = ch
+	}
+}
+
+// StateChangedFunc sets a function which is called for every client
+// connection state change with the previous and the new state. A restored
+// connection is reported as a change from Reconnecting to Connected.
+//
+// The function is called synchronously from the connection handling and
+// must not block.
+func StateChangedFunc(f func(from, to ConnState)) Option {
+	return func(cfg *Config) {
+		cfg.stateFunc = f
+	}
+}
+
+// ReconnectBackoff enables an exponential backoff for the reconnection
+// attempts. The interval between the attempts starts with the
+// ReconnectInterval and doubles after every failed attempt until it
+// reaches max.
+func ReconnectBackoff(max time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.sechan.ReconnectMaxInterval = max
+	}
+}
+
+// WaitForReconnect sets the policy for requests which are sent while
+// the client is restoring a lost connection. If b is true the requests
+// wait until the connection has been restored or their context is done.
+// Otherwise, they fail immediately with ErrReconnecting which is
+// the default.
+//
+// Requests which have already been sent when the connection is lost are
+// only sent again if they are read-only like Read and Browse. Other
+// requests like Write and Call fail with ErrResponseLost since the server
+// may have executed them.
+func WaitForReconnect(b bool) Option {
+	return func(cfg *Config) {
+		cfg.waitReconnect = b
+	}
+}
+
 // Lifetime sets the lifetime of the secure channel in milliseconds.
+//
+// Deprecated: Use SecureChannelLifetime which validates the lifetime.
 func Lifetime(d time.Duration) Option {
 	return func(cfg *Config) {
 		cfg.sechan.Lifetime = uint32(d / time.Millisecond)
 	}
 }
 
+// SecureChannelLifetime sets the requested lifetime of the security
+// token of the secure channel. The server may grant a shorter lifetime
+// which is returned by Client.SecureChannelLifetime. The client renews
+// the channel after 75% of the lifetime has elapsed. The lifetime must
+// be between 1ms and uasc.MaxTimeout.
+func SecureChannelLifetime(d time.Duration) Option {
+	return func(cfg *Config) {
+		if d < time.Millisecond || d > uasc.MaxTimeout {
+			cfg.setError(errors.Errorf("invalid secure channel lifetime %s", d))
+			return
+		}
+		cfg.sechan.Lifetime = uint32(d / time.Millisecond)
+	}
+}
+
+// StrictMethodArguments sets whether CallMethod validates the arguments
+// strictly. If b is true the values must already have the data types of
+// the InputArguments of the method and are not converted. Methods
+// without InputArguments cannot be called with arguments. The default
+// is false.
+func StrictMethodArguments(b bool) Option {
+	return func(cfg *Config) {
+		cfg.strictMethodArgs = b
+	}
+}
+
+// SecureChannelRenewalFunc sets a function which is called after every
+// attempt to renew the security token of the secure channel. A failed
+// renewal is retried until the token expires. Then the connection is
+// restored with a new secure channel if AutoReconnect is enabled.
+//
+// The function is called synchronously from the renewal and must not
+// block.
+func SecureChannelRenewalFunc(f func(uasc.RenewalEvent)) Option {
+	return func(cfg *Config) {
+		cfg.sechan.RenewalFunc = f
+	}
+}
+
+// RequestTracer sets a function which is called after every request with
+// the service, the request handle, the duration, the status and the size
+// of the request and the response, e.g. to record metrics or traces.
+//
+// The function is called synchronously by the caller of the request and
+// must not block.
+func RequestTracer(f func(uasc.RequestInfo)) Option {
+	return func(cfg *Config) {
+		cfg.sechan.RequestTracer = f
+	}
+}
+
+// Logger sets the logger for the events of the client, its secure channel
+// and its connection, e.g. connection state changes, secure channel
+// renewals and publish errors. The events are printed with the debug
+// logger if OPC_DEBUG=debug is set and discarded otherwise by default.
+// Use logger.Slog to log with a log/slog logger and logger.Nop to discard
+// all events.
+func Logger(l logger.Logger) Option {
+	return func(cfg *Config) {
+		cfg.logger = l
+		cfg.sechan.Logger = l
+	}
+}
+
+// WithTracer sets a tracer which starts a span for every service call of
+// the client. The span is named after the service, e.g. "Read", and is a
+// child of the span in the context of the call. It has the attributes
+// opcua.service, opcua.node_count and opcua.status_code and the status of
+// the span is set for a failed call.
+//
+// Use uaotel.WithTracer from the github.com/zzylovesll/myOpcUa/uaotel
+// module to trace the calls with an OpenTelemetry trace.Tracer.
+func WithTracer(t uasc.Tracer) Option {
+	return func(cfg *Config) {
+		cfg.sechan.Tracer = t
+	}
+}
+
+// Metrics sets a hook which receives the events that update the
+// statistics of the client and its subscriptions, e.g. to record them as
+// Prometheus metrics. Client.Stats and Subscription.NotificationStats
+// return the statistics without a hook.
+func Metrics(h MetricsHook) Option {
+	return func(cfg *Config) {
+		cfg.metricsHook = h
+	}
+}
+
+// SessionKeepalive enables a keepalive which reads the state of the
+// server when the client has not sent a successful request for the
+// duration d. Client.ServerStatus returns the last state which has been
+// read. The client reconnects after three consecutive failed keepalive
+// requests.
+//
+// The keepalive is disabled by default.
+func SessionKeepalive(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.keepaliveInterval = d
+	}
+}
+
+// ServerStatusFunc sets a function which is called when the session
+// keepalive reads a new status of the server, e.g. when the server
+// enters the Shutdown state. It is called for the first status after
+// the client has connected, too.
+//
+// The function is called synchronously from the keepalive and must not
+// block.
+func ServerStatusFunc(f func(ServerStatus)) Option {
+	return func(cfg *Config) {
+		cfg.serverStatusFunc = f
+	}
+}
+
 // Locales sets the locales in the session configuration.
 func Locales(locale ...string) Option {
 	return func(cfg *Config) {
@@ -183,6 +471,49 @@ func RemoteCertificateFile(filename string) Option {
 	}
 }
 
+// ServerCertificateTrust validates the server certificate against the
+// trust list in the given directories when the session is created.
+// See uacert.LoadTrustList for the format of the directories.
+//
+// Validation errors can be checked with errors.Is against the ErrXXX
+// values of the uacert package, e.g. uacert.ErrCertUntrusted.
+func ServerCertificateTrust(trustedDir, issuersDir, crlDir string) Option {
+	return func(cfg *Config) {
+		l, err := uacert.LoadTrustList(trustedDir, issuersDir, crlDir)
+		if err != nil {
+			cfg.setError(err)
+			return
+		}
+		cfg.certVerifier = l
+	}
+}
+
+// ServerCertificateVerifier validates the server certificate with v
+// when the session is created.
+func ServerCertificateVerifier(v uacert.Verifier) Option {
+	return func(cfg *Config) {
+		cfg.certVerifier = v
+	}
+}
+
+// VerifyServerHostname checks that the server certificate is valid for
+// the host of the endpoint url in addition to the checks of the
+// certificate verifier.
+func VerifyServerHostname(b bool) Option {
+	return func(cfg *Config) {
+		cfg.verifyHostname = b
+	}
+}
+
+// InsecureSkipVerify disables the validation of the server certificate,
+// e.g. for commissioning a server whose certificate is not yet trusted.
+// This makes the connection vulnerable to man-in-the-middle attacks.
+func InsecureSkipVerify() Option {
+	return func(cfg *Config) {
+		cfg.insecureSkipVerify = true
+	}
+}
+
 // SecurityMode sets the security mode for the secure channel.
 func SecurityMode(m ua.MessageSecurityMode) Option {
 	return func(cfg *Config) {
@@ -191,10 +522,20 @@ func SecurityMode(m ua.MessageSecurityMode) Option {
 }
 
 // SecurityModeString sets the security mode for the secure channel.
-// Valid values are "None", "Sign", and "SignAndEncrypt".
+// Valid values are "None", "Sign", and "SignAndEncrypt" in any case. An
+// empty string sets no security mode.
 func SecurityModeString(s string) Option {
 	return func(cfg *Config) {
-		cfg.sechan.SecurityMode = ua.MessageSecurityModeFromString(s)
+		if s == "" {
+			cfg.sechan.SecurityMode = ua.MessageSecurityModeInvalid
+			return
+		}
+		m, err := ua.MessageSecurityModeFromString(s)
+		if err != nil {
+			cfg.setError(err)
+			return
+		}
+		cfg.sechan.SecurityMode = m
 	}
 }
 
@@ -248,15 +589,34 @@ func loadPrivateKey(filename string) (*rsa.PrivateKey, error) {
 		return nil, errors.Errorf("Failed to load private key: %s", err)
 	}
 
-	derBytes := b
 	if strings.HasSuffix(filename, ".pem") {
-		block, _ := pem.Decode(b)
-		if block == nil || block.Type != "RSA PRIVATE KEY" {
-			return nil, errors.Errorf("Failed to decode PEM block with private key")
+		return parsePrivateKeyPEM(b)
+	}
+	return parsePrivateKey(b)
+}
+
+// PrivateKeyBytes sets the RSA private key in the secure channel
+// configuration from PEM encoded data, e.g. from an environment variable.
+func PrivateKeyBytes(b []byte) Option {
+	return func(cfg *Config) {
+		key, err := parsePrivateKeyPEM(b)
+		if err != nil {
+			cfg.setError(err)
+			return
 		}
-		derBytes = block.Bytes
+		cfg.sechan.LocalKey = key
 	}
+}
 
+func parsePrivateKeyPEM(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, errors.Errorf("Failed to decode PEM block with private key")
+	}
+	return parsePrivateKey(block.Bytes)
+}
+
+func parsePrivateKey(derBytes []byte) (*rsa.PrivateKey, error) {
 	pk, err := x509.ParsePKCS1PrivateKey(derBytes)
 	if err != nil {
 		return nil, errors.Errorf("Failed to parse private key: %s", err)
@@ -299,7 +659,78 @@ func loadCertificate(filename string) ([]byte, error) {
 	if !strings.HasSuffix(filename, ".pem") {
 		return b, nil
 	}
+	return parseCertificatePEM(b)
+}
 
+// CertificateBytes sets the client X509 certificate in the secure channel
+// configuration from PEM encoded data, e.g. from an environment variable.
+// It also detects and sets the ApplicationURI from the URI within the
+// certificate.
+func CertificateBytes(b []byte) Option {
+	return func(cfg *Config) {
+		cert, err := parseCertificatePEM(b)
+		if err != nil {
+			cfg.setError(err)
+			return
+		}
+		if _, err := x509.ParseCertificate(cert); err != nil {
+			cfg.setError(errors.Errorf("Failed to parse certificate: %s", err))
+			return
+		}
+		setCertificate(cert, cfg)
+	}
+}
+
+// AutoGenerateCert sets a self-signed application instance certificate
+// and private key for the application uri of the session configuration.
+// The certificate is generated after all options have been applied so
+// that it uses the uri of the ApplicationURI option. It is valid for
+// localhost and the host name.
+//
+// The certificate and key are loaded from certFile and keyFile. If the
+// files do not exist they are generated with keySize bits and written to
+// the files so that servers which trust the certificate keep trusting it
+// after a restart. The files are replaced if the certificate has expired
+// or was issued for a different application uri. If both file names are
+// empty the certificate is only kept in memory. keySize must be 2048,
+// 3072 or 4096 and defaults to uacert.KeySize if it is 0.
+func AutoGenerateCert(certFile, keyFile string, keySize int) Option {
+	return func(cfg *Config) {
+		if keySize == 0 {
+			keySize = uacert.KeySize
+		}
+		cfg.autoCert = &autoCertConfig{certFile: certFile, keyFile: keyFile, keySize: keySize}
+	}
+}
+
+// generateCert loads or generates the certificate of AutoGenerateCert.
+func (cfg *Config) generateCert() {
+	hosts := []string{"localhost"}
+	if h, err := os.Hostname(); err == nil && h != "localhost" {
+		hosts = append(hosts, h)
+	}
+
+	a := cfg.autoCert
+	certPEM, keyPEM, err := uacert.LoadOrGenerateCert(a.certFile, a.keyFile, cfg.session.ClientDescription.ApplicationURI, hosts, uacert.DefaultValidity, a.keySize)
+	if err != nil {
+		cfg.setError(err)
+		return
+	}
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		cfg.setError(err)
+		return
+	}
+	cert, err := parseCertificatePEM(certPEM)
+	if err != nil {
+		cfg.setError(err)
+		return
+	}
+	cfg.sechan.LocalKey = key
+	setCertificate(cert, cfg)
+}
+
+func parseCertificatePEM(b []byte) ([]byte, error) {
 	block, _ := pem.Decode(b)
 	if block == nil || block.Type != "CERTIFICATE" {
 		return nil, errors.Errorf("Failed to decode PEM block with certificate")
@@ -365,6 +796,94 @@ func SecurityFromEndpoint(ep *ua.EndpointDescription, authType ua.UserTokenType)
 	}
 }
 
+// EndpointSelectionPolicy defines the order in which the client tries the
+// endpoints of the server.
+type EndpointSelectionPolicy int
+
+const (
+	// EndpointSelectionOff connects to the endpoint url of the client
+	// with the configured security settings.
+	EndpointSelectionOff EndpointSelectionPolicy = iota
+
+	// EndpointSelectionStrongest tries the endpoints with the strongest
+	// security first. The order is the same as for SelectEndpoint.
+	EndpointSelectionStrongest
+
+	// EndpointSelectionSecurityLevel tries the endpoints with the highest
+	// security level assigned by the server first.
+	EndpointSelectionSecurityLevel
+
+	// EndpointSelectionServerOrder tries the endpoints in the order in
+	// which the server returned them.
+	EndpointSelectionServerOrder
+)
+
+// EndpointSelection makes the client discover the endpoints of the server
+// with GetEndpoints when it connects and try them in the order of the
+// policy until the secure channel can be opened. The client uses the
+// security policy, the security mode and the certificate of the endpoint.
+//
+// Servers often return endpoint urls with host names which cannot be
+// resolved by the client. If the endpoint url cannot be dialed the client
+// retries with the host and port of the url it was created with.
+func EndpointSelection(policy EndpointSelectionPolicy) Option {
+	return func(cfg *Config) {
+		cfg.endpointSelection = policy
+	}
+}
+
+// SelectEndpointFilter sets the function which selects the endpoints the
+// client may connect to. It enables EndpointSelectionStrongest unless
+// another endpoint selection policy is set.
+func SelectEndpointFilter(f func(*ua.EndpointDescription) bool) Option {
+	return func(cfg *Config) {
+		cfg.endpointFilter = f
+		if cfg.endpointSelection == EndpointSelectionOff {
+			cfg.endpointSelection = EndpointSelectionStrongest
+		}
+	}
+}
+
+// PublishRequests sets the number of PublishRequests which the client
+// keeps outstanding so that the server can send notifications without
+// waiting for the next request. The default is the number of
+// subscriptions + 1 as recommended by the specification.
+//
+// See Part 4, 5.13.5
+func PublishRequests(n int) Option {
+	return func(cfg *Config) {
+		cfg.publishRequests = n
+	}
+}
+
+func policyID(t interface{}) string {
+	switch tok := t.(type) {
+	case *ua.AnonymousIdentityToken:
+		return tok.PolicyID
+	case *ua.UserNameIdentityToken:
+		return tok.PolicyID
+	case *ua.X509IdentityToken:
+		return tok.PolicyID
+	case *ua.IssuedIdentityToken:
+		return tok.PolicyID
+	default:
+		return ""
+	}
+}
+
+func tokenType(t interface{}) ua.UserTokenType {
+	switch t.(type) {
+	case *ua.UserNameIdentityToken:
+		return ua.UserTokenTypeUserName
+	case *ua.X509IdentityToken:
+		return ua.UserTokenTypeCertificate
+	case *ua.IssuedIdentityToken:
+		return ua.UserTokenTypeIssuedToken
+	default:
+		return ua.UserTokenTypeAnonymous
+	}
+}
+
 func setPolicyID(t interface{}, policy string) {
 	switch tok := t.(type) {
 	case *ua.AnonymousIdentityToken:
@@ -413,8 +932,10 @@ func AuthAnonymous() Option {
 }
 
 // AuthUsername sets the client's authentication username and password
-// Note: PolicyID still needs to be set outside of this method, typically through
-// the SecurityFromEndpoint() Option
+// The password is encrypted with the security policy of the user token
+// policy. The PolicyID is selected from the server endpoints when the session
+// is created unless it is set with the SecurityFromEndpoint() or AuthPolicyID()
+// options.
 func AuthUsername(user, pass string) Option {
 	return func(cfg *Config) {
 		if cfg.session.UserIdentityToken == nil {
@@ -434,8 +955,10 @@ func AuthUsername(user, pass string) Option {
 }
 
 // AuthCertificate sets the client's authentication X509 certificate
-// Note: PolicyID still needs to be set outside of this method, typically through
-// the SecurityFromEndpoint() Option
+// Use AuthPrivateKey to set the private key of the certificate which
+// signs the user token. The PolicyID is selected from the server endpoints
+// when the session is created unless it is set with the SecurityFromEndpoint()
+// or AuthPolicyID() options.
 func AuthCertificate(cert []byte) Option {
 	return func(cfg *Config) {
 		if cfg.session.UserIdentityToken == nil {
@@ -453,10 +976,30 @@ func AuthCertificate(cert []byte) Option {
 	}
 }
 
-// AuthIssuedToken sets the client's authentication data based on an externally-issued token
-// Note: PolicyID still needs to be set outside of this method, typically through
-// the SecurityFromEndpoint() Option
-func AuthIssuedToken(tokenData []byte) Option {
+// AuthPrivateKey sets the private key of the certificate of the
+// AuthCertificate option. The server certificate and nonce are signed with
+// this key to prove the possession of the user certificate. The private
+// key of the application instance certificate is only used if it is not
+// set and the user certificate is the application instance certificate.
+// Otherwise, the activation of the session fails.
+func AuthPrivateKey(key *rsa.PrivateKey) Option {
+	return func(cfg *Config) {
+		cfg.session.AuthPrivateKey = key
+	}
+}
+
+// AuthIssuedToken sets the client's authentication data to an externally
+// issued token, e.g. a JWT from an OAuth2 authorization server.
+//
+// If encryptionAlgorithm is empty the token is encrypted with the server
+// certificate when the security policy of the user token policy requires
+// it. Otherwise, the token is already encrypted with encryptionAlgorithm
+// and is sent unchanged.
+//
+// The PolicyID is selected from the server endpoints when the session is
+// created. Use AuthIssuedTokenType to select the policy for a specific
+// token type.
+func AuthIssuedToken(tokenData []byte, encryptionAlgorithm string) Option {
 	return func(cfg *Config) {
 		if cfg.session.UserIdentityToken == nil {
 			cfg.session.UserIdentityToken = &ua.IssuedIdentityToken{}
@@ -468,18 +1011,173 @@ func AuthIssuedToken(tokenData []byte) Option {
 			return
 		}
 
-		// todo(dw): not correct; need to read spec
+		if encryptionAlgorithm == "" {
+			t.TokenData = nil
+			t.EncryptionAlgorithm = ""
+			cfg.session.AuthIssuedToken = tokenData
+			return
+		}
 		t.TokenData = tokenData
+		t.EncryptionAlgorithm = encryptionAlgorithm
+		cfg.session.AuthIssuedToken = nil
+	}
+}
+
+// AuthIssuedTokenType selects the user token policy for an issued token
+// by its IssuedTokenType, e.g. ua.IssuedTokenTypeJWT.
+func AuthIssuedTokenType(uri string) Option {
+	return func(cfg *Config) {
+		cfg.issuedTokenType = uri
+	}
+}
+
+// IssuedTokenFunc returns an unencrypted issued token and the time
+// at which it expires. A zero expiry time means that the token does
+// not expire.
+type IssuedTokenFunc func(ctx context.Context) (token []byte, expiry time.Time, err error)
+
+// AuthIssuedTokenFunc authenticates the client with tokens returned by f.
+// The first token is fetched when the client connects. Before the token
+// expires the client fetches a new token and activates the session again
+// with it so that the session is kept. Failed refreshes are retried until
+// the client is closed.
+func AuthIssuedTokenFunc(f IssuedTokenFunc) Option {
+	return func(cfg *Config) {
+		if cfg.session.UserIdentityToken == nil {
+			cfg.session.UserIdentityToken = &ua.IssuedIdentityToken{}
+		}
+
+		if _, ok := cfg.session.UserIdentityToken.(*ua.IssuedIdentityToken); !ok {
+			log.Printf("non-issued token authentication already configured, ignoring")
+			return
+		}
+		cfg.issuedTokenFunc = f
+	}
+}
+
+// MaxNodesPerRead limits the number of nodes the client reads with a single
+// read request. Larger read requests are split into multiple requests
+// and the results are merged in the original order.
+//
+// If the server rejects a read request with StatusBadTooManyOperations the
+// client reads the MaxNodesPerRead operation limit of the server and splits
+// the request accordingly even if this option is not set.
+func MaxNodesPerRead(n uint32) Option {
+	return func(cfg *Config) {
+		cfg.maxNodesPerRead = n
+	}
+}
+
+// DataTypeCacheSize sets the maximum number of nodes for which WriteValue
+// caches the data type and value rank. The least recently used entries
+// are evicted when the cache is full. A negative size disables the cache.
+// The default is DefaultDataTypeCacheSize.
+func DataTypeCacheSize(n int) Option {
+	return func(cfg *Config) {
+		cfg.dataTypeCacheSize = n
+	}
+}
+
+// AutoRegisterNodes makes the client register nodes which are read more
+// than n times with the server and use the registered node ids in
+// subsequent read requests. Servers can access registered nodes more
+// efficiently which helps when the same nodes are polled frequently.
+// The registered nodes are registered again when the session is
+// recreated. A value of 0 disables automatic registration which is the
+// default.
+func AutoRegisterNodes(n int) Option {
+	return func(cfg *Config) {
+		cfg.autoRegisterThreshold = n
+	}
+}
+
+// MaxNodesPerWrite limits the number of nodes the client writes with a
+// single write request in WriteBatched.
+func MaxNodesPerWrite(n uint32) Option {
+	return func(cfg *Config) {
+		cfg.maxNodesPerWrite = n
+	}
+}
+
+// FetchOperationLimits makes the client read the MaxNodesPerRead and
+// MaxNodesPerWrite operation limits of the server when it connects so
+// that large requests are split before they are sent.
+func FetchOperationLimits(b bool) Option {
+	return func(cfg *Config) {
+		cfg.fetchOperationLimits = b
+	}
+}
+
+// BatchSize sets the number of nodes per request for ReadBatched and
+// WriteBatched if neither the client nor the server limit the number of
+// nodes per request. The default is DefaultBatchSize.
+func BatchSize(n int) Option {
+	return func(cfg *Config) {
+		cfg.batchSize = n
+	}
+}
+
+// BatchConcurrency sets the maximum number of requests which are sent in
+// parallel when a read or write request is split. The default is 1.
+func BatchConcurrency(n int) Option {
+	return func(cfg *Config) {
+		cfg.batchConcurrency = n
 	}
 }
 
-// RequestTimeout sets the timeout for all requests over SecureChannel
+// RequestTimeout sets the timeout for all requests over SecureChannel.
+// Requests with a context with a deadline use the deadline instead.
 func RequestTimeout(t time.Duration) Option {
 	return func(cfg *Config) {
 		cfg.sechan.RequestTimeout = t
 	}
 }
 
+// ReturnDiagnostics sets the ReturnDiagnostics mask of all requests so
+// that the server returns the selected diagnostic infos, e.g.
+// ua.ReturnDiagnosticsAll. Use DiagnosticInfo.Resolve with the StringTable
+// of the ResponseHeader to get a readable message.
+func ReturnDiagnostics(mask uint32) Option {
+	return func(cfg *Config) {
+		cfg.sechan.ReturnDiagnostics = mask
+	}
+}
+
+// DecodeLimits sets the limits for decoding the responses of the server,
+// e.g. the maximum length of arrays and strings. The zero fields of
+// limits use the value of ua.DefaultDecodeLimits. Responses which
+// exceed a limit fail with ua.StatusBadEncodingLimitsExceeded.
+func DecodeLimits(limits ua.DecodeLimits) Option {
+	return func(cfg *Config) {
+		cfg.sechan.DecodeLimits = limits
+	}
+}
+
+// TimestampsToReturn sets the timestamps which the server returns for the
+// values which are read with the methods of a Node, e.g. Node.Attributes.
+// Servers which are slow to compute the server timestamp answer faster
+// with ua.TimestampsToReturnSource. The default is
+// ua.TimestampsToReturnBoth.
+func TimestampsToReturn(ts ua.TimestampsToReturn) Option {
+	return func(cfg *Config) {
+		cfg.timestampsToReturn = &ts
+	}
+}
+
+// ReverseConnect makes the client wait for a reverse connection of the
+// server with the given ServerURI on l instead of dialing the endpoint.
+// The endpoint of the client must be the EndpointURL which the server
+// sends in its ReverseHello message.
+//
+// When the connection is lost the client waits for the next connection
+// of the server if AutoReconnect is enabled.
+func ReverseConnect(l *ReverseListener, serverURI string) Option {
+	return func(cfg *Config) {
+		cfg.reverseListener = l
+		cfg.reverseServerURI = serverURI
+	}
+}
+
 // Dialer sets the uacp.Dialer to establish the connection to the server.
 func Dialer(d *uacp.Dialer) Option {
 	return func(cfg *Config) {
@@ -537,6 +1235,8 @@ func initDialer(cfg *Config) {
 		cfg.dialer.Dialer = &net.Dialer{}
 	}
 	if cfg.dialer.ClientACK == nil {
-		cfg.dialer.ClientACK = uacp.DefaultClientACK
+		// copy the defaults since the options modify the values
+		ack := *uacp.DefaultClientACK
+		cfg.dialer.ClientACK = &ack
 	}
 }
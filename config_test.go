@@ -3,6 +3,7 @@ package opcua
 import (
 	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
@@ -218,19 +219,50 @@ func TestOptions(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name: `AuthPrivateKey()`,
+			opt:  AuthPrivateKey(cert.PrivateKey.(*rsa.PrivateKey)),
+			cfg: &Config{
+				session: func() *uasc.SessionConfig {
+					sc := DefaultSessionConfig()
+					sc.AuthPrivateKey = cert.PrivateKey.(*rsa.PrivateKey)
+					return sc
+				}(),
+			},
+		},
 		{
 			name: `AuthIssuedToken()`,
-			opt:  AuthIssuedToken([]byte("a")),
+			opt:  AuthIssuedToken([]byte("a"), ""),
+			cfg: &Config{
+				session: func() *uasc.SessionConfig {
+					sc := DefaultSessionConfig()
+					sc.UserIdentityToken = &ua.IssuedIdentityToken{}
+					sc.AuthIssuedToken = []byte("a")
+					return sc
+				}(),
+			},
+		},
+		{
+			name: `AuthIssuedToken(encrypted)`,
+			opt:  AuthIssuedToken([]byte("a"), "alg"),
 			cfg: &Config{
 				session: func() *uasc.SessionConfig {
 					sc := DefaultSessionConfig()
 					sc.UserIdentityToken = &ua.IssuedIdentityToken{
-						TokenData: []byte("a"),
+						TokenData:           []byte("a"),
+						EncryptionAlgorithm: "alg",
 					}
 					return sc
 				}(),
 			},
 		},
+		{
+			name: `AuthIssuedTokenType()`,
+			opt:  AuthIssuedTokenType(ua.IssuedTokenTypeJWT),
+			cfg: &Config{
+				issuedTokenType: ua.IssuedTokenTypeJWT,
+			},
+		},
 		{
 			name: `AuthUsername()`,
 			opt:  AuthUsername("user", "pass"),
@@ -302,6 +334,31 @@ func TestOptions(t *testing.T) {
 				err: notFoundError("certificate", "x"),
 			},
 		},
+		{
+			name: `CertificateBytes(pem)`,
+			opt:  CertificateBytes(certPEM),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.Certificate = certDER
+					return c
+				}(),
+			},
+		},
+		{
+			name: `CertificateBytes() error`,
+			opt:  CertificateBytes(certDER),
+			cfg: &Config{
+				err: fmt.Errorf("opcua: Failed to decode PEM block with certificate"),
+			},
+		},
+		{
+			name: `CertificateBytes() invalid certificate`,
+			opt:  CertificateBytes([]byte("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n")),
+			cfg: &Config{
+				err: fmt.Errorf("opcua: Failed to parse certificate: x509: malformed certificate"),
+			},
+		},
 		{
 			name: `Lifetime(10ms)`,
 			opt:  Lifetime(10 * time.Millisecond),
@@ -364,6 +421,24 @@ func TestOptions(t *testing.T) {
 				err: notFoundError("private key", "x"),
 			},
 		},
+		{
+			name: `PrivateKeyBytes(pem)`,
+			opt:  PrivateKeyBytes(keyPEM),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.LocalKey = cert.PrivateKey.(*rsa.PrivateKey)
+					return c
+				}(),
+			},
+		},
+		{
+			name: `PrivateKeyBytes() error`,
+			opt:  PrivateKeyBytes(certPEM),
+			cfg: &Config{
+				err: fmt.Errorf("opcua: Failed to decode PEM block with private key"),
+			},
+		},
 		{
 			name: `ProductURI("a")`,
 			opt:  ProductURI("a"),
@@ -448,6 +523,46 @@ func TestOptions(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name: `ReturnDiagnostics(ua.ServiceLevelAll)`,
+			opt:  ReturnDiagnostics(ua.ServiceLevelAll),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.ReturnDiagnostics = ua.ServiceLevelAll
+					return c
+				}(),
+			},
+		},
+		{
+			name: `DecodeLimits(ua.DecodeLimits{MaxArrayLength: 10})`,
+			opt:  DecodeLimits(ua.DecodeLimits{MaxArrayLength: 10}),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.DecodeLimits = ua.DecodeLimits{MaxArrayLength: 10}
+					return c
+				}(),
+			},
+		},
+		{
+			name: `SecureChannelLifetime(2s)`,
+			opt:  SecureChannelLifetime(2 * time.Second),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.Lifetime = 2000
+					return c
+				}(),
+			},
+		},
+		{
+			name: `SecureChannelLifetime(0)`,
+			opt:  SecureChannelLifetime(0),
+			cfg: &Config{
+				err: fmt.Errorf("opcua: invalid secure channel lifetime 0s"),
+			},
+		},
 		{
 			name: `SecurityFromEndpoint(no-match)`,
 			opt: SecurityFromEndpoint(&ua.EndpointDescription{
@@ -565,6 +680,30 @@ func TestOptions(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name: `InsecureSkipVerify()`,
+			opt:  InsecureSkipVerify(),
+			cfg: &Config{
+				insecureSkipVerify: true,
+			},
+		},
+		{
+			name: `ServerCertificateTrust() error`,
+			opt:  ServerCertificateTrust("x", "", ""),
+			cfg: &Config{
+				err: fmt.Errorf("opcua: failed to read x: %s", func() string {
+					_, err := ioutil.ReadDir("x")
+					return err.Error()
+				}()),
+			},
+		},
+		{
+			name: `VerifyServerHostname(true)`,
+			opt:  VerifyServerHostname(true),
+			cfg: &Config{
+				verifyHostname: true,
+			},
+		},
 		{
 			name: `SecurityMode(Sign)`,
 			opt:  SecurityMode(ua.MessageSecurityModeSign),
@@ -579,6 +718,13 @@ func TestOptions(t *testing.T) {
 		{
 			name: `SecurityModeString("bad")`,
 			opt:  SecurityModeString("bad"),
+			cfg: &Config{
+				err: fmt.Errorf(`opcua: invalid MessageSecurityMode "bad"`),
+			},
+		},
+		{
+			name: `SecurityModeString("")`,
+			opt:  SecurityModeString(""),
 			cfg: &Config{
 				sechan: func() *uasc.Config {
 					c := DefaultClientConfig()
@@ -587,6 +733,17 @@ func TestOptions(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name: `SecurityModeString("sign")`,
+			opt:  SecurityModeString("sign"),
+			cfg: &Config{
+				sechan: func() *uasc.Config {
+					c := DefaultClientConfig()
+					c.SecurityMode = ua.MessageSecurityModeSign
+					return c
+				}(),
+			},
+		},
 		{
 			name: `SecurityModeString("None")`,
 			opt:  SecurityModeString("None"),
@@ -720,13 +877,52 @@ func TestOptions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: `ReverseConnect()`,
+			opt:  ReverseConnect(&ReverseListener{}, "urn:server"),
+			cfg: &Config{
+				reverseListener:  &ReverseListener{},
+				reverseServerURI: "urn:server",
+			},
+		},
+		{
+			name: `EndpointSelection()`,
+			opt:  EndpointSelection(EndpointSelectionSecurityLevel),
+			cfg: &Config{
+				endpointSelection: EndpointSelectionSecurityLevel,
+			},
+		},
+		{
+			name: `SelectEndpointFilter()`,
+			opt:  SelectEndpointFilter(nil),
+			cfg: &Config{
+				endpointSelection: EndpointSelectionStrongest,
+			},
+		},
+		{
+			name: `PublishRequests(3)`,
+			opt:  PublishRequests(3),
+			cfg: &Config{
+				publishRequests: 3,
+			},
+		},
+		{
+			name: `TimestampsToReturn(ua.TimestampsToReturnSource)`,
+			opt:  TimestampsToReturn(ua.TimestampsToReturnSource),
+			cfg: &Config{
+				timestampsToReturn: func() *ua.TimestampsToReturn {
+					ts := ua.TimestampsToReturnSource
+					return &ts
+				}(),
+			},
+		},
 		{
 			name: `DialTimeout(5s)`,
 			opt:  DialTimeout(5 * time.Second),
 			cfg: &Config{
 				dialer: &uacp.Dialer{
 					Dialer:    &net.Dialer{Timeout: 5 * time.Second},
-					ClientACK: uacp.DefaultClientACK,
+					ClientACK: defaultClientACK(),
 				},
 			},
 		},
@@ -737,7 +933,7 @@ func TestOptions(t *testing.T) {
 				dialer: func() *uacp.Dialer {
 					d := &uacp.Dialer{
 						Dialer:    &net.Dialer{},
-						ClientACK: uacp.DefaultClientACK,
+						ClientACK: defaultClientACK(),
 					}
 					d.ClientACK.MaxMessageSize = 5
 					return d
@@ -751,7 +947,7 @@ func TestOptions(t *testing.T) {
 				dialer: func() *uacp.Dialer {
 					d := &uacp.Dialer{
 						Dialer:    &net.Dialer{},
-						ClientACK: uacp.DefaultClientACK,
+						ClientACK: defaultClientACK(),
 					}
 					d.ClientACK.MaxChunkCount = 5
 					return d
@@ -765,7 +961,7 @@ func TestOptions(t *testing.T) {
 				dialer: func() *uacp.Dialer {
 					d := &uacp.Dialer{
 						Dialer:    &net.Dialer{},
-						ClientACK: uacp.DefaultClientACK,
+						ClientACK: defaultClientACK(),
 					}
 					d.ClientACK.ReceiveBufSize = 5
 					return d
@@ -779,13 +975,55 @@ func TestOptions(t *testing.T) {
 				dialer: func() *uacp.Dialer {
 					d := &uacp.Dialer{
 						Dialer:    &net.Dialer{},
-						ClientACK: uacp.DefaultClientACK,
+						ClientACK: defaultClientACK(),
 					}
 					d.ClientACK.SendBufSize = 5
 					return d
 				}(),
 			},
 		},
+		{
+			name: `MaxNodesPerWrite()`,
+			opt:  MaxNodesPerWrite(5),
+			cfg: &Config{
+				maxNodesPerWrite: 5,
+			},
+		},
+		{
+			name: `FetchOperationLimits()`,
+			opt:  FetchOperationLimits(true),
+			cfg: &Config{
+				fetchOperationLimits: true,
+			},
+		},
+		{
+			name: `BatchSize()`,
+			opt:  BatchSize(5),
+			cfg: &Config{
+				batchSize: 5,
+			},
+		},
+		{
+			name: `BatchConcurrency()`,
+			opt:  BatchConcurrency(5),
+			cfg: &Config{
+				batchConcurrency: 5,
+			},
+		},
+		{
+			name: `AutoRegisterNodes()`,
+			opt:  AutoRegisterNodes(5),
+			cfg: &Config{
+				autoRegisterThreshold: 5,
+			},
+		},
+		{
+			name: `DataTypeCacheSize()`,
+			opt:  DataTypeCacheSize(5),
+			cfg: &Config{
+				dataTypeCacheSize: 5,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -818,3 +1056,66 @@ func TestOptions(t *testing.T) {
 		})
 	}
 }
+
+// defaultClientACK returns a copy of uacp.DefaultClientACK.
+func defaultClientACK() *uacp.Acknowledge {
+	ack := *uacp.DefaultClientACK
+	return &ack
+}
+
+func TestDialerOptionsKeepDefaultACK(t *testing.T) {
+	want := *uacp.DefaultClientACK
+	ApplyConfig(MaxMessageSize(5), MaxChunkCount(5), ReceiveBufferSize(5), SendBufferSize(5))
+	verify.Values(t, "", *uacp.DefaultClientACK, want)
+}
+
+func TestAutoGenerateCert(t *testing.T) {
+	d, err := ioutil.TempDir("", "gopcua")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	certFile, keyFile := filepath.Join(d, "cert.pem"), filepath.Join(d, "key.pem")
+	// the certificate uses the application uri of a later option.
+	cfg := ApplyConfig(AutoGenerateCert(certFile, keyFile, 0), ApplicationURI("urn:gopcua:test"))
+	if err := cfg.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.sechan.LocalKey == nil {
+		t.Fatal("no private key")
+	}
+	c, err := x509.ParseCertificate(cfg.sechan.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.URIs) != 1 || c.URIs[0].String() != "urn:gopcua:test" {
+		t.Fatalf("got uris %v want [urn:gopcua:test]", c.URIs)
+	}
+	if got, want := cfg.session.ClientDescription.ApplicationURI, "urn:gopcua:test"; got != want {
+		t.Fatalf("got application uri %q want %q", got, want)
+	}
+
+	// the certificate is reused for the same application uri.
+	cfg2 := ApplyConfig(ApplicationURI("urn:gopcua:test"), AutoGenerateCert(certFile, keyFile, 0))
+	if err := cfg2.Error(); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "certificate", cfg2.sechan.Certificate, cfg.sechan.Certificate)
+
+	// the certificate is replaced for a different application uri.
+	cfg3 := ApplyConfig(AutoGenerateCert(certFile, keyFile, 0))
+	if err := cfg3.Error(); err != nil {
+		t.Fatal(err)
+	}
+	c3, err := x509.ParseCertificate(cfg3.sechan.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "uris", c3.URIs[0].String(), "urn:gopcua:client")
+	verify.Values(t, "application uri", cfg3.session.ClientDescription.ApplicationURI, "urn:gopcua:client")
+
+	if err := ApplyConfig(AutoGenerateCert("", "", 1024)).Error(); err == nil {
+		t.Fatal("got nil want error for invalid key size")
+	}
+}
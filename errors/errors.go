@@ -2,6 +2,8 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	pkg_errors "github.com/pkg/errors"
 )
@@ -19,6 +21,29 @@ func New(text string) error {
 	return pkg_errors.New(Prefix + text)
 }
 
+// Wrapf returns an error with the message of format followed by the
+// message of err. The error wraps err so that Is and As find it, e.g. the
+// StatusCode of a failed operation.
+func Wrapf(err error, format string, a ...interface{}) error {
+	return &wrapError{
+		msg: Prefix + fmt.Sprintf(format, a...) + ": " + strings.TrimPrefix(err.Error(), Prefix),
+		err: err,
+	}
+}
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string {
+	return e.msg
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
+
 // Is wraps errors.Is
 func Is(err error, target error) bool {
 	return errors.Is(err, target)
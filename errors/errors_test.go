@@ -18,3 +18,14 @@ func TestErrors(t *testing.T) {
 		t.Fatalf("got %s, wanted %s", err.Error(), "opcua: %s")
 	}
 }
+
+func TestWrapf(t *testing.T) {
+	base := New("base")
+	err := Wrapf(base, "node %d", 1)
+	if got, want := err.Error(), "opcua: node 1: base"; got != want {
+		t.Fatalf("got %s, wanted %s", got, want)
+	}
+	if !Is(err, base) {
+		t.Fatalf("got %v which does not wrap %v", err, base)
+	}
+}
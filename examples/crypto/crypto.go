@@ -15,6 +15,7 @@ import (
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/crypto/ssh/terminal"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacert"
 )
 
 var (
@@ -108,6 +110,20 @@ func main() {
 	}
 }
 
+func generateCert(appURI, certFile, keyFile string) {
+	certPEM, keyPEM, err := uacert.GenerateCert(appURI, []string{"localhost"}, 365*24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		log.Fatalf("failed to write %s: %s", certFile, err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		log.Fatalf("failed to write %s: %s", keyFile, err)
+	}
+	log.Printf("wrote %s and %s", certFile, keyFile)
+}
+
 func clientOptsFromFlags(endpoints []*ua.EndpointDescription) []opcua.Option {
 	opts := []opcua.Option{}
 
@@ -119,7 +135,7 @@ func clientOptsFromFlags(endpoints []*ua.EndpointDescription) []opcua.Option {
 	var cert []byte
 	if *gencert || (*certfile != "" && *keyfile != "") {
 		if *gencert {
-			generate_cert(*appuri, 2048, *certfile, *keyfile)
+			generateCert(*appuri, *certfile, *keyfile)
 		}
 		debug.Printf("Loading cert/key from %s/%s", *certfile, *keyfile)
 		c, err := tls.LoadX509KeyPair(*certfile, *keyfile)
@@ -194,7 +210,7 @@ func clientOptsFromFlags(endpoints []*ua.EndpointDescription) []opcua.Option {
 
 	case *mode == "auto" && *policy != "auto": // User only cares about policy, select highest securitylevel with that policy
 		for _, e := range endpoints {
-			if e.SecurityPolicyURI == secPolicy && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
+			if e.HasSecurityPolicy(secPolicy) && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
 				serverEndpoint = e
 			}
 		}
@@ -202,7 +218,7 @@ func clientOptsFromFlags(endpoints []*ua.EndpointDescription) []opcua.Option {
 	default: // User cares about both
 		fmt.Println("secMode: ", secMode, "secPolicy:", secPolicy)
 		for _, e := range endpoints {
-			if e.SecurityPolicyURI == secPolicy && e.SecurityMode == secMode && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
+			if e.HasSecurityPolicy(secPolicy) && e.SecurityMode == secMode && (serverEndpoint == nil || e.SecurityLevel >= serverEndpoint.SecurityLevel) {
 				serverEndpoint = e
 			}
 		}
@@ -276,7 +292,7 @@ func authFromFlags(cert []byte) (ua.UserTokenType, opcua.Option) {
 	case "issuedtoken":
 		// todo: this is unsupported, fail here or fail in the opcua package?
 		authMode = ua.UserTokenTypeIssuedToken
-		authOption = opcua.AuthIssuedToken([]byte(nil))
+		authOption = opcua.AuthIssuedToken([]byte(nil), "")
 
 	default:
 		log.Printf("unknown auth-mode, defaulting to Anonymous")
@@ -290,12 +306,8 @@ func authFromFlags(cert []byte) (ua.UserTokenType, opcua.Option) {
 
 func validateEndpointConfig(endpoints []*ua.EndpointDescription, secPolicy string, secMode ua.MessageSecurityMode, authMode ua.UserTokenType) error {
 	for _, e := range endpoints {
-		if e.SecurityMode == secMode && e.SecurityPolicyURI == secPolicy {
-			for _, t := range e.UserIdentityTokens {
-				if t.TokenType == authMode {
-					return nil
-				}
-			}
+		if e.SecurityMode == secMode && e.HasSecurityPolicy(secPolicy) && e.HasUserTokenType(authMode) {
+			return nil
 		}
 	}
 
@@ -309,8 +321,8 @@ func printEndpointOptions(endpoints []*ua.EndpointDescription) {
 	log.Print("         sec-policy    |    sec-mode     |      auth-modes\n")
 	log.Print("-----------------------|-----------------|---------------------------\n")
 	for _, e := range endpoints {
-		p := strings.TrimPrefix(e.SecurityPolicyURI, "http://opcfoundation.org/UA/SecurityPolicy#")
-		m := strings.TrimPrefix(e.SecurityMode.String(), "MessageSecurityMode")
+		p := e.SecurityPolicy()
+		m := e.SecurityModeName()
 		var tt []string
 		for _, t := range e.UserIdentityTokens {
 			tok := strings.TrimPrefix(t.TokenType.String(), "UserTokenType")
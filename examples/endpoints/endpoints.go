@@ -26,6 +26,6 @@ func main() {
 	}
 
 	for _, ep := range eps {
-		log.Println(ep.EndpointURL, ep.SecurityPolicyURI, ep.SecurityMode)
+		log.Println(ep.EndpointURL, ep.SecurityPolicy(), ep.SecurityModeName())
 	}
 }
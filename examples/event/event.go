@@ -0,0 +1,116 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/zzylovesll/myOpcUa"
+	"github.com/zzylovesll/myOpcUa/debug"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+func main() {
+	var (
+		endpoint = flag.String("endpoint", "opc.tcp://localhost:4840", "OPC UA Endpoint URL")
+		policy   = flag.String("policy", "", "Security policy: None, Basic128Rsa15, Basic256, Basic256Sha256. Default: auto")
+		mode     = flag.String("mode", "", "Security mode: None, Sign, SignAndEncrypt. Default: auto")
+		certFile = flag.String("cert", "", "Path to cert.pem. Required for security mode/policy != None")
+		keyFile  = flag.String("key", "", "Path to private key.pem. Required for security mode/policy != None")
+		nodeID   = flag.String("node", "i=2253", "node id of the event notifier. Default: Server object")
+		severity = flag.Uint("severity", 0, "minimum severity of the events")
+		interval = flag.Duration("interval", opcua.DefaultSubscriptionInterval, "subscription interval")
+	)
+	flag.BoolVar(&debug.Enable, "debug", false, "enable debug logging")
+	flag.Parse()
+	log.SetFlags(0)
+
+	ctx := context.Background()
+
+	endpoints, err := opcua.GetEndpoints(ctx, *endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// an empty mode selects the endpoint with any security mode
+	var secMode ua.MessageSecurityMode
+	if *mode != "" {
+		if secMode, err = ua.MessageSecurityModeFromString(*mode); err != nil {
+			log.Fatal(err)
+		}
+	}
+	ep := opcua.SelectEndpoint(endpoints, *policy, secMode)
+	if ep == nil {
+		log.Fatal("Failed to find suitable endpoint")
+	}
+
+	fmt.Println("*", ep.SecurityPolicyURI, ep.SecurityMode)
+
+	opts := []opcua.Option{
+		opcua.SecurityPolicy(*policy),
+		opcua.SecurityModeString(*mode),
+		opcua.CertificateFile(*certFile),
+		opcua.PrivateKeyFile(*keyFile),
+		opcua.AuthAnonymous(),
+		opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeAnonymous),
+	}
+
+	c := opcua.NewClient(ep.EndpointURL, opts...)
+	if err := c.Connect(ctx); err != nil {
+		log.Fatal(err)
+	}
+	defer c.CloseWithContext(ctx)
+
+	notifyCh := make(chan *opcua.PublishNotificationData)
+
+	sub, err := c.SubscribeWithContext(ctx, &opcua.SubscriptionParameters{
+		Interval: *interval,
+	}, notifyCh)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sub.Cancel(ctx)
+	log.Printf("Created subscription with id %v", sub.SubscriptionID)
+
+	nid, err := ua.ParseNodeID(*nodeID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// select the severity and message of all alarms with
+	// a severity of at least -severity.
+	filter := ua.NewEventFilter().
+		Select(nil, "Severity").
+		Select(nil, "Message").
+		Where(ua.FilterOperatorAnd, &ua.ElementOperand{Index: 1}, &ua.ElementOperand{Index: 2}).
+		Where(ua.FilterOperatorOfType, ua.NewNumericNodeID(0, id.AlarmConditionType)).
+		Where(ua.FilterOperatorGreaterThanOrEqual, ua.NewSelectOperand("Severity"), uint16(*severity))
+
+	req := opcua.NewMonitoredItemCreateRequestWithDefaults(nid, ua.AttributeIDEventNotifier, 1)
+	res, err := sub.MonitorWithFilter(ctx, ua.TimestampsToReturnBoth, filter.ExtensionObject(), req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if res.Results[0].StatusCode != ua.StatusOK {
+		log.Fatal(res.Results[0].StatusCode)
+	}
+
+	for res := range notifyCh {
+		if res.Error != nil {
+			log.Print(res.Error)
+			continue
+		}
+		for _, ev := range res.Events {
+			var msg string
+			if lt, ok := ev.Value("Message").(*ua.LocalizedText); ok {
+				msg = lt.Text
+			}
+			log.Printf("severity=%v message=%q", ev.Value("Severity"), msg)
+		}
+	}
+}
@@ -8,94 +8,67 @@ import (
 	"context"
 	"flag"
 	"log"
+	"math"
+	"os"
+	"os/signal"
+	"time"
 
-	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/debug"
+	"github.com/zzylovesll/myOpcUa/server"
+	"github.com/zzylovesll/myOpcUa/ua"
 )
 
 func main() {
 	var (
-		endpoint = flag.String("endpoint", "opc.tcp://example.com/foo/bar", "OPC UA Endpoint URL")
+		endpoint = flag.String("endpoint", "opc.tcp://localhost:4840", "OPC UA Endpoint URL")
+		user     = flag.String("user", "", "user name for user name authentication")
+		pass     = flag.String("pass", "", "password for user name authentication")
 	)
+	flag.BoolVar(&debug.Enable, "debug", false, "enable debug logging")
 	flag.Parse()
+	log.SetFlags(0)
 
-	ctx := context.Background()
+	var opts []server.Option
+	if *user != "" {
+		opts = append(opts,
+			server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+			server.UsernameAuth(func(u, p string) bool { return u == *user && p == *pass }),
+		)
+	}
+	srv := server.New(*endpoint, opts...)
 
-	log.Printf("Listening on %s", *endpoint)
-	l, err := uacp.Listen(*endpoint, nil)
+	dev, err := srv.AddObject(nil, "Device")
 	if err != nil {
 		log.Fatal(err)
 	}
-	c, err := l.Accept(ctx)
+	speed, err := srv.AddVariable(dev, "Speed", ua.MustVariant(0.0))
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer c.Close()
-	log.Printf("conn %d: connection from %s", c.ID(), c.RemoteAddr())
-
-	// listener, err := uacp.Listen(*endpoint, uint32(*bufsize))
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-	// log.Printf("Started listening on %s.", listener.Endpoint())
-
-	// cfg := uasc.NewServerConfig(
-	// 	"http://opcfoundation.org/UA/SecurityPolicy#None",
-	// 	nil, nil, 1111, services.SecModeNone, 2222, 3600000,
-	// )
-	// for {
-	// 	func() {
-	// 		ctx := context.Background()
-	// 		ctx, cancel := context.WithCancel(ctx)
-	// 		defer cancel()
-
-	// 		conn, err := listener.Accept(ctx)
-	// 		if err != nil {
-	// 			log.Print(err)
-	// 			return
-	// 		}
-	// 		defer func() {
-	// 			conn.Close()
-	// 			log.Println("Successfully closed connection")
-	// 		}()
-	// 		log.Printf("Successfully established connection with %v", conn.RemoteAddr())
-
-	// 		secChan, err := uasc.ListenAndAcceptSecureChannel(ctx, conn, cfg)
-	// 		if err != nil {
-	// 			log.Fatal(err)
-	// 		}
-	// 		defer func() {
-	// 			secChan.Close()
-	// 			log.Printf("Successfully closed secure channel with %v", conn.RemoteAddr())
-	// 		}()
-	// 		log.Printf("Successfully opened secure channel with %v", conn.RemoteAddr())
+	if _, err := srv.AddVariable(dev, "Setpoint", ua.MustVariant(int32(0)), server.Writable()); err != nil {
+		log.Fatal(err)
+	}
 
-	// 		sessCfg := uasc.NewServerSessionConfig(secChan)
-	// 		session, err := uasc.ListenAndAcceptSession(ctx, secChan, sessCfg)
-	// 		if err != nil {
-	// 			log.Fatal(err)
-	// 		}
-	// 		defer func() {
-	// 			session.Close()
-	// 			log.Printf("Successfully closed session with %v", conn.RemoteAddr())
-	// 		}()
-	// 		log.Printf("Successfully activated session with %v", conn.RemoteAddr())
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 
-	// 		buf := make([]byte, 1024)
-	// 		for {
-	// 			n, err := session.ReadService(buf)
-	// 			if err != nil {
-	// 				log.Printf("Couldn't read UASC: %s", err)
-	// 				continue
-	// 			}
-	// 			log.Printf("Successfully received message: %x\n%s", buf[:n], utils.Wireshark(0, buf[:n]))
+	if err := srv.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Listening on %s", *endpoint)
 
-	// 			srv, err := services.Decode(buf[:n])
-	// 			if err != nil {
-	// 				log.Printf("Couldn't decode received bytes as Service: %s", err)
-	// 				continue
-	// 			}
-	// 			log.Printf("Successfully decoded as Service: %v", srv)
-	// 		}
-	// 	}()
-	// }
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			srv.Close()
+			return
+		case now := <-t.C:
+			v := 100 * math.Sin(float64(now.Unix())/10)
+			if err := srv.SetValue(speed, ua.MustVariant(v)); err != nil {
+				log.Print(err)
+			}
+		}
+	}
 }
@@ -39,7 +39,14 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ep := opcua.SelectEndpoint(endpoints, *policy, ua.MessageSecurityModeFromString(*mode))
+	// an empty mode selects the endpoint with any security mode
+	var secMode ua.MessageSecurityMode
+	if *mode != "" {
+		if secMode, err = ua.MessageSecurityModeFromString(*mode); err != nil {
+			log.Fatal(err)
+		}
+	}
+	ep := opcua.SelectEndpoint(endpoints, *policy, secMode)
 	if ep == nil {
 		log.Fatal("Failed to find suitable endpoint")
 	}
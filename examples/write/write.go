@@ -8,6 +8,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"time"
 
 	"github.com/zzylovesll/myOpcUa"
 	"github.com/zzylovesll/myOpcUa/debug"
@@ -37,7 +38,7 @@ func main() {
 		log.Fatalf("invalid node id: %v", err)
 	}
 
-	v, err := ua.NewVariant(*value)
+	v, err := ua.NewDataValue(*value, ua.StatusOK, time.Time{})
 	if err != nil {
 		log.Fatalf("invalid value: %v", err)
 	}
@@ -47,10 +48,7 @@ func main() {
 			{
 				NodeID:      id,
 				AttributeID: ua.AttributeIDValue,
-				Value: &ua.DataValue{
-					EncodingMask: ua.DataValueValue,
-					Value:        v,
-				},
+				Value:       v,
 			},
 		},
 	}
@@ -9,8 +9,9 @@ go run cmd/stats/*.go
 # install stringer if not installed already
 command -v stringer || go get -u golang.org/x/tools/cmd/stringer
 
-# find all enum types
-enums=$(grep -w '^type' ua/enums*.go | awk '{print $2;}' | paste -sd, -)
+# find all enum types which are not generated. cmd/service generates
+# the string methods of the generated enums.
+enums=$(grep -w '^type' ua/enums.go | awk '{print $2;}' | paste -sd, -)
 
 # generate enum string method
 (cd ua && stringer -type $enums -output enums_strings_gen.go)
@@ -0,0 +1,142 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package logger defines the leveled, structured logger of the client,
+// the secure channel and the connection and adapters for other loggers.
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/debug"
+)
+
+// Logger logs events with a level, a message and key-value pairs which
+// describe the event, e.g.
+//
+//	l.Info("secure channel opened", "channel_id", 5, "token_id", 1)
+//
+// The keys are strings and every key is followed by its value.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// Nop is a Logger which discards all events.
+var Nop Logger = nop{}
+
+// Default is the logger of the clients, secure channels and connections
+// without a logger. It prints all events with the logger of the debug
+// package if debug logging is enabled with OPC_DEBUG=debug and discards
+// them otherwise.
+var Default Logger = debugLogger{}
+
+// OrDefault returns l or Default if l is nil.
+func OrDefault(l Logger) Logger {
+	if l == nil {
+		return Default
+	}
+	return l
+}
+
+// With returns a logger which adds the key-value pairs to every event,
+// e.g. the id of a connection.
+func With(l Logger, kv ...interface{}) Logger {
+	if w, ok := l.(*withLogger); ok {
+		return &withLogger{l: w.l, kv: append(append([]interface{}(nil), w.kv...), kv...)}
+	}
+	return &withLogger{l: OrDefault(l), kv: kv}
+}
+
+// Std returns a Logger which prints the events with l in the format
+//
+//	INFO secure channel opened channel_id=5 token_id=1
+func Std(l *log.Logger) Logger {
+	return stdLogger{l}
+}
+
+type nop struct{}
+
+func (nop) Debug(string, ...interface{}) {}
+func (nop) Info(string, ...interface{})  {}
+func (nop) Warn(string, ...interface{})  {}
+func (nop) Error(string, ...interface{}) {}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s stdLogger) Debug(msg string, kv ...interface{}) { s.l.Print(format("DEBUG", msg, kv)) }
+func (s stdLogger) Info(msg string, kv ...interface{})  { s.l.Print(format("INFO", msg, kv)) }
+func (s stdLogger) Warn(msg string, kv ...interface{})  { s.l.Print(format("WARN", msg, kv)) }
+func (s stdLogger) Error(msg string, kv ...interface{}) { s.l.Print(format("ERROR", msg, kv)) }
+
+// debugLogger prints the events with debug.Logger when debug logging is
+// enabled. debug.Logger is looked up for every event since it can be
+// replaced.
+type debugLogger struct{}
+
+func (debugLogger) Debug(msg string, kv ...interface{}) {
+	if debug.Enable {
+		Std(debug.Logger).Debug(msg, kv...)
+	}
+}
+
+func (debugLogger) Info(msg string, kv ...interface{}) {
+	if debug.Enable {
+		Std(debug.Logger).Info(msg, kv...)
+	}
+}
+
+func (debugLogger) Warn(msg string, kv ...interface{}) {
+	if debug.Enable {
+		Std(debug.Logger).Warn(msg, kv...)
+	}
+}
+
+func (debugLogger) Error(msg string, kv ...interface{}) {
+	if debug.Enable {
+		Std(debug.Logger).Error(msg, kv...)
+	}
+}
+
+type withLogger struct {
+	l  Logger
+	kv []interface{}
+}
+
+func (w *withLogger) Debug(msg string, kv ...interface{}) { w.l.Debug(msg, w.join(kv)...) }
+func (w *withLogger) Info(msg string, kv ...interface{})  { w.l.Info(msg, w.join(kv)...) }
+func (w *withLogger) Warn(msg string, kv ...interface{})  { w.l.Warn(msg, w.join(kv)...) }
+func (w *withLogger) Error(msg string, kv ...interface{}) { w.l.Error(msg, w.join(kv)...) }
+
+func (w *withLogger) join(kv []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(w.kv)+len(kv)), w.kv...), kv...)
+}
+
+// format returns the text of an event. A key without a value is printed
+// with the value MISSING.
+func format(level, msg string, kv []interface{}) string {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		var v interface{} = "MISSING"
+		if i+1 < len(kv) {
+			v = kv[i+1]
+		}
+		s := fmt.Sprint(v)
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			s = fmt.Sprintf("%q", s)
+		}
+		fmt.Fprintf(&b, " %v=%s", kv[i], s)
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestStd(t *testing.T) {
+	var buf bytes.Buffer
+	l := Std(log.New(&buf, "", 0))
+
+	l.Debug("a")
+	l.Info("secure channel opened", "channel_id", 5, "token_id", 1)
+	l.Warn("b", "err", "connection reset by peer", "empty", "")
+	l.Error("c", "key")
+
+	want := []string{
+		`DEBUG a`,
+		`INFO secure channel opened channel_id=5 token_id=1`,
+		`WARN b err="connection reset by peer" empty=""`,
+		`ERROR c key=MISSING`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := With(With(Std(log.New(&buf, "", 0)), "conn_id", 1), "channel_id", 2)
+	l.Info("a", "k", "v")
+	if got, want := strings.TrimSpace(buf.String()), "INFO a conn_id=1 channel_id=2 k=v"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	// the key-value pairs of the loggers are not shared
+	buf.Reset()
+	parent := With(Std(log.New(&buf, "", 0)), "conn_id", 1)
+	a, b := With(parent, "x", 1), With(parent, "y", 2)
+	a.Info("a")
+	b.Info("b")
+	if got, want := strings.TrimSpace(buf.String()), "INFO a conn_id=1 x=1\nINFO b conn_id=1 y=2"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	if OrDefault(nil) != Default {
+		t.Fatal("got a logger other than Default for nil")
+	}
+	if OrDefault(Nop) != Nop {
+		t.Fatal("got a logger other than Nop for Nop")
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+//go:build go1.21
+
+package logger
+
+import "log/slog"
+
+// Slog returns a Logger which logs the events with l. The key-value pairs
+// of the events are the attributes of the records.
+func Slog(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, kv ...interface{}) { s.l.Debug(msg, kv...) }
+func (s slogLogger) Info(msg string, kv ...interface{})  { s.l.Info(msg, kv...) }
+func (s slogLogger) Warn(msg string, kv ...interface{})  { s.l.Warn(msg, kv...) }
+func (s slogLogger) Error(msg string, kv ...interface{}) { s.l.Error(msg, kv...) }
@@ -190,7 +190,10 @@ func (n *Node) Attribute(attrID ua.AttributeID) (*ua.Variant, error) {
 // Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
 func (n *Node) AttributeWithContext(ctx context.Context, attrID ua.AttributeID) (*ua.Variant, error) {
 	rv := &ua.ReadValueID{NodeID: n.ID, AttributeID: attrID}
-	req := &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{rv}}
+	req := &ua.ReadRequest{
+		NodesToRead:        []*ua.ReadValueID{rv},
+		TimestampsToReturn: n.c.timestampsToReturn(),
+	}
 	res, err := n.c.ReadWithContext(ctx, req)
 	if err != nil {
 		return nil, err
@@ -217,7 +220,7 @@ func (n *Node) Attributes(attrID ...ua.AttributeID) ([]*ua.DataValue, error) {
 
 // Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
 func (n *Node) AttributesWithContext(ctx context.Context, attrID ...ua.AttributeID) ([]*ua.DataValue, error) {
-	req := &ua.ReadRequest{}
+	req := &ua.ReadRequest{TimestampsToReturn: n.c.timestampsToReturn()}
 	for _, id := range attrID {
 		rv := &ua.ReadValueID{NodeID: n.ID, AttributeID: id}
 		req.NodesToRead = append(req.NodesToRead, rv)
@@ -341,23 +344,7 @@ func (n *Node) TranslateBrowsePathsToNodeIDs(pathNames []*ua.QualifiedName) (*ua
 // Note: Starting with v0.5 this method is superseded by the non 'WithContext' method.
 func (n *Node) TranslateBrowsePathsToNodeIDsWithContext(ctx context.Context, pathNames []*ua.QualifiedName) (*ua.NodeID, error) {
 	req := ua.TranslateBrowsePathsToNodeIDsRequest{
-		BrowsePaths: []*ua.BrowsePath{
-			{
-				StartingNode: n.ID,
-				RelativePath: &ua.RelativePath{
-					Elements: []*ua.RelativePathElement{},
-				},
-			},
-		}}
-
-	for _, name := range pathNames {
-		req.BrowsePaths[0].RelativePath.Elements = append(req.BrowsePaths[0].RelativePath.Elements,
-			&ua.RelativePathElement{ReferenceTypeID: ua.NewTwoByteNodeID(id.HierarchicalReferences),
-				IsInverse:       false,
-				IncludeSubtypes: true,
-				TargetName:      name,
-			},
-		)
+		BrowsePaths: []*ua.BrowsePath{ua.NewBrowsePath(n.ID, pathNames)},
 	}
 
 	var nodeID *ua.NodeID
@@ -0,0 +1,345 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// ErrPoolClosed is returned for requests to a closed pool.
+var ErrPoolClosed = errors.New("pool closed")
+
+// DefaultPoolSize is the number of clients of a pool.
+const DefaultPoolSize = 4
+
+// PoolOption is an option function type to modify the configuration
+// of a pool.
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	size        int
+	healthCheck time.Duration
+	clientOpts  []Option
+}
+
+// PoolSize sets the number of clients of the pool.
+func PoolSize(n int) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.size = n
+	}
+}
+
+// PoolHealthCheckInterval sets the interval in which the pool reads the
+// server state with every connected client. Clients whose check fails
+// are not used until a later check succeeds. 0 disables the check.
+func PoolHealthCheckInterval(d time.Duration) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.healthCheck = d
+	}
+}
+
+// PoolClientOptions sets the options for the clients of the pool.
+// Every client reconnects on its own with the reconnect options.
+func PoolClientOptions(opts ...Option) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.clientOpts = append(cfg.clientOpts, opts...)
+	}
+}
+
+// Pool dispatches requests to several clients which are connected to
+// the same endpoint. Every client has its own secure channel and session
+// so that requests from many goroutines are not serialized through a
+// single channel.
+//
+// Requests are sent with the healthy client which has the fewest
+// requests in flight. Clients with the same load are used in turn.
+type Pool struct {
+	cfg     *poolConfig
+	clients []*poolClient
+
+	// next is the index of the client to start the search with.
+	next uint32
+
+	// mu guards closed and the start of requests so that Close waits
+	// for all requests which have been started.
+	mu       sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type poolClient struct {
+	*Client
+
+	// load is the number of requests in flight.
+	load int64
+
+	// unhealthy is set when the last health check failed.
+	unhealthy int32
+}
+
+func (pc *poolClient) healthy() bool {
+	return pc.State() == Connected && atomic.LoadInt32(&pc.unhealthy) == 0
+}
+
+// NewPool creates a pool of clients for the endpoint. The clients are
+// not connected until Connect is called.
+func NewPool(endpoint string, opts ...PoolOption) *Pool {
+	cfg := &poolConfig{size: DefaultPoolSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.size < 1 {
+		cfg.size = 1
+	}
+
+	p := &Pool{cfg: cfg, done: make(chan struct{})}
+	for i := 0; i < cfg.size; i++ {
+		p.clients = append(p.clients, &poolClient{Client: NewClient(endpoint, cfg.clientOpts...)})
+	}
+	return p
+}
+
+// Connect connects all clients of the pool. If one of the clients cannot
+// connect the connected clients are closed again.
+func (p *Pool) Connect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if p.cancel != nil {
+		return errors.Errorf("already connected")
+	}
+
+	errs := make([]error, len(p.clients))
+	var wg sync.WaitGroup
+	for i, pc := range p.clients {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			errs[i] = c.Connect(ctx)
+		}(i, pc.Client)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			for _, pc := range p.clients {
+				pc.CloseWithContext(ctx)
+			}
+			return err
+		}
+	}
+
+	hctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.monitor(hctx)
+	return nil
+}
+
+// monitor runs the health checks until ctx is cancelled.
+func (p *Pool) monitor(ctx context.Context) {
+	defer close(p.done)
+	if p.cfg.healthCheck <= 0 {
+		return
+	}
+
+	t := time.NewTicker(p.cfg.healthCheck)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			for _, pc := range p.clients {
+				p.check(ctx, pc)
+			}
+		}
+	}
+}
+
+// check reads the server state with the client and records whether the
+// server is running.
+func (p *Pool) check(ctx context.Context, pc *poolClient) {
+	if pc.State() != Connected {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.healthCheck)
+	defer cancel()
+
+	res, err := pc.ReadWithContext(ctx, &ua.ReadRequest{
+		NodesToRead:        []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err == nil && len(res.Results) != 1 {
+		err = ua.StatusBadUnexpectedError
+	}
+	if err == nil && res.Results[0].Status != ua.StatusOK {
+		err = res.Results[0].Status
+	}
+	if err != nil {
+		stats.RecordError(err)
+		atomic.StoreInt32(&pc.unhealthy, 1)
+		return
+	}
+	atomic.StoreInt32(&pc.unhealthy, 0)
+}
+
+// acquire returns the client for the next request and registers the
+// request with the pool. The request must be finished with release.
+func (p *Pool) acquire() (*poolClient, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	p.inflight.Add(1)
+
+	pc := p.pick()
+	atomic.AddInt64(&pc.load, 1)
+	return pc, nil
+}
+
+func (p *Pool) release(pc *poolClient) {
+	atomic.AddInt64(&pc.load, -1)
+	p.inflight.Done()
+}
+
+// pick returns the healthy client with the lowest load. If no client is
+// healthy it returns the client with the lowest load so that the request
+// fails or waits for the reconnect depending on the client options.
+func (p *Pool) pick() *poolClient {
+	n := len(p.clients)
+	start := int(atomic.AddUint32(&p.next, 1)-1) % n
+
+	var best *poolClient
+	var bestHealthy bool
+	for i := 0; i < n; i++ {
+		pc := p.clients[(start+i)%n]
+		healthy := pc.healthy()
+		switch {
+		case best == nil,
+			healthy && !bestHealthy,
+			healthy == bestHealthy && atomic.LoadInt64(&pc.load) < atomic.LoadInt64(&best.load):
+			best, bestHealthy = pc, healthy
+		}
+	}
+	return best
+}
+
+// Do calls f with the client which should handle the next request.
+// It can be used for services which the pool does not provide.
+func (p *Pool) Do(f func(c *Client) error) error {
+	pc, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	defer p.release(pc)
+	return f(pc.Client)
+}
+
+// Clients returns the clients of the pool.
+func (p *Pool) Clients() []*Client {
+	clients := make([]*Client, len(p.clients))
+	for i, pc := range p.clients {
+		clients[i] = pc.Client
+	}
+	return clients
+}
+
+// Read executes a synchronous read request with one of the clients.
+// See Client.Read.
+func (p *Pool) Read(req *ua.ReadRequest) (*ua.ReadResponse, error) {
+	return p.ReadWithContext(context.Background(), req)
+}
+
+// ReadWithContext executes a synchronous read request with one of the
+// clients. See Client.ReadWithContext.
+func (p *Pool) ReadWithContext(ctx context.Context, req *ua.ReadRequest) (res *ua.ReadResponse, err error) {
+	err = p.Do(func(c *Client) error {
+		res, err = c.ReadWithContext(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// Write executes a synchronous write request with one of the clients.
+// See Client.Write.
+func (p *Pool) Write(req *ua.WriteRequest) (*ua.WriteResponse, error) {
+	return p.WriteWithContext(context.Background(), req)
+}
+
+// WriteWithContext executes a synchronous write request with one of the
+// clients. See Client.WriteWithContext.
+func (p *Pool) WriteWithContext(ctx context.Context, req *ua.WriteRequest) (res *ua.WriteResponse, err error) {
+	err = p.Do(func(c *Client) error {
+		res, err = c.WriteWithContext(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// Call executes a synchronous call request for a single method with one
+// of the clients. See Client.Call.
+func (p *Pool) Call(req *ua.CallMethodRequest) (*ua.CallMethodResult, error) {
+	return p.CallWithContext(context.Background(), req)
+}
+
+// CallWithContext executes a synchronous call request for a single
+// method with one of the clients. See Client.CallWithContext.
+func (p *Pool) CallWithContext(ctx context.Context, req *ua.CallMethodRequest) (res *ua.CallMethodResult, err error) {
+	err = p.Do(func(c *Client) error {
+		res, err = c.CallWithContext(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// Close rejects new requests with ErrPoolClosed, waits for the requests
+// in flight to finish and closes all clients. If ctx is done before all
+// requests have finished the clients are closed anyway and the error of
+// the context is returned.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+	for _, pc := range p.clients {
+		pc.CloseWithContext(ctx)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package opcua
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolPick(t *testing.T) {
+	p := NewPool("opc.tcp://example.com:4840", PoolSize(3))
+	for _, pc := range p.clients {
+		pc.setState(Connected)
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		seen := map[*poolClient]bool{}
+		for i := 0; i < 3; i++ {
+			seen[p.pick()] = true
+		}
+		if got, want := len(seen), 3; got != want {
+			t.Fatalf("got %d clients want %d", got, want)
+		}
+	})
+
+	t.Run("least loaded", func(t *testing.T) {
+		p.clients[0].load, p.clients[1].load, p.clients[2].load = 2, 1, 3
+		defer func() { p.clients[0].load, p.clients[1].load, p.clients[2].load = 0, 0, 0 }()
+		for i := 0; i < 3; i++ {
+			if got, want := p.pick(), p.clients[1]; got != want {
+				t.Fatalf("got client %p want %p", got, want)
+			}
+		}
+	})
+
+	t.Run("unhealthy", func(t *testing.T) {
+		p.clients[0].unhealthy = 1
+		p.clients[1].setState(Reconnecting)
+		p.clients[2].load = 5
+		defer func() {
+			p.clients[0].unhealthy = 0
+			p.clients[1].setState(Connected)
+			p.clients[2].load = 0
+		}()
+		for i := 0; i < 3; i++ {
+			if got, want := p.pick(), p.clients[2]; got != want {
+				t.Fatalf("got client %p want %p", got, want)
+			}
+		}
+	})
+}
+
+func TestPoolClose(t *testing.T) {
+	p := NewPool("opc.tcp://example.com:4840", PoolSize(2))
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	go p.Do(func(c *Client) error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	closed := make(chan error)
+	go func() { closed <- p.Close(context.Background()) }()
+
+	// wait until the pool rejects new requests.
+	for {
+		if err := p.Do(func(*Client) error { return nil }); errors.Is(err, ErrPoolClosed) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case <-closed:
+		t.Fatal("pool closed before the request finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(finish)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Connect(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("got error %v want %v", err, ErrPoolClosed)
+	}
+}
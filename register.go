@@ -0,0 +1,207 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// DefaultRegisterInterval is the interval in which a ServerRegistration
+// registers the server again. Discovery servers remove servers which
+// have not registered again within their cleanup interval.
+const DefaultRegisterInterval = 10 * time.Minute
+
+// unregisterTimeout is the time for removing the server from the
+// discovery server when the registration stops.
+const unregisterTimeout = 10 * time.Second
+
+// RegisterServer2 registers the server with the discovery server of the
+// client. The discovery configuration contains the parameters for the
+// discovery of the server, e.g. a ua.MdnsDiscoveryConfiguration, and the
+// response contains a ConfigurationResult for every parameter. Set
+// IsOnline of the server to false to remove the registration.
+//
+// See Part 4, 5.4.6
+func (c *Client) RegisterServer2(ctx context.Context, server *ua.RegisteredServer, config ...*ua.ExtensionObject) (*ua.RegisterServer2Response, error) {
+	stats.Client().Add("RegisterServer2", 1)
+
+	req := &ua.RegisterServer2Request{
+		Server:                 server,
+		DiscoveryConfiguration: config,
+	}
+	var res *ua.RegisterServer2Response
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	return res, err
+}
+
+// RegisterServer registers the server with the discovery server of the
+// client with the RegisterServer service which discovery servers without
+// support for RegisterServer2 provide.
+//
+// See Part 4, 5.4.5
+func (c *Client) RegisterServer(ctx context.Context, server *ua.RegisteredServer) (*ua.RegisterServerResponse, error) {
+	stats.Client().Add("RegisterServer", 1)
+
+	req := &ua.RegisterServerRequest{
+		Server: server,
+	}
+	var res *ua.RegisterServerResponse
+	err := c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	return res, err
+}
+
+// RegisterServer registers the server once with the discovery server,
+// e.g. a Local Discovery Server (LDS), and returns the results for the
+// discovery configuration. If the discovery server does not support
+// RegisterServer2 the server is registered with RegisterServer and the
+// results are nil.
+//
+// Discovery servers usually accept registrations only over secure
+// channels with security. Use the opts to configure the certificate of
+// the server and the security of the secure channel.
+func RegisterServer(ctx context.Context, discoveryURL string, server *ua.RegisteredServer, config []*ua.ExtensionObject, opts ...Option) ([]ua.StatusCode, error) {
+	opts = append(opts, AutoReconnect(false))
+	c := NewClient(discoveryURL, opts...)
+	if err := c.Dial(ctx); err != nil {
+		return nil, err
+	}
+	defer c.CloseWithContext(ctx)
+
+	res, err := c.RegisterServer2(ctx, server, config...)
+	switch {
+	case err == nil:
+		return res.ConfigurationResults, nil
+	case errors.Is(err, ua.StatusBadServiceUnsupported):
+		_, err = c.RegisterServer(ctx, server)
+		return nil, err
+	default:
+		return nil, err
+	}
+}
+
+// ServerRegistration registers a server periodically with a discovery
+// server until it is stopped. The server is removed from the discovery
+// server when the registration stops.
+type ServerRegistration struct {
+	discoveryURL string
+	server       *ua.RegisteredServer
+	config       []*ua.ExtensionObject
+	opts         []Option
+	interval     time.Duration
+
+	// log logs the failed registrations with the discovery url.
+	log logger.Logger
+
+	// mu guards results and err.
+	mu      sync.Mutex
+	results []ua.StatusCode
+	err     error
+
+	stopOnce sync.Once
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// StartServerRegistration registers the server with the discovery server
+// and then again in the interval until Stop is called or ctx is done. An
+// interval of 0 uses DefaultRegisterInterval. It returns an error if the
+// first registration fails. See RegisterServer for the config and the
+// opts.
+func StartServerRegistration(ctx context.Context, discoveryURL string, server *ua.RegisteredServer, interval time.Duration, config []*ua.ExtensionObject, opts ...Option) (*ServerRegistration, error) {
+	if interval <= 0 {
+		interval = DefaultRegisterInterval
+	}
+	r := &ServerRegistration{
+		discoveryURL: discoveryURL,
+		server:       server,
+		config:       config,
+		opts:         opts,
+		interval:     interval,
+		log:          logger.With(ApplyConfig(opts...).logger, "discovery_url", discoveryURL),
+		done:         make(chan struct{}),
+	}
+	if err := r.register(ctx, true); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	go r.run(ctx)
+	return r, nil
+}
+
+// ConfigurationResults returns the results for the discovery
+// configuration of the last successful registration.
+func (r *ServerRegistration) ConfigurationResults() []ua.StatusCode {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.results
+}
+
+// Err returns the error of the last registration or nil if it was
+// successful.
+func (r *ServerRegistration) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Stop stops the periodic registration and removes the server from the
+// discovery server. It returns the error of the removal.
+func (r *ServerRegistration) Stop() error {
+	r.stopOnce.Do(r.cancel)
+	<-r.done
+	return r.Err()
+}
+
+func (r *ServerRegistration) run(ctx context.Context) {
+	defer close(r.done)
+
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// the context of the registration is done
+			// and cannot be used to unregister.
+			uctx, cancel := context.WithTimeout(context.Background(), unregisterTimeout)
+			if err := r.register(uctx, false); err != nil {
+				r.log.Warn("register: unregister failed", "err", err)
+			}
+			cancel()
+			return
+		case <-t.C:
+			if err := r.register(ctx, true); err != nil {
+				r.log.Warn("register: register failed", "err", err)
+			}
+		}
+	}
+}
+
+// register registers the server with IsOnline set to online.
+func (r *ServerRegistration) register(ctx context.Context, online bool) error {
+	srv := *r.server
+	srv.IsOnline = online
+	results, err := RegisterServer(ctx, r.discoveryURL, &srv, r.config, r.opts...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+	if err == nil {
+		r.results = results
+	}
+	return err
+}
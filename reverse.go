@@ -0,0 +1,252 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+)
+
+// ErrReverseListenerClosed is returned when a client waits for a reverse
+// connection on a closed listener.
+var ErrReverseListenerClosed = errors.New("reverse listener closed")
+
+// reverseHelloTimeout is the time a server has to send the ReverseHello
+// message after it has opened the connection.
+const reverseHelloTimeout = 10 * time.Second
+
+// The listener waits before it accepts the next connection when Accept
+// fails, e.g. because the process has run out of file descriptors. The
+// delay starts at minAcceptDelay and doubles up to maxAcceptDelay.
+const (
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
+
+// ReverseListener accepts the reverse connections of servers and hands
+// them to the clients which were created with the ReverseConnect option.
+//
+// Servers which are not reachable from the client, e.g. because they are
+// behind a NAT, open the connection to the client and send a ReverseHello
+// message with their ServerURI and EndpointURL. The client then continues
+// with the HEL/ACK handshake and opens the secure channel over this
+// connection as if it had dialed the server.
+//
+// The clients of the listener are its allow-list. Connections of servers
+// are only accepted if a client with the ServerURI and the EndpointURL of
+// the ReverseHello message uses the listener. All other connections are
+// closed.
+//
+// Specification: Part 6, 7.1.3
+type ReverseListener struct {
+	l reverseAccepter
+
+	// mu guards servers.
+	mu      sync.Mutex
+	servers map[reverseServer]chan *uacp.Conn
+
+	// log logs the accepted and rejected connections.
+	log logger.Logger
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// reverseAccepter accepts the connections of the servers. It is
+// implemented by uacp.ReverseListener.
+type reverseAccepter interface {
+	Accept() (*uacp.Conn, error)
+	Close() error
+	Addr() net.Addr
+}
+
+// reverseServer identifies a server by the values of its ReverseHello
+// message.
+type reverseServer struct {
+	serverURI   string
+	endpointURL string
+}
+
+// ListenReverse listens for reverse connections of servers on listenAddr
+// until ctx is cancelled or Close is called. listenAddr is either a
+// "host:port" address or an endpoint in "opc.tcp://<addr[:port]>" format.
+// The listener uses the logger of the Logger option. Other options are
+// ignored.
+func ListenReverse(ctx context.Context, listenAddr string, opts ...Option) (*ReverseListener, error) {
+	l, err := uacp.ListenReverse(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	return newReverseListener(ctx, l, logger.OrDefault(ApplyConfig(opts...).logger)), nil
+}
+
+func newReverseListener(ctx context.Context, l reverseAccepter, log logger.Logger) *ReverseListener {
+	rl := &ReverseListener{
+		l:       l,
+		servers: make(map[reverseServer]chan *uacp.Conn),
+		log:     log,
+		done:    make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			rl.Close()
+		case <-rl.done:
+		}
+	}()
+	go rl.run()
+	return rl
+}
+
+// Addr returns the network address of the listener.
+func (l *ReverseListener) Addr() string {
+	return l.l.Addr().String()
+}
+
+// Close stops the listener. Clients which wait for a reverse connection
+// return ErrReverseListenerClosed.
+func (l *ReverseListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.l.Close()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, ch := range l.servers {
+			select {
+			case c := <-ch:
+				c.Close()
+			default:
+			}
+		}
+	})
+	return err
+}
+
+func (l *ReverseListener) run() {
+	var delay time.Duration
+	for {
+		c, err := l.l.Accept()
+		if err != nil {
+			select {
+			case <-l.done:
+				return
+			default:
+			}
+			delay = nextAcceptDelay(delay)
+			l.log.Warn("reverse: accept failed", "err", err, "retry_in", delay)
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-l.done:
+				t.Stop()
+				return
+			}
+			continue
+		}
+		delay = 0
+		go l.dispatch(c)
+	}
+}
+
+// nextAcceptDelay returns the delay after a failed Accept call when the
+// previous call waited for d.
+func nextAcceptDelay(d time.Duration) time.Duration {
+	if d == 0 {
+		return minAcceptDelay
+	}
+	if d *= 2; d > maxAcceptDelay {
+		return maxAcceptDelay
+	}
+	return d
+}
+
+// dispatch reads the ReverseHello message of the server and hands the
+// connection to the clients of the server. A connection which no client
+// has picked up yet is replaced so that the client gets the most recent
+// connection of the server.
+func (l *ReverseListener) dispatch(c *uacp.Conn) {
+	log := logger.With(l.log, "conn_id", c.ID())
+
+	c.SetReadDeadline(time.Now().Add(reverseHelloTimeout))
+	rhe, err := c.ReceiveReverseHello()
+	if err != nil {
+		log.Warn("reverse: failed to receive reverse hello", "err", err)
+		c.Close()
+		return
+	}
+	c.SetReadDeadline(time.Time{})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch, ok := l.servers[reverseServer{rhe.ServerURI, rhe.EndpointURL}]
+	if !ok {
+		log.Warn("reverse: rejecting server", "server_uri", rhe.ServerURI, "endpoint", rhe.EndpointURL)
+		c.SendError(ua.StatusBadTCPEndpointURLInvalid)
+		c.Close()
+		return
+	}
+
+	select {
+	case <-l.done:
+		c.Close()
+		return
+	default:
+	}
+
+	select {
+	case old := <-ch:
+		old.Close()
+	default:
+	}
+	ch <- c
+	log.Info("reverse: accepted server", "server_uri", rhe.ServerURI, "endpoint", rhe.EndpointURL)
+}
+
+// dial waits for a reverse connection of the server and performs the
+// HEL/ACK handshake with the parameters of the dialer. The server is
+// added to the allow-list of the listener on the first call.
+func (l *ReverseListener) dial(ctx context.Context, d *uacp.Dialer, serverURI, endpointURL string) (*uacp.Conn, error) {
+	key := reverseServer{serverURI, endpointURL}
+
+	l.mu.Lock()
+	ch, ok := l.servers[key]
+	if !ok {
+		ch = make(chan *uacp.Conn, 1)
+		l.servers[key] = ch
+	}
+	l.mu.Unlock()
+
+	logger.OrDefault(d.Logger).Debug("reverse: waiting for server", "server_uri", serverURI, "endpoint", endpointURL)
+	var rc *uacp.Conn
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, ErrReverseListenerClosed
+	case rc = <-ch:
+	}
+
+	c, err := uacp.NewConn(rc.TCPConn, d.ClientACK)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	c.SetLogger(d.Logger)
+	if err := c.Handshake(endpointURL); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
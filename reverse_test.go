@@ -0,0 +1,128 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/uacp"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+// fakeAccepter returns the queued connections and errors from Accept.
+type fakeAccepter struct {
+	results chan interface{}
+	done    chan struct{}
+}
+
+func (a *fakeAccepter) Accept() (*uacp.Conn, error) {
+	select {
+	case r := <-a.results:
+		if err, ok := r.(error); ok {
+			return nil, err
+		}
+		return r.(*uacp.Conn), nil
+	case <-a.done:
+		return nil, errors.New("closed")
+	}
+}
+
+func (a *fakeAccepter) Close() error {
+	close(a.done)
+	return nil
+}
+
+func (a *fakeAccepter) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// delayLogger reports the retry delays of failed Accept calls.
+type delayLogger struct {
+	logger.Logger
+	delays chan time.Duration
+}
+
+func (l *delayLogger) Warn(msg string, kv ...interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i] == "retry_in" {
+			l.delays <- kv[i+1].(time.Duration)
+		}
+	}
+}
+
+// testTCPConn returns the accepted side of a local TCP connection.
+func testTCPConn(t *testing.T) *uacp.Conn {
+	t.Helper()
+	ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	dc, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dc.Close() })
+
+	tc, err := ln.AcceptTCP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := uacp.NewConn(tc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestReverseListenerAcceptBackoff(t *testing.T) {
+	fail := errors.New("too many open files")
+	a := &fakeAccepter{results: make(chan interface{}, 5), done: make(chan struct{})}
+	a.results <- fail
+	a.results <- fail
+	a.results <- fail
+	a.results <- testTCPConn(t)
+	a.results <- fail
+
+	log := &delayLogger{Logger: logger.Nop, delays: make(chan time.Duration, 5)}
+	l := newReverseListener(context.Background(), a, log)
+	defer l.Close()
+
+	var got []time.Duration
+	for len(got) < 4 {
+		select {
+		case d := <-log.delays:
+			got = append(got, d)
+		case <-time.After(time.Second):
+			t.Fatalf("got delays %v want 4", got)
+		}
+	}
+	want := []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 5 * time.Millisecond}
+	verify.Values(t, "", got, want)
+}
+
+func TestNextAcceptDelay(t *testing.T) {
+	tests := []struct {
+		d, want time.Duration
+	}{
+		{0, 5 * time.Millisecond},
+		{5 * time.Millisecond, 10 * time.Millisecond},
+		{640 * time.Millisecond, time.Second},
+		{time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		if got := nextAcceptDelay(tt.d); got != tt.want {
+			t.Errorf("nextAcceptDelay(%v) got %v want %v", tt.d, got, tt.want)
+		}
+	}
+}
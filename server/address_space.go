@@ -0,0 +1,620 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// NodeManager provides the nodes of the address space to the services
+// of the server. The server calls the methods concurrently for requests
+// of different sessions.
+//
+// AddressSpace is the in-memory implementation which is used by the
+// server. The interface allows other implementations, e.g. for nodes
+// which are backed by a database, and is the extension point for
+// monitored items which will need to be notified of value changes.
+type NodeManager interface {
+	// Read returns the value of the attribute of a node. Errors are
+	// reported with the status code of the data value.
+	Read(ctx context.Context, rv *ua.ReadValueID) *ua.DataValue
+
+	// Write writes the value of the attribute of a node.
+	Write(ctx context.Context, wv *ua.WriteValue) ua.StatusCode
+
+	// Browse returns all references of the node which match the browse
+	// description. The server handles the continuation points.
+	Browse(ctx context.Context, bd *ua.BrowseDescription) ([]*ua.ReferenceDescription, ua.StatusCode)
+}
+
+var _ NodeManager = (*AddressSpace)(nil)
+
+// reference is a reference from a node to the target node.
+type reference struct {
+	refType   *ua.NodeID
+	isForward bool
+	target    *ua.NodeID
+}
+
+// node is a node in the address space.
+type node struct {
+	id          *ua.NodeID
+	class       ua.NodeClass
+	browseName  *ua.QualifiedName
+	displayName *ua.LocalizedText
+	description *ua.LocalizedText
+	refs        []*reference
+
+	// variable attributes
+	dataType    *ua.NodeID
+	valueRank   int32
+	accessLevel ua.AccessLevelType
+	value       *ua.DataValue
+
+	// valueFunc returns the value of variables whose value is
+	// computed when it is read.
+	valueFunc func() *ua.DataValue
+}
+
+// typeDefinition returns the target of the HasTypeDefinition reference.
+func (n *node) typeDefinition() *ua.NodeID {
+	for _, r := range n.refs {
+		if r.isForward && r.refType.IntID() == id.HasTypeDefinition && r.refType.Namespace() == 0 {
+			return r.target
+		}
+	}
+	return nil
+}
+
+// NodeOption configures a node added with AddObject or AddVariable.
+type NodeOption func(*node)
+
+// WithNodeID sets the node id of the new node instead of deriving it
+// from the node id of the parent and the name.
+func WithNodeID(id *ua.NodeID) NodeOption {
+	return func(n *node) {
+		n.id = id
+	}
+}
+
+// WithDescription sets the description of the new node.
+func WithDescription(s string) NodeOption {
+	return func(n *node) {
+		n.description = ua.NewLocalizedText(s)
+	}
+}
+
+// Writable allows clients to write the value of the new variable.
+// Variables are read-only by default.
+func Writable() NodeOption {
+	return func(n *node) {
+		n.accessLevel |= ua.AccessLevelTypeCurrentWrite
+	}
+}
+
+// AddressSpace is an in-memory address space which contains the standard
+// nodes required by clients and the nodes added by the application. It is
+// safe for concurrent use.
+type AddressSpace struct {
+	mu         sync.RWMutex
+	nodes      map[string]*node
+	namespaces []string
+	startTime  time.Time
+}
+
+// NewAddressSpace returns an address space with the standard nodes of
+// namespace 0. uri is the uri of namespace 1 which contains the nodes
+// added with AddObject and AddVariable.
+func NewAddressSpace(uri string) *AddressSpace {
+	as := &AddressSpace{
+		nodes:      map[string]*node{},
+		namespaces: []string{"http://opcfoundation.org/UA/", uri},
+		startTime:  time.Now(),
+	}
+	as.addStandardNodes(uri)
+	return as
+}
+
+// Namespaces returns the uris of the namespaces.
+func (as *AddressSpace) Namespaces() []string {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+	return append([]string(nil), as.namespaces...)
+}
+
+// AddNamespace adds a namespace and returns its index. If the namespace
+// already exists its index is returned.
+func (as *AddressSpace) AddNamespace(uri string) uint16 {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	for i, ns := range as.namespaces {
+		if ns == uri {
+			return uint16(i)
+		}
+	}
+	as.namespaces = append(as.namespaces, uri)
+	return uint16(len(as.namespaces) - 1)
+}
+
+// AddObject adds an object with the given name below the parent node and
+// returns its node id. If parent is nil the object is added to the
+// Objects folder. The node id is a string node id in namespace 1 which
+// is the dot-separated path of names from the Objects folder, e.g.
+// "ns=1;s=MyDevice.Motor", unless WithNodeID is used.
+func (as *AddressSpace) AddObject(parent *ua.NodeID, name string, opts ...NodeOption) (*ua.NodeID, error) {
+	n := &node{
+		class:       ua.NodeClassObject,
+		browseName:  &ua.QualifiedName{NamespaceIndex: 1, Name: name},
+		displayName: ua.NewLocalizedText(name),
+	}
+	return as.addNode(parent, n, ua.NewNumericNodeID(0, id.BaseObjectType), opts)
+}
+
+// AddVariable adds a variable with the given name and initial value below
+// the parent node and returns its node id. See AddObject for the parent
+// and the node id.
+//
+// The data type and value rank of the variable are derived from the
+// value. Clients can only write values of the same type and only if the
+// variable was added with the Writable option.
+func (as *AddressSpace) AddVariable(parent *ua.NodeID, name string, v *ua.Variant, opts ...NodeOption) (*ua.NodeID, error) {
+	if v == nil {
+		return nil, errors.Errorf("variable %s has no value", name)
+	}
+	n := &node{
+		class:       ua.NodeClassVariable,
+		browseName:  &ua.QualifiedName{NamespaceIndex: 1, Name: name},
+		displayName: ua.NewLocalizedText(name),
+		dataType:    dataTypeOf(v),
+		valueRank:   valueRankOf(v),
+		accessLevel: ua.AccessLevelTypeCurrentRead,
+		value:       newDataValue(v, time.Now()),
+	}
+	return as.addNode(parent, n, ua.NewNumericNodeID(0, id.BaseDataVariableType), opts)
+}
+
+func (as *AddressSpace) addNode(parent *ua.NodeID, n *node, typeDef *ua.NodeID, opts []NodeOption) (*ua.NodeID, error) {
+	if n.browseName.Name == "" {
+		return nil, ua.StatusBadBrowseNameInvalid
+	}
+	if parent == nil {
+		parent = ua.NewNumericNodeID(0, id.ObjectsFolder)
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	p := as.nodes[parent.String()]
+	if p == nil {
+		return nil, ua.StatusBadParentNodeIDInvalid
+	}
+	if n.id == nil {
+		name := n.browseName.Name
+		if parent.Namespace() == 1 && parent.Type() == ua.NodeIDTypeString {
+			name = parent.StringID() + "." + name
+		}
+		n.id = ua.NewStringNodeID(1, name)
+	}
+	if as.nodes[n.id.String()] != nil {
+		return nil, ua.StatusBadNodeIDExists
+	}
+
+	var refType uint32 = id.HasComponent
+	if td := p.typeDefinition(); td != nil && td.IntID() == id.FolderType && td.Namespace() == 0 {
+		refType = id.Organizes
+	}
+	as.add(n, typeDef)
+	as.link(p, n, refType)
+	return n.id, nil
+}
+
+// SetValue sets the value of a variable. The source timestamp is the
+// current time.
+func (as *AddressSpace) SetValue(nodeID *ua.NodeID, v *ua.Variant) error {
+	return as.SetDataValue(nodeID, newDataValue(v, time.Now()))
+}
+
+// SetDataValue sets the value, status code and source timestamp of a
+// variable.
+func (as *AddressSpace) SetDataValue(nodeID *ua.NodeID, dv *ua.DataValue) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	n := as.nodes[nodeID.String()]
+	if n == nil {
+		return ua.StatusBadNodeIDUnknown
+	}
+	if n.class != ua.NodeClassVariable || n.valueFunc != nil {
+		return ua.StatusBadNotWritable
+	}
+	n.value = dv
+	return nil
+}
+
+// Value returns the current value of a variable.
+func (as *AddressSpace) Value(nodeID *ua.NodeID) (*ua.DataValue, error) {
+	dv := as.Read(context.Background(), &ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDValue})
+	if dv.Status != ua.StatusOK {
+		return nil, dv.Status
+	}
+	return dv, nil
+}
+
+// Read implements NodeManager.
+func (as *AddressSpace) Read(ctx context.Context, rv *ua.ReadValueID) *ua.DataValue {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	n := as.nodes[rv.NodeID.String()]
+	if n == nil {
+		return errorValue(ua.StatusBadNodeIDUnknown)
+	}
+	if rv.IndexRange != "" {
+		return errorValue(ua.StatusBadIndexRangeInvalid)
+	}
+	if rv.DataEncoding != nil && rv.DataEncoding.Name != "" {
+		return errorValue(ua.StatusBadDataEncodingUnsupported)
+	}
+
+	var v interface{}
+	switch rv.AttributeID {
+	case ua.AttributeIDNodeID:
+		v = n.id
+	case ua.AttributeIDNodeClass:
+		v = int32(n.class)
+	case ua.AttributeIDBrowseName:
+		v = n.browseName
+	case ua.AttributeIDDisplayName:
+		v = n.displayName
+	case ua.AttributeIDDescription:
+		v = n.description
+		if n.description == nil {
+			v = &ua.LocalizedText{}
+		}
+	case ua.AttributeIDWriteMask, ua.AttributeIDUserWriteMask:
+		v = uint32(0)
+	case ua.AttributeIDEventNotifier:
+		if n.class != ua.NodeClassObject {
+			return errorValue(ua.StatusBadAttributeIDInvalid)
+		}
+		v = byte(0)
+	default:
+		if n.class != ua.NodeClassVariable {
+			return errorValue(ua.StatusBadAttributeIDInvalid)
+		}
+		return n.readVariable(rv.AttributeID)
+	}
+	return &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)}
+}
+
+// readVariable returns the value of a variable attribute.
+func (n *node) readVariable(attr ua.AttributeID) *ua.DataValue {
+	var v interface{}
+	switch attr {
+	case ua.AttributeIDValue:
+		if n.valueFunc != nil {
+			return n.valueFunc()
+		}
+		dv := *n.value
+		return &dv
+	case ua.AttributeIDDataType:
+		v = n.dataType
+	case ua.AttributeIDValueRank:
+		v = n.valueRank
+	case ua.AttributeIDArrayDimensions:
+		if n.valueRank <= 0 {
+			v = nil
+		} else {
+			v = make([]uint32, n.valueRank)
+		}
+	case ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel:
+		v = byte(n.accessLevel)
+	case ua.AttributeIDMinimumSamplingInterval:
+		v = float64(0)
+	case ua.AttributeIDHistorizing:
+		v = false
+	default:
+		return errorValue(ua.StatusBadAttributeIDInvalid)
+	}
+	return &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)}
+}
+
+// Write implements NodeManager. Only the value of writable variables can
+// be written.
+func (as *AddressSpace) Write(ctx context.Context, wv *ua.WriteValue) ua.StatusCode {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	n := as.nodes[wv.NodeID.String()]
+	switch {
+	case n == nil:
+		return ua.StatusBadNodeIDUnknown
+	case wv.AttributeID != ua.AttributeIDValue:
+		return ua.StatusBadNotWritable
+	case n.class != ua.NodeClassVariable || n.accessLevel&ua.AccessLevelTypeCurrentWrite == 0:
+		return ua.StatusBadNotWritable
+	case wv.IndexRange != "":
+		return ua.StatusBadIndexRangeInvalid
+	case wv.Value == nil || wv.Value.Value == nil:
+		return ua.StatusBadTypeMismatch
+	case !n.accepts(wv.Value.Value):
+		return ua.StatusBadTypeMismatch
+	}
+
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+		Value:           wv.Value.Value,
+		SourceTimestamp: time.Now(),
+	}
+	if wv.Value.Has(ua.DataValueStatusCode) {
+		dv.EncodingMask |= ua.DataValueStatusCode
+		dv.Status = wv.Value.Status
+	}
+	if wv.Value.Has(ua.DataValueSourceTimestamp) {
+		dv.SourceTimestamp = wv.Value.SourceTimestamp
+	}
+	n.value = dv
+	return ua.StatusOK
+}
+
+// accepts returns true if the value matches the data type and value
+// rank of the variable.
+func (n *node) accepts(v *ua.Variant) bool {
+	array := v.Has(ua.VariantArrayValues)
+	if n.valueRank == -1 && array || n.valueRank >= 0 && !array {
+		return false
+	}
+	if n.dataType.Namespace() != 0 {
+		return true
+	}
+	t := n.dataType.IntID()
+	if t == id.BaseDataType || t > id.DiagnosticInfo {
+		return true
+	}
+	return v.Type() == ua.TypeID(t)
+}
+
+// Browse implements NodeManager.
+func (as *AddressSpace) Browse(ctx context.Context, bd *ua.BrowseDescription) ([]*ua.ReferenceDescription, ua.StatusCode) {
+	as.mu.RLock()
+	defer as.mu.RUnlock()
+
+	n := as.nodes[bd.NodeID.String()]
+	if n == nil {
+		return nil, ua.StatusBadNodeIDUnknown
+	}
+	if bd.BrowseDirection > ua.BrowseDirectionBoth {
+		return nil, ua.StatusBadBrowseDirectionInvalid
+	}
+	refType := bd.ReferenceTypeID
+	if refType != nil && refType.IntID() == 0 && refType.Namespace() == 0 {
+		refType = nil
+	}
+	if refType != nil && !isReferenceType(refType) {
+		return nil, ua.StatusBadReferenceTypeIDInvalid
+	}
+
+	var refs []*ua.ReferenceDescription
+	for _, r := range n.refs {
+		switch {
+		case bd.BrowseDirection == ua.BrowseDirectionForward && !r.isForward:
+			continue
+		case bd.BrowseDirection == ua.BrowseDirectionInverse && r.isForward:
+			continue
+		case refType != nil && !isSubtype(r.refType, refType, bd.IncludeSubtypes):
+			continue
+		}
+		t := as.nodes[r.target.String()]
+		if t == nil {
+			continue
+		}
+		if bd.NodeClassMask != 0 && bd.NodeClassMask&uint32(t.class) == 0 {
+			continue
+		}
+		typeDef := ua.NewTwoByteExpandedNodeID(0)
+		if td := t.typeDefinition(); td != nil {
+			typeDef = ua.NewExpandedNodeID(td, "", 0)
+		}
+		refs = append(refs, &ua.ReferenceDescription{
+			ReferenceTypeID: r.refType,
+			IsForward:       r.isForward,
+			NodeID:          ua.NewExpandedNodeID(t.id, "", 0),
+			BrowseName:      t.browseName,
+			DisplayName:     t.displayName,
+			NodeClass:       t.class,
+			TypeDefinition:  typeDef,
+		})
+	}
+	return refs, ua.StatusOK
+}
+
+// add adds the node and its type definition reference. The lock must
+// be held.
+func (as *AddressSpace) add(n *node, typeDef *ua.NodeID) {
+	if typeDef != nil {
+		n.refs = append(n.refs, &reference{
+			refType:   ua.NewNumericNodeID(0, id.HasTypeDefinition),
+			isForward: true,
+			target:    typeDef,
+		})
+	}
+	as.nodes[n.id.String()] = n
+}
+
+// link adds a forward reference from the source to the target node and
+// the inverse reference. The lock must be held.
+func (as *AddressSpace) link(source, target *node, refType uint32) {
+	rt := ua.NewNumericNodeID(0, refType)
+	source.refs = append(source.refs, &reference{refType: rt, isForward: true, target: target.id})
+	target.refs = append(target.refs, &reference{refType: rt, isForward: false, target: source.id})
+}
+
+// addStandardNodes adds the folders and the Server object of namespace 0.
+func (as *AddressSpace) addStandardNodes(uri string) {
+	ns0 := func(i uint32) *ua.NodeID { return ua.NewNumericNodeID(0, i) }
+	folder := func(i uint32, name string) *node {
+		n := &node{
+			id:          ns0(i),
+			class:       ua.NodeClassObject,
+			browseName:  &ua.QualifiedName{Name: name},
+			displayName: ua.NewLocalizedText(name),
+		}
+		as.add(n, ns0(id.FolderType))
+		return n
+	}
+	variable := func(parent *node, i uint32, name string, refType, typeDef uint32, dataType *ua.NodeID, rank int32, fn func() *ua.DataValue) {
+		n := &node{
+			id:          ns0(i),
+			class:       ua.NodeClassVariable,
+			browseName:  &ua.QualifiedName{Name: name},
+			displayName: ua.NewLocalizedText(name),
+			dataType:    dataType,
+			valueRank:   rank,
+			accessLevel: ua.AccessLevelTypeCurrentRead,
+			valueFunc:   fn,
+		}
+		as.add(n, ns0(typeDef))
+		as.link(parent, n, refType)
+	}
+
+	root := folder(id.RootFolder, "Root")
+	objects := folder(id.ObjectsFolder, "Objects")
+	as.link(root, objects, id.Organizes)
+	as.link(root, folder(id.TypesFolder, "Types"), id.Organizes)
+	as.link(root, folder(id.ViewsFolder, "Views"), id.Organizes)
+
+	srv := &node{
+		id:          ns0(id.Server),
+		class:       ua.NodeClassObject,
+		browseName:  &ua.QualifiedName{Name: "Server"},
+		displayName: ua.NewLocalizedText("Server"),
+	}
+	as.add(srv, ns0(id.ServerType))
+	as.link(objects, srv, id.Organizes)
+
+	value := func(v interface{}) func() *ua.DataValue {
+		return func() *ua.DataValue { return newDataValue(ua.MustVariant(v), as.startTime) }
+	}
+	// the lock is held by Read when the value functions are called
+	variable(srv, id.Server_NamespaceArray, "NamespaceArray", id.HasProperty, id.PropertyType, ns0(id.String), 1, func() *ua.DataValue {
+		return newDataValue(ua.MustVariant(append([]string(nil), as.namespaces...)), as.startTime)
+	})
+	variable(srv, id.Server_ServerArray, "ServerArray", id.HasProperty, id.PropertyType, ns0(id.String), 1, value([]string{uri}))
+	variable(srv, id.Server_ServerStatus, "ServerStatus", id.HasComponent, id.ServerStatusType, ns0(id.ServerStatusDataType), -1, func() *ua.DataValue {
+		return newDataValue(ua.MustVariant(ua.NewExtensionObject(as.serverStatus())), time.Now())
+	})
+
+	status := as.nodes[ns0(id.Server_ServerStatus).String()]
+	variable(status, id.Server_ServerStatus_StartTime, "StartTime", id.HasComponent, id.BaseDataVariableType, ns0(id.UtcTime), -1, value(as.startTime))
+	variable(status, id.Server_ServerStatus_CurrentTime, "CurrentTime", id.HasComponent, id.BaseDataVariableType, ns0(id.UtcTime), -1, func() *ua.DataValue {
+		now := time.Now()
+		return newDataValue(ua.MustVariant(now), now)
+	})
+	variable(status, id.Server_ServerStatus_State, "State", id.HasComponent, id.BaseDataVariableType, ns0(id.ServerState), -1, value(int32(ua.ServerStateRunning)))
+}
+
+func (as *AddressSpace) serverStatus() *ua.ServerStatusDataType {
+	return &ua.ServerStatusDataType{
+		StartTime:   as.startTime,
+		CurrentTime: time.Now(),
+		State:       ua.ServerStateRunning,
+		BuildInfo: &ua.BuildInfo{
+			ProductURI:  as.namespaces[1],
+			ProductName: "gopcua server",
+		},
+		ShutdownReason: &ua.LocalizedText{},
+	}
+}
+
+// referenceTypes maps the standard reference types to their super type.
+var referenceTypes = map[uint32]uint32{
+	id.References:                0,
+	id.HierarchicalReferences:    id.References,
+	id.NonHierarchicalReferences: id.References,
+	id.HasChild:                  id.HierarchicalReferences,
+	id.Organizes:                 id.HierarchicalReferences,
+	id.HasEventSource:            id.HierarchicalReferences,
+	id.HasNotifier:               id.HasEventSource,
+	id.Aggregates:                id.HasChild,
+	id.HasSubtype:                id.HasChild,
+	id.HasComponent:              id.Aggregates,
+	id.HasOrderedComponent:       id.HasComponent,
+	id.HasProperty:               id.Aggregates,
+	id.HasTypeDefinition:         id.NonHierarchicalReferences,
+	id.HasModellingRule:          id.NonHierarchicalReferences,
+	id.HasEncoding:               id.NonHierarchicalReferences,
+	id.HasDescription:            id.NonHierarchicalReferences,
+	id.GeneratesEvent:            id.NonHierarchicalReferences,
+}
+
+func isReferenceType(n *ua.NodeID) bool {
+	if n.Namespace() != 0 {
+		return false
+	}
+	_, ok := referenceTypes[n.IntID()]
+	return ok
+}
+
+// isSubtype returns true if refType is the same as superType or, if
+// includeSubtypes is set, a subtype of it.
+func isSubtype(refType, superType *ua.NodeID, includeSubtypes bool) bool {
+	if refType.Namespace() != 0 || superType.Namespace() != 0 {
+		return false
+	}
+	for t, want := refType.IntID(), superType.IntID(); t != 0; t = referenceTypes[t] {
+		if t == want {
+			return true
+		}
+		if !includeSubtypes {
+			return false
+		}
+	}
+	return false
+}
+
+// dataTypeOf returns the data type node id for the type of the value.
+func dataTypeOf(v *ua.Variant) *ua.NodeID {
+	switch t := v.Type(); {
+	case t == ua.TypeIDExtensionObject || t == ua.TypeIDVariant || t == ua.TypeIDDataValue:
+		return ua.NewNumericNodeID(0, id.BaseDataType)
+	default:
+		return ua.NewNumericNodeID(0, uint32(t))
+	}
+}
+
+// valueRankOf returns the value rank for the value.
+func valueRankOf(v *ua.Variant) int32 {
+	if !v.Has(ua.VariantArrayValues) {
+		return -1
+	}
+	if dims := v.ArrayDimensions(); len(dims) > 1 {
+		return int32(len(dims))
+	}
+	return 1
+}
+
+func newDataValue(v *ua.Variant, ts time.Time) *ua.DataValue {
+	return &ua.DataValue{
+		EncodingMask:    ua.DataValueValue | ua.DataValueSourceTimestamp,
+		Value:           v,
+		SourceTimestamp: ts,
+	}
+}
+
+func errorValue(code ua.StatusCode) *ua.DataValue {
+	return &ua.DataValue{
+		EncodingMask: ua.DataValueStatusCode,
+		Status:       code,
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+func TestAddressSpace_AddVariable(t *testing.T) {
+	as := NewAddressSpace("urn:test")
+
+	dev, err := as.AddObject(nil, "Device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dev.String(), "ns=1;s=Device"; got != want {
+		t.Fatalf("got object id %s want %s", got, want)
+	}
+	speed, err := as.AddVariable(dev, "Speed", ua.MustVariant(float64(1.5)), Writable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := speed.String(), "ns=1;s=Device.Speed"; got != want {
+		t.Fatalf("got variable id %s want %s", got, want)
+	}
+
+	if _, err := as.AddVariable(dev, "Speed", ua.MustVariant(int32(1))); err != ua.StatusBadNodeIDExists {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadNodeIDExists)
+	}
+	if _, err := as.AddObject(ua.NewStringNodeID(1, "Missing"), "X"); err != ua.StatusBadParentNodeIDInvalid {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadParentNodeIDInvalid)
+	}
+
+	if err := as.SetValue(speed, ua.MustVariant(float64(2.5))); err != nil {
+		t.Fatal(err)
+	}
+	dv, err := as.Value(speed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dv.Value.Value(), float64(2.5); got != want {
+		t.Fatalf("got value %v want %v", got, want)
+	}
+
+	dt := as.Read(context.Background(), &ua.ReadValueID{NodeID: speed, AttributeID: ua.AttributeIDDataType})
+	if got, want := dt.Value.Value().(*ua.NodeID).IntID(), uint32(id.Double); got != want {
+		t.Fatalf("got data type %d want %d", got, want)
+	}
+}
+
+func TestAddressSpace_Write(t *testing.T) {
+	as := NewAddressSpace("urn:test")
+	rw, _ := as.AddVariable(nil, "RW", ua.MustVariant(int32(1)), Writable())
+	ro, _ := as.AddVariable(nil, "RO", ua.MustVariant(int32(1)))
+
+	value := func(v interface{}) *ua.DataValue {
+		return &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)}
+	}
+	tests := []struct {
+		name string
+		wv   *ua.WriteValue
+		want ua.StatusCode
+	}{
+		{"writable", &ua.WriteValue{NodeID: rw, AttributeID: ua.AttributeIDValue, Value: value(int32(5))}, ua.StatusOK},
+		{"read-only", &ua.WriteValue{NodeID: ro, AttributeID: ua.AttributeIDValue, Value: value(int32(5))}, ua.StatusBadNotWritable},
+		{"type mismatch", &ua.WriteValue{NodeID: rw, AttributeID: ua.AttributeIDValue, Value: value("x")}, ua.StatusBadTypeMismatch},
+		{"array", &ua.WriteValue{NodeID: rw, AttributeID: ua.AttributeIDValue, Value: value([]int32{1})}, ua.StatusBadTypeMismatch},
+		{"attribute", &ua.WriteValue{NodeID: rw, AttributeID: ua.AttributeIDDisplayName, Value: value("x")}, ua.StatusBadNotWritable},
+		{"unknown node", &ua.WriteValue{NodeID: ua.NewStringNodeID(1, "X"), AttributeID: ua.AttributeIDValue, Value: value(int32(5))}, ua.StatusBadNodeIDUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := as.Write(context.Background(), tt.wv); got != tt.want {
+				t.Fatalf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+
+	dv, _ := as.Value(rw)
+	if got, want := dv.Value.Value(), int32(5); got != want {
+		t.Fatalf("got value %v want %v", got, want)
+	}
+}
+
+func TestAddressSpace_Browse(t *testing.T) {
+	as := NewAddressSpace("urn:test")
+	dev, _ := as.AddObject(nil, "Device")
+	as.AddVariable(dev, "Speed", ua.MustVariant(float64(1)))
+
+	browse := func(n *ua.NodeID, refType uint32, dir ua.BrowseDirection) []string {
+		t.Helper()
+		refs, code := as.Browse(context.Background(), &ua.BrowseDescription{
+			NodeID:          n,
+			BrowseDirection: dir,
+			ReferenceTypeID: ua.NewNumericNodeID(0, refType),
+			IncludeSubtypes: true,
+		})
+		if code != ua.StatusOK {
+			t.Fatalf("browse %s: %v", n, code)
+		}
+		var names []string
+		for _, r := range refs {
+			names = append(names, r.BrowseName.Name)
+		}
+		return names
+	}
+
+	if got := browse(ua.NewNumericNodeID(0, id.ObjectsFolder), id.HierarchicalReferences, ua.BrowseDirectionForward); len(got) != 2 || got[0] != "Server" || got[1] != "Device" {
+		t.Fatalf("got objects %v want [Server Device]", got)
+	}
+	if got := browse(dev, id.HasComponent, ua.BrowseDirectionForward); len(got) != 1 || got[0] != "Speed" {
+		t.Fatalf("got components %v want [Speed]", got)
+	}
+	if got := browse(dev, id.Organizes, ua.BrowseDirectionInverse); len(got) != 1 || got[0] != "Objects" {
+		t.Fatalf("got parents %v want [Objects]", got)
+	}
+	if got := browse(dev, id.HasProperty, ua.BrowseDirectionForward); len(got) != 0 {
+		t.Fatalf("got properties %v want none", got)
+	}
+}
+
+func TestIsSubtype(t *testing.T) {
+	ns0 := func(i uint32) *ua.NodeID { return ua.NewNumericNodeID(0, i) }
+	tests := []struct {
+		ref, super uint32
+		subtypes   bool
+		want       bool
+	}{
+		{id.HasComponent, id.HasComponent, false, true},
+		{id.HasComponent, id.HierarchicalReferences, false, false},
+		{id.HasComponent, id.HierarchicalReferences, true, true},
+		{id.HasTypeDefinition, id.HierarchicalReferences, true, false},
+		{id.References, id.HasComponent, true, false},
+	}
+	for _, tt := range tests {
+		if got := isSubtype(ns0(tt.ref), ns0(tt.super), tt.subtypes); got != tt.want {
+			t.Errorf("isSubtype(%d, %d, %v) got %v want %v", tt.ref, tt.super, tt.subtypes, got, tt.want)
+		}
+	}
+}
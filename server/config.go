@@ -0,0 +1,234 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"crypto/rsa"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+)
+
+const (
+	// DefaultApplicationURI is the application uri of the server and
+	// the uri of the namespace for the nodes added to the address space.
+	DefaultApplicationURI = "urn:gopcua:server"
+
+	// DefaultSessionTimeout is the maximum time a session can be idle
+	// before the server closes it.
+	DefaultSessionTimeout = time.Hour
+
+	// DefaultChannelLifetime is the maximum lifetime of a security token
+	// of a secure channel.
+	DefaultChannelLifetime = time.Hour
+
+	// DefaultMaxSessions is the maximum number of concurrent sessions.
+	DefaultMaxSessions = 100
+
+	// DefaultMaxReferencesPerNode is the maximum number of references
+	// returned for a node in a single Browse response if the client
+	// does not request a lower limit.
+	DefaultMaxReferencesPerNode = 1000
+)
+
+// Config contains the server configuration.
+type Config struct {
+	applicationURI  string
+	productURI      string
+	applicationName string
+
+	certificate []byte
+	privateKey  *rsa.PrivateKey
+
+	// security contains the enabled security policies and modes.
+	security []securityConfig
+
+	// authModes contains the enabled user token types.
+	authModes []ua.UserTokenType
+
+	// authUser validates the user name and password of a
+	// UserNameIdentityToken.
+	authUser func(user, password string) bool
+
+	sessionTimeout       time.Duration
+	channelLifetime      time.Duration
+	maxSessions          int
+	maxReferencesPerNode uint32
+
+	ack *uacp.Acknowledge
+
+	// acceptRegistrations enables the RegisterServer and
+	// RegisterServer2 services.
+	acceptRegistrations bool
+
+	// logger logs the events of the server, its secure channels and
+	// sessions. logger.Default is used if it is nil.
+	logger logger.Logger
+}
+
+type securityConfig struct {
+	policyURI string
+	mode      ua.MessageSecurityMode
+}
+
+// Option is an option function type to modify the configuration.
+type Option func(*Config)
+
+func defaultConfig() *Config {
+	return &Config{
+		applicationURI:       DefaultApplicationURI,
+		productURI:           DefaultApplicationURI,
+		applicationName:      "gopcua server",
+		sessionTimeout:       DefaultSessionTimeout,
+		channelLifetime:      DefaultChannelLifetime,
+		maxSessions:          DefaultMaxSessions,
+		maxReferencesPerNode: DefaultMaxReferencesPerNode,
+	}
+}
+
+// applyDefaults enables security policy None and anonymous
+// authentication if nothing else has been configured.
+func (cfg *Config) applyDefaults() {
+	if len(cfg.security) == 0 {
+		cfg.security = []securityConfig{{ua.SecurityPolicyURINone, ua.MessageSecurityModeNone}}
+	}
+	if len(cfg.authModes) == 0 {
+		cfg.authModes = []ua.UserTokenType{ua.UserTokenTypeAnonymous}
+	}
+}
+
+// ApplicationURI sets the application uri of the server. It is also the
+// uri of namespace 1 which contains the nodes added to the address space.
+func ApplicationURI(s string) Option {
+	return func(cfg *Config) {
+		cfg.applicationURI = s
+	}
+}
+
+// ProductURI sets the product uri of the server.
+func ProductURI(s string) Option {
+	return func(cfg *Config) {
+		cfg.productURI = s
+	}
+}
+
+// ApplicationName sets the application name of the server.
+func ApplicationName(s string) Option {
+	return func(cfg *Config) {
+		cfg.applicationName = s
+	}
+}
+
+// Certificate sets the DER encoded certificate of the server. The
+// certificate and a private key are required for all security policies
+// other than None and for encrypted user passwords.
+func Certificate(cert []byte) Option {
+	return func(cfg *Config) {
+		cfg.certificate = cert
+	}
+}
+
+// PrivateKey sets the private key of the server certificate.
+func PrivateKey(key *rsa.PrivateKey) Option {
+	return func(cfg *Config) {
+		cfg.privateKey = key
+	}
+}
+
+// EnableSecurity adds an endpoint with the security policy and mode.
+// The policy can be the full uri or only the name, e.g. "Basic256Sha256".
+// If no security is enabled the server only offers an endpoint with
+// security policy None.
+func EnableSecurity(policy string, mode ua.MessageSecurityMode) Option {
+	return func(cfg *Config) {
+		cfg.security = append(cfg.security, securityConfig{ua.FormatSecurityPolicyURI(policy), mode})
+	}
+}
+
+// EnableAuthMode enables an authentication mode. Only anonymous and user
+// name authentication are supported. If no mode is enabled the server
+// only accepts anonymous users.
+func EnableAuthMode(t ua.UserTokenType) Option {
+	return func(cfg *Config) {
+		for _, m := range cfg.authModes {
+			if m == t {
+				return
+			}
+		}
+		cfg.authModes = append(cfg.authModes, t)
+	}
+}
+
+// UsernameAuth enables user name authentication and sets the function
+// which validates the user name and password.
+func UsernameAuth(fn func(user, password string) bool) Option {
+	return func(cfg *Config) {
+		EnableAuthMode(ua.UserTokenTypeUserName)(cfg)
+		cfg.authUser = fn
+	}
+}
+
+// SessionTimeout sets the maximum time a session can be idle before
+// the server closes it. Clients can request a shorter timeout.
+func SessionTimeout(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.sessionTimeout = d
+	}
+}
+
+// ChannelLifetime sets the maximum lifetime of the security token of a
+// secure channel. Clients can request a shorter lifetime.
+func ChannelLifetime(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.channelLifetime = d
+	}
+}
+
+// MaxSessions sets the maximum number of concurrent sessions.
+func MaxSessions(n int) Option {
+	return func(cfg *Config) {
+		cfg.maxSessions = n
+	}
+}
+
+// MaxReferencesPerNode sets the maximum number of references returned
+// for a node in a single Browse response. Clients fetch the remaining
+// references with BrowseNext.
+func MaxReferencesPerNode(n uint32) Option {
+	return func(cfg *Config) {
+		cfg.maxReferencesPerNode = n
+	}
+}
+
+// Acknowledge sets the buffer sizes and limits the server sends to the
+// client during the connection handshake. The default is
+// uacp.DefaultServerACK.
+func Acknowledge(ack *uacp.Acknowledge) Option {
+	return func(cfg *Config) {
+		cfg.ack = ack
+	}
+}
+
+// AcceptRegistrations makes the server accept the registration of other
+// servers with the RegisterServer and RegisterServer2 services like a
+// Local Discovery Server. Registered servers which are online are returned
+// by FindServers. Multicast discovery is not supported and the results
+// for all discovery configurations are StatusBadNotSupported.
+func AcceptRegistrations() Option {
+	return func(cfg *Config) {
+		cfg.acceptRegistrations = true
+	}
+}
+
+// Logger sets the logger for the events of the server, its connections,
+// secure channels and sessions. The events are printed with the debug
+// logger if OPC_DEBUG=debug is set and discarded otherwise by default.
+func Logger(l logger.Logger) Option {
+	return func(cfg *Config) {
+		cfg.logger = l
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package server provides a minimal embeddable OPC UA server.
+//
+// The server serves the nodes of an in-memory address space which the
+// application populates with objects and variables and updates at
+// runtime:
+//
+//	srv := server.New("opc.tcp://localhost:4840")
+//	dev, _ := srv.AddObject(nil, "Device")
+//	speed, _ := srv.AddVariable(dev, "Speed", ua.MustVariant(0.0))
+//	if err := srv.Start(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	srv.SetValue(speed, ua.MustVariant(42.0))
+//
+// The server supports the session services, Read, Write, Browse,
+// BrowseNext, TranslateBrowsePathsToNodeIDs, RegisterNodes and the
+// discovery services GetEndpoints and FindServers. With the
+// AcceptRegistrations option other servers can register with the server
+// as with a Local Discovery Server. Clients can authenticate anonymously
+// or with a user name and password.
+//
+// Secure channels use the uacp and uasc packages. Security policy None
+// is enabled by default. Other policies, e.g. Basic256Sha256, require a
+// server certificate and are enabled with the EnableSecurity option.
+package server
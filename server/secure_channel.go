@@ -0,0 +1,441 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
+	"github.com/zzylovesll/myOpcUa/uasc"
+)
+
+// securityToken is a security token issued for a secure channel.
+type securityToken struct {
+	id        uint32
+	createdAt time.Time
+	lifetime  time.Duration
+	algo      *uapolicy.EncryptionAlgorithm
+}
+
+// expired returns true if the token cannot be used anymore. Messages
+// secured with a token are accepted for up to 25% of the lifetime after
+// the token expired. See Part 4, 5.5.2.1
+func (t *securityToken) expired(now time.Time) bool {
+	return now.After(t.createdAt.Add(t.lifetime * 5 / 4))
+}
+
+// secureChannel is the server side of a secure channel. It receives the
+// requests from the client on the connection and sends the responses
+// of the server.
+type secureChannel struct {
+	srv  *Server
+	conn *uacp.Conn
+	id   uint32
+
+	// log logs the events of the secure channel with its id.
+	log logger.Logger
+
+	// policyURI, mode and remoteCert are set when the channel is opened.
+	policyURI  string
+	mode       ua.MessageSecurityMode
+	remoteCert []byte
+	remoteKey  *rsa.PublicKey
+
+	// asym secures the OpenSecureChannel messages.
+	asym *uapolicy.EncryptionAlgorithm
+
+	// tokens contains the current and the previous security token.
+	tokens      []*securityToken
+	nextTokenID uint32
+
+	// chunks contains the chunks of incomplete requests.
+	chunks map[uint32][]*uasc.MessageChunk
+
+	// sendMu guards the sequence number and the connection writes.
+	sendMu         sync.Mutex
+	sequenceNumber uint32
+}
+
+func newSecureChannel(srv *Server, conn *uacp.Conn, id uint32) *secureChannel {
+	conn.SetLogger(srv.cfg.logger)
+	return &secureChannel{
+		srv:    srv,
+		conn:   conn,
+		id:     id,
+		log:    logger.With(srv.log, "channel_id", id),
+		chunks: map[uint32][]*uasc.MessageChunk{},
+	}
+}
+
+// serve handles the messages of the client until the client closes the
+// secure channel or an error occurs.
+func (s *secureChannel) serve() error {
+	for {
+		b, err := s.conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		m := new(uasc.MessageChunk)
+		if _, err := m.Decode(b); err != nil {
+			s.conn.SendError(ua.StatusBadDecodingError)
+			return err
+		}
+
+		switch m.MessageType {
+		case uasc.MessageTypeOpenSecureChannel:
+			err = s.handleOpen(m, b)
+		case uasc.MessageTypeMessage:
+			err = s.handleMessage(m, b)
+		case uasc.MessageTypeCloseSecureChannel:
+			s.log.Debug("server: secure channel closed by client")
+			return io.EOF
+		}
+		if err != nil {
+			code, ok := err.(ua.StatusCode)
+			if !ok {
+				code = ua.StatusBadTCPInternalError
+			}
+			s.conn.SendError(code)
+			return err
+		}
+	}
+}
+
+// handleOpen issues or renews the security token of the channel.
+func (s *secureChannel) handleOpen(m *uasc.MessageChunk, b []byte) error {
+	h := m.AsymmetricSecurityHeader
+	if s.asym == nil {
+		if err := s.initSecurity(h); err != nil {
+			return err
+		}
+	} else if h.SecurityPolicyURI != s.policyURI {
+		return ua.StatusBadSecurityPolicyRejected
+	}
+	if s.tokens != nil && m.SecureChannelID != s.id {
+		return ua.StatusBadSecureChannelIDInvalid
+	}
+
+	data, err := s.verifyAndDecrypt(s.asym, b, 12+h.Len(), true)
+	if err != nil {
+		return err
+	}
+	seq := new(uasc.SequenceHeader)
+	n, err := seq.Decode(data)
+	if err != nil {
+		return err
+	}
+	_, svc, err := ua.DecodeService(data[n:])
+	if err != nil {
+		return err
+	}
+	req, ok := svc.(*ua.OpenSecureChannelRequest)
+	if !ok {
+		return ua.StatusBadDecodingError
+	}
+
+	switch req.RequestType {
+	case ua.SecurityTokenRequestTypeIssue:
+		if s.tokens != nil {
+			return ua.StatusBadRequestTypeInvalid
+		}
+		if !s.srv.securityEnabled(s.policyURI, req.SecurityMode) && !s.discoveryOnly(req.SecurityMode) {
+			return ua.StatusBadSecurityModeRejected
+		}
+		s.mode = req.SecurityMode
+	case ua.SecurityTokenRequestTypeRenew:
+		if s.tokens == nil || req.SecurityMode != s.mode {
+			return ua.StatusBadRequestTypeInvalid
+		}
+	default:
+		return ua.StatusBadRequestTypeInvalid
+	}
+
+	var nonce []byte
+	if s.policyURI != ua.SecurityPolicyURINone {
+		if nonce, err = s.asym.MakeNonce(); err != nil {
+			return err
+		}
+	}
+	algo, err := uapolicy.Symmetric(s.policyURI, nonce, req.ClientNonce)
+	if err != nil {
+		return ua.StatusBadNonceInvalid
+	}
+
+	lifetime := s.srv.cfg.channelLifetime
+	if d := time.Duration(req.RequestedLifetime) * time.Millisecond; d > 0 && d < lifetime {
+		lifetime = d
+	}
+	s.nextTokenID++
+	tok := &securityToken{
+		id:        s.nextTokenID,
+		createdAt: time.Now(),
+		lifetime:  lifetime,
+		algo:      algo,
+	}
+
+	// keep the previous token since the client may still use it
+	// until it receives the response.
+	if len(s.tokens) > 0 {
+		s.tokens = []*securityToken{s.tokens[len(s.tokens)-1], tok}
+	} else {
+		s.tokens = []*securityToken{tok}
+	}
+
+	s.log.Info("server: security token issued", "token_id", tok.id, "policy", s.policyURI, "mode", s.mode, "lifetime", lifetime)
+
+	res := &ua.OpenSecureChannelResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		SecurityToken: &ua.ChannelSecurityToken{
+			ChannelID:       s.id,
+			TokenID:         tok.id,
+			CreatedAt:       tok.createdAt,
+			RevisedLifetime: uint32(lifetime / time.Millisecond),
+		},
+		ServerNonce: nonce,
+	}
+	return s.sendOpen(res, seq.RequestID)
+}
+
+// discoveryOnly returns true if the channel has no security although
+// security policy None is not enabled. Clients can only call the
+// discovery services on such a channel. See Part 4, 5.4.1
+func (s *secureChannel) discoveryOnly(mode ua.MessageSecurityMode) bool {
+	return s.policyURI == ua.SecurityPolicyURINone && mode == ua.MessageSecurityModeNone &&
+		!s.srv.securityEnabled(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone)
+}
+
+// initSecurity sets the security policy of the channel from the security
+// header of the first OpenSecureChannel request.
+func (s *secureChannel) initSecurity(h *uasc.AsymmetricSecurityHeader) error {
+	if !s.srv.securityEnabled(h.SecurityPolicyURI, ua.MessageSecurityModeInvalid) && h.SecurityPolicyURI != ua.SecurityPolicyURINone {
+		return ua.StatusBadSecurityPolicyRejected
+	}
+	s.policyURI = h.SecurityPolicyURI
+
+	if s.policyURI != ua.SecurityPolicyURINone {
+		if !bytes.Equal(h.ReceiverCertificateThumbprint, uapolicy.Thumbprint(s.srv.cfg.certificate)) {
+			return ua.StatusBadCertificateInvalid
+		}
+		key, err := publicKey(h.SenderCertificate)
+		if err != nil {
+			return ua.StatusBadCertificateInvalid
+		}
+		s.remoteCert = h.SenderCertificate
+		s.remoteKey = key
+	}
+
+	algo, err := uapolicy.Asymmetric(s.policyURI, s.srv.cfg.privateKey, s.remoteKey)
+	if err != nil {
+		return ua.StatusBadSecurityPolicyRejected
+	}
+	s.asym = algo
+	return nil
+}
+
+// handleMessage handles a message chunk and the request when the final
+// chunk has been received.
+func (s *secureChannel) handleMessage(m *uasc.MessageChunk, b []byte) error {
+	tok := s.token(m.SymmetricSecurityHeader.TokenID)
+	if tok == nil {
+		return ua.StatusBadSecureChannelTokenUnknown
+	}
+	if m.SecureChannelID != s.id {
+		return ua.StatusBadSecureChannelIDInvalid
+	}
+
+	data, err := s.verifyAndDecrypt(tok.algo, b, 12+m.SymmetricSecurityHeader.Len(), false)
+	if err != nil {
+		return err
+	}
+	n, err := m.SequenceHeader.Decode(data)
+	if err != nil {
+		return err
+	}
+	m.Data = data[n:]
+
+	reqID := m.SequenceHeader.RequestID
+	switch m.ChunkType {
+	case uasc.ChunkTypeIntermediate:
+		s.chunks[reqID] = append(s.chunks[reqID], m)
+		if max := s.conn.MaxChunkCount(); max > 0 && uint32(len(s.chunks[reqID])) > max {
+			return ua.StatusBadEncodingLimitsExceeded
+		}
+		return nil
+	case uasc.ChunkTypeError:
+		delete(s.chunks, reqID)
+		return nil
+	}
+
+	var body []byte
+	for _, c := range s.chunks[reqID] {
+		body = append(body, c.Data...)
+	}
+	body = append(body, m.Data...)
+	delete(s.chunks, reqID)
+
+	if max := s.conn.MaxMessageSize(); max > 0 && uint32(len(body)) > max {
+		s.log.Warn("server: request too large", "request_id", reqID, "size", len(body), "max", max)
+		return s.send(&ua.ServiceFault{ResponseHeader: responseHeader(nil, ua.StatusBadRequestTooLarge)}, reqID)
+	}
+
+	_, svc, err := ua.DecodeService(body)
+	if err != nil {
+		s.log.Warn("server: cannot decode request", "request_id", reqID, "err", err)
+		return s.send(&ua.ServiceFault{ResponseHeader: responseHeader(nil, ua.StatusBadServiceUnsupported)}, reqID)
+	}
+	req, ok := svc.(ua.Request)
+	if !ok {
+		return s.send(&ua.ServiceFault{ResponseHeader: responseHeader(nil, ua.StatusBadServiceUnsupported)}, reqID)
+	}
+	s.log.Debug("server: recv", "request_id", reqID, "type", reflect.TypeOf(req))
+	return s.send(s.srv.handle(s, req), reqID)
+}
+
+// token returns the valid security token with the given id.
+func (s *secureChannel) token(tokenID uint32) *securityToken {
+	now := time.Now()
+	for _, t := range s.tokens {
+		if t.id == tokenID && !t.expired(now) {
+			return t
+		}
+	}
+	return nil
+}
+
+// sendOpen sends the OpenSecureChannel response.
+func (s *secureChannel) sendOpen(res *ua.OpenSecureChannelResponse, reqID uint32) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	var cert, thumbprint []byte
+	if s.policyURI != ua.SecurityPolicyURINone {
+		cert = s.srv.cfg.certificate
+		thumbprint = uapolicy.Thumbprint(s.remoteCert)
+	}
+	m := &uasc.Message{
+		MessageHeader: &uasc.MessageHeader{
+			Header:                   uasc.NewHeader(uasc.MessageTypeOpenSecureChannel, uasc.ChunkTypeFinal, s.id),
+			AsymmetricSecurityHeader: uasc.NewAsymmetricSecurityHeader(s.policyURI, cert, thumbprint),
+			SequenceHeader:           uasc.NewSequenceHeader(s.nextSequenceNumber(), reqID),
+		},
+		TypeID:  ua.NewFourByteExpandedNodeID(0, id.OpenSecureChannelResponse_Encoding_DefaultBinary),
+		Service: res,
+	}
+	b, err := m.Encode()
+	if err != nil {
+		return err
+	}
+	b, err = s.signAndEncrypt(s.asym, b, 12+m.AsymmetricSecurityHeader.Len(), true)
+	if err != nil {
+		return err
+	}
+	_, err = s.conn.Write(b)
+	return err
+}
+
+// send sends the response with the current security token.
+func (s *secureChannel) send(res ua.Response, reqID uint32) error {
+	typeID := ua.ServiceTypeID(res)
+	if typeID == 0 {
+		return errors.Errorf("unknown service %T", res)
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	tok := s.tokens[len(s.tokens)-1]
+	m := &uasc.Message{
+		MessageHeader: &uasc.MessageHeader{
+			Header:                  uasc.NewHeader(uasc.MessageTypeMessage, uasc.ChunkTypeFinal, s.id),
+			SymmetricSecurityHeader: uasc.NewSymmetricSecurityHeader(tok.id),
+			SequenceHeader:          uasc.NewSequenceHeader(s.nextSequenceNumber(), reqID),
+		},
+		TypeID:  ua.NewFourByteExpandedNodeID(0, typeID),
+		Service: res,
+	}
+	chunks, err := m.EncodeChunks(s.maxBodySize(tok.algo))
+	if err != nil {
+		return err
+	}
+	if err := uasc.CheckSendLimits(s.conn, chunks, ua.StatusBadResponseTooLarge); err != nil {
+		// the client would reject the response
+		s.log.Warn("server: response too large", "type", reflect.TypeOf(res), "err", err)
+		fault := &ua.ServiceFault{ResponseHeader: responseHeader(nil, ua.StatusBadResponseTooLarge)}
+		fault.ResponseHeader.RequestHandle = res.Header().RequestHandle
+		m.TypeID = ua.NewFourByteExpandedNodeID(0, id.ServiceFault_Encoding_DefaultBinary)
+		m.Service = fault
+		if chunks, err = m.EncodeChunks(s.maxBodySize(tok.algo)); err != nil {
+			return err
+		}
+	}
+	for i, chunk := range chunks {
+		if i > 0 {
+			binary.LittleEndian.PutUint32(chunk[16:], s.nextSequenceNumber())
+		}
+		chunk, err = s.signAndEncrypt(tok.algo, chunk, 12+m.SymmetricSecurityHeader.Len(), false)
+		if err != nil {
+			return err
+		}
+		if _, err := s.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	s.log.Debug("server: sent", "type", reflect.TypeOf(res))
+	return nil
+}
+
+// nextSequenceNumber returns the sequence number for the next chunk.
+// The send lock must be held.
+func (s *secureChannel) nextSequenceNumber() uint32 {
+	s.sequenceNumber++
+	if s.sequenceNumber > math.MaxUint32-1023 {
+		s.sequenceNumber = 1
+	}
+	return s.sequenceNumber
+}
+
+// maxBodySize returns the maximum size of the message body in a chunk.
+func (s *secureChannel) maxBodySize(algo *uapolicy.EncryptionAlgorithm) uint32 {
+	return uasc.MaxBodySize(algo, int(s.conn.SendBufSize()), s.encrypted(false))
+}
+
+// encrypted returns true if the messages of the channel are encrypted.
+// OpenSecureChannel messages are always encrypted unless the security
+// policy is None.
+func (s *secureChannel) encrypted(asymmetric bool) bool {
+	return s.mode == ua.MessageSecurityModeSignAndEncrypt || asymmetric
+}
+
+// signAndEncrypt signs and encrypts a chunk with a header of the given
+// length.
+func (s *secureChannel) signAndEncrypt(algo *uapolicy.EncryptionAlgorithm, b []byte, headerLength int, asymmetric bool) ([]byte, error) {
+	if s.policyURI == ua.SecurityPolicyURINone {
+		return b, nil
+	}
+	return uasc.SignAndEncrypt(algo, b, headerLength, s.encrypted(asymmetric))
+}
+
+// verifyAndDecrypt decrypts a chunk with a header of the given length,
+// verifies the signature and returns the data after the security header
+// without the padding and the signature.
+func (s *secureChannel) verifyAndDecrypt(algo *uapolicy.EncryptionAlgorithm, r []byte, headerLength int, asymmetric bool) ([]byte, error) {
+	if s.policyURI == ua.SecurityPolicyURINone {
+		return r[headerLength:], nil
+	}
+	return uasc.VerifyAndDecrypt(algo, r, headerLength, s.encrypted(asymmetric))
+}
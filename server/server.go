@@ -0,0 +1,306 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+)
+
+// TransportProfileURI is the transport profile of the server endpoints.
+const TransportProfileURI = ua.TransportProfileURIUATCPBinary
+
+// Server is an OPC UA server which serves the nodes of an address space
+// over the binary TCP protocol.
+type Server struct {
+	url string
+	cfg *Config
+	log logger.Logger
+
+	as    *AddressSpace
+	nodes NodeManager
+
+	mu        sync.Mutex
+	l         *uacp.Listener
+	channels  map[uint32]*secureChannel
+	nextID    uint32
+	closing   bool
+	sessions  *sessionManager
+	endpoints []*ua.EndpointDescription
+
+	// registered contains the servers which have registered with
+	// the server by their server uri.
+	registered map[string]*ua.RegisteredServer
+
+	wg sync.WaitGroup
+}
+
+// New returns a server for the endpoint url, e.g.
+// "opc.tcp://localhost:4840". The server has an empty address space with
+// the standard nodes. Call Start to accept client connections.
+func New(url string, opts ...Option) *Server {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.applyDefaults()
+
+	as := NewAddressSpace(cfg.applicationURI)
+	s := &Server{
+		url:      url,
+		cfg:      cfg,
+		log:      logger.OrDefault(cfg.logger),
+		as:       as,
+		nodes:    as,
+		channels: map[uint32]*secureChannel{},
+	}
+	s.sessions = newSessionManager(s)
+	s.endpoints = s.newEndpoints()
+	return s
+}
+
+// AddressSpace returns the address space of the server.
+func (s *Server) AddressSpace() *AddressSpace {
+	return s.as
+}
+
+// AddObject adds an object to the address space. See
+// AddressSpace.AddObject.
+func (s *Server) AddObject(parent *ua.NodeID, name string, opts ...NodeOption) (*ua.NodeID, error) {
+	return s.as.AddObject(parent, name, opts...)
+}
+
+// AddVariable adds a variable to the address space. See
+// AddressSpace.AddVariable.
+func (s *Server) AddVariable(parent *ua.NodeID, name string, v *ua.Variant, opts ...NodeOption) (*ua.NodeID, error) {
+	return s.as.AddVariable(parent, name, v, opts...)
+}
+
+// SetValue updates the value of a variable in the address space.
+func (s *Server) SetValue(nodeID *ua.NodeID, v *ua.Variant) error {
+	return s.as.SetValue(nodeID, v)
+}
+
+// Endpoints returns the endpoints of the server.
+func (s *Server) Endpoints() []*ua.EndpointDescription {
+	return s.endpoints
+}
+
+// Start starts listening on the endpoint url and serves clients in the
+// background until Close is called or the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	l, err := uacp.Listen(s.url, s.cfg.ack)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.l != nil || s.closing {
+		s.mu.Unlock()
+		l.Close()
+		return errors.New("server already started")
+	}
+	s.l = l
+	s.mu.Unlock()
+
+	s.log.Info("server: listening", "endpoint", s.url)
+
+	s.wg.Add(1)
+	go s.acceptLoop(ctx, l)
+
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	return nil
+}
+
+// validate checks that the configuration is complete for the enabled
+// security policies and authentication modes.
+func (s *Server) validate() error {
+	for _, sec := range s.cfg.security {
+		if sec.policyURI == ua.SecurityPolicyURINone {
+			continue
+		}
+		if s.cfg.certificate == nil || s.cfg.privateKey == nil {
+			return errors.Errorf("security policy %s requires a certificate and a private key", sec.policyURI)
+		}
+	}
+	for _, m := range s.cfg.authModes {
+		switch m {
+		case ua.UserTokenTypeAnonymous:
+		case ua.UserTokenTypeUserName:
+			if s.cfg.authUser == nil {
+				return errors.New("user name authentication requires UsernameAuth")
+			}
+		default:
+			return errors.Errorf("unsupported auth mode %s", m)
+		}
+	}
+	return nil
+}
+
+// Close stops listening, closes all connections and waits until all
+// connections are closed.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closing = true
+	var err error
+	if s.l != nil {
+		err = s.l.Close()
+	}
+	for _, ch := range s.channels {
+		ch.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) acceptLoop(ctx context.Context, l *uacp.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := l.Accept(ctx)
+		if err != nil {
+			s.mu.Lock()
+			closing := s.closing
+			s.mu.Unlock()
+			if closing {
+				return
+			}
+			s.log.Warn("server: accept failed", "err", err)
+			continue
+		}
+
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.nextID++
+		ch := newSecureChannel(s, conn, s.nextID)
+		s.channels[ch.id] = ch
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			ch.log.Debug("server: connection accepted", "remote_addr", conn.RemoteAddr())
+			if err := ch.serve(); err != nil {
+				ch.log.Warn("server: secure channel failed", "err", err)
+			}
+			conn.Close()
+
+			s.mu.Lock()
+			delete(s.channels, ch.id)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// securityEnabled returns true if the security policy and mode are
+// enabled. If the mode is MessageSecurityModeInvalid only the policy is
+// checked.
+func (s *Server) securityEnabled(policyURI string, mode ua.MessageSecurityMode) bool {
+	for _, sec := range s.cfg.security {
+		if sec.policyURI == policyURI && (mode == ua.MessageSecurityModeInvalid || sec.mode == mode) {
+			return true
+		}
+	}
+	return false
+}
+
+// newEndpoints returns the endpoint descriptions for the enabled security
+// policies and modes.
+func (s *Server) newEndpoints() []*ua.EndpointDescription {
+	app := &ua.ApplicationDescription{
+		ApplicationURI:  s.cfg.applicationURI,
+		ProductURI:      s.cfg.productURI,
+		ApplicationName: ua.NewLocalizedText(s.cfg.applicationName),
+		ApplicationType: ua.ApplicationTypeServer,
+		DiscoveryURLs:   []string{s.url},
+	}
+
+	var eps []*ua.EndpointDescription
+	for _, sec := range s.cfg.security {
+		var tokens []*ua.UserTokenPolicy
+		for _, m := range s.cfg.authModes {
+			switch m {
+			case ua.UserTokenTypeAnonymous:
+				tokens = append(tokens, &ua.UserTokenPolicy{
+					PolicyID:  "Anonymous",
+					TokenType: ua.UserTokenTypeAnonymous,
+				})
+			case ua.UserTokenTypeUserName:
+				tokens = append(tokens, &ua.UserTokenPolicy{
+					PolicyID:          "UserName",
+					TokenType:         ua.UserTokenTypeUserName,
+					SecurityPolicyURI: s.passwordPolicyURI(sec.policyURI),
+				})
+			}
+		}
+
+		var level byte
+		switch sec.mode {
+		case ua.MessageSecurityModeSign:
+			level = 1
+		case ua.MessageSecurityModeSignAndEncrypt:
+			level = 2
+		}
+
+		eps = append(eps, &ua.EndpointDescription{
+			EndpointURL:         s.url,
+			Server:              app,
+			ServerCertificate:   s.cfg.certificate,
+			SecurityMode:        sec.mode,
+			SecurityPolicyURI:   sec.policyURI,
+			UserIdentityTokens:  tokens,
+			TransportProfileURI: TransportProfileURI,
+			SecurityLevel:       level,
+		})
+	}
+	return eps
+}
+
+// passwordPolicyURI returns the security policy for encrypting user
+// passwords on an endpoint with the given policy. Passwords are encrypted
+// with Basic256Sha256 on endpoints without security if the server has a
+// certificate.
+func (s *Server) passwordPolicyURI(policyURI string) string {
+	if policyURI == ua.SecurityPolicyURINone && s.cfg.certificate != nil && s.cfg.privateKey != nil {
+		return ua.SecurityPolicyURIBasic256Sha256
+	}
+	return policyURI
+}
+
+// responseHeader returns the response header for a request.
+func responseHeader(req *ua.RequestHeader, code ua.StatusCode) *ua.ResponseHeader {
+	h := &ua.ResponseHeader{
+		Timestamp:          time.Now(),
+		ServiceResult:      code,
+		ServiceDiagnostics: &ua.DiagnosticInfo{},
+		AdditionalHeader:   ua.NewExtensionObject(nil),
+	}
+	if req != nil {
+		h.RequestHandle = req.RequestHandle
+	}
+	return h
+}
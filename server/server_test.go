@@ -0,0 +1,1290 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/uasc"
+)
+
+// freeEndpoint returns an endpoint url on a free local port.
+func freeEndpoint(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return fmt.Sprintf("opc.tcp://%s", l.Addr())
+}
+
+// newCert returns a self-signed certificate and its private key.
+func newCert(t *testing.T, uri string) ([]byte, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: uri},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{u},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func startServer(t *testing.T, opts ...Option) (*Server, string) {
+	t.Helper()
+	endpoint := freeEndpoint(t)
+	srv := New(endpoint, opts...)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv, endpoint
+}
+
+func connect(t *testing.T, endpoint string, opts ...opcua.Option) *opcua.Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := opcua.NewClient(endpoint, append([]opcua.Option{opcua.AutoReconnect(false)}, opts...)...)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestServer_ReadWriteBrowse(t *testing.T) {
+	srv, endpoint := startServer(t)
+
+	dev, err := srv.AddObject(nil, "Device")
+	if err != nil {
+		t.Fatal(err)
+	}
+	speed, err := srv.AddVariable(dev, "Speed", ua.MustVariant(int32(10)), Writable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := connect(t, endpoint)
+	ctx := context.Background()
+
+	read := func() interface{} {
+		t.Helper()
+		res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+			NodesToRead:        []*ua.ReadValueID{{NodeID: speed, AttributeID: ua.AttributeIDValue}},
+			TimestampsToReturn: ua.TimestampsToReturnBoth,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Results[0].Status != ua.StatusOK {
+			t.Fatalf("read failed: %v", res.Results[0].Status)
+		}
+		return res.Results[0].Value.Value()
+	}
+
+	if got, want := read(), int32(10); got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	srv.SetValue(speed, ua.MustVariant(int32(20)))
+	if got, want := read(), int32(20); got != want {
+		t.Fatalf("got %v want %v after SetValue", got, want)
+	}
+
+	res, err := c.WriteWithContext(ctx, &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{{
+			NodeID:      speed,
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(int32(30))},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Results[0] != ua.StatusOK {
+		t.Fatalf("write failed: %v", res.Results[0])
+	}
+	if got, want := read(), int32(30); got != want {
+		t.Fatalf("got %v want %v after Write", got, want)
+	}
+
+	ids, err := c.TranslateBrowsePaths(ctx, nil, "Objects/1:Device/1:Speed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids[0].String(), speed.String(); got != want {
+		t.Fatalf("got path target %s want %s", got, want)
+	}
+
+	refs, err := c.BrowseAll(ctx, ua.NewNumericNodeID(0, id.ObjectsFolder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range refs {
+		if r.NodeID.NodeID.String() == speed.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("browse did not find %s", speed)
+	}
+
+	ns, err := c.NamespaceArrayWithContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 2 || ns[1] != DefaultApplicationURI {
+		t.Fatalf("got namespaces %v", ns)
+	}
+}
+
+func TestServer_Chunking(t *testing.T) {
+	// tiny buffers of different sizes force multi-chunk requests and
+	// responses whose chunks must fit into the buffers of the other end
+	srv, endpoint := startServer(t, Acknowledge(&uacp.Acknowledge{
+		ReceiveBufSize: 8192,
+		SendBufSize:    16384,
+		MaxMessageSize: 512 * uacp.KB,
+		MaxChunkCount:  100,
+	}))
+	file, err := srv.AddVariable(nil, "File", ua.MustVariant([]byte{}), Writable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := connect(t, endpoint, opcua.ReceiveBufferSize(12288), opcua.MaxMessageSize(256*uacp.KB))
+	ctx := context.Background()
+
+	write := func(b []byte) error {
+		t.Helper()
+		res, err := c.WriteWithContext(ctx, &ua.WriteRequest{
+			NodesToWrite: []*ua.WriteValue{{
+				NodeID:      file,
+				AttributeID: ua.AttributeIDValue,
+				Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(b)},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		return ua.CombineStatusCodes(res.Results)
+	}
+	read := func() ([]byte, error) {
+		t.Helper()
+		res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+			NodesToRead: []*ua.ReadValueID{{NodeID: file, AttributeID: ua.AttributeIDValue}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		b, _ := res.Results[0].Value.Value().([]byte)
+		return b, nil
+	}
+
+	want := make([]byte, 100*uacp.KB)
+	for i := range want {
+		want[i] = byte(i)
+	}
+	if err := write(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes which differ from the %d written bytes", len(got), len(want))
+	}
+
+	// the request exceeds the MaxMessageSize of the server and is not sent
+	if err := write(make([]byte, 600*uacp.KB)); !errors.Is(err, ua.StatusBadRequestTooLarge) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadRequestTooLarge)
+	}
+
+	// the response exceeds the MaxMessageSize of the client
+	if err := write(make([]byte, 300*uacp.KB)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(); !errors.Is(err, ua.StatusBadResponseTooLarge) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadResponseTooLarge)
+	}
+
+	// the channel is still usable
+	if err := write(want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := read(); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes and error %v after the failed requests", len(got), err)
+	}
+}
+
+func TestServer_TimestampsToReturn(t *testing.T) {
+	srv, endpoint := startServer(t)
+	speed, err := srv.AddVariable(nil, "Speed", ua.MustVariant(int32(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ts             ua.TimestampsToReturn
+		source, server bool
+	}{
+		{ua.TimestampsToReturnSource, true, false},
+		{ua.TimestampsToReturnServer, false, true},
+		{ua.TimestampsToReturnBoth, true, true},
+		{ua.TimestampsToReturnNeither, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ts.String(), func(t *testing.T) {
+			c := connect(t, endpoint, opcua.TimestampsToReturn(tt.ts))
+			dvs, err := c.Node(speed).AttributesWithContext(context.Background(), ua.AttributeIDValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dv := dvs[0]
+			if got, want := dv.Has(ua.DataValueSourceTimestamp), tt.source; got != want || got == dv.SourceTimestamp.IsZero() {
+				t.Fatalf("got source timestamp %v (%v) want %v", got, dv.SourceTimestamp, want)
+			}
+			if got, want := dv.Has(ua.DataValueServerTimestamp), tt.server; got != want || got == dv.ServerTimestamp.IsZero() {
+				t.Fatalf("got server timestamp %v (%v) want %v", got, dv.ServerTimestamp, want)
+			}
+		})
+	}
+}
+
+func TestServer_BrowseContinuationPoints(t *testing.T) {
+	srv, endpoint := startServer(t, MaxReferencesPerNode(3))
+	dev, _ := srv.AddObject(nil, "Device")
+	for i := 0; i < 10; i++ {
+		srv.AddVariable(dev, fmt.Sprintf("V%d", i), ua.MustVariant(int32(i)))
+	}
+
+	c := connect(t, endpoint)
+	var n int
+	err := c.BrowseRecursive(context.Background(), dev, func(parent *ua.NodeID, ref *ua.ReferenceDescription, depth int) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, 10; got != want {
+		t.Fatalf("got %d references want %d", got, want)
+	}
+}
+
+func TestServer_ClientGetEndpoints(t *testing.T) {
+	_, endpoint := startServer(t)
+	c := connect(t, endpoint)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := c.GetEndpoints(ctx, opcua.GetEndpointsLocaleIDs("en-US"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Endpoints) == 0 {
+		t.Fatal("got no endpoints")
+	}
+	for _, ep := range res.Endpoints {
+		if !ep.HasSecurityPolicy("None") || ep.SecurityPolicy() != "None" {
+			t.Fatalf("got security policy %s want None", ep.SecurityPolicyURI)
+		}
+	}
+
+	res, err = c.GetEndpoints(ctx, opcua.GetEndpointsProfileURIs(ua.TransportProfileURIUATCPBinary))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Endpoints) == 0 {
+		t.Fatalf("got no endpoints for profile %s", ua.TransportProfileURIUATCPBinary)
+	}
+
+	res, err = c.GetEndpoints(ctx, opcua.GetEndpointsProfileURIs("http://opcfoundation.org/UA-Profile/Transport/https-uabinary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(res.Endpoints); got != 0 {
+		t.Fatalf("got %d endpoints for https want 0", got)
+	}
+}
+
+func TestServer_UsernameAuth(t *testing.T) {
+	_, endpoint := startServer(t, UsernameAuth(func(user, pass string) bool {
+		return user == "admin" && pass == "secret"
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	eps, err := opcua.GetEndpoints(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := opcua.SelectEndpoint(eps, ua.SecurityPolicyURINone, ua.MessageSecurityModeNone)
+
+	connect(t, endpoint,
+		opcua.AuthUsername("admin", "secret"),
+		opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeUserName),
+	)
+
+	c := opcua.NewClient(endpoint,
+		opcua.AutoReconnect(false),
+		opcua.AuthUsername("admin", "wrong"),
+		opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeUserName),
+	)
+	if err := c.Connect(ctx); err == nil {
+		c.Close()
+		t.Fatal("got nil want error for wrong password")
+	}
+}
+
+func TestServer_UsernameAuthEncryptedPassword(t *testing.T) {
+	cert, key := newCert(t, "urn:gopcua:server")
+	_, endpoint := startServer(t,
+		Certificate(cert),
+		PrivateKey(key),
+		UsernameAuth(func(user, pass string) bool {
+			return user == "admin" && pass == "secret"
+		}),
+	)
+
+	// the user token policy is selected from the endpoints of the session
+	// and the password is encrypted with Basic256Sha256 on a channel
+	// without security.
+	connect(t, endpoint, opcua.AuthUsername("admin", "secret"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := opcua.NewClient(endpoint, opcua.AutoReconnect(false), opcua.AuthCertificate(cert))
+	err := c.Connect(ctx)
+	if err == nil {
+		c.Close()
+		t.Fatal("got nil want error for missing certificate user token policy")
+	}
+	if want := "no user token policy for Certificate"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("got error %q want %q", err, want)
+	}
+}
+
+func TestServer_ReactivateSession(t *testing.T) {
+	_, endpoint := startServer(t, UsernameAuth(func(user, pass string) bool {
+		return user == "admin" && pass == "secret"
+	}))
+	c := connect(t, endpoint, opcua.AuthUsername("admin", "secret"))
+
+	// activating the session again, e.g. with a refreshed issued token,
+	// keeps the session.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s := c.Session()
+	if err := c.ActivateSessionWithContext(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if c.Session() != s {
+		t.Fatal("session was replaced")
+	}
+	res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := res.Results[0].Status, ua.StatusOK; got != want {
+		t.Fatalf("got status %s want %s", got, want)
+	}
+}
+
+func TestServer_ContextDeadline(t *testing.T) {
+	// the authentication of the user blocks the server while the gate
+	// is closed so that the server answers slowly.
+	var mu sync.Mutex
+	gate := make(chan struct{})
+	close(gate)
+	setGate := func(ch chan struct{}) {
+		mu.Lock()
+		gate = ch
+		mu.Unlock()
+	}
+	_, endpoint := startServer(t, UsernameAuth(func(user, pass string) bool {
+		mu.Lock()
+		ch := gate
+		mu.Unlock()
+		<-ch
+		return user == "admin" && pass == "secret"
+	}))
+	c := connect(t, endpoint, opcua.AuthUsername("admin", "secret"), opcua.RequestTimeout(200*time.Millisecond))
+
+	read := func(ctx context.Context) error {
+		_, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+			NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+		})
+		return err
+	}
+
+	// the calls return when the context is done and do not wait for
+	// the slow server. The blocked activation stalls the secure channel
+	// of the server for the read, too.
+	release := make(chan struct{})
+	setGate(release)
+	calls := []struct {
+		name string
+		call func(context.Context) error
+	}{
+		{"activate", func(ctx context.Context) error { return c.ActivateSessionWithContext(ctx, c.Session()) }},
+		{"read", read},
+	}
+	for _, tt := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		start := time.Now()
+		err := tt.call(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%s: got error %v want %v", tt.name, err, context.DeadlineExceeded)
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Fatalf("%s: returned after %v", tt.name, d)
+		}
+	}
+
+	// the deadline of the context is the timeout of the request even
+	// if it is longer than the RequestTimeout of the client.
+	time.AfterFunc(time.Second, func() { close(release) })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := read(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_SecurityPolicies(t *testing.T) {
+	cert, key := newCert(t, "urn:gopcua:server")
+	clientCert, clientKey := newCert(t, "urn:gopcua:client")
+
+	for _, policy := range []string{"Basic128Rsa15", "Basic256", "Basic256Sha256", "Aes128Sha256RsaOaep", "Aes256Sha256RsaPss"} {
+		t.Run(policy, func(t *testing.T) {
+			srv, endpoint := startServer(t,
+				Certificate(cert),
+				PrivateKey(key),
+				EnableSecurity(policy, ua.MessageSecurityModeSign),
+				EnableSecurity(policy, ua.MessageSecurityModeSignAndEncrypt),
+				UsernameAuth(func(user, pass string) bool {
+					return user == "admin" && pass == "secret"
+				}),
+			)
+
+			// the value does not fit into a single chunk
+			data := bytes.Repeat([]byte{0x5a}, 100*uacp.KB)
+			node, err := srv.AddVariable(nil, "Data", ua.MustVariant(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			eps, err := opcua.GetEndpoints(ctx, endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, mode := range []ua.MessageSecurityMode{ua.MessageSecurityModeSign, ua.MessageSecurityModeSignAndEncrypt} {
+				ep := opcua.SelectEndpoint(eps, policy, mode)
+				if ep == nil {
+					t.Fatalf("no %s endpoint", mode)
+				}
+
+				c := connect(t, endpoint,
+					opcua.Certificate(clientCert),
+					opcua.PrivateKey(clientKey),
+					opcua.SecurityPolicy(policy),
+					opcua.SecurityMode(mode),
+					opcua.AuthUsername("admin", "secret"),
+					opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeUserName),
+				)
+
+				res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+					NodesToRead: []*ua.ReadValueID{
+						{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue},
+						{NodeID: node, AttributeID: ua.AttributeIDValue},
+					},
+				})
+				if err != nil {
+					t.Fatalf("%s: %v", mode, err)
+				}
+				if got, want := res.Results[0].Value.Value(), int32(ua.ServerStateRunning); got != want {
+					t.Fatalf("%s: got state %v want %v", mode, got, want)
+				}
+				if got, _ := res.Results[1].Value.Value().([]byte); !bytes.Equal(got, data) {
+					t.Fatalf("%s: got %d bytes want %d", mode, len(got), len(data))
+				}
+			}
+		})
+	}
+}
+
+func TestServer_SecureChannelRenewal(t *testing.T) {
+	_, endpoint := startServer(t)
+	c := connect(t, endpoint, opcua.SecureChannelLifetime(2*time.Second))
+
+	if got, want := c.SecureChannelLifetime(), 2*time.Second; got != want {
+		t.Fatalf("got lifetime %s want %s", got, want)
+	}
+	token := c.SecureChannel().TokenID()
+
+	// the channel is renewed after 75% of the lifetime.
+	time.Sleep(1200 * time.Millisecond)
+	if got := c.SecureChannel().TokenID(); got != token {
+		t.Fatalf("channel renewed before 75%% of the lifetime. token=%d", got)
+	}
+	time.Sleep(600 * time.Millisecond)
+	if got := c.SecureChannel().TokenID(); got == token {
+		t.Fatal("channel not renewed after 75% of the lifetime")
+	}
+
+	_, err := c.ReadWithContext(context.Background(), &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordingLogger records the messages of the events with level Info and
+// above.
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recordingLogger) record(msg string) {
+	l.mu.Lock()
+	l.msgs = append(l.msgs, msg)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) has(msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range l.msgs {
+		if m == msg {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *recordingLogger) Debug(string, ...interface{})        {}
+func (l *recordingLogger) Info(msg string, kv ...interface{})  { l.record(msg) }
+func (l *recordingLogger) Warn(msg string, kv ...interface{})  { l.record(msg) }
+func (l *recordingLogger) Error(msg string, kv ...interface{}) { l.record(msg) }
+
+func TestServer_Logger(t *testing.T) {
+	sl := &recordingLogger{}
+	_, endpoint := startServer(t, Logger(sl))
+	l := &recordingLogger{}
+	c := connect(t, endpoint, opcua.Logger(l), opcua.SecureChannelLifetime(2*time.Second))
+
+	for _, msg := range []string{
+		"uacp: connected",
+		"uasc: secure channel opened",
+		"client: session created",
+		"client: session activated",
+		"client: connection state changed",
+	} {
+		if !l.has(msg) {
+			t.Errorf("event %q not logged", msg)
+		}
+	}
+	for _, msg := range []string{
+		"server: listening",
+		"server: security token issued",
+		"server: session created",
+		"server: session activated",
+	} {
+		if !sl.has(msg) {
+			t.Errorf("server event %q not logged", msg)
+		}
+	}
+
+	token := c.SecureChannel().TokenID()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.SecureChannel().TokenID() == token && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !l.has("uasc: security token renewed") {
+		t.Error("renewal not logged")
+	}
+}
+
+// countingHook counts the service calls by service.
+type countingHook struct {
+	opcua.NopMetricsHook
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (h *countingHook) ServiceCall(service string, status ua.StatusCode, d time.Duration, sent, received int) {
+	h.mu.Lock()
+	h.calls[service]++
+	h.mu.Unlock()
+}
+
+func TestServer_ClientStats(t *testing.T) {
+	_, endpoint := startServer(t)
+	hook := &countingHook{calls: map[string]int{}}
+	c := connect(t, endpoint, opcua.Metrics(hook))
+	ctx := context.Background()
+
+	start, before := time.Now(), c.Stats()
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.ReadWithContext(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := &ua.QueryFirstRequest{View: &ua.ViewDescription{ViewID: ua.NewTwoByteNodeID(0)}, Filter: &ua.ContentFilter{}}
+	err := c.SendWithContext(ctx, query, func(interface{}) error { return nil })
+	if !errors.Is(err, ua.StatusBadServiceUnsupported) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadServiceUnsupported)
+	}
+
+	s := c.Stats()
+	if got, want := s.Requests["Read"]-before.Requests["Read"], uint64(3); got != want {
+		t.Fatalf("got %d reads want %d", got, want)
+	}
+	for _, svc := range []string{"OpenSecureChannel", "CreateSession", "ActivateSession", "QueryFirst"} {
+		if s.Requests[svc] != 1 {
+			t.Fatalf("got %d %s requests want 1", s.Requests[svc], svc)
+		}
+	}
+	if got, want := s.Errors[ua.StatusBadServiceUnsupported], uint64(1); got != want {
+		t.Fatalf("got %d errors want %d", got, want)
+	}
+	if s.BytesSent == 0 || s.BytesReceived == 0 {
+		t.Fatalf("got %d bytes sent and %d bytes received", s.BytesSent, s.BytesReceived)
+	}
+	if s.LastSuccess.Before(start) {
+		t.Fatalf("got last success %v before %v", s.LastSuccess, start)
+	}
+	if s.InFlight != 0 {
+		t.Fatalf("got %d requests in flight want 0", s.InFlight)
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if got, want := hook.calls["Read"], int(before.Requests["Read"])+3; got != want {
+		t.Fatalf("got %d reads in the hook want %d", got, want)
+	}
+}
+
+func TestServer_SessionKeepalive(t *testing.T) {
+	_, endpoint := startServer(t)
+	const interval = 200 * time.Millisecond
+	statusCh := make(chan opcua.ServerStatus, 1)
+	c := connect(t, endpoint,
+		opcua.SessionKeepalive(interval),
+		opcua.ServerStatusFunc(func(s opcua.ServerStatus) { statusCh <- s }),
+	)
+
+	select {
+	case s := <-statusCh:
+		if s.State != ua.ServerStateRunning || s.UpdatedAt.IsZero() {
+			t.Fatalf("got status %+v want state Running", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the server status")
+	}
+	if got := c.ServerStatus(); got.State != ua.ServerStateRunning || got.UpdatedAt.IsZero() {
+		t.Fatalf("got status %+v want state Running", got)
+	}
+
+	// the keepalive pauses while other requests succeed
+	ctx := context.Background()
+	req := &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+	}
+	before := c.Stats().Requests["Read"]
+	const n = 50
+	for i := 0; i < n; i++ {
+		if _, err := c.ReadWithContext(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(interval / 20)
+	}
+	if got := c.Stats().Requests["Read"] - before; got != n {
+		t.Fatalf("got %d reads want %d without keepalive requests", got, n)
+	}
+}
+
+type vendorType struct {
+	A int32
+	B string
+}
+
+func TestServer_ExtensionObjectURI(t *testing.T) {
+	const uri = "urn:gopcua:test:vendor"
+	ua.RegisterExtensionObjectURI(uri, 7101, new(vendorType))
+
+	srv, endpoint := startServer(t)
+	ns := srv.AddressSpace().AddNamespace(uri)
+	body := []byte{0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x78}
+	eo := &ua.ExtensionObject{
+		TypeID:       ua.NewFourByteExpandedNodeID(uint8(ns), 7101),
+		EncodingMask: ua.ExtensionObjectBinary,
+		Value:        ua.ExtensionObjectBody(body),
+	}
+	nodeID, err := srv.AddVariable(nil, "Vendor", ua.MustVariant(eo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := connect(t, endpoint)
+	v, err := c.Node(nodeID).ValueWithContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := v.Value().(*ua.ExtensionObject)
+	if !ok {
+		t.Fatalf("got %T want *ua.ExtensionObject", v.Value())
+	}
+	if want := (&vendorType{A: 5, B: "x"}); !reflect.DeepEqual(got.Value, want) {
+		t.Fatalf("got %#v want %#v", got.Value, want)
+	}
+}
+
+func TestServer_SecureChannelRenewalMidRequest(t *testing.T) {
+	cert, key := newCert(t, "urn:gopcua:server")
+	clientCert, clientKey := newCert(t, "urn:gopcua:client")
+	_, endpoint := startServer(t,
+		Certificate(cert),
+		PrivateKey(key),
+		EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	eps, err := opcua.GetEndpoints(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := opcua.SelectEndpoint(eps, "Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt)
+	if ep == nil {
+		t.Fatal("no endpoint")
+	}
+
+	var mu sync.Mutex
+	var events []uasc.RenewalEvent
+	c := connect(t, endpoint,
+		opcua.Certificate(clientCert),
+		opcua.PrivateKey(clientKey),
+		opcua.SecurityPolicy("Basic256Sha256"),
+		opcua.SecurityMode(ua.MessageSecurityModeSignAndEncrypt),
+		opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeAnonymous),
+		opcua.SecureChannelRenewalFunc(func(ev uasc.RenewalEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+	)
+
+	// send requests while the channel is renewed so that some of them
+	// are sent with the old and answered with the new security token.
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+					NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	const renewals = 5
+	for i := 0; i < renewals; i++ {
+		if err := c.SecureChannel().Renew(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := len(events), renewals; got != want {
+		t.Fatalf("got %d renewal events want %d", got, want)
+	}
+	for i, ev := range events {
+		if ev.Err != nil || ev.Lifetime == 0 {
+			t.Fatalf("renewal %d: got %+v", i, ev)
+		}
+		if i > 0 && ev.TokenID == events[i-1].TokenID {
+			t.Fatalf("renewal %d: token %d not renewed", i, ev.TokenID)
+		}
+	}
+	if got, want := c.SecureChannel().TokenID(), events[renewals-1].TokenID; got != want {
+		t.Fatalf("got token %d want %d", got, want)
+	}
+}
+
+func TestServer_Validate(t *testing.T) {
+	srv := New("opc.tcp://127.0.0.1:0", EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSign))
+	if err := srv.Start(context.Background()); err == nil {
+		srv.Close()
+		t.Fatal("got nil want error for missing certificate")
+	}
+
+	srv = New("opc.tcp://127.0.0.1:0", EnableAuthMode(ua.UserTokenTypeUserName))
+	if err := srv.Start(context.Background()); err == nil {
+		srv.Close()
+		t.Fatal("got nil want error for missing user name validation")
+	}
+}
+
+func TestServer_Pool(t *testing.T) {
+	srv, endpoint := startServer(t)
+	speed, err := srv.AddVariable(nil, "Speed", ua.MustVariant(int32(0)), Writable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	p := opcua.NewPool(endpoint,
+		opcua.PoolSize(3),
+		opcua.PoolHealthCheckInterval(20*time.Millisecond),
+		opcua.PoolClientOptions(opcua.AutoReconnect(false)),
+	)
+	if err := p.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(v int32) {
+			defer wg.Done()
+			_, err := p.WriteWithContext(ctx, &ua.WriteRequest{
+				NodesToWrite: []*ua.WriteValue{{
+					NodeID:      speed,
+					AttributeID: ua.AttributeIDValue,
+					Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(v)},
+				}},
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			res, err := p.ReadWithContext(ctx, &ua.ReadRequest{
+				NodesToRead: []*ua.ReadValueID{{NodeID: speed, AttributeID: ua.AttributeIDValue}},
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if res.Results[0].Status != ua.StatusOK {
+				errs <- res.Results[0].Status
+			}
+		}(int32(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// wait for a health check of every client.
+	time.Sleep(50 * time.Millisecond)
+	for i, c := range p.Clients() {
+		if got, want := c.State(), opcua.Connected; got != want {
+			t.Fatalf("client %d: got state %s want %s", i, got, want)
+		}
+	}
+
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range p.Clients() {
+		if got, want := c.State(), opcua.Closed; got != want {
+			t.Fatalf("client %d: got state %s want %s", i, got, want)
+		}
+	}
+	if _, err := p.ReadWithContext(ctx, &ua.ReadRequest{}); err != opcua.ErrPoolClosed {
+		t.Fatalf("got error %v want %v", err, opcua.ErrPoolClosed)
+	}
+}
+
+// reverseConnect connects the server at endpoint to the reverse listener
+// at addr like a server which opens the connection to the client. It
+// sends the ReverseHello message until the client accepts it and then
+// forwards the connection to the server. Closing the returned connection
+// drops the reverse connection.
+func reverseConnect(t *testing.T, addr, serverURI, endpoint string) net.Conn {
+	t.Helper()
+	_, saddr, err := uacp.ResolveEndpoint(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := uacp.NewConn(c.(*net.TCPConn), uacp.DefaultServerACK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Send("RHEF", &uacp.ReverseHello{ServerURI: serverURI, EndpointURL: endpoint}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the client answers with HEL or rejects the server with ERR
+		// if it does not wait for the server yet.
+		hdr := make([]byte, 8)
+		if _, err := io.ReadFull(c, hdr); err != nil || string(hdr[:3]) != "HEL" {
+			c.Close()
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+
+		s, err := net.Dial("tcp", saddr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			defer c.Close()
+			defer s.Close()
+			if _, err := s.Write(hdr); err != nil {
+				return
+			}
+			go io.Copy(c, s)
+			io.Copy(s, c)
+		}()
+		return c
+	}
+	t.Fatal("client did not accept the reverse connection")
+	return nil
+}
+
+func TestServer_ReverseConnect(t *testing.T) {
+	_, endpoint := startServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	l, err := opcua.ListenReverse(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	const serverURI = "urn:gopcua:server"
+	restored := make(chan struct{}, 1)
+	c := opcua.NewClient(endpoint,
+		opcua.ReverseConnect(l, serverURI),
+		opcua.ReconnectInterval(10*time.Millisecond),
+		opcua.StateChangedFunc(func(from, to opcua.ConnState) {
+			if from == opcua.Reconnecting && to == opcua.Connected {
+				select {
+				case restored <- struct{}{}:
+				default:
+				}
+			}
+		}),
+	)
+	defer c.Close()
+
+	connected := make(chan error, 1)
+	go func() { connected <- c.Connect(ctx) }()
+	conn := reverseConnect(t, l.Addr(), serverURI, endpoint)
+	if err := <-connected; err != nil {
+		t.Fatal(err)
+	}
+
+	read := func() {
+		t.Helper()
+		res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+			NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := res.Results[0].Status, ua.StatusOK; got != want {
+			t.Fatalf("got status %s want %s", got, want)
+		}
+	}
+	read()
+
+	// servers which the client does not wait for are rejected.
+	other, err := net.Dial("tcp", l.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	oc, err := uacp.NewConn(other.(*net.TCPConn), uacp.DefaultServerACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oc.Send("RHEF", &uacp.ReverseHello{ServerURI: "urn:gopcua:other", EndpointURL: endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oc.Receive(); !errors.Is(err, ua.StatusBadTCPEndpointURLInvalid) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadTCPEndpointURLInvalid)
+	}
+
+	// a dropped connection is restored when the server connects again.
+	conn.Close()
+	for c.State() == opcua.Connected {
+		time.Sleep(time.Millisecond)
+	}
+	reverseConnect(t, l.Addr(), serverURI, endpoint)
+	select {
+	case <-restored:
+	case <-ctx.Done():
+		t.Fatalf("got state %s want %s", c.State(), opcua.Connected)
+	}
+}
+
+func TestServer_EndpointSelection(t *testing.T) {
+	cert, key := newCert(t, "urn:gopcua:server")
+	clientCert, clientKey := newCert(t, "urn:gopcua:client")
+	srv, endpoint := startServer(t,
+		Certificate(cert),
+		PrivateKey(key),
+		EnableSecurity("None", ua.MessageSecurityModeNone),
+		EnableSecurity("Basic256Sha256", ua.MessageSecurityModeSignAndEncrypt),
+	)
+
+	// advertise an endpoint url which the client cannot dial.
+	unreachable := freeEndpoint(t)
+	for _, ep := range srv.Endpoints() {
+		ep.EndpointURL = unreachable
+	}
+
+	tests := []struct {
+		name string
+		opts []opcua.Option
+		err  bool
+	}{
+		{
+			name: "fallback url",
+			opts: []opcua.Option{opcua.Certificate(clientCert), opcua.PrivateKey(clientKey), opcua.EndpointSelection(opcua.EndpointSelectionStrongest)},
+		},
+		{
+			// the client has no certificate for Basic256Sha256
+			name: "next endpoint",
+			opts: []opcua.Option{opcua.EndpointSelection(opcua.EndpointSelectionStrongest)},
+		},
+		{
+			name: "filter",
+			opts: []opcua.Option{opcua.SelectEndpointFilter(func(ep *ua.EndpointDescription) bool {
+				return ep.SecurityMode == ua.MessageSecurityModeNone
+			})},
+		},
+		{
+			name: "no match",
+			opts: []opcua.Option{opcua.SelectEndpointFilter(func(*ua.EndpointDescription) bool { return false })},
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			c := opcua.NewClient(endpoint, append([]opcua.Option{opcua.AutoReconnect(false)}, tt.opts...)...)
+			err := c.Connect(ctx)
+			if tt.err {
+				if err == nil {
+					c.Close()
+					t.Fatal("got nil want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			res, err := c.ReadWithContext(ctx, &ua.ReadRequest{
+				NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := res.Results[0].Value.Value(), int32(ua.ServerStateRunning); got != want {
+				t.Fatalf("got state %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestServer_FindServers(t *testing.T) {
+	srv, endpoint := startServer(t, ApplicationURI("urn:gopcua:test"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	servers, err := opcua.FindServers(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(servers), 1; got != want {
+		t.Fatalf("got %d servers want %d", got, want)
+	}
+	if got, want := servers[0].ApplicationURI, "urn:gopcua:test"; got != want {
+		t.Fatalf("got application uri %s want %s", got, want)
+	}
+
+	// the first discovery urls cannot be used.
+	app := servers[0]
+	app.DiscoveryURLs = []string{"http://127.0.0.1/discovery", freeEndpoint(t), endpoint}
+	eps, err := opcua.GetServerEndpoints(ctx, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(eps), len(srv.Endpoints()); got != want {
+		t.Fatalf("got %d endpoints want %d", got, want)
+	}
+
+	app.DiscoveryURLs = []string{"http://127.0.0.1/discovery"}
+	if _, err := opcua.GetServerEndpoints(ctx, app); err == nil {
+		t.Fatal("got nil want error without opc.tcp discovery url")
+	}
+
+	// the server is not a discovery server with multicast extension.
+	if _, err := opcua.FindServersOnNetwork(ctx, endpoint, 0, 0, nil); !errors.Is(err, ua.StatusBadServiceUnsupported) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadServiceUnsupported)
+	}
+}
+
+func TestServer_DiscoverEndpoint(t *testing.T) {
+	srv, endpoint := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ep, err := opcua.DiscoverEndpoint(ctx, endpoint, "", ua.MessageSecurityModeInvalid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ep.SecurityPolicyURI, opcua.SelectEndpoint(srv.Endpoints(), "", ua.MessageSecurityModeInvalid).SecurityPolicyURI; got != want {
+		t.Fatalf("got security policy %s want %s", got, want)
+	}
+
+	ep, err = opcua.DiscoverEndpoint(ctx, endpoint, ua.SecurityPolicyURINone, ua.MessageSecurityModeNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ep.EndpointURL, endpoint; got != want {
+		t.Fatalf("got endpoint %s want %s", got, want)
+	}
+
+	if _, err := opcua.DiscoverEndpoint(ctx, endpoint, ua.SecurityPolicyURINone, ua.MessageSecurityModeSign); err == nil {
+		t.Fatal("got nil want error for a missing endpoint")
+	}
+}
+
+func TestServer_RegisterServer(t *testing.T) {
+	_, endpoint := startServer(t, AcceptRegistrations())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reg := &ua.RegisteredServer{
+		ServerURI:     "urn:gopcua:registered",
+		ServerNames:   []*ua.LocalizedText{ua.NewLocalizedText("registered")},
+		ServerType:    ua.ApplicationTypeServer,
+		DiscoveryURLs: []string{"opc.tcp://registered:4840"},
+	}
+	config := []*ua.ExtensionObject{ua.NewExtensionObject(&ua.MdnsDiscoveryConfiguration{MdnsServerName: "registered"})}
+
+	find := func() string {
+		t.Helper()
+		servers, err := opcua.FindServers(ctx, endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var uris []string
+		for _, s := range servers {
+			uris = append(uris, s.ApplicationURI)
+		}
+		return strings.Join(uris, ",")
+	}
+
+	r, err := opcua.StartServerRegistration(ctx, endpoint, reg, time.Hour, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.ConfigurationResults(); len(got) != 1 || got[0] != ua.StatusBadNotSupported {
+		t.Fatalf("got configuration results %v want [%v]", got, ua.StatusBadNotSupported)
+	}
+	if got, want := find(), DefaultApplicationURI+",urn:gopcua:registered"; got != want {
+		t.Fatalf("got servers %s want %s", got, want)
+	}
+
+	if err := r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := find(), DefaultApplicationURI; got != want {
+		t.Fatalf("got servers %s want %s", got, want)
+	}
+
+	if _, err := opcua.RegisterServer(ctx, endpoint, &ua.RegisteredServer{ServerURI: "urn:gopcua:invalid", IsOnline: true}, nil); !errors.Is(err, ua.StatusBadServerNameMissing) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadServerNameMissing)
+	}
+
+	// servers which are not discovery servers reject the registration.
+	_, other := startServer(t)
+	if _, err := opcua.RegisterServer(ctx, other, reg, nil); !errors.Is(err, ua.StatusBadServiceUnsupported) {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadServiceUnsupported)
+	}
+}
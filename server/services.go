@@ -0,0 +1,440 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"math"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// handle dispatches the request to the service and returns the response.
+// Unsupported services are answered with a ServiceFault.
+func (s *Server) handle(ch *secureChannel, req ua.Request) ua.Response {
+	h := req.Header()
+	if h == nil {
+		h = &ua.RequestHeader{}
+	}
+
+	// discovery services are available on all secure channels
+	switch req := req.(type) {
+	case *ua.GetEndpointsRequest:
+		return &ua.GetEndpointsResponse{
+			ResponseHeader: responseHeader(h, ua.StatusOK),
+			Endpoints:      s.getEndpoints(req),
+		}
+	case *ua.FindServersRequest:
+		return &ua.FindServersResponse{
+			ResponseHeader: responseHeader(h, ua.StatusOK),
+			Servers:        s.findServers(req),
+		}
+	case *ua.RegisterServerRequest:
+		if !s.cfg.acceptRegistrations {
+			return &ua.ServiceFault{ResponseHeader: responseHeader(h, ua.StatusBadServiceUnsupported)}
+		}
+		return &ua.RegisterServerResponse{ResponseHeader: responseHeader(h, s.registerServer(req.Server))}
+	case *ua.RegisterServer2Request:
+		if !s.cfg.acceptRegistrations {
+			return &ua.ServiceFault{ResponseHeader: responseHeader(h, ua.StatusBadServiceUnsupported)}
+		}
+		code := s.registerServer(req.Server)
+		if code != ua.StatusOK {
+			return &ua.ServiceFault{ResponseHeader: responseHeader(h, code)}
+		}
+		results := make([]ua.StatusCode, len(req.DiscoveryConfiguration))
+		for i := range results {
+			results[i] = ua.StatusBadNotSupported
+		}
+		return &ua.RegisterServer2Response{
+			ResponseHeader:       responseHeader(h, code),
+			ConfigurationResults: results,
+		}
+	case *ua.FindServersOnNetworkRequest:
+		// only discovery servers with multicast extension
+		// provide this service.
+		return &ua.ServiceFault{ResponseHeader: responseHeader(h, ua.StatusBadServiceUnsupported)}
+	}
+	if ch.discoveryOnly(ch.mode) {
+		return &ua.ServiceFault{ResponseHeader: responseHeader(h, ua.StatusBadSecurityModeRejected)}
+	}
+
+	// services which do not require an activated session
+	switch req := req.(type) {
+	case *ua.CreateSessionRequest:
+		return s.sessions.create(ch, req)
+	case *ua.ActivateSessionRequest:
+		return s.sessions.activate(ch, req)
+	case *ua.CloseSessionRequest:
+		return s.sessions.close(ch, req)
+	}
+
+	sess, code := s.sessions.lookup(ch, h.AuthenticationToken)
+	if code != ua.StatusOK {
+		return &ua.ServiceFault{ResponseHeader: responseHeader(h, code)}
+	}
+
+	ctx := context.Background()
+	switch req := req.(type) {
+	case *ua.ReadRequest:
+		return s.read(ctx, req)
+	case *ua.WriteRequest:
+		return s.write(ctx, req)
+	case *ua.BrowseRequest:
+		return s.browse(ctx, sess, req)
+	case *ua.BrowseNextRequest:
+		return s.browseNext(sess, req)
+	case *ua.TranslateBrowsePathsToNodeIDsRequest:
+		return s.translateBrowsePaths(ctx, req)
+	case *ua.RegisterNodesRequest:
+		return &ua.RegisterNodesResponse{
+			ResponseHeader:    responseHeader(h, ua.StatusOK),
+			RegisteredNodeIDs: req.NodesToRegister,
+		}
+	case *ua.UnregisterNodesRequest:
+		return &ua.UnregisterNodesResponse{ResponseHeader: responseHeader(h, ua.StatusOK)}
+	default:
+		return &ua.ServiceFault{ResponseHeader: responseHeader(h, ua.StatusBadServiceUnsupported)}
+	}
+}
+
+// getEndpoints implements the GetEndpoints service. See Part 4, 5.4.4
+func (s *Server) getEndpoints(req *ua.GetEndpointsRequest) []*ua.EndpointDescription {
+	if len(req.ProfileURIs) == 0 {
+		return s.endpoints
+	}
+	var filtered []*ua.EndpointDescription
+	for _, ep := range s.endpoints {
+		for _, uri := range req.ProfileURIs {
+			if ep.TransportProfileURI == uri {
+				filtered = append(filtered, ep)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// findServers implements the FindServers service. See Part 4, 5.4.2
+func (s *Server) findServers(req *ua.FindServersRequest) []*ua.ApplicationDescription {
+	servers := []*ua.ApplicationDescription{s.endpoints[0].Server}
+
+	s.mu.Lock()
+	for _, r := range s.registered {
+		app := &ua.ApplicationDescription{
+			ApplicationURI:   r.ServerURI,
+			ProductURI:       r.ProductURI,
+			ApplicationType:  r.ServerType,
+			GatewayServerURI: r.GatewayServerURI,
+			DiscoveryURLs:    r.DiscoveryURLs,
+		}
+		if len(r.ServerNames) > 0 {
+			app.ApplicationName = r.ServerNames[0]
+		}
+		servers = append(servers, app)
+	}
+	s.mu.Unlock()
+
+	if len(req.ServerURIs) == 0 {
+		return servers
+	}
+	var filtered []*ua.ApplicationDescription
+	for _, app := range servers {
+		for _, uri := range req.ServerURIs {
+			if app.ApplicationURI == uri {
+				filtered = append(filtered, app)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// registerServer adds the server to the registered servers or removes it
+// if it is offline. See Part 4, 5.4.5
+func (s *Server) registerServer(r *ua.RegisteredServer) ua.StatusCode {
+	switch {
+	case r == nil || r.ServerURI == "":
+		return ua.StatusBadServerURIInvalid
+	case len(r.ServerNames) == 0:
+		return ua.StatusBadServerNameMissing
+	case len(r.DiscoveryURLs) == 0:
+		return ua.StatusBadDiscoveryURLMissing
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !r.IsOnline {
+		delete(s.registered, r.ServerURI)
+		return ua.StatusOK
+	}
+	if s.registered == nil {
+		s.registered = make(map[string]*ua.RegisteredServer)
+	}
+	s.registered[r.ServerURI] = r
+	return ua.StatusOK
+}
+
+// read implements the Read service. See Part 4, 5.10.2
+func (s *Server) read(ctx context.Context, req *ua.ReadRequest) *ua.ReadResponse {
+	if len(req.NodesToRead) == 0 {
+		return &ua.ReadResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadNothingToDo)}
+	}
+	if req.MaxAge < 0 {
+		return &ua.ReadResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadMaxAgeInvalid)}
+	}
+	if req.TimestampsToReturn > ua.TimestampsToReturnNeither {
+		return &ua.ReadResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadTimestampsToReturnInvalid)}
+	}
+
+	now := time.Now()
+	results := make([]*ua.DataValue, len(req.NodesToRead))
+	for i, rv := range req.NodesToRead {
+		dv := s.nodes.Read(ctx, rv)
+		if rv.AttributeID == ua.AttributeIDValue {
+			dv = withTimestamps(dv, req.TimestampsToReturn, now)
+		}
+		results[i] = dv
+	}
+	return &ua.ReadResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		Results:        results,
+	}
+}
+
+// withTimestamps returns a copy of the data value with the requested
+// timestamps.
+func withTimestamps(dv *ua.DataValue, ts ua.TimestampsToReturn, now time.Time) *ua.DataValue {
+	v := *dv
+	if ts == ua.TimestampsToReturnServer || ts == ua.TimestampsToReturnNeither {
+		v.EncodingMask &^= ua.DataValueSourceTimestamp | ua.DataValueSourcePicoseconds
+		v.SourceTimestamp = time.Time{}
+		v.SourcePicoseconds = 0
+	}
+	if ts == ua.TimestampsToReturnServer || ts == ua.TimestampsToReturnBoth {
+		v.EncodingMask |= ua.DataValueServerTimestamp
+		v.ServerTimestamp = now
+	}
+	return &v
+}
+
+// write implements the Write service. See Part 4, 5.10.4
+func (s *Server) write(ctx context.Context, req *ua.WriteRequest) *ua.WriteResponse {
+	if len(req.NodesToWrite) == 0 {
+		return &ua.WriteResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadNothingToDo)}
+	}
+	results := make([]ua.StatusCode, len(req.NodesToWrite))
+	for i, wv := range req.NodesToWrite {
+		results[i] = s.nodes.Write(ctx, wv)
+	}
+	return &ua.WriteResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		Results:        results,
+	}
+}
+
+// browse implements the Browse service. References which exceed the
+// maximum number of references per node are stored in the session and
+// returned by BrowseNext. See Part 4, 5.8.2
+func (s *Server) browse(ctx context.Context, sess *session, req *ua.BrowseRequest) *ua.BrowseResponse {
+	if len(req.NodesToBrowse) == 0 {
+		return &ua.BrowseResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadNothingToDo)}
+	}
+	if req.View != nil && req.View.ViewID != nil && !(req.View.ViewID.Namespace() == 0 && req.View.ViewID.IntID() == 0) {
+		return &ua.BrowseResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadViewIDUnknown)}
+	}
+
+	max := s.cfg.maxReferencesPerNode
+	if n := req.RequestedMaxReferencesPerNode; n > 0 && (max == 0 || n < max) {
+		max = n
+	}
+
+	results := make([]*ua.BrowseResult, len(req.NodesToBrowse))
+	for i, bd := range req.NodesToBrowse {
+		refs, code := s.nodes.Browse(ctx, bd)
+		if code != ua.StatusOK {
+			results[i] = &ua.BrowseResult{StatusCode: code}
+			continue
+		}
+		for j, ref := range refs {
+			refs[j] = maskReference(ref, ua.BrowseResultMask(bd.ResultMask))
+		}
+		results[i] = s.browseResult(sess, refs, max)
+	}
+	return &ua.BrowseResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		Results:        results,
+	}
+}
+
+// browseNext implements the BrowseNext service. See Part 4, 5.8.3
+func (s *Server) browseNext(sess *session, req *ua.BrowseNextRequest) *ua.BrowseNextResponse {
+	if len(req.ContinuationPoints) == 0 {
+		return &ua.BrowseNextResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadNothingToDo)}
+	}
+
+	max := s.cfg.maxReferencesPerNode
+	results := make([]*ua.BrowseResult, len(req.ContinuationPoints))
+	for i, cp := range req.ContinuationPoints {
+		var refs []*ua.ReferenceDescription
+		var ok bool
+		s.sessions.withSession(sess, func(sess *session) {
+			refs, ok = sess.continuationPoints[string(cp)]
+			delete(sess.continuationPoints, string(cp))
+		})
+		switch {
+		case !ok:
+			results[i] = &ua.BrowseResult{StatusCode: ua.StatusBadContinuationPointInvalid}
+		case req.ReleaseContinuationPoints:
+			results[i] = &ua.BrowseResult{StatusCode: ua.StatusOK}
+		default:
+			results[i] = s.browseResult(sess, refs, max)
+		}
+	}
+	return &ua.BrowseNextResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		Results:        results,
+	}
+}
+
+// browseResult returns the first max references and stores the remaining
+// references under a new continuation point.
+func (s *Server) browseResult(sess *session, refs []*ua.ReferenceDescription, max uint32) *ua.BrowseResult {
+	if max == 0 || uint32(len(refs)) <= max {
+		return &ua.BrowseResult{StatusCode: ua.StatusOK, References: refs}
+	}
+
+	cp := make([]byte, 16)
+	if _, err := rand.Read(cp); err != nil {
+		return &ua.BrowseResult{StatusCode: ua.StatusBadInternalError}
+	}
+	code := ua.StatusOK
+	s.sessions.withSession(sess, func(sess *session) {
+		if len(sess.continuationPoints) >= maxContinuationPoints {
+			code = ua.StatusBadNoContinuationPoints
+			return
+		}
+		sess.continuationPoints[string(cp)] = refs[max:]
+	})
+	if code != ua.StatusOK {
+		return &ua.BrowseResult{StatusCode: code}
+	}
+	return &ua.BrowseResult{
+		StatusCode:        ua.StatusOK,
+		ContinuationPoint: cp,
+		References:        refs[:max],
+	}
+}
+
+// maskReference returns the reference with only the fields of the result
+// mask. The other fields are set to their zero values.
+func maskReference(ref *ua.ReferenceDescription, mask ua.BrowseResultMask) *ua.ReferenceDescription {
+	r := &ua.ReferenceDescription{
+		ReferenceTypeID: ua.NewTwoByteNodeID(0),
+		NodeID:          ref.NodeID,
+		BrowseName:      &ua.QualifiedName{},
+		DisplayName:     &ua.LocalizedText{},
+		TypeDefinition:  ua.NewTwoByteExpandedNodeID(0),
+	}
+	if mask&ua.BrowseResultMaskReferenceTypeID != 0 {
+		r.ReferenceTypeID = ref.ReferenceTypeID
+	}
+	if mask&ua.BrowseResultMaskIsForward != 0 {
+		r.IsForward = ref.IsForward
+	}
+	if mask&ua.BrowseResultMaskNodeClass != 0 {
+		r.NodeClass = ref.NodeClass
+	}
+	if mask&ua.BrowseResultMaskBrowseName != 0 {
+		r.BrowseName = ref.BrowseName
+	}
+	if mask&ua.BrowseResultMaskDisplayName != 0 {
+		r.DisplayName = ref.DisplayName
+	}
+	if mask&ua.BrowseResultMaskTypeDefinition != 0 {
+		r.TypeDefinition = ref.TypeDefinition
+	}
+	return r
+}
+
+// translateBrowsePaths implements the TranslateBrowsePathsToNodeIDs
+// service. See Part 4, 5.8.4
+func (s *Server) translateBrowsePaths(ctx context.Context, req *ua.TranslateBrowsePathsToNodeIDsRequest) *ua.TranslateBrowsePathsToNodeIDsResponse {
+	if len(req.BrowsePaths) == 0 {
+		return &ua.TranslateBrowsePathsToNodeIDsResponse{ResponseHeader: responseHeader(req.RequestHeader, ua.StatusBadNothingToDo)}
+	}
+	results := make([]*ua.BrowsePathResult, len(req.BrowsePaths))
+	for i, p := range req.BrowsePaths {
+		results[i] = s.translateBrowsePath(ctx, p)
+	}
+	return &ua.TranslateBrowsePathsToNodeIDsResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		Results:        results,
+	}
+}
+
+func (s *Server) translateBrowsePath(ctx context.Context, p *ua.BrowsePath) *ua.BrowsePathResult {
+	if p.RelativePath == nil || len(p.RelativePath.Elements) == 0 {
+		return &ua.BrowsePathResult{StatusCode: ua.StatusBadNothingToDo}
+	}
+
+	nodes := []*ua.NodeID{p.StartingNode}
+	for i, e := range p.RelativePath.Elements {
+		if e.TargetName == nil || e.TargetName.Name == "" {
+			return &ua.BrowsePathResult{StatusCode: ua.StatusBadBrowseNameInvalid}
+		}
+		refType := e.ReferenceTypeID
+		if refType == nil || refType.Namespace() == 0 && refType.IntID() == 0 {
+			refType = ua.NewNumericNodeID(0, id.HierarchicalReferences)
+		}
+		dir := ua.BrowseDirectionForward
+		if e.IsInverse {
+			dir = ua.BrowseDirectionInverse
+		}
+
+		var next []*ua.NodeID
+		seen := map[string]bool{}
+		for _, n := range nodes {
+			refs, code := s.nodes.Browse(ctx, &ua.BrowseDescription{
+				NodeID:          n,
+				BrowseDirection: dir,
+				ReferenceTypeID: refType,
+				IncludeSubtypes: e.IncludeSubtypes,
+				ResultMask:      uint32(ua.BrowseResultMaskAll),
+			})
+			if code != ua.StatusOK {
+				if i == 0 {
+					return &ua.BrowsePathResult{StatusCode: code}
+				}
+				continue
+			}
+			for _, r := range refs {
+				if r.BrowseName.NamespaceIndex != e.TargetName.NamespaceIndex || r.BrowseName.Name != e.TargetName.Name {
+					continue
+				}
+				if k := r.NodeID.NodeID.String(); !seen[k] {
+					seen[k] = true
+					next = append(next, r.NodeID.NodeID)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return &ua.BrowsePathResult{StatusCode: ua.StatusBadNoMatch}
+		}
+		nodes = next
+	}
+
+	targets := make([]*ua.BrowsePathTarget, len(nodes))
+	for i, n := range nodes {
+		targets[i] = &ua.BrowsePathTarget{
+			TargetID:           ua.NewExpandedNodeID(n, "", 0),
+			RemainingPathIndex: math.MaxUint32,
+		}
+	}
+	return &ua.BrowsePathResult{StatusCode: ua.StatusOK, Targets: targets}
+}
@@ -0,0 +1,356 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
+)
+
+// maxContinuationPoints is the maximum number of browse continuation
+// points per session.
+const maxContinuationPoints = 100
+
+// session is a session created by a client.
+type session struct {
+	id        *ua.NodeID
+	authToken *ua.NodeID
+	name      string
+	timeout   time.Duration
+
+	// channelID is the id of the secure channel the session is bound to.
+	channelID  uint32
+	clientCert []byte
+	activated  bool
+	nonce      []byte
+	user       string
+	lastSeen   time.Time
+
+	// continuationPoints contains the references which have not been
+	// returned by Browse.
+	continuationPoints map[string][]*ua.ReferenceDescription
+}
+
+// sessionManager contains the sessions of the server.
+type sessionManager struct {
+	srv *Server
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	nextID   uint32
+}
+
+func newSessionManager(srv *Server) *sessionManager {
+	return &sessionManager{
+		srv:      srv,
+		sessions: map[string]*session{},
+	}
+}
+
+// create creates a new session for the client on the secure channel.
+// See Part 4, 5.6.2
+func (m *sessionManager) create(ch *secureChannel, req *ua.CreateSessionRequest) *ua.CreateSessionResponse {
+	fault := func(code ua.StatusCode) *ua.CreateSessionResponse {
+		return &ua.CreateSessionResponse{
+			ResponseHeader:      responseHeader(req.RequestHeader, code),
+			SessionID:           ua.NewTwoByteNodeID(0),
+			AuthenticationToken: ua.NewTwoByteNodeID(0),
+			ServerSignature:     &ua.SignatureData{},
+		}
+	}
+
+	cfg := m.srv.cfg
+	if ch.policyURI != ua.SecurityPolicyURINone && !bytes.Equal(req.ClientCertificate, ch.remoteCert) {
+		return fault(ua.StatusBadCertificateInvalid)
+	}
+
+	timeout := cfg.sessionTimeout
+	if d := time.Duration(req.RequestedSessionTimeout * float64(time.Millisecond)); d > 0 && d < timeout {
+		timeout = d
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return fault(ua.StatusBadInternalError)
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return fault(ua.StatusBadInternalError)
+	}
+
+	sig := &ua.SignatureData{}
+	if ch.policyURI != ua.SecurityPolicyURINone {
+		sig.Signature, err = ch.asym.Signature(append(append([]byte(nil), req.ClientCertificate...), req.ClientNonce...))
+		if err != nil {
+			return fault(ua.StatusBadInternalError)
+		}
+		sig.Algorithm = ch.asym.SignatureURI()
+	}
+
+	m.mu.Lock()
+	m.purge()
+	if len(m.sessions) >= cfg.maxSessions {
+		m.mu.Unlock()
+		return fault(ua.StatusBadTooManySessions)
+	}
+	m.nextID++
+	s := &session{
+		id:                 ua.NewNumericNodeID(1, m.nextID),
+		authToken:          ua.NewByteStringNodeID(0, token),
+		name:               req.SessionName,
+		timeout:            timeout,
+		channelID:          ch.id,
+		clientCert:         req.ClientCertificate,
+		nonce:              nonce,
+		lastSeen:           time.Now(),
+		continuationPoints: map[string][]*ua.ReferenceDescription{},
+	}
+	m.sessions[s.authToken.String()] = s
+	m.mu.Unlock()
+
+	ch.log.Info("server: session created", "session_id", s.id, "session_name", s.name)
+
+	return &ua.CreateSessionResponse{
+		ResponseHeader:        responseHeader(req.RequestHeader, ua.StatusOK),
+		SessionID:             s.id,
+		AuthenticationToken:   s.authToken,
+		RevisedSessionTimeout: float64(timeout / time.Millisecond),
+		ServerNonce:           nonce,
+		ServerCertificate:     cfg.certificate,
+		ServerEndpoints:       m.srv.endpoints,
+		ServerSignature:       sig,
+	}
+}
+
+// activate activates the session and binds it to the secure channel.
+// See Part 4, 5.6.3
+func (m *sessionManager) activate(ch *secureChannel, req *ua.ActivateSessionRequest) *ua.ActivateSessionResponse {
+	fault := func(code ua.StatusCode) *ua.ActivateSessionResponse {
+		return &ua.ActivateSessionResponse{ResponseHeader: responseHeader(req.RequestHeader, code)}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, code := m.session(req.RequestHeader.AuthenticationToken)
+	if code != ua.StatusOK {
+		return fault(code)
+	}
+
+	if ch.policyURI != ua.SecurityPolicyURINone {
+		if !s.activated && s.channelID != ch.id {
+			return fault(ua.StatusBadSecureChannelIDInvalid)
+		}
+		if !bytes.Equal(s.clientCert, ch.remoteCert) {
+			return fault(ua.StatusBadApplicationSignatureInvalid)
+		}
+		if req.ClientSignature == nil {
+			return fault(ua.StatusBadApplicationSignatureInvalid)
+		}
+		msg := append(append([]byte(nil), m.srv.cfg.certificate...), s.nonce...)
+		if err := ch.asym.VerifySignature(msg, req.ClientSignature.Signature); err != nil {
+			return fault(ua.StatusBadApplicationSignatureInvalid)
+		}
+	}
+
+	user, code := m.authenticate(ch, s, req.UserIdentityToken)
+	if code != ua.StatusOK {
+		return fault(code)
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return fault(ua.StatusBadInternalError)
+	}
+
+	s.channelID = ch.id
+	s.activated = true
+	s.user = user
+	s.nonce = nonce
+	s.lastSeen = time.Now()
+
+	ch.log.Info("server: session activated", "session_id", s.id, "user", user)
+
+	return &ua.ActivateSessionResponse{
+		ResponseHeader: responseHeader(req.RequestHeader, ua.StatusOK),
+		ServerNonce:    nonce,
+	}
+}
+
+// authenticate validates the user identity token and returns the name of
+// the user. The lock must be held.
+func (m *sessionManager) authenticate(ch *secureChannel, s *session, eo *ua.ExtensionObject) (string, ua.StatusCode) {
+	cfg := m.srv.cfg
+	enabled := func(t ua.UserTokenType) bool {
+		for _, m := range cfg.authModes {
+			if m == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	var tok interface{}
+	if eo != nil {
+		tok = eo.Value
+	}
+	switch tok := tok.(type) {
+	case nil, *ua.AnonymousIdentityToken:
+		if !enabled(ua.UserTokenTypeAnonymous) {
+			return "", ua.StatusBadIdentityTokenRejected
+		}
+		return "", ua.StatusOK
+
+	case *ua.UserNameIdentityToken:
+		if !enabled(ua.UserTokenTypeUserName) {
+			return "", ua.StatusBadIdentityTokenRejected
+		}
+		pass, code := m.decryptPassword(ch, s, tok)
+		if code != ua.StatusOK {
+			return "", code
+		}
+		if !cfg.authUser(tok.UserName, pass) {
+			return "", ua.StatusBadUserAccessDenied
+		}
+		return tok.UserName, ua.StatusOK
+
+	default:
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+}
+
+// decryptPassword returns the password of the user name token. The
+// password is encrypted with the server certificate unless the security
+// policy of the token is None. See Part 4, 7.36.3
+func (m *sessionManager) decryptPassword(ch *secureChannel, s *session, tok *ua.UserNameIdentityToken) (string, ua.StatusCode) {
+	policyURI := m.srv.passwordPolicyURI(ch.policyURI)
+	if policyURI == ua.SecurityPolicyURINone {
+		if tok.EncryptionAlgorithm != "" {
+			return "", ua.StatusBadIdentityTokenInvalid
+		}
+		return string(tok.Password), ua.StatusOK
+	}
+
+	algo, err := uapolicy.Asymmetric(policyURI, m.srv.cfg.privateKey, nil)
+	if err != nil {
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+	if tok.EncryptionAlgorithm != algo.EncryptionURI() {
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+	b, err := algo.Decrypt(tok.Password)
+	if err != nil || len(b) < 4 {
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n < len(s.nonce) || len(b) < 4+n {
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+	pass, nonce := b[4:4+n-len(s.nonce)], b[4+n-len(s.nonce):4+n]
+	if !bytes.Equal(nonce, s.nonce) {
+		return "", ua.StatusBadIdentityTokenInvalid
+	}
+	return string(pass), ua.StatusOK
+}
+
+// close closes the session. See Part 4, 5.6.4
+func (m *sessionManager) close(ch *secureChannel, req *ua.CloseSessionRequest) *ua.CloseSessionResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, code := m.session(req.RequestHeader.AuthenticationToken)
+	if code == ua.StatusOK && s.channelID != ch.id {
+		code = ua.StatusBadSecureChannelIDInvalid
+	}
+	if code == ua.StatusOK {
+		delete(m.sessions, s.authToken.String())
+		ch.log.Info("server: session closed", "session_id", s.id)
+	}
+	return &ua.CloseSessionResponse{ResponseHeader: responseHeader(req.RequestHeader, code)}
+}
+
+// lookup returns the activated session for the authentication token which
+// must be bound to the secure channel.
+func (m *sessionManager) lookup(ch *secureChannel, authToken *ua.NodeID) (*session, ua.StatusCode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, code := m.session(authToken)
+	switch {
+	case code != ua.StatusOK:
+		return nil, code
+	case !s.activated:
+		return nil, ua.StatusBadSessionNotActivated
+	case s.channelID != ch.id:
+		return nil, ua.StatusBadSecureChannelIDInvalid
+	}
+	s.lastSeen = time.Now()
+	return s, ua.StatusOK
+}
+
+// session returns the session for the authentication token. Expired
+// sessions are removed. The lock must be held.
+func (m *sessionManager) session(authToken *ua.NodeID) (*session, ua.StatusCode) {
+	if authToken == nil {
+		return nil, ua.StatusBadSessionIDInvalid
+	}
+	key := authToken.String()
+	s := m.sessions[key]
+	if s == nil {
+		return nil, ua.StatusBadSessionIDInvalid
+	}
+	if time.Since(s.lastSeen) > s.timeout {
+		delete(m.sessions, key)
+		return nil, ua.StatusBadSessionIDInvalid
+	}
+	return s, ua.StatusOK
+}
+
+// purge removes the expired sessions. The lock must be held.
+func (m *sessionManager) purge() {
+	for k, s := range m.sessions {
+		if time.Since(s.lastSeen) > s.timeout {
+			m.srv.log.Info("server: session timed out", "session_id", s.id)
+			delete(m.sessions, k)
+		}
+	}
+}
+
+// withSession calls fn with the session while holding the lock.
+func (m *sessionManager) withSession(s *session, fn func(s *session)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(s)
+}
+
+// newNonce returns a random session nonce.
+func newNonce() ([]byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// publicKey returns the public key of a DER encoded certificate.
+func publicKey(cert []byte) (*rsa.PublicKey, error) {
+	c, err := x509.ParseCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := c.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ua.StatusBadCertificateInvalid
+	}
+	return key, nil
+}
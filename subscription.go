@@ -3,12 +3,13 @@ package opcua
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/stats"
 	"github.com/zzylovesll/myOpcUa/ua"
 	"github.com/zzylovesll/myOpcUa/uasc"
@@ -36,6 +37,22 @@ type Subscription struct {
 	lastSeq                   uint32
 	nextSeq                   uint32
 	c                         *Client
+
+	// triggers contains the client handles of the triggered items by
+	// the client handle of the triggering item. It is guarded by itemsMu.
+	triggers map[uint32]map[uint32]bool
+
+	// pending contains the notification messages by sequence number
+	// which have been received before the messages with lower sequence
+	// numbers. It is guarded by c.subMux.
+	pending map[uint32]*pendingNotif
+
+	// delivered is closed when the last batch of notification messages
+	// has been delivered. It is guarded by c.subMux.
+	delivered <-chan struct{}
+
+	// stats contains the cumulative statistics of the subscription.
+	stats subscriptionStats
 }
 
 type SubscriptionParameters struct {
@@ -44,6 +61,17 @@ type SubscriptionParameters struct {
 	MaxKeepAliveCount          uint32
 	MaxNotificationsPerPublish uint32
 	Priority                   uint8
+
+	// DisableTransfer disables the transfer of the subscription to a new
+	// session when the client restores a lost connection. The subscription
+	// and its monitored items are recreated instead. This is useful for
+	// servers which do not support the TransferSubscriptions service.
+	DisableTransfer bool
+
+	// RevisedFunc is called when Modify changes the publishing interval,
+	// the lifetime count or the max keep-alive count which the server
+	// has revised, e.g. to adjust the timers of the application.
+	RevisedFunc func(interval time.Duration, lifetimeCount, maxKeepAliveCount uint32)
 }
 
 type monitoredItem struct {
@@ -52,11 +80,52 @@ type monitoredItem struct {
 	ts  ua.TimestampsToReturn
 }
 
-func NewMonitoredItemCreateRequestWithDefaults(nodeID *ua.NodeID, attributeID ua.AttributeID, clientHandle uint32) *ua.MonitoredItemCreateRequest {
+// MonitoredItemOption configures a monitored item created with
+// NewMonitoredItemCreateRequestWithDefaults.
+type MonitoredItemOption func(*ua.MonitoredItemCreateRequest)
+
+// MonitoredItemAggregate configures the monitored item to report the
+// aggregate of the values over the given interval, e.g. the average with
+// id.AggregateFunction_Average, instead of the values themselves.
+//
+// If the server rejects the aggregate the StatusCode of the
+// MonitoredItemCreateResult is bad and the FilterResult can contain an
+// AggregateFilterResult with the revised parameters.
+func MonitoredItemAggregate(aggregateType *ua.NodeID, interval time.Duration) MonitoredItemOption {
+	return func(req *ua.MonitoredItemCreateRequest) {
+		req.RequestedParameters.Filter = ua.NewAggregateFilter(aggregateType, time.Now(), interval)
+	}
+}
+
+// MonitoredItemMode sets the monitoring mode of the monitored item, e.g.
+// ua.MonitoringModeSampling for items which only report their values
+// when a triggering item reports. See Subscription.SetItemsTriggering.
+func MonitoredItemMode(mode ua.MonitoringMode) MonitoredItemOption {
+	return func(req *ua.MonitoredItemCreateRequest) {
+		req.MonitoringMode = mode
+	}
+}
+
+// MonitoredItemDeadband configures a DataChangeFilter for the monitored
+// item which reports a new value only if it differs from the last
+// reported value by more than the deadband, e.g. to suppress the noise of
+// an analog value with an absolute deadband.
+//
+// For ua.DeadbandTypeAbsolute value is the absolute change of the value
+// and for ua.DeadbandTypePercent the percentage of the EURange of the
+// variable. Monitored items with a percent deadband on a variable without
+// an EURange property are rejected with StatusBadFilterNotAllowed.
+func MonitoredItemDeadband(kind ua.DeadbandType, value float64, trigger ua.DataChangeTrigger) MonitoredItemOption {
+	return func(req *ua.MonitoredItemCreateRequest) {
+		req.RequestedParameters.Filter = ua.NewDataChangeFilter(trigger, kind, value)
+	}
+}
+
+func NewMonitoredItemCreateRequestWithDefaults(nodeID *ua.NodeID, attributeID ua.AttributeID, clientHandle uint32, opts ...MonitoredItemOption) *ua.MonitoredItemCreateRequest {
 	if attributeID == 0 {
 		attributeID = ua.AttributeIDValue
 	}
-	return &ua.MonitoredItemCreateRequest{
+	req := &ua.MonitoredItemCreateRequest{
 		ItemToMonitor: &ua.ReadValueID{
 			NodeID:       nodeID,
 			AttributeID:  attributeID,
@@ -71,12 +140,40 @@ func NewMonitoredItemCreateRequestWithDefaults(nodeID *ua.NodeID, attributeID ua
 			SamplingInterval: 0.0,
 		},
 	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req
 }
 
 type PublishNotificationData struct {
 	SubscriptionID uint32
 	Error          error
 	Value          interface{}
+
+	// Events contains the decoded events of an EventNotificationList.
+	// The event fields are mapped to the select clauses of the
+	// EventFilter of the monitored item with the same client handle.
+	Events []*Event
+}
+
+// Event contains the fields of an event notification keyed by the
+// field name of the select clauses of the EventFilter, e.g. "Severity"
+// or "EnabledState/Id".
+//
+// See ua.SimpleAttributeOperand.FieldName
+type Event struct {
+	ClientHandle uint32
+	Fields       map[string]*ua.Variant
+}
+
+// Value returns the value of the event field or nil if the event
+// does not have the field.
+func (e *Event) Value(name string) interface{} {
+	if v := e.Fields[name]; v != nil {
+		return v.Value()
+	}
+	return nil
 }
 
 // Cancel stops the subscription and removes it
@@ -87,6 +184,50 @@ func (s *Subscription) Cancel(ctx context.Context) error {
 	return s.delete(ctx)
 }
 
+// Transfer moves the subscription from a previous session to the current
+// session of the client and republishes the notifications which have not
+// been acknowledged yet. If the server rejects the transfer, e.g. with
+// StatusBadSubscriptionIDInvalid, the subscription and its monitored items
+// are recreated instead and the subscription gets a new SubscriptionID.
+// The subscription is always recreated if DisableTransfer is set in the
+// SubscriptionParameters.
+//
+// The client transfers all subscriptions automatically when it restores a
+// lost connection with a new session.
+func (s *Subscription) Transfer(ctx context.Context) error {
+	stats.Subscription().Add("Transfer", 1)
+
+	id := s.SubscriptionID
+	if s.params.DisableTransfer {
+		return s.c.recreateSubscription(ctx, id)
+	}
+
+	res, err := s.c.transferSubscriptions(ctx, []uint32{id})
+	switch {
+	case err != nil:
+		s.log().Warn("client: transfer failed", "sub_id", id, "err", err)
+	case len(res.Results) != 1:
+		s.log().Warn("client: transfer failed", "sub_id", id, "results", len(res.Results))
+	case res.Results[0].StatusCode != ua.StatusOK:
+		s.log().Warn("client: transfer failed", "sub_id", id, "status", res.Results[0].StatusCode)
+	default:
+		err := s.c.republishSubscription(ctx, id, res.Results[0].AvailableSequenceNumbers)
+		if err == nil {
+			return nil
+		}
+		s.log().Warn("client: republish failed", "sub_id", id, "err", err)
+	}
+	return s.c.recreateSubscription(ctx, id)
+}
+
+// log returns the logger of the client of the subscription.
+func (s *Subscription) log() logger.Logger {
+	if s.c == nil {
+		return logger.Default
+	}
+	return s.c.log
+}
+
 // delete removes the subscription from the server.
 func (s *Subscription) delete(ctx context.Context) error {
 	req := &ua.DeleteSubscriptionsRequest{
@@ -122,25 +263,45 @@ func (s *Subscription) MonitorWithContext(ctx context.Context, ts ua.TimestampsT
 	stats.Subscription().Add("Monitor", 1)
 	stats.Subscription().Add("MonitoredItems", int64(len(items)))
 
-	// Part 4, 5.12.2.2 CreateMonitoredItems Service Parameters
-	req := &ua.CreateMonitoredItemsRequest{
-		SubscriptionID:     s.SubscriptionID,
-		TimestampsToReturn: ts,
-		ItemsToCreate:      items,
+	rejected, err := s.checkPercentDeadbands(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	create := items
+	if len(rejected) > 0 {
+		create = nil
+		for i, item := range items {
+			if !rejected[i] {
+				create = append(create, item)
+			}
+		}
 	}
 
-	var res *ua.CreateMonitoredItemsResponse
-	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
-		return safeAssign(v, &res)
-	})
+	res := &ua.CreateMonitoredItemsResponse{ResponseHeader: &ua.ResponseHeader{}}
+	if len(create) > 0 {
+		// Part 4, 5.12.2.2 CreateMonitoredItems Service Parameters
+		req := &ua.CreateMonitoredItemsRequest{
+			SubscriptionID:     s.SubscriptionID,
+			TimestampsToReturn: ts,
+			ItemsToCreate:      create,
+		}
 
-	if err != nil {
-		return nil, err
+		err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
+			return safeAssign(v, &res)
+		})
+
+		if err != nil {
+			return nil, err
+		}
 	}
+	res.Results = mergeMonitorResults(len(items), rejected, res.Results)
 
 	// store monitored items
 	s.itemsMu.Lock()
 	for i, item := range items {
+		if rejected[i] {
+			continue
+		}
 		result := res.Results[i]
 		s.items[result.MonitoredItemID] = &monitoredItem{
 			req: item,
@@ -150,7 +311,100 @@ func (s *Subscription) MonitorWithContext(ctx context.Context, ts ua.TimestampsT
 	}
 	s.itemsMu.Unlock()
 
-	return res, err
+	return res, nil
+}
+
+// checkPercentDeadbands returns the indexes of the items with a percent
+// deadband on a variable without an EURange property. The server would
+// reject them with StatusBadFilterNotAllowed.
+//
+// Specification: Part 8, 6.2
+func (s *Subscription) checkPercentDeadbands(ctx context.Context, items []*ua.MonitoredItemCreateRequest) (map[int]bool, error) {
+	var idx []int
+	var paths []*ua.BrowsePath
+	for i, item := range items {
+		if !isPercentDeadband(item) {
+			continue
+		}
+		idx = append(idx, i)
+		paths = append(paths, ua.NewBrowsePath(item.ItemToMonitor.NodeID, []*ua.QualifiedName{{Name: "EURange"}}))
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	req := &ua.TranslateBrowsePathsToNodeIDsRequest{BrowsePaths: paths}
+	var res *ua.TranslateBrowsePathsToNodeIDsResponse
+	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != len(paths) {
+		return nil, ua.StatusBadUnexpectedError
+	}
+
+	rejected := make(map[int]bool)
+	for i, r := range res.Results {
+		if r.StatusCode != ua.StatusOK || len(r.Targets) == 0 {
+			rejected[idx[i]] = true
+		}
+	}
+	return rejected, nil
+}
+
+func isPercentDeadband(item *ua.MonitoredItemCreateRequest) bool {
+	if item.ItemToMonitor == nil || item.RequestedParameters == nil || item.RequestedParameters.Filter == nil {
+		return false
+	}
+	f, ok := item.RequestedParameters.Filter.Value.(*ua.DataChangeFilter)
+	return ok && ua.DeadbandType(f.DeadbandType) == ua.DeadbandTypePercent
+}
+
+// mergeMonitorResults returns the results for n items from the results
+// of the items which have been created and StatusBadFilterNotAllowed for
+// the rejected items.
+func mergeMonitorResults(n int, rejected map[int]bool, created []*ua.MonitoredItemCreateResult) []*ua.MonitoredItemCreateResult {
+	if len(rejected) == 0 {
+		return created
+	}
+	results := make([]*ua.MonitoredItemCreateResult, 0, n)
+	for i := 0; i < n; i++ {
+		if rejected[i] {
+			results = append(results, &ua.MonitoredItemCreateResult{StatusCode: ua.StatusBadFilterNotAllowed})
+			continue
+		}
+		if len(created) == 0 {
+			results = append(results, &ua.MonitoredItemCreateResult{StatusCode: ua.StatusBadUnexpectedError})
+			continue
+		}
+		results = append(results, created[0])
+		created = created[1:]
+	}
+	return results
+}
+
+// MonitorWithFilter creates the monitored items with the given filter, e.g.
+// a DataChangeFilter created with ua.NewDataChangeFilter. The filter
+// replaces the filter in the RequestedParameters of the items but the
+// items themselves are not modified.
+//
+// Servers report unsupported filters in the StatusCode of the results
+// and return the revised filter parameters, if any, in the FilterResult.
+func (s *Subscription) MonitorWithFilter(ctx context.Context, ts ua.TimestampsToReturn, filter *ua.ExtensionObject, items ...*ua.MonitoredItemCreateRequest) (*ua.CreateMonitoredItemsResponse, error) {
+	filtered := make([]*ua.MonitoredItemCreateRequest, len(items))
+	for i, item := range items {
+		fi := *item
+		params := &ua.MonitoringParameters{}
+		if item.RequestedParameters != nil {
+			*params = *item.RequestedParameters
+		}
+		params.Filter = filter
+		fi.RequestedParameters = params
+		filtered[i] = &fi
+	}
+	return s.MonitorWithContext(ctx, ts, filtered...)
 }
 
 // Note: Starting with v0.5 this method will require a context
@@ -178,7 +432,7 @@ func (s *Subscription) UnmonitorWithContext(ctx context.Context, monitoredItemID
 		// remove monitored items
 		s.itemsMu.Lock()
 		for _, id := range monitoredItemIDs {
-			delete(s.items, id)
+			s.deleteItem_NeedsItemsMuLock(id)
 		}
 		s.itemsMu.Unlock()
 	}
@@ -186,6 +440,150 @@ func (s *Subscription) UnmonitorWithContext(ctx context.Context, monitoredItemID
 	return res, err
 }
 
+// UnmonitorItems deletes the monitored items with the given client
+// handles from the server and the subscription. Client handles of items
+// which are not monitored are ignored so that removing an item twice is
+// not an error. Items which the server no longer knows are removed as
+// well. If the server rejects the deletion of an item the item is kept
+// and an error is returned after all other items have been removed.
+func (s *Subscription) UnmonitorItems(ctx context.Context, clientHandles ...uint32) error {
+	ids, handles := s.monitoredItemIDs(clientHandles)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stats.Subscription().Add("Unmonitor", 1)
+	stats.Subscription().Add("UnmonitoredItems", int64(len(ids)))
+
+	req := &ua.DeleteMonitoredItemsRequest{
+		MonitoredItemIDs: ids,
+		SubscriptionID:   s.SubscriptionID,
+	}
+	var res *ua.DeleteMonitoredItemsResponse
+	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Results) != len(ids) {
+		return errors.Errorf("sub %d: got %d results for %d monitored items", s.SubscriptionID, len(res.Results), len(ids))
+	}
+	return s.removeItems(ids, handles, res.Results)
+}
+
+// monitoredItemIDs returns the ids and the client handles of the monitored
+// items with the given client handles.
+func (s *Subscription) monitoredItemIDs(clientHandles []uint32) (ids, handles []uint32) {
+	s.itemsMu.Lock()
+	defer s.itemsMu.Unlock()
+
+	for _, h := range clientHandles {
+		for id, item := range s.items {
+			if p := item.req.RequestedParameters; p != nil && p.ClientHandle == h {
+				ids = append(ids, id)
+				handles = append(handles, h)
+				break
+			}
+		}
+	}
+	return ids, handles
+}
+
+// removeItems removes the monitored items which the server has deleted
+// and returns an error for the first item which it has not deleted.
+func (s *Subscription) removeItems(ids, handles []uint32, results []ua.StatusCode) error {
+	s.itemsMu.Lock()
+	defer s.itemsMu.Unlock()
+
+	var err error
+	for i, status := range results {
+		switch status {
+		case ua.StatusOK, ua.StatusBadMonitoredItemIDInvalid:
+			s.deleteItem_NeedsItemsMuLock(ids[i])
+		default:
+			if err == nil {
+				err = errors.Wrapf(status, "sub %d: cannot unmonitor client handle %d", s.SubscriptionID, handles[i])
+			}
+		}
+	}
+	return err
+}
+
+// deleteItem_NeedsItemsMuLock removes the monitored item and its
+// triggering links which the server removes with the item.
+func (s *Subscription) deleteItem_NeedsItemsMuLock(id uint32) {
+	if item, ok := s.items[id]; ok && item.req.RequestedParameters != nil {
+		h := item.req.RequestedParameters.ClientHandle
+		delete(s.triggers, h)
+		for _, triggered := range s.triggers {
+			delete(triggered, h)
+		}
+	}
+	delete(s.items, id)
+}
+
+// Modify changes the parameters of the subscription with the
+// ModifySubscription service. Parameters that have not been set are set
+// to their default values. The params replace the parameters of the
+// subscription but the RevisedFunc is kept if params has none.
+//
+// The revised values of the server are stored in the subscription and
+// RevisedFunc is called if they have changed.
+func (s *Subscription) Modify(ctx context.Context, params *SubscriptionParameters) (*ua.ModifySubscriptionResponse, error) {
+	stats.Subscription().Add("Modify", 1)
+
+	p := &SubscriptionParameters{}
+	if params != nil {
+		*p = *params
+	}
+	p.setDefaults()
+
+	req := &ua.ModifySubscriptionRequest{
+		SubscriptionID:              s.SubscriptionID,
+		RequestedPublishingInterval: float64(p.Interval / time.Millisecond),
+		RequestedLifetimeCount:      p.LifetimeCount,
+		RequestedMaxKeepAliveCount:  p.MaxKeepAliveCount,
+		MaxNotificationsPerPublish:  p.MaxNotificationsPerPublish,
+		Priority:                    p.Priority,
+	}
+	var res *ua.ModifySubscriptionResponse
+	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status := res.ResponseHeader.ServiceResult; status != ua.StatusOK {
+		return nil, status
+	}
+
+	s.c.subMux.Lock()
+	if p.RevisedFunc == nil {
+		p.RevisedFunc = s.params.RevisedFunc
+	}
+	s.params = p
+	interval := time.Duration(res.RevisedPublishingInterval) * time.Millisecond
+	changed := s.revise(interval, res.RevisedLifetimeCount, res.RevisedMaxKeepAliveCount)
+	s.c.updatePublishTimeout_NeedsSubMuxRLock()
+	s.c.subMux.Unlock()
+
+	if changed && p.RevisedFunc != nil {
+		p.RevisedFunc(interval, res.RevisedLifetimeCount, res.RevisedMaxKeepAliveCount)
+	}
+	return res, nil
+}
+
+// revise stores the values revised by the server and returns true if
+// they have changed.
+func (s *Subscription) revise(interval time.Duration, lifetimeCount, maxKeepAliveCount uint32) bool {
+	changed := s.RevisedPublishingInterval != interval || s.RevisedLifetimeCount != lifetimeCount || s.RevisedMaxKeepAliveCount != maxKeepAliveCount
+	s.RevisedPublishingInterval = interval
+	s.RevisedLifetimeCount = lifetimeCount
+	s.RevisedMaxKeepAliveCount = maxKeepAliveCount
+	return changed
+}
+
 // Note: Starting with v0.5 this method will require a context
 // and the corresponding XXXWithContext(ctx) method will be removed.
 func (s *Subscription) ModifyMonitoredItems(ts ua.TimestampsToReturn, items ...*ua.MonitoredItemModifyRequest) (*ua.ModifyMonitoredItemsResponse, error) {
@@ -201,6 +599,7 @@ func (s *Subscription) ModifyMonitoredItemsWithContext(ctx context.Context, ts u
 	for _, item := range items {
 		id := item.MonitoredItemID
 		if _, exists := s.items[id]; !exists {
+			s.itemsMu.Unlock()
 			return nil, fmt.Errorf("sub %d: cannot modify unknown monitored item id: %d", s.SubscriptionID, id)
 		}
 	}
@@ -218,26 +617,100 @@ func (s *Subscription) ModifyMonitoredItemsWithContext(ctx context.Context, ts u
 	if err != nil {
 		return nil, err
 	}
+	s.updateItems(ts, items, res.Results)
+	return res, nil
+}
 
-	// update monitored items
+// updateItems stores the parameters of the monitored items which the
+// server has modified so that they are used when the subscription is
+// recreated after a reconnect.
+func (s *Subscription) updateItems(ts ua.TimestampsToReturn, items []*ua.MonitoredItemModifyRequest, results []*ua.MonitoredItemModifyResult) {
 	s.itemsMu.Lock()
-	for i, res := range res.Results {
-		if res.StatusCode != ua.StatusOK {
+	defer s.itemsMu.Unlock()
+
+	for i, res := range results {
+		if i >= len(items) || res == nil || res.StatusCode != ua.StatusOK {
+			continue
+		}
+		item, ok := s.items[items[i].MonitoredItemID]
+		if !ok {
 			continue
 		}
 
-		id := req.ItemsToModify[i].MonitoredItemID
-		item := s.items[id]
-		item.ts = req.TimestampsToReturn
-		item.req.RequestedParameters = req.ItemsToModify[i].RequestedParameters
-		item.res.StatusCode = res.StatusCode
-		item.res.RevisedSamplingInterval = res.RevisedSamplingInterval
-		item.res.RevisedQueueSize = res.RevisedQueueSize
-		item.res.FilterResult = res.FilterResult
+		// the create request belongs to the caller of Monitor.
+		req := *item.req
+		req.RequestedParameters = items[i].RequestedParameters
+		item.req = &req
+		item.ts = ts
+		if item.res != nil {
+			item.res.StatusCode = res.StatusCode
+			item.res.RevisedSamplingInterval = res.RevisedSamplingInterval
+			item.res.RevisedQueueSize = res.RevisedQueueSize
+			item.res.FilterResult = res.FilterResult
+		}
 	}
-	s.itemsMu.Unlock()
+}
 
-	return res, nil
+// ModifyItems changes the sampling interval, the queue size, the filter
+// and the other monitoring parameters of the monitored items with the
+// ClientHandle of the RequestedParameters. The MonitoredItemID of the
+// requests is ignored.
+//
+// It returns the revised parameters of the server in the order of the
+// requests. The parameters of the items which the server has modified are
+// stored so that they are used when the subscription is recreated after a
+// reconnect. If the server rejects an item an error is returned for the
+// first rejected item.
+//
+// See Part 4, 5.12.3
+func (s *Subscription) ModifyItems(ctx context.Context, ts ua.TimestampsToReturn, items ...*ua.MonitoredItemModifyRequest) ([]*ua.MonitoredItemModifyResult, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]uint32, len(items))
+	for i, item := range items {
+		if item.RequestedParameters == nil {
+			return nil, errors.Errorf("sub %d: missing monitoring parameters", s.SubscriptionID)
+		}
+		handles[i] = item.RequestedParameters.ClientHandle
+	}
+	ids, err := s.knownItemIDs(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]*ua.MonitoredItemModifyRequest, len(items))
+	for i, item := range items {
+		req := *item
+		req.MonitoredItemID = ids[i]
+		reqs[i] = &req
+	}
+	res, err := s.ModifyMonitoredItemsWithContext(ctx, ts, reqs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) != len(reqs) {
+		return nil, errors.Errorf("sub %d: got %d results for %d monitored items", s.SubscriptionID, len(res.Results), len(reqs))
+	}
+	for i, r := range res.Results {
+		if r.StatusCode != ua.StatusOK {
+			return res.Results, errors.Wrapf(r.StatusCode, "sub %d: cannot modify monitored item with client handle %d", s.SubscriptionID, handles[i])
+		}
+	}
+	return res.Results, nil
+}
+
+// knownItemIDs returns the ids of the monitored items with the client
+// handles in the same order and an error for the first unknown handle.
+func (s *Subscription) knownItemIDs(clientHandles []uint32) ([]uint32, error) {
+	ids, handles := s.monitoredItemIDs(clientHandles)
+	for i, h := range clientHandles {
+		if i >= len(handles) || handles[i] != h {
+			return nil, errors.Errorf("sub %d: unknown monitored item with client handle %d", s.SubscriptionID, h)
+		}
+	}
+	return ids, nil
 }
 
 // SetTriggering sends a request to the server to add and/or remove triggering links from a triggering item.
@@ -266,7 +739,221 @@ func (s *Subscription) SetTriggeringWithContext(ctx context.Context, triggeringI
 	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
 		return safeAssign(v, &res)
 	})
-	return res, err
+	if err != nil {
+		return nil, err
+	}
+	s.updateTriggers(triggeringItemID, add, remove, res.AddResults, res.RemoveResults)
+	return res, nil
+}
+
+// SetItemsTriggering adds and removes the links from the triggering item
+// to the triggered items like SetTriggering but with the client handles
+// of the monitored items instead of their ids. Triggered items are
+// usually created in sampling mode with the MonitoredItemMode option so
+// that they only report their values when the triggering item reports.
+//
+// The links are stored with the subscription and are established again
+// when the subscription is recreated after a reconnect. If the server
+// rejects a link an error is returned for the first rejected link.
+//
+// See Part 4, 5.12.5
+func (s *Subscription) SetItemsTriggering(ctx context.Context, triggeringHandle uint32, add, remove []uint32) error {
+	ids, err := s.knownItemIDs(append([]uint32{triggeringHandle}, add...))
+	if err != nil {
+		return err
+	}
+	addIDs := ids[1:]
+
+	// links to items which no longer exist have been removed already.
+	removeIDs, _ := s.monitoredItemIDs(remove)
+
+	if len(addIDs) == 0 && len(removeIDs) == 0 {
+		return nil
+	}
+	res, err := s.SetTriggeringWithContext(ctx, ids[0], addIDs, removeIDs)
+	if err != nil {
+		return err
+	}
+	if len(res.AddResults) != len(addIDs) || len(res.RemoveResults) != len(removeIDs) {
+		return errors.Errorf("sub %d: got %d results for %d triggering links", s.SubscriptionID, len(res.AddResults)+len(res.RemoveResults), len(addIDs)+len(removeIDs))
+	}
+	for i, status := range res.AddResults {
+		if status != ua.StatusOK {
+			return errors.Wrapf(status, "sub %d: cannot link client handle %d to %d", s.SubscriptionID, triggeringHandle, add[i])
+		}
+	}
+	for _, status := range res.RemoveResults {
+		if status != ua.StatusOK && status != ua.StatusBadMonitoredItemIDInvalid {
+			return errors.Wrapf(status, "sub %d: cannot remove link of client handle %d", s.SubscriptionID, triggeringHandle)
+		}
+	}
+	return nil
+}
+
+// updateTriggers stores the links which the server has added and forgets
+// the links which it has removed.
+func (s *Subscription) updateTriggers(triggeringID uint32, add, remove []uint32, addResults, removeResults []ua.StatusCode) {
+	s.itemsMu.Lock()
+	defer s.itemsMu.Unlock()
+
+	handle := func(id uint32) (uint32, bool) {
+		item, ok := s.items[id]
+		if !ok || item.req.RequestedParameters == nil {
+			return 0, false
+		}
+		return item.req.RequestedParameters.ClientHandle, true
+	}
+
+	th, ok := handle(triggeringID)
+	if !ok {
+		return
+	}
+	for i, status := range removeResults {
+		if i >= len(remove) || (status != ua.StatusOK && status != ua.StatusBadMonitoredItemIDInvalid) {
+			continue
+		}
+		if h, ok := handle(remove[i]); ok {
+			delete(s.triggers[th], h)
+		}
+	}
+	for i, status := range addResults {
+		if i >= len(add) || status != ua.StatusOK {
+			continue
+		}
+		h, ok := handle(add[i])
+		if !ok {
+			continue
+		}
+		if s.triggers == nil {
+			s.triggers = make(map[uint32]map[uint32]bool)
+		}
+		if s.triggers[th] == nil {
+			s.triggers[th] = make(map[uint32]bool)
+		}
+		s.triggers[th][h] = true
+	}
+	if len(s.triggers[th]) == 0 {
+		delete(s.triggers, th)
+	}
+}
+
+// triggerLinks returns the stored links as monitored item ids of the
+// triggered items by the id of the triggering item. Links of items which
+// no longer exist are removed.
+func (s *Subscription) triggerLinks() map[uint32][]uint32 {
+	s.itemsMu.Lock()
+	defer s.itemsMu.Unlock()
+
+	ids := make(map[uint32]uint32, len(s.items))
+	for id, item := range s.items {
+		if p := item.req.RequestedParameters; p != nil {
+			ids[p.ClientHandle] = id
+		}
+	}
+
+	links := make(map[uint32][]uint32)
+	for th, triggered := range s.triggers {
+		tid, ok := ids[th]
+		if !ok {
+			delete(s.triggers, th)
+			continue
+		}
+		for h := range triggered {
+			id, ok := ids[h]
+			if !ok {
+				delete(triggered, h)
+				continue
+			}
+			links[tid] = append(links[tid], id)
+		}
+	}
+	return links
+}
+
+// SetMonitoringMode sets the monitoring mode of the monitored items with
+// the SetMonitoringMode service. Items in sampling mode are sampled by the
+// server but do not report notifications until they are set to reporting
+// mode again. Disabled items are neither sampled nor reported.
+//
+// The mode is stored with the items so that it is applied again when the
+// subscription is recreated after a reconnect. If the server rejects the
+// mode of an item the item keeps its mode and an error is returned after
+// the mode of all other items has been stored.
+//
+// See Part 4, 5.12.4
+func (s *Subscription) SetMonitoringMode(ctx context.Context, mode ua.MonitoringMode, monitoredItemIDs ...uint32) error {
+	if len(monitoredItemIDs) == 0 {
+		return nil
+	}
+
+	s.itemsMu.Lock()
+	for _, id := range monitoredItemIDs {
+		if _, exists := s.items[id]; !exists {
+			s.itemsMu.Unlock()
+			return errors.Errorf("sub %d: cannot set monitoring mode of unknown monitored item id: %d", s.SubscriptionID, id)
+		}
+	}
+	s.itemsMu.Unlock()
+
+	stats.Subscription().Add("SetMonitoringMode", 1)
+
+	req := &ua.SetMonitoringModeRequest{
+		SubscriptionID:   s.SubscriptionID,
+		MonitoringMode:   mode,
+		MonitoredItemIDs: monitoredItemIDs,
+	}
+	var res *ua.SetMonitoringModeResponse
+	err := s.c.SendWithContext(ctx, req, func(v interface{}) error {
+		return safeAssign(v, &res)
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Results) != len(monitoredItemIDs) {
+		return errors.Errorf("sub %d: got %d results for %d monitored items", s.SubscriptionID, len(res.Results), len(monitoredItemIDs))
+	}
+	return s.setItemsMode(mode, monitoredItemIDs, res.Results)
+}
+
+// SetItemsMonitoringMode sets the monitoring mode of the monitored items
+// with the client handles like SetMonitoringMode.
+func (s *Subscription) SetItemsMonitoringMode(ctx context.Context, mode ua.MonitoringMode, clientHandles ...uint32) error {
+	if len(clientHandles) == 0 {
+		return nil
+	}
+	ids, err := s.knownItemIDs(clientHandles)
+	if err != nil {
+		return err
+	}
+	return s.SetMonitoringMode(ctx, mode, ids...)
+}
+
+// setItemsMode stores the monitoring mode of the items which the server
+// has accepted and returns an error for the first item which it has not
+// accepted.
+func (s *Subscription) setItemsMode(mode ua.MonitoringMode, ids []uint32, results []ua.StatusCode) error {
+	s.itemsMu.Lock()
+	defer s.itemsMu.Unlock()
+
+	var err error
+	for i, status := range results {
+		item, ok := s.items[ids[i]]
+		if !ok {
+			continue
+		}
+		if status != ua.StatusOK {
+			if err == nil {
+				err = errors.Wrapf(status, "sub %d: cannot set monitoring mode of monitored item id %d", s.SubscriptionID, ids[i])
+			}
+			continue
+		}
+
+		// the create request belongs to the caller of Monitor.
+		req := *item.req
+		req.MonitoringMode = mode
+		item.req = &req
+	}
+	return err
 }
 
 func (s *Subscription) publishTimeout() time.Duration {
@@ -280,6 +967,48 @@ func (s *Subscription) publishTimeout() time.Duration {
 	return timeout
 }
 
+// decodeEvents maps the event fields to the select clauses of the
+// monitored items. Fields of events of unknown monitored items are
+// keyed by their index.
+func (s *Subscription) decodeEvents(list *ua.EventNotificationList) []*Event {
+	selects := map[uint32][]*ua.SimpleAttributeOperand{}
+	s.itemsMu.Lock()
+	for _, item := range s.items {
+		params := item.req.RequestedParameters
+		if params == nil || params.Filter == nil {
+			continue
+		}
+		switch f := params.Filter.Value.(type) {
+		case *ua.EventFilter:
+			selects[params.ClientHandle] = f.SelectClauses
+		case ua.EventFilter:
+			selects[params.ClientHandle] = f.SelectClauses
+		}
+	}
+	s.itemsMu.Unlock()
+
+	events := make([]*Event, 0, len(list.Events))
+	for _, ev := range list.Events {
+		if ev == nil {
+			continue
+		}
+		sel := selects[ev.ClientHandle]
+		e := &Event{
+			ClientHandle: ev.ClientHandle,
+			Fields:       make(map[string]*ua.Variant, len(ev.EventFields)),
+		}
+		for i, v := range ev.EventFields {
+			name := strconv.Itoa(i)
+			if i < len(sel) && sel[i] != nil {
+				name = sel[i].FieldName()
+			}
+			e.Fields[name] = v
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
 func (s *Subscription) notify(ctx context.Context, data *PublishNotificationData) {
 	select {
 	case <-ctx.Done():
@@ -347,10 +1076,10 @@ func (p *SubscriptionParameters) setDefaults() {
 // recreate_NeedsSubMuxLock creates a new subscription based on the previous subscription
 // parameters and monitored items.
 func (s *Subscription) recreate_NeedsSubMuxLock(ctx context.Context) error {
-	dlog := debug.NewPrefixLogger("sub %d: recreate: ", s.SubscriptionID)
+	log := logger.With(s.log(), "sub_id", s.SubscriptionID)
 
 	if s.SubscriptionID == terminatedSubscriptionID {
-		dlog.Printf("subscription is not in a valid state")
+		log.Warn("client: recreate: subscription is not in a valid state")
 		return nil
 	}
 
@@ -363,10 +1092,10 @@ func (s *Subscription) recreate_NeedsSubMuxLock(ctx context.Context) error {
 		_ = s.c.SendWithContext(ctx, req, func(v interface{}) error {
 			return safeAssign(v, &res)
 		})
-		dlog.Print("subscription deleted")
+		log.Debug("client: recreate: subscription deleted")
 	}
 	s.c.forgetSubscription_NeedsSubMuxLock(ctx, s.SubscriptionID)
-	dlog.Printf("subscription forgotton")
+	log.Debug("client: recreate: subscription forgotten")
 
 	req := &ua.CreateSubscriptionRequest{
 		RequestedPublishingInterval: float64(params.Interval / time.Millisecond),
@@ -381,27 +1110,26 @@ func (s *Subscription) recreate_NeedsSubMuxLock(ctx context.Context) error {
 		return safeAssign(v, &res)
 	})
 	if err != nil {
-		dlog.Printf("failed to recreate subscription")
+		log.Warn("client: recreate: failed to recreate subscription", "err", err)
 		return err
 	}
 	// todo (unknownet): check if necessary
 	if status := res.ResponseHeader.ServiceResult; status != ua.StatusOK {
 		return status
 	}
-	dlog.Printf("recreated as subscription %d", res.SubscriptionID)
-	dlog.SetPrefix(fmt.Sprintf("sub %d: recreate: ", res.SubscriptionID))
+	log.Info("client: recreate: subscription recreated", "new_sub_id", res.SubscriptionID)
+	log = logger.With(s.log(), "sub_id", res.SubscriptionID)
 
 	s.SubscriptionID = res.SubscriptionID
-	s.RevisedPublishingInterval = time.Duration(res.RevisedPublishingInterval) * time.Millisecond
-	s.RevisedLifetimeCount = res.RevisedLifetimeCount
-	s.RevisedMaxKeepAliveCount = res.RevisedMaxKeepAliveCount
+	s.revise(time.Duration(res.RevisedPublishingInterval)*time.Millisecond, res.RevisedLifetimeCount, res.RevisedMaxKeepAliveCount)
 	s.lastSeq = 0
 	s.nextSeq = 1
+	s.pending = nil
 
 	if err := s.c.registerSubscription_NeedsSubMuxLock(s); err != nil {
 		return err
 	}
-	dlog.Printf("subscription registered")
+	log.Debug("client: recreate: subscription registered")
 
 	// Sort by timestamp to return
 	itemsByTimestamps := make(map[ua.TimestampsToReturn][]*ua.MonitoredItemCreateRequest)
@@ -424,7 +1152,7 @@ func (s *Subscription) recreate_NeedsSubMuxLock(ctx context.Context) error {
 			return safeAssign(v, &res)
 		})
 		if err != nil {
-			dlog.Printf("failed to create monitored items: %v", err)
+			log.Warn("client: recreate: failed to create monitored items", "err", err)
 			return err
 		}
 
@@ -444,7 +1172,20 @@ func (s *Subscription) recreate_NeedsSubMuxLock(ctx context.Context) error {
 		}
 		s.itemsMu.Unlock()
 	}
-	dlog.Printf("subscription successfully recreated")
+
+	for tid, add := range s.triggerLinks() {
+		res, err := s.SetTriggeringWithContext(ctx, tid, add, nil)
+		if err != nil {
+			log.Warn("client: recreate: failed to restore triggering links", "err", err)
+			return err
+		}
+		for _, status := range res.AddResults {
+			if status != ua.StatusOK {
+				return status
+			}
+		}
+	}
+	log.Debug("client: recreate: subscription successfully recreated")
 
 	return nil
 }
@@ -1,8 +1,16 @@
 package opcua
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/id"
 	"github.com/zzylovesll/myOpcUa/ua"
 )
 
@@ -104,3 +112,467 @@ func BenchmarkUnmonitorItems(b *testing.B) {
 		b.Log("src", len(src)) // ensure src and dst are not GC'ed
 	})
 }
+
+func TestTransferableSubscriptionIDs(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.subs[1] = &Subscription{SubscriptionID: 1, params: &SubscriptionParameters{}}
+	c.subs[2] = &Subscription{SubscriptionID: 2, params: &SubscriptionParameters{DisableTransfer: true}}
+
+	transfer, recreate := c.transferableSubscriptionIDs()
+	if len(transfer) != 1 || transfer[0] != 1 {
+		t.Fatalf("got transfer %v want [1]", transfer)
+	}
+	if len(recreate) != 1 || recreate[0] != 2 {
+		t.Fatalf("got recreate %v want [2]", recreate)
+	}
+}
+
+func TestMissingSequenceNumbers(t *testing.T) {
+	tests := []struct {
+		next, seq uint32
+		want      []uint32
+	}{
+		{1, 1, nil},
+		{1, 2, []uint32{1}},
+		{5, 8, []uint32{5, 6, 7}},
+		{8, 5, nil},
+		{1, 1 + maxRepublishGap, func() []uint32 {
+			var s []uint32
+			for i := uint32(1); i <= maxRepublishGap; i++ {
+				s = append(s, i)
+			}
+			return s
+		}()},
+		{1, 2 + maxRepublishGap, nil},
+	}
+	for _, tt := range tests {
+		verify.Values(t, fmt.Sprintf("%d-%d", tt.next, tt.seq), missingSequenceNumbers(tt.next, tt.seq), tt.want)
+	}
+}
+
+func TestHandleNotificationGap(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	sub := &Subscription{SubscriptionID: 1, nextSeq: 3, lastSeq: 2}
+	c.subs[1] = sub
+	data := []*ua.ExtensionObject{ua.NewExtensionObject(&ua.DataChangeNotification{})}
+	notif := func(seq uint32, data []*ua.ExtensionObject) *ua.PublishResponse {
+		return &ua.PublishResponse{SubscriptionID: 1, NotificationMessage: &ua.NotificationMessage{SequenceNumber: seq, NotificationData: data}}
+	}
+	// handle handles the response of the publish request with the ticket
+	// and returns the sequence numbers of the delivered messages and
+	// whether they need to be republished.
+	handle := func(ticket uint64, res *ua.PublishResponse) []string {
+		c.handleNotification_NeedsSubMuxLock(sub, res)
+		c.finishPublish_NeedsSubMuxLock(ticket, 0, true)
+		var got []string
+		for _, b := range c.sequenceNotifications_NeedsSubMuxLock() {
+			for _, n := range b.notifs {
+				if n.msg == nil {
+					got = append(got, fmt.Sprintf("republish %d", n.seq))
+				} else {
+					got = append(got, fmt.Sprintf("notif %d", n.seq))
+				}
+			}
+		}
+		return got
+	}
+
+	// two outstanding requests whose responses arrive out of order
+	c.startPublish_NeedsSubMuxLock()
+	c.startPublish_NeedsSubMuxLock()
+	verify.Values(t, "wait for notif 3", handle(2, notif(5, data)), []string(nil))
+	verify.Values(t, "acks", c.pendingAcks, []*ua.SubscriptionAcknowledgement{{SubscriptionID: 1, SequenceNumber: 5}})
+	verify.Values(t, "next", sub.nextSeq, uint32(3))
+	verify.Values(t, "notif 3 and lost notif 4", handle(1, notif(3, data)), []string{"notif 3", "republish 4", "notif 5"})
+	verify.Values(t, "next", sub.nextSeq, uint32(6))
+
+	// a keep-alive contains the next sequence number
+	c.startPublish_NeedsSubMuxLock()
+	verify.Values(t, "keep-alive", handle(3, notif(7, nil)), []string{"republish 6"})
+	verify.Values(t, "keep-alive next", sub.nextSeq, uint32(7))
+
+	// late messages are not delivered again
+	c.startPublish_NeedsSubMuxLock()
+	verify.Values(t, "duplicate", handle(4, notif(5, data)), []string(nil))
+	verify.Values(t, "pending", len(sub.pending), 0)
+
+	verify.Values(t, "stats", sub.NotificationStats(), SubscriptionStats{Notifications: 3, KeepAlives: 1, Late: 1})
+}
+
+func TestHandleAcks(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	c.pendingAcks = []*ua.SubscriptionAcknowledgement{{SubscriptionID: 1, SequenceNumber: 9}}
+	acks := []*ua.SubscriptionAcknowledgement{
+		{SubscriptionID: 1, SequenceNumber: 1},
+		{SubscriptionID: 1, SequenceNumber: 2},
+		{SubscriptionID: 2, SequenceNumber: 3},
+	}
+	res := []ua.StatusCode{ua.StatusOK, ua.StatusBadSequenceNumberUnknown, ua.StatusBadTooManyOperations}
+	c.handleAcks_NeedsSubMuxLock(acks, res)
+	verify.Values(t, "", c.pendingAcks, []*ua.SubscriptionAcknowledgement{
+		{SubscriptionID: 1, SequenceNumber: 9},
+		{SubscriptionID: 2, SequenceNumber: 3},
+	})
+}
+
+func TestPublishStats(t *testing.T) {
+	c := NewClient("opc.tcp://example.com:4840")
+	t1, _ := c.startPublish_NeedsSubMuxLock()
+	t2, _ := c.startPublish_NeedsSubMuxLock()
+	t3, _ := c.startPublish_NeedsSubMuxLock()
+	c.finishPublish_NeedsSubMuxLock(t2, 30*time.Millisecond, true)
+	c.finishPublish_NeedsSubMuxLock(t1, 10*time.Millisecond, true)
+	c.finishPublish_NeedsSubMuxLock(t3, time.Second, false)
+	verify.Values(t, "", c.PublishStats(), PublishStats{
+		Responses:   2,
+		LastLatency: 10 * time.Millisecond,
+		MeanLatency: 20 * time.Millisecond,
+		MaxLatency:  30 * time.Millisecond,
+	})
+}
+
+func TestUnmonitorItemsResults(t *testing.T) {
+	item := func(h uint32) *monitoredItem {
+		return &monitoredItem{req: NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(0, id.Server_ServerStatus_State), ua.AttributeIDValue, h)}
+	}
+	s := &Subscription{
+		SubscriptionID: 1,
+		items:          map[uint32]*monitoredItem{10: item(1), 11: item(2), 12: item(3)},
+	}
+
+	ids, handles := s.monitoredItemIDs([]uint32{3, 1, 2, 4})
+	verify.Values(t, "ids", ids, []uint32{12, 10, 11})
+	verify.Values(t, "handles", handles, []uint32{3, 1, 2})
+
+	err := s.removeItems(ids, handles, []ua.StatusCode{ua.StatusOK, ua.StatusBadMonitoredItemIDInvalid, ua.StatusBadTooManyOperations})
+	if err == nil {
+		t.Fatal("got nil want error for rejected item")
+	}
+	if _, ok := s.items[11]; !ok || len(s.items) != 1 {
+		t.Fatalf("got items %v want only 11", s.items)
+	}
+
+	// removed items are ignored
+	ids, _ = s.monitoredItemIDs([]uint32{1, 3})
+	if len(ids) != 0 {
+		t.Fatalf("got ids %v want none", ids)
+	}
+	if err := s.UnmonitorItems(context.Background(), 1, 3); err != nil {
+		t.Fatalf("got error %v want nil for removed items", err)
+	}
+}
+
+func TestSetMonitoringModeResults(t *testing.T) {
+	req := func(h uint32) *ua.MonitoredItemCreateRequest {
+		return NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(0, id.Server_ServerStatus_State), ua.AttributeIDValue, h)
+	}
+	r1, r2 := req(1), req(2)
+	s := &Subscription{
+		SubscriptionID: 1,
+		items:          map[uint32]*monitoredItem{10: {req: r1}, 11: {req: r2}},
+	}
+
+	err := s.setItemsMode(ua.MonitoringModeSampling, []uint32{10, 11}, []ua.StatusCode{ua.StatusOK, ua.StatusBadMonitoredItemIDInvalid})
+	if err == nil {
+		t.Fatal("got nil want error for rejected item")
+	}
+	verify.Values(t, "mode 10", s.items[10].req.MonitoringMode, ua.MonitoringModeSampling)
+	verify.Values(t, "mode 11", s.items[11].req.MonitoringMode, ua.MonitoringModeReporting)
+
+	// the requests of the caller are not modified
+	verify.Values(t, "request mode", r1.MonitoringMode, ua.MonitoringModeReporting)
+
+	if err := s.SetMonitoringMode(context.Background(), ua.MonitoringModeDisabled, 12); err == nil {
+		t.Fatal("got nil want error for unknown item")
+	}
+}
+
+func TestModifyItemsResults(t *testing.T) {
+	req := func(h uint32) *ua.MonitoredItemCreateRequest {
+		return NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(0, id.Server_ServerStatus_State), ua.AttributeIDValue, h)
+	}
+	r1, r2 := req(1), req(2)
+	s := &Subscription{
+		SubscriptionID: 1,
+		items: map[uint32]*monitoredItem{
+			10: {req: r1, res: &ua.MonitoredItemCreateResult{MonitoredItemID: 10}},
+			11: {req: r2, res: &ua.MonitoredItemCreateResult{MonitoredItemID: 11}},
+		},
+	}
+
+	params := func(h uint32) *ua.MonitoringParameters {
+		return &ua.MonitoringParameters{ClientHandle: h, SamplingInterval: 500, QueueSize: 5, DiscardOldest: true}
+	}
+	items := []*ua.MonitoredItemModifyRequest{
+		{MonitoredItemID: 10, RequestedParameters: params(1)},
+		{MonitoredItemID: 11, RequestedParameters: params(2)},
+	}
+	s.updateItems(ua.TimestampsToReturnSource, items, []*ua.MonitoredItemModifyResult{
+		{StatusCode: ua.StatusOK, RevisedSamplingInterval: 1000, RevisedQueueSize: 5},
+		{StatusCode: ua.StatusBadMonitoredItemIDInvalid},
+	})
+	verify.Values(t, "params 10", s.items[10].req.RequestedParameters, params(1))
+	verify.Values(t, "timestamps 10", s.items[10].ts, ua.TimestampsToReturnSource)
+	verify.Values(t, "revised interval 10", s.items[10].res.RevisedSamplingInterval, 1000.0)
+	verify.Values(t, "params 11", s.items[11].req.RequestedParameters, r2.RequestedParameters)
+
+	// the requests of the caller are not modified
+	verify.Values(t, "request interval", r1.RequestedParameters.SamplingInterval, 0.0)
+
+	ids, err := s.knownItemIDs([]uint32{2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "ids", ids, []uint32{11, 10})
+
+	ctx := context.Background()
+	if _, err := s.ModifyItems(ctx, ua.TimestampsToReturnBoth, &ua.MonitoredItemModifyRequest{RequestedParameters: params(3)}); err == nil {
+		t.Fatal("got nil want error for unknown client handle")
+	}
+	if _, err := s.ModifyItems(ctx, ua.TimestampsToReturnBoth, &ua.MonitoredItemModifyRequest{}); err == nil {
+		t.Fatal("got nil want error for missing parameters")
+	}
+	if err := s.SetItemsMonitoringMode(ctx, ua.MonitoringModeDisabled, 1, 3); err == nil {
+		t.Fatal("got nil want error for unknown client handle")
+	}
+}
+
+func TestTriggerLinks(t *testing.T) {
+	item := func(h uint32) *monitoredItem {
+		return &monitoredItem{req: NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, h), ua.AttributeIDValue, h, MonitoredItemMode(ua.MonitoringModeSampling))}
+	}
+	s := &Subscription{
+		SubscriptionID: 1,
+		items:          map[uint32]*monitoredItem{10: item(1), 11: item(2), 12: item(3)},
+	}
+	verify.Values(t, "mode", s.items[11].req.MonitoringMode, ua.MonitoringModeSampling)
+
+	s.updateTriggers(10, []uint32{11, 12}, nil, []ua.StatusCode{ua.StatusOK, ua.StatusBadMonitoredItemIDInvalid}, nil)
+	verify.Values(t, "triggers", s.triggers, map[uint32]map[uint32]bool{1: {2: true}})
+	verify.Values(t, "links", s.triggerLinks(), map[uint32][]uint32{10: {11}})
+
+	// the items get new ids when the subscription is recreated
+	s.items = map[uint32]*monitoredItem{20: item(1), 21: item(2), 22: item(3)}
+	verify.Values(t, "recreated links", s.triggerLinks(), map[uint32][]uint32{20: {21}})
+
+	s.updateTriggers(20, []uint32{22}, []uint32{21}, []ua.StatusCode{ua.StatusOK}, []ua.StatusCode{ua.StatusOK})
+	verify.Values(t, "modified triggers", s.triggers, map[uint32]map[uint32]bool{1: {3: true}})
+
+	// the server removes the links of deleted items
+	s.itemsMu.Lock()
+	s.deleteItem_NeedsItemsMuLock(22)
+	s.itemsMu.Unlock()
+	verify.Values(t, "links after delete", s.triggerLinks(), map[uint32][]uint32{})
+
+	if err := s.SetItemsTriggering(context.Background(), 1, []uint32{4}, nil); err == nil {
+		t.Fatal("got nil want error for unknown client handle")
+	}
+}
+
+func TestSubscriptionRevise(t *testing.T) {
+	s := &Subscription{
+		RevisedPublishingInterval: time.Second,
+		RevisedLifetimeCount:      30,
+		RevisedMaxKeepAliveCount:  10,
+	}
+	if s.revise(time.Second, 30, 10) {
+		t.Fatal("got changed for the same values")
+	}
+	if !s.revise(2*time.Second, 30, 10) {
+		t.Fatal("got unchanged for a new interval")
+	}
+	if got, want := s.RevisedPublishingInterval, 2*time.Second; got != want {
+		t.Fatalf("got interval %s want %s", got, want)
+	}
+	if !s.revise(2*time.Second, 60, 20) {
+		t.Fatal("got unchanged for new counts")
+	}
+	if got, want := s.RevisedLifetimeCount, uint32(60); got != want {
+		t.Fatalf("got lifetime count %d want %d", got, want)
+	}
+	if got, want := s.RevisedMaxKeepAliveCount, uint32(20); got != want {
+		t.Fatalf("got max keep-alive count %d want %d", got, want)
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	filter := &ua.EventFilter{
+		SelectClauses: []*ua.SimpleAttributeOperand{
+			ua.NewSelectOperand("Severity"),
+			ua.NewSelectOperand("Message"),
+		},
+	}
+	req := NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(0, 2253), ua.AttributeIDEventNotifier, 7)
+	req.RequestedParameters.Filter = ua.NewExtensionObject(filter)
+
+	s := &Subscription{items: map[uint32]*monitoredItem{1: {req: req}}}
+	events := s.decodeEvents(&ua.EventNotificationList{
+		Events: []*ua.EventFieldList{
+			{ClientHandle: 7, EventFields: []*ua.Variant{ua.MustVariant(uint16(500)), ua.MustVariant(ua.NewLocalizedText("alarm"))}},
+			{ClientHandle: 8, EventFields: []*ua.Variant{ua.MustVariant(uint16(100))}},
+		},
+	})
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events want 2", len(events))
+	}
+	if got, want := events[0].Value("Severity"), uint16(500); got != want {
+		t.Fatalf("got Severity %v want %v", got, want)
+	}
+	if got, want := events[0].Value("Message").(*ua.LocalizedText).Text, "alarm"; got != want {
+		t.Fatalf("got Message %v want %v", got, want)
+	}
+	if got, want := events[1].Value("0"), uint16(100); got != want {
+		t.Fatalf("got field 0 %v want %v", got, want)
+	}
+}
+
+func TestMonitoredItemAggregate(t *testing.T) {
+	avg := ua.NewNumericNodeID(0, id.AggregateFunction_Average)
+	req := NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, 1), ua.AttributeIDValue, 1, MonitoredItemAggregate(avg, 5*time.Second))
+
+	f, ok := req.RequestedParameters.Filter.Value.(*ua.AggregateFilter)
+	if !ok {
+		t.Fatalf("got filter %T want *ua.AggregateFilter", req.RequestedParameters.Filter.Value)
+	}
+	if f.AggregateType != avg {
+		t.Fatalf("got aggregate type %v want %v", f.AggregateType, avg)
+	}
+	if f.ProcessingInterval != 5000 {
+		t.Fatalf("got processing interval %v want 5000", f.ProcessingInterval)
+	}
+}
+
+func TestMonitoredItemDeadband(t *testing.T) {
+	req := NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, 1), ua.AttributeIDValue, 1, MonitoredItemDeadband(ua.DeadbandTypeAbsolute, 0.5, ua.DataChangeTriggerStatusValue))
+
+	f, ok := req.RequestedParameters.Filter.Value.(*ua.DataChangeFilter)
+	if !ok {
+		t.Fatalf("got filter %T want *ua.DataChangeFilter", req.RequestedParameters.Filter.Value)
+	}
+	verify.Values(t, "", f, &ua.DataChangeFilter{
+		Trigger:       ua.DataChangeTriggerStatusValue,
+		DeadbandType:  uint32(ua.DeadbandTypeAbsolute),
+		DeadbandValue: 0.5,
+	})
+	if isPercentDeadband(req) {
+		t.Fatal("absolute deadband is a percent deadband")
+	}
+
+	req = NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, 1), ua.AttributeIDValue, 1, MonitoredItemDeadband(ua.DeadbandTypePercent, 5, ua.DataChangeTriggerStatusValue))
+	if !isPercentDeadband(req) {
+		t.Fatal("percent deadband is not a percent deadband")
+	}
+}
+
+func TestMergeMonitorResults(t *testing.T) {
+	a := &ua.MonitoredItemCreateResult{MonitoredItemID: 1}
+	b := &ua.MonitoredItemCreateResult{MonitoredItemID: 2}
+	rejected := &ua.MonitoredItemCreateResult{StatusCode: ua.StatusBadFilterNotAllowed}
+
+	verify.Values(t, "none rejected", mergeMonitorResults(2, nil, []*ua.MonitoredItemCreateResult{a, b}), []*ua.MonitoredItemCreateResult{a, b})
+	verify.Values(t, "rejected", mergeMonitorResults(3, map[int]bool{1: true}, []*ua.MonitoredItemCreateResult{a, b}), []*ua.MonitoredItemCreateResult{a, rejected, b})
+	verify.Values(t, "all rejected", mergeMonitorResults(2, map[int]bool{0: true, 1: true}, nil), []*ua.MonitoredItemCreateResult{rejected, rejected})
+}
+
+// TestSubscriptionTransferRecreate checks that a subscription is recreated
+// with its monitored items if the server rejects the transfer.
+func TestSubscriptionTransferRecreate(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		nextSubID uint32
+		transfers []uint32
+		created   []*ua.CreateMonitoredItemsRequest
+	)
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		mu.Lock()
+		defer mu.Unlock()
+
+		h := fakeResponseHeader(req.Header())
+		switch req := req.(type) {
+		case *ua.CreateSubscriptionRequest:
+			nextSubID++
+			return &ua.CreateSubscriptionResponse{
+				ResponseHeader:            h,
+				SubscriptionID:            nextSubID,
+				RevisedPublishingInterval: req.RequestedPublishingInterval,
+				RevisedLifetimeCount:      req.RequestedLifetimeCount,
+				RevisedMaxKeepAliveCount:  req.RequestedMaxKeepAliveCount,
+			}
+		case *ua.CreateMonitoredItemsRequest:
+			created = append(created, req)
+			res := &ua.CreateMonitoredItemsResponse{ResponseHeader: h}
+			for i := range req.ItemsToCreate {
+				res.Results = append(res.Results, &ua.MonitoredItemCreateResult{
+					StatusCode:      ua.StatusOK,
+					MonitoredItemID: req.SubscriptionID*100 + uint32(i),
+					FilterResult:    ua.NewExtensionObject(nil),
+				})
+			}
+			return res
+		case *ua.TransferSubscriptionsRequest:
+			transfers = append(transfers, req.SubscriptionIDs...)
+			res := &ua.TransferSubscriptionsResponse{ResponseHeader: h}
+			for range req.SubscriptionIDs {
+				res.Results = append(res.Results, &ua.TransferResult{StatusCode: ua.StatusBadSubscriptionIDInvalid})
+			}
+			return res
+		case *ua.DeleteSubscriptionsRequest:
+			res := &ua.DeleteSubscriptionsResponse{ResponseHeader: h}
+			for range req.SubscriptionIDs {
+				res.Results = append(res.Results, ua.StatusOK)
+			}
+			return res
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := NewClient(endpoint, AutoReconnect(false))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	sub, err := c.SubscribeWithContext(ctx, &SubscriptionParameters{Interval: 100 * time.Millisecond}, make(chan *PublishNotificationData, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sub.MonitorWithContext(ctx, ua.TimestampsToReturnBoth,
+		NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, 1), ua.AttributeIDValue, 42),
+		NewMonitoredItemCreateRequestWithDefaults(ua.NewNumericNodeID(1, 2), ua.AttributeIDValue, 43),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sub.Transfer(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	verify.Values(t, "transfers", transfers, []uint32{1})
+	verify.Values(t, "subscription id", sub.SubscriptionID, uint32(2))
+	if len(created) != 2 {
+		t.Fatalf("got %d create monitored items requests want 2", len(created))
+	}
+	verify.Values(t, "recreated subscription id", created[1].SubscriptionID, uint32(2))
+
+	var handles []uint32
+	for _, item := range created[1].ItemsToCreate {
+		handles = append(handles, item.RequestedParameters.ClientHandle)
+	}
+	sort.Slice(handles, func(i, j int) bool { return handles[i] < handles[j] })
+	verify.Values(t, "client handles", handles, []uint32{42, 43})
+
+	sub.itemsMu.Lock()
+	handles = handles[:0]
+	for _, item := range sub.items {
+		handles = append(handles, item.req.RequestedParameters.ClientHandle)
+	}
+	sub.itemsMu.Unlock()
+	sort.Slice(handles, func(i, j int) bool { return handles[i] < handles[j] })
+	verify.Values(t, "monitored items", handles, []uint32{42, 43})
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+// ParseBrowsePath parses a path of browse names separated by '/', e.g.
+// "Objects/2:DeviceSet/3:PLC1". A name can have a namespace prefix
+// separated by ':'. Names without prefix are in namespace 0. A leading
+// '/' is ignored so that "/Objects/2:DeviceSet" is the same path.
+//
+// The characters '/', ':' and '&' in names must be escaped with '&',
+// e.g. "2:a&/b" is the name "a/b" in namespace 2.
+//
+// Specification: Part 4, A.2
+func ParseBrowsePath(path string) ([]*QualifiedName, error) {
+	if path == "" || path == "/" {
+		return nil, errors.Errorf("empty browse path")
+	}
+
+	var names []*QualifiedName
+	var name strings.Builder
+	var ns string
+	hasNS := false
+
+	add := func() error {
+		if name.Len() == 0 {
+			return errors.Errorf("empty browse name in %q", path)
+		}
+		qn := &QualifiedName{Name: name.String()}
+		if hasNS {
+			n, err := strconv.ParseUint(ns, 10, 16)
+			if err != nil {
+				return errors.Errorf("invalid namespace %q in %q", ns, path)
+			}
+			qn.NamespaceIndex = uint16(n)
+		}
+		names = append(names, qn)
+		name.Reset()
+		ns, hasNS = "", false
+		return nil
+	}
+
+	start := 0
+	if path[0] == '/' {
+		start = 1
+	}
+	for i := start; i < len(path); i++ {
+		switch ch := path[i]; ch {
+		case '&':
+			i++
+			if i == len(path) {
+				return nil, errors.Errorf("invalid escape sequence at end of %q", path)
+			}
+			name.WriteByte(path[i])
+		case ':':
+			if hasNS || name.Len() == 0 {
+				return nil, errors.Errorf("unexpected ':' at position %d in %q", i, path)
+			}
+			ns, hasNS = name.String(), true
+			name.Reset()
+		case '/':
+			if err := add(); err != nil {
+				return nil, err
+			}
+		default:
+			name.WriteByte(ch)
+		}
+	}
+	if err := add(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// NewBrowsePath returns a browse path which follows the hierarchical
+// references with the given browse names from the starting node.
+func NewBrowsePath(start *NodeID, names []*QualifiedName) *BrowsePath {
+	elems := make([]*RelativePathElement, len(names))
+	for i, name := range names {
+		elems[i] = &RelativePathElement{
+			ReferenceTypeID: NewTwoByteNodeID(id.HierarchicalReferences),
+			IsInverse:       false,
+			IncludeSubtypes: true,
+			TargetName:      name,
+		}
+	}
+	return &BrowsePath{
+		StartingNode: start,
+		RelativePath: &RelativePath{Elements: elems},
+	}
+}
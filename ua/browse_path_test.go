@@ -0,0 +1,60 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+func TestParseBrowsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []*QualifiedName
+		err  error
+	}{
+		{
+			path: "Objects/2:DeviceSet/3:PLC1",
+			want: []*QualifiedName{
+				{NamespaceIndex: 0, Name: "Objects"},
+				{NamespaceIndex: 2, Name: "DeviceSet"},
+				{NamespaceIndex: 3, Name: "PLC1"},
+			},
+		},
+		{
+			path: "2:a&/b/3:c&:d&&e",
+			want: []*QualifiedName{
+				{NamespaceIndex: 2, Name: "a/b"},
+				{NamespaceIndex: 3, Name: "c:d&e"},
+			},
+		},
+		{
+			path: "/Objects/2:Temperature",
+			want: []*QualifiedName{
+				{NamespaceIndex: 0, Name: "Objects"},
+				{NamespaceIndex: 2, Name: "Temperature"},
+			},
+		},
+		{path: "", err: errors.New("empty browse path")},
+		{path: "/", err: errors.New("empty browse path")},
+		{path: "a//b", err: errors.New(`empty browse name in "a//b"`)},
+		{path: "x:a", err: errors.New(`invalid namespace "x" in "x:a"`)},
+		{path: "1:2:a", err: errors.New(`unexpected ':' at position 3 in "1:2:a"`)},
+		{path: "a&", err: errors.New(`invalid escape sequence at end of "a&"`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseBrowsePath(tt.path)
+			if !errors.Equal(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+			verify.Values(t, "", got, tt.want)
+		})
+	}
+}
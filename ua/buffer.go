@@ -8,6 +8,7 @@ import (
 	"encoding/binary"
 	"io"
 	"math"
+	"reflect"
 	"time"
 
 	"github.com/zzylovesll/myOpcUa/errors"
@@ -23,12 +24,58 @@ type Buffer struct {
 	buf []byte
 	pos int
 	err error
+
+	// limits are the limits for decoding. nil selects
+	// DefaultDecodeLimits.
+	limits *DecodeLimits
+
+	// depth is the nesting depth of the decoded value.
+	depth int
+
+	// namespaces is the namespace array of the server which resolves
+	// the types of extension objects registered with
+	// RegisterExtensionObjectURI.
+	namespaces []string
 }
 
 func NewBuffer(b []byte) *Buffer {
 	return &Buffer{buf: b}
 }
 
+// child returns a buffer for decoding the embedded value d, e.g. the
+// body of an extension object, with the limits, the nesting depth and
+// the namespace array of b.
+func (b *Buffer) child(d []byte) *Buffer {
+	return &Buffer{buf: d, limits: b.limits, depth: b.depth, namespaces: b.namespaces}
+}
+
+func (b *Buffer) decodeLimits() *DecodeLimits {
+	if b.limits == nil {
+		return &DefaultDecodeLimits
+	}
+	return b.limits
+}
+
+// enter increases the nesting depth before a nested value is decoded.
+// It returns false and sets the error if the value is nested too deeply.
+// Every successful call must be followed by a call to leave.
+func (b *Buffer) enter() bool {
+	if b.err != nil {
+		return false
+	}
+	if max := b.decodeLimits().MaxNestingDepth; exceeds(int64(b.depth+1), max) {
+		b.err = limitError("nesting depth", int64(b.depth+1), max)
+		return false
+	}
+	b.depth++
+	return true
+}
+
+// leave decreases the nesting depth after a nested value was decoded.
+func (b *Buffer) leave() {
+	b.depth--
+}
+
 func (b *Buffer) Error() error {
 	return b.err
 }
@@ -141,10 +188,19 @@ func (b *Buffer) ReadFloat64() float64 {
 }
 
 func (b *Buffer) ReadString() string {
-	return string(b.ReadBytes())
+	return string(b.readBytes("string length", b.decodeLimits().MaxStringLength))
 }
 
+// ReadBytes reads a ByteString. The returned slice is a copy so that
+// the decoded values do not reference the buffer, which can be reused
+// for the next message.
 func (b *Buffer) ReadBytes() []byte {
+	return copyBytes(b.readBytes("byte string length", b.decodeLimits().MaxByteStringLength))
+}
+
+// readBytes reads a ByteString with at most max bytes without copying
+// it. what describes the length for the error.
+func (b *Buffer) readBytes(what string, max int) []byte {
 	n := b.ReadUint32()
 	if b.err != nil {
 		return nil
@@ -152,6 +208,10 @@ func (b *Buffer) ReadBytes() []byte {
 	if n == 0 || n == null {
 		return nil
 	}
+	if exceeds(int64(n), max) {
+		b.err = limitError(what, int64(n), max)
+		return nil
+	}
 	d := b.ReadN(int(n))
 	if b.err != nil {
 		return nil
@@ -159,23 +219,28 @@ func (b *Buffer) ReadBytes() []byte {
 	return d
 }
 
+// ReadStruct decodes the value r which is nested in the value that is
+// decoded from b. Values which implement BinaryDecoder outside of this
+// package are decoded with the default limits.
 func (b *Buffer) ReadStruct(r interface{}) {
-	if b.err != nil {
+	if !b.enter() {
 		return
 	}
-	var n int
-	var err error
+	defer b.leave()
+
 	switch x := r.(type) {
+	case bufferDecoder:
+		x.decodeBuffer(b)
 	case BinaryDecoder:
-		n, err = x.Decode(b.buf[b.pos:])
+		n, err := x.Decode(b.buf[b.pos:])
+		if err != nil {
+			b.err = err
+			return
+		}
+		b.pos += n
 	default:
-		n, err = Decode(b.buf[b.pos:], r)
+		decode(b, reflect.ValueOf(r), "")
 	}
-	if err != nil {
-		b.err = err
-		return
-	}
-	b.pos += n
 }
 
 func (b *Buffer) ReadTime() time.Time {
@@ -183,12 +248,68 @@ func (b *Buffer) ReadTime() time.Time {
 	if b.err != nil {
 		return time.Time{}
 	}
-	ts := binary.LittleEndian.Uint64(d)
-	if ts == 0 {
+	return dateTimeToTime(int64(binary.LittleEndian.Uint64(d)))
+}
+
+// MinDateTime and MaxDateTime are the earliest and the latest time which
+// can be encoded as a DateTime. Earlier times and the zero time are
+// encoded as the null DateTime which is decoded as the zero time. Later
+// times are encoded as the maximum DateTime which is decoded as
+// MaxDateTime.
+var (
+	MinDateTime = time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC)
+	MaxDateTime = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+)
+
+const (
+	// ticksPerSecond is the number of 100 nanosecond intervals of a
+	// DateTime per second.
+	ticksPerSecond = 10000000
+
+	// epochOffset is the number of seconds between January 1, 1601 and
+	// January 1, 1970.
+	epochOffset = 11644473600
+)
+
+// maxDateTimeTicks is the DateTime of MaxDateTime. Larger values are
+// decoded as MaxDateTime.
+var maxDateTimeTicks = (MaxDateTime.Unix() + epochOffset) * ticksPerSecond
+
+// timeToDateTime returns the number of 100 nanosecond intervals since
+// January 1, 1601 of t. It returns 0 for the zero time and times up to
+// MinDateTime and math.MaxInt64 for times from MaxDateTime.
+func timeToDateTime(t time.Time) int64 {
+	switch {
+	case t.IsZero() || !t.After(MinDateTime):
+		return 0
+	case !t.Before(MaxDateTime):
+		return math.MaxInt64
+	}
+	t = t.UTC()
+	return (t.Unix()+epochOffset)*ticksPerSecond + int64(t.Nanosecond()/100)
+}
+
+// dateTimeToTime returns the time of the number of 100 nanosecond
+// intervals since January 1, 1601. It returns the zero time for values
+// up to 0 and MaxDateTime for values from the DateTime of MaxDateTime.
+func dateTimeToTime(ts int64) time.Time {
+	switch {
+	case ts <= 0:
 		return time.Time{}
+	case ts >= maxDateTimeTicks:
+		return MaxDateTime
+	}
+	return time.Unix(ts/ticksPerSecond-epochOffset, ts%ticksPerSecond*100).UTC()
+}
+
+// copyBytes returns a copy of d which is nil if d is nil.
+func copyBytes(d []byte) []byte {
+	if d == nil {
+		return nil
 	}
-	// decode time in "100 nanosecond intervals since January 1, 1601" manner.
-	return time.Unix(0, int64((ts-116444736000000000)*100)).UTC()
+	c := make([]byte, len(d))
+	copy(c, d)
+	return c
 }
 
 func (b *Buffer) ReadN(n int) []byte {
@@ -310,11 +431,7 @@ func (b *Buffer) WriteStruct(w interface{}) {
 
 func (b *Buffer) WriteTime(v time.Time) {
 	d := make([]byte, 8)
-	if !v.IsZero() {
-		// encode time in "100 nanosecond intervals since January 1, 1601"
-		ts := uint64(v.UTC().UnixNano()/100 + 116444736000000000)
-		binary.LittleEndian.PutUint64(d, ts)
-	}
+	binary.LittleEndian.PutUint64(d, uint64(timeToDateTime(v)))
 	b.Write(d)
 }
 
@@ -0,0 +1,181 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// DecodeOption configures DecodeAll and a Decoder.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	limits        *DecodeLimits
+	namespaces    []string
+	trailingBytes bool
+}
+
+// WithDecodeLimits sets the decode limits. The zero fields of the limits
+// use DefaultDecodeLimits.
+func WithDecodeLimits(l *DecodeLimits) DecodeOption {
+	return func(o *decodeOptions) {
+		o.limits = l
+	}
+}
+
+// WithNamespaces sets the namespace array of the server which sent the
+// values. It is required to decode the extension objects which have been
+// registered with RegisterExtensionObjectURI.
+func WithNamespaces(namespaces []string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.namespaces = namespaces
+	}
+}
+
+// WithTrailingBytes makes DecodeAll ignore the bytes after the value
+// instead of returning an error.
+func WithTrailingBytes() DecodeOption {
+	return func(o *decodeOptions) {
+		o.trailingBytes = true
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) *decodeOptions {
+	o := &decodeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DecodeAll decodes v from b with the same rules as the messages of the
+// services, including the opcua struct tags. v must be a pointer. It
+// returns an error if b contains bytes after the value unless the
+// WithTrailingBytes option is set.
+func DecodeAll(b []byte, v interface{}, opts ...DecodeOption) error {
+	if err := checkDecodeTarget(v); err != nil {
+		return err
+	}
+	o := newDecodeOptions(opts)
+	n, err := decodeWith(b, v, o.limits, o.namespaces)
+	if err != nil {
+		return err
+	}
+	if n < len(b) && !o.trailingBytes {
+		return errors.Errorf("%d trailing bytes after %T", len(b)-n, v)
+	}
+	return nil
+}
+
+// checkDecodeTarget returns an error if v is not a non-nil pointer.
+func checkDecodeTarget(v interface{}) error {
+	if val := reflect.ValueOf(v); val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.Errorf("cannot decode into %T", v)
+	}
+	return nil
+}
+
+// An Encoder writes the binary encoding of values to a stream.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the binary encoding of v with the same rules as Encode.
+func (e *Encoder) Encode(v interface{}) error {
+	b, err := Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+// A Decoder reads values which have been written by an Encoder from a
+// stream. Since the size of a value is only known after it has been
+// decoded, the decoder reads more bytes from the stream whenever the
+// bytes read so far end in the middle of the value.
+type Decoder struct {
+	r   io.Reader
+	o   *decodeOptions
+	b   []byte
+	err error
+}
+
+// decoderReadSize is the minimum number of bytes the decoder reads from
+// the stream at once.
+const decoderReadSize = 4096
+
+// NewDecoder returns a decoder which reads from r. The WithTrailingBytes
+// option has no effect since the values are read one after the other.
+func NewDecoder(r io.Reader, opts ...DecodeOption) *Decoder {
+	return &Decoder{r: r, o: newDecodeOptions(opts)}
+}
+
+// Decode decodes the next value from the stream into v. It returns
+// io.EOF if there are no more values and io.ErrUnexpectedEOF if the
+// stream ends in the middle of a value.
+func (d *Decoder) Decode(v interface{}) error {
+	if err := checkDecodeTarget(v); err != nil {
+		return err
+	}
+	limits := d.o.limits.withDefaults()
+	for {
+		if len(d.b) > 0 {
+			n, err := d.decode(v, limits)
+			if err == nil {
+				d.b = d.b[n:]
+				return nil
+			}
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				return err
+			}
+			if max := limits.MaxMessageSize; exceeds(int64(len(d.b)), max) {
+				return limitError("message size", int64(len(d.b)), max)
+			}
+		}
+		if d.err != nil {
+			if d.err == io.EOF && len(d.b) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return d.err
+		}
+		d.fill()
+	}
+}
+
+// decode decodes v from the bytes which have been read so far and
+// returns the number of bytes of the value. v is reset first since
+// it may contain a partially decoded value of a previous attempt.
+func (d *Decoder) decode(v interface{}, limits *DecodeLimits) (int, error) {
+	val := reflect.ValueOf(v)
+	val.Elem().Set(reflect.Zero(val.Elem().Type()))
+
+	buf := NewBuffer(d.b)
+	buf.limits = limits
+	buf.namespaces = d.o.namespaces
+	decode(buf, val, "")
+	return buf.Pos(), buf.Error()
+}
+
+// fill reads the next bytes from the stream. The error of the stream is
+// kept until the bytes which have been read before are decoded.
+func (d *Decoder) fill() {
+	if cap(d.b)-len(d.b) < decoderReadSize {
+		b := make([]byte, len(d.b), 2*len(d.b)+decoderReadSize)
+		copy(b, d.b)
+		d.b = b
+	}
+	n, err := d.r.Read(d.b[len(d.b):cap(d.b)])
+	d.b = d.b[:len(d.b)+n]
+	d.err = err
+}
@@ -5,11 +5,15 @@
 package ua
 
 import (
+	"bytes"
+	"crypto/rand"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
 )
 
 // These flags define which fields of a DataValue are set.
@@ -38,9 +42,24 @@ type DataValue struct {
 
 func (d *DataValue) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	d.decode(buf, nil)
+	return buf.Pos(), buf.Error()
+}
+
+func (d *DataValue) decodeBuffer(buf *Buffer) {
+	d.decode(buf, nil)
+}
+
+// decode reads the data value from buf. v is used for the value if it
+// is not nil so that the variants of many values can be allocated at
+// once.
+func (d *DataValue) decode(buf *Buffer, v *Variant) {
 	d.EncodingMask = buf.ReadByte()
 	if d.Has(DataValueValue) {
-		d.Value = new(Variant)
+		if v == nil {
+			v = new(Variant)
+		}
+		d.Value = v
 		buf.ReadStruct(d.Value)
 	}
 	if d.Has(DataValueStatusCode) {
@@ -58,7 +77,6 @@ func (d *DataValue) Decode(b []byte) (int, error) {
 	if d.Has(DataValueServerPicoseconds) {
 		d.ServerPicoseconds = buf.ReadUint16()
 	}
-	return buf.Pos(), buf.Error()
 }
 
 func (d *DataValue) Encode() ([]byte, error) {
@@ -112,8 +130,70 @@ func (d *DataValue) UpdateMask() {
 	}
 }
 
+// NewDataValue returns a data value for a write with the value, the status
+// and the source timestamp. value can be a *Variant or a value which is
+// accepted by NewVariant. Only the fields which are set are encoded: a nil
+// value, a Good status and a zero time are omitted since some servers
+// reject writes with a status or a timestamp. The part of the time below
+// the 100ns resolution of a DateTime is stored in SourcePicoseconds.
+func NewDataValue(value interface{}, status StatusCode, sourceTime time.Time) (*DataValue, error) {
+	d := &DataValue{Status: status}
+	switch v := value.(type) {
+	case nil:
+	case *Variant:
+		d.Value = v
+	default:
+		var err error
+		if d.Value, err = NewVariant(value); err != nil {
+			return nil, err
+		}
+	}
+	if !sourceTime.IsZero() {
+		sub := sourceTime.Nanosecond() % 100
+		d.SourceTimestamp = sourceTime.Add(-time.Duration(sub))
+		d.SourcePicoseconds = uint16(sub) * 100
+	}
+	d.UpdateMask()
+	return d, nil
+}
+
+// Good returns true if the status of the data value is good. A data
+// value without a status is good.
+func (d *DataValue) Good() bool {
+	return d != nil && d.Status.IsGood()
+}
+
+// Time returns the source timestamp of the data value or the server
+// timestamp if it has no source timestamp. The picoseconds are added to
+// the timestamps with nanosecond resolution. It returns the zero time if
+// the data value has no timestamp.
+func (d *DataValue) Time() time.Time {
+	switch {
+	case d == nil:
+		return time.Time{}
+	case !d.SourceTimestamp.IsZero():
+		return addPicoseconds(d.SourceTimestamp, d.SourcePicoseconds)
+	case !d.ServerTimestamp.IsZero():
+		return addPicoseconds(d.ServerTimestamp, d.ServerPicoseconds)
+	default:
+		return time.Time{}
+	}
+}
+
+// addPicoseconds adds the number of 10 picosecond intervals to t with
+// nanosecond resolution.
+//
+// Specification: Part 4, 7.11
+func addPicoseconds(t time.Time, ps uint16) time.Time {
+	return t.Add(time.Duration(ps/100) * time.Nanosecond)
+}
+
 // GUID represents GUID in binary stream. It is a 16-byte globally unique identifier.
 //
+// The binary encoding stores Data1, Data2 and Data3 in little endian byte
+// order and the string form is the RFC 4122 form in which they are in big
+// endian byte order, e.g. 72962B91-FA75-4AE6-8D28-B404DC7DAF63.
+//
 // Specification: Part 6, 5.1.3
 type GUID struct {
 	Data1 uint32
@@ -122,59 +202,134 @@ type GUID struct {
 	Data4 []byte
 }
 
-// NewGUID creates a new GUID.
-// Input should be GUID string of 16 hexadecimal characters like 1111AAAA-22BB-33CC-44DD-55EE77FF9900.
-// Dash can be omitted, and alphabets are not case-sensitive.
+// NewGUID returns the GUID of the string form like
+// 1111AAAA-22BB-33CC-44DD-55EE77FF9900 or nil if guid is not a valid
+// GUID.
+//
+// Deprecated: Use ParseGUID which returns an error for invalid GUIDs.
 func NewGUID(guid string) *GUID {
-	h := strings.Replace(guid, "-", "", -1)
-	b, err := hex.DecodeString(h)
+	g, err := ParseGUID(guid)
 	if err != nil {
 		return nil
 	}
-	if len(b) != 16 {
-		return nil
+	return g
+}
+
+// NewRandomGUID returns a new random (version 4) GUID.
+func NewRandomGUID() *GUID {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
 	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant RFC 4122
+	return GUIDFromBytes(b)
+}
+
+// ParseGUID returns the GUID of the string form like
+// 72962B91-FA75-4AE6-8D28-B404DC7DAF63. The GUID can be enclosed in
+// braces, the dashes can be omitted and the hexadecimal digits are not
+// case-sensitive.
+func ParseGUID(s string) (*GUID, error) {
+	h := s
+	if strings.HasPrefix(h, "{") && strings.HasSuffix(h, "}") {
+		h = h[1 : len(h)-1]
+	}
+	if len(h) == 36 {
+		if h[8] != '-' || h[13] != '-' || h[18] != '-' || h[23] != '-' {
+			return nil, errors.Errorf("invalid guid %q", s)
+		}
+		h = h[:8] + h[9:13] + h[14:18] + h[19:23] + h[24:]
+	}
+	if len(h) != 32 {
+		return nil, errors.Errorf("invalid guid %q", s)
+	}
+	var b [16]byte
+	if _, err := hex.Decode(b[:], []byte(h)); err != nil {
+		return nil, errors.Errorf("invalid guid %q", s)
+	}
+	return GUIDFromBytes(b), nil
+}
+
+// MustParseGUID is like ParseGUID but panics if s is not a valid GUID.
+func MustParseGUID(s string) *GUID {
+	g, err := ParseGUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
 
+// GUIDFromBytes returns the GUID of the bytes in RFC 4122 byte order,
+// e.g. of a uuid.UUID of github.com/google/uuid.
+func GUIDFromBytes(b [16]byte) *GUID {
 	return &GUID{
 		Data1: binary.BigEndian.Uint32(b[:4]),
 		Data2: binary.BigEndian.Uint16(b[4:6]),
 		Data3: binary.BigEndian.Uint16(b[6:8]),
-		Data4: b[8:16],
+		Data4: append([]byte(nil), b[8:16]...),
 	}
 }
 
+// Bytes returns the GUID in RFC 4122 byte order which is the order of
+// the string form and not of the binary encoding.
+func (g *GUID) Bytes() [16]byte {
+	var b [16]byte
+	binary.BigEndian.PutUint32(b[:4], g.Data1)
+	binary.BigEndian.PutUint16(b[4:6], g.Data2)
+	binary.BigEndian.PutUint16(b[6:8], g.Data3)
+	copy(b[8:], g.Data4)
+	return b
+}
+
+// Equal returns true if g and h are the same GUID or both are nil.
+func (g *GUID) Equal(h *GUID) bool {
+	return g.Compare(h) == 0
+}
+
+// Compare returns -1, 0 or 1 if g is less than, equal to or greater
+// than h in RFC 4122 byte order. A nil GUID is less than all other
+// GUIDs.
+func (g *GUID) Compare(h *GUID) int {
+	switch {
+	case g == nil && h == nil:
+		return 0
+	case g == nil:
+		return -1
+	case h == nil:
+		return 1
+	}
+	gb, hb := g.Bytes(), h.Bytes()
+	return bytes.Compare(gb[:], hb[:])
+}
+
 func (g *GUID) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
 	g.Data1 = buf.ReadUint32()
 	g.Data2 = buf.ReadUint16()
 	g.Data3 = buf.ReadUint16()
-	g.Data4 = buf.ReadN(8)
+	g.Data4 = copyBytes(buf.ReadN(8))
 	return buf.Pos(), buf.Error()
 }
 
 func (g *GUID) Encode() ([]byte, error) {
+	// Data4 is always 8 bytes even if the field is shorter
+	var d4 [8]byte
+	copy(d4[:], g.Data4)
+
 	buf := NewBuffer(nil)
 	buf.WriteUint32(g.Data1)
 	buf.WriteUint16(g.Data2)
 	buf.WriteUint16(g.Data3)
-	buf.Write(g.Data4)
+	buf.Write(d4[:])
 	return buf.Bytes(), buf.Error()
 }
 
-// String returns GUID in human-readable string.
+// String returns the GUID in the RFC 4122 string form with upper case
+// hexadecimal digits.
 func (g *GUID) String() string {
-	return fmt.Sprintf("%0*X-%0*X-%0*X-%0*X-%0*X",
-		8,
-		g.Data1,
-		4,
-		g.Data2,
-		4,
-		g.Data3,
-		4,
-		g.Data4[:2],
-		12,
-		g.Data4[2:],
-	)
+	b := g.Bytes()
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
 }
 
 // These flags define which fields of a LocalizedText are set.
@@ -215,6 +370,11 @@ func NewLocalizedTextWithLocale(text, locale string) *LocalizedText {
 
 func (l *LocalizedText) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	l.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (l *LocalizedText) decodeBuffer(buf *Buffer) {
 	l.EncodingMask = buf.ReadByte()
 	l.Locale = ""
 	l.Text = ""
@@ -224,7 +384,6 @@ func (l *LocalizedText) Decode(b []byte) (int, error) {
 	if l.Has(LocalizedTextText) {
 		l.Text = buf.ReadString()
 	}
-	return buf.Pos(), buf.Error()
 }
 
 func (l *LocalizedText) Encode() ([]byte, error) {
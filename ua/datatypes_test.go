@@ -5,6 +5,7 @@
 package ua
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -90,11 +91,79 @@ func TestDataValueArray(t *testing.T) {
 	RunCodecTest(t, cases)
 }
 
+func TestNewDataValue(t *testing.T) {
+	ts := time.Date(2018, time.September, 17, 14, 28, 29, 112000042, time.UTC)
+
+	d, err := NewDataValue(int32(5), StatusOK, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.EncodingMask, byte(DataValueValue); got != want {
+		t.Fatalf("got mask %#x want %#x", got, want)
+	}
+
+	d, err = NewDataValue(MustVariant("a"), StatusUncertain, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := byte(DataValueValue | DataValueStatusCode | DataValueSourceTimestamp | DataValueSourcePicoseconds)
+	if got := d.EncodingMask; got != want {
+		t.Fatalf("got mask %#x want %#x", got, want)
+	}
+	if d.Good() {
+		t.Fatal("got good for an uncertain status")
+	}
+	if got, want := d.SourcePicoseconds, uint16(4200); got != want {
+		t.Fatalf("got %d picoseconds want %d", got, want)
+	}
+
+	// the picoseconds survive the encoding which has a resolution of 100ns
+	b, err := d.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dv DataValue
+	if err := DecodeAll(b, &dv); err != nil {
+		t.Fatal(err)
+	}
+	if got := dv.Time(); !got.Equal(ts) {
+		t.Fatalf("got time %v want %v", got, ts)
+	}
+
+	if _, err := NewDataValue(struct{}{}, StatusOK, time.Time{}); err == nil {
+		t.Fatal("got nil want error for an invalid value")
+	}
+}
+
+func TestDataValueTime(t *testing.T) {
+	src := time.Date(2018, time.September, 17, 14, 28, 29, 0, time.UTC)
+	srv := src.Add(time.Second)
+
+	tests := []struct {
+		d    *DataValue
+		want time.Time
+	}{
+		{nil, time.Time{}},
+		{&DataValue{}, time.Time{}},
+		{&DataValue{ServerTimestamp: srv, ServerPicoseconds: 100}, srv.Add(time.Nanosecond)},
+		{&DataValue{SourceTimestamp: src, ServerTimestamp: srv}, src},
+	}
+	for _, tt := range tests {
+		if got := tt.d.Time(); !got.Equal(tt.want) {
+			t.Errorf("%v: got %v want %v", tt.d, got, tt.want)
+		}
+	}
+
+	if (*DataValue)(nil).Good() || !(&DataValue{}).Good() {
+		t.Fatal("got wrong status for nil or empty data value")
+	}
+}
+
 func TestGUID(t *testing.T) {
 	cases := []CodecTestCase{
 		{
 			Name:   "ok",
-			Struct: NewGUID("AAAABBBB-CCDD-EEFF-0102-0123456789AB"),
+			Struct: MustParseGUID("AAAABBBB-CCDD-EEFF-0102-0123456789AB"),
 			Bytes: []byte{
 				// data1 (inverse order)
 				0xbb, 0xbb, 0xaa, 0xaa,
@@ -108,7 +177,7 @@ func TestGUID(t *testing.T) {
 		},
 		{
 			Name:   "spec",
-			Struct: NewGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"),
+			Struct: MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"),
 			Bytes: []byte{
 				// data1 (inverse order)
 				0x91, 0x2b, 0x96, 0x72,
@@ -124,6 +193,114 @@ func TestGUID(t *testing.T) {
 	RunCodecTest(t, cases)
 }
 
+func TestParseGUID(t *testing.T) {
+	want := &GUID{
+		Data1: 0x72962b91,
+		Data2: 0xfa75,
+		Data3: 0x4ae6,
+		Data4: []byte{0x8d, 0x28, 0xb4, 0x04, 0xdc, 0x7d, 0xaf, 0x63},
+	}
+	for _, s := range []string{
+		"72962B91-FA75-4AE6-8D28-B404DC7DAF63",
+		"72962b91-fa75-4ae6-8d28-b404dc7daf63",
+		"{72962B91-FA75-4AE6-8D28-B404DC7DAF63}",
+		"72962B91FA754AE68D28B404DC7DAF63",
+	} {
+		t.Run(s, func(t *testing.T) {
+			g, err := ParseGUID(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(g, want) {
+				t.Fatalf("got %#v want %#v", g, want)
+			}
+			if got, want := g.String(), "72962B91-FA75-4AE6-8D28-B404DC7DAF63"; got != want {
+				t.Fatalf("got %s want %s", got, want)
+			}
+		})
+	}
+
+	for _, s := range []string{
+		"",
+		"abc",
+		"72962B91-FA75-4AE6-8D28-B404DC7DAF6",
+		"72962B91FA75-4AE6-8D28-B404DC7DAF63-",
+		"72962B91-FA75-4AE6-8D28-B404DC7DAF6X",
+		"{72962B91-FA75-4AE6-8D28-B404DC7DAF63",
+	} {
+		t.Run("invalid "+s, func(t *testing.T) {
+			if g, err := ParseGUID(s); err == nil {
+				t.Fatalf("got %v want error", g)
+			}
+		})
+	}
+}
+
+func TestNewGUID(t *testing.T) {
+	if got, want := NewGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"), MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"); !got.Equal(want) {
+		t.Fatalf("got %s want %s", got, want)
+	}
+	if got := NewGUID("abc"); got != nil {
+		t.Fatalf("got %s want nil", got)
+	}
+}
+
+func TestNewRandomGUID(t *testing.T) {
+	g, h := NewRandomGUID(), NewRandomGUID()
+	if g.Equal(h) {
+		t.Fatalf("got the same GUID %s twice", g)
+	}
+	b := g.Bytes()
+	if got, want := b[6]>>4, byte(4); got != want {
+		t.Fatalf("got version %d want %d", got, want)
+	}
+	if got, want := b[8]>>6, byte(2); got != want {
+		t.Fatalf("got variant %d want %d", got, want)
+	}
+	p, err := ParseGUID(g.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(g) {
+		t.Fatalf("got %s want %s", p, g)
+	}
+}
+
+func TestGUIDCompare(t *testing.T) {
+	a := MustParseGUID("00000001-0000-0000-0000-000000000000")
+	b := MustParseGUID("00000000-0000-0000-0000-000000000002")
+	tests := []struct {
+		g, h *GUID
+		want int
+	}{
+		{nil, nil, 0},
+		{nil, a, -1},
+		{a, nil, 1},
+		{a, MustParseGUID("00000001-0000-0000-0000-000000000000"), 0},
+		{a, b, 1},
+		{b, a, -1},
+	}
+	for _, tt := range tests {
+		if got := tt.g.Compare(tt.h); got != tt.want {
+			t.Fatalf("%v.Compare(%v) got %d want %d", tt.g, tt.h, got, tt.want)
+		}
+		if got, want := tt.g.Equal(tt.h), tt.want == 0; got != want {
+			t.Fatalf("%v.Equal(%v) got %v want %v", tt.g, tt.h, got, want)
+		}
+	}
+}
+
+func TestGUIDBytes(t *testing.T) {
+	b := [16]byte{0x72, 0x96, 0x2b, 0x91, 0xfa, 0x75, 0x4a, 0xe6, 0x8d, 0x28, 0xb4, 0x04, 0xdc, 0x7d, 0xaf, 0x63}
+	g := GUIDFromBytes(b)
+	if got, want := g.String(), "72962B91-FA75-4AE6-8D28-B404DC7DAF63"; got != want {
+		t.Fatalf("got %s want %s", got, want)
+	}
+	if got := g.Bytes(); got != b {
+		t.Fatalf("got %x want %x", got, b)
+	}
+}
+
 func TestLocalizedText(t *testing.T) {
 	cases := []CodecTestCase{
 		{
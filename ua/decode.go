@@ -30,24 +30,60 @@ type BinaryDecoder interface {
 	Decode([]byte) (int, error)
 }
 
+// bufferDecoder is implemented by the types of this package which decode
+// themselves from the buffer of the enclosing value so that nested
+// values are decoded with the limits and the nesting depth of the
+// message.
+type bufferDecoder interface {
+	decodeBuffer(buf *Buffer)
+}
+
+// Decode decodes v from b with DefaultDecodeLimits and returns the
+// number of bytes read.
 func Decode(b []byte, v interface{}) (int, error) {
-	val := reflect.ValueOf(v)
-	return decode(b, val, val.Type().String())
+	return DecodeWithLimits(b, v, nil)
+}
+
+// DecodeWithLimits decodes v from b with the limits and returns the
+// number of bytes read. The zero fields of limits and a nil limits use
+// DefaultDecodeLimits.
+func DecodeWithLimits(b []byte, v interface{}, limits *DecodeLimits) (int, error) {
+	return decodeWith(b, v, limits, nil)
+}
+
+// decodeWith decodes v from b with the limits and the namespace array of
+// the server.
+func decodeWith(b []byte, v interface{}, limits *DecodeLimits, namespaces []string) (int, error) {
+	buf := NewBuffer(b)
+	buf.limits = limits.withDefaults()
+	buf.namespaces = namespaces
+	if max := buf.limits.MaxMessageSize; exceeds(int64(len(b)), max) {
+		return 0, limitError("message size", int64(len(b)), max)
+	}
+	decode(buf, reflect.ValueOf(v), "")
+	return buf.Pos(), buf.Error()
 }
 
-func decode(b []byte, val reflect.Value, name string) (n int, err error) {
+// decode decodes val from buf. name is the path of the value for the
+// debug output and is only set if debugCodec is enabled.
+func decode(buf *Buffer, val reflect.Value, name string) {
+	if buf.err != nil {
+		return
+	}
 	if debugCodec {
-		fmt.Printf("decode: %s has type %v and is a %s, %d bytes\n", name, val.Type(), val.Type().Kind(), len(b))
+		if name == "" {
+			name = val.Type().String()
+		}
+		fmt.Printf("decode: %s has type %v and is a %s, %d bytes\n", name, val.Type(), val.Type().Kind(), buf.Len())
+		pos := buf.Pos()
 		defer func() {
-			fmt.Printf("decode: decoded %d bytes into %s\n", n, name)
+			fmt.Printf("decode: decoded %d bytes into %s\n", buf.Pos()-pos, name)
 		}()
 	}
 
-	buf := NewBuffer(b)
 	switch {
 	case isBinaryDecoder(val):
-		v := val.Interface().(BinaryDecoder)
-		return v.Decode(b)
+		buf.ReadStruct(val.Interface())
 	case isTime(val):
 		val.Set(reflect.ValueOf(buf.ReadTime()))
 	default:
@@ -78,57 +114,81 @@ func decode(b []byte, val reflect.Value, name string) (n int, err error) {
 		case reflect.String:
 			val.SetString(buf.ReadString())
 		case reflect.Slice:
-			return decodeSlice(b, val, name)
+			decodeSlice(buf, val, name)
 		case reflect.Array:
-			return decodeArray(b, val, name)
+			decodeArray(buf, val, name)
 		case reflect.Ptr:
-			return decode(b, val.Elem(), name)
+			decode(buf, val.Elem(), name)
 		case reflect.Struct:
-			return decodeStruct(b, val, name)
+			decodeStruct(buf, val, name)
 		default:
-			return 0, errors.Errorf("unsupported type %s", val.Type())
+			buf.err = errors.Errorf("unsupported type %s", val.Type())
 		}
 	}
-	return buf.Pos(), buf.Error()
 }
 
-func decodeStruct(b []byte, val reflect.Value, name string) (int, error) {
-	pos := 0
-	valt := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		ft := valt.Field(i)
-		fname := name + "." + ft.Name
+func decodeStruct(buf *Buffer, val reflect.Value, name string) {
+	fields, err := structFields(val.Type())
+	if err != nil {
+		buf.err = err
+		return
+	}
+
+	if !buf.enter() {
+		return
+	}
+	defer buf.leave()
+
+	for _, sf := range fields {
+		if !sf.present(val) {
+			continue
+		}
+		var fname string
+		if debugCodec {
+			fname = name + "." + sf.name
+		}
 
 		// if the field is a pointer we need to create
 		// the value before we can marshal data into it.
-		f := val.Field(i)
+		f := val.Field(sf.index)
 		if f.Type().Kind() == reflect.Ptr {
 			f.Set(reflect.New(f.Type().Elem()))
 			// fmt.Printf("decode: %s has type %v and has new value %#v\n", fname, f.Type(), f.Interface())
 		}
 
-		n, err := decode(b[pos:], f, fname)
-		if err != nil {
-			return pos, err
+		if sf.lengthField >= 0 {
+			decodeElements(buf, f, fname, intValue(val.Field(sf.lengthField)))
+		} else {
+			decode(buf, f, fname)
+		}
+		if buf.err != nil {
+			return
 		}
-		pos += n
 	}
-	return pos, nil
 }
 
-func decodeSlice(b []byte, val reflect.Value, name string) (int, error) {
-	buf := NewBuffer(b)
+func decodeSlice(buf *Buffer, val reflect.Value, name string) {
 	n := buf.ReadUint32()
 	if buf.Error() != nil {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	if n == null {
-		return buf.Pos(), nil
+		return
 	}
 
+	decodeElements(buf, val, name, int64(n))
+}
+
+// decodeElements decodes n slice elements which are not prefixed
+// with the length.
+func decodeElements(buf *Buffer, val reflect.Value, name string, n int64) {
+	if n < 0 {
+		return
+	}
 	if n > math.MaxInt32 {
-		return buf.Pos(), errors.Errorf("array too large: %d > %d", n, math.MaxInt32)
+		buf.err = errors.Errorf("array too large: %d > %d", n, math.MaxInt32)
+		return
 	}
 
 	// elemType is the type of the slice elements
@@ -139,11 +199,19 @@ func decodeSlice(b []byte, val reflect.Value, name string) (int, error) {
 	// fast path for []byte
 	if elemType.Kind() == reflect.Uint8 {
 		// fmt.Println("decode: []byte fast path")
-		val.SetBytes(buf.ReadN(int(n)))
-		return buf.Pos(), buf.Error()
+		if max := buf.decodeLimits().MaxByteStringLength; exceeds(n, max) {
+			buf.err = limitError("byte string length", n, max)
+			return
+		}
+		// copy the bytes so that the value does not reference b
+		val.SetBytes(copyBytes(buf.ReadN(int(n))))
+		return
+	}
+
+	if !checkArrayLength(buf, n, 1) {
+		return
 	}
 
-	pos := buf.Pos()
 	// a is a slice of []*Foo
 	a := reflect.MakeSlice(val.Type(), int(n), int(n))
 	for i := 0; i < int(n); i++ {
@@ -154,35 +222,36 @@ func decodeSlice(b []byte, val reflect.Value, name string) (int, error) {
 			a.Index(i).Set(reflect.New(elemType.Elem()))
 		}
 
-		ename := fmt.Sprintf("%s[%d]", name, i)
-		m, err := decode(b[pos:], a.Index(i), ename)
-		if err != nil {
-			return pos, err
+		var ename string
+		if debugCodec {
+			ename = fmt.Sprintf("%s[%d]", name, i)
+		}
+		decode(buf, a.Index(i), ename)
+		if buf.err != nil {
+			return
 		}
-		pos += m
 	}
 	val.Set(a)
-
-	return pos, nil
 }
 
-func decodeArray(b []byte, val reflect.Value, name string) (int, error) {
-	buf := NewBuffer(b)
+func decodeArray(buf *Buffer, val reflect.Value, name string) {
 	n := buf.ReadUint32()
 	if buf.Error() != nil {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	if n == null {
-		return buf.Pos(), nil
+		return
 	}
 
 	if n > math.MaxInt32 {
-		return buf.Pos(), errors.Errorf("array too large: %d > %d", n, math.MaxInt32)
+		buf.err = errors.Errorf("array too large: %d > %d", n, math.MaxInt32)
+		return
 	}
 
 	if n > uint32(val.Len()) {
-		return buf.Pos(), errors.Errorf("array too large: %d > %d", n, val.Len())
+		buf.err = errors.Errorf("array too large: %d > %d", n, val.Len())
+		return
 	}
 
 	// elemType is the type of the slice elements
@@ -194,10 +263,9 @@ func decodeArray(b []byte, val reflect.Value, name string) (int, error) {
 	if elemType.Kind() == reflect.Uint8 {
 		// fmt.Println("decode: []byte fast path")
 		reflect.Copy(val, reflect.ValueOf(buf.ReadN(int(n))))
-		return buf.Pos(), buf.Error()
+		return
 	}
 
-	pos := buf.Pos()
 	// a is a pointer to an array [n]*Foo, where n is know at compile time
 	a := reflect.New(val.Type()).Elem()
 	for i := 0; i < int(n); i++ {
@@ -208,14 +276,14 @@ func decodeArray(b []byte, val reflect.Value, name string) (int, error) {
 			a.Index(i).Set(reflect.New(elemType.Elem()))
 		}
 
-		ename := fmt.Sprintf("%s[%d]", name, i)
-		m, err := decode(b[pos:], a.Index(i), ename)
-		if err != nil {
-			return pos, err
+		var ename string
+		if debugCodec {
+			ename = fmt.Sprintf("%s[%d]", name, i)
+		}
+		decode(buf, a.Index(i), ename)
+		if buf.err != nil {
+			return
 		}
-		pos += m
 	}
 	val.Set(a)
-
-	return pos, nil
 }
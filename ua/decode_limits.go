@@ -0,0 +1,103 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"io"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// DecodeLimits are the limits for decoding a message. They protect the
+// decoder from malformed or malicious messages which announce huge
+// arrays or strings or which nest values so deeply that decoding them
+// exhausts the memory or the stack. A value which exceeds a limit fails
+// with an error which wraps StatusBadEncodingLimitsExceeded.
+//
+// The arrays of a message are additionally limited by the size of the
+// message, i.e. the decoder does not allocate an array whose elements
+// cannot be contained in the remaining bytes.
+type DecodeLimits struct {
+	// MaxArrayLength is the maximum number of elements of an array.
+	MaxArrayLength int
+
+	// MaxStringLength is the maximum length of a string in bytes.
+	MaxStringLength int
+
+	// MaxByteStringLength is the maximum length of a ByteString.
+	MaxByteStringLength int
+
+	// MaxMessageSize is the maximum size of a message.
+	MaxMessageSize int
+
+	// MaxNestingDepth is the maximum nesting depth of structured values,
+	// e.g. of variants which contain variants or of extension objects.
+	MaxNestingDepth int
+}
+
+// DefaultDecodeLimits are the limits of DecodeService and Decode. They
+// are also used for the zero fields of the limits which are passed to
+// DecodeServiceWithLimits and DecodeWithLimits. A negative limit
+// disables the check, e.g. DecodeLimits{MaxArrayLength: -1} decodes
+// arrays of any length.
+var DefaultDecodeLimits = DecodeLimits{
+	MaxArrayLength:      1 << 20,
+	MaxStringLength:     4 << 20,
+	MaxByteStringLength: 16 << 20,
+	MaxMessageSize:      64 << 20,
+	MaxNestingDepth:     200,
+}
+
+// withDefaults returns the limits with the zero fields set to the value
+// of DefaultDecodeLimits.
+func (l *DecodeLimits) withDefaults() *DecodeLimits {
+	if l == nil {
+		return &DefaultDecodeLimits
+	}
+	d := *l
+	if d.MaxArrayLength == 0 {
+		d.MaxArrayLength = DefaultDecodeLimits.MaxArrayLength
+	}
+	if d.MaxStringLength == 0 {
+		d.MaxStringLength = DefaultDecodeLimits.MaxStringLength
+	}
+	if d.MaxByteStringLength == 0 {
+		d.MaxByteStringLength = DefaultDecodeLimits.MaxByteStringLength
+	}
+	if d.MaxMessageSize == 0 {
+		d.MaxMessageSize = DefaultDecodeLimits.MaxMessageSize
+	}
+	if d.MaxNestingDepth == 0 {
+		d.MaxNestingDepth = DefaultDecodeLimits.MaxNestingDepth
+	}
+	return &d
+}
+
+// exceeds returns true if n is larger than the limit max. A limit of
+// zero or less is no limit.
+func exceeds(n int64, max int) bool {
+	return max > 0 && n > int64(max)
+}
+
+// limitError returns the error for a value which exceeds a limit.
+func limitError(what string, n int64, max int) error {
+	return errors.Wrapf(StatusBadEncodingLimitsExceeded, "%s %d exceeds the limit of %d", what, n, max)
+}
+
+// checkArrayLength returns true if an array with n elements of at least
+// minSize bytes is within the limits of buf and fits into its remaining
+// bytes. Otherwise, it sets the error of buf. The check prevents the
+// allocation of huge arrays for short messages.
+func checkArrayLength(buf *Buffer, n int64, minSize int) bool {
+	if n > int64(buf.Len()/minSize) {
+		buf.err = io.ErrUnexpectedEOF
+		return false
+	}
+	if max := buf.decodeLimits().MaxArrayLength; exceeds(n, max) {
+		buf.err = limitError("array length", n, max)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"io"
+	"testing"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// nestedVariant returns a variant which contains depth variants.
+func nestedVariant(depth int) *Variant {
+	v := MustVariant(int32(42))
+	for i := 0; i < depth; i++ {
+		v = MustVariant(v)
+	}
+	return v
+}
+
+// nestedExtensionObject returns a variant with an extension object which
+// contains depth extension objects in the bodies of literal operands.
+func nestedExtensionObject(depth int) *Variant {
+	v := MustVariant(int32(42))
+	for i := 0; i < depth; i++ {
+		v = MustVariant(NewExtensionObject(&LiteralOperand{Value: v}))
+	}
+	return v
+}
+
+func mustEncode(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := Encode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      []byte
+		v      interface{}
+		limits *DecodeLimits
+		err    error
+	}{
+		{
+			name:   "array length",
+			b:      mustEncode(t, []uint32{1, 2, 3}),
+			v:      new([]uint32),
+			limits: &DecodeLimits{MaxArrayLength: 2},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name: "array length exceeds message",
+			b:    []byte{0xff, 0xff, 0xff, 0x0f, 0x01, 0x00, 0x00, 0x00},
+			v:    new([]uint32),
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:   "string length",
+			b:      mustEncode(t, "abcdef"),
+			v:      new(string),
+			limits: &DecodeLimits{MaxStringLength: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "byte string length",
+			b:      mustEncode(t, []byte("abcdef")),
+			v:      new([]byte),
+			limits: &DecodeLimits{MaxByteStringLength: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "node id string length",
+			b:      mustEncode(t, NewStringNodeID(1, "abcdef")),
+			v:      new(NodeID),
+			limits: &DecodeLimits{MaxStringLength: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "localized text length",
+			b:      mustEncode(t, NewLocalizedText("abcdef")),
+			v:      new(LocalizedText),
+			limits: &DecodeLimits{MaxStringLength: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "variant array length",
+			b:      mustEncode(t, MustVariant([]int32{1, 2, 3})),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxArrayLength: 2},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name: "variant array length exceeds message",
+			b:    []byte{0x86, 0x00, 0x10, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00},
+			v:    new(Variant),
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name:   "variant nesting depth",
+			b:      mustEncode(t, nestedVariant(10)),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxNestingDepth: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name: "variant nesting depth default",
+			b:    mustEncode(t, nestedVariant(DefaultDecodeLimits.MaxNestingDepth+1)),
+			v:    new(Variant),
+			err:  StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "extension object nesting depth",
+			b:      mustEncode(t, nestedExtensionObject(10)),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxNestingDepth: 10},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "extension object length",
+			b:      mustEncode(t, NewExtensionObject(&LiteralOperand{Value: MustVariant("abcdef")})),
+			v:      new(ExtensionObject),
+			limits: &DecodeLimits{MaxByteStringLength: 5},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name: "extension object body exceeds message",
+			b:    []byte{0x01, 0x00, 0x2a, 0x02, 0x01, 0x10, 0x00, 0x00, 0x00, 0x00},
+			v:    new(ExtensionObject),
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "diagnostic info nesting depth",
+			b: mustEncode(t, &DiagnosticInfo{
+				EncodingMask: DiagnosticInfoInnerDiagnosticInfo,
+				InnerDiagnosticInfo: &DiagnosticInfo{
+					EncodingMask:        DiagnosticInfoInnerDiagnosticInfo,
+					InnerDiagnosticInfo: &DiagnosticInfo{},
+				},
+			}),
+			v:      new(DiagnosticInfo),
+			limits: &DecodeLimits{MaxNestingDepth: 2},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "zero limit uses the default",
+			b:      mustEncode(t, nestedVariant(DefaultDecodeLimits.MaxNestingDepth+1)),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxNestingDepth: 0, MaxArrayLength: 10},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+		{
+			name:   "message size",
+			b:      mustEncode(t, uint64(1)),
+			v:      new(uint64),
+			limits: &DecodeLimits{MaxMessageSize: 4},
+			err:    StatusBadEncodingLimitsExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeWithLimits(tt.b, tt.v, tt.limits)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeWithinLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		b      []byte
+		v      interface{}
+		limits *DecodeLimits
+	}{
+		{
+			name:   "array length",
+			b:      mustEncode(t, []uint32{1, 2, 3}),
+			v:      new([]uint32),
+			limits: &DecodeLimits{MaxArrayLength: 3},
+		},
+		{
+			name:   "string length",
+			b:      mustEncode(t, "abcdef"),
+			v:      new(string),
+			limits: &DecodeLimits{MaxStringLength: 6},
+		},
+		{
+			name:   "variant nesting depth",
+			b:      mustEncode(t, nestedVariant(10)),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxNestingDepth: 11},
+		},
+		{
+			name: "extension object nesting depth",
+			b:    mustEncode(t, nestedExtensionObject(10)),
+			v:    new(Variant),
+		},
+		{
+			name:   "negative limit disables the check",
+			b:      mustEncode(t, nestedVariant(DefaultDecodeLimits.MaxNestingDepth+1)),
+			v:      new(Variant),
+			limits: &DecodeLimits{MaxNestingDepth: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeWithLimits(tt.b, tt.v, tt.limits); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDecodeServiceWithLimits(t *testing.T) {
+	b := encodePublishResponse(t, 3)
+
+	if _, _, err := DecodeServiceWithLimits(b, nil); err != nil {
+		t.Fatalf("got error %v for the default limits", err)
+	}
+
+	_, _, err := DecodeServiceWithLimits(b, &DecodeLimits{MaxArrayLength: 2})
+	if !errors.Is(err, StatusBadEncodingLimitsExceeded) {
+		t.Fatalf("got error %v want %v", err, StatusBadEncodingLimitsExceeded)
+	}
+
+	// every prefix of the message is truncated and must fail
+	for i := 0; i < len(b); i++ {
+		if _, _, err := DecodeServiceWithLimits(b[:i], nil); err == nil {
+			t.Fatalf("got no error for %d of %d bytes", i, len(b))
+		}
+	}
+}
@@ -6,9 +6,13 @@ package ua
 
 import (
 	"bytes"
+	"io"
 	"reflect"
 	"testing"
+	"testing/iotest"
 	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
 )
 
 type A struct {
@@ -205,6 +209,21 @@ func TestCodec(t *testing.T) {
 			v:    &struct{ V time.Time }{time.Time{}},
 			b:    []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 		},
+		{
+			name: "DateTimeUnixEpoch",
+			v:    &struct{ V time.Time }{time.Unix(0, 0).UTC()},
+			b:    []byte{0x00, 0x80, 0x3e, 0xd5, 0xde, 0xb1, 0x9d, 0x01},
+		},
+		{
+			name: "DateTimeMinPlus100ns",
+			v:    &struct{ V time.Time }{MinDateTime.Add(100 * time.Nanosecond)},
+			b:    []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "DateTimeMax",
+			v:    &struct{ V time.Time }{MaxDateTime},
+			b:    []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		},
 		{
 			name: "[]uint32==nil",
 			v:    &struct{ V []uint32 }{},
@@ -402,6 +421,60 @@ func TestCodec(t *testing.T) {
 	}
 }
 
+func TestDateTimeBoundaries(t *testing.T) {
+	null := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	max := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+
+	t.Run("encode", func(t *testing.T) {
+		tests := []struct {
+			name string
+			v    time.Time
+			b    []byte
+		}{
+			{"zero", time.Time{}, null},
+			{"before 1601", time.Date(1600, time.December, 31, 23, 59, 59, 0, time.UTC), null},
+			{"min", MinDateTime, null},
+			{"year 9999", time.Date(9999, time.December, 31, 23, 59, 58, 999999900, time.UTC), []byte{0x7f, 0xa9, 0x27, 0xd1, 0x5e, 0x5a, 0xc8, 0x24}},
+			{"after 9999", time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC), max},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				b, err := Encode(tt.v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := b, tt.b; !bytes.Equal(got, want) {
+					t.Fatalf("got %#v, want %#v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		tests := []struct {
+			name string
+			b    []byte
+			v    time.Time
+		}{
+			{"null", null, time.Time{}},
+			{"negative", []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80}, time.Time{}},
+			{"max", max, MaxDateTime},
+			{"after 9999", []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7f}, MaxDateTime},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var v time.Time
+				if err := DecodeAll(tt.b, &v); err != nil {
+					t.Fatal(err)
+				}
+				if got, want := v, tt.v; !got.Equal(want) {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			})
+		}
+	})
+}
+
 func TestFailDecodeArray(t *testing.T) {
 	b := []byte{
 		// len
@@ -417,3 +490,99 @@ func TestFailDecodeArray(t *testing.T) {
 		t.Fatalf("was expecting error for tryig to decode a stream of bytes with length 3 into an array of size 2")
 	}
 }
+
+func TestDecodeAll(t *testing.T) {
+	b := []byte{0x01, 0x00, 0x00, 0x00, 0xff}
+
+	var v uint32
+	if err := DecodeAll(b, &v); err == nil {
+		t.Fatal("got nil want error for trailing bytes")
+	}
+	if err := DecodeAll(b, &v, WithTrailingBytes()); err != nil || v != 1 {
+		t.Fatalf("got %d, %v want 1, nil", v, err)
+	}
+	if err := DecodeAll(b[:4], v); err == nil {
+		t.Fatal("got nil want error for a non-pointer")
+	}
+
+	var s string
+	err := DecodeAll([]byte{0x03, 0x00, 0x00, 0x00, 'a', 'b', 'c'}, &s, WithDecodeLimits(&DecodeLimits{MaxStringLength: 2}))
+	if !errors.Is(err, StatusBadEncodingLimitsExceeded) {
+		t.Fatalf("got error %v want %v", err, StatusBadEncodingLimitsExceeded)
+	}
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	ts := time.Date(2019, 1, 1, 12, 13, 14, 0, time.UTC)
+	values := []interface{}{
+		&DataValue{EncodingMask: DataValueValue | DataValueSourceTimestamp, Value: MustVariant(int32(5)), SourceTimestamp: ts},
+		NewExtensionObject(&ReadValueID{NodeID: NewNumericNodeID(0, 2259), AttributeID: AttributeIDValue, DataEncoding: &QualifiedName{}}),
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec := NewDecoder(&buf)
+	dv, eo := new(DataValue), new(ExtensionObject)
+	for _, v := range []interface{}{dv, eo} {
+		if err := dec.Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := dec.Decode(new(DataValue)); err != io.EOF {
+		t.Fatalf("got error %v want io.EOF", err)
+	}
+
+	if got, want := dv.Value.Value(), int32(5); got != want || !dv.SourceTimestamp.Equal(ts) {
+		t.Fatalf("got %v at %v want %v at %v", got, dv.SourceTimestamp, want, ts)
+	}
+	if got, ok := eo.Value.(*ReadValueID); !ok || got.NodeID.IntID() != 2259 {
+		t.Fatalf("got %#v want *ReadValueID", eo.Value)
+	}
+}
+
+func TestDecoderReadsIncrementally(t *testing.T) {
+	r, w := io.Pipe()
+	dec := NewDecoder(iotest.OneByteReader(r))
+
+	// the writer is not closed so the decoder must return the value
+	// without waiting for the end of the stream.
+	go NewEncoder(w).Encode(&ReadValueID{NodeID: NewNumericNodeID(0, 2259), AttributeID: AttributeIDValue, DataEncoding: &QualifiedName{}})
+
+	var v ReadValueID
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.NodeID.IntID(), uint32(2259); got != want {
+		t.Fatalf("got node id %d want %d", got, want)
+	}
+	w.Close()
+	if err := dec.Decode(new(ReadValueID)); err != io.EOF {
+		t.Fatalf("got error %v want io.EOF", err)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	b, err := Encode("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s string
+	if err := NewDecoder(bytes.NewReader(b[:5])).Decode(&s); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v want io.ErrUnexpectedEOF", err)
+	}
+
+	dec := NewDecoder(bytes.NewReader(b), WithDecodeLimits(&DecodeLimits{MaxStringLength: 2}))
+	if err := dec.Decode(&s); !errors.Is(err, StatusBadEncodingLimitsExceeded) {
+		t.Fatalf("got error %v want %v", err, StatusBadEncodingLimitsExceeded)
+	}
+	if err := NewDecoder(bytes.NewReader(b)).Decode(s); err == nil {
+		t.Fatal("got nil want error for a non-pointer")
+	}
+}
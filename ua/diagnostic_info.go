@@ -4,6 +4,13 @@
 
 package ua
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
 // These flags define which fields of a DiagnosticInfo are set.
 // Bits are or'ed together if multiple fields are set.
 const (
@@ -16,8 +23,15 @@ const (
 	DiagnosticInfoInnerDiagnosticInfo = 0x40
 )
 
+// MaxDiagnosticInfoDepth is the maximum nesting depth of the inner
+// diagnostic infos of a DiagnosticInfo.
+const MaxDiagnosticInfoDepth = 100
+
 // DiagnosticInfo represents the DiagnosticInfo.
 //
+// SymbolicID, NamespaceURI, Locale and LocalizedText are indices into
+// the StringTable of the ResponseHeader. Use Resolve to get the strings.
+//
 // Specification: Part 4, 7.8
 type DiagnosticInfo struct {
 	EncodingMask        uint8
@@ -32,6 +46,19 @@ type DiagnosticInfo struct {
 
 func (d *DiagnosticInfo) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	d.decode(buf, 0)
+	return buf.Pos(), buf.Error()
+}
+
+func (d *DiagnosticInfo) decodeBuffer(buf *Buffer) {
+	d.decode(buf, 0)
+}
+
+func (d *DiagnosticInfo) decode(buf *Buffer, depth int) {
+	if depth > MaxDiagnosticInfoDepth {
+		buf.err = errors.Errorf("diagnostic info nested too deeply")
+		return
+	}
 	d.EncodingMask = buf.ReadByte()
 	if d.Has(DiagnosticInfoSymbolicID) {
 		d.SymbolicID = buf.ReadInt32()
@@ -51,11 +78,11 @@ func (d *DiagnosticInfo) Decode(b []byte) (int, error) {
 	if d.Has(DiagnosticInfoInnerStatusCode) {
 		d.InnerStatusCode = StatusCode(buf.ReadUint32())
 	}
-	if d.Has(DiagnosticInfoInnerDiagnosticInfo) {
+	if d.Has(DiagnosticInfoInnerDiagnosticInfo) && buf.enter() {
 		d.InnerDiagnosticInfo = new(DiagnosticInfo)
-		buf.ReadStruct(d.InnerDiagnosticInfo)
+		d.InnerDiagnosticInfo.decode(buf, depth+1)
+		buf.leave()
 	}
-	return buf.Pos(), buf.Error()
 }
 
 func (d *DiagnosticInfo) Encode() ([]byte, error) {
@@ -113,3 +140,94 @@ func (d *DiagnosticInfo) UpdateMask() {
 		d.EncodingMask |= DiagnosticInfoInnerDiagnosticInfo
 	}
 }
+
+// ResolvedDiagnostic is a DiagnosticInfo whose indices into the
+// StringTable of the response have been replaced with the strings. Fields
+// which are not set in the DiagnosticInfo are empty and indices which are
+// not in the string table are shown as "#<index>".
+type ResolvedDiagnostic struct {
+	SymbolicID      string
+	NamespaceURI    string
+	Locale          string
+	LocalizedText   string
+	AdditionalInfo  string
+	InnerStatusCode StatusCode
+	Inner           *ResolvedDiagnostic
+}
+
+// Resolve returns the diagnostic info and its inner diagnostic infos with
+// the strings of the StringTable of the response.
+func (d *DiagnosticInfo) Resolve(stringTable []string) ResolvedDiagnostic {
+	return *d.resolve(stringTable, 0)
+}
+
+func (d *DiagnosticInfo) resolve(stringTable []string, depth int) *ResolvedDiagnostic {
+	lookup := func(mask byte, idx int32) string {
+		switch {
+		case !d.Has(mask):
+			return ""
+		case idx < 0 || int(idx) >= len(stringTable):
+			return fmt.Sprintf("#%d", idx)
+		default:
+			return stringTable[idx]
+		}
+	}
+
+	r := &ResolvedDiagnostic{
+		SymbolicID:    lookup(DiagnosticInfoSymbolicID, d.SymbolicID),
+		NamespaceURI:  lookup(DiagnosticInfoNamespaceURI, d.NamespaceURI),
+		Locale:        lookup(DiagnosticInfoLocale, d.Locale),
+		LocalizedText: lookup(DiagnosticInfoLocalizedText, d.LocalizedText),
+	}
+	if d.Has(DiagnosticInfoAdditionalInfo) {
+		r.AdditionalInfo = d.AdditionalInfo
+	}
+	if d.Has(DiagnosticInfoInnerStatusCode) {
+		r.InnerStatusCode = d.InnerStatusCode
+	}
+	if d.Has(DiagnosticInfoInnerDiagnosticInfo) && d.InnerDiagnosticInfo != nil && depth < MaxDiagnosticInfoDepth {
+		r.Inner = d.InnerDiagnosticInfo.resolve(stringTable, depth+1)
+	}
+	return r
+}
+
+// String returns a readable message of the diagnostic and its inner
+// diagnostics. The message contains the localized text, the symbolic id
+// with its namespace, the additional info and the inner status code if
+// they are set.
+func (r ResolvedDiagnostic) String() string {
+	var msgs []string
+	for d := &r; d != nil; d = d.Inner {
+		msgs = append(msgs, d.message())
+	}
+	return strings.Join(msgs, ": ")
+}
+
+// message returns the message of the diagnostic without the inner
+// diagnostic.
+func (r *ResolvedDiagnostic) message() string {
+	var parts []string
+	if r.LocalizedText != "" {
+		parts = append(parts, r.LocalizedText)
+	}
+	if r.SymbolicID != "" {
+		id := r.SymbolicID
+		if r.NamespaceURI != "" {
+			id = r.NamespaceURI + "#" + id
+		}
+		if len(parts) > 0 {
+			id = "(" + id + ")"
+		}
+		parts = append(parts, id)
+	}
+	if r.AdditionalInfo != "" {
+		parts = append(parts, r.AdditionalInfo)
+	}
+	if r.InnerStatusCode != StatusOK {
+		parts = append(parts, "["+r.InnerStatusCode.Error()+"]")
+	}
+	if len(parts) == 0 {
+		return "no diagnostics"
+	}
+	return strings.Join(parts, " ")
+}
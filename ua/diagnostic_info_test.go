@@ -5,7 +5,10 @@
 package ua
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
 )
 
 func TestDiagnosticInfo(t *testing.T) {
@@ -131,6 +134,123 @@ func TestDiagnosticInfo(t *testing.T) {
 				0x01, 0x07, 0x00, 0x00, 0x00,
 			},
 		},
+		{
+			Name: "Nested InnerDiagnosticInfo",
+			Struct: &DiagnosticInfo{
+				EncodingMask: DiagnosticInfoSymbolicID | DiagnosticInfoInnerDiagnosticInfo,
+				SymbolicID:   1,
+				InnerDiagnosticInfo: &DiagnosticInfo{
+					EncodingMask:    DiagnosticInfoInnerStatusCode | DiagnosticInfoInnerDiagnosticInfo,
+					InnerStatusCode: 2,
+					InnerDiagnosticInfo: &DiagnosticInfo{
+						EncodingMask:   DiagnosticInfoAdditionalInfo,
+						AdditionalInfo: "a",
+					},
+				},
+			},
+			Bytes: []byte{
+				0x41, 0x01, 0x00, 0x00, 0x00,
+				0x60, 0x02, 0x00, 0x00, 0x00,
+				0x10, 0x01, 0x00, 0x00, 0x00, 0x61,
+			},
+		},
 	}
 	RunCodecTest(t, cases)
 }
+
+func TestDiagnosticInfoDepth(t *testing.T) {
+	decode := func(depth int) error {
+		b := append(bytes.Repeat([]byte{DiagnosticInfoInnerDiagnosticInfo}, depth), 0x00)
+		_, err := new(DiagnosticInfo).Decode(b)
+		return err
+	}
+	if err := decode(MaxDiagnosticInfoDepth); err != nil {
+		t.Fatalf("got error %v for depth %d", err, MaxDiagnosticInfoDepth)
+	}
+	if err := decode(MaxDiagnosticInfoDepth + 1); err == nil {
+		t.Fatalf("got no error for depth %d", MaxDiagnosticInfoDepth+1)
+	}
+}
+
+func TestDiagnosticInfoResolve(t *testing.T) {
+	table := []string{"BadTypeMismatch", "urn:server", "Value has the wrong type", "Conversion"}
+	tests := []struct {
+		name string
+		d    *DiagnosticInfo
+		want string
+	}{
+		{
+			name: "empty",
+			d:    &DiagnosticInfo{},
+			want: "no diagnostics",
+		},
+		{
+			name: "symbolic id",
+			d: &DiagnosticInfo{
+				EncodingMask: DiagnosticInfoSymbolicID | DiagnosticInfoNamespaceURI,
+				SymbolicID:   0,
+				NamespaceURI: 1,
+			},
+			want: "urn:server#BadTypeMismatch",
+		},
+		{
+			name: "nested",
+			d: &DiagnosticInfo{
+				EncodingMask:   DiagnosticInfoSymbolicID | DiagnosticInfoLocalizedText | DiagnosticInfoAdditionalInfo | DiagnosticInfoInnerDiagnosticInfo,
+				SymbolicID:     0,
+				LocalizedText:  2,
+				AdditionalInfo: "Int32 expected",
+				InnerDiagnosticInfo: &DiagnosticInfo{
+					EncodingMask:    DiagnosticInfoSymbolicID | DiagnosticInfoInnerStatusCode,
+					SymbolicID:      3,
+					InnerStatusCode: StatusBadOutOfRange,
+				},
+			},
+			want: "Value has the wrong type (BadTypeMismatch) Int32 expected: Conversion [" + StatusBadOutOfRange.Error() + "]",
+		},
+		{
+			name: "invalid index",
+			d: &DiagnosticInfo{
+				EncodingMask: DiagnosticInfoSymbolicID,
+				SymbolicID:   7,
+			},
+			want: "#7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.Resolve(table).String(); got != tt.want {
+				t.Fatalf("got %q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticInfoResolveFields(t *testing.T) {
+	table := []string{"BadTypeMismatch", "urn:server", "en-US", "Value has the wrong type"}
+	d := &DiagnosticInfo{
+		EncodingMask:   DiagnosticInfoSymbolicID | DiagnosticInfoNamespaceURI | DiagnosticInfoLocale | DiagnosticInfoLocalizedText | DiagnosticInfoAdditionalInfo | DiagnosticInfoInnerDiagnosticInfo,
+		SymbolicID:     0,
+		NamespaceURI:   1,
+		Locale:         2,
+		LocalizedText:  3,
+		AdditionalInfo: "Int32 expected",
+		InnerDiagnosticInfo: &DiagnosticInfo{
+			EncodingMask:    DiagnosticInfoLocalizedText | DiagnosticInfoInnerStatusCode,
+			LocalizedText:   9,
+			InnerStatusCode: StatusBadOutOfRange,
+		},
+	}
+	want := ResolvedDiagnostic{
+		SymbolicID:     "BadTypeMismatch",
+		NamespaceURI:   "urn:server",
+		Locale:         "en-US",
+		LocalizedText:  "Value has the wrong type",
+		AdditionalInfo: "Int32 expected",
+		Inner: &ResolvedDiagnostic{
+			LocalizedText:   "#9",
+			InnerStatusCode: StatusBadOutOfRange,
+		},
+	}
+	verify.Values(t, "", d.Resolve(table), want)
+}
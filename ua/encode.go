@@ -93,12 +93,28 @@ func encode(val reflect.Value, name string) ([]byte, error) {
 }
 
 func writeStruct(val reflect.Value, name string) ([]byte, error) {
+	fields, err := structFields(val.Type())
+	if err != nil {
+		return nil, err
+	}
+
 	var buf []byte
-	valt := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		ft := valt.Field(i)
-		fname := name + "." + ft.Name
-		b, err := encode(val.Field(i), fname)
+	for _, f := range fields {
+		if !f.present(val) {
+			continue
+		}
+		fname := name + "." + f.name
+
+		var b []byte
+		fv := val.Field(f.index)
+		if f.lengthField >= 0 {
+			if n := intValue(val.Field(f.lengthField)); int64(fv.Len()) != n {
+				return nil, errors.Errorf("%s has %d elements but length field is %d", fname, fv.Len(), n)
+			}
+			b, err = writeElements(fv, fname)
+		} else {
+			b, err = encode(fv, fname)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -120,6 +136,18 @@ func writeSlice(val reflect.Value, name string) ([]byte, error) {
 
 	buf.WriteUint32(uint32(val.Len()))
 
+	b, err := writeElements(val, name)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(b)
+	return buf.Bytes(), buf.Error()
+}
+
+// writeElements encodes the elements of a slice without the length.
+func writeElements(val reflect.Value, name string) ([]byte, error) {
+	buf := NewBuffer(nil)
+
 	// fast path for []byte
 	if val.Type().Elem().Kind() == reflect.Uint8 {
 		// fmt.Println("[]byte fast path")
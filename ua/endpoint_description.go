@@ -0,0 +1,43 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import "strings"
+
+// TransportProfileURIUATCPBinary is the transport profile of endpoints
+// which use the opc.tcp protocol with the binary encoding.
+//
+// Specification: Part 7, 6.6.1
+const TransportProfileURIUATCPBinary = "http://opcfoundation.org/UA-Profile/Transport/uatcp-uasc-uabinary"
+
+// SecurityPolicy returns the short name of the security policy of the
+// endpoint, e.g. "Basic256Sha256".
+func (e *EndpointDescription) SecurityPolicy() string {
+	return strings.TrimPrefix(e.SecurityPolicyURI, SecurityPolicyURIPrefix)
+}
+
+// HasSecurityPolicy returns true if the endpoint uses the security
+// policy which is either a short name like "Basic256Sha256" or a policy
+// URI.
+func (e *EndpointDescription) HasSecurityPolicy(policy string) bool {
+	return e.SecurityPolicyURI == FormatSecurityPolicyURI(policy)
+}
+
+// SecurityModeName returns the short name of the security mode of the
+// endpoint, e.g. "SignAndEncrypt".
+func (e *EndpointDescription) SecurityModeName() string {
+	return strings.TrimPrefix(e.SecurityMode.String(), "MessageSecurityMode")
+}
+
+// HasUserTokenType returns true if the endpoint has a user token policy
+// with the token type.
+func (e *EndpointDescription) HasUserTokenType(typ UserTokenType) bool {
+	for _, t := range e.UserIdentityTokens {
+		if t != nil && t.TokenType == typ {
+			return true
+		}
+	}
+	return false
+}
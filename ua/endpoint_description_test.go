@@ -288,3 +288,30 @@ func TestEndpointDescriptionArray(t *testing.T) {
 	}
 	RunCodecTest(t, cases)
 }
+
+func TestEndpointDescriptionSecurity(t *testing.T) {
+	ep := &EndpointDescription{
+		SecurityMode:      MessageSecurityModeSignAndEncrypt,
+		SecurityPolicyURI: SecurityPolicyURIBasic256Sha256,
+		UserIdentityTokens: []*UserTokenPolicy{
+			{TokenType: UserTokenTypeUserName},
+		},
+	}
+	if got, want := ep.SecurityPolicy(), "Basic256Sha256"; got != want {
+		t.Fatalf("got policy %q want %q", got, want)
+	}
+	if got, want := ep.SecurityModeName(), "SignAndEncrypt"; got != want {
+		t.Fatalf("got mode %q want %q", got, want)
+	}
+	for _, p := range []string{"Basic256Sha256", SecurityPolicyURIBasic256Sha256} {
+		if !ep.HasSecurityPolicy(p) {
+			t.Fatalf("HasSecurityPolicy(%q) = false", p)
+		}
+	}
+	if ep.HasSecurityPolicy("Basic256") {
+		t.Fatal("HasSecurityPolicy(Basic256) = true")
+	}
+	if !ep.HasUserTokenType(UserTokenTypeUserName) || ep.HasUserTokenType(UserTokenTypeAnonymous) {
+		t.Fatal("HasUserTokenType mismatch")
+	}
+}
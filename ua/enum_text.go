@@ -0,0 +1,67 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// OptionSetBit is the name of a bit of an enum which is a bit mask. It is
+// used by the generated code.
+type OptionSetBit struct {
+	Mask uint64
+	Name string
+}
+
+// FormatOptionSet returns the names of the bits which are set in v
+// separated by |, e.g. "CurrentRead|CurrentWrite". Bits without a name
+// are added as a number.
+func FormatOptionSet(v uint64, bits []OptionSetBit) string {
+	if v == 0 {
+		return "0"
+	}
+	var names []string
+	for _, b := range bits {
+		if v&b.Mask != 0 {
+			names = append(names, b.Name)
+			v &^= b.Mask
+		}
+	}
+	if v != 0 {
+		names = append(names, strconv.FormatUint(v, 10))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseEnumText parses the text of an enum value which is a name or a
+// number with the function which returns the value of a name. The names
+// and numbers of the bits of an option set are separated by |. It is
+// used by the generated code.
+func ParseEnumText(s string, optionSet bool, fromString func(string) (uint64, error)) (uint64, error) {
+	parse := func(s string) (uint64, error) {
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n, nil
+		}
+		return fromString(s)
+	}
+	if !optionSet {
+		return parse(s)
+	}
+	if s == "" {
+		return 0, errors.New("empty option set")
+	}
+	var v uint64
+	for _, name := range strings.Split(s, "|") {
+		n, err := parse(strings.TrimSpace(name))
+		if err != nil {
+			return 0, err
+		}
+		v |= n
+	}
+	return v, nil
+}
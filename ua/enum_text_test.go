@@ -0,0 +1,86 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnumString(t *testing.T) {
+	tests := []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{MessageSecurityModeSignAndEncrypt, "SignAndEncrypt"},
+		{MessageSecurityMode(17), "17"},
+		{ServerStateShutdown, "Shutdown"},
+		{NodeClassVariable, "Variable"},
+		{NodeClassUnspecified, "Unspecified"},
+		{NodeClassObject | NodeClassVariable, "Object|Variable"},
+		{NodeClassObject | 0x100, "Object|256"},
+		{AccessLevelTypeCurrentRead | AccessLevelTypeCurrentWrite, "CurrentRead|CurrentWrite"},
+		{AccessLevelTypeNone, "None"},
+	}
+	for _, tt := range tests {
+		if got := tt.v.String(); got != tt.want {
+			t.Errorf("%T(%v): got %q want %q", tt.v, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestEnumFromString(t *testing.T) {
+	for _, s := range []string{"Sign", "sign", "SIGN"} {
+		m, err := MessageSecurityModeFromString(s)
+		if err != nil || m != MessageSecurityModeSign {
+			t.Fatalf("%q: got %v, %v want %v", s, m, err, MessageSecurityModeSign)
+		}
+	}
+	for _, s := range []string{"", "bad", "2"} {
+		if _, err := MessageSecurityModeFromString(s); err == nil {
+			t.Fatalf("%q: got nil want error", s)
+		}
+	}
+}
+
+func TestEnumText(t *testing.T) {
+	type msg struct {
+		Mode   MessageSecurityMode
+		Class  NodeClass
+		Access AccessLevelType
+	}
+	in := msg{
+		Mode:   MessageSecurityModeSign,
+		Class:  NodeClassObject | NodeClassMethod,
+		Access: AccessLevelTypeCurrentRead | 0x80,
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"Mode":"Sign","Class":"Object|Method","Access":"CurrentRead|128"}`; got != want {
+		t.Fatalf("got %s want %s", got, want)
+	}
+
+	var out msg
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Fatalf("got %+v want %+v", out, in)
+	}
+
+	var m MessageSecurityMode
+	if err := m.UnmarshalText([]byte("3")); err != nil || m != MessageSecurityModeSignAndEncrypt {
+		t.Fatalf("got %v, %v want %v", m, err, MessageSecurityModeSignAndEncrypt)
+	}
+	if err := m.UnmarshalText([]byte("Sign|None")); err == nil {
+		t.Fatal("got nil want error for bits of an enum which is not an option set")
+	}
+	var c NodeClass
+	if err := c.UnmarshalText([]byte("object | bogus")); err == nil {
+		t.Fatal("got nil want error for an unknown bit")
+	}
+}
@@ -99,6 +99,11 @@ const (
 	SecurityPolicyURIAes256Sha256RsaPss  = "http://opcfoundation.org/UA/SecurityPolicy#Aes256_Sha256_RsaPss"
 )
 
+// IssuedTokenTypeJWT is the IssuedTokenType of a user token policy for
+// JSON Web Tokens.
+// Specification: Part 6, 6.5.3
+const IssuedTokenTypeJWT = "http://opcfoundation.org/UA/UserToken#JWT"
+
 var SecurityPolicyURIs = map[string]string{
 	"None":                SecurityPolicyURINone,
 	"Basic128Rsa15":       SecurityPolicyURIBasic128Rsa15,
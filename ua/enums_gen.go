@@ -6,27 +6,74 @@
 
 package ua
 
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// NodeIDType describes the possible encodings for a NodeId value.
 type NodeIDType uint8
 
-func NodeIDTypeFromString(s string) NodeIDType {
-	switch s {
-	case "TwoByte":
-		return 0
-	case "FourByte":
-		return 1
-	case "Numeric":
-		return 2
-	case "String":
-		return 3
-	case "Guid":
-		return 4
-	case "ByteString":
-		return 5
+// NodeIDTypeFromString returns the NodeIDType with the case-insensitive name s.
+func NodeIDTypeFromString(s string) (NodeIDType, error) {
+	switch strings.ToLower(s) {
+	case "twobyte":
+		return 0, nil
+	case "fourbyte":
+		return 1, nil
+	case "numeric":
+		return 2, nil
+	case "string":
+		return 3, nil
+	case "guid":
+		return 4, nil
+	case "bytestring":
+		return 5, nil
+	default:
+		return 0, errors.Errorf("invalid NodeIDType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v NodeIDType) String() string {
+	switch v {
+	case 0:
+		return "TwoByte"
+	case 1:
+		return "FourByte"
+	case 2:
+		return "Numeric"
+	case 3:
+		return "String"
+	case 4:
+		return "Guid"
+	case 5:
+		return "ByteString"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v NodeIDType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *NodeIDType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := NodeIDTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = NodeIDType(n)
+	return nil
+}
+
 const (
 	NodeIDTypeTwoByte    NodeIDType = 0
 	NodeIDTypeFourByte   NodeIDType = 1
@@ -38,17 +85,50 @@ const (
 
 type NamingRuleType uint32
 
-func NamingRuleTypeFromString(s string) NamingRuleType {
-	switch s {
-	case "Mandatory":
-		return 1
-	case "Optional":
-		return 2
-	case "Constraint":
-		return 3
+// NamingRuleTypeFromString returns the NamingRuleType with the case-insensitive name s.
+func NamingRuleTypeFromString(s string) (NamingRuleType, error) {
+	switch strings.ToLower(s) {
+	case "mandatory":
+		return 1, nil
+	case "optional":
+		return 2, nil
+	case "constraint":
+		return 3, nil
+	default:
+		return 0, errors.Errorf("invalid NamingRuleType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v NamingRuleType) String() string {
+	switch v {
+	case 1:
+		return "Mandatory"
+	case 2:
+		return "Optional"
+	case 3:
+		return "Constraint"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v NamingRuleType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *NamingRuleType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := NamingRuleTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = NamingRuleType(n)
+	return nil
 }
 
 const (
@@ -59,19 +139,62 @@ const (
 
 type OpenFileMode uint32
 
-func OpenFileModeFromString(s string) OpenFileMode {
-	switch s {
-	case "Read":
-		return 1
-	case "Write":
-		return 2
-	case "EraseExisting":
-		return 4
-	case "Append":
-		return 8
+// OpenFileModeFromString returns the OpenFileMode with the case-insensitive name s.
+func OpenFileModeFromString(s string) (OpenFileMode, error) {
+	switch strings.ToLower(s) {
+	case "read":
+		return 1, nil
+	case "write":
+		return 2, nil
+	case "eraseexisting":
+		return 4, nil
+	case "append":
+		return 8, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid OpenFileMode %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v OpenFileMode) String() string {
+	switch v {
+	case 1:
+		return "Read"
+	case 2:
+		return "Write"
+	case 4:
+		return "EraseExisting"
+	case 8:
+		return "Append"
+	default:
+		return FormatOptionSet(uint64(v), openFileModeBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v OpenFileMode) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *OpenFileMode) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := OpenFileModeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = OpenFileMode(n)
+	return nil
+}
+
+var openFileModeBits = []OptionSetBit{
+	{Mask: 1, Name: "Read"},
+	{Mask: 2, Name: "Write"},
+	{Mask: 4, Name: "EraseExisting"},
+	{Mask: 8, Name: "Append"},
 }
 
 const (
@@ -83,25 +206,64 @@ const (
 
 type IdentityCriteriaType uint32
 
-func IdentityCriteriaTypeFromString(s string) IdentityCriteriaType {
-	switch s {
-	case "UserName":
-		return 1
-	case "Thumbprint":
-		return 2
-	case "Role":
-		return 3
-	case "GroupId":
-		return 4
-	case "Anonymous":
-		return 5
-	case "AuthenticatedUser":
-		return 6
+// IdentityCriteriaTypeFromString returns the IdentityCriteriaType with the case-insensitive name s.
+func IdentityCriteriaTypeFromString(s string) (IdentityCriteriaType, error) {
+	switch strings.ToLower(s) {
+	case "username":
+		return 1, nil
+	case "thumbprint":
+		return 2, nil
+	case "role":
+		return 3, nil
+	case "groupid":
+		return 4, nil
+	case "anonymous":
+		return 5, nil
+	case "authenticateduser":
+		return 6, nil
+	default:
+		return 0, errors.Errorf("invalid IdentityCriteriaType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v IdentityCriteriaType) String() string {
+	switch v {
+	case 1:
+		return "UserName"
+	case 2:
+		return "Thumbprint"
+	case 3:
+		return "Role"
+	case 4:
+		return "GroupId"
+	case 5:
+		return "Anonymous"
+	case 6:
+		return "AuthenticatedUser"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v IdentityCriteriaType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *IdentityCriteriaType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := IdentityCriteriaTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = IdentityCriteriaType(n)
+	return nil
+}
+
 const (
 	IdentityCriteriaTypeUserName          IdentityCriteriaType = 1
 	IdentityCriteriaTypeThumbprint        IdentityCriteriaType = 2
@@ -113,23 +275,70 @@ const (
 
 type TrustListMasks uint32
 
-func TrustListMasksFromString(s string) TrustListMasks {
-	switch s {
-	case "None":
-		return 0
-	case "TrustedCertificates":
-		return 1
-	case "TrustedCrls":
-		return 2
-	case "IssuerCertificates":
-		return 4
-	case "IssuerCrls":
-		return 8
-	case "All":
-		return 15
+// TrustListMasksFromString returns the TrustListMasks with the case-insensitive name s.
+func TrustListMasksFromString(s string) (TrustListMasks, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "trustedcertificates":
+		return 1, nil
+	case "trustedcrls":
+		return 2, nil
+	case "issuercertificates":
+		return 4, nil
+	case "issuercrls":
+		return 8, nil
+	case "all":
+		return 15, nil
+	default:
+		return 0, errors.Errorf("invalid TrustListMasks %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v TrustListMasks) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "TrustedCertificates"
+	case 2:
+		return "TrustedCrls"
+	case 4:
+		return "IssuerCertificates"
+	case 8:
+		return "IssuerCrls"
+	case 15:
+		return "All"
 	default:
-		return 0
+		return FormatOptionSet(uint64(v), trustListMasksBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v TrustListMasks) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *TrustListMasks) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := TrustListMasksFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = TrustListMasks(n)
+	return nil
+}
+
+var trustListMasksBits = []OptionSetBit{
+	{Mask: 1, Name: "TrustedCertificates"},
+	{Mask: 2, Name: "TrustedCrls"},
+	{Mask: 4, Name: "IssuerCertificates"},
+	{Mask: 8, Name: "IssuerCrls"},
 }
 
 const (
@@ -143,21 +352,56 @@ const (
 
 type PubSubState uint32
 
-func PubSubStateFromString(s string) PubSubState {
-	switch s {
-	case "Disabled":
-		return 0
-	case "Paused":
-		return 1
-	case "Operational":
-		return 2
-	case "Error":
-		return 3
+// PubSubStateFromString returns the PubSubState with the case-insensitive name s.
+func PubSubStateFromString(s string) (PubSubState, error) {
+	switch strings.ToLower(s) {
+	case "disabled":
+		return 0, nil
+	case "paused":
+		return 1, nil
+	case "operational":
+		return 2, nil
+	case "error":
+		return 3, nil
+	default:
+		return 0, errors.Errorf("invalid PubSubState %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v PubSubState) String() string {
+	switch v {
+	case 0:
+		return "Disabled"
+	case 1:
+		return "Paused"
+	case 2:
+		return "Operational"
+	case 3:
+		return "Error"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v PubSubState) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *PubSubState) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := PubSubStateFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = PubSubState(n)
+	return nil
+}
+
 const (
 	PubSubStateDisabled    PubSubState = 0
 	PubSubStatePaused      PubSubState = 1
@@ -167,15 +411,51 @@ const (
 
 type DataSetFieldFlags uint16
 
-func DataSetFieldFlagsFromString(s string) DataSetFieldFlags {
-	switch s {
-	case "None":
-		return 0
-	case "PromotedField":
-		return 1
+// DataSetFieldFlagsFromString returns the DataSetFieldFlags with the case-insensitive name s.
+func DataSetFieldFlagsFromString(s string) (DataSetFieldFlags, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "promotedfield":
+		return 1, nil
+	default:
+		return 0, errors.Errorf("invalid DataSetFieldFlags %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v DataSetFieldFlags) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "PromotedField"
 	default:
-		return 0
+		return FormatOptionSet(uint64(v), dataSetFieldFlagsBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DataSetFieldFlags) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DataSetFieldFlags) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := DataSetFieldFlagsFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = DataSetFieldFlags(n)
+	return nil
+}
+
+var dataSetFieldFlagsBits = []OptionSetBit{
+	{Mask: 1, Name: "PromotedField"},
 }
 
 const (
@@ -185,25 +465,76 @@ const (
 
 type DataSetFieldContentMask uint32
 
-func DataSetFieldContentMaskFromString(s string) DataSetFieldContentMask {
-	switch s {
-	case "None":
-		return 0
-	case "StatusCode":
-		return 1
-	case "SourceTimestamp":
-		return 2
-	case "ServerTimestamp":
-		return 4
-	case "SourcePicoSeconds":
-		return 8
-	case "ServerPicoSeconds":
-		return 16
-	case "RawData":
-		return 32
+// DataSetFieldContentMaskFromString returns the DataSetFieldContentMask with the case-insensitive name s.
+func DataSetFieldContentMaskFromString(s string) (DataSetFieldContentMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "statuscode":
+		return 1, nil
+	case "sourcetimestamp":
+		return 2, nil
+	case "servertimestamp":
+		return 4, nil
+	case "sourcepicoseconds":
+		return 8, nil
+	case "serverpicoseconds":
+		return 16, nil
+	case "rawdata":
+		return 32, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid DataSetFieldContentMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v DataSetFieldContentMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "StatusCode"
+	case 2:
+		return "SourceTimestamp"
+	case 4:
+		return "ServerTimestamp"
+	case 8:
+		return "SourcePicoSeconds"
+	case 16:
+		return "ServerPicoSeconds"
+	case 32:
+		return "RawData"
+	default:
+		return FormatOptionSet(uint64(v), dataSetFieldContentMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DataSetFieldContentMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DataSetFieldContentMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := DataSetFieldContentMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = DataSetFieldContentMask(n)
+	return nil
+}
+
+var dataSetFieldContentMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "StatusCode"},
+	{Mask: 2, Name: "SourceTimestamp"},
+	{Mask: 4, Name: "ServerTimestamp"},
+	{Mask: 8, Name: "SourcePicoSeconds"},
+	{Mask: 16, Name: "ServerPicoSeconds"},
+	{Mask: 32, Name: "RawData"},
 }
 
 const (
@@ -218,19 +549,52 @@ const (
 
 type OverrideValueHandling uint32
 
-func OverrideValueHandlingFromString(s string) OverrideValueHandling {
-	switch s {
-	case "Disabled":
-		return 0
-	case "LastUsableValue":
-		return 1
-	case "OverrideValue":
-		return 2
+// OverrideValueHandlingFromString returns the OverrideValueHandling with the case-insensitive name s.
+func OverrideValueHandlingFromString(s string) (OverrideValueHandling, error) {
+	switch strings.ToLower(s) {
+	case "disabled":
+		return 0, nil
+	case "lastusablevalue":
+		return 1, nil
+	case "overridevalue":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid OverrideValueHandling %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v OverrideValueHandling) String() string {
+	switch v {
+	case 0:
+		return "Disabled"
+	case 1:
+		return "LastUsableValue"
+	case 2:
+		return "OverrideValue"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v OverrideValueHandling) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *OverrideValueHandling) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := OverrideValueHandlingFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = OverrideValueHandling(n)
+	return nil
+}
+
 const (
 	OverrideValueHandlingDisabled        OverrideValueHandling = 0
 	OverrideValueHandlingLastUsableValue OverrideValueHandling = 1
@@ -239,19 +603,52 @@ const (
 
 type DataSetOrderingType uint32
 
-func DataSetOrderingTypeFromString(s string) DataSetOrderingType {
-	switch s {
-	case "Undefined":
-		return 0
-	case "AscendingWriterId":
-		return 1
-	case "AscendingWriterIdSingle":
-		return 2
+// DataSetOrderingTypeFromString returns the DataSetOrderingType with the case-insensitive name s.
+func DataSetOrderingTypeFromString(s string) (DataSetOrderingType, error) {
+	switch strings.ToLower(s) {
+	case "undefined":
+		return 0, nil
+	case "ascendingwriterid":
+		return 1, nil
+	case "ascendingwriteridsingle":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid DataSetOrderingType %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v DataSetOrderingType) String() string {
+	switch v {
+	case 0:
+		return "Undefined"
+	case 1:
+		return "AscendingWriterId"
+	case 2:
+		return "AscendingWriterIdSingle"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DataSetOrderingType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DataSetOrderingType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := DataSetOrderingTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = DataSetOrderingType(n)
+	return nil
+}
+
 const (
 	DataSetOrderingTypeUndefined               DataSetOrderingType = 0
 	DataSetOrderingTypeAscendingWriterID       DataSetOrderingType = 1
@@ -260,35 +657,101 @@ const (
 
 type UADPNetworkMessageContentMask uint32
 
-func UADPNetworkMessageContentMaskFromString(s string) UADPNetworkMessageContentMask {
-	switch s {
-	case "None":
-		return 0
-	case "PublisherId":
-		return 1
-	case "GroupHeader":
-		return 2
-	case "WriterGroupId":
-		return 4
-	case "GroupVersion":
-		return 8
-	case "NetworkMessageNumber":
-		return 16
-	case "SequenceNumber":
-		return 32
-	case "PayloadHeader":
-		return 64
-	case "Timestamp":
-		return 128
-	case "PicoSeconds":
-		return 256
-	case "DataSetClassId":
-		return 512
-	case "PromotedFields":
-		return 1024
-	default:
-		return 0
+// UADPNetworkMessageContentMaskFromString returns the UADPNetworkMessageContentMask with the case-insensitive name s.
+func UADPNetworkMessageContentMaskFromString(s string) (UADPNetworkMessageContentMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "publisherid":
+		return 1, nil
+	case "groupheader":
+		return 2, nil
+	case "writergroupid":
+		return 4, nil
+	case "groupversion":
+		return 8, nil
+	case "networkmessagenumber":
+		return 16, nil
+	case "sequencenumber":
+		return 32, nil
+	case "payloadheader":
+		return 64, nil
+	case "timestamp":
+		return 128, nil
+	case "picoseconds":
+		return 256, nil
+	case "datasetclassid":
+		return 512, nil
+	case "promotedfields":
+		return 1024, nil
+	default:
+		return 0, errors.Errorf("invalid UADPNetworkMessageContentMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v UADPNetworkMessageContentMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "PublisherId"
+	case 2:
+		return "GroupHeader"
+	case 4:
+		return "WriterGroupId"
+	case 8:
+		return "GroupVersion"
+	case 16:
+		return "NetworkMessageNumber"
+	case 32:
+		return "SequenceNumber"
+	case 64:
+		return "PayloadHeader"
+	case 128:
+		return "Timestamp"
+	case 256:
+		return "PicoSeconds"
+	case 512:
+		return "DataSetClassId"
+	case 1024:
+		return "PromotedFields"
+	default:
+		return FormatOptionSet(uint64(v), uADPNetworkMessageContentMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v UADPNetworkMessageContentMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *UADPNetworkMessageContentMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := UADPNetworkMessageContentMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = UADPNetworkMessageContentMask(n)
+	return nil
+}
+
+var uADPNetworkMessageContentMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "PublisherId"},
+	{Mask: 2, Name: "GroupHeader"},
+	{Mask: 4, Name: "WriterGroupId"},
+	{Mask: 8, Name: "GroupVersion"},
+	{Mask: 16, Name: "NetworkMessageNumber"},
+	{Mask: 32, Name: "SequenceNumber"},
+	{Mask: 64, Name: "PayloadHeader"},
+	{Mask: 128, Name: "Timestamp"},
+	{Mask: 256, Name: "PicoSeconds"},
+	{Mask: 512, Name: "DataSetClassId"},
+	{Mask: 1024, Name: "PromotedFields"},
 }
 
 const (
@@ -308,25 +771,76 @@ const (
 
 type UADPDataSetMessageContentMask uint32
 
-func UADPDataSetMessageContentMaskFromString(s string) UADPDataSetMessageContentMask {
-	switch s {
-	case "None":
-		return 0
-	case "Timestamp":
-		return 1
-	case "PicoSeconds":
-		return 2
-	case "Status":
-		return 4
-	case "MajorVersion":
-		return 8
-	case "MinorVersion":
-		return 16
-	case "SequenceNumber":
-		return 32
+// UADPDataSetMessageContentMaskFromString returns the UADPDataSetMessageContentMask with the case-insensitive name s.
+func UADPDataSetMessageContentMaskFromString(s string) (UADPDataSetMessageContentMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "timestamp":
+		return 1, nil
+	case "picoseconds":
+		return 2, nil
+	case "status":
+		return 4, nil
+	case "majorversion":
+		return 8, nil
+	case "minorversion":
+		return 16, nil
+	case "sequencenumber":
+		return 32, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid UADPDataSetMessageContentMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v UADPDataSetMessageContentMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "Timestamp"
+	case 2:
+		return "PicoSeconds"
+	case 4:
+		return "Status"
+	case 8:
+		return "MajorVersion"
+	case 16:
+		return "MinorVersion"
+	case 32:
+		return "SequenceNumber"
+	default:
+		return FormatOptionSet(uint64(v), uADPDataSetMessageContentMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v UADPDataSetMessageContentMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *UADPDataSetMessageContentMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := UADPDataSetMessageContentMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = UADPDataSetMessageContentMask(n)
+	return nil
+}
+
+var uADPDataSetMessageContentMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "Timestamp"},
+	{Mask: 2, Name: "PicoSeconds"},
+	{Mask: 4, Name: "Status"},
+	{Mask: 8, Name: "MajorVersion"},
+	{Mask: 16, Name: "MinorVersion"},
+	{Mask: 32, Name: "SequenceNumber"},
 }
 
 const (
@@ -341,25 +855,76 @@ const (
 
 type JSONNetworkMessageContentMask uint32
 
-func JSONNetworkMessageContentMaskFromString(s string) JSONNetworkMessageContentMask {
-	switch s {
-	case "None":
-		return 0
-	case "NetworkMessageHeader":
-		return 1
-	case "DataSetMessageHeader":
-		return 2
-	case "SingleDataSetMessage":
-		return 4
-	case "PublisherId":
-		return 8
-	case "DataSetClassId":
-		return 16
-	case "ReplyTo":
-		return 32
+// JSONNetworkMessageContentMaskFromString returns the JSONNetworkMessageContentMask with the case-insensitive name s.
+func JSONNetworkMessageContentMaskFromString(s string) (JSONNetworkMessageContentMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "networkmessageheader":
+		return 1, nil
+	case "datasetmessageheader":
+		return 2, nil
+	case "singledatasetmessage":
+		return 4, nil
+	case "publisherid":
+		return 8, nil
+	case "datasetclassid":
+		return 16, nil
+	case "replyto":
+		return 32, nil
+	default:
+		return 0, errors.Errorf("invalid JSONNetworkMessageContentMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v JSONNetworkMessageContentMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "NetworkMessageHeader"
+	case 2:
+		return "DataSetMessageHeader"
+	case 4:
+		return "SingleDataSetMessage"
+	case 8:
+		return "PublisherId"
+	case 16:
+		return "DataSetClassId"
+	case 32:
+		return "ReplyTo"
 	default:
-		return 0
+		return FormatOptionSet(uint64(v), jSONNetworkMessageContentMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v JSONNetworkMessageContentMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *JSONNetworkMessageContentMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := JSONNetworkMessageContentMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = JSONNetworkMessageContentMask(n)
+	return nil
+}
+
+var jSONNetworkMessageContentMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "NetworkMessageHeader"},
+	{Mask: 2, Name: "DataSetMessageHeader"},
+	{Mask: 4, Name: "SingleDataSetMessage"},
+	{Mask: 8, Name: "PublisherId"},
+	{Mask: 16, Name: "DataSetClassId"},
+	{Mask: 32, Name: "ReplyTo"},
 }
 
 const (
@@ -374,25 +939,73 @@ const (
 
 type JSONDataSetMessageContentMask uint32
 
-func JSONDataSetMessageContentMaskFromString(s string) JSONDataSetMessageContentMask {
-	switch s {
-	case "None":
-		return 0
-	case "DataSetWriterId":
-		return 1
-	case "MetaDataVersion":
-		return 2
-	case "SequenceNumber":
-		return 4
-	case "Timestamp":
-		return 8
-	case "Status":
-		return 16
+// JSONDataSetMessageContentMaskFromString returns the JSONDataSetMessageContentMask with the case-insensitive name s.
+func JSONDataSetMessageContentMaskFromString(s string) (JSONDataSetMessageContentMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "datasetwriterid":
+		return 1, nil
+	case "metadataversion":
+		return 2, nil
+	case "sequencenumber":
+		return 4, nil
+	case "timestamp":
+		return 8, nil
+	case "status":
+		return 16, nil
+	default:
+		return 0, errors.Errorf("invalid JSONDataSetMessageContentMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v JSONDataSetMessageContentMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "DataSetWriterId"
+	case 2:
+		return "MetaDataVersion"
+	case 4:
+		return "SequenceNumber"
+	case 8:
+		return "Timestamp"
+	case 16:
+		return "Status"
 	default:
-		return 0
+		return FormatOptionSet(uint64(v), jSONDataSetMessageContentMaskBits)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v JSONDataSetMessageContentMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *JSONDataSetMessageContentMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := JSONDataSetMessageContentMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = JSONDataSetMessageContentMask(n)
+	return nil
+}
+
+var jSONDataSetMessageContentMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "DataSetWriterId"},
+	{Mask: 2, Name: "MetaDataVersion"},
+	{Mask: 4, Name: "SequenceNumber"},
+	{Mask: 8, Name: "Timestamp"},
+	{Mask: 16, Name: "Status"},
+}
+
 const (
 	JSONDataSetMessageContentMaskNone            JSONDataSetMessageContentMask = 0
 	JSONDataSetMessageContentMaskDataSetWriterID JSONDataSetMessageContentMask = 1
@@ -404,21 +1017,58 @@ const (
 
 type BrokerTransportQoS uint32
 
-func BrokerTransportQoSFromString(s string) BrokerTransportQoS {
-	switch s {
-	case "NotSpecified":
-		return 0
-	case "BestEffort":
-		return 1
-	case "AtLeastOnce":
-		return 2
-	case "AtMostOnce":
-		return 3
-	case "ExactlyOnce":
-		return 4
+// BrokerTransportQoSFromString returns the BrokerTransportQoS with the case-insensitive name s.
+func BrokerTransportQoSFromString(s string) (BrokerTransportQoS, error) {
+	switch strings.ToLower(s) {
+	case "notspecified":
+		return 0, nil
+	case "besteffort":
+		return 1, nil
+	case "atleastonce":
+		return 2, nil
+	case "atmostonce":
+		return 3, nil
+	case "exactlyonce":
+		return 4, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid BrokerTransportQoS %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v BrokerTransportQoS) String() string {
+	switch v {
+	case 0:
+		return "NotSpecified"
+	case 1:
+		return "BestEffort"
+	case 2:
+		return "AtLeastOnce"
+	case 3:
+		return "AtMostOnce"
+	case 4:
+		return "ExactlyOnce"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v BrokerTransportQoS) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *BrokerTransportQoS) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := BrokerTransportQoSFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = BrokerTransportQoS(n)
+	return nil
 }
 
 const (
@@ -431,21 +1081,58 @@ const (
 
 type DiagnosticsLevel uint32
 
-func DiagnosticsLevelFromString(s string) DiagnosticsLevel {
-	switch s {
-	case "Basic":
-		return 0
-	case "Advanced":
-		return 1
-	case "Info":
-		return 2
-	case "Log":
-		return 3
-	case "Debug":
-		return 4
+// DiagnosticsLevelFromString returns the DiagnosticsLevel with the case-insensitive name s.
+func DiagnosticsLevelFromString(s string) (DiagnosticsLevel, error) {
+	switch strings.ToLower(s) {
+	case "basic":
+		return 0, nil
+	case "advanced":
+		return 1, nil
+	case "info":
+		return 2, nil
+	case "log":
+		return 3, nil
+	case "debug":
+		return 4, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid DiagnosticsLevel %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v DiagnosticsLevel) String() string {
+	switch v {
+	case 0:
+		return "Basic"
+	case 1:
+		return "Advanced"
+	case 2:
+		return "Info"
+	case 3:
+		return "Log"
+	case 4:
+		return "Debug"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DiagnosticsLevel) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DiagnosticsLevel) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := DiagnosticsLevelFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = DiagnosticsLevel(n)
+	return nil
 }
 
 const (
@@ -458,37 +1145,104 @@ const (
 
 type PubSubDiagnosticsCounterClassification uint32
 
-func PubSubDiagnosticsCounterClassificationFromString(s string) PubSubDiagnosticsCounterClassification {
-	switch s {
-	case "Information":
-		return 0
-	case "Error":
-		return 1
+// PubSubDiagnosticsCounterClassificationFromString returns the PubSubDiagnosticsCounterClassification with the case-insensitive name s.
+func PubSubDiagnosticsCounterClassificationFromString(s string) (PubSubDiagnosticsCounterClassification, error) {
+	switch strings.ToLower(s) {
+	case "information":
+		return 0, nil
+	case "error":
+		return 1, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid PubSubDiagnosticsCounterClassification %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v PubSubDiagnosticsCounterClassification) String() string {
+	switch v {
+	case 0:
+		return "Information"
+	case 1:
+		return "Error"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v PubSubDiagnosticsCounterClassification) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *PubSubDiagnosticsCounterClassification) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := PubSubDiagnosticsCounterClassificationFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = PubSubDiagnosticsCounterClassification(n)
+	return nil
+}
+
 const (
 	PubSubDiagnosticsCounterClassificationInformation PubSubDiagnosticsCounterClassification = 0
 	PubSubDiagnosticsCounterClassificationError       PubSubDiagnosticsCounterClassification = 1
 )
 
+// IDType describes the type of identifier used in a node id.
 type IDType uint32
 
-func IDTypeFromString(s string) IDType {
-	switch s {
-	case "Numeric":
-		return 0
-	case "String":
-		return 1
-	case "Guid":
-		return 2
-	case "Opaque":
-		return 3
+// IDTypeFromString returns the IDType with the case-insensitive name s.
+func IDTypeFromString(s string) (IDType, error) {
+	switch strings.ToLower(s) {
+	case "numeric":
+		return 0, nil
+	case "string":
+		return 1, nil
+	case "guid":
+		return 2, nil
+	case "opaque":
+		return 3, nil
+	default:
+		return 0, errors.Errorf("invalid IDType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v IDType) String() string {
+	switch v {
+	case 0:
+		return "Numeric"
+	case 1:
+		return "String"
+	case 2:
+		return "Guid"
+	case 3:
+		return "Opaque"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v IDType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *IDType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := IDTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = IDType(n)
+	return nil
 }
 
 const (
@@ -498,31 +1252,89 @@ const (
 	IDTypeOpaque  IDType = 3
 )
 
+// NodeClass is a mask specifying the class of the node.
 type NodeClass uint32
 
-func NodeClassFromString(s string) NodeClass {
-	switch s {
-	case "Unspecified":
-		return 0
-	case "Object":
-		return 1
-	case "Variable":
-		return 2
-	case "Method":
-		return 4
-	case "ObjectType":
-		return 8
-	case "VariableType":
-		return 16
-	case "ReferenceType":
-		return 32
-	case "DataType":
-		return 64
-	case "View":
-		return 128
-	default:
-		return 0
+// NodeClassFromString returns the NodeClass with the case-insensitive name s.
+func NodeClassFromString(s string) (NodeClass, error) {
+	switch strings.ToLower(s) {
+	case "unspecified":
+		return 0, nil
+	case "object":
+		return 1, nil
+	case "variable":
+		return 2, nil
+	case "method":
+		return 4, nil
+	case "objecttype":
+		return 8, nil
+	case "variabletype":
+		return 16, nil
+	case "referencetype":
+		return 32, nil
+	case "datatype":
+		return 64, nil
+	case "view":
+		return 128, nil
+	default:
+		return 0, errors.Errorf("invalid NodeClass %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v NodeClass) String() string {
+	switch v {
+	case 0:
+		return "Unspecified"
+	case 1:
+		return "Object"
+	case 2:
+		return "Variable"
+	case 4:
+		return "Method"
+	case 8:
+		return "ObjectType"
+	case 16:
+		return "VariableType"
+	case 32:
+		return "ReferenceType"
+	case 64:
+		return "DataType"
+	case 128:
+		return "View"
+	default:
+		return FormatOptionSet(uint64(v), nodeClassBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v NodeClass) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *NodeClass) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := NodeClassFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = NodeClass(n)
+	return nil
+}
+
+var nodeClassBits = []OptionSetBit{
+	{Mask: 1, Name: "Object"},
+	{Mask: 2, Name: "Variable"},
+	{Mask: 4, Name: "Method"},
+	{Mask: 8, Name: "ObjectType"},
+	{Mask: 16, Name: "VariableType"},
+	{Mask: 32, Name: "ReferenceType"},
+	{Mask: 64, Name: "DataType"},
+	{Mask: 128, Name: "View"},
 }
 
 const (
@@ -539,49 +1351,133 @@ const (
 
 type PermissionType uint32
 
-func PermissionTypeFromString(s string) PermissionType {
-	switch s {
-	case "None":
-		return 0
-	case "Browse":
-		return 1
-	case "ReadRolePermissions":
-		return 2
-	case "WriteAttribute":
-		return 4
-	case "WriteRolePermissions":
-		return 8
-	case "WriteHistorizing":
-		return 16
-	case "Read":
-		return 32
-	case "Write":
-		return 64
-	case "ReadHistory":
-		return 128
-	case "InsertHistory":
-		return 256
-	case "ModifyHistory":
-		return 512
-	case "DeleteHistory":
-		return 1024
-	case "ReceiveEvents":
-		return 2048
-	case "Call":
-		return 4096
-	case "AddReference":
-		return 8192
-	case "RemoveReference":
-		return 16384
-	case "DeleteNode":
-		return 32768
-	case "AddNode":
-		return 65536
-	default:
-		return 0
+// PermissionTypeFromString returns the PermissionType with the case-insensitive name s.
+func PermissionTypeFromString(s string) (PermissionType, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "browse":
+		return 1, nil
+	case "readrolepermissions":
+		return 2, nil
+	case "writeattribute":
+		return 4, nil
+	case "writerolepermissions":
+		return 8, nil
+	case "writehistorizing":
+		return 16, nil
+	case "read":
+		return 32, nil
+	case "write":
+		return 64, nil
+	case "readhistory":
+		return 128, nil
+	case "inserthistory":
+		return 256, nil
+	case "modifyhistory":
+		return 512, nil
+	case "deletehistory":
+		return 1024, nil
+	case "receiveevents":
+		return 2048, nil
+	case "call":
+		return 4096, nil
+	case "addreference":
+		return 8192, nil
+	case "removereference":
+		return 16384, nil
+	case "deletenode":
+		return 32768, nil
+	case "addnode":
+		return 65536, nil
+	default:
+		return 0, errors.Errorf("invalid PermissionType %q", s)
 	}
 }
 
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v PermissionType) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "Browse"
+	case 2:
+		return "ReadRolePermissions"
+	case 4:
+		return "WriteAttribute"
+	case 8:
+		return "WriteRolePermissions"
+	case 16:
+		return "WriteHistorizing"
+	case 32:
+		return "Read"
+	case 64:
+		return "Write"
+	case 128:
+		return "ReadHistory"
+	case 256:
+		return "InsertHistory"
+	case 512:
+		return "ModifyHistory"
+	case 1024:
+		return "DeleteHistory"
+	case 2048:
+		return "ReceiveEvents"
+	case 4096:
+		return "Call"
+	case 8192:
+		return "AddReference"
+	case 16384:
+		return "RemoveReference"
+	case 32768:
+		return "DeleteNode"
+	case 65536:
+		return "AddNode"
+	default:
+		return FormatOptionSet(uint64(v), permissionTypeBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v PermissionType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *PermissionType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := PermissionTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = PermissionType(n)
+	return nil
+}
+
+var permissionTypeBits = []OptionSetBit{
+	{Mask: 1, Name: "Browse"},
+	{Mask: 2, Name: "ReadRolePermissions"},
+	{Mask: 4, Name: "WriteAttribute"},
+	{Mask: 8, Name: "WriteRolePermissions"},
+	{Mask: 16, Name: "WriteHistorizing"},
+	{Mask: 32, Name: "Read"},
+	{Mask: 64, Name: "Write"},
+	{Mask: 128, Name: "ReadHistory"},
+	{Mask: 256, Name: "InsertHistory"},
+	{Mask: 512, Name: "ModifyHistory"},
+	{Mask: 1024, Name: "DeleteHistory"},
+	{Mask: 2048, Name: "ReceiveEvents"},
+	{Mask: 4096, Name: "Call"},
+	{Mask: 8192, Name: "AddReference"},
+	{Mask: 16384, Name: "RemoveReference"},
+	{Mask: 32768, Name: "DeleteNode"},
+	{Mask: 65536, Name: "AddNode"},
+}
+
 const (
 	PermissionTypeNone                 PermissionType = 0
 	PermissionTypeBrowse               PermissionType = 1
@@ -605,29 +1501,83 @@ const (
 
 type AccessLevelType uint8
 
-func AccessLevelTypeFromString(s string) AccessLevelType {
-	switch s {
-	case "None":
-		return 0
-	case "CurrentRead":
-		return 1
-	case "CurrentWrite":
-		return 2
-	case "HistoryRead":
-		return 4
-	case "HistoryWrite":
-		return 8
-	case "SemanticChange":
-		return 16
-	case "StatusWrite":
-		return 32
-	case "TimestampWrite":
-		return 64
-	default:
-		return 0
+// AccessLevelTypeFromString returns the AccessLevelType with the case-insensitive name s.
+func AccessLevelTypeFromString(s string) (AccessLevelType, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "currentread":
+		return 1, nil
+	case "currentwrite":
+		return 2, nil
+	case "historyread":
+		return 4, nil
+	case "historywrite":
+		return 8, nil
+	case "semanticchange":
+		return 16, nil
+	case "statuswrite":
+		return 32, nil
+	case "timestampwrite":
+		return 64, nil
+	default:
+		return 0, errors.Errorf("invalid AccessLevelType %q", s)
 	}
 }
 
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v AccessLevelType) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "CurrentRead"
+	case 2:
+		return "CurrentWrite"
+	case 4:
+		return "HistoryRead"
+	case 8:
+		return "HistoryWrite"
+	case 16:
+		return "SemanticChange"
+	case 32:
+		return "StatusWrite"
+	case 64:
+		return "TimestampWrite"
+	default:
+		return FormatOptionSet(uint64(v), accessLevelTypeBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v AccessLevelType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *AccessLevelType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := AccessLevelTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = AccessLevelType(n)
+	return nil
+}
+
+var accessLevelTypeBits = []OptionSetBit{
+	{Mask: 1, Name: "CurrentRead"},
+	{Mask: 2, Name: "CurrentWrite"},
+	{Mask: 4, Name: "HistoryRead"},
+	{Mask: 8, Name: "HistoryWrite"},
+	{Mask: 16, Name: "SemanticChange"},
+	{Mask: 32, Name: "StatusWrite"},
+	{Mask: 64, Name: "TimestampWrite"},
+}
+
 const (
 	AccessLevelTypeNone           AccessLevelType = 0
 	AccessLevelTypeCurrentRead    AccessLevelType = 1
@@ -641,35 +1591,98 @@ const (
 
 type AccessLevelExType uint32
 
-func AccessLevelExTypeFromString(s string) AccessLevelExType {
-	switch s {
-	case "None":
-		return 0
-	case "CurrentRead":
-		return 1
-	case "CurrentWrite":
-		return 2
-	case "HistoryRead":
-		return 4
-	case "HistoryWrite":
-		return 8
-	case "SemanticChange":
-		return 16
-	case "StatusWrite":
-		return 32
-	case "TimestampWrite":
-		return 64
-	case "NonatomicRead":
-		return 256
-	case "NonatomicWrite":
-		return 512
-	case "WriteFullArrayOnly":
-		return 1024
-	default:
-		return 0
+// AccessLevelExTypeFromString returns the AccessLevelExType with the case-insensitive name s.
+func AccessLevelExTypeFromString(s string) (AccessLevelExType, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "currentread":
+		return 1, nil
+	case "currentwrite":
+		return 2, nil
+	case "historyread":
+		return 4, nil
+	case "historywrite":
+		return 8, nil
+	case "semanticchange":
+		return 16, nil
+	case "statuswrite":
+		return 32, nil
+	case "timestampwrite":
+		return 64, nil
+	case "nonatomicread":
+		return 256, nil
+	case "nonatomicwrite":
+		return 512, nil
+	case "writefullarrayonly":
+		return 1024, nil
+	default:
+		return 0, errors.Errorf("invalid AccessLevelExType %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v AccessLevelExType) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "CurrentRead"
+	case 2:
+		return "CurrentWrite"
+	case 4:
+		return "HistoryRead"
+	case 8:
+		return "HistoryWrite"
+	case 16:
+		return "SemanticChange"
+	case 32:
+		return "StatusWrite"
+	case 64:
+		return "TimestampWrite"
+	case 256:
+		return "NonatomicRead"
+	case 512:
+		return "NonatomicWrite"
+	case 1024:
+		return "WriteFullArrayOnly"
+	default:
+		return FormatOptionSet(uint64(v), accessLevelExTypeBits)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v AccessLevelExType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *AccessLevelExType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := AccessLevelExTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = AccessLevelExType(n)
+	return nil
+}
+
+var accessLevelExTypeBits = []OptionSetBit{
+	{Mask: 1, Name: "CurrentRead"},
+	{Mask: 2, Name: "CurrentWrite"},
+	{Mask: 4, Name: "HistoryRead"},
+	{Mask: 8, Name: "HistoryWrite"},
+	{Mask: 16, Name: "SemanticChange"},
+	{Mask: 32, Name: "StatusWrite"},
+	{Mask: 64, Name: "TimestampWrite"},
+	{Mask: 256, Name: "NonatomicRead"},
+	{Mask: 512, Name: "NonatomicWrite"},
+	{Mask: 1024, Name: "WriteFullArrayOnly"},
+}
+
 const (
 	AccessLevelExTypeNone               AccessLevelExType = 0
 	AccessLevelExTypeCurrentRead        AccessLevelExType = 1
@@ -686,19 +1699,61 @@ const (
 
 type EventNotifierType uint8
 
-func EventNotifierTypeFromString(s string) EventNotifierType {
-	switch s {
-	case "None":
-		return 0
-	case "SubscribeToEvents":
-		return 1
-	case "HistoryRead":
-		return 4
-	case "HistoryWrite":
-		return 8
+// EventNotifierTypeFromString returns the EventNotifierType with the case-insensitive name s.
+func EventNotifierTypeFromString(s string) (EventNotifierType, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "subscribetoevents":
+		return 1, nil
+	case "historyread":
+		return 4, nil
+	case "historywrite":
+		return 8, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid EventNotifierType %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v EventNotifierType) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "SubscribeToEvents"
+	case 4:
+		return "HistoryRead"
+	case 8:
+		return "HistoryWrite"
+	default:
+		return FormatOptionSet(uint64(v), eventNotifierTypeBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v EventNotifierType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *EventNotifierType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := EventNotifierTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = EventNotifierType(n)
+	return nil
+}
+
+var eventNotifierTypeBits = []OptionSetBit{
+	{Mask: 1, Name: "SubscribeToEvents"},
+	{Mask: 4, Name: "HistoryRead"},
+	{Mask: 8, Name: "HistoryWrite"},
 }
 
 const (
@@ -710,17 +1765,50 @@ const (
 
 type StructureType uint32
 
-func StructureTypeFromString(s string) StructureType {
-	switch s {
-	case "Structure":
-		return 0
-	case "StructureWithOptionalFields":
-		return 1
-	case "Union":
-		return 2
+// StructureTypeFromString returns the StructureType with the case-insensitive name s.
+func StructureTypeFromString(s string) (StructureType, error) {
+	switch strings.ToLower(s) {
+	case "structure":
+		return 0, nil
+	case "structurewithoptionalfields":
+		return 1, nil
+	case "union":
+		return 2, nil
+	default:
+		return 0, errors.Errorf("invalid StructureType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v StructureType) String() string {
+	switch v {
+	case 0:
+		return "Structure"
+	case 1:
+		return "StructureWithOptionalFields"
+	case 2:
+		return "Union"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v StructureType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *StructureType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := StructureTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = StructureType(n)
+	return nil
 }
 
 const (
@@ -729,23 +1817,59 @@ const (
 	StructureTypeUnion                       StructureType = 2
 )
 
+// ApplicationType describes the types of applications.
 type ApplicationType uint32
 
-func ApplicationTypeFromString(s string) ApplicationType {
-	switch s {
-	case "Server":
-		return 0
-	case "Client":
-		return 1
-	case "ClientAndServer":
-		return 2
-	case "DiscoveryServer":
-		return 3
+// ApplicationTypeFromString returns the ApplicationType with the case-insensitive name s.
+func ApplicationTypeFromString(s string) (ApplicationType, error) {
+	switch strings.ToLower(s) {
+	case "server":
+		return 0, nil
+	case "client":
+		return 1, nil
+	case "clientandserver":
+		return 2, nil
+	case "discoveryserver":
+		return 3, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid ApplicationType %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v ApplicationType) String() string {
+	switch v {
+	case 0:
+		return "Server"
+	case 1:
+		return "Client"
+	case 2:
+		return "ClientAndServer"
+	case 3:
+		return "DiscoveryServer"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v ApplicationType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *ApplicationType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := ApplicationTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = ApplicationType(n)
+	return nil
+}
+
 const (
 	ApplicationTypeServer          ApplicationType = 0
 	ApplicationTypeClient          ApplicationType = 1
@@ -753,21 +1877,57 @@ const (
 	ApplicationTypeDiscoveryServer ApplicationType = 3
 )
 
+// MessageSecurityMode describes the type of security to use on a message.
 type MessageSecurityMode uint32
 
-func MessageSecurityModeFromString(s string) MessageSecurityMode {
-	switch s {
-	case "Invalid":
-		return 0
-	case "None":
-		return 1
-	case "Sign":
-		return 2
-	case "SignAndEncrypt":
-		return 3
+// MessageSecurityModeFromString returns the MessageSecurityMode with the case-insensitive name s.
+func MessageSecurityModeFromString(s string) (MessageSecurityMode, error) {
+	switch strings.ToLower(s) {
+	case "invalid":
+		return 0, nil
+	case "none":
+		return 1, nil
+	case "sign":
+		return 2, nil
+	case "signandencrypt":
+		return 3, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid MessageSecurityMode %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v MessageSecurityMode) String() string {
+	switch v {
+	case 0:
+		return "Invalid"
+	case 1:
+		return "None"
+	case 2:
+		return "Sign"
+	case 3:
+		return "SignAndEncrypt"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v MessageSecurityMode) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *MessageSecurityMode) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := MessageSecurityModeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = MessageSecurityMode(n)
+	return nil
 }
 
 const (
@@ -777,23 +1937,59 @@ const (
 	MessageSecurityModeSignAndEncrypt MessageSecurityMode = 3
 )
 
+// UserTokenType describes the possible user token types.
 type UserTokenType uint32
 
-func UserTokenTypeFromString(s string) UserTokenType {
-	switch s {
-	case "Anonymous":
-		return 0
-	case "UserName":
-		return 1
-	case "Certificate":
-		return 2
-	case "IssuedToken":
-		return 3
+// UserTokenTypeFromString returns the UserTokenType with the case-insensitive name s.
+func UserTokenTypeFromString(s string) (UserTokenType, error) {
+	switch strings.ToLower(s) {
+	case "anonymous":
+		return 0, nil
+	case "username":
+		return 1, nil
+	case "certificate":
+		return 2, nil
+	case "issuedtoken":
+		return 3, nil
+	default:
+		return 0, errors.Errorf("invalid UserTokenType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v UserTokenType) String() string {
+	switch v {
+	case 0:
+		return "Anonymous"
+	case 1:
+		return "UserName"
+	case 2:
+		return "Certificate"
+	case 3:
+		return "IssuedToken"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v UserTokenType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *UserTokenType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := UserTokenTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = UserTokenType(n)
+	return nil
+}
+
 const (
 	UserTokenTypeAnonymous   UserTokenType = 0
 	UserTokenTypeUserName    UserTokenType = 1
@@ -801,101 +1997,262 @@ const (
 	UserTokenTypeIssuedToken UserTokenType = 3
 )
 
+// SecurityTokenRequestType indicates whether a token if being created or
+// renewed.
 type SecurityTokenRequestType uint32
 
-func SecurityTokenRequestTypeFromString(s string) SecurityTokenRequestType {
-	switch s {
-	case "Issue":
-		return 0
-	case "Renew":
-		return 1
+// SecurityTokenRequestTypeFromString returns the SecurityTokenRequestType with the case-insensitive name s.
+func SecurityTokenRequestTypeFromString(s string) (SecurityTokenRequestType, error) {
+	switch strings.ToLower(s) {
+	case "issue":
+		return 0, nil
+	case "renew":
+		return 1, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid SecurityTokenRequestType %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v SecurityTokenRequestType) String() string {
+	switch v {
+	case 0:
+		return "Issue"
+	case 1:
+		return "Renew"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v SecurityTokenRequestType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *SecurityTokenRequestType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := SecurityTokenRequestTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = SecurityTokenRequestType(n)
+	return nil
+}
+
 const (
 	SecurityTokenRequestTypeIssue SecurityTokenRequestType = 0
 	SecurityTokenRequestTypeRenew SecurityTokenRequestType = 1
 )
 
+// NodeAttributesMask describes the bits used to specify default attributes
+// for a new node.
 type NodeAttributesMask uint32
 
-func NodeAttributesMaskFromString(s string) NodeAttributesMask {
-	switch s {
-	case "None":
-		return 0
-	case "AccessLevel":
-		return 1
-	case "ArrayDimensions":
-		return 2
-	case "BrowseName":
-		return 4
-	case "ContainsNoLoops":
-		return 8
-	case "DataType":
-		return 16
-	case "Description":
-		return 32
-	case "DisplayName":
-		return 64
-	case "EventNotifier":
-		return 128
-	case "Executable":
-		return 256
-	case "Historizing":
-		return 512
-	case "InverseName":
-		return 1024
-	case "IsAbstract":
-		return 2048
-	case "MinimumSamplingInterval":
-		return 4096
-	case "NodeClass":
-		return 8192
-	case "NodeId":
-		return 16384
-	case "Symmetric":
-		return 32768
-	case "UserAccessLevel":
-		return 65536
-	case "UserExecutable":
-		return 131072
-	case "UserWriteMask":
-		return 262144
-	case "ValueRank":
-		return 524288
-	case "WriteMask":
-		return 1048576
-	case "Value":
-		return 2097152
-	case "DataTypeDefinition":
-		return 4194304
-	case "RolePermissions":
-		return 8388608
-	case "AccessRestrictions":
-		return 16777216
-	case "All":
-		return 33554431
-	case "BaseNode":
-		return 26501220
-	case "Object":
-		return 26501348
-	case "ObjectType":
-		return 26503268
-	case "Variable":
-		return 26571383
-	case "VariableType":
-		return 28600438
-	case "Method":
-		return 26632548
-	case "ReferenceType":
-		return 26537060
-	case "View":
-		return 26501356
-	default:
-		return 0
+// NodeAttributesMaskFromString returns the NodeAttributesMask with the case-insensitive name s.
+func NodeAttributesMaskFromString(s string) (NodeAttributesMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "accesslevel":
+		return 1, nil
+	case "arraydimensions":
+		return 2, nil
+	case "browsename":
+		return 4, nil
+	case "containsnoloops":
+		return 8, nil
+	case "datatype":
+		return 16, nil
+	case "description":
+		return 32, nil
+	case "displayname":
+		return 64, nil
+	case "eventnotifier":
+		return 128, nil
+	case "executable":
+		return 256, nil
+	case "historizing":
+		return 512, nil
+	case "inversename":
+		return 1024, nil
+	case "isabstract":
+		return 2048, nil
+	case "minimumsamplinginterval":
+		return 4096, nil
+	case "nodeclass":
+		return 8192, nil
+	case "nodeid":
+		return 16384, nil
+	case "symmetric":
+		return 32768, nil
+	case "useraccesslevel":
+		return 65536, nil
+	case "userexecutable":
+		return 131072, nil
+	case "userwritemask":
+		return 262144, nil
+	case "valuerank":
+		return 524288, nil
+	case "writemask":
+		return 1048576, nil
+	case "value":
+		return 2097152, nil
+	case "datatypedefinition":
+		return 4194304, nil
+	case "rolepermissions":
+		return 8388608, nil
+	case "accessrestrictions":
+		return 16777216, nil
+	case "all":
+		return 33554431, nil
+	case "basenode":
+		return 26501220, nil
+	case "object":
+		return 26501348, nil
+	case "objecttype":
+		return 26503268, nil
+	case "variable":
+		return 26571383, nil
+	case "variabletype":
+		return 28600438, nil
+	case "method":
+		return 26632548, nil
+	case "referencetype":
+		return 26537060, nil
+	case "view":
+		return 26501356, nil
+	default:
+		return 0, errors.Errorf("invalid NodeAttributesMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v NodeAttributesMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "AccessLevel"
+	case 2:
+		return "ArrayDimensions"
+	case 4:
+		return "BrowseName"
+	case 8:
+		return "ContainsNoLoops"
+	case 16:
+		return "DataType"
+	case 32:
+		return "Description"
+	case 64:
+		return "DisplayName"
+	case 128:
+		return "EventNotifier"
+	case 256:
+		return "Executable"
+	case 512:
+		return "Historizing"
+	case 1024:
+		return "InverseName"
+	case 2048:
+		return "IsAbstract"
+	case 4096:
+		return "MinimumSamplingInterval"
+	case 8192:
+		return "NodeClass"
+	case 16384:
+		return "NodeId"
+	case 32768:
+		return "Symmetric"
+	case 65536:
+		return "UserAccessLevel"
+	case 131072:
+		return "UserExecutable"
+	case 262144:
+		return "UserWriteMask"
+	case 524288:
+		return "ValueRank"
+	case 1048576:
+		return "WriteMask"
+	case 2097152:
+		return "Value"
+	case 4194304:
+		return "DataTypeDefinition"
+	case 8388608:
+		return "RolePermissions"
+	case 16777216:
+		return "AccessRestrictions"
+	case 33554431:
+		return "All"
+	case 26501220:
+		return "BaseNode"
+	case 26501348:
+		return "Object"
+	case 26503268:
+		return "ObjectType"
+	case 26571383:
+		return "Variable"
+	case 28600438:
+		return "VariableType"
+	case 26632548:
+		return "Method"
+	case 26537060:
+		return "ReferenceType"
+	case 26501356:
+		return "View"
+	default:
+		return FormatOptionSet(uint64(v), nodeAttributesMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v NodeAttributesMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *NodeAttributesMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := NodeAttributesMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = NodeAttributesMask(n)
+	return nil
+}
+
+var nodeAttributesMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "AccessLevel"},
+	{Mask: 2, Name: "ArrayDimensions"},
+	{Mask: 4, Name: "BrowseName"},
+	{Mask: 8, Name: "ContainsNoLoops"},
+	{Mask: 16, Name: "DataType"},
+	{Mask: 32, Name: "Description"},
+	{Mask: 64, Name: "DisplayName"},
+	{Mask: 128, Name: "EventNotifier"},
+	{Mask: 256, Name: "Executable"},
+	{Mask: 512, Name: "Historizing"},
+	{Mask: 1024, Name: "InverseName"},
+	{Mask: 2048, Name: "IsAbstract"},
+	{Mask: 4096, Name: "MinimumSamplingInterval"},
+	{Mask: 8192, Name: "NodeClass"},
+	{Mask: 16384, Name: "NodeId"},
+	{Mask: 32768, Name: "Symmetric"},
+	{Mask: 65536, Name: "UserAccessLevel"},
+	{Mask: 131072, Name: "UserExecutable"},
+	{Mask: 262144, Name: "UserWriteMask"},
+	{Mask: 524288, Name: "ValueRank"},
+	{Mask: 1048576, Name: "WriteMask"},
+	{Mask: 2097152, Name: "Value"},
+	{Mask: 4194304, Name: "DataTypeDefinition"},
+	{Mask: 8388608, Name: "RolePermissions"},
+	{Mask: 16777216, Name: "AccessRestrictions"},
 }
 
 const (
@@ -936,69 +2293,182 @@ const (
 	NodeAttributesMaskView                    NodeAttributesMask = 26501356
 )
 
+// AttributeWriteMask defines bits used to indicate which attributes are
+// writable.
 type AttributeWriteMask uint32
 
-func AttributeWriteMaskFromString(s string) AttributeWriteMask {
-	switch s {
-	case "None":
-		return 0
-	case "AccessLevel":
-		return 1
-	case "ArrayDimensions":
-		return 2
-	case "BrowseName":
-		return 4
-	case "ContainsNoLoops":
-		return 8
-	case "DataType":
-		return 16
-	case "Description":
-		return 32
-	case "DisplayName":
-		return 64
-	case "EventNotifier":
-		return 128
-	case "Executable":
-		return 256
-	case "Historizing":
-		return 512
-	case "InverseName":
-		return 1024
-	case "IsAbstract":
-		return 2048
-	case "MinimumSamplingInterval":
-		return 4096
-	case "NodeClass":
-		return 8192
-	case "NodeId":
-		return 16384
-	case "Symmetric":
-		return 32768
-	case "UserAccessLevel":
-		return 65536
-	case "UserExecutable":
-		return 131072
-	case "UserWriteMask":
-		return 262144
-	case "ValueRank":
-		return 524288
-	case "WriteMask":
-		return 1048576
-	case "ValueForVariableType":
-		return 2097152
-	case "DataTypeDefinition":
-		return 4194304
-	case "RolePermissions":
-		return 8388608
-	case "AccessRestrictions":
-		return 16777216
-	case "AccessLevelEx":
-		return 33554432
-	default:
-		return 0
+// AttributeWriteMaskFromString returns the AttributeWriteMask with the case-insensitive name s.
+func AttributeWriteMaskFromString(s string) (AttributeWriteMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "accesslevel":
+		return 1, nil
+	case "arraydimensions":
+		return 2, nil
+	case "browsename":
+		return 4, nil
+	case "containsnoloops":
+		return 8, nil
+	case "datatype":
+		return 16, nil
+	case "description":
+		return 32, nil
+	case "displayname":
+		return 64, nil
+	case "eventnotifier":
+		return 128, nil
+	case "executable":
+		return 256, nil
+	case "historizing":
+		return 512, nil
+	case "inversename":
+		return 1024, nil
+	case "isabstract":
+		return 2048, nil
+	case "minimumsamplinginterval":
+		return 4096, nil
+	case "nodeclass":
+		return 8192, nil
+	case "nodeid":
+		return 16384, nil
+	case "symmetric":
+		return 32768, nil
+	case "useraccesslevel":
+		return 65536, nil
+	case "userexecutable":
+		return 131072, nil
+	case "userwritemask":
+		return 262144, nil
+	case "valuerank":
+		return 524288, nil
+	case "writemask":
+		return 1048576, nil
+	case "valueforvariabletype":
+		return 2097152, nil
+	case "datatypedefinition":
+		return 4194304, nil
+	case "rolepermissions":
+		return 8388608, nil
+	case "accessrestrictions":
+		return 16777216, nil
+	case "accesslevelex":
+		return 33554432, nil
+	default:
+		return 0, errors.Errorf("invalid AttributeWriteMask %q", s)
+	}
+}
+
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v AttributeWriteMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "AccessLevel"
+	case 2:
+		return "ArrayDimensions"
+	case 4:
+		return "BrowseName"
+	case 8:
+		return "ContainsNoLoops"
+	case 16:
+		return "DataType"
+	case 32:
+		return "Description"
+	case 64:
+		return "DisplayName"
+	case 128:
+		return "EventNotifier"
+	case 256:
+		return "Executable"
+	case 512:
+		return "Historizing"
+	case 1024:
+		return "InverseName"
+	case 2048:
+		return "IsAbstract"
+	case 4096:
+		return "MinimumSamplingInterval"
+	case 8192:
+		return "NodeClass"
+	case 16384:
+		return "NodeId"
+	case 32768:
+		return "Symmetric"
+	case 65536:
+		return "UserAccessLevel"
+	case 131072:
+		return "UserExecutable"
+	case 262144:
+		return "UserWriteMask"
+	case 524288:
+		return "ValueRank"
+	case 1048576:
+		return "WriteMask"
+	case 2097152:
+		return "ValueForVariableType"
+	case 4194304:
+		return "DataTypeDefinition"
+	case 8388608:
+		return "RolePermissions"
+	case 16777216:
+		return "AccessRestrictions"
+	case 33554432:
+		return "AccessLevelEx"
+	default:
+		return FormatOptionSet(uint64(v), attributeWriteMaskBits)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v AttributeWriteMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *AttributeWriteMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := AttributeWriteMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = AttributeWriteMask(n)
+	return nil
+}
+
+var attributeWriteMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "AccessLevel"},
+	{Mask: 2, Name: "ArrayDimensions"},
+	{Mask: 4, Name: "BrowseName"},
+	{Mask: 8, Name: "ContainsNoLoops"},
+	{Mask: 16, Name: "DataType"},
+	{Mask: 32, Name: "Description"},
+	{Mask: 64, Name: "DisplayName"},
+	{Mask: 128, Name: "EventNotifier"},
+	{Mask: 256, Name: "Executable"},
+	{Mask: 512, Name: "Historizing"},
+	{Mask: 1024, Name: "InverseName"},
+	{Mask: 2048, Name: "IsAbstract"},
+	{Mask: 4096, Name: "MinimumSamplingInterval"},
+	{Mask: 8192, Name: "NodeClass"},
+	{Mask: 16384, Name: "NodeId"},
+	{Mask: 32768, Name: "Symmetric"},
+	{Mask: 65536, Name: "UserAccessLevel"},
+	{Mask: 131072, Name: "UserExecutable"},
+	{Mask: 262144, Name: "UserWriteMask"},
+	{Mask: 524288, Name: "ValueRank"},
+	{Mask: 1048576, Name: "WriteMask"},
+	{Mask: 2097152, Name: "ValueForVariableType"},
+	{Mask: 4194304, Name: "DataTypeDefinition"},
+	{Mask: 8388608, Name: "RolePermissions"},
+	{Mask: 16777216, Name: "AccessRestrictions"},
+	{Mask: 33554432, Name: "AccessLevelEx"},
+}
+
 const (
 	AttributeWriteMaskNone                    AttributeWriteMask = 0
 	AttributeWriteMaskAccessLevel             AttributeWriteMask = 1
@@ -1029,23 +2499,59 @@ const (
 	AttributeWriteMaskAccessLevelEx           AttributeWriteMask = 33554432
 )
 
+// BrowseDirection describes the directions of the references to return.
 type BrowseDirection uint32
 
-func BrowseDirectionFromString(s string) BrowseDirection {
-	switch s {
-	case "Forward":
-		return 0
-	case "Inverse":
-		return 1
-	case "Both":
-		return 2
-	case "Invalid":
-		return 3
+// BrowseDirectionFromString returns the BrowseDirection with the case-insensitive name s.
+func BrowseDirectionFromString(s string) (BrowseDirection, error) {
+	switch strings.ToLower(s) {
+	case "forward":
+		return 0, nil
+	case "inverse":
+		return 1, nil
+	case "both":
+		return 2, nil
+	case "invalid":
+		return 3, nil
+	default:
+		return 0, errors.Errorf("invalid BrowseDirection %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v BrowseDirection) String() string {
+	switch v {
+	case 0:
+		return "Forward"
+	case 1:
+		return "Inverse"
+	case 2:
+		return "Both"
+	case 3:
+		return "Invalid"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v BrowseDirection) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *BrowseDirection) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := BrowseDirectionFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = BrowseDirection(n)
+	return nil
+}
+
 const (
 	BrowseDirectionForward BrowseDirection = 0
 	BrowseDirectionInverse BrowseDirection = 1
@@ -1053,35 +2559,94 @@ const (
 	BrowseDirectionInvalid BrowseDirection = 3
 )
 
+// BrowseResultMask is a bit mask which specifies what should be returned
+// in a browse response.
 type BrowseResultMask uint32
 
-func BrowseResultMaskFromString(s string) BrowseResultMask {
-	switch s {
-	case "None":
-		return 0
-	case "ReferenceTypeId":
-		return 1
-	case "IsForward":
-		return 2
-	case "NodeClass":
-		return 4
-	case "BrowseName":
-		return 8
-	case "DisplayName":
-		return 16
-	case "TypeDefinition":
-		return 32
-	case "All":
-		return 63
-	case "ReferenceTypeInfo":
-		return 3
-	case "TargetInfo":
-		return 60
-	default:
-		return 0
+// BrowseResultMaskFromString returns the BrowseResultMask with the case-insensitive name s.
+func BrowseResultMaskFromString(s string) (BrowseResultMask, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "referencetypeid":
+		return 1, nil
+	case "isforward":
+		return 2, nil
+	case "nodeclass":
+		return 4, nil
+	case "browsename":
+		return 8, nil
+	case "displayname":
+		return 16, nil
+	case "typedefinition":
+		return 32, nil
+	case "all":
+		return 63, nil
+	case "referencetypeinfo":
+		return 3, nil
+	case "targetinfo":
+		return 60, nil
+	default:
+		return 0, errors.Errorf("invalid BrowseResultMask %q", s)
 	}
 }
 
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v BrowseResultMask) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "ReferenceTypeId"
+	case 2:
+		return "IsForward"
+	case 4:
+		return "NodeClass"
+	case 8:
+		return "BrowseName"
+	case 16:
+		return "DisplayName"
+	case 32:
+		return "TypeDefinition"
+	case 63:
+		return "All"
+	case 3:
+		return "ReferenceTypeInfo"
+	case 60:
+		return "TargetInfo"
+	default:
+		return FormatOptionSet(uint64(v), browseResultMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v BrowseResultMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *BrowseResultMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := BrowseResultMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = BrowseResultMask(n)
+	return nil
+}
+
+var browseResultMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "ReferenceTypeId"},
+	{Mask: 2, Name: "IsForward"},
+	{Mask: 4, Name: "NodeClass"},
+	{Mask: 8, Name: "BrowseName"},
+	{Mask: 16, Name: "DisplayName"},
+	{Mask: 32, Name: "TypeDefinition"},
+}
+
 const (
 	BrowseResultMaskNone              BrowseResultMask = 0
 	BrowseResultMaskReferenceTypeID   BrowseResultMask = 1
@@ -1097,47 +2662,110 @@ const (
 
 type FilterOperator uint32
 
-func FilterOperatorFromString(s string) FilterOperator {
-	switch s {
-	case "Equals":
-		return 0
-	case "IsNull":
-		return 1
-	case "GreaterThan":
-		return 2
-	case "LessThan":
-		return 3
-	case "GreaterThanOrEqual":
-		return 4
-	case "LessThanOrEqual":
-		return 5
-	case "Like":
-		return 6
-	case "Not":
-		return 7
-	case "Between":
-		return 8
-	case "InList":
-		return 9
-	case "And":
-		return 10
-	case "Or":
-		return 11
-	case "Cast":
-		return 12
-	case "InView":
-		return 13
-	case "OfType":
-		return 14
-	case "RelatedTo":
-		return 15
-	case "BitwiseAnd":
-		return 16
-	case "BitwiseOr":
-		return 17
-	default:
-		return 0
+// FilterOperatorFromString returns the FilterOperator with the case-insensitive name s.
+func FilterOperatorFromString(s string) (FilterOperator, error) {
+	switch strings.ToLower(s) {
+	case "equals":
+		return 0, nil
+	case "isnull":
+		return 1, nil
+	case "greaterthan":
+		return 2, nil
+	case "lessthan":
+		return 3, nil
+	case "greaterthanorequal":
+		return 4, nil
+	case "lessthanorequal":
+		return 5, nil
+	case "like":
+		return 6, nil
+	case "not":
+		return 7, nil
+	case "between":
+		return 8, nil
+	case "inlist":
+		return 9, nil
+	case "and":
+		return 10, nil
+	case "or":
+		return 11, nil
+	case "cast":
+		return 12, nil
+	case "inview":
+		return 13, nil
+	case "oftype":
+		return 14, nil
+	case "relatedto":
+		return 15, nil
+	case "bitwiseand":
+		return 16, nil
+	case "bitwiseor":
+		return 17, nil
+	default:
+		return 0, errors.Errorf("invalid FilterOperator %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v FilterOperator) String() string {
+	switch v {
+	case 0:
+		return "Equals"
+	case 1:
+		return "IsNull"
+	case 2:
+		return "GreaterThan"
+	case 3:
+		return "LessThan"
+	case 4:
+		return "GreaterThanOrEqual"
+	case 5:
+		return "LessThanOrEqual"
+	case 6:
+		return "Like"
+	case 7:
+		return "Not"
+	case 8:
+		return "Between"
+	case 9:
+		return "InList"
+	case 10:
+		return "And"
+	case 11:
+		return "Or"
+	case 12:
+		return "Cast"
+	case 13:
+		return "InView"
+	case 14:
+		return "OfType"
+	case 15:
+		return "RelatedTo"
+	case 16:
+		return "BitwiseAnd"
+	case 17:
+		return "BitwiseOr"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v FilterOperator) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *FilterOperator) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := FilterOperatorFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = FilterOperator(n)
+	return nil
 }
 
 const (
@@ -1163,21 +2791,58 @@ const (
 
 type TimestampsToReturn uint32
 
-func TimestampsToReturnFromString(s string) TimestampsToReturn {
-	switch s {
-	case "Source":
-		return 0
-	case "Server":
-		return 1
-	case "Both":
-		return 2
-	case "Neither":
-		return 3
-	case "Invalid":
-		return 4
+// TimestampsToReturnFromString returns the TimestampsToReturn with the case-insensitive name s.
+func TimestampsToReturnFromString(s string) (TimestampsToReturn, error) {
+	switch strings.ToLower(s) {
+	case "source":
+		return 0, nil
+	case "server":
+		return 1, nil
+	case "both":
+		return 2, nil
+	case "neither":
+		return 3, nil
+	case "invalid":
+		return 4, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid TimestampsToReturn %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v TimestampsToReturn) String() string {
+	switch v {
+	case 0:
+		return "Source"
+	case 1:
+		return "Server"
+	case 2:
+		return "Both"
+	case 3:
+		return "Neither"
+	case 4:
+		return "Invalid"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v TimestampsToReturn) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *TimestampsToReturn) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := TimestampsToReturnFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = TimestampsToReturn(n)
+	return nil
 }
 
 const (
@@ -1190,21 +2855,56 @@ const (
 
 type HistoryUpdateType uint32
 
-func HistoryUpdateTypeFromString(s string) HistoryUpdateType {
-	switch s {
-	case "Insert":
-		return 1
-	case "Replace":
-		return 2
-	case "Update":
-		return 3
-	case "Delete":
-		return 4
+// HistoryUpdateTypeFromString returns the HistoryUpdateType with the case-insensitive name s.
+func HistoryUpdateTypeFromString(s string) (HistoryUpdateType, error) {
+	switch strings.ToLower(s) {
+	case "insert":
+		return 1, nil
+	case "replace":
+		return 2, nil
+	case "update":
+		return 3, nil
+	case "delete":
+		return 4, nil
+	default:
+		return 0, errors.Errorf("invalid HistoryUpdateType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v HistoryUpdateType) String() string {
+	switch v {
+	case 1:
+		return "Insert"
+	case 2:
+		return "Replace"
+	case 3:
+		return "Update"
+	case 4:
+		return "Delete"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler.
+func (v HistoryUpdateType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *HistoryUpdateType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := HistoryUpdateTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = HistoryUpdateType(n)
+	return nil
+}
+
 const (
 	HistoryUpdateTypeInsert  HistoryUpdateType = 1
 	HistoryUpdateTypeReplace HistoryUpdateType = 2
@@ -1214,19 +2914,54 @@ const (
 
 type PerformUpdateType uint32
 
-func PerformUpdateTypeFromString(s string) PerformUpdateType {
-	switch s {
-	case "Insert":
-		return 1
-	case "Replace":
-		return 2
-	case "Update":
-		return 3
-	case "Remove":
-		return 4
+// PerformUpdateTypeFromString returns the PerformUpdateType with the case-insensitive name s.
+func PerformUpdateTypeFromString(s string) (PerformUpdateType, error) {
+	switch strings.ToLower(s) {
+	case "insert":
+		return 1, nil
+	case "replace":
+		return 2, nil
+	case "update":
+		return 3, nil
+	case "remove":
+		return 4, nil
+	default:
+		return 0, errors.Errorf("invalid PerformUpdateType %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v PerformUpdateType) String() string {
+	switch v {
+	case 1:
+		return "Insert"
+	case 2:
+		return "Replace"
+	case 3:
+		return "Update"
+	case 4:
+		return "Remove"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v PerformUpdateType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *PerformUpdateType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := PerformUpdateTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = PerformUpdateType(n)
+	return nil
 }
 
 const (
@@ -1238,17 +2973,50 @@ const (
 
 type MonitoringMode uint32
 
-func MonitoringModeFromString(s string) MonitoringMode {
-	switch s {
-	case "Disabled":
-		return 0
-	case "Sampling":
-		return 1
-	case "Reporting":
-		return 2
+// MonitoringModeFromString returns the MonitoringMode with the case-insensitive name s.
+func MonitoringModeFromString(s string) (MonitoringMode, error) {
+	switch strings.ToLower(s) {
+	case "disabled":
+		return 0, nil
+	case "sampling":
+		return 1, nil
+	case "reporting":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid MonitoringMode %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v MonitoringMode) String() string {
+	switch v {
+	case 0:
+		return "Disabled"
+	case 1:
+		return "Sampling"
+	case 2:
+		return "Reporting"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v MonitoringMode) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *MonitoringMode) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := MonitoringModeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = MonitoringMode(n)
+	return nil
 }
 
 const (
@@ -1259,17 +3027,50 @@ const (
 
 type DataChangeTrigger uint32
 
-func DataChangeTriggerFromString(s string) DataChangeTrigger {
-	switch s {
-	case "Status":
-		return 0
-	case "StatusValue":
-		return 1
-	case "StatusValueTimestamp":
-		return 2
+// DataChangeTriggerFromString returns the DataChangeTrigger with the case-insensitive name s.
+func DataChangeTriggerFromString(s string) (DataChangeTrigger, error) {
+	switch strings.ToLower(s) {
+	case "status":
+		return 0, nil
+	case "statusvalue":
+		return 1, nil
+	case "statusvaluetimestamp":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid DataChangeTrigger %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v DataChangeTrigger) String() string {
+	switch v {
+	case 0:
+		return "Status"
+	case 1:
+		return "StatusValue"
+	case 2:
+		return "StatusValueTimestamp"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DataChangeTrigger) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DataChangeTrigger) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := DataChangeTriggerFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = DataChangeTrigger(n)
+	return nil
 }
 
 const (
@@ -1280,19 +3081,52 @@ const (
 
 type DeadbandType uint32
 
-func DeadbandTypeFromString(s string) DeadbandType {
-	switch s {
-	case "None":
-		return 0
-	case "Absolute":
-		return 1
-	case "Percent":
-		return 2
+// DeadbandTypeFromString returns the DeadbandType with the case-insensitive name s.
+func DeadbandTypeFromString(s string) (DeadbandType, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "absolute":
+		return 1, nil
+	case "percent":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid DeadbandType %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v DeadbandType) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "Absolute"
+	case 2:
+		return "Percent"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v DeadbandType) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *DeadbandType) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := DeadbandTypeFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = DeadbandType(n)
+	return nil
+}
+
 const (
 	DeadbandTypeNone     DeadbandType = 0
 	DeadbandTypeAbsolute DeadbandType = 1
@@ -1301,23 +3135,62 @@ const (
 
 type RedundancySupport uint32
 
-func RedundancySupportFromString(s string) RedundancySupport {
-	switch s {
-	case "None":
-		return 0
-	case "Cold":
-		return 1
-	case "Warm":
-		return 2
-	case "Hot":
-		return 3
-	case "Transparent":
-		return 4
-	case "HotAndMirrored":
-		return 5
+// RedundancySupportFromString returns the RedundancySupport with the case-insensitive name s.
+func RedundancySupportFromString(s string) (RedundancySupport, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "cold":
+		return 1, nil
+	case "warm":
+		return 2, nil
+	case "hot":
+		return 3, nil
+	case "transparent":
+		return 4, nil
+	case "hotandmirrored":
+		return 5, nil
+	default:
+		return 0, errors.Errorf("invalid RedundancySupport %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v RedundancySupport) String() string {
+	switch v {
+	case 0:
+		return "None"
+	case 1:
+		return "Cold"
+	case 2:
+		return "Warm"
+	case 3:
+		return "Hot"
+	case 4:
+		return "Transparent"
+	case 5:
+		return "HotAndMirrored"
 	default:
-		return 0
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v RedundancySupport) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *RedundancySupport) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := RedundancySupportFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = RedundancySupport(n)
+	return nil
 }
 
 const (
@@ -1331,27 +3204,70 @@ const (
 
 type ServerState uint32
 
-func ServerStateFromString(s string) ServerState {
-	switch s {
-	case "Running":
-		return 0
-	case "Failed":
-		return 1
-	case "NoConfiguration":
-		return 2
-	case "Suspended":
-		return 3
-	case "Shutdown":
-		return 4
-	case "Test":
-		return 5
-	case "CommunicationFault":
-		return 6
-	case "Unknown":
-		return 7
-	default:
-		return 0
+// ServerStateFromString returns the ServerState with the case-insensitive name s.
+func ServerStateFromString(s string) (ServerState, error) {
+	switch strings.ToLower(s) {
+	case "running":
+		return 0, nil
+	case "failed":
+		return 1, nil
+	case "noconfiguration":
+		return 2, nil
+	case "suspended":
+		return 3, nil
+	case "shutdown":
+		return 4, nil
+	case "test":
+		return 5, nil
+	case "communicationfault":
+		return 6, nil
+	case "unknown":
+		return 7, nil
+	default:
+		return 0, errors.Errorf("invalid ServerState %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v ServerState) String() string {
+	switch v {
+	case 0:
+		return "Running"
+	case 1:
+		return "Failed"
+	case 2:
+		return "NoConfiguration"
+	case 3:
+		return "Suspended"
+	case 4:
+		return "Shutdown"
+	case 5:
+		return "Test"
+	case 6:
+		return "CommunicationFault"
+	case 7:
+		return "Unknown"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v ServerState) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *ServerState) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := ServerStateFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = ServerState(n)
+	return nil
 }
 
 const (
@@ -1367,23 +3283,69 @@ const (
 
 type ModelChangeStructureVerbMask uint32
 
-func ModelChangeStructureVerbMaskFromString(s string) ModelChangeStructureVerbMask {
-	switch s {
-	case "NodeAdded":
-		return 1
-	case "NodeDeleted":
-		return 2
-	case "ReferenceAdded":
-		return 4
-	case "ReferenceDeleted":
-		return 8
-	case "DataTypeChanged":
-		return 16
+// ModelChangeStructureVerbMaskFromString returns the ModelChangeStructureVerbMask with the case-insensitive name s.
+func ModelChangeStructureVerbMaskFromString(s string) (ModelChangeStructureVerbMask, error) {
+	switch strings.ToLower(s) {
+	case "nodeadded":
+		return 1, nil
+	case "nodedeleted":
+		return 2, nil
+	case "referenceadded":
+		return 4, nil
+	case "referencedeleted":
+		return 8, nil
+	case "datatypechanged":
+		return 16, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid ModelChangeStructureVerbMask %q", s)
 	}
 }
 
+// String returns the name of the value or the names of the bits which are
+// set separated by |.
+func (v ModelChangeStructureVerbMask) String() string {
+	switch v {
+	case 1:
+		return "NodeAdded"
+	case 2:
+		return "NodeDeleted"
+	case 4:
+		return "ReferenceAdded"
+	case 8:
+		return "ReferenceDeleted"
+	case 16:
+		return "DataTypeChanged"
+	default:
+		return FormatOptionSet(uint64(v), modelChangeStructureVerbMaskBits)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v ModelChangeStructureVerbMask) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *ModelChangeStructureVerbMask) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), true, func(s string) (uint64, error) {
+		x, err := ModelChangeStructureVerbMaskFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = ModelChangeStructureVerbMask(n)
+	return nil
+}
+
+var modelChangeStructureVerbMaskBits = []OptionSetBit{
+	{Mask: 1, Name: "NodeAdded"},
+	{Mask: 2, Name: "NodeDeleted"},
+	{Mask: 4, Name: "ReferenceAdded"},
+	{Mask: 8, Name: "ReferenceDeleted"},
+	{Mask: 16, Name: "DataTypeChanged"},
+}
+
 const (
 	ModelChangeStructureVerbMaskNodeAdded        ModelChangeStructureVerbMask = 1
 	ModelChangeStructureVerbMaskNodeDeleted      ModelChangeStructureVerbMask = 2
@@ -1394,19 +3356,52 @@ const (
 
 type AxisScaleEnumeration uint32
 
-func AxisScaleEnumerationFromString(s string) AxisScaleEnumeration {
-	switch s {
-	case "Linear":
-		return 0
-	case "Log":
-		return 1
-	case "Ln":
-		return 2
+// AxisScaleEnumerationFromString returns the AxisScaleEnumeration with the case-insensitive name s.
+func AxisScaleEnumerationFromString(s string) (AxisScaleEnumeration, error) {
+	switch strings.ToLower(s) {
+	case "linear":
+		return 0, nil
+	case "log":
+		return 1, nil
+	case "ln":
+		return 2, nil
 	default:
-		return 0
+		return 0, errors.Errorf("invalid AxisScaleEnumeration %q", s)
 	}
 }
 
+// String returns the name of the value or its number if it has no name.
+func (v AxisScaleEnumeration) String() string {
+	switch v {
+	case 0:
+		return "Linear"
+	case 1:
+		return "Log"
+	case 2:
+		return "Ln"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v AxisScaleEnumeration) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *AxisScaleEnumeration) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := AxisScaleEnumerationFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
+	}
+	*v = AxisScaleEnumeration(n)
+	return nil
+}
+
 const (
 	AxisScaleEnumerationLinear AxisScaleEnumeration = 0
 	AxisScaleEnumerationLog    AxisScaleEnumeration = 1
@@ -1415,21 +3410,58 @@ const (
 
 type ExceptionDeviationFormat uint32
 
-func ExceptionDeviationFormatFromString(s string) ExceptionDeviationFormat {
-	switch s {
-	case "AbsoluteValue":
-		return 0
-	case "PercentOfValue":
-		return 1
-	case "PercentOfRange":
-		return 2
-	case "PercentOfEURange":
-		return 3
-	case "Unknown":
-		return 4
-	default:
-		return 0
+// ExceptionDeviationFormatFromString returns the ExceptionDeviationFormat with the case-insensitive name s.
+func ExceptionDeviationFormatFromString(s string) (ExceptionDeviationFormat, error) {
+	switch strings.ToLower(s) {
+	case "absolutevalue":
+		return 0, nil
+	case "percentofvalue":
+		return 1, nil
+	case "percentofrange":
+		return 2, nil
+	case "percentofeurange":
+		return 3, nil
+	case "unknown":
+		return 4, nil
+	default:
+		return 0, errors.Errorf("invalid ExceptionDeviationFormat %q", s)
+	}
+}
+
+// String returns the name of the value or its number if it has no name.
+func (v ExceptionDeviationFormat) String() string {
+	switch v {
+	case 0:
+		return "AbsoluteValue"
+	case 1:
+		return "PercentOfValue"
+	case 2:
+		return "PercentOfRange"
+	case 3:
+		return "PercentOfEURange"
+	case 4:
+		return "Unknown"
+	default:
+		return strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v ExceptionDeviationFormat) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *ExceptionDeviationFormat) UnmarshalText(b []byte) error {
+	n, err := ParseEnumText(string(b), false, func(s string) (uint64, error) {
+		x, err := ExceptionDeviationFormatFromString(s)
+		return uint64(x), err
+	})
+	if err != nil {
+		return err
 	}
+	*v = ExceptionDeviationFormat(n)
+	return nil
 }
 
 const (
@@ -0,0 +1,155 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+// NewSelectOperand returns an operand which selects the value of the
+// event field with the given browse path relative to the BaseEventType.
+// The names are in namespace 0, e.g.
+//
+//	ua.NewSelectOperand("Severity")
+//	ua.NewSelectOperand("EnabledState", "Id")
+//
+// The operands are used as select clauses of an EventFilter and as
+// operands of a ContentFilterElement.
+//
+// Specification: Part 4, 7.4.4.5
+func NewSelectOperand(path ...string) *SimpleAttributeOperand {
+	qn := make([]*QualifiedName, len(path))
+	for i, name := range path {
+		qn[i] = &QualifiedName{NamespaceIndex: 0, Name: name}
+	}
+	return newSelectOperand(nil, qn)
+}
+
+func newSelectOperand(typeDef *NodeID, path []*QualifiedName) *SimpleAttributeOperand {
+	if typeDef == nil {
+		typeDef = NewNumericNodeID(0, id.BaseEventType)
+	}
+	return &SimpleAttributeOperand{
+		TypeDefinitionID: typeDef,
+		BrowsePath:       path,
+		AttributeID:      AttributeIDValue,
+	}
+}
+
+// FieldName returns the browse path of the operand as a string
+// with the names separated by '/', e.g. "EnabledState/Id".
+func (o *SimpleAttributeOperand) FieldName() string {
+	names := make([]string, len(o.BrowsePath))
+	for i, qn := range o.BrowsePath {
+		names[i] = qn.Name
+	}
+	return strings.Join(names, "/")
+}
+
+// NewLiteralOperand returns an operand with a literal value.
+// It panics if the value cannot be converted to a Variant.
+//
+// Specification: Part 4, 7.4.4.3
+func NewLiteralOperand(v interface{}) *LiteralOperand {
+	return &LiteralOperand{Value: MustVariant(v)}
+}
+
+// NewContentFilterElement returns an element of a where clause which
+// applies the operator to the operands. The operands must be one of
+// *SimpleAttributeOperand, *LiteralOperand, *ElementOperand or
+// *AttributeOperand. Other values are wrapped in a literal operand,
+// e.g.
+//
+//	ua.NewContentFilterElement(ua.FilterOperatorGreaterThanOrEqual, ua.NewSelectOperand("Severity"), uint16(500))
+//
+// It panics if a value cannot be converted to a Variant.
+//
+// Specification: Part 4, 7.4.1
+func NewContentFilterElement(op FilterOperator, operands ...interface{}) *ContentFilterElement {
+	el := &ContentFilterElement{FilterOperator: op}
+	for _, o := range operands {
+		switch o.(type) {
+		case *SimpleAttributeOperand, *LiteralOperand, *ElementOperand, *AttributeOperand:
+		default:
+			o = NewLiteralOperand(o)
+		}
+		el.FilterOperands = append(el.FilterOperands, NewExtensionObject(o))
+	}
+	return el
+}
+
+// EventFilterBuilder builds an EventFilter with a fluent API, e.g.
+//
+//	filter := ua.NewEventFilter().
+//		Select(nil, "Severity").
+//		Select(nil, "Message").
+//		Where(ua.FilterOperatorGreaterThanOrEqual, ua.NewSelectOperand("Severity"), uint16(500)).
+//		ExtensionObject()
+//
+// The zero value is not usable. Use NewEventFilter instead.
+type EventFilterBuilder struct {
+	filter *EventFilter
+}
+
+// NewEventFilter returns a builder for an EventFilter without select
+// and where clauses.
+//
+// Specification: Part 4, 7.17.3
+func NewEventFilter() *EventFilterBuilder {
+	return &EventFilterBuilder{
+		filter: &EventFilter{
+			SelectClauses: []*SimpleAttributeOperand{},
+			WhereClause:   &ContentFilter{Elements: []*ContentFilterElement{}},
+		},
+	}
+}
+
+// Select adds a select clause for the event field with the given browse
+// path relative to the event type typeDef. If typeDef is nil the path is
+// relative to the BaseEventType. The names of the browse path can have a
+// namespace prefix, e.g. "2:Temperature". Names without prefix are in
+// namespace 0.
+func (b *EventFilterBuilder) Select(typeDef *NodeID, browsePath ...string) *EventFilterBuilder {
+	qn := make([]*QualifiedName, len(browsePath))
+	for i, name := range browsePath {
+		qn[i] = parseBrowseName(name)
+	}
+	b.filter.SelectClauses = append(b.filter.SelectClauses, newSelectOperand(typeDef, qn))
+	return b
+}
+
+// Where adds an element to the where clause. The operands are handled
+// as in NewContentFilterElement. The first element is the root of the
+// where clause and can refer to other elements with an ElementOperand.
+func (b *EventFilterBuilder) Where(op FilterOperator, operands ...interface{}) *EventFilterBuilder {
+	b.filter.WhereClause.Elements = append(b.filter.WhereClause.Elements, NewContentFilterElement(op, operands...))
+	return b
+}
+
+// EventFilter returns the event filter.
+func (b *EventFilterBuilder) EventFilter() *EventFilter {
+	return b.filter
+}
+
+// ExtensionObject returns the event filter wrapped in an extension object
+// which can be used as filter in the MonitoringParameters of a monitored
+// item.
+func (b *EventFilterBuilder) ExtensionObject() *ExtensionObject {
+	return NewExtensionObject(b.filter)
+}
+
+// parseBrowseName parses a browse name with an optional namespace
+// prefix, e.g. "2:Temperature".
+func parseBrowseName(s string) *QualifiedName {
+	if i := strings.IndexByte(s, ':'); i > 0 {
+		if ns, err := strconv.ParseUint(s[:i], 10, 16); err == nil {
+			return &QualifiedName{NamespaceIndex: uint16(ns), Name: s[i+1:]}
+		}
+	}
+	return &QualifiedName{NamespaceIndex: 0, Name: s}
+}
@@ -0,0 +1,88 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+func TestNewSelectOperand(t *testing.T) {
+	o := NewSelectOperand("EnabledState", "Id")
+	want := &SimpleAttributeOperand{
+		TypeDefinitionID: NewNumericNodeID(0, id.BaseEventType),
+		BrowsePath: []*QualifiedName{
+			{NamespaceIndex: 0, Name: "EnabledState"},
+			{NamespaceIndex: 0, Name: "Id"},
+		},
+		AttributeID: AttributeIDValue,
+	}
+	verify.Values(t, "", o, want)
+	verify.Values(t, "", o.FieldName(), "EnabledState/Id")
+}
+
+func TestNewContentFilterElement(t *testing.T) {
+	sel := NewSelectOperand("Severity")
+	el := NewContentFilterElement(FilterOperatorGreaterThanOrEqual, sel, uint16(500))
+	want := &ContentFilterElement{
+		FilterOperator: FilterOperatorGreaterThanOrEqual,
+		FilterOperands: []*ExtensionObject{
+			NewExtensionObject(sel),
+			NewExtensionObject(&LiteralOperand{Value: MustVariant(uint16(500))}),
+		},
+	}
+	verify.Values(t, "", el, want)
+	verify.Values(t, "", el.FilterOperands[1].TypeID.NodeID.IntID(), uint32(id.LiteralOperand_Encoding_DefaultBinary))
+}
+
+func TestEventFilterBuilder(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		eo := NewEventFilter().ExtensionObject()
+		b, err := eo.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ExtensionObject
+		if _, err := got.Decode(b); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", got.Value, &EventFilter{
+			SelectClauses: []*SimpleAttributeOperand{},
+			WhereClause:   &ContentFilter{Elements: []*ContentFilterElement{}},
+		})
+	})
+
+	t.Run("select and where", func(t *testing.T) {
+		typeDef := NewNumericNodeID(2, 1000)
+		f := NewEventFilter().
+			Select(nil, "Severity").
+			Select(typeDef, "2:Sensor", "Temperature").
+			Where(FilterOperatorOfType, NewNumericNodeID(0, id.AlarmConditionType)).
+			EventFilter()
+
+		want := &EventFilter{
+			SelectClauses: []*SimpleAttributeOperand{
+				NewSelectOperand("Severity"),
+				{
+					TypeDefinitionID: typeDef,
+					BrowsePath: []*QualifiedName{
+						{NamespaceIndex: 2, Name: "Sensor"},
+						{NamespaceIndex: 0, Name: "Temperature"},
+					},
+					AttributeID: AttributeIDValue,
+				},
+			},
+			WhereClause: &ContentFilter{
+				Elements: []*ContentFilterElement{
+					NewContentFilterElement(FilterOperatorOfType, NewNumericNodeID(0, id.AlarmConditionType)),
+				},
+			},
+		}
+		verify.Values(t, "", f, want)
+	})
+}
@@ -6,6 +6,7 @@ package ua
 
 import (
 	"encoding/base64"
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -24,10 +25,28 @@ type ExpandedNodeID struct {
 	ServerIndex  uint32
 }
 
+// String returns the string representation of the ExpandedNodeID
+// in the format described by ParseExpandedNodeID.
 func (a ExpandedNodeID) String() string {
-	return a.NodeID.String()
+	s := a.NodeID.String()
+	if a.NamespaceURI != "" {
+		s = "nsu=" + namespaceURIEscaper.Replace(a.NamespaceURI) + ";" + a.NodeID.identifier()
+	}
+	if a.ServerIndex > 0 {
+		s = fmt.Sprintf("svr=%d;%s", a.ServerIndex, s)
+	}
+	return s
 }
 
+// namespaceURIEscaper escapes the characters of a namespace URI which
+// cannot be used in the string representation of an ExpandedNodeID.
+//
+// Specification: Part 6, 5.3.1.11
+var namespaceURIEscaper = strings.NewReplacer("%", "%25", ";", "%3B")
+
+// namespaceURIUnescaper reverses namespaceURIEscaper.
+var namespaceURIUnescaper = strings.NewReplacer("%25", "%", "%3B", ";", "%3b", ";")
+
 // NewExpandedNodeID creates a new ExpandedNodeID.
 func NewExpandedNodeID(nodeID *NodeID, uri string, idx uint32) *ExpandedNodeID {
 	e := &ExpandedNodeID{
@@ -75,7 +94,8 @@ func NewStringExpandedNodeID(ns uint16, id string) *ExpandedNodeID {
 	}
 }
 
-// NewGUIDExpandedNodeID creates a GUID expanded node id.
+// NewGUIDExpandedNodeID creates a GUID expanded node id. It panics if
+// id is not a valid GUID.
 func NewGUIDExpandedNodeID(ns uint16, id string) *ExpandedNodeID {
 	return &ExpandedNodeID{
 		NodeID: NewGUIDNodeID(ns, id),
@@ -91,6 +111,11 @@ func NewByteStringExpandedNodeID(ns uint16, id []byte) *ExpandedNodeID {
 
 func (e *ExpandedNodeID) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	e.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (e *ExpandedNodeID) decodeBuffer(buf *Buffer) {
 	e.NodeID = new(NodeID)
 	buf.ReadStruct(e.NodeID)
 	if e.HasNamespaceURI() {
@@ -99,7 +124,6 @@ func (e *ExpandedNodeID) Decode(b []byte) (int, error) {
 	if e.HasServerIndex() {
 		e.ServerIndex = buf.ReadUint32()
 	}
-	return buf.Pos(), buf.Error()
 }
 
 func (e *ExpandedNodeID) Encode() ([]byte, error) {
@@ -126,26 +150,48 @@ func (e *ExpandedNodeID) HasServerIndex() bool {
 }
 
 // ParseExpandedNodeID returns a node id from a string definition of the format
-// '{ns,nsu}=<namespace>;{s,i,b,g}=<identifier>'.
+// '[svr=<serverindex>;]{ns,nsu}=<namespace>;{s,i,b,g}=<identifier>'.
 //
 // The 's=' prefix can be omitted for string node ids in namespace 0.
+// Byte string identifiers are base64 encoded and GUID identifiers can
+// be enclosed in braces.
 //
 // For numeric ids the smallest possible type which can store the namespace
 // and id value is returned.
 //
+// The characters '%' and ';' of namespace URIs are escaped as %25 and %3B.
+//
 // Namespace URIs are resolved to ids from the provided list of namespaces.
+// If the list is nil the namespace URI is not resolved and the namespace
+// id is 0.
 func ParseExpandedNodeID(s string, ns []string) (*ExpandedNodeID, error) {
 	if s == "" {
 		return NewTwoByteExpandedNodeID(0), nil
 	}
 
+	// parse server index
+	v := s
+	var svr uint32
+	if strings.HasPrefix(v, "svr=") {
+		p := strings.SplitN(v, ";", 2)
+		if len(p) != 2 {
+			return nil, errors.Errorf("invalid node id: %s", s)
+		}
+		n, err := strconv.ParseUint(p[0][4:], 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid server index: %s", s)
+		}
+		svr, v = uint32(n), p[1]
+	}
+
 	var nsval, idval string
 
-	p := strings.SplitN(s, ";", 2)
-	switch len(p) {
-	case 1:
-		nsval, idval = "ns=0", p[0]
-	case 2:
+	// identifiers in namespace 0 can contain a ';'
+	p := strings.SplitN(v, ";", 2)
+	switch {
+	case len(p) == 1 || hasIdentifierPrefix(v):
+		nsval, idval = "ns=0", v
+	default:
 		nsval, idval = p[0], p[1]
 	}
 
@@ -154,15 +200,18 @@ func ParseExpandedNodeID(s string, ns []string) (*ExpandedNodeID, error) {
 	var nsu string
 	switch {
 	case strings.HasPrefix(nsval, "nsu="):
+		nsu = namespaceURIUnescaper.Replace(strings.TrimPrefix(nsval, "nsu="))
+		if nsu == "" {
+			return nil, errors.Errorf("invalid namespace uri: %s", s)
+		}
 		if ns == nil {
-			return nil, errors.Errorf("namespace urls require a server NamespaceArray")
+			break
 		}
 
-		nsuval := strings.TrimPrefix(nsval, "nsu=")
 		ok := false
 		for id, uri := range ns {
-			if uri == nsuval {
-				nsid, nsu = uint16(id), uri
+			if uri == nsu {
+				nsid = uint16(id)
 				ok = true
 				break
 			}
@@ -194,36 +243,46 @@ func ParseExpandedNodeID(s string, ns []string) (*ExpandedNodeID, error) {
 		}
 		switch {
 		case nsid == 0 && id < 256:
-			return NewExpandedNodeID(NewTwoByteNodeID(byte(id)), "", 0), nil
-		case nsid < 256 && id < math.MaxUint16:
-			return NewExpandedNodeID(NewFourByteNodeID(byte(nsid), uint16(id)), nsu, 0), nil
+			return NewExpandedNodeID(NewTwoByteNodeID(byte(id)), nsu, svr), nil
+		case nsid < 256 && id <= math.MaxUint16:
+			return NewExpandedNodeID(NewFourByteNodeID(byte(nsid), uint16(id)), nsu, svr), nil
 		case id <= math.MaxUint32:
-			return NewExpandedNodeID(NewNumericNodeID(nsid, uint32(id)), nsu, 0), nil
+			return NewExpandedNodeID(NewNumericNodeID(nsid, uint32(id)), nsu, svr), nil
 		default:
 			return nil, errors.Errorf("numeric id out of range (0..2^32-1): %s", s)
 		}
 
 	case strings.HasPrefix(idval, "s="):
-		return NewExpandedNodeID(NewStringNodeID(nsid, idval[2:]), nsu, 0), nil
+		return NewExpandedNodeID(NewStringNodeID(nsid, idval[2:]), nsu, svr), nil
 
 	case strings.HasPrefix(idval, "g="):
-		n := NewGUIDNodeID(nsid, idval[2:])
-		if n == nil || n.StringID() == "" {
+		g, err := ParseGUID(idval[2:])
+		if err != nil {
 			return nil, errors.Errorf("invalid guid node id: %s", s)
 		}
-		return NewExpandedNodeID(n, nsu, 0), nil
+		n := &NodeID{mask: NodeIDTypeGUID, ns: nsid, gid: g}
+		return NewExpandedNodeID(n, nsu, svr), nil
 
 	case strings.HasPrefix(idval, "b="):
 		b, err := base64.StdEncoding.DecodeString(idval[2:])
 		if err != nil {
 			return nil, errors.Errorf("invalid opaque node id: %s", s)
 		}
-		return NewExpandedNodeID(NewByteStringNodeID(nsid, b), nsu, 0), nil
+		return NewExpandedNodeID(NewByteStringNodeID(nsid, b), nsu, svr), nil
 
 	case strings.HasPrefix(idval, "ns="):
 		return nil, errors.Errorf("invalid node id: %s", s)
 
 	default:
-		return NewExpandedNodeID(NewStringNodeID(nsid, idval), nsu, 0), nil
+		return NewExpandedNodeID(NewStringNodeID(nsid, idval), nsu, svr), nil
+	}
+}
+
+func hasIdentifierPrefix(s string) bool {
+	for _, prefix := range []string{"i=", "s=", "g=", "b="} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
 	}
+	return false
 }
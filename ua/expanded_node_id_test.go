@@ -78,7 +78,9 @@ func TestParseExpandedNodeID(t *testing.T) {
 		{s: "abc=0;i=2", err: errors.New("invalid node id: abc=0;i=2")},
 		{s: "ns=0;i=1;s=2", err: errors.New("invalid numeric id: ns=0;i=1;s=2")},
 		{s: "ns=0", err: errors.New("invalid node id: ns=0")},
-		{s: "nsu=abc;i=1", err: errors.New("namespace urls require a server NamespaceArray")},
+		{s: "nsu=;i=1", err: errors.New("invalid namespace uri: nsu=;i=1")},
+		{s: "svr=x;i=1", err: errors.New("invalid server index: svr=x;i=1")},
+		{s: "svr=1", err: errors.New("invalid node id: svr=1")},
 		{s: "ns=65536;i=1", err: errors.New("namespace id out of range (0..65535): ns=65536;i=1")},
 		{s: "ns=abc;i=1", err: errors.New("invalid namespace id: ns=abc;i=1")},
 		{s: "ns=1;i=abc", err: errors.New("invalid numeric id: ns=1;i=abc")},
@@ -92,6 +94,15 @@ func TestParseExpandedNodeID(t *testing.T) {
 		{s: "nsu=abc;b=YWJj", ns: []string{"", "abc"}, n: NewExpandedNodeID(NewByteStringNodeID(1, []byte{'a', 'b', 'c'}), "abc", 0)},
 		{s: "nsu=abc;a", ns: []string{"", "abc"}, n: NewExpandedNodeID(NewStringNodeID(1, "a"), "abc", 0)},
 		{s: "nsu=abc;s=a", ns: []string{"", "abc"}, n: NewExpandedNodeID(NewStringNodeID(1, "a"), "abc", 0)},
+		{s: "nsu=urn:a%3bb;i=2", ns: []string{"", "urn:a;b"}, n: NewExpandedNodeID(NewFourByteNodeID(1, 2), "urn:a;b", 0)},
+
+		// unresolved nsu and svr
+		{s: "nsu=http://example.com/UA/;s=foo", n: NewExpandedNodeID(NewStringNodeID(0, "foo"), "http://example.com/UA/", 0)},
+		{s: "nsu=abc;i=1", n: NewExpandedNodeID(NewTwoByteNodeID(1), "abc", 0)},
+		{s: "nsu=urn:a%3Bb%25c;s=foo", n: NewExpandedNodeID(NewStringNodeID(0, "foo"), "urn:a;b%c", 0)},
+		{s: "svr=1;ns=3;i=42", n: NewExpandedNodeID(NewFourByteNodeID(3, 42), "", 1)},
+		{s: "svr=2;nsu=abc;g={5eac051c-c313-43d7-b790-24aa2c3cfd37}", n: NewExpandedNodeID(NewGUIDNodeID(0, "5eac051c-c313-43d7-b790-24aa2c3cfd37"), "abc", 2)},
+		{s: "svr=1;s=foo;bar", n: NewExpandedNodeID(NewStringNodeID(0, "foo;bar"), "", 1)},
 
 		// nsu error flows
 		{s: "nsu=abc;i=2253", ns: []string{}, err: errors.New("namespace uri nsu=abc not found in the server NamespaceArray []string{}")},
@@ -110,3 +121,53 @@ func TestParseExpandedNodeID(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandedNodeIDString(t *testing.T) {
+	cases := []string{
+		"i=1",
+		"ns=3;s=foo",
+		"s=foo;bar",
+		"nsu=http://example.com/UA/;s=foo",
+		"svr=1;ns=3;i=42",
+		"svr=1;nsu=abc;b=YWJj",
+		"nsu=abc;g=5EAC051C-C313-43D7-B790-24AA2C3CFD37",
+		"svr=2;nsu=urn:a%3Bb%25c;i=1",
+	}
+	for _, s := range cases {
+		t.Run(s, func(t *testing.T) {
+			n, err := ParseExpandedNodeID(s, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := n.String(), s; got != want {
+				t.Fatalf("got %s want %s", got, want)
+			}
+		})
+	}
+}
+
+func FuzzParseExpandedNodeID(f *testing.F) {
+	for _, s := range []string{
+		"i=1",
+		"ns=1;s=foo;bar",
+		"ns=1;g={5eac051c-c313-43d7-b790-24aa2c3cfd37}",
+		"ns=1;b=YWJj",
+		"nsu=http://example.com/UA/;s=foo",
+		"svr=1;ns=3;i=42",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		n, err := ParseExpandedNodeID(s, nil)
+		if err != nil {
+			return
+		}
+		n2, err := ParseExpandedNodeID(n.String(), nil)
+		if err != nil {
+			t.Fatalf("%q: cannot parse %q: %s", s, n.String(), err)
+		}
+		if !reflect.DeepEqual(n, n2) {
+			t.Fatalf("%q: got %#v after round trip want %#v", s, n2, n)
+		}
+	})
+}
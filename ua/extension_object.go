@@ -5,7 +5,11 @@
 package ua
 
 import (
+	"reflect"
+	"sync"
+
 	"github.com/zzylovesll/myOpcUa/debug"
+	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/id"
 )
 
@@ -20,6 +24,152 @@ func RegisterExtensionObject(typeID *NodeID, v interface{}) {
 	}
 }
 
+// RegisterExtensionObjectType registers a Go struct for the binary
+// encoding id of a custom structured data type at runtime. v must be
+// a struct or a pointer to one. Its fields are encoded in declaration
+// order with the binary codec and the encoding of single fields can be
+// controlled with the opcua struct tag:
+//
+//	type Recipe struct {
+//		Mask   uint32
+//		Name   string
+//		Target float64  `opcua:"switch=Mask,bit=0"`
+//		Steps  []int32
+//		Cache  []string `opcua:"-"`
+//	}
+//
+//	err := ua.RegisterExtensionObjectType(ua.NewNumericNodeID(2, 5002), new(Recipe))
+//
+// The tag options are "-" to skip a field, "switch=<field>" with
+// "bit=<n>" or "value=<n>" for fields which are only encoded if the
+// switch field has the bit set or the value, and "length=<field>"
+// for slices which are encoded without a length prefix since their
+// length is in another field.
+//
+// Extension objects with the encoding id are decoded into a pointer to
+// the struct. Registering a different type for an id which is already
+// registered returns an error. Registering the same type again is a
+// no-op.
+func RegisterExtensionObjectType(binaryEncodingID *NodeID, v interface{}) error {
+	if binaryEncodingID == nil {
+		return errors.New("missing binary encoding id")
+	}
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return errors.New("missing extension object type")
+	}
+	if typ.Kind() == reflect.Struct {
+		v = reflect.New(typ).Interface()
+		typ = reflect.TypeOf(v)
+	}
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("extension object type %s is not a struct", typ)
+	}
+	if _, err := structFields(typ.Elem()); err != nil {
+		return errors.Errorf("invalid extension object type: %s", err)
+	}
+	return eotypes.Register(binaryEncodingID, v)
+}
+
+// uritypes contains the extension objects which are registered with the
+// URI of their namespace.
+var uritypes = &uriTypeRegistry{
+	types: make(map[uriTypeID]reflect.Type),
+	ids:   make(map[reflect.Type]uriTypeID),
+}
+
+// RegisterExtensionObjectURI registers an extension object type with the
+// numeric binary encoding id in the namespace with the URI nsURI. The
+// namespace index of a type id is resolved with the namespace array of
+// the server when an extension object is decoded, e.g.
+//
+//	ua.RegisterExtensionObjectURI("urn:vendor:types", 5002, new(Recipe))
+//
+// decodes an extension object with the type id ns=3;i=5002 into a
+// *Recipe if the server has the namespace "urn:vendor:types" at index 3.
+// The client provides the namespace array to the decoder after it has
+// read it from the server. Extension objects of the type are encoded with
+// a type id which contains the namespace URI.
+//
+// It panics if the id is already registered as a different type.
+func RegisterExtensionObjectURI(nsURI string, id uint32, v interface{}) {
+	if err := uritypes.register(uriTypeID{nsURI, id}, v); err != nil {
+		panic("Extension object " + err.Error())
+	}
+}
+
+// uriTypeID is the id of an extension object type which is registered
+// with the URI of its namespace.
+type uriTypeID struct {
+	uri string
+	id  uint32
+}
+
+// uriTypeRegistry is a registry for extension object types by the URI
+// of their namespace and their numeric id.
+type uriTypeRegistry struct {
+	mu    sync.RWMutex
+	types map[uriTypeID]reflect.Type
+	ids   map[reflect.Type]uriTypeID
+}
+
+func (r *uriTypeRegistry) register(id uriTypeID, v interface{}) error {
+	if id.uri == "" {
+		return errors.New("missing namespace uri")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	typ := reflect.TypeOf(v)
+	if cur := r.types[id]; cur != nil && cur != typ {
+		return errors.Errorf("nsu=%s;i=%d is already registered as %v", id.uri, id.id, cur)
+	}
+	r.types[id] = typ
+	if _, exists := r.ids[typ]; !exists {
+		r.ids[typ] = id
+	}
+	return nil
+}
+
+// new returns a new instance of the type with the type id or nil if the
+// type is unknown. The namespace of a type id without a namespace URI is
+// resolved with the namespace array.
+func (r *uriTypeRegistry) new(typeID *ExpandedNodeID, namespaces []string) interface{} {
+	switch typeID.NodeID.Type() {
+	case NodeIDTypeTwoByte, NodeIDTypeFourByte, NodeIDTypeNumeric:
+	default:
+		return nil
+	}
+	uri := typeID.NamespaceURI
+	if uri == "" {
+		ns := int(typeID.NodeID.Namespace())
+		if ns >= len(namespaces) {
+			return nil
+		}
+		uri = namespaces[ns]
+	}
+
+	r.mu.RLock()
+	typ := r.types[uriTypeID{uri, typeID.NodeID.IntID()}]
+	r.mu.RUnlock()
+	if typ == nil {
+		return nil
+	}
+	return reflect.New(typ.Elem()).Interface()
+}
+
+// lookup returns the type id with the namespace URI of the type of v or
+// nil if the type is not registered.
+func (r *uriTypeRegistry) lookup(v interface{}) *ExpandedNodeID {
+	r.mu.RLock()
+	id, ok := r.ids[reflect.TypeOf(v)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return NewExpandedNodeID(NewNumericNodeID(0, id.id), id.uri, 0)
+}
+
 // These flags define the value type of an ExtensionObject.
 // They cannot be combined.
 const (
@@ -28,6 +178,12 @@ const (
 	ExtensionObjectXML    = 2
 )
 
+// ExtensionObjectBody is the encoded body of an extension object with
+// an unknown type id or an empty body. It is encoded again unchanged so
+// that the object can be forwarded without knowing its type. A nil body
+// is encoded with the length -1.
+type ExtensionObjectBody []byte
+
 // ExtensionObject is encoded as sequence of bytes prefixed by the NodeId of its DataTypeEncoding
 // and the number of bytes encoded.
 //
@@ -49,39 +205,72 @@ func NewExtensionObject(value interface{}) *ExtensionObject {
 
 func (e *ExtensionObject) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	e.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (e *ExtensionObject) decodeBuffer(buf *Buffer) {
 	e.TypeID = new(ExpandedNodeID)
 	buf.ReadStruct(e.TypeID)
 
 	e.EncodingMask = buf.ReadByte()
 	if e.EncodingMask == ExtensionObjectEmpty {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	length := buf.ReadUint32()
-	if length == 0 || length == 0xffffffff || buf.Error() != nil {
-		return buf.Pos(), buf.Error()
+	if buf.Error() != nil {
+		return
+	}
+	switch length {
+	case 0:
+		e.Value = ExtensionObjectBody{}
+		return
+	case 0xffffffff:
+		e.Value = ExtensionObjectBody(nil)
+		return
+	}
+	if max := buf.decodeLimits().MaxByteStringLength; exceeds(int64(length), max) {
+		buf.err = limitError("extension object length", int64(length), max)
+		return
 	}
 
-	body := NewBuffer(buf.ReadN(int(length)))
+	// the body is decoded with the limits and the nesting depth of
+	// the enclosing message.
+	body := buf.child(buf.ReadN(int(length)))
 	if buf.Error() != nil {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	if e.EncodingMask == ExtensionObjectXML {
 		e.Value = new(XMLElement)
 		body.ReadStruct(e.Value)
-		return buf.Pos(), body.Error()
+		buf.err = body.Error()
+		return
 	}
 
 	typeID := e.TypeID.NodeID
 	e.Value = eotypes.New(typeID)
 	if e.Value == nil {
+		e.Value = uritypes.new(e.TypeID, buf.namespaces)
+	}
+	if e.Value == nil {
+		// fall back to the definition of the data type if there
+		// is no Go type for it.
+		if s := datatypes.newStructure(typeID); s != nil {
+			e.Value = s
+		}
+	}
+	if e.Value == nil {
+		// keep the body of an unknown type so that it is not lost
+		// when the object is encoded again.
 		debug.Printf("ua: unknown extension object %s", typeID)
-		return buf.Pos(), buf.Error()
+		e.Value = ExtensionObjectBody(append([]byte(nil), body.Bytes()...))
+		return
 	}
 
 	body.ReadStruct(e.Value)
-	return buf.Pos(), body.Error()
+	buf.err = body.Error()
 }
 
 func (e *ExtensionObject) Encode() ([]byte, error) {
@@ -95,6 +284,21 @@ func (e *ExtensionObject) Encode() ([]byte, error) {
 		return buf.Bytes(), buf.Error()
 	}
 
+	switch v := e.Value.(type) {
+	case nil:
+		// an object with an empty body
+		buf.WriteUint32(0)
+		return buf.Bytes(), buf.Error()
+	case ExtensionObjectBody:
+		if v == nil {
+			buf.WriteInt32(-1)
+			return buf.Bytes(), buf.Error()
+		}
+		buf.WriteUint32(uint32(len(v)))
+		buf.Write(v)
+		return buf.Bytes(), buf.Error()
+	}
+
 	body := NewBuffer(nil)
 	body.WriteStruct(e.Value)
 	if body.Error() != nil {
@@ -116,7 +320,7 @@ func (e *ExtensionObject) UpdateMask() {
 }
 
 func ExtensionObjectTypeID(v interface{}) *ExpandedNodeID {
-	switch v.(type) {
+	switch v := v.(type) {
 	case *AnonymousIdentityToken:
 		return NewFourByteExpandedNodeID(0, id.AnonymousIdentityToken_Encoding_DefaultBinary)
 	case *UserNameIdentityToken:
@@ -127,10 +331,18 @@ func ExtensionObjectTypeID(v interface{}) *ExpandedNodeID {
 		return NewFourByteExpandedNodeID(0, id.IssuedIdentityToken_Encoding_DefaultBinary)
 	case *ServerStatusDataType:
 		return NewFourByteExpandedNodeID(0, id.ServerStatusDataType_Encoding_DefaultBinary)
+	case *Structure:
+		if v.Definition != nil && v.Definition.DefaultEncodingID != nil {
+			return &ExpandedNodeID{NodeID: v.Definition.DefaultEncodingID}
+		}
+		return NewTwoByteExpandedNodeID(0)
 	default:
 		if id := eotypes.Lookup(v); id != nil {
 			return &ExpandedNodeID{NodeID: id}
 		}
+		if id := uritypes.lookup(v); id != nil {
+			return id
+		}
 		return NewTwoByteExpandedNodeID(0)
 	}
 }
@@ -6,6 +6,8 @@ package ua
 
 import (
 	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
 )
 
 func TestExtensionObject(t *testing.T) {
@@ -24,6 +26,360 @@ func TestExtensionObject(t *testing.T) {
 				0x09, 0x00, 0x00, 0x00, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73,
 			},
 		},
+		{
+			Name:   "binary-empty-body",
+			Struct: &ExtensionObject{TypeID: NewFourByteExpandedNodeID(0, 321), EncodingMask: ExtensionObjectBinary, Value: ExtensionObjectBody{}},
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x00, 0x41, 0x01,
+				// EncodingMask
+				0x01,
+				// Length
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestExtensionObjectArray(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name: "binary, null and unknown",
+			Struct: MustVariant([]*ExtensionObject{
+				NewExtensionObject(&AnonymousIdentityToken{PolicyID: "anonymous"}),
+				{TypeID: NewTwoByteExpandedNodeID(0), EncodingMask: ExtensionObjectEmpty},
+				{TypeID: NewFourByteExpandedNodeID(1, 9999), EncodingMask: ExtensionObjectBinary, Value: ExtensionObjectBody{0xaa, 0xbb, 0xcc}},
+			}),
+			Bytes: []byte{
+				// variant encoding mask
+				0x96,
+				// array length
+				0x03, 0x00, 0x00, 0x00,
+
+				// element 1: TypeID
+				0x01, 0x00, 0x41, 0x01,
+				// EncodingMask
+				0x01,
+				// Length
+				0x0d, 0x00, 0x00, 0x00,
+				// AnonymousIdentityToken
+				0x09, 0x00, 0x00, 0x00, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73,
+
+				// element 2: TypeID
+				0x00, 0x00,
+				// EncodingMask
+				0x00,
+
+				// element 3: TypeID
+				0x01, 0x01, 0x0f, 0x27,
+				// EncodingMask
+				0x01,
+				// Length
+				0x03, 0x00, 0x00, 0x00,
+				// Body
+				0xaa, 0xbb, 0xcc,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestExtensionObjectUnknownRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		b    []byte
+	}{
+		{
+			name: "numeric type id",
+			b: []byte{
+				// TypeID ns=1;i=9999
+				0x01, 0x01, 0x0f, 0x27,
+				// EncodingMask
+				0x01,
+				// Length
+				0x05, 0x00, 0x00, 0x00,
+				// Body
+				0x01, 0x02, 0x03, 0x04, 0x05,
+			},
+		},
+		{
+			name: "string type id",
+			b: []byte{
+				// TypeID ns=3;s=Vendor.Custom
+				0x03, 0x03, 0x00,
+				0x0d, 0x00, 0x00, 0x00, 'V', 'e', 'n', 'd', 'o', 'r', '.', 'C', 'u', 's', 't', 'o', 'm',
+				// EncodingMask
+				0x01,
+				// Length
+				0x02, 0x00, 0x00, 0x00,
+				// Body
+				0xff, 0x00,
+			},
+		},
+		{
+			name: "null body",
+			b: []byte{
+				// TypeID ns=1;i=9999
+				0x01, 0x01, 0x0f, 0x27,
+				// EncodingMask
+				0x01,
+				// Length
+				0xff, 0xff, 0xff, 0xff,
+			},
+		},
+		{
+			name: "empty body",
+			b: []byte{
+				// TypeID ns=1;i=9999
+				0x01, 0x01, 0x0f, 0x27,
+				// EncodingMask
+				0x01,
+				// Length
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := new(ExtensionObject)
+			n, err := e.Decode(c.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(c.b) {
+				t.Fatalf("decoded %d bytes want %d", n, len(c.b))
+			}
+			if _, ok := e.Value.(ExtensionObjectBody); !ok {
+				t.Fatalf("got value %T want ExtensionObjectBody", e.Value)
+			}
+			b, err := e.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "", b, c.b)
+
+			// the object is forwarded unchanged in a variant
+			v := MustVariant(e)
+			vb, err := v.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "variant", vb, append([]byte{byte(TypeIDExtensionObject)}, c.b...))
+		})
+	}
+}
+
+type testTaggedType struct {
+	Mask   uint32
+	Name   string
+	Target float64 `opcua:"switch=Mask,bit=0"`
+	Kind   int32
+	I      int32  `opcua:"switch=Kind,value=1"`
+	S      string `opcua:"switch=Kind,value=2"`
+	Count  int32
+	Items  []uint16 `opcua:"length=Count"`
+	Cache  string   `opcua:"-"`
+}
+
+func TestRegisterExtensionObjectType(t *testing.T) {
+	encID := NewFourByteNodeID(2, 7002)
+	if err := RegisterExtensionObjectType(encID, testTaggedType{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterExtensionObjectType(encID, new(testTaggedType)); err != nil {
+		t.Fatalf("registering the same type again: %v", err)
+	}
+	if err := RegisterExtensionObjectType(encID, new(AnonymousIdentityToken)); err == nil {
+		t.Fatal("got nil want error for conflicting registration")
+	}
+
+	cases := []CodecTestCase{
+		{
+			Name: "union and length",
+			Struct: NewExtensionObject(&testTaggedType{
+				Name:  "a",
+				Kind:  2,
+				S:     "x",
+				Count: 2,
+				Items: []uint16{1, 2},
+			}),
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x02, 0x5a, 0x1b,
+				// EncodingMask
+				0x01,
+				// Length
+				0x1a, 0x00, 0x00, 0x00,
+				// Mask
+				0x00, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+				// Kind
+				0x02, 0x00, 0x00, 0x00,
+				// S
+				0x01, 0x00, 0x00, 0x00, 0x78,
+				// Count
+				0x02, 0x00, 0x00, 0x00,
+				// Items
+				0x01, 0x00, 0x02, 0x00,
+			},
+		},
+		{
+			Name: "optional field",
+			Struct: NewExtensionObject(&testTaggedType{
+				Mask:   1,
+				Name:   "a",
+				Target: 1.5,
+				Kind:   1,
+				I:      7,
+				Items:  []uint16{},
+			}),
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x02, 0x5a, 0x1b,
+				// EncodingMask
+				0x01,
+				// Length
+				0x1d, 0x00, 0x00, 0x00,
+				// Mask
+				0x01, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+				// Target
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f,
+				// Kind
+				0x01, 0x00, 0x00, 0x00,
+				// I
+				0x07, 0x00, 0x00, 0x00,
+				// Count
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
 	}
 	RunCodecTest(t, cases)
+
+	if _, err := Encode(&testTaggedType{Count: 1}); err == nil {
+		t.Fatal("got nil want error for length mismatch")
+	}
+}
+
+func TestRegisterExtensionObjectTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"not a struct", new(int32)},
+		{"unknown switch", new(struct {
+			A int32 `opcua:"switch=B,bit=0"`
+			B uint32
+		})},
+		{"missing bit", new(struct {
+			B uint32
+			A int32 `opcua:"switch=B"`
+		})},
+		{"length on non-slice", new(struct {
+			N int32
+			A int32 `opcua:"length=N"`
+		})},
+		{"invalid option", new(struct {
+			A int32 `opcua:"optional"`
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterExtensionObjectType(NewFourByteNodeID(2, 7999), tt.v); err == nil {
+				t.Fatal("got nil want error")
+			}
+		})
+	}
+}
+
+type testURIType struct {
+	A int32
+	B string
+}
+
+func TestRegisterExtensionObjectURI(t *testing.T) {
+	const uri = "urn:gopcua:test:types"
+	RegisterExtensionObjectURI(uri, 7100, new(testURIType))
+	RegisterExtensionObjectURI(uri, 7100, new(testURIType))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("got no panic for conflicting registration")
+			}
+		}()
+		RegisterExtensionObjectURI(uri, 7100, new(AnonymousIdentityToken))
+	}()
+
+	body := []byte{
+		// A
+		0x05, 0x00, 0x00, 0x00,
+		// B
+		0x01, 0x00, 0x00, 0x00, 0x78,
+	}
+	encode := func(typeID *ExpandedNodeID) []byte {
+		b, err := Encode(&ExtensionObject{TypeID: typeID, EncodingMask: ExtensionObjectBinary, Value: ExtensionObjectBody(body)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	want := &testURIType{A: 5, B: "x"}
+
+	tests := []struct {
+		name       string
+		typeID     *ExpandedNodeID
+		namespaces []string
+		want       interface{}
+	}{
+		{
+			name:       "namespace index",
+			typeID:     NewFourByteExpandedNodeID(3, 7100),
+			namespaces: []string{"http://opcfoundation.org/UA/", "urn:a", "urn:b", uri},
+			want:       want,
+		},
+		{
+			name:       "other namespace",
+			typeID:     NewFourByteExpandedNodeID(2, 7100),
+			namespaces: []string{"http://opcfoundation.org/UA/", "urn:a", "urn:b", uri},
+			want:       ExtensionObjectBody(body),
+		},
+		{
+			name:   "unknown namespace array",
+			typeID: NewFourByteExpandedNodeID(3, 7100),
+			want:   ExtensionObjectBody(body),
+		},
+		{
+			name:   "namespace uri",
+			typeID: NewExpandedNodeID(NewNumericNodeID(0, 7100), uri, 0),
+			want:   want,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eo := new(ExtensionObject)
+			if _, err := decodeWith(encode(tt.typeID), eo, nil, tt.namespaces); err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "", eo.Value, tt.want)
+		})
+	}
+
+	// the type id of an object of the type contains the namespace uri
+	eo := NewExtensionObject(want)
+	if got, want := eo.TypeID.String(), "nsu="+uri+";i=7100"; got != want {
+		t.Fatalf("got type id %s want %s", got, want)
+	}
+	b, err := Encode(eo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(ExtensionObject)
+	if _, err := Decode(b, got); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", got.Value, want)
 }
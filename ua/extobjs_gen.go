@@ -6,7 +6,11 @@
 
 package ua
 
-import "time"
+import (
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
 
 type Request interface {
 	Header() *RequestHeader
@@ -404,6 +408,7 @@ type EnumDefinition struct {
 	Fields []*EnumField
 }
 
+// Node specifies the attributes which belong to all nodes.
 type Node struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -446,6 +451,7 @@ type TypeNode struct {
 	References          []*ReferenceNode
 }
 
+// ObjectNode specifies the attributes which belong to object nodes.
 type ObjectNode struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -461,6 +467,8 @@ type ObjectNode struct {
 	EventNotifier       uint8
 }
 
+// ObjectTypeNode specifies the attributes which belong to object type
+// nodes.
 type ObjectTypeNode struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -476,6 +484,7 @@ type ObjectTypeNode struct {
 	IsAbstract          bool
 }
 
+// VariableNode specifies the attributes which belong to variable nodes.
 type VariableNode struct {
 	NodeID                  *NodeID
 	NodeClass               NodeClass
@@ -499,6 +508,8 @@ type VariableNode struct {
 	AccessLevelEx           uint32
 }
 
+// VariableTypeNode specifies the attributes which belong to variable type
+// nodes.
 type VariableTypeNode struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -518,6 +529,8 @@ type VariableTypeNode struct {
 	IsAbstract          bool
 }
 
+// ReferenceTypeNode specifies the attributes which belong to reference
+// type nodes.
 type ReferenceTypeNode struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -535,6 +548,7 @@ type ReferenceTypeNode struct {
 	InverseName         *LocalizedText
 }
 
+// MethodNode specifies the attributes which belong to method nodes.
 type MethodNode struct {
 	NodeID              *NodeID
 	NodeClass           NodeClass
@@ -583,12 +597,14 @@ type DataTypeNode struct {
 	DataTypeDefinition  *ExtensionObject
 }
 
+// ReferenceNode specifies a reference which belongs to a node.
 type ReferenceNode struct {
 	ReferenceTypeID *NodeID
 	IsInverse       bool
 	TargetID        *ExpandedNodeID
 }
 
+// Argument is an argument for a method.
 type Argument struct {
 	Name            string
 	DataType        *NodeID
@@ -597,6 +613,8 @@ type Argument struct {
 	Description     *LocalizedText
 }
 
+// EnumValueType is a mapping between a value of an enumerated type and a
+// name and description.
 type EnumValueType struct {
 	Value       int64
 	DisplayName *LocalizedText
@@ -610,11 +628,14 @@ type EnumField struct {
 	Name        string
 }
 
+// OptionSet is the base DataType for all DataTypes representing a bit
+// mask.
 type OptionSet struct {
 	Value     []byte
 	ValidBits []byte
 }
 
+// Union is the base DataType for all union DataTypes.
 type Union struct{}
 
 type TimeZoneDataType struct {
@@ -622,6 +643,7 @@ type TimeZoneDataType struct {
 	DaylightSavingInOffset bool
 }
 
+// ApplicationDescription describes an application and how to find it.
 type ApplicationDescription struct {
 	ApplicationURI      string
 	ProductURI          string
@@ -632,6 +654,7 @@ type ApplicationDescription struct {
 	DiscoveryURLs       []string
 }
 
+// RequestHeader describes the header passed with every server request.
 type RequestHeader struct {
 	AuthenticationToken *NodeID
 	Timestamp           time.Time
@@ -642,6 +665,7 @@ type RequestHeader struct {
 	AdditionalHeader    *ExtensionObject
 }
 
+// ResponseHeader describes the header passed with every server response.
 type ResponseHeader struct {
 	Timestamp          time.Time
 	RequestHandle      uint32
@@ -651,6 +675,8 @@ type ResponseHeader struct {
 	AdditionalHeader   *ExtensionObject
 }
 
+// ServiceFault describes the response returned by all services when there
+// is a service level error.
 type ServiceFault struct {
 	ResponseHeader *ResponseHeader
 }
@@ -663,6 +689,14 @@ func (t *ServiceFault) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ServiceFault) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("ServiceFault.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type SessionlessInvokeRequestType struct {
 	URIsVersion   []uint32
 	NamespaceURIs []string
@@ -677,6 +711,7 @@ type SessionlessInvokeResponseType struct {
 	ServiceID     uint32
 }
 
+// FindServersRequest finds the servers known to the discovery server.
 type FindServersRequest struct {
 	RequestHeader *RequestHeader
 	EndpointURL   string
@@ -692,6 +727,15 @@ func (t *FindServersRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *FindServersRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("FindServersRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// FindServersResponse finds the servers known to the discovery server.
 type FindServersResponse struct {
 	ResponseHeader *ResponseHeader
 	Servers        []*ApplicationDescription
@@ -705,6 +749,14 @@ func (t *FindServersResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *FindServersResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("FindServersResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type ServerOnNetwork struct {
 	RecordID           uint32
 	ServerName         string
@@ -727,6 +779,14 @@ func (t *FindServersOnNetworkRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *FindServersOnNetworkRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("FindServersOnNetworkRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type FindServersOnNetworkResponse struct {
 	ResponseHeader       *ResponseHeader
 	LastCounterResetTime time.Time
@@ -741,6 +801,15 @@ func (t *FindServersOnNetworkResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *FindServersOnNetworkResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("FindServersOnNetworkResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// UserTokenPolicy describes a user token that can be used with a server.
 type UserTokenPolicy struct {
 	PolicyID          string
 	TokenType         UserTokenType
@@ -749,6 +818,8 @@ type UserTokenPolicy struct {
 	SecurityPolicyURI string
 }
 
+// EndpointDescription describes a endpoint that can be used to access a
+// server.
 type EndpointDescription struct {
 	EndpointURL         string
 	Server              *ApplicationDescription
@@ -760,6 +831,7 @@ type EndpointDescription struct {
 	SecurityLevel       uint8
 }
 
+// GetEndpointsRequest gets the endpoints used by the server.
 type GetEndpointsRequest struct {
 	RequestHeader *RequestHeader
 	EndpointURL   string
@@ -775,6 +847,15 @@ func (t *GetEndpointsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *GetEndpointsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("GetEndpointsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// GetEndpointsResponse gets the endpoints used by the server.
 type GetEndpointsResponse struct {
 	ResponseHeader *ResponseHeader
 	Endpoints      []*EndpointDescription
@@ -788,6 +869,16 @@ func (t *GetEndpointsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *GetEndpointsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("GetEndpointsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// RegisteredServer describes the information required to register a server
+// with a discovery server.
 type RegisteredServer struct {
 	ServerURI         string
 	ProductURI        string
@@ -799,6 +890,7 @@ type RegisteredServer struct {
 	IsOnline          bool
 }
 
+// RegisterServerRequest registers a server with the discovery server.
 type RegisterServerRequest struct {
 	RequestHeader *RequestHeader
 	Server        *RegisteredServer
@@ -812,6 +904,18 @@ func (t *RegisterServerRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterServerRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("RegisterServerRequest.RequestHeader is nil")
+	}
+	if t.Server == nil {
+		return errors.New("RegisterServerRequest.Server is nil")
+	}
+	return nil
+}
+
+// RegisterServerResponse registers a server with the discovery server.
 type RegisterServerResponse struct {
 	ResponseHeader *ResponseHeader
 }
@@ -824,8 +928,20 @@ func (t *RegisterServerResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterServerResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("RegisterServerResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// DiscoveryConfiguration is a base type for discovery configuration
+// information.
 type DiscoveryConfiguration struct{}
 
+// MdnsDiscoveryConfiguration describes the discovery information needed
+// for mDNS registration.
 type MdnsDiscoveryConfiguration struct {
 	MdnsServerName     string
 	ServerCapabilities []string
@@ -845,6 +961,17 @@ func (t *RegisterServer2Request) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterServer2Request) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("RegisterServer2Request.RequestHeader is nil")
+	}
+	if t.Server == nil {
+		return errors.New("RegisterServer2Request.Server is nil")
+	}
+	return nil
+}
+
 type RegisterServer2Response struct {
 	ResponseHeader       *ResponseHeader
 	ConfigurationResults []StatusCode
@@ -859,6 +986,16 @@ func (t *RegisterServer2Response) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterServer2Response) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("RegisterServer2Response.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// ChannelSecurityToken describes the token that identifies a set of keys
+// for an active secure channel.
 type ChannelSecurityToken struct {
 	ChannelID       uint32
 	TokenID         uint32
@@ -866,6 +1003,7 @@ type ChannelSecurityToken struct {
 	RevisedLifetime uint32
 }
 
+// OpenSecureChannelRequest creates a secure channel with a server.
 type OpenSecureChannelRequest struct {
 	RequestHeader         *RequestHeader
 	ClientProtocolVersion uint32
@@ -883,6 +1021,15 @@ func (t *OpenSecureChannelRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *OpenSecureChannelRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("OpenSecureChannelRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// OpenSecureChannelResponse creates a secure channel with a server.
 type OpenSecureChannelResponse struct {
 	ResponseHeader        *ResponseHeader
 	ServerProtocolVersion uint32
@@ -898,6 +1045,18 @@ func (t *OpenSecureChannelResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *OpenSecureChannelResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("OpenSecureChannelResponse.ResponseHeader is nil")
+	}
+	if t.SecurityToken == nil {
+		return errors.New("OpenSecureChannelResponse.SecurityToken is nil")
+	}
+	return nil
+}
+
+// CloseSecureChannelRequest closes a secure channel.
 type CloseSecureChannelRequest struct {
 	RequestHeader *RequestHeader
 }
@@ -910,6 +1069,15 @@ func (t *CloseSecureChannelRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CloseSecureChannelRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CloseSecureChannelRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// CloseSecureChannelResponse closes a secure channel.
 type CloseSecureChannelResponse struct {
 	ResponseHeader *ResponseHeader
 }
@@ -922,16 +1090,28 @@ func (t *CloseSecureChannelResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CloseSecureChannelResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CloseSecureChannelResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// SignedSoftwareCertificate is a software certificate with a digital
+// signature.
 type SignedSoftwareCertificate struct {
 	CertificateData []byte
 	Signature       []byte
 }
 
+// SignatureData is a digital signature.
 type SignatureData struct {
 	Algorithm string
 	Signature []byte
 }
 
+// CreateSessionRequest creates a new session with the server.
 type CreateSessionRequest struct {
 	RequestHeader           *RequestHeader
 	ClientDescription       *ApplicationDescription
@@ -952,6 +1132,18 @@ func (t *CreateSessionRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateSessionRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CreateSessionRequest.RequestHeader is nil")
+	}
+	if t.ClientDescription == nil {
+		return errors.New("CreateSessionRequest.ClientDescription is nil")
+	}
+	return nil
+}
+
+// CreateSessionResponse creates a new session with the server.
 type CreateSessionResponse struct {
 	ResponseHeader             *ResponseHeader
 	SessionID                  *NodeID
@@ -973,14 +1165,35 @@ func (t *CreateSessionResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateSessionResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CreateSessionResponse.ResponseHeader is nil")
+	}
+	if t.SessionID == nil {
+		return errors.New("CreateSessionResponse.SessionID is nil")
+	}
+	if t.AuthenticationToken == nil {
+		return errors.New("CreateSessionResponse.AuthenticationToken is nil")
+	}
+	if t.ServerSignature == nil {
+		return errors.New("CreateSessionResponse.ServerSignature is nil")
+	}
+	return nil
+}
+
+// UserIdentityToken is a base type for a user identity token.
 type UserIdentityToken struct {
 	PolicyID string
 }
 
+// AnonymousIdentityToken is a token representing an anonymous user.
 type AnonymousIdentityToken struct {
 	PolicyID string
 }
 
+// UserNameIdentityToken is a token representing a user identified by a
+// user name and password.
 type UserNameIdentityToken struct {
 	PolicyID            string
 	UserName            string
@@ -988,17 +1201,22 @@ type UserNameIdentityToken struct {
 	EncryptionAlgorithm string
 }
 
+// X509IdentityToken is a token representing a user identified by an X509
+// certificate.
 type X509IdentityToken struct {
 	PolicyID        string
 	CertificateData []byte
 }
 
+// IssuedIdentityToken is a token representing a user identified by a
+// WS-Security XML token.
 type IssuedIdentityToken struct {
 	PolicyID            string
 	TokenData           []byte
 	EncryptionAlgorithm string
 }
 
+// ActivateSessionRequest activates a session with the server.
 type ActivateSessionRequest struct {
 	RequestHeader              *RequestHeader
 	ClientSignature            *SignatureData
@@ -1016,6 +1234,21 @@ func (t *ActivateSessionRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ActivateSessionRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("ActivateSessionRequest.RequestHeader is nil")
+	}
+	if t.ClientSignature == nil {
+		return errors.New("ActivateSessionRequest.ClientSignature is nil")
+	}
+	if t.UserTokenSignature == nil {
+		return errors.New("ActivateSessionRequest.UserTokenSignature is nil")
+	}
+	return nil
+}
+
+// ActivateSessionResponse activates a session with the server.
 type ActivateSessionResponse struct {
 	ResponseHeader  *ResponseHeader
 	ServerNonce     []byte
@@ -1031,6 +1264,15 @@ func (t *ActivateSessionResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ActivateSessionResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("ActivateSessionResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// CloseSessionRequest closes a session with the server.
 type CloseSessionRequest struct {
 	RequestHeader       *RequestHeader
 	DeleteSubscriptions bool
@@ -1044,6 +1286,15 @@ func (t *CloseSessionRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CloseSessionRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CloseSessionRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// CloseSessionResponse closes a session with the server.
 type CloseSessionResponse struct {
 	ResponseHeader *ResponseHeader
 }
@@ -1056,6 +1307,15 @@ func (t *CloseSessionResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CloseSessionResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CloseSessionResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// CancelRequest cancels an outstanding request.
 type CancelRequest struct {
 	RequestHeader *RequestHeader
 	RequestHandle uint32
@@ -1069,6 +1329,15 @@ func (t *CancelRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CancelRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CancelRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// CancelResponse cancels an outstanding request.
 type CancelResponse struct {
 	ResponseHeader *ResponseHeader
 	CancelCount    uint32
@@ -1082,6 +1351,15 @@ func (t *CancelResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CancelResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CancelResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// NodeAttributes describes the base attributes for all nodes.
 type NodeAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1090,6 +1368,7 @@ type NodeAttributes struct {
 	UserWriteMask       uint32
 }
 
+// ObjectAttributes describes the attributes for an object node.
 type ObjectAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1099,6 +1378,7 @@ type ObjectAttributes struct {
 	EventNotifier       uint8
 }
 
+// VariableAttributes describes the attributes for a variable node.
 type VariableAttributes struct {
 	SpecifiedAttributes     uint32
 	DisplayName             *LocalizedText
@@ -1115,6 +1395,7 @@ type VariableAttributes struct {
 	Historizing             bool
 }
 
+// MethodAttributes describes the attributes for a method node.
 type MethodAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1125,6 +1406,7 @@ type MethodAttributes struct {
 	UserExecutable      bool
 }
 
+// ObjectTypeAttributes describes the attributes for an object type node.
 type ObjectTypeAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1134,6 +1416,8 @@ type ObjectTypeAttributes struct {
 	IsAbstract          bool
 }
 
+// VariableTypeAttributes describes the attributes for a variable type
+// node.
 type VariableTypeAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1147,6 +1431,8 @@ type VariableTypeAttributes struct {
 	IsAbstract          bool
 }
 
+// ReferenceTypeAttributes describes the attributes for a reference type
+// node.
 type ReferenceTypeAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1158,6 +1444,7 @@ type ReferenceTypeAttributes struct {
 	InverseName         *LocalizedText
 }
 
+// DataTypeAttributes describes the attributes for a data type node.
 type DataTypeAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1167,6 +1454,7 @@ type DataTypeAttributes struct {
 	IsAbstract          bool
 }
 
+// ViewAttributes describes the attributes for a view node.
 type ViewAttributes struct {
 	SpecifiedAttributes uint32
 	DisplayName         *LocalizedText
@@ -1191,6 +1479,7 @@ type GenericAttributes struct {
 	AttributeValues     []*GenericAttributeValue
 }
 
+// AddNodesItem is a request to add a node to the server address space.
 type AddNodesItem struct {
 	ParentNodeID       *ExpandedNodeID
 	ReferenceTypeID    *NodeID
@@ -1201,11 +1490,13 @@ type AddNodesItem struct {
 	TypeDefinition     *ExpandedNodeID
 }
 
+// AddNodesResult is a result of an add node operation.
 type AddNodesResult struct {
 	StatusCode  StatusCode
 	AddedNodeID *NodeID
 }
 
+// AddNodesRequest adds one or more nodes to the server address space.
 type AddNodesRequest struct {
 	RequestHeader *RequestHeader
 	NodesToAdd    []*AddNodesItem
@@ -1219,6 +1510,15 @@ func (t *AddNodesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *AddNodesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("AddNodesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// AddNodesResponse adds one or more nodes to the server address space.
 type AddNodesResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*AddNodesResult
@@ -1233,6 +1533,16 @@ func (t *AddNodesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *AddNodesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("AddNodesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// AddReferencesItem is a request to add a reference to the server address
+// space.
 type AddReferencesItem struct {
 	SourceNodeID    *NodeID
 	ReferenceTypeID *NodeID
@@ -1242,6 +1552,8 @@ type AddReferencesItem struct {
 	TargetNodeClass NodeClass
 }
 
+// AddReferencesRequest adds one or more references to the server address
+// space.
 type AddReferencesRequest struct {
 	RequestHeader   *RequestHeader
 	ReferencesToAdd []*AddReferencesItem
@@ -1255,6 +1567,16 @@ func (t *AddReferencesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *AddReferencesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("AddReferencesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// AddReferencesResponse adds one or more references to the server address
+// space.
 type AddReferencesResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -1269,11 +1591,23 @@ func (t *AddReferencesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *AddReferencesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("AddReferencesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// DeleteNodesItem is a request to delete a node to the server address
+// space.
 type DeleteNodesItem struct {
 	NodeID                 *NodeID
 	DeleteTargetReferences bool
 }
 
+// DeleteNodesRequest deletes one or more nodes from the server address
+// space.
 type DeleteNodesRequest struct {
 	RequestHeader *RequestHeader
 	NodesToDelete []*DeleteNodesItem
@@ -1287,6 +1621,16 @@ func (t *DeleteNodesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteNodesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("DeleteNodesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// DeleteNodesResponse deletes one or more nodes from the server address
+// space.
 type DeleteNodesResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -1301,6 +1645,16 @@ func (t *DeleteNodesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteNodesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("DeleteNodesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// DeleteReferencesItem is a request to delete a node from the server
+// address space.
 type DeleteReferencesItem struct {
 	SourceNodeID        *NodeID
 	ReferenceTypeID     *NodeID
@@ -1309,6 +1663,8 @@ type DeleteReferencesItem struct {
 	DeleteBidirectional bool
 }
 
+// DeleteReferencesRequest deletes one or more references from the server
+// address space.
 type DeleteReferencesRequest struct {
 	RequestHeader      *RequestHeader
 	ReferencesToDelete []*DeleteReferencesItem
@@ -1322,6 +1678,16 @@ func (t *DeleteReferencesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteReferencesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("DeleteReferencesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// DeleteReferencesResponse deletes one or more references from the server
+// address space.
 type DeleteReferencesResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -1336,12 +1702,22 @@ func (t *DeleteReferencesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteReferencesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("DeleteReferencesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// ViewDescription describes the view to browse.
 type ViewDescription struct {
 	ViewID      *NodeID
 	Timestamp   time.Time
 	ViewVersion uint32
 }
 
+// BrowseDescription is a request to browse the the references from a node.
 type BrowseDescription struct {
 	NodeID          *NodeID
 	BrowseDirection BrowseDirection
@@ -1351,6 +1727,7 @@ type BrowseDescription struct {
 	ResultMask      uint32
 }
 
+// ReferenceDescription describes a reference.
 type ReferenceDescription struct {
 	ReferenceTypeID *NodeID
 	IsForward       bool
@@ -1361,12 +1738,15 @@ type ReferenceDescription struct {
 	TypeDefinition  *ExpandedNodeID
 }
 
+// BrowseResult describes the result of a browse operation.
 type BrowseResult struct {
 	StatusCode        StatusCode
 	ContinuationPoint []byte
 	References        []*ReferenceDescription
 }
 
+// BrowseRequest browses the references for one or more nodes from the
+// server address space.
 type BrowseRequest struct {
 	RequestHeader                 *RequestHeader
 	View                          *ViewDescription
@@ -1382,6 +1762,19 @@ func (t *BrowseRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *BrowseRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("BrowseRequest.RequestHeader is nil")
+	}
+	if t.View == nil {
+		return errors.New("BrowseRequest.View is nil")
+	}
+	return nil
+}
+
+// BrowseResponse browses the references for one or more nodes from the
+// server address space.
 type BrowseResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*BrowseResult
@@ -1396,6 +1789,15 @@ func (t *BrowseResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *BrowseResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("BrowseResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// BrowseNextRequest continues one or more browse operations.
 type BrowseNextRequest struct {
 	RequestHeader             *RequestHeader
 	ReleaseContinuationPoints bool
@@ -1410,6 +1812,15 @@ func (t *BrowseNextRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *BrowseNextRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("BrowseNextRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// BrowseNextResponse continues one or more browse operations.
 type BrowseNextResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*BrowseResult
@@ -1424,6 +1835,15 @@ func (t *BrowseNextResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *BrowseNextResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("BrowseNextResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// RelativePathElement is an element in a relative path.
 type RelativePathElement struct {
 	ReferenceTypeID *NodeID
 	IsInverse       bool
@@ -1431,25 +1851,32 @@ type RelativePathElement struct {
 	TargetName      *QualifiedName
 }
 
+// RelativePath is a relative path constructed from reference types and
+// browse names.
 type RelativePath struct {
 	Elements []*RelativePathElement
 }
 
+// BrowsePath is a request to translate a path into a node id.
 type BrowsePath struct {
 	StartingNode *NodeID
 	RelativePath *RelativePath
 }
 
+// BrowsePathTarget describes the target of the translated path.
 type BrowsePathTarget struct {
 	TargetID           *ExpandedNodeID
 	RemainingPathIndex uint32
 }
 
+// BrowsePathResult describes the result of a translate opearation.
 type BrowsePathResult struct {
 	StatusCode StatusCode
 	Targets    []*BrowsePathTarget
 }
 
+// TranslateBrowsePathsToNodeIDsRequest translates one or more paths in the
+// server address space.
 type TranslateBrowsePathsToNodeIDsRequest struct {
 	RequestHeader *RequestHeader
 	BrowsePaths   []*BrowsePath
@@ -1463,6 +1890,16 @@ func (t *TranslateBrowsePathsToNodeIDsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *TranslateBrowsePathsToNodeIDsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("TranslateBrowsePathsToNodeIDsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// TranslateBrowsePathsToNodeIDsResponse translates one or more paths in
+// the server address space.
 type TranslateBrowsePathsToNodeIDsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*BrowsePathResult
@@ -1477,6 +1914,16 @@ func (t *TranslateBrowsePathsToNodeIDsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *TranslateBrowsePathsToNodeIDsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("TranslateBrowsePathsToNodeIDsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// RegisterNodesRequest registers one or more nodes for repeated use within
+// a session.
 type RegisterNodesRequest struct {
 	RequestHeader   *RequestHeader
 	NodesToRegister []*NodeID
@@ -1490,6 +1937,16 @@ func (t *RegisterNodesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterNodesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("RegisterNodesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// RegisterNodesResponse registers one or more nodes for repeated use
+// within a session.
 type RegisterNodesResponse struct {
 	ResponseHeader    *ResponseHeader
 	RegisteredNodeIDs []*NodeID
@@ -1503,6 +1960,16 @@ func (t *RegisterNodesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RegisterNodesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("RegisterNodesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
+// UnregisterNodesRequest unregisters one or more previously registered
+// nodes.
 type UnregisterNodesRequest struct {
 	RequestHeader     *RequestHeader
 	NodesToUnregister []*NodeID
@@ -1516,6 +1983,16 @@ func (t *UnregisterNodesRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *UnregisterNodesRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("UnregisterNodesRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
+// UnregisterNodesResponse unregisters one or more previously registered
+// nodes.
 type UnregisterNodesResponse struct {
 	ResponseHeader *ResponseHeader
 }
@@ -1528,6 +2005,14 @@ func (t *UnregisterNodesResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *UnregisterNodesResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("UnregisterNodesResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type EndpointConfiguration struct {
 	OperationTimeout      int32
 	UseBinaryEncoding     bool
@@ -1633,6 +2118,20 @@ func (t *QueryFirstRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *QueryFirstRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("QueryFirstRequest.RequestHeader is nil")
+	}
+	if t.View == nil {
+		return errors.New("QueryFirstRequest.View is nil")
+	}
+	if t.Filter == nil {
+		return errors.New("QueryFirstRequest.Filter is nil")
+	}
+	return nil
+}
+
 type QueryFirstResponse struct {
 	ResponseHeader    *ResponseHeader
 	QueryDataSets     []*QueryDataSet
@@ -1650,6 +2149,17 @@ func (t *QueryFirstResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *QueryFirstResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("QueryFirstResponse.ResponseHeader is nil")
+	}
+	if t.FilterResult == nil {
+		return errors.New("QueryFirstResponse.FilterResult is nil")
+	}
+	return nil
+}
+
 type QueryNextRequest struct {
 	RequestHeader            *RequestHeader
 	ReleaseContinuationPoint bool
@@ -1664,6 +2174,14 @@ func (t *QueryNextRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *QueryNextRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("QueryNextRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type QueryNextResponse struct {
 	ResponseHeader           *ResponseHeader
 	QueryDataSets            []*QueryDataSet
@@ -1678,6 +2196,14 @@ func (t *QueryNextResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *QueryNextResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("QueryNextResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type ReadValueID struct {
 	NodeID       *NodeID
 	AttributeID  AttributeID
@@ -1700,6 +2226,14 @@ func (t *ReadRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ReadRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("ReadRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type ReadResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*DataValue
@@ -1714,6 +2248,14 @@ func (t *ReadResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ReadResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("ReadResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type HistoryReadValueID struct {
 	NodeID            *NodeID
 	IndexRange        string
@@ -1792,6 +2334,14 @@ func (t *HistoryReadRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *HistoryReadRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("HistoryReadRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type HistoryReadResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*HistoryReadResult
@@ -1806,6 +2356,14 @@ func (t *HistoryReadResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *HistoryReadResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("HistoryReadResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type WriteValue struct {
 	NodeID      *NodeID
 	AttributeID AttributeID
@@ -1826,6 +2384,14 @@ func (t *WriteRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *WriteRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("WriteRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type WriteResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -1840,6 +2406,14 @@ func (t *WriteResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *WriteResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("WriteResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type HistoryUpdateDetails struct {
 	NodeID *NodeID
 }
@@ -1899,6 +2473,14 @@ func (t *HistoryUpdateRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *HistoryUpdateRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("HistoryUpdateRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type HistoryUpdateResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*HistoryUpdateResult
@@ -1913,6 +2495,14 @@ func (t *HistoryUpdateResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *HistoryUpdateResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("HistoryUpdateResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type CallMethodRequest struct {
 	ObjectID       *NodeID
 	MethodID       *NodeID
@@ -1939,6 +2529,14 @@ func (t *CallRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CallRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CallRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type CallResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*CallMethodResult
@@ -1953,6 +2551,14 @@ func (t *CallResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CallResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CallResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type MonitoringFilter struct{}
 
 type DataChangeFilter struct {
@@ -2032,6 +2638,14 @@ func (t *CreateMonitoredItemsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateMonitoredItemsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CreateMonitoredItemsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type CreateMonitoredItemsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*MonitoredItemCreateResult
@@ -2046,6 +2660,14 @@ func (t *CreateMonitoredItemsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateMonitoredItemsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CreateMonitoredItemsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type MonitoredItemModifyRequest struct {
 	MonitoredItemID     uint32
 	RequestedParameters *MonitoringParameters
@@ -2073,6 +2695,14 @@ func (t *ModifyMonitoredItemsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ModifyMonitoredItemsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("ModifyMonitoredItemsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type ModifyMonitoredItemsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*MonitoredItemModifyResult
@@ -2087,6 +2717,14 @@ func (t *ModifyMonitoredItemsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ModifyMonitoredItemsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("ModifyMonitoredItemsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type SetMonitoringModeRequest struct {
 	RequestHeader    *RequestHeader
 	SubscriptionID   uint32
@@ -2102,6 +2740,14 @@ func (t *SetMonitoringModeRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetMonitoringModeRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("SetMonitoringModeRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type SetMonitoringModeResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -2116,6 +2762,14 @@ func (t *SetMonitoringModeResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetMonitoringModeResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("SetMonitoringModeResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type SetTriggeringRequest struct {
 	RequestHeader    *RequestHeader
 	SubscriptionID   uint32
@@ -2132,6 +2786,14 @@ func (t *SetTriggeringRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetTriggeringRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("SetTriggeringRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type SetTriggeringResponse struct {
 	ResponseHeader        *ResponseHeader
 	AddResults            []StatusCode
@@ -2148,6 +2810,14 @@ func (t *SetTriggeringResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetTriggeringResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("SetTriggeringResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type DeleteMonitoredItemsRequest struct {
 	RequestHeader    *RequestHeader
 	SubscriptionID   uint32
@@ -2162,6 +2832,14 @@ func (t *DeleteMonitoredItemsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteMonitoredItemsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("DeleteMonitoredItemsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type DeleteMonitoredItemsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -2176,6 +2854,14 @@ func (t *DeleteMonitoredItemsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteMonitoredItemsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("DeleteMonitoredItemsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type CreateSubscriptionRequest struct {
 	RequestHeader               *RequestHeader
 	RequestedPublishingInterval float64
@@ -2194,6 +2880,14 @@ func (t *CreateSubscriptionRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateSubscriptionRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("CreateSubscriptionRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type CreateSubscriptionResponse struct {
 	ResponseHeader            *ResponseHeader
 	SubscriptionID            uint32
@@ -2210,6 +2904,14 @@ func (t *CreateSubscriptionResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *CreateSubscriptionResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("CreateSubscriptionResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type ModifySubscriptionRequest struct {
 	RequestHeader               *RequestHeader
 	SubscriptionID              uint32
@@ -2228,6 +2930,14 @@ func (t *ModifySubscriptionRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ModifySubscriptionRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("ModifySubscriptionRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type ModifySubscriptionResponse struct {
 	ResponseHeader            *ResponseHeader
 	RevisedPublishingInterval float64
@@ -2243,6 +2953,14 @@ func (t *ModifySubscriptionResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *ModifySubscriptionResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("ModifySubscriptionResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type SetPublishingModeRequest struct {
 	RequestHeader     *RequestHeader
 	PublishingEnabled bool
@@ -2257,6 +2975,14 @@ func (t *SetPublishingModeRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetPublishingModeRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("SetPublishingModeRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type SetPublishingModeResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -2271,6 +2997,14 @@ func (t *SetPublishingModeResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *SetPublishingModeResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("SetPublishingModeResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type NotificationMessage struct {
 	SequenceNumber   uint32
 	PublishTime      time.Time
@@ -2325,6 +3059,14 @@ func (t *PublishRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *PublishRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("PublishRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type PublishResponse struct {
 	ResponseHeader           *ResponseHeader
 	SubscriptionID           uint32
@@ -2343,6 +3085,17 @@ func (t *PublishResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *PublishResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("PublishResponse.ResponseHeader is nil")
+	}
+	if t.NotificationMessage == nil {
+		return errors.New("PublishResponse.NotificationMessage is nil")
+	}
+	return nil
+}
+
 type RepublishRequest struct {
 	RequestHeader            *RequestHeader
 	SubscriptionID           uint32
@@ -2357,6 +3110,14 @@ func (t *RepublishRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RepublishRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("RepublishRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type RepublishResponse struct {
 	ResponseHeader      *ResponseHeader
 	NotificationMessage *NotificationMessage
@@ -2370,6 +3131,17 @@ func (t *RepublishResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *RepublishResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("RepublishResponse.ResponseHeader is nil")
+	}
+	if t.NotificationMessage == nil {
+		return errors.New("RepublishResponse.NotificationMessage is nil")
+	}
+	return nil
+}
+
 type TransferResult struct {
 	StatusCode               StatusCode
 	AvailableSequenceNumbers []uint32
@@ -2389,6 +3161,14 @@ func (t *TransferSubscriptionsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *TransferSubscriptionsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("TransferSubscriptionsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type TransferSubscriptionsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []*TransferResult
@@ -2403,6 +3183,14 @@ func (t *TransferSubscriptionsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *TransferSubscriptionsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("TransferSubscriptionsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type DeleteSubscriptionsRequest struct {
 	RequestHeader   *RequestHeader
 	SubscriptionIDs []uint32
@@ -2416,6 +3204,14 @@ func (t *DeleteSubscriptionsRequest) SetHeader(h *RequestHeader) {
 	t.RequestHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteSubscriptionsRequest) Validate() error {
+	if t.RequestHeader == nil {
+		return errors.New("DeleteSubscriptionsRequest.RequestHeader is nil")
+	}
+	return nil
+}
+
 type DeleteSubscriptionsResponse struct {
 	ResponseHeader  *ResponseHeader
 	Results         []StatusCode
@@ -2430,6 +3226,14 @@ func (t *DeleteSubscriptionsResponse) SetHeader(h *ResponseHeader) {
 	t.ResponseHeader = h
 }
 
+// Validate returns an error if a required field is nil.
+func (t *DeleteSubscriptionsResponse) Validate() error {
+	if t.ResponseHeader == nil {
+		return errors.New("DeleteSubscriptionsResponse.ResponseHeader is nil")
+	}
+	return nil
+}
+
 type BuildInfo struct {
 	ProductURI       string
 	ManufacturerName string
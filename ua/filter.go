@@ -0,0 +1,48 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import "time"
+
+// NewDataChangeFilter returns a DataChangeFilter wrapped in an extension
+// object which can be used as filter in the MonitoringParameters of a
+// monitored item.
+//
+// For DeadbandTypeAbsolute the deadbandValue is the absolute change of the
+// value and for DeadbandTypePercent the percentage of the EURange of the
+// variable. Servers which do not support the deadband type reject the
+// monitored item with StatusBadDeadbandFilterInvalid or
+// StatusBadFilterNotAllowed.
+//
+// Specification: Part 4, 7.17.2
+func NewDataChangeFilter(trigger DataChangeTrigger, deadbandType DeadbandType, deadbandValue float64) *ExtensionObject {
+	return NewExtensionObject(&DataChangeFilter{
+		Trigger:       trigger,
+		DeadbandType:  uint32(deadbandType),
+		DeadbandValue: deadbandValue,
+	})
+}
+
+// NewAggregateFilter returns an AggregateFilter wrapped in an extension
+// object which can be used as filter in the MonitoringParameters of a
+// monitored item. aggregateType is the node id of the aggregate function,
+// e.g. id.AggregateFunction_Average, and interval the processing interval
+// of the aggregate. The server uses its default aggregate configuration.
+//
+// Servers which do not support the aggregate reject the monitored item and
+// may return the revised parameters as AggregateFilterResult in the
+// FilterResult of the MonitoredItemCreateResult.
+//
+// Specification: Part 4, 7.17.4
+func NewAggregateFilter(aggregateType *NodeID, startTime time.Time, interval time.Duration) *ExtensionObject {
+	return NewExtensionObject(&AggregateFilter{
+		StartTime:          startTime,
+		AggregateType:      aggregateType,
+		ProcessingInterval: float64(interval) / float64(time.Millisecond),
+		AggregateConfiguration: &AggregateConfiguration{
+			UseServerCapabilitiesDefaults: true,
+		},
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+func TestDataChangeFilter(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name:   "absolute deadband",
+			Struct: NewDataChangeFilter(DataChangeTriggerStatusValue, DeadbandTypeAbsolute, 0.5),
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x00, 0xd4, 0x02,
+				// EncodingMask
+				0x01,
+				// Length
+				0x10, 0x00, 0x00, 0x00,
+				// Trigger
+				0x01, 0x00, 0x00, 0x00,
+				// DeadbandType
+				0x01, 0x00, 0x00, 0x00,
+				// DeadbandValue
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xe0, 0x3f,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestAggregateFilter(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	eo := NewAggregateFilter(NewNumericNodeID(0, id.AggregateFunction_Average), start, 1500*time.Millisecond)
+	verify.Values(t, "type id", eo.TypeID.NodeID.IntID(), uint32(id.AggregateFilter_Encoding_DefaultBinary))
+
+	b, err := eo.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ExtensionObject
+	if _, err := got.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", got.Value, &AggregateFilter{
+		StartTime:              start,
+		AggregateType:          NewNumericNodeID(0, id.AggregateFunction_Average),
+		ProcessingInterval:     1500,
+		AggregateConfiguration: &AggregateConfiguration{UseServerCapabilitiesDefaults: true},
+	})
+}
+
+func TestAggregateFilterResult(t *testing.T) {
+	// a server rejecting the aggregate returns the revised parameters
+	res := &MonitoredItemCreateResult{
+		StatusCode: StatusBadAggregateNotSupported,
+		FilterResult: NewExtensionObject(&AggregateFilterResult{
+			RevisedStartTime:              time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			RevisedProcessingInterval:     1000,
+			RevisedAggregateConfiguration: &AggregateConfiguration{},
+		}),
+	}
+	b, err := Encode(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(MonitoredItemCreateResult)
+	if _, err := Decode(b, got); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", got.FilterResult.Value, res.FilterResult.Value)
+}
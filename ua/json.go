@@ -0,0 +1,1090 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// EncodeJSON encodes v with the reversible OPC UA JSON encoding.
+//
+// v can be a built-in type, a generated structure or a pointer to one.
+// Int64 and UInt64 values are encoded as strings, ByteString values as
+// base64 strings and multi-dimensional Variant arrays as a flat array
+// with an additional Dimensions field.
+//
+// Specification: Part 6, 5.4
+func EncodeJSON(v interface{}) ([]byte, error) {
+	return (&JSONEncoder{}).Encode(v)
+}
+
+// DecodeJSON decodes b which must contain a value in the reversible
+// OPC UA JSON encoding into v. v must be a non-nil pointer.
+//
+// Specification: Part 6, 5.4
+func DecodeJSON(b []byte, v interface{}) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.Errorf("json: cannot decode into %T", v)
+	}
+	return jsonDecode(b, val.Elem())
+}
+
+// JSONEncoder encodes values with the OPC UA JSON encoding.
+//
+// The zero value produces the reversible form which DecodeJSON can
+// decode again. The non-reversible form is meant for consumers which
+// do not know the OPC UA type system, e.g. a web dashboard. It drops
+// the type information of Variants and ExtensionObjects, encodes
+// LocalizedText as a plain string, StatusCodes with their symbolic
+// name and multi-dimensional arrays as nested arrays. It cannot be
+// decoded.
+//
+// Specification: Part 6, 5.4.1
+type JSONEncoder struct {
+	// NonReversible selects the non-reversible form.
+	NonReversible bool
+
+	// NamespaceURIs is the namespace array of the server. In the
+	// non-reversible form namespace indexes greater than one are
+	// encoded as the matching uri from this list.
+	NamespaceURIs []string
+
+	// ServerURIs is the server array of the server. In the
+	// non-reversible form server indexes are encoded as the
+	// matching uri from this list.
+	ServerURIs []string
+}
+
+// Encode encodes v which can be a built-in type, a generated structure
+// or a pointer to one.
+func (e *JSONEncoder) Encode(v interface{}) ([]byte, error) {
+	j, err := e.encode(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+var (
+	jsonTimeType           = reflect.TypeOf(time.Time{})
+	jsonByteStringType     = reflect.TypeOf([]byte{})
+	jsonXMLElementType     = reflect.TypeOf(XMLElement(""))
+	jsonGUIDType           = reflect.TypeOf(new(GUID))
+	jsonNodeIDType         = reflect.TypeOf(new(NodeID))
+	jsonExpandedNodeIDType = reflect.TypeOf(new(ExpandedNodeID))
+	jsonQualifiedNameType  = reflect.TypeOf(new(QualifiedName))
+	jsonLocalizedTextType  = reflect.TypeOf(new(LocalizedText))
+	jsonExtensionObjType   = reflect.TypeOf(new(ExtensionObject))
+	jsonDataValueType      = reflect.TypeOf(new(DataValue))
+	jsonVariantType        = reflect.TypeOf(new(Variant))
+	jsonDiagnosticInfoType = reflect.TypeOf(new(DiagnosticInfo))
+)
+
+// jsonField is a single member of a JSON object.
+type jsonField struct {
+	name  string
+	value interface{}
+}
+
+// jsonObject is a JSON object which preserves the order of its members.
+type jsonObject []jsonField
+
+func (o jsonObject) add(name string, value interface{}) jsonObject {
+	return append(o, jsonField{name, value})
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encode converts val into a tree of values which can be
+// marshaled with encoding/json.
+func (e *JSONEncoder) encode(val reflect.Value) (interface{}, error) {
+	if !val.IsValid() {
+		return nil, nil
+	}
+
+	switch val.Type() {
+	case jsonTimeType:
+		return jsonEncodeTime(val.Interface().(time.Time)), nil
+	case jsonByteStringType:
+		if val.IsNil() {
+			return nil, nil
+		}
+		return base64.StdEncoding.EncodeToString(val.Bytes()), nil
+	case jsonXMLElementType:
+		return val.String(), nil
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return nil, nil
+		}
+	}
+
+	switch v := val.Interface().(type) {
+	case StatusCode:
+		return e.encodeStatusCode(v), nil
+	case *GUID:
+		return v.String(), nil
+	case *NodeID:
+		return e.encodeNodeID(v), nil
+	case *ExpandedNodeID:
+		return e.encodeExpandedNodeID(v), nil
+	case *QualifiedName:
+		var o jsonObject
+		o = o.add("Name", v.Name)
+		if v.NamespaceIndex != 0 {
+			o = o.add("Uri", e.namespace(v.NamespaceIndex))
+		}
+		return o, nil
+	case *LocalizedText:
+		if e.NonReversible {
+			return v.Text, nil
+		}
+		var o jsonObject
+		if v.Locale != "" {
+			o = o.add("Locale", v.Locale)
+		}
+		if v.Text != "" {
+			o = o.add("Text", v.Text)
+		}
+		return o, nil
+	case *ExtensionObject:
+		return e.encodeExtensionObject(v)
+	case *DataValue:
+		return e.encodeDataValue(v)
+	case *Variant:
+		return e.encodeVariant(v)
+	case *DiagnosticInfo:
+		return e.encodeDiagnosticInfo(v), nil
+	case *Structure:
+		return e.encodeStructure(v)
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.encode(val.Elem())
+	case reflect.Bool, reflect.String:
+		return val.Interface(), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		return val.Int(), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		return val.Uint(), nil
+	case reflect.Int64:
+		return strconv.FormatInt(val.Int(), 10), nil
+	case reflect.Uint64:
+		return strconv.FormatUint(val.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return jsonEncodeFloat(val.Float()), nil
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil, nil
+		}
+		fallthrough
+	case reflect.Array:
+		a := make([]interface{}, val.Len())
+		for i := range a {
+			v, err := e.encode(val.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			a[i] = v
+		}
+		return a, nil
+	case reflect.Struct:
+		var o jsonObject
+		for i := 0; i < val.NumField(); i++ {
+			f := val.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			v, err := e.encode(val.Field(i))
+			if err != nil {
+				return nil, errors.Errorf("json: %s.%s: %s", val.Type().Name(), f.Name, err)
+			}
+			o = o.add(f.Name, v)
+		}
+		return o, nil
+	default:
+		return nil, errors.Errorf("json: unsupported type %s", val.Type())
+	}
+}
+
+func jsonEncodeTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func jsonEncodeFloat(f float64) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return f
+	}
+}
+
+// namespace returns the value of a Namespace or Uri field for ns.
+// The non-reversible form uses the namespace uri unless ns is 1
+// or the uri is not known.
+//
+// Specification: Part 6, 5.4.2.10
+func (e *JSONEncoder) namespace(ns uint16) interface{} {
+	if e.NonReversible && ns > 1 && int(ns) < len(e.NamespaceURIs) {
+		return e.NamespaceURIs[ns]
+	}
+	return ns
+}
+
+// encodeStatusCode encodes s as a number or as an object with the code
+// and the symbolic name of the status code in the non-reversible form.
+//
+// Specification: Part 6, 5.4.2.12
+func (e *JSONEncoder) encodeStatusCode(s StatusCode) interface{} {
+	if !e.NonReversible {
+		return uint32(s)
+	}
+	var o jsonObject
+	o = o.add("Code", uint32(s))
+	switch d, ok := StatusCodes[s]; {
+	case s == StatusOK:
+		o = o.add("Symbol", "Good")
+	case ok:
+		o = o.add("Symbol", strings.TrimPrefix(d.Name, "Status"))
+	}
+	return o
+}
+
+// Specification: Part 6, 5.4.2.10
+func (e *JSONEncoder) encodeNodeID(n *NodeID) jsonObject {
+	var o jsonObject
+	switch n.Type() {
+	case NodeIDTypeString:
+		o = o.add("IdType", 1).add("Id", string(n.bid))
+	case NodeIDTypeGUID:
+		o = o.add("IdType", 2).add("Id", n.gid.String())
+	case NodeIDTypeByteString:
+		o = o.add("IdType", 3).add("Id", base64.StdEncoding.EncodeToString(n.bid))
+	default:
+		o = o.add("Id", n.nid)
+	}
+	if n.ns != 0 {
+		o = o.add("Namespace", e.namespace(n.ns))
+	}
+	return o
+}
+
+// Specification: Part 6, 5.4.2.11
+func (e *JSONEncoder) encodeExpandedNodeID(x *ExpandedNodeID) jsonObject {
+	var o jsonObject
+	if x.NodeID != nil {
+		for _, f := range e.encodeNodeID(x.NodeID) {
+			if f.name == "Namespace" && x.NamespaceURI != "" {
+				continue
+			}
+			o = append(o, f)
+		}
+	}
+	if x.NamespaceURI != "" {
+		o = o.add("Namespace", x.NamespaceURI)
+	}
+	if x.ServerIndex != 0 {
+		if e.NonReversible && int(x.ServerIndex) < len(e.ServerURIs) {
+			o = o.add("ServerUri", e.ServerURIs[x.ServerIndex])
+		} else {
+			o = o.add("ServerUri", x.ServerIndex)
+		}
+	}
+	return o
+}
+
+// Specification: Part 6, 5.4.2.16
+func (e *JSONEncoder) encodeExtensionObject(x *ExtensionObject) (interface{}, error) {
+	if x.EncodingMask == ExtensionObjectEmpty && x.Value == nil {
+		return nil, nil
+	}
+
+	var o jsonObject
+	if x.TypeID != nil && x.TypeID.NodeID != nil {
+		o = o.add("TypeId", e.encodeNodeID(x.TypeID.NodeID))
+	}
+
+	switch v := x.Value.(type) {
+	case *XMLElement:
+		if e.NonReversible {
+			return string(*v), nil
+		}
+		return o.add("Encoding", ExtensionObjectXML).add("Body", string(*v)), nil
+	case ExtensionObjectBody:
+		body := base64.StdEncoding.EncodeToString(v)
+		if e.NonReversible {
+			return body, nil
+		}
+		return o.add("Encoding", ExtensionObjectBinary).add("Body", body), nil
+	case nil:
+		// an object without a body.
+		if e.NonReversible {
+			return nil, nil
+		}
+		return o.add("Encoding", ExtensionObjectBinary).add("Body", nil), nil
+	default:
+		body, err := e.encode(reflect.ValueOf(v))
+		if err != nil {
+			return nil, err
+		}
+		if e.NonReversible {
+			return body, nil
+		}
+		return o.add("Body", body), nil
+	}
+}
+
+// Specification: Part 6, 5.4.2.18
+func (e *JSONEncoder) encodeDataValue(d *DataValue) (interface{}, error) {
+	var o jsonObject
+	if d.Has(DataValueValue) {
+		v, err := e.encodeVariant(d.Value)
+		if err != nil {
+			return nil, err
+		}
+		o = o.add("Value", v)
+	}
+	if d.Has(DataValueStatusCode) {
+		o = o.add("Status", e.encodeStatusCode(d.Status))
+	}
+	if d.Has(DataValueSourceTimestamp) {
+		o = o.add("SourceTimestamp", jsonEncodeTime(d.SourceTimestamp))
+	}
+	if d.Has(DataValueSourcePicoseconds) {
+		o = o.add("SourcePicoseconds", d.SourcePicoseconds)
+	}
+	if d.Has(DataValueServerTimestamp) {
+		o = o.add("ServerTimestamp", jsonEncodeTime(d.ServerTimestamp))
+	}
+	if d.Has(DataValueServerPicoseconds) {
+		o = o.add("ServerPicoseconds", d.ServerPicoseconds)
+	}
+	return o, nil
+}
+
+// Specification: Part 6, 5.4.2.17
+func (e *JSONEncoder) encodeVariant(v *Variant) (interface{}, error) {
+	if v == nil || v.Type() == TypeIDNull {
+		return nil, nil
+	}
+
+	// the non-reversible form only encodes the value and
+	// multi-dimensional arrays as nested arrays.
+	val := reflect.ValueOf(v.Value())
+	if e.NonReversible {
+		return e.encode(val)
+	}
+
+	var o jsonObject
+	o = o.add("Type", uint8(v.Type()))
+
+	if !v.Has(VariantArrayValues) {
+		body, err := e.encode(val)
+		if err != nil {
+			return nil, err
+		}
+		return o.add("Body", body), nil
+	}
+
+	// multi-dimensional arrays are encoded as a flat array
+	// followed by the dimensions.
+	var elems []reflect.Value
+	var flatten func(reflect.Value)
+	flatten = func(a reflect.Value) {
+		if a.Kind() == reflect.Slice && a.Type() != jsonByteStringType {
+			for i := 0; i < a.Len(); i++ {
+				flatten(a.Index(i))
+			}
+			return
+		}
+		elems = append(elems, a)
+	}
+	flatten(val)
+
+	body := make([]interface{}, len(elems))
+	for i, el := range elems {
+		b, err := e.encode(el)
+		if err != nil {
+			return nil, err
+		}
+		body[i] = b
+	}
+	o = o.add("Body", body)
+
+	if v.Has(VariantArrayDimensions) {
+		o = o.add("Dimensions", v.ArrayDimensions())
+	}
+	return o, nil
+}
+
+// encodeStructure encodes the fields of a structure in the order
+// of its definition.
+//
+// Specification: Part 6, 5.4.6
+func (e *JSONEncoder) encodeStructure(s *Structure) (interface{}, error) {
+	if s.Definition == nil {
+		return nil, errors.Errorf("json: missing definition for structure %s", s.TypeID)
+	}
+	var o jsonObject
+	for _, f := range s.Definition.Fields {
+		fv, ok := s.Fields[f.Name]
+		if !ok {
+			continue
+		}
+		v, err := e.encode(reflect.ValueOf(fv))
+		if err != nil {
+			return nil, errors.Errorf("json: %s: %s", f.Name, err)
+		}
+		o = o.add(f.Name, v)
+	}
+	return o, nil
+}
+
+// Specification: Part 6, 5.4.2.13
+func (e *JSONEncoder) encodeDiagnosticInfo(d *DiagnosticInfo) jsonObject {
+	var o jsonObject
+	if d.Has(DiagnosticInfoSymbolicID) {
+		o = o.add("SymbolicId", d.SymbolicID)
+	}
+	if d.Has(DiagnosticInfoNamespaceURI) {
+		o = o.add("NamespaceUri", d.NamespaceURI)
+	}
+	if d.Has(DiagnosticInfoLocale) {
+		o = o.add("Locale", d.Locale)
+	}
+	if d.Has(DiagnosticInfoLocalizedText) {
+		o = o.add("LocalizedText", d.LocalizedText)
+	}
+	if d.Has(DiagnosticInfoAdditionalInfo) {
+		o = o.add("AdditionalInfo", d.AdditionalInfo)
+	}
+	if d.Has(DiagnosticInfoInnerStatusCode) {
+		o = o.add("InnerStatusCode", e.encodeStatusCode(d.InnerStatusCode))
+	}
+	if d.Has(DiagnosticInfoInnerDiagnosticInfo) && d.InnerDiagnosticInfo != nil {
+		o = o.add("InnerDiagnosticInfo", e.encodeDiagnosticInfo(d.InnerDiagnosticInfo))
+	}
+	return o
+}
+
+func isJSONNull(b []byte) bool {
+	return len(b) == 0 || string(bytes.TrimSpace(b)) == "null"
+}
+
+// jsonDecode decodes b into val which must be settable.
+func jsonDecode(b []byte, val reflect.Value) error {
+	if isJSONNull(b) {
+		// a null Variant is a valid value and not a nil pointer.
+		if val.Type() == jsonVariantType {
+			val.Set(reflect.ValueOf(&Variant{}))
+			return nil
+		}
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+
+	switch val.Type() {
+	case jsonTimeType:
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		t, err := jsonDecodeTime(s)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(t))
+		return nil
+
+	case jsonByteStringType:
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		val.SetBytes(v)
+		return nil
+
+	case jsonGUIDType:
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		g, err := ParseGUID(s)
+		if err != nil {
+			return errors.Errorf("json: invalid guid %q", s)
+		}
+		val.Set(reflect.ValueOf(g))
+		return nil
+
+	case jsonNodeIDType:
+		n, _, err := jsonDecodeNodeID(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(n))
+		return nil
+
+	case jsonExpandedNodeIDType:
+		e, err := jsonDecodeExpandedNodeID(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(e))
+		return nil
+
+	case jsonQualifiedNameType:
+		var v struct {
+			Name string
+			Uri  uint16
+		}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(&QualifiedName{NamespaceIndex: v.Uri, Name: v.Name}))
+		return nil
+
+	case jsonLocalizedTextType:
+		var v struct {
+			Locale string
+			Text   string
+		}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(NewLocalizedTextWithLocale(v.Text, v.Locale)))
+		return nil
+
+	case jsonExtensionObjType:
+		e, err := jsonDecodeExtensionObject(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(e))
+		return nil
+
+	case jsonDataValueType:
+		d, err := jsonDecodeDataValue(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(d))
+		return nil
+
+	case jsonVariantType:
+		v, err := jsonDecodeVariant(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(v))
+		return nil
+
+	case jsonDiagnosticInfoType:
+		d, err := jsonDecodeDiagnosticInfo(b)
+		if err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		v := reflect.New(val.Type().Elem())
+		if err := jsonDecode(b, v.Elem()); err != nil {
+			return err
+		}
+		val.Set(v)
+		return nil
+
+	case reflect.Bool:
+		var v bool
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		val.SetBool(v)
+		return nil
+
+	case reflect.String:
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		val.SetString(v)
+		return nil
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		s := string(bytes.Trim(b, `"`))
+		v, err := strconv.ParseInt(s, 10, val.Type().Bits())
+		if err != nil {
+			return errors.Errorf("json: invalid %s value %s", val.Type(), b)
+		}
+		val.SetInt(v)
+		return nil
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		s := string(bytes.Trim(b, `"`))
+		v, err := strconv.ParseUint(s, 10, val.Type().Bits())
+		if err != nil {
+			return errors.Errorf("json: invalid %s value %s", val.Type(), b)
+		}
+		val.SetUint(v)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		v, err := jsonDecodeFloat(b)
+		if err != nil {
+			return err
+		}
+		val.SetFloat(v)
+		return nil
+
+	case reflect.Slice:
+		var a []json.RawMessage
+		if err := json.Unmarshal(b, &a); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(val.Type(), len(a), len(a))
+		for i := range a {
+			if err := jsonDecode(a[i], s.Index(i)); err != nil {
+				return err
+			}
+		}
+		val.Set(s)
+		return nil
+
+	case reflect.Array:
+		var a []json.RawMessage
+		if err := json.Unmarshal(b, &a); err != nil {
+			return err
+		}
+		if len(a) != val.Len() {
+			return errors.Errorf("json: got %d elements for %s", len(a), val.Type())
+		}
+		for i := range a {
+			if err := jsonDecode(a[i], val.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+		for i := 0; i < val.NumField(); i++ {
+			f := val.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			fb, ok := m[f.Name]
+			if !ok {
+				continue
+			}
+			if err := jsonDecode(fb, val.Field(i)); err != nil {
+				return errors.Errorf("json: %s.%s: %s", val.Type().Name(), f.Name, err)
+			}
+		}
+		return nil
+
+	default:
+		return errors.Errorf("json: unsupported type %s", val.Type())
+	}
+}
+
+func jsonDecodeTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.IsZero() {
+		return time.Time{}, nil
+	}
+	return t.UTC(), nil
+}
+
+func jsonDecodeFloat(b []byte) (float64, error) {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		switch s {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		default:
+			return 0, errors.Errorf("json: invalid float value %q", s)
+		}
+	}
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return 0, err
+	}
+	return f, nil
+}
+
+// jsonDecodeNodeID decodes a NodeID and returns the namespace uri
+// if the Namespace field contains a string.
+func jsonDecodeNodeID(b []byte) (*NodeID, string, error) {
+	var v struct {
+		IdType    uint8
+		Id        json.RawMessage
+		Namespace json.RawMessage
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, "", err
+	}
+
+	var ns uint16
+	var uri string
+	if !isJSONNull(v.Namespace) {
+		if err := json.Unmarshal(v.Namespace, &ns); err != nil {
+			if err := json.Unmarshal(v.Namespace, &uri); err != nil {
+				return nil, "", errors.Errorf("json: invalid namespace %s", v.Namespace)
+			}
+		}
+	}
+
+	switch v.IdType {
+	case 0:
+		var id uint32
+		if err := json.Unmarshal(v.Id, &id); err != nil {
+			return nil, "", errors.Errorf("json: invalid numeric node id %s", v.Id)
+		}
+		switch {
+		case ns == 0 && id < 256:
+			return NewTwoByteNodeID(byte(id)), uri, nil
+		case ns < 256 && id <= math.MaxUint16:
+			return NewFourByteNodeID(byte(ns), uint16(id)), uri, nil
+		default:
+			return NewNumericNodeID(ns, id), uri, nil
+		}
+	case 1:
+		var id string
+		if err := json.Unmarshal(v.Id, &id); err != nil {
+			return nil, "", err
+		}
+		return NewStringNodeID(ns, id), uri, nil
+	case 2:
+		var id string
+		if err := json.Unmarshal(v.Id, &id); err != nil {
+			return nil, "", err
+		}
+		if _, err := ParseGUID(id); err != nil {
+			return nil, "", errors.Errorf("json: invalid guid %q", id)
+		}
+		return NewGUIDNodeID(ns, id), uri, nil
+	case 3:
+		var id []byte
+		if err := json.Unmarshal(v.Id, &id); err != nil {
+			return nil, "", err
+		}
+		return NewByteStringNodeID(ns, id), uri, nil
+	default:
+		return nil, "", errors.Errorf("json: invalid node id type %d", v.IdType)
+	}
+}
+
+func jsonDecodeExpandedNodeID(b []byte) (*ExpandedNodeID, error) {
+	n, uri, err := jsonDecodeNodeID(b)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		ServerUri uint32
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return NewExpandedNodeID(n, uri, v.ServerUri), nil
+}
+
+func jsonDecodeExtensionObject(b []byte) (*ExtensionObject, error) {
+	var v struct {
+		TypeId   json.RawMessage
+		Encoding uint8
+		Body     json.RawMessage
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	e := &ExtensionObject{TypeID: NewTwoByteExpandedNodeID(0)}
+	if !isJSONNull(v.TypeId) {
+		n, _, err := jsonDecodeNodeID(v.TypeId)
+		if err != nil {
+			return nil, err
+		}
+		e.TypeID = NewExpandedNodeID(n, "", 0)
+	}
+
+	if isJSONNull(v.Body) {
+		e.EncodingMask = v.Encoding
+		return e, nil
+	}
+
+	switch v.Encoding {
+	case ExtensionObjectXML:
+		var s string
+		if err := json.Unmarshal(v.Body, &s); err != nil {
+			return nil, err
+		}
+		x := XMLElement(s)
+		e.Value = &x
+
+	case ExtensionObjectBinary:
+		var body []byte
+		if err := json.Unmarshal(v.Body, &body); err != nil {
+			return nil, err
+		}
+		e.Value = eotypes.New(e.TypeID.NodeID)
+		if e.Value == nil {
+			// keep the body of an unknown type.
+			e.Value = ExtensionObjectBody(body)
+			break
+		}
+		if _, err := Decode(body, e.Value); err != nil {
+			return nil, err
+		}
+
+	default:
+		e.Value = eotypes.New(e.TypeID.NodeID)
+		if e.Value == nil {
+			return nil, errors.Errorf("json: unknown extension object %s", e.TypeID.NodeID)
+		}
+		if err := jsonDecode(v.Body, reflect.ValueOf(e.Value).Elem()); err != nil {
+			return nil, err
+		}
+	}
+	e.UpdateMask()
+	return e, nil
+}
+
+func jsonDecodeDataValue(b []byte) (*DataValue, error) {
+	var v struct {
+		Value             json.RawMessage
+		Status            *uint32
+		SourceTimestamp   *string
+		SourcePicoseconds *uint16
+		ServerTimestamp   *string
+		ServerPicoseconds *uint16
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	d := &DataValue{}
+	if v.Value != nil {
+		val, err := jsonDecodeVariant(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		d.Value = val
+		d.EncodingMask |= DataValueValue
+	}
+	if v.Status != nil {
+		d.Status = StatusCode(*v.Status)
+		d.EncodingMask |= DataValueStatusCode
+	}
+	if v.SourceTimestamp != nil {
+		t, err := jsonDecodeTime(*v.SourceTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		d.SourceTimestamp = t
+		d.EncodingMask |= DataValueSourceTimestamp
+	}
+	if v.SourcePicoseconds != nil {
+		d.SourcePicoseconds = *v.SourcePicoseconds
+		d.EncodingMask |= DataValueSourcePicoseconds
+	}
+	if v.ServerTimestamp != nil {
+		t, err := jsonDecodeTime(*v.ServerTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		d.ServerTimestamp = t
+		d.EncodingMask |= DataValueServerTimestamp
+	}
+	if v.ServerPicoseconds != nil {
+		d.ServerPicoseconds = *v.ServerPicoseconds
+		d.EncodingMask |= DataValueServerPicoseconds
+	}
+	return d, nil
+}
+
+func jsonDecodeVariant(b []byte) (*Variant, error) {
+	if isJSONNull(b) {
+		return &Variant{}, nil
+	}
+
+	var v struct {
+		Type       uint8
+		Body       json.RawMessage
+		Dimensions []int32
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if TypeID(v.Type) == TypeIDNull {
+		return &Variant{}, nil
+	}
+
+	typ, ok := variantTypeIDToType[TypeID(v.Type)]
+	if !ok {
+		return nil, errors.Errorf("json: invalid variant type id %d", v.Type)
+	}
+
+	body := bytes.TrimSpace(v.Body)
+	if len(body) == 0 || body[0] != '[' {
+		val := reflect.New(typ).Elem()
+		if err := jsonDecode(v.Body, val); err != nil {
+			return nil, err
+		}
+		va := &Variant{}
+		if err := va.set(val.Interface()); err != nil {
+			return nil, err
+		}
+		return va, nil
+	}
+
+	var a []json.RawMessage
+	if err := json.Unmarshal(body, &a); err != nil {
+		return nil, err
+	}
+
+	sliceType := reflect.SliceOf(typ)
+	if TypeID(v.Type) == TypeIDByte {
+		sliceType = reflect.TypeOf(ByteArray{})
+	}
+	vals := reflect.MakeSlice(sliceType, len(a), len(a))
+	for i := range a {
+		if err := jsonDecode(a[i], vals.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+
+	va := &Variant{}
+	if len(v.Dimensions) <= 1 {
+		if err := va.set(vals.Interface()); err != nil {
+			return nil, err
+		}
+		return va, nil
+	}
+
+	n := 1
+	dims := make([]int, len(v.Dimensions))
+	for i, d := range v.Dimensions {
+		if d < 0 {
+			return nil, errors.Errorf("json: invalid array dimension %d", d)
+		}
+		dims[i] = int(d)
+		n *= dims[i]
+	}
+	if n != vals.Len() {
+		return nil, errors.Errorf("json: array dimensions %v do not match %d elements", v.Dimensions, vals.Len())
+	}
+
+	va.mask = byte(v.Type) | VariantArrayValues | VariantArrayDimensions
+	va.arrayLength = int32(vals.Len())
+	va.arrayDimensionsLength = int32(len(v.Dimensions))
+	va.arrayDimensions = v.Dimensions
+	va.value = split(0, 0, vals.Len(), dims, vals).Interface()
+	return va, nil
+}
+
+func jsonDecodeDiagnosticInfo(b []byte) (*DiagnosticInfo, error) {
+	var v struct {
+		SymbolicId          *int32
+		NamespaceUri        *int32
+		Locale              *int32
+		LocalizedText       *int32
+		AdditionalInfo      *string
+		InnerStatusCode     *uint32
+		InnerDiagnosticInfo json.RawMessage
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	d := &DiagnosticInfo{}
+	if v.SymbolicId != nil {
+		d.SymbolicID = *v.SymbolicId
+		d.EncodingMask |= DiagnosticInfoSymbolicID
+	}
+	if v.NamespaceUri != nil {
+		d.NamespaceURI = *v.NamespaceUri
+		d.EncodingMask |= DiagnosticInfoNamespaceURI
+	}
+	if v.Locale != nil {
+		d.Locale = *v.Locale
+		d.EncodingMask |= DiagnosticInfoLocale
+	}
+	if v.LocalizedText != nil {
+		d.LocalizedText = *v.LocalizedText
+		d.EncodingMask |= DiagnosticInfoLocalizedText
+	}
+	if v.AdditionalInfo != nil {
+		d.AdditionalInfo = *v.AdditionalInfo
+		d.EncodingMask |= DiagnosticInfoAdditionalInfo
+	}
+	if v.InnerStatusCode != nil {
+		d.InnerStatusCode = StatusCode(*v.InnerStatusCode)
+		d.EncodingMask |= DiagnosticInfoInnerStatusCode
+	}
+	if !isJSONNull(v.InnerDiagnosticInfo) {
+		inner, err := jsonDecodeDiagnosticInfo(v.InnerDiagnosticInfo)
+		if err != nil {
+			return nil, err
+		}
+		d.InnerDiagnosticInfo = inner
+		d.EncodingMask |= DiagnosticInfoInnerDiagnosticInfo
+	}
+	return d, nil
+}
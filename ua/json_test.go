@@ -0,0 +1,271 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestJSON(t *testing.T) {
+	cases := []struct {
+		Name string
+		In   interface{}
+		JSON string
+	}{
+		{
+			Name: "null variant",
+			In:   MustVariant(nil),
+			JSON: `null`,
+		},
+		{
+			Name: "int32 variant",
+			In:   MustVariant(int32(-5)),
+			JSON: `{"Type":6,"Body":-5}`,
+		},
+		{
+			Name: "int64 variant",
+			In:   MustVariant(int64(1) << 60),
+			JSON: `{"Type":8,"Body":"1152921504606846976"}`,
+		},
+		{
+			Name: "bytestring variant",
+			In:   MustVariant([]byte{0xca, 0xfe}),
+			JSON: `{"Type":15,"Body":"yv4="}`,
+		},
+		{
+			Name: "string array variant",
+			In:   MustVariant([]string{"a", "b"}),
+			JSON: `{"Type":12,"Body":["a","b"]}`,
+		},
+		{
+			Name: "2d array variant",
+			In:   MustVariant([][]int32{{1, 2, 3}, {4, 5, 6}}),
+			JSON: `{"Type":6,"Body":[1,2,3,4,5,6],"Dimensions":[2,3]}`,
+		},
+		{
+			Name: "numeric node id",
+			In:   NewFourByteNodeID(1, 2253),
+			JSON: `{"Id":2253,"Namespace":1}`,
+		},
+		{
+			Name: "string node id",
+			In:   NewStringNodeID(2, "foo"),
+			JSON: `{"IdType":1,"Id":"foo","Namespace":2}`,
+		},
+		{
+			Name: "guid node id",
+			In:   NewGUIDNodeID(0, "AAAABBBB-CCDD-EEFF-0102-0123456789AB"),
+			JSON: `{"IdType":2,"Id":"AAAABBBB-CCDD-EEFF-0102-0123456789AB"}`,
+		},
+		{
+			Name: "opaque node id",
+			In:   NewByteStringNodeID(1, []byte{0xca, 0xfe}),
+			JSON: `{"IdType":3,"Id":"yv4=","Namespace":1}`,
+		},
+		{
+			Name: "expanded node id",
+			In:   NewExpandedNodeID(NewStringNodeID(0, "foo"), "urn:foo", 2),
+			JSON: `{"IdType":1,"Id":"foo","Namespace":"urn:foo","ServerUri":2}`,
+		},
+		{
+			Name: "localized text",
+			In:   NewLocalizedTextWithLocale("foo", "en"),
+			JSON: `{"Locale":"en","Text":"foo"}`,
+		},
+		{
+			Name: "data value",
+			In: &DataValue{
+				EncodingMask:    DataValueValue | DataValueSourceTimestamp,
+				Value:           MustVariant(float64(2.5)),
+				SourceTimestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			JSON: `{"Value":{"Type":11,"Body":2.5},"SourceTimestamp":"2020-01-02T03:04:05Z"}`,
+		},
+		{
+			Name: "extension object",
+			In:   NewExtensionObject(&AnonymousIdentityToken{PolicyID: "anonymous"}),
+			JSON: `{"TypeId":{"Id":321},"Body":{"PolicyID":"anonymous"}}`,
+		},
+		{
+			Name: "struct",
+			In: &ReadValueID{
+				NodeID:       NewTwoByteNodeID(1),
+				AttributeID:  AttributeIDValue,
+				DataEncoding: &QualifiedName{},
+			},
+			JSON: `{"NodeID":{"Id":1},"AttributeID":13,"IndexRange":"","DataEncoding":{"Name":""}}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			b, err := EncodeJSON(c.In)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "encode", string(b), c.JSON)
+
+			v := reflect.New(reflect.TypeOf(c.In))
+			if err := DecodeJSON(b, v.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "decode", v.Elem().Interface(), c.In)
+		})
+	}
+}
+
+func TestDecodeJSONNumericNodeIDType(t *testing.T) {
+	tests := []*NodeID{
+		NewTwoByteNodeID(255),
+		NewFourByteNodeID(0, 256),
+		NewFourByteNodeID(1, 65535),
+		NewFourByteNodeID(255, 65535),
+		NewNumericNodeID(1, 65536),
+		NewNumericNodeID(256, 1),
+	}
+	for _, n := range tests {
+		t.Run(n.String(), func(t *testing.T) {
+			b, err := EncodeJSON(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *NodeID
+			if err := DecodeJSON(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Type() != n.Type() || got.String() != n.String() {
+				t.Fatalf("got %s of type %v want %s of type %v", got.String(), got.Type(), n.String(), n.Type())
+			}
+		})
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	var n *NodeID
+	if err := DecodeJSON([]byte(`{"IdType":9,"Id":1}`), &n); err == nil {
+		t.Fatal("got nil want error")
+	}
+
+	var v *Variant
+	if err := DecodeJSON([]byte(`{"Type":6,"Body":[1,2,3],"Dimensions":[2,2]}`), &v); err == nil {
+		t.Fatal("got nil want error")
+	}
+
+	if err := DecodeJSON([]byte(`null`), v); err == nil {
+		t.Fatal("got nil want error")
+	}
+}
+
+func TestJSONNonReversible(t *testing.T) {
+	enc := &JSONEncoder{
+		NonReversible: true,
+		NamespaceURIs: []string{"http://opcfoundation.org/UA/", "urn:server", "urn:foo"},
+		ServerURIs:    []string{"urn:server", "urn:other"},
+	}
+
+	cases := []struct {
+		Name string
+		In   interface{}
+		JSON string
+	}{
+		{
+			Name: "int32 variant",
+			In:   MustVariant(int32(-5)),
+			JSON: `-5`,
+		},
+		{
+			Name: "2d array variant",
+			In:   MustVariant([][]int32{{1, 2, 3}, {4, 5, 6}}),
+			JSON: `[[1,2,3],[4,5,6]]`,
+		},
+		{
+			Name: "node id in namespace 1",
+			In:   NewStringNodeID(1, "foo"),
+			JSON: `{"IdType":1,"Id":"foo","Namespace":1}`,
+		},
+		{
+			Name: "node id with known namespace",
+			In:   NewStringNodeID(2, "foo"),
+			JSON: `{"IdType":1,"Id":"foo","Namespace":"urn:foo"}`,
+		},
+		{
+			Name: "node id with unknown namespace",
+			In:   NewStringNodeID(5, "foo"),
+			JSON: `{"IdType":1,"Id":"foo","Namespace":5}`,
+		},
+		{
+			Name: "expanded node id",
+			In:   NewExpandedNodeID(NewNumericNodeID(2, 1234), "", 1),
+			JSON: `{"Id":1234,"Namespace":"urn:foo","ServerUri":"urn:other"}`,
+		},
+		{
+			Name: "qualified name",
+			In:   &QualifiedName{NamespaceIndex: 2, Name: "Speed"},
+			JSON: `{"Name":"Speed","Uri":"urn:foo"}`,
+		},
+		{
+			Name: "localized text",
+			In:   NewLocalizedTextWithLocale("foo", "en"),
+			JSON: `"foo"`,
+		},
+		{
+			Name: "status code",
+			In:   StatusBadNodeIDUnknown,
+			JSON: `{"Code":2150891520,"Symbol":"BadNodeIDUnknown"}`,
+		},
+		{
+			Name: "good status code",
+			In:   StatusOK,
+			JSON: `{"Code":0,"Symbol":"Good"}`,
+		},
+		{
+			Name: "data value",
+			In: &DataValue{
+				EncodingMask:    DataValueValue | DataValueStatusCode | DataValueSourceTimestamp,
+				Value:           MustVariant(NewLocalizedText("on")),
+				Status:          StatusUncertain,
+				SourceTimestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			JSON: `{"Value":"on","Status":{"Code":1073741824,"Symbol":"Uncertain"},"SourceTimestamp":"2020-01-02T03:04:05Z"}`,
+		},
+		{
+			Name: "extension object",
+			In:   NewExtensionObject(&AnonymousIdentityToken{PolicyID: "anonymous"}),
+			JSON: `{"PolicyID":"anonymous"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			b, err := enc.Encode(c.In)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify.Values(t, "encode", string(b), c.JSON)
+		})
+	}
+}
+
+func TestJSONStatusCode(t *testing.T) {
+	d := &DiagnosticInfo{
+		EncodingMask:    DiagnosticInfoInnerStatusCode,
+		InnerStatusCode: StatusBadTimeout,
+	}
+	b, err := EncodeJSON(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "encode", string(b), `{"InnerStatusCode":2148139008}`)
+
+	var got *DiagnosticInfo
+	if err := DecodeJSON(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "decode", got, d)
+}
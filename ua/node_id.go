@@ -60,12 +60,15 @@ func NewStringNodeID(ns uint16, id string) *NodeID {
 	}
 }
 
-// NewGUIDNodeID returns a new GUID node id.
+// NewGUIDNodeID returns a new GUID node id. It panics if id is not a
+// valid GUID.
+//
+// See ParseGUID.
 func NewGUIDNodeID(ns uint16, id string) *NodeID {
 	return &NodeID{
 		mask: NodeIDTypeGUID,
 		ns:   ns,
-		gid:  NewGUID(id),
+		gid:  MustParseGUID(id),
 	}
 }
 
@@ -93,12 +96,14 @@ func MustParseNodeID(s string) *NodeID {
 // 'ns=<namespace>;{s,i,b,g}=<identifier>'.
 //
 // The 's=' prefix can be omitted for string node ids in namespace 0.
+// Byte string identifiers are base64 encoded and GUID identifiers can
+// be enclosed in braces.
 //
 // For numeric ids the smallest possible type which can store the namespace
 // and id value is returned.
 //
-// Namespace URIs are not supported since NodeID cannot store them. If you need
-// to support namespace URIs use ParseExpandedNodeID.
+// Namespace URIs and server indexes are not supported since NodeID cannot
+// store them. If you need to support them use ParseExpandedNodeID.
 func ParseNodeID(s string) (*NodeID, error) {
 	id, err := ParseExpandedNodeID(s, nil)
 	if err != nil {
@@ -238,7 +243,11 @@ func (n *NodeID) StringID() string {
 func (n *NodeID) SetStringID(v string) error {
 	switch n.Type() {
 	case NodeIDTypeGUID:
-		n.gid = NewGUID(v)
+		g, err := ParseGUID(v)
+		if err != nil {
+			return err
+		}
+		n.gid = g
 		return nil
 
 	case NodeIDTypeString:
@@ -261,39 +270,27 @@ func (n *NodeID) SetStringID(v string) error {
 // String returns the string representation of the NodeID
 // in the format described by ParseNodeID.
 func (n *NodeID) String() string {
+	if n.ns == 0 {
+		return n.identifier()
+	}
+	return fmt.Sprintf("ns=%d;%s", n.ns, n.identifier())
+}
+
+// identifier returns the '{s,i,b,g}=<identifier>' part of the string
+// representation of the node id.
+func (n *NodeID) identifier() string {
 	switch n.Type() {
-	case NodeIDTypeTwoByte:
+	case NodeIDTypeTwoByte, NodeIDTypeFourByte, NodeIDTypeNumeric:
 		return fmt.Sprintf("i=%d", n.nid)
 
-	case NodeIDTypeFourByte:
-		if n.ns == 0 {
-			return fmt.Sprintf("i=%d", n.nid)
-		}
-		return fmt.Sprintf("ns=%d;i=%d", n.ns, n.nid)
-
-	case NodeIDTypeNumeric:
-		if n.ns == 0 {
-			return fmt.Sprintf("i=%d", n.nid)
-		}
-		return fmt.Sprintf("ns=%d;i=%d", n.ns, n.nid)
-
 	case NodeIDTypeString:
-		if n.ns == 0 {
-			return fmt.Sprintf("s=%s", n.StringID())
-		}
-		return fmt.Sprintf("ns=%d;s=%s", n.ns, n.StringID())
+		return "s=" + n.StringID()
 
 	case NodeIDTypeGUID:
-		if n.ns == 0 {
-			return fmt.Sprintf("g=%s", n.StringID())
-		}
-		return fmt.Sprintf("ns=%d;g=%s", n.ns, n.StringID())
+		return "g=" + n.StringID()
 
 	case NodeIDTypeByteString:
-		if n.ns == 0 {
-			return fmt.Sprintf("b=%s", n.StringID())
-		}
-		return fmt.Sprintf("ns=%d;b=%s", n.ns, n.StringID())
+		return "b=" + n.StringID()
 
 	default:
 		panic(fmt.Sprintf("invalid node id type: %d", n.Type()))
@@ -302,38 +299,43 @@ func (n *NodeID) String() string {
 
 func (n *NodeID) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	n.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
 
+func (n *NodeID) decodeBuffer(buf *Buffer) {
 	n.mask = NodeIDType(buf.ReadByte())
 	typ := n.mask & 0xf
 
 	switch typ {
 	case NodeIDTypeTwoByte:
 		n.nid = uint32(buf.ReadByte())
-		return buf.Pos(), buf.Error()
 
 	case NodeIDTypeFourByte:
 		n.ns = uint16(buf.ReadByte())
 		n.nid = uint32(buf.ReadUint16())
-		return buf.Pos(), buf.Error()
 
 	case NodeIDTypeNumeric:
 		n.ns = buf.ReadUint16()
 		n.nid = buf.ReadUint32()
-		return buf.Pos(), buf.Error()
 
 	case NodeIDTypeGUID:
 		n.ns = buf.ReadUint16()
 		n.gid = &GUID{}
 		buf.ReadStruct(n.gid)
-		return buf.Pos(), buf.Error()
 
-	case NodeIDTypeByteString, NodeIDTypeString:
+	case NodeIDTypeByteString:
 		n.ns = buf.ReadUint16()
 		n.bid = buf.ReadBytes()
-		return buf.Pos(), buf.Error()
+
+	case NodeIDTypeString:
+		n.ns = buf.ReadUint16()
+		n.bid = copyBytes(buf.readBytes("string length", buf.decodeLimits().MaxStringLength))
 
 	default:
-		return 0, errors.Errorf("invalid node id type %v", typ)
+		if buf.err == nil {
+			buf.err = errors.Errorf("invalid node id type %v", typ)
+		}
 	}
 }
 
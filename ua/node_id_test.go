@@ -5,6 +5,7 @@
 package ua
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"math"
@@ -144,6 +145,7 @@ func TestParseNodeID(t *testing.T) {
 		{s: "i=1", n: NewTwoByteNodeID(1)},
 		{s: "i=2253", n: NewFourByteNodeID(0, 2253)},
 		{s: "ns=1;i=2", n: NewFourByteNodeID(1, 2)},
+		{s: "ns=1;i=65535", n: NewFourByteNodeID(1, 65535)},
 		{s: "ns=256;i=2", n: NewNumericNodeID(256, 2)},
 		{s: "ns=1;i=65536", n: NewNumericNodeID(1, 65536)},
 		{s: "ns=65535;i=65536", n: NewNumericNodeID(65535, 65536)},
@@ -153,6 +155,8 @@ func TestParseNodeID(t *testing.T) {
 		{s: "ns=1;s=a", n: NewStringNodeID(1, "a")},
 		{s: "ns=1;a", n: NewStringNodeID(1, "a")},
 		{s: "ns=1;s=foo;bar;", n: NewStringNodeID(1, "foo;bar;")},
+		{s: "s=foo;bar", n: NewStringNodeID(0, "foo;bar")},
+		{s: "ns=1;g={5eac051c-c313-43d7-b790-24aa2c3cfd37}", n: NewGUIDNodeID(1, "5eac051c-c313-43d7-b790-24aa2c3cfd37")},
 
 		// from https://github.com/Azure-Samples/iot-edge-opc-plc
 		{s: "ns=5;s=Special_\"!§$%&/()=?`´\\\\+~*\\'#_-:.;,<>|@^°€µ{[]}", n: NewStringNodeID(5, "Special_\"!§$%&/()=?`´\\\\+~*\\'#_-:.;,<>|@^°€µ{[]}")},
@@ -161,7 +165,8 @@ func TestParseNodeID(t *testing.T) {
 		{s: "abc=0;i=2", err: errors.New("invalid node id: abc=0;i=2")},
 		{s: "ns=0;i=1;s=2", err: errors.New("invalid numeric id: ns=0;i=1;s=2")},
 		{s: "ns=0", err: errors.New("invalid node id: ns=0")},
-		{s: "nsu=abc;i=1", err: errors.New("namespace urls require a server NamespaceArray")},
+		{s: "nsu=abc;i=1", err: errors.New("namespace uris are not supported. use `ua.ParseExpandedNodeID`")},
+		{s: "svr=1;i=1", err: errors.New("server index is not supported. use `ua.ParseExpandedNodeID`")},
 		{s: "ns=65536;i=1", err: errors.New("namespace id out of range (0..65535): ns=65536;i=1")},
 		{s: "ns=abc;i=1", err: errors.New("invalid namespace id: ns=abc;i=1")},
 		{s: "ns=1;i=abc", err: errors.New("invalid numeric id: ns=1;i=abc")},
@@ -249,7 +254,7 @@ func TestSetIntID(t *testing.T) {
 		},
 		{
 			name: "GUID.incompatible",
-			n:    NewGUIDNodeID(0, "a"),
+			n:    NewGUIDNodeID(0, "AAAABBBB-CCDD-EEFF-0101-0123456789AB"),
 			v:    1,
 			err:  errors.New("incompatible node id type"),
 		},
@@ -329,6 +334,12 @@ func TestSetStringID(t *testing.T) {
 			v:    "a",
 			err:  errors.New("incompatible node id type"),
 		},
+		{
+			name: "GUID.invalid",
+			n:    NewGUIDNodeID(0, "AAAABBBB-CCDD-EEFF-0101-0123456789AB"),
+			v:    "AAAABBBB-CCDD-EEFF-0101",
+			err:  errors.Errorf("invalid guid %q", "AAAABBBB-CCDD-EEFF-0101"),
+		},
 		{
 			name: "Opaque.badBase64",
 			n:    NewByteStringNodeID(0, []byte{'a'}),
@@ -516,3 +527,118 @@ func TestNodeIDToString(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeIDRoundTrip(t *testing.T) {
+	tests := []*NodeID{
+		// numeric
+		NewTwoByteNodeID(0),
+		NewTwoByteNodeID(255),
+		NewFourByteNodeID(1, 65535),
+		NewNumericNodeID(256, 1),
+		NewNumericNodeID(1, math.MaxUint32),
+
+		// string
+		NewStringNodeID(0, "foo;bar"),
+		NewStringNodeID(0, "i=5"),
+		NewStringNodeID(2, "ns=1;s=foo"),
+		NewStringNodeID(65535, "Speed"),
+
+		// guid
+		NewGUIDNodeID(0, "5EAC051C-C313-43D7-B790-24AA2C3CFD37"),
+		NewGUIDNodeID(3, "00000000-0000-0000-0000-000000000000"),
+
+		// opaque
+		NewByteStringNodeID(0, []byte{0x00, 0xff}),
+		NewByteStringNodeID(4, []byte("abc")),
+	}
+	for _, n := range tests {
+		t.Run(n.String(), func(t *testing.T) {
+			got, err := ParseNodeID(n.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, n) {
+				t.Fatalf("\ngot  %#v\nwant %#v", got, n)
+			}
+		})
+	}
+}
+
+func TestGUIDNodeIDRoundTrip(t *testing.T) {
+	b := []byte{
+		// mask
+		0x04,
+		// namespace
+		0x01, 0x00,
+		// data1 (inverse order)
+		0x91, 0x2b, 0x96, 0x72,
+		// data2 (inverse order)
+		0x75, 0xfa,
+		// data3 (inverse order)
+		0xe6, 0x4a,
+		// data4 (same order)
+		0x8d, 0x28, 0xb4, 0x04, 0xdc, 0x7d, 0xaf, 0x63,
+	}
+	for _, s := range []string{
+		"ns=1;g=72962B91-FA75-4AE6-8D28-B404DC7DAF63",
+		"ns=1;g=72962b91-fa75-4ae6-8d28-b404dc7daf63",
+		"ns=1;g={72962B91-FA75-4AE6-8D28-B404DC7DAF63}",
+	} {
+		t.Run(s, func(t *testing.T) {
+			n, err := ParseNodeID(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := n.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, b) {
+				t.Fatalf("got bytes %#v want %#v", got, b)
+			}
+
+			var m NodeID
+			if _, err := m.Decode(b); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := m.String(), "ns=1;g=72962B91-FA75-4AE6-8D28-B404DC7DAF63"; got != want {
+				t.Fatalf("got %s want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestNewGUIDNodeIDInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("NewGUIDNodeID(0, \"a\") did not panic")
+		}
+	}()
+	NewGUIDNodeID(0, "a")
+}
+
+func FuzzParseNodeID(f *testing.F) {
+	for _, s := range []string{
+		"i=1",
+		"ns=1;i=65536",
+		"ns=1;s=foo;bar",
+		"ns=1;g=5eac051c-c313-43d7-b790-24aa2c3cfd37",
+		"ns=1;b=YWJj",
+		"foo",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		n, err := ParseNodeID(s)
+		if err != nil {
+			return
+		}
+		n2, err := ParseNodeID(n.String())
+		if err != nil {
+			t.Fatalf("%q: cannot parse %q: %s", s, n.String(), err)
+		}
+		if !reflect.DeepEqual(n, n2) {
+			t.Fatalf("%q: got %#v after round trip want %#v", s, n2, n)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"math"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// The types of the notifications of a subscription are decoded without
+// reflection since a PublishResponse can contain thousands of values.
+// The decoders produce the same values as the reflection based decoder.
+
+// Decode implements the codec interface.
+func (m *NotificationMessage) Decode(b []byte) (int, error) {
+	buf := NewBuffer(b)
+	m.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (m *NotificationMessage) decodeBuffer(buf *Buffer) {
+	m.SequenceNumber = buf.ReadUint32()
+	m.PublishTime = buf.ReadTime()
+
+	// an extension object has at least a two byte type id and a mask
+	n := readArrayLength(buf, 3)
+	if n >= 0 {
+		m.NotificationData = make([]*ExtensionObject, n)
+		for i := range m.NotificationData {
+			m.NotificationData[i] = new(ExtensionObject)
+			buf.ReadStruct(m.NotificationData[i])
+		}
+	}
+}
+
+// Decode implements the codec interface.
+func (d *DataChangeNotification) Decode(b []byte) (int, error) {
+	buf := NewBuffer(b)
+	d.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (d *DataChangeNotification) decodeBuffer(buf *Buffer) {
+	// a monitored item notification has at least a client handle and
+	// the mask of the data value
+	n := readArrayLength(buf, 5)
+	if n >= 0 {
+		// allocate the items with their values at once
+		items := make([]MonitoredItemNotification, n)
+		values := make([]DataValue, n)
+		variants := make([]Variant, n)
+		d.MonitoredItems = make([]*MonitoredItemNotification, n)
+		for i := range items {
+			items[i].decode(buf, &values[i], &variants[i])
+			d.MonitoredItems[i] = &items[i]
+		}
+	}
+	d.DiagnosticInfos = readDiagnosticInfos(buf)
+}
+
+// Decode implements the codec interface.
+func (m *MonitoredItemNotification) Decode(b []byte) (int, error) {
+	buf := NewBuffer(b)
+	m.decode(buf, new(DataValue), nil)
+	return buf.Pos(), buf.Error()
+}
+
+func (m *MonitoredItemNotification) decodeBuffer(buf *Buffer) {
+	m.decode(buf, new(DataValue), nil)
+}
+
+// decode reads the notification into m and its value into dv. v is used
+// for the variant of the value if it is not nil.
+func (m *MonitoredItemNotification) decode(buf *Buffer, dv *DataValue, v *Variant) {
+	m.ClientHandle = buf.ReadUint32()
+	m.Value = dv
+	dv.decode(buf, v)
+}
+
+// readDiagnosticInfos reads an array of diagnostic infos.
+func readDiagnosticInfos(buf *Buffer) []*DiagnosticInfo {
+	n := readArrayLength(buf, 1)
+	if n < 0 {
+		return nil
+	}
+	d := make([]*DiagnosticInfo, n)
+	for i := range d {
+		d[i] = new(DiagnosticInfo)
+		d[i].decode(buf, 0)
+	}
+	return d
+}
+
+// readArrayLength reads the length of an array whose elements have at
+// least minSize bytes. It returns -1 for a null array and for errors.
+func readArrayLength(buf *Buffer, minSize int) int {
+	n := buf.ReadUint32()
+	if buf.err != nil || n == null {
+		return -1
+	}
+	if n > math.MaxInt32 {
+		buf.err = errors.Errorf("array too large: %d > %d", n, math.MaxInt32)
+		return -1
+	}
+	if !checkArrayLength(buf, int64(n), minSize) {
+		return -1
+	}
+	return int(n)
+}
@@ -0,0 +1,242 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+// publishResponse returns a PublishResponse with a DataChangeNotification
+// for n monitored items with the mix of values of a typical subscription.
+func publishResponse(n int) *PublishResponse {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	items := make([]*MonitoredItemNotification, n)
+	for i := range items {
+		var v interface{}
+		switch i % 4 {
+		case 0:
+			v = float64(i) * 1.5
+		case 1:
+			v = int32(i)
+		case 2:
+			v = i%3 == 0
+		case 3:
+			v = fmt.Sprintf("value %d", i)
+		}
+		items[i] = &MonitoredItemNotification{
+			ClientHandle: uint32(i),
+			Value: &DataValue{
+				EncodingMask:    DataValueValue | DataValueStatusCode | DataValueSourceTimestamp | DataValueServerTimestamp,
+				Value:           MustVariant(v),
+				Status:          StatusOK,
+				SourceTimestamp: ts,
+				ServerTimestamp: ts,
+			},
+		}
+	}
+	return &PublishResponse{
+		ResponseHeader: &ResponseHeader{
+			Timestamp:          ts,
+			RequestHandle:      42,
+			ServiceDiagnostics: &DiagnosticInfo{},
+			StringTable:        []string{},
+			AdditionalHeader:   NewExtensionObject(nil),
+		},
+		SubscriptionID:           7,
+		AvailableSequenceNumbers: []uint32{12},
+		NotificationMessage: &NotificationMessage{
+			SequenceNumber: 12,
+			PublishTime:    ts,
+			NotificationData: []*ExtensionObject{
+				NewExtensionObject(&DataChangeNotification{
+					MonitoredItems:  items,
+					DiagnosticInfos: []*DiagnosticInfo{},
+				}),
+			},
+		},
+		Results:         []StatusCode{StatusOK},
+		DiagnosticInfos: []*DiagnosticInfo{},
+	}
+}
+
+func encodePublishResponse(t testing.TB, n int) []byte {
+	t.Helper()
+	b, err := NewFourByteExpandedNodeID(0, id.PublishResponse_Encoding_DefaultBinary).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := Encode(publishResponse(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(b, body...)
+}
+
+func TestMonitoredItemNotification(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name: "Normal",
+			Struct: &MonitoredItemNotification{
+				ClientHandle: 1,
+				Value: &DataValue{
+					EncodingMask: DataValueValue | DataValueStatusCode,
+					Value:        MustVariant(int32(42)),
+					Status:       StatusUncertain,
+				},
+			},
+			Bytes: []byte{
+				// ClientHandle
+				0x01, 0x00, 0x00, 0x00,
+				// EncodingMask
+				0x03,
+				// Value
+				0x06, 0x2a, 0x00, 0x00, 0x00,
+				// Status
+				0x00, 0x00, 0x00, 0x40,
+			},
+		},
+		{
+			Name: "NoValue",
+			Struct: &MonitoredItemNotification{
+				ClientHandle: 2,
+				Value:        &DataValue{},
+			},
+			Bytes: []byte{
+				// ClientHandle
+				0x02, 0x00, 0x00, 0x00,
+				// EncodingMask
+				0x00,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestDataChangeNotification(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name: "Normal",
+			Struct: &DataChangeNotification{
+				MonitoredItems: []*MonitoredItemNotification{
+					{ClientHandle: 1, Value: &DataValue{EncodingMask: DataValueValue, Value: MustVariant(true)}},
+					{ClientHandle: 2, Value: &DataValue{EncodingMask: DataValueValue, Value: MustVariant("abc")}},
+				},
+				DiagnosticInfos: []*DiagnosticInfo{},
+			},
+			Bytes: []byte{
+				// MonitoredItems
+				0x02, 0x00, 0x00, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x01, 0x01, 0x01,
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x0c, 0x03, 0x00, 0x00, 0x00, 0x61, 0x62, 0x63,
+				// DiagnosticInfos
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			Name: "Null",
+			Struct: &DataChangeNotification{
+				DiagnosticInfos: []*DiagnosticInfo{{EncodingMask: DiagnosticInfoSymbolicID, SymbolicID: 1}},
+			},
+			Bytes: []byte{
+				// MonitoredItems
+				0xff, 0xff, 0xff, 0xff,
+				// DiagnosticInfos
+				0x01, 0x00, 0x00, 0x00,
+				0x01, 0x01, 0x00, 0x00, 0x00,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestDecodePublishResponse(t *testing.T) {
+	want := publishResponse(100)
+	b, err := Encode(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(PublishResponse)
+	if _, err := Decode(b, got); err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", got, want)
+}
+
+func TestDataChangeNotificationTooShort(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{
+			name: "count exceeds buffer",
+			b:    []byte{0x00, 0x00, 0x00, 0x10, 0x01, 0x00, 0x00, 0x00, 0x00},
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "count too large",
+			b:    []byte{0xfe, 0xff, 0xff, 0xff},
+			err:  errors.Errorf("array too large: 4294967294 > 2147483647"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := new(DataChangeNotification).Decode(tt.b)
+			if !errors.Equal(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDecodeDoesNotReferenceBuffer(t *testing.T) {
+	b, err := Encode(&MonitoredItemNotification{
+		ClientHandle: 1,
+		Value:        &DataValue{EncodingMask: DataValueValue, Value: MustVariant([]byte{1, 2, 3})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := new(MonitoredItemNotification)
+	if _, err := m.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := range b {
+		b[i] = 0xff
+	}
+	verify.Values(t, "", m.Value.Value.Value(), []byte{1, 2, 3})
+}
+
+func FuzzDecodeDataChangeNotification(f *testing.F) {
+	b, err := Encode(publishResponse(4).NotificationMessage.NotificationData[0].Value)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(b)
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		new(DataChangeNotification).Decode(b)
+	})
+}
+
+func BenchmarkDecodePublishResponse(b *testing.B) {
+	data := encodePublishResponse(b, 1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := DecodeService(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -32,7 +32,30 @@ func ServiceTypeID(v interface{}) uint16 {
 	return uint16(id.IntID())
 }
 
+// DecodeService decodes the type id and the service object of a message
+// with DefaultDecodeLimits.
 func DecodeService(b []byte) (*ExpandedNodeID, interface{}, error) {
+	return DecodeServiceWithLimits(b, nil)
+}
+
+// DecodeServiceWithLimits decodes the type id and the service object of
+// a message with the limits. The zero fields of limits and a nil limits
+// use DefaultDecodeLimits. The type id is also returned with the error
+// for an unknown service or a service which cannot be decoded.
+func DecodeServiceWithLimits(b []byte, limits *DecodeLimits) (*ExpandedNodeID, interface{}, error) {
+	return DecodeServiceWithNamespaces(b, limits, nil)
+}
+
+// DecodeServiceWithNamespaces decodes the type id and the service object
+// of a message like DecodeServiceWithLimits. The namespace array of the
+// server resolves the types of the extension objects which are
+// registered with RegisterExtensionObjectURI.
+func DecodeServiceWithNamespaces(b []byte, limits *DecodeLimits, namespaces []string) (*ExpandedNodeID, interface{}, error) {
+	limits = limits.withDefaults()
+	if exceeds(int64(len(b)), limits.MaxMessageSize) {
+		return nil, nil, limitError("message size", int64(len(b)), limits.MaxMessageSize)
+	}
+
 	typeID := new(ExpandedNodeID)
 	n, err := typeID.Decode(b)
 	if err != nil {
@@ -42,13 +65,13 @@ func DecodeService(b []byte) (*ExpandedNodeID, interface{}, error) {
 
 	v := svcreg.New(typeID.NodeID)
 	if v == nil {
-		return nil, nil, StatusBadServiceUnsupported
+		return typeID, nil, StatusBadServiceUnsupported
 	}
 
 	if debug.FlagSet("packet") {
 		fmt.Printf("%T: %#v\n", v, b)
 	}
 
-	_, err = Decode(b, v)
+	_, err = decodeWith(b, v, limits, namespaces)
 	return typeID, v, err
 }
@@ -0,0 +1,176 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// These flags define the bits of a StatusCode.
+//
+// Specification: Part 4, 7.34.1
+const (
+	statusSeverityMask      = 0xC0000000
+	statusSubCodeMask       = 0xFFFF0000
+	statusStructureChanged  = 0x8000
+	statusSemanticsChanged  = 0x4000
+	statusInfoTypeMask      = 0x0C00
+	statusInfoTypeDataValue = 0x0400
+	statusLimitMask         = 0x0300
+	statusOverflow          = 0x0080
+)
+
+// StatusLimit is the value of the limit bits of a StatusCode which
+// indicate whether a value is at one of its limits.
+type StatusLimit uint8
+
+// These are the values of the limit bits of a StatusCode.
+const (
+	StatusLimitNone     StatusLimit = 0
+	StatusLimitLow      StatusLimit = 1
+	StatusLimitHigh     StatusLimit = 2
+	StatusLimitConstant StatusLimit = 3
+)
+
+// IsGood returns true if the severity of the status code is good.
+func (n StatusCode) IsGood() bool {
+	return n&statusSeverityMask == 0
+}
+
+// IsUncertain returns true if the severity of the status code is
+// uncertain.
+func (n StatusCode) IsUncertain() bool {
+	return n&statusSeverityMask == StatusUncertain
+}
+
+// IsBad returns true if the severity of the status code is bad.
+func (n StatusCode) IsBad() bool {
+	return n&StatusBad != 0
+}
+
+// Code returns the status code without the info bits, i.e. the
+// severity and the sub code which identify the status.
+func (n StatusCode) Code() StatusCode {
+	return n & statusSubCodeMask
+}
+
+// Name returns the symbolic name of the status code without the info
+// bits, e.g. "BadNodeIDUnknown". It returns "Good", "Uncertain" or
+// "Bad" for unknown status codes.
+func (n StatusCode) Name() string {
+	if d, ok := StatusCodes[n.Code()]; ok {
+		return strings.TrimPrefix(d.Name, "Status")
+	}
+	switch {
+	case n.IsGood():
+		return "Good"
+	case n.IsUncertain():
+		return "Uncertain"
+	default:
+		return "Bad"
+	}
+}
+
+// Description returns the description of the status code without the
+// info bits from the specification, e.g. "There is no subscription
+// available for this session." for StatusBadNoSubscription, or an empty
+// string for unknown status codes.
+func (n StatusCode) Description() string {
+	return StatusCodes[n.Code()].Text
+}
+
+// StructureChanged returns true if the structure of the value has
+// changed, e.g. its data type or array dimensions.
+func (n StatusCode) StructureChanged() bool {
+	return n&statusStructureChanged != 0
+}
+
+// SemanticsChanged returns true if the semantics of the value have
+// changed, e.g. its engineering units.
+func (n StatusCode) SemanticsChanged() bool {
+	return n&statusSemanticsChanged != 0
+}
+
+// Limit returns the limit bits of a status code of a data value.
+func (n StatusCode) Limit() StatusLimit {
+	if n&statusInfoTypeMask != statusInfoTypeDataValue {
+		return StatusLimitNone
+	}
+	return StatusLimit(n & statusLimitMask >> 8)
+}
+
+// Overflow returns true if the status code of a data value indicates
+// that notifications of a monitored item have been lost because its
+// queue has overflowed.
+func (n StatusCode) Overflow() bool {
+	return n&statusInfoTypeMask == statusInfoTypeDataValue && n&statusOverflow != 0
+}
+
+// CombineStatusCodes returns an error for the bad status codes of the
+// results of a service with multiple operations, e.g. Write, Read or
+// Call, or nil if none of the operations failed. The error is a
+// *StatusCodesError with the index and the status code of every failed
+// operation. Uncertain status codes are not considered failures.
+func CombineStatusCodes(codes []StatusCode) error {
+	var failed []IndexedStatusCode
+	for i, code := range codes {
+		if code.IsBad() {
+			failed = append(failed, IndexedStatusCode{Index: i, StatusCode: code})
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &StatusCodesError{Count: len(codes), Failed: failed}
+}
+
+// IndexedStatusCode is the status code of the operation with the index
+// in the request.
+type IndexedStatusCode struct {
+	Index      int
+	StatusCode StatusCode
+}
+
+// StatusCodesError is the error for the failed operations of a service
+// with multiple operations.
+type StatusCodesError struct {
+	// Count is the number of operations.
+	Count int
+
+	// Failed contains the operations with a bad status code in the
+	// order of their index.
+	Failed []IndexedStatusCode
+}
+
+func (e *StatusCodesError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%d of %d operations failed:", errors.Prefix, len(e.Failed), e.Count)
+	for i, f := range e.Failed {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, " [%d] %s (0x%X)", f.Index, f.StatusCode.Name(), uint32(f.StatusCode))
+	}
+	return b.String()
+}
+
+// Is returns true if one of the failed operations has the status code
+// target so that errors.Is(err, StatusBadNodeIDUnknown) works for the
+// combined error.
+func (e *StatusCodesError) Is(target error) bool {
+	code, ok := target.(StatusCode)
+	if !ok {
+		return false
+	}
+	for _, f := range e.Failed {
+		if f.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
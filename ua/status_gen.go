@@ -12,7 +12,7 @@ import "fmt"
 type StatusCode uint32
 
 func (n StatusCode) Error() string {
-	if d, ok := StatusCodes[n]; ok {
+	if d, ok := StatusCodes[n.Code()]; ok {
 		return fmt.Sprintf("%s %s (0x%X)", d.Text, d.Name, uint32(n))
 	}
 	return fmt.Sprintf("0x%X", uint32(n))
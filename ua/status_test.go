@@ -0,0 +1,124 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestStatusCode(t *testing.T) {
+	type result struct {
+		Good, Uncertain, Bad bool
+		Name                 string
+		Limit                StatusLimit
+		Overflow             bool
+		StructureChanged     bool
+		SemanticsChanged     bool
+	}
+	tests := []struct {
+		code StatusCode
+		want result
+	}{
+		{StatusOK, result{Good: true, Name: "OK"}},
+		{StatusGoodOverload, result{Good: true, Name: "GoodOverload"}},
+		{StatusUncertainLastUsableValue, result{Uncertain: true, Name: "UncertainLastUsableValue"}},
+		{StatusBadSessionIDInvalid, result{Bad: true, Name: "BadSessionIDInvalid"}},
+		{0x80FF0000, result{Bad: true, Name: "Bad"}},
+		{0xC0000000, result{Bad: true, Name: "Bad"}},
+		{StatusOK | 0x0400 | 0x0100, result{Good: true, Name: "OK", Limit: StatusLimitLow}},
+		{StatusOK | 0x0400 | 0x0300 | 0x0080, result{Good: true, Name: "OK", Limit: StatusLimitConstant, Overflow: true}},
+		{StatusOK | 0x0300 | 0x0080, result{Good: true, Name: "OK"}},
+		{StatusBadTypeMismatch | 0x8000 | 0x4000, result{Bad: true, Name: "BadTypeMismatch", StructureChanged: true, SemanticsChanged: true}},
+	}
+	for _, tt := range tests {
+		got := result{
+			Good:             tt.code.IsGood(),
+			Uncertain:        tt.code.IsUncertain(),
+			Bad:              tt.code.IsBad(),
+			Name:             tt.code.Name(),
+			Limit:            tt.code.Limit(),
+			Overflow:         tt.code.Overflow(),
+			StructureChanged: tt.code.StructureChanged(),
+			SemanticsChanged: tt.code.SemanticsChanged(),
+		}
+		verify.Values(t, tt.code.Error(), got, tt.want)
+	}
+}
+
+func TestStatusCodeDescription(t *testing.T) {
+	tests := []struct {
+		code       StatusCode
+		name, desc string
+	}{
+		{StatusBadNoSubscription, "BadNoSubscription", "There is no subscription available for this session."},
+		{0x80790000 | 0x0400 | 0x0080, "BadNoSubscription", "There is no subscription available for this session."},
+		{StatusBadInvalidArgument, "BadInvalidArgument", "One or more arguments are invalid."},
+		{0x80FF0000, "Bad", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.code.Name(); got != tt.name {
+			t.Fatalf("%#x: got name %q want %q", uint32(tt.code), got, tt.name)
+		}
+		if got := tt.code.Description(); got != tt.desc {
+			t.Fatalf("%#x: got description %q want %q", uint32(tt.code), got, tt.desc)
+		}
+	}
+}
+
+func TestStatusCodeError(t *testing.T) {
+	tests := []struct {
+		code StatusCode
+		want string
+	}{
+		{StatusBadSessionIDInvalid, "The session id is not valid. StatusBadSessionIDInvalid (0x80250000)"},
+		{StatusGoodOverload | 0x0400 | 0x0200, "Sampling has slowed down due to resource limitations. StatusGoodOverload (0x2F0600)"},
+		{0x80FF0000, "0x80FF0000"},
+	}
+	for _, tt := range tests {
+		if got := tt.code.Error(); got != tt.want {
+			t.Fatalf("got %q want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCombineStatusCodes(t *testing.T) {
+	t.Run("good", func(t *testing.T) {
+		codes := []StatusCode{StatusOK, StatusGoodOverload, StatusUncertainLastUsableValue}
+		if err := CombineStatusCodes(codes); err != nil {
+			t.Fatalf("got %v want nil", err)
+		}
+		if err := CombineStatusCodes(nil); err != nil {
+			t.Fatalf("got %v want nil", err)
+		}
+	})
+
+	t.Run("bad", func(t *testing.T) {
+		codes := []StatusCode{StatusOK, StatusBadNodeIDUnknown, StatusOK, StatusBadTypeMismatch | 0x8000}
+		err := CombineStatusCodes(codes)
+		want := &StatusCodesError{
+			Count: 4,
+			Failed: []IndexedStatusCode{
+				{Index: 1, StatusCode: StatusBadNodeIDUnknown},
+				{Index: 3, StatusCode: StatusBadTypeMismatch | 0x8000},
+			},
+		}
+		verify.Values(t, "", err, want)
+
+		msg := "opcua: 2 of 4 operations failed: [1] BadNodeIDUnknown (0x80340000), [3] BadTypeMismatch (0x80748000)"
+		if got := err.Error(); got != msg {
+			t.Fatalf("got %q want %q", got, msg)
+		}
+		if !errors.Is(err, StatusBadNodeIDUnknown) {
+			t.Fatal("errors.Is(err, StatusBadNodeIDUnknown) = false")
+		}
+		if errors.Is(err, StatusBadUserAccessDenied) {
+			t.Fatal("errors.Is(err, StatusBadUserAccessDenied) = true")
+		}
+	})
+}
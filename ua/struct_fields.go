@@ -0,0 +1,181 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// structField describes how a field of a struct is encoded.
+//
+// The encoding of a field can be controlled with the opcua struct tag
+// which mirrors the SwitchField, SwitchValue and LengthField attributes
+// of the fields in an OPC UA binary schema:
+//
+//	Mask  uint32
+//	Name  string  `opcua:"switch=Mask,bit=0"`   // only if bit 0 of Mask is set
+//	Kind  int32
+//	Value float64 `opcua:"switch=Kind,value=2"` // only if Kind is 2
+//	Count int32
+//	Items []int32 `opcua:"length=Count"`        // Count elements without length prefix
+//	Cache string  `opcua:"-"`                   // never encoded
+//
+// The switch and length fields must be declared before the field which
+// refers to them.
+//
+// Specification: Part 6, Annex C
+type structField struct {
+	index int
+	name  string
+
+	// switchField is the index of the field which determines whether
+	// the field is encoded or -1.
+	switchField int
+	switchBit   int
+	switchValue int64
+	hasValue    bool
+
+	// lengthField is the index of the field which contains the number
+	// of elements of a slice which is encoded without a length prefix
+	// or -1.
+	lengthField int
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the encoded fields of the struct type t.
+func structFields(t reflect.Type) ([]structField, error) {
+	if f, ok := structFieldCache.Load(t); ok {
+		return f.([]structField), nil
+	}
+
+	var fields []structField
+	index := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		index[ft.Name] = i
+
+		tag := ft.Tag.Get("opcua")
+		if tag == "-" {
+			continue
+		}
+		f := structField{index: i, name: ft.Name, switchField: -1, switchBit: -1, lengthField: -1}
+		if tag != "" {
+			if err := f.parseTag(t, tag, index); err != nil {
+				return nil, errors.Errorf("%s.%s: %s", t.Name(), ft.Name, err)
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	structFieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func (f *structField) parseTag(t reflect.Type, tag string, index map[string]int) error {
+	// prior returns the index of a field declared before the current
+	// field which can hold a switch or a length.
+	prior := func(name string, kinds ...reflect.Kind) (int, error) {
+		i, ok := index[name]
+		if !ok || i == f.index {
+			return -1, errors.Errorf("unknown or later field %q", name)
+		}
+		k := t.Field(i).Type.Kind()
+		for _, kind := range kinds {
+			if k == kind {
+				return i, nil
+			}
+		}
+		return -1, errors.Errorf("field %q has unsupported type %s", name, t.Field(i).Type)
+	}
+
+	var err error
+	for _, opt := range strings.Split(tag, ",") {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf("invalid tag option %q", opt)
+		}
+		switch k, v := kv[0], kv[1]; k {
+		case "switch":
+			f.switchField, err = prior(v, intKinds...)
+			if err != nil {
+				return err
+			}
+		case "bit":
+			f.switchBit, err = strconv.Atoi(v)
+			if err != nil || f.switchBit < 0 || f.switchBit > 63 {
+				return errors.Errorf("invalid bit %q", v)
+			}
+		case "value":
+			f.switchValue, err = strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return errors.Errorf("invalid value %q", v)
+			}
+			f.hasValue = true
+		case "length":
+			if t.Field(f.index).Type.Kind() != reflect.Slice {
+				return errors.Errorf("length requires a slice")
+			}
+			f.lengthField, err = prior(v, intKinds[1:]...)
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("invalid tag option %q", opt)
+		}
+	}
+
+	switch {
+	case f.switchField < 0 && (f.switchBit >= 0 || f.hasValue):
+		return errors.Errorf("bit or value without switch")
+	case f.switchBit >= 0 && f.hasValue:
+		return errors.Errorf("bit and value are exclusive")
+	case f.switchField >= 0 && f.switchBit < 0 && !f.hasValue && t.Field(f.switchField).Type.Kind() != reflect.Bool:
+		return errors.Errorf("switch requires bit or value")
+	}
+	return nil
+}
+
+// intKinds are the kinds of switch fields. Length fields must be integers.
+var intKinds = []reflect.Kind{
+	reflect.Bool,
+	reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+	reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+}
+
+func intValue(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return int64(v.Uint())
+	default:
+		return v.Int()
+	}
+}
+
+// present returns true if the field of the struct val is encoded.
+func (f *structField) present(val reflect.Value) bool {
+	if f.switchField < 0 {
+		return true
+	}
+	sw := intValue(val.Field(f.switchField))
+	switch {
+	case f.switchBit >= 0:
+		return sw&(1<<uint(f.switchBit)) != 0
+	case f.hasValue:
+		return sw == f.switchValue
+	default:
+		return sw != 0
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+)
+
+// optionalFields has the layout which cmd/service generates for a
+// structure with optional fields.
+type optionalFields struct {
+	EncodingMask uint32
+	Name         string
+	Low          float64        `opcua:"switch=EncodingMask,bit=0"`
+	High         *LocalizedText `opcua:"switch=EncodingMask,bit=1"`
+}
+
+// union has the layout which cmd/service generates for a union.
+type union struct {
+	SwitchField uint32
+	Number      int32  `opcua:"switch=SwitchField,value=1"`
+	Text        string `opcua:"switch=SwitchField,value=2"`
+}
+
+func TestOptionalFields(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name:   "none",
+			Struct: &optionalFields{Name: "a"},
+			Bytes: []byte{
+				// EncodingMask
+				0x00, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+			},
+		},
+		{
+			Name: "second",
+			Struct: &optionalFields{
+				EncodingMask: 0x2,
+				Name:         "a",
+				High:         &LocalizedText{EncodingMask: LocalizedTextText, Text: "x"},
+			},
+			Bytes: []byte{
+				// EncodingMask
+				0x02, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+				// High
+				0x02, 0x01, 0x00, 0x00, 0x00, 0x78,
+			},
+		},
+		{
+			Name: "all",
+			Struct: &optionalFields{
+				EncodingMask: 0x3,
+				Name:         "a",
+				Low:          1.5,
+				High:         &LocalizedText{EncodingMask: LocalizedTextText, Text: "x"},
+			},
+			Bytes: []byte{
+				// EncodingMask
+				0x03, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+				// Low
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f,
+				// High
+				0x02, 0x01, 0x00, 0x00, 0x00, 0x78,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestUnion(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name:   "null",
+			Struct: &union{},
+			Bytes:  []byte{0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			Name:   "number",
+			Struct: &union{SwitchField: 1, Number: 5},
+			Bytes: []byte{
+				// SwitchField
+				0x01, 0x00, 0x00, 0x00,
+				// Number
+				0x05, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			Name:   "text",
+			Struct: &union{SwitchField: 2, Text: "ab"},
+			Bytes: []byte{
+				// SwitchField
+				0x02, 0x00, 0x00, 0x00,
+				// Text
+				0x02, 0x00, 0x00, 0x00, 0x61, 0x62,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
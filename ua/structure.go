@@ -0,0 +1,441 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/id"
+)
+
+// MaxStructureDepth is the maximum nesting depth of structures which
+// are decoded with a StructureDefinition.
+const MaxStructureDepth = 100
+
+// datatypes contains the data types which are decoded with their
+// definition instead of a registered Go type.
+var datatypes = newDataTypeRegistry()
+
+func init() {
+	// abstract and derived data types of namespace 0 which are encoded
+	// as one of the built-in types.
+	//
+	// Specification: Part 6, 5.1.5 and 5.1.6
+	for dt, typ := range map[uint32]TypeID{
+		id.Number:                         TypeIDVariant,
+		id.Integer:                        TypeIDVariant,
+		id.UInteger:                       TypeIDVariant,
+		id.Enumeration:                    TypeIDInt32,
+		id.Image:                          TypeIDByteString,
+		id.IntegerID:                      TypeIDUint32,
+		id.Counter:                        TypeIDUint32,
+		id.Duration:                       TypeIDDouble,
+		id.NumericRange:                   TypeIDString,
+		id.Time:                           TypeIDString,
+		id.Date:                           TypeIDDateTime,
+		id.UtcTime:                        TypeIDDateTime,
+		id.LocaleID:                       TypeIDString,
+		id.ApplicationInstanceCertificate: TypeIDByteString,
+		id.Index:                          TypeIDUint32,
+	} {
+		RegisterSimpleDataType(NewNumericNodeID(0, dt), typ)
+	}
+}
+
+// RegisterStructureDefinition registers the definition of a structured
+// data type. Extension objects with the DefaultEncodingID of the
+// definition are decoded into a *Structure unless a Go type was
+// registered for the encoding id with RegisterExtensionObject.
+// Registering a data type again replaces the previous definition.
+//
+// Specification: Part 3, 8.48
+func RegisterStructureDefinition(dataTypeID *NodeID, def *StructureDefinition) {
+	datatypes.registerStructure(dataTypeID, def)
+}
+
+// RegisterSimpleDataType registers a data type which is encoded as the
+// built-in type typ, e.g. an enumeration which is encoded as Int32.
+func RegisterSimpleDataType(dataTypeID *NodeID, typ TypeID) {
+	datatypes.registerSimple(dataTypeID, typ)
+}
+
+// LookupStructureDefinition returns the registered definition of a
+// structured data type or nil.
+func LookupStructureDefinition(dataTypeID *NodeID) *StructureDefinition {
+	return datatypes.structure(dataTypeID)
+}
+
+// LookupSimpleDataType returns the built-in type which is used to encode
+// the values of a data type and true if the data type is a built-in type
+// or was registered with RegisterSimpleDataType.
+func LookupSimpleDataType(dataTypeID *NodeID) (TypeID, bool) {
+	return datatypes.builtin(dataTypeID)
+}
+
+type dataTypeRegistry struct {
+	mu        sync.RWMutex
+	structs   map[string]*StructureDefinition
+	encodings map[string]*NodeID
+	simple    map[string]TypeID
+}
+
+func newDataTypeRegistry() *dataTypeRegistry {
+	return &dataTypeRegistry{
+		structs:   make(map[string]*StructureDefinition),
+		encodings: make(map[string]*NodeID),
+		simple:    make(map[string]TypeID),
+	}
+}
+
+func (r *dataTypeRegistry) registerStructure(dataTypeID *NodeID, def *StructureDefinition) {
+	if dataTypeID == nil || def == nil {
+		panic("opcua: missing id or definition in call to RegisterStructureDefinition")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.structs[dataTypeID.String()] = def
+	if def.DefaultEncodingID != nil {
+		r.encodings[def.DefaultEncodingID.String()] = dataTypeID
+	}
+}
+
+func (r *dataTypeRegistry) registerSimple(dataTypeID *NodeID, typ TypeID) {
+	if dataTypeID == nil {
+		panic("opcua: missing id in call to RegisterSimpleDataType")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.simple[dataTypeID.String()] = typ
+}
+
+func (r *dataTypeRegistry) structure(dataTypeID *NodeID) *StructureDefinition {
+	if dataTypeID == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.structs[dataTypeID.String()]
+}
+
+// builtin returns the built-in type which is used to encode values
+// of the data type.
+func (r *dataTypeRegistry) builtin(dataTypeID *NodeID) (TypeID, bool) {
+	if dataTypeID == nil {
+		return 0, false
+	}
+	// the ids of the built-in data types match their type ids.
+	if dataTypeID.Namespace() == 0 && dataTypeID.IntID() >= uint32(TypeIDBoolean) && dataTypeID.IntID() <= uint32(TypeIDDiagnosticInfo) {
+		return TypeID(dataTypeID.IntID()), true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	typ, ok := r.simple[dataTypeID.String()]
+	return typ, ok
+}
+
+// newStructure returns an empty structure for the binary encoding id
+// of a registered data type or nil.
+func (r *dataTypeRegistry) newStructure(encodingID *NodeID) *Structure {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dt, ok := r.encodings[encodingID.String()]
+	if !ok {
+		return nil
+	}
+	return &Structure{TypeID: dt, Definition: r.structs[dt.String()]}
+}
+
+// Structure is the value of a structured data type which is decoded
+// with a StructureDefinition instead of a Go type.
+//
+// Fields contains the field values by name. Optional fields which are
+// not set and the fields of a union which are not selected are not in
+// the map. Values of built-in types have the same Go type as in a
+// Variant, nested structures are *Structure values unless a Go type is
+// registered for their encoding and arrays are slices of these types.
+//
+// Specification: Part 6, 5.2.6 and 5.2.7
+type Structure struct {
+	// TypeID is the id of the data type.
+	TypeID *NodeID
+
+	// Definition describes the fields of the data type.
+	Definition *StructureDefinition
+
+	// Fields contains the values of the fields.
+	Fields map[string]interface{}
+}
+
+func (s *Structure) Decode(b []byte) (int, error) {
+	buf := NewBuffer(b)
+	s.decode(buf, 0)
+	return buf.Pos(), buf.Error()
+}
+
+func (s *Structure) decodeBuffer(buf *Buffer) {
+	s.decode(buf, 0)
+}
+
+func (s *Structure) decode(buf *Buffer, depth int) {
+	if depth > MaxStructureDepth {
+		buf.err = errors.Errorf("structure %s nested too deeply", s.TypeID)
+		return
+	}
+	if s.Definition == nil {
+		buf.err = errors.Errorf("missing definition for structure %s", s.TypeID)
+		return
+	}
+
+	s.Fields = make(map[string]interface{})
+	fields := s.Definition.Fields
+	switch s.Definition.StructureType {
+	case StructureTypeUnion:
+		sw := buf.ReadUint32()
+		if buf.Error() != nil || sw == 0 {
+			return
+		}
+		if int64(sw) > int64(len(fields)) {
+			buf.err = errors.Errorf("invalid switch field %d for union %s", sw, s.TypeID)
+			return
+		}
+		f := fields[sw-1]
+		s.Fields[f.Name] = decodeStructureField(buf, f, depth)
+
+	case StructureTypeStructureWithOptionalFields:
+		mask := buf.ReadUint32()
+		var bit uint
+		for _, f := range fields {
+			if f.IsOptional {
+				set := mask&(1<<bit) != 0
+				bit++
+				if !set {
+					continue
+				}
+			}
+			s.Fields[f.Name] = decodeStructureField(buf, f, depth)
+		}
+
+	default:
+		for _, f := range fields {
+			s.Fields[f.Name] = decodeStructureField(buf, f, depth)
+		}
+	}
+}
+
+func decodeStructureField(buf *Buffer, f *StructureField, depth int) interface{} {
+	if buf.Error() != nil {
+		return nil
+	}
+	if f.ValueRank > 1 || f.ValueRank < -1 {
+		buf.err = errors.Errorf("field %s: unsupported value rank %d", f.Name, f.ValueRank)
+		return nil
+	}
+	if f.ValueRank == -1 {
+		return decodeStructureValue(buf, f.DataType, depth)
+	}
+
+	typ, err := structureValueType(f.DataType)
+	if err != nil {
+		buf.err = errors.Errorf("field %s: %s", f.Name, err)
+		return nil
+	}
+	n := buf.ReadInt32()
+	if buf.Error() != nil {
+		return nil
+	}
+	if n < 0 {
+		return reflect.Zero(reflect.SliceOf(typ)).Interface()
+	}
+	if int(n) > MaxVariantArrayLength {
+		buf.err = errors.Errorf("field %s: array too large: %d > %d", f.Name, n, MaxVariantArrayLength)
+		return nil
+	}
+	if !checkArrayLength(buf, int64(n), 1) {
+		return nil
+	}
+	a := reflect.MakeSlice(reflect.SliceOf(typ), 0, int(n))
+	for i := int32(0); i < n; i++ {
+		v := decodeStructureValue(buf, f.DataType, depth)
+		if buf.Error() != nil {
+			return nil
+		}
+		a = reflect.Append(a, reflect.ValueOf(v))
+	}
+	return a.Interface()
+}
+
+// structureValueType returns the Go type of the values of a data type.
+// The values of a structured data type are a *Structure unless a Go type
+// is registered for its default encoding.
+func structureValueType(dataType *NodeID) (reflect.Type, error) {
+	if def := datatypes.structure(dataType); def != nil {
+		if v := newStructureGoValue(def); v != nil {
+			return reflect.TypeOf(v), nil
+		}
+		return reflect.TypeOf(new(Structure)), nil
+	}
+	typ, ok := datatypes.builtin(dataType)
+	if !ok {
+		return nil, errors.Errorf("unknown data type %s", dataType)
+	}
+	return variantTypeIDToType[typ], nil
+}
+
+// newStructureGoValue returns a new value of the Go type which is
+// registered with RegisterExtensionObject for the default encoding of
+// a structured data type or nil.
+func newStructureGoValue(def *StructureDefinition) interface{} {
+	if def.DefaultEncodingID == nil {
+		return nil
+	}
+	return eotypes.New(def.DefaultEncodingID)
+}
+
+func decodeStructureValue(buf *Buffer, dataType *NodeID, depth int) interface{} {
+	if def := datatypes.structure(dataType); def != nil {
+		if v := newStructureGoValue(def); v != nil {
+			buf.ReadStruct(v)
+			return v
+		}
+		s := &Structure{TypeID: dataType, Definition: def}
+		if buf.enter() {
+			s.decode(buf, depth+1)
+			buf.leave()
+		}
+		return s
+	}
+
+	typ, err := structureValueType(dataType)
+	if err != nil {
+		buf.err = err
+		return nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		v := reflect.New(typ.Elem())
+		buf.ReadStruct(v.Interface())
+		return v.Interface()
+	}
+	v := reflect.New(typ)
+	buf.ReadStruct(v.Interface())
+	return v.Elem().Interface()
+}
+
+func (s *Structure) Encode() ([]byte, error) {
+	buf := NewBuffer(nil)
+	s.encode(buf)
+	return buf.Bytes(), buf.Error()
+}
+
+func (s *Structure) encode(buf *Buffer) {
+	if s.Definition == nil {
+		buf.err = errors.Errorf("missing definition for structure %s", s.TypeID)
+		return
+	}
+
+	fields := s.Definition.Fields
+	switch s.Definition.StructureType {
+	case StructureTypeUnion:
+		var sw uint32
+		for i, f := range fields {
+			if _, ok := s.Fields[f.Name]; !ok {
+				continue
+			}
+			if sw != 0 {
+				buf.err = errors.Errorf("union %s has more than one field", s.TypeID)
+				return
+			}
+			sw = uint32(i + 1)
+		}
+		buf.WriteUint32(sw)
+		if sw != 0 {
+			f := fields[sw-1]
+			encodeStructureField(buf, f, s.Fields[f.Name])
+		}
+
+	case StructureTypeStructureWithOptionalFields:
+		var mask uint32
+		var bit uint
+		for _, f := range fields {
+			if !f.IsOptional {
+				continue
+			}
+			if _, ok := s.Fields[f.Name]; ok {
+				mask |= 1 << bit
+			}
+			bit++
+		}
+		buf.WriteUint32(mask)
+		for _, f := range fields {
+			v, ok := s.Fields[f.Name]
+			if !ok && f.IsOptional {
+				continue
+			}
+			if !ok {
+				buf.err = errors.Errorf("missing field %s in structure %s", f.Name, s.TypeID)
+				return
+			}
+			encodeStructureField(buf, f, v)
+		}
+
+	default:
+		for _, f := range fields {
+			v, ok := s.Fields[f.Name]
+			if !ok {
+				buf.err = errors.Errorf("missing field %s in structure %s", f.Name, s.TypeID)
+				return
+			}
+			encodeStructureField(buf, f, v)
+		}
+	}
+}
+
+func encodeStructureField(buf *Buffer, f *StructureField, v interface{}) {
+	if buf.Error() != nil {
+		return
+	}
+	typ, err := structureValueType(f.DataType)
+	if err != nil {
+		buf.err = errors.Errorf("field %s: %s", f.Name, err)
+		return
+	}
+
+	if f.ValueRank == -1 {
+		if reflect.TypeOf(v) != typ {
+			buf.err = errors.Errorf("field %s: got %T want %s", f.Name, v, typ)
+			return
+		}
+		encodeStructureValue(buf, v)
+		return
+	}
+
+	val := reflect.ValueOf(v)
+	if !val.IsValid() || val.Type() != reflect.SliceOf(typ) {
+		buf.err = errors.Errorf("field %s: got %T want %s", f.Name, v, reflect.SliceOf(typ))
+		return
+	}
+	if val.IsNil() {
+		buf.WriteInt32(-1)
+		return
+	}
+	if val.Len() > math.MaxInt32 {
+		buf.err = errors.Errorf("field %s: array too large", f.Name)
+		return
+	}
+	buf.WriteInt32(int32(val.Len()))
+	for i := 0; i < val.Len(); i++ {
+		encodeStructureValue(buf, val.Index(i).Interface())
+	}
+}
+
+func encodeStructureValue(buf *Buffer, v interface{}) {
+	if s, ok := v.(*Structure); ok {
+		s.encode(buf)
+		return
+	}
+	buf.WriteStruct(v)
+}
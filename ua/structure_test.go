@@ -0,0 +1,151 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+var (
+	testPointDef = &StructureDefinition{
+		DefaultEncodingID: NewFourByteNodeID(2, 1002),
+		StructureType:     StructureTypeStructure,
+		Fields: []*StructureField{
+			{Name: "X", DataType: NewTwoByteNodeID(uint8(TypeIDDouble)), ValueRank: -1},
+			{Name: "Y", DataType: NewTwoByteNodeID(uint8(TypeIDDouble)), ValueRank: -1},
+		},
+	}
+	testRecipeDef = &StructureDefinition{
+		DefaultEncodingID: NewFourByteNodeID(2, 2002),
+		StructureType:     StructureTypeStructureWithOptionalFields,
+		Fields: []*StructureField{
+			{Name: "Name", DataType: NewTwoByteNodeID(uint8(TypeIDString)), ValueRank: -1},
+			{Name: "Target", DataType: NewFourByteNodeID(2, 1001), ValueRank: -1},
+			{Name: "Steps", DataType: NewTwoByteNodeID(uint8(TypeIDInt32)), ValueRank: 1},
+			{Name: "Note", DataType: NewTwoByteNodeID(uint8(TypeIDString)), ValueRank: -1, IsOptional: true},
+			{Name: "Unit", DataType: NewFourByteNodeID(2, 3001), ValueRank: -1, IsOptional: true},
+		},
+	}
+	testUnionDef = &StructureDefinition{
+		DefaultEncodingID: NewFourByteNodeID(2, 4002),
+		StructureType:     StructureTypeUnion,
+		Fields: []*StructureField{
+			{Name: "I", DataType: NewTwoByteNodeID(uint8(TypeIDInt32)), ValueRank: -1},
+			{Name: "S", DataType: NewTwoByteNodeID(uint8(TypeIDString)), ValueRank: -1},
+		},
+	}
+)
+
+func init() {
+	RegisterStructureDefinition(NewFourByteNodeID(2, 1001), testPointDef)
+	RegisterStructureDefinition(NewFourByteNodeID(2, 2001), testRecipeDef)
+	RegisterStructureDefinition(NewFourByteNodeID(2, 4001), testUnionDef)
+	RegisterSimpleDataType(NewFourByteNodeID(2, 3001), TypeIDInt32)
+}
+
+func testRecipe() *Structure {
+	return &Structure{
+		TypeID:     NewFourByteNodeID(2, 2001),
+		Definition: testRecipeDef,
+		Fields: map[string]interface{}{
+			"Name": "a",
+			"Target": &Structure{
+				TypeID:     NewFourByteNodeID(2, 1001),
+				Definition: testPointDef,
+				Fields:     map[string]interface{}{"X": 1.5, "Y": -2.0},
+			},
+			"Steps": []int32{1, 2},
+			"Note":  "n",
+		},
+	}
+}
+
+func TestStructure(t *testing.T) {
+	cases := []CodecTestCase{
+		{
+			Name:   "structure with optional fields",
+			Struct: NewExtensionObject(testRecipe()),
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x02, 0xd2, 0x07,
+				// EncodingMask
+				0x01,
+				// Length
+				0x2a, 0x00, 0x00, 0x00,
+				// optional fields: Note
+				0x01, 0x00, 0x00, 0x00,
+				// Name
+				0x01, 0x00, 0x00, 0x00, 0x61,
+				// Target
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0,
+				// Steps
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00,
+				// Note
+				0x01, 0x00, 0x00, 0x00, 0x6e,
+			},
+		},
+		{
+			Name: "union",
+			Struct: NewExtensionObject(&Structure{
+				TypeID:     NewFourByteNodeID(2, 4001),
+				Definition: testUnionDef,
+				Fields:     map[string]interface{}{"S": "x"},
+			}),
+			Bytes: []byte{
+				// TypeID
+				0x01, 0x02, 0xa2, 0x0f,
+				// EncodingMask
+				0x01,
+				// Length
+				0x09, 0x00, 0x00, 0x00,
+				// switch field
+				0x02, 0x00, 0x00, 0x00,
+				// S
+				0x01, 0x00, 0x00, 0x00, 0x78,
+			},
+		},
+	}
+	RunCodecTest(t, cases)
+}
+
+func TestStructureJSON(t *testing.T) {
+	b, err := (&JSONEncoder{NonReversible: true}).Encode(NewExtensionObject(testRecipe()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", string(b), `{"Name":"a","Target":{"X":1.5,"Y":-2},"Steps":[1,2],"Note":"n"}`)
+}
+
+func TestStructureErrors(t *testing.T) {
+	s := testRecipe()
+	delete(s.Fields, "Name")
+	if _, err := s.Encode(); err == nil {
+		t.Fatal("got nil want error for missing field")
+	}
+
+	s = testRecipe()
+	s.Fields["Steps"] = []int64{1}
+	if _, err := s.Encode(); err == nil {
+		t.Fatal("got nil want error for invalid field type")
+	}
+
+	s = &Structure{
+		TypeID: NewFourByteNodeID(2, 5001),
+		Definition: &StructureDefinition{
+			Fields: []*StructureField{{Name: "X", DataType: NewFourByteNodeID(2, 9999), ValueRank: -1}},
+		},
+	}
+	if _, err := s.Decode([]byte{0x00}); err == nil {
+		t.Fatal("got nil want error for unknown data type")
+	}
+
+	u := &Structure{TypeID: NewFourByteNodeID(2, 4001), Definition: testUnionDef}
+	if _, err := u.Decode([]byte{0x03, 0x00, 0x00, 0x00}); err == nil {
+		t.Fatal("got nil want error for invalid switch field")
+	}
+}
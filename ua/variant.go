@@ -64,7 +64,7 @@ type Variant struct {
 func NewVariant(v interface{}) (*Variant, error) {
 	va := &Variant{}
 	if !isBuiltinType(v) {
-		return nil, fmt.Errorf("trying to create a variant from a type that it is not supported: %s", reflect.ValueOf(v).Type().Name())
+		return nil, fmt.Errorf("trying to create a variant from a type that it is not supported: %T", v)
 	}
 	if err := va.set(v); err != nil {
 		return nil, err
@@ -72,6 +72,49 @@ func NewVariant(v interface{}) (*Variant, error) {
 	return va, nil
 }
 
+// NewMatrixVariant returns a variant for a multi-dimensional array from
+// the values of a one-dimensional slice and the size of each dimension,
+// e.g. a 3x4 matrix from a []float32 with 12 elements. The elements are
+// stored in row-major order, i.e. the last dimension changes fastest.
+// The value of the variant is a nested slice, e.g. [][]float32, as it is
+// for decoded multi-dimensional arrays.
+func NewMatrixVariant(values interface{}, dims []int32) (*Variant, error) {
+	val := reflect.ValueOf(values)
+	if val.Kind() != reflect.Slice || val.Type().Elem().Kind() == reflect.Slice {
+		return nil, errors.Errorf("matrix values must be a one-dimensional slice: %T", values)
+	}
+	if _, ok := values.([]byte); ok {
+		return nil, errors.New("matrix values must not be a ByteString")
+	}
+	if len(dims) == 0 {
+		return nil, errors.New("matrix has no dimensions")
+	}
+	for _, d := range dims {
+		if d < 1 {
+			return nil, errors.Errorf("invalid matrix dimensions %v", dims)
+		}
+	}
+	if val.Len() > MaxVariantArrayLength || !matchesLength(dims, int32(val.Len())) {
+		return nil, errors.Errorf("matrix dimensions %v do not match %d values", dims, val.Len())
+	}
+	if !isBuiltinType(values) {
+		return nil, fmt.Errorf("trying to create a variant from a type that it is not supported: %T", values)
+	}
+	if len(dims) == 1 {
+		return NewVariant(values)
+	}
+
+	n := make([]int, len(dims))
+	for i, d := range dims {
+		n[i] = int(d)
+	}
+	v := &Variant{}
+	if err := v.set(split(0, 0, val.Len(), n, val).Interface()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 func MustVariant(v interface{}) *Variant {
 	va, err := NewVariant(v)
 	if err != nil {
@@ -109,6 +152,50 @@ func (m *Variant) ArrayDimensions() []int32 {
 	return m.arrayDimensions
 }
 
+// Dimensions returns the size of each dimension of an array value.
+// It returns the encoded dimensions for multi-dimensional arrays,
+// the array length for one-dimensional arrays and nil for scalars.
+func (m *Variant) Dimensions() []int32 {
+	switch {
+	case m.Has(VariantArrayDimensions):
+		return m.arrayDimensions
+	case m.Has(VariantArrayValues):
+		return []int32{m.arrayLength}
+	default:
+		return nil
+	}
+}
+
+// MatrixValue returns the value of a multi-dimensional array as a nested
+// slice, e.g. [][]int32 for a two-dimensional array of Int32 values. A flat
+// value is reshaped using the array dimensions.
+func (m *Variant) MatrixValue() (interface{}, error) {
+	if !m.Has(VariantArrayDimensions) || len(m.arrayDimensions) < 2 {
+		return nil, errors.Errorf("variant is not a multi-dimensional array")
+	}
+
+	val := reflect.ValueOf(m.value)
+	_, dim, _, err := sliceDim(val)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(dim, m.arrayDimensions) {
+		return m.value, nil
+	}
+
+	// reshape a flat array
+	n := 1
+	dims := make([]int, len(m.arrayDimensions))
+	for i, d := range m.arrayDimensions {
+		dims[i] = int(d)
+		n *= dims[i]
+	}
+	if len(dim) != 1 || val.Len() != n {
+		return nil, errors.Errorf("array dimensions %v do not match value with dimensions %v", m.arrayDimensions, dim)
+	}
+	return split(0, 0, n, dims, val).Interface(), nil
+}
+
 // Value returns the value.
 func (m *Variant) Value() interface{} {
 	return m.value
@@ -117,32 +204,46 @@ func (m *Variant) Value() interface{} {
 // Decode implements the codec interface.
 func (m *Variant) Decode(b []byte) (int, error) {
 	buf := NewBuffer(b)
+	m.decodeBuffer(buf)
+	return buf.Pos(), buf.Error()
+}
+
+func (m *Variant) decodeBuffer(buf *Buffer) {
 	m.mask = buf.ReadByte()
 
 	// a null value specifies that no other fields are encoded
 	if m.Type() == TypeIDNull {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
-	// check the type
-	typ, ok := variantTypeIDToType[m.Type()]
-	if !ok {
-		return buf.Pos(), errors.Errorf("invalid type id: %d", m.Type())
+	// check the type. The type ids are numbered without gaps so that
+	// scalar values can be decoded without a lookup.
+	if m.Type() > TypeIDDiagnosticInfo {
+		buf.err = errors.Errorf("invalid type id: %d", m.Type())
+		return
 	}
 
 	// read single value and return
 	if !m.Has(VariantArrayValues) {
 		m.value = m.decodeValue(buf)
-		return buf.Pos(), buf.Error()
+		return
 	}
+	typ := variantTypeIDToType[m.Type()]
 
 	// get total array length (flattened for multi-dimensional arrays)
 	m.arrayLength = buf.ReadInt32()
+	if buf.Error() != nil {
+		return
+	}
 
 	// read flattened array elements
 	n := int(m.arrayLength)
 	if n < 0 || n > MaxVariantArrayLength {
-		return buf.Pos(), StatusBadEncodingLimitsExceeded
+		buf.err = StatusBadEncodingLimitsExceeded
+		return
+	}
+	if !checkArrayLength(buf, int64(n), 1) {
+		return
 	}
 
 	var vals reflect.Value
@@ -159,14 +260,16 @@ func (m *Variant) Decode(b []byte) (int, error) {
 	// check for dimensions of multi-dimensional array
 	if m.Has(VariantArrayDimensions) {
 		m.arrayDimensionsLength = buf.ReadInt32()
-		if m.arrayDimensionsLength < 0 {
-			return buf.Pos(), StatusBadEncodingLimitsExceeded
+		if m.arrayDimensionsLength < 0 || int(m.arrayDimensionsLength) > MaxVariantArrayLength {
+			buf.err = StatusBadEncodingLimitsExceeded
+			return
 		}
 		m.arrayDimensions = make([]int32, m.arrayDimensionsLength)
 		for i := 0; i < int(m.arrayDimensionsLength); i++ {
 			m.arrayDimensions[i] = buf.ReadInt32()
 			if m.arrayDimensions[i] < 1 {
-				return buf.Pos(), StatusBadEncodingLimitsExceeded
+				buf.err = StatusBadEncodingLimitsExceeded
+				return
 			}
 		}
 	}
@@ -175,25 +278,20 @@ func (m *Variant) Decode(b []byte) (int, error) {
 	// depends on the assumption that the array dimensions were read
 	// correctly.
 	if buf.Error() != nil {
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	// validate that the total number of elements
 	// matches the product of the array dimensions
-	if m.arrayDimensionsLength > 0 {
-		count := int32(1)
-		for i := range m.arrayDimensions {
-			count *= m.arrayDimensions[i]
-		}
-		if count != m.arrayLength {
-			return buf.Pos(), errUnbalancedSlice
-		}
+	if m.arrayDimensionsLength > 0 && !matchesLength(m.arrayDimensions, m.arrayLength) {
+		buf.err = errUnbalancedSlice
+		return
 	}
 
 	// handle one-dimensional arrays
 	if m.arrayDimensionsLength < 2 {
 		m.value = vals.Interface()
-		return buf.Pos(), buf.Error()
+		return
 	}
 
 	// handle multi-dimensional arrays
@@ -203,8 +301,19 @@ func (m *Variant) Decode(b []byte) (int, error) {
 		dims[i] = int(m.arrayDimensions[i])
 	}
 	m.value = split(0, 0, vals.Len(), dims, vals).Interface()
+}
 
-	return buf.Pos(), buf.Error()
+// matchesLength returns true if the product of the dimensions is the
+// array length. The product is computed without overflow.
+func matchesLength(dims []int32, length int32) bool {
+	count := int64(1)
+	for _, d := range dims {
+		count *= int64(d)
+		if count > int64(length) {
+			return false
+		}
+	}
+	return count == int64(length)
 }
 
 // split recursively creates a multi-dimensional array from a set of values
@@ -303,12 +412,11 @@ func (m *Variant) decodeValue(buf *Buffer) interface{} {
 		buf.ReadStruct(v)
 		return v
 	case TypeIDVariant:
-		// todo(fs): limit recursion depth to 100
+		// ReadStruct limits the nesting depth
 		v := new(Variant)
 		buf.ReadStruct(v)
 		return v
 	case TypeIDDiagnosticInfo:
-		// todo(fs): limit recursion depth to 100
 		v := new(DiagnosticInfo)
 		buf.ReadStruct(v)
 		return v
@@ -0,0 +1,371 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// ConvertOption configures the conversion of a Variant value.
+type ConvertOption func(*convertConfig)
+
+type convertConfig struct {
+	parseStrings bool
+}
+
+// ParseStrings enables the conversion of String values into numeric and
+// Boolean values. By default, String values can only be converted
+// to String.
+func ParseStrings() ConvertOption {
+	return func(cfg *convertConfig) {
+		cfg.parseStrings = true
+	}
+}
+
+// ConvertTo returns a new Variant with the value converted to the given
+// numeric, Boolean or String type.
+//
+// Integer and floating point values are widened and narrowed as long as the
+// value fits into the target type. Floating point values are rounded to the
+// nearest integer when converted to an integer type. Boolean values convert
+// to 0 and 1 and only 0 and 1 convert to Boolean. Arrays are converted
+// element-wise and the error contains the index of the failing element.
+func (m *Variant) ConvertTo(t TypeID, opts ...ConvertOption) (*Variant, error) {
+	cfg := &convertConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if _, ok := convertTargetTypes[t]; !ok {
+		return nil, errors.Errorf("cannot convert to %s", t)
+	}
+	if m.Type() == TypeIDNull {
+		return nil, errors.Errorf("cannot convert null value to %s", t)
+	}
+
+	if !m.Has(VariantArrayValues) {
+		v, err := convertScalar(m.value, t, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewVariant(v)
+	}
+
+	v, err := convertArray(reflect.ValueOf(m.value), t, cfg, "")
+	if err != nil {
+		return nil, err
+	}
+	return NewVariant(v.Interface())
+}
+
+// ToFloat64 converts a scalar value to a float64.
+// See ConvertTo for the conversion rules.
+func (m *Variant) ToFloat64(opts ...ConvertOption) (float64, error) {
+	v, err := m.convertScalarTo(TypeIDDouble, opts)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// ToInt64 converts a scalar value to an int64.
+// See ConvertTo for the conversion rules.
+func (m *Variant) ToInt64(opts ...ConvertOption) (int64, error) {
+	v, err := m.convertScalarTo(TypeIDInt64, opts)
+	if err != nil {
+		return 0, err
+	}
+	return v.(int64), nil
+}
+
+// ToUint64 converts a scalar value to a uint64.
+// See ConvertTo for the conversion rules.
+func (m *Variant) ToUint64(opts ...ConvertOption) (uint64, error) {
+	v, err := m.convertScalarTo(TypeIDUint64, opts)
+	if err != nil {
+		return 0, err
+	}
+	return v.(uint64), nil
+}
+
+// ToBool converts a scalar value to a bool.
+// See ConvertTo for the conversion rules.
+func (m *Variant) ToBool(opts ...ConvertOption) (bool, error) {
+	v, err := m.convertScalarTo(TypeIDBoolean, opts)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+func (m *Variant) convertScalarTo(t TypeID, opts []ConvertOption) (interface{}, error) {
+	if m.Has(VariantArrayValues) {
+		return nil, errors.Errorf("cannot convert array to scalar %s", t)
+	}
+	v, err := m.ConvertTo(t, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return v.value, nil
+}
+
+// convertTargetTypes contains the types ConvertTo can convert to.
+var convertTargetTypes = map[TypeID]reflect.Type{
+	TypeIDBoolean: reflect.TypeOf(false),
+	TypeIDSByte:   reflect.TypeOf(int8(0)),
+	TypeIDByte:    reflect.TypeOf(uint8(0)),
+	TypeIDInt16:   reflect.TypeOf(int16(0)),
+	TypeIDUint16:  reflect.TypeOf(uint16(0)),
+	TypeIDInt32:   reflect.TypeOf(int32(0)),
+	TypeIDUint32:  reflect.TypeOf(uint32(0)),
+	TypeIDInt64:   reflect.TypeOf(int64(0)),
+	TypeIDUint64:  reflect.TypeOf(uint64(0)),
+	TypeIDFloat:   reflect.TypeOf(float32(0)),
+	TypeIDDouble:  reflect.TypeOf(float64(0)),
+	TypeIDString:  reflect.TypeOf(""),
+}
+
+// convertArray converts a one or multi-dimensional array element-wise.
+// idx is the index of val in the enclosing arrays.
+func convertArray(val reflect.Value, t TypeID, cfg *convertConfig, idx string) (reflect.Value, error) {
+	var typ reflect.Type
+	inner := val.Type().Elem().Kind() == reflect.Slice && val.Type().Elem() != reflect.TypeOf([]byte{})
+	switch {
+	case inner:
+		typ = nil // determined by the first converted element
+	case t == TypeIDByte:
+		typ = reflect.TypeOf(ByteArray{})
+	default:
+		typ = reflect.SliceOf(convertTargetTypes[t])
+	}
+
+	var elems []reflect.Value
+	for i := 0; i < val.Len(); i++ {
+		eidx := idx + "[" + strconv.Itoa(i) + "]"
+		if inner {
+			e, err := convertArray(val.Index(i), t, cfg, eidx)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elems = append(elems, e)
+			continue
+		}
+		v, err := convertScalar(val.Index(i).Interface(), t, cfg)
+		if err != nil {
+			return reflect.Value{}, errors.Errorf("element %s: %s", eidx, strings.TrimPrefix(err.Error(), errors.Prefix))
+		}
+		elems = append(elems, reflect.ValueOf(v))
+	}
+
+	if typ == nil {
+		if len(elems) == 0 {
+			typ = reflect.SliceOf(reflect.SliceOf(convertTargetTypes[t]))
+		} else {
+			typ = reflect.SliceOf(elems[0].Type())
+		}
+	}
+	a := reflect.MakeSlice(typ, len(elems), len(elems))
+	for i, e := range elems {
+		a.Index(i).Set(e.Convert(typ.Elem()))
+	}
+	return a, nil
+}
+
+// convertScalar converts v to the Go type of the given type id.
+func convertScalar(v interface{}, t TypeID, cfg *convertConfig) (interface{}, error) {
+	switch x := v.(type) {
+	case bool:
+		if t == TypeIDBoolean {
+			return x, nil
+		}
+		if t == TypeIDString {
+			return strconv.FormatBool(x), nil
+		}
+		if x {
+			return convertUint(1, t)
+		}
+		return convertUint(0, t)
+	case int8:
+		return convertInt(int64(x), t)
+	case int16:
+		return convertInt(int64(x), t)
+	case int32:
+		return convertInt(int64(x), t)
+	case int64:
+		return convertInt(x, t)
+	case uint8:
+		return convertUint(uint64(x), t)
+	case uint16:
+		return convertUint(uint64(x), t)
+	case uint32:
+		return convertUint(uint64(x), t)
+	case uint64:
+		return convertUint(x, t)
+	case float32:
+		return convertFloat(float64(x), t)
+	case float64:
+		return convertFloat(x, t)
+	case string:
+		return convertString(x, t, cfg)
+	default:
+		return nil, errors.Errorf("cannot convert %T to %s", v, t)
+	}
+}
+
+func convertInt(v int64, t TypeID) (interface{}, error) {
+	switch t {
+	case TypeIDBoolean:
+		return convertToBool(v == 0, v == 1, v)
+	case TypeIDSByte:
+		if v < math.MinInt8 || v > math.MaxInt8 {
+			return nil, errOutOfRange(v, t)
+		}
+		return int8(v), nil
+	case TypeIDInt16:
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			return nil, errOutOfRange(v, t)
+		}
+		return int16(v), nil
+	case TypeIDInt32:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, errOutOfRange(v, t)
+		}
+		return int32(v), nil
+	case TypeIDInt64:
+		return v, nil
+	case TypeIDFloat:
+		return float32(v), nil
+	case TypeIDDouble:
+		return float64(v), nil
+	case TypeIDString:
+		return strconv.FormatInt(v, 10), nil
+	default:
+		if v < 0 {
+			return nil, errOutOfRange(v, t)
+		}
+		return convertUint(uint64(v), t)
+	}
+}
+
+func convertUint(v uint64, t TypeID) (interface{}, error) {
+	switch t {
+	case TypeIDBoolean:
+		return convertToBool(v == 0, v == 1, v)
+	case TypeIDByte:
+		if v > math.MaxUint8 {
+			return nil, errOutOfRange(v, t)
+		}
+		return uint8(v), nil
+	case TypeIDUint16:
+		if v > math.MaxUint16 {
+			return nil, errOutOfRange(v, t)
+		}
+		return uint16(v), nil
+	case TypeIDUint32:
+		if v > math.MaxUint32 {
+			return nil, errOutOfRange(v, t)
+		}
+		return uint32(v), nil
+	case TypeIDUint64:
+		return v, nil
+	case TypeIDFloat:
+		return float32(v), nil
+	case TypeIDDouble:
+		return float64(v), nil
+	case TypeIDString:
+		return strconv.FormatUint(v, 10), nil
+	default:
+		if v > math.MaxInt64 {
+			return nil, errOutOfRange(v, t)
+		}
+		return convertInt(int64(v), t)
+	}
+}
+
+func convertFloat(v float64, t TypeID) (interface{}, error) {
+	switch t {
+	case TypeIDDouble:
+		return v, nil
+	case TypeIDFloat:
+		if !math.IsInf(v, 0) && !math.IsNaN(v) && math.Abs(v) > math.MaxFloat32 {
+			return nil, errOutOfRange(v, t)
+		}
+		return float32(v), nil
+	case TypeIDString:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case TypeIDBoolean:
+		return convertToBool(v == 0, v == 1, v)
+	}
+
+	// integer types
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, errOutOfRange(v, t)
+	}
+	r := math.Round(v)
+	switch {
+	case r >= 0 && r < math.MaxUint64:
+		return convertUint(uint64(r), t)
+	case r < 0 && r >= math.MinInt64:
+		return convertInt(int64(r), t)
+	default:
+		return nil, errOutOfRange(v, t)
+	}
+}
+
+func convertString(v string, t TypeID, cfg *convertConfig) (interface{}, error) {
+	if t == TypeIDString {
+		return v, nil
+	}
+	if !cfg.parseStrings {
+		return nil, errors.Errorf("cannot convert String to %s without ParseStrings option", t)
+	}
+
+	switch t {
+	case TypeIDBoolean:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse %q as %s", v, t)
+		}
+		return b, nil
+	case TypeIDFloat, TypeIDDouble:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse %q as %s", v, t)
+		}
+		return convertFloat(f, t)
+	case TypeIDByte, TypeIDUint16, TypeIDUint32, TypeIDUint64:
+		u, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse %q as %s", v, t)
+		}
+		return convertUint(u, t)
+	default:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("cannot parse %q as %s", v, t)
+		}
+		return convertInt(i, t)
+	}
+}
+
+func convertToBool(isFalse, isTrue bool, v interface{}) (interface{}, error) {
+	switch {
+	case isFalse:
+		return false, nil
+	case isTrue:
+		return true, nil
+	default:
+		return nil, errOutOfRange(v, TypeIDBoolean)
+	}
+}
+
+func errOutOfRange(v interface{}, t TypeID) error {
+	return errors.Errorf("value %v out of range for %s", v, t)
+}
@@ -0,0 +1,70 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"testing"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+func TestVariantConvertTo(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		t    TypeID
+		opts []ConvertOption
+		want interface{}
+		err  error
+	}{
+		{name: "int16 to double", v: int16(-7), t: TypeIDDouble, want: float64(-7)},
+		{name: "uint8 to int64", v: uint8(200), t: TypeIDInt64, want: int64(200)},
+		{name: "int32 to sbyte", v: int32(-128), t: TypeIDSByte, want: int8(-128)},
+		{name: "int32 to sbyte overflow", v: int32(128), t: TypeIDSByte, err: errors.New("value 128 out of range for TypeIDSByte")},
+		{name: "negative to uint32", v: int64(-1), t: TypeIDUint32, err: errors.New("value -1 out of range for TypeIDUint32")},
+		{name: "uint64 to int64 overflow", v: uint64(1 << 63), t: TypeIDInt64, err: errors.New("value 9223372036854775808 out of range for TypeIDInt64")},
+		{name: "double to int32 rounds", v: float64(2.5), t: TypeIDInt32, want: int32(3)},
+		{name: "double to float overflow", v: float64(1e300), t: TypeIDFloat, err: errors.New("value 1e+300 out of range for TypeIDFloat")},
+		{name: "bool to byte", v: true, t: TypeIDByte, want: uint8(1)},
+		{name: "int to bool", v: int32(0), t: TypeIDBoolean, want: false},
+		{name: "int to bool out of range", v: int32(2), t: TypeIDBoolean, err: errors.New("value 2 out of range for TypeIDBoolean")},
+		{name: "int to string", v: int32(42), t: TypeIDString, want: "42"},
+		{name: "string without parsing", v: "42", t: TypeIDInt32, err: errors.New("cannot convert String to TypeIDInt32 without ParseStrings option")},
+		{name: "string with parsing", v: "42", t: TypeIDInt32, opts: []ConvertOption{ParseStrings()}, want: int32(42)},
+		{name: "invalid string", v: "x", t: TypeIDDouble, opts: []ConvertOption{ParseStrings()}, err: errors.New(`cannot parse "x" as TypeIDDouble`)},
+		{name: "array", v: []int16{1, 2}, t: TypeIDFloat, want: []float32{1, 2}},
+		{name: "byte array", v: []int32{1, 2}, t: TypeIDByte, want: ByteArray{1, 2}},
+		{name: "matrix", v: [][]int32{{1, 2}, {3, 4}}, t: TypeIDDouble, want: [][]float64{{1, 2}, {3, 4}}},
+		{name: "array element out of range", v: [][]int32{{1, 2}, {3, 400}}, t: TypeIDByte, err: errors.New("element [1][1]: value 400 out of range for TypeIDByte")},
+		{name: "unsupported target", v: int32(1), t: TypeIDNodeID, err: errors.New("cannot convert to TypeIDNodeID")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := MustVariant(tt.v).ConvertTo(tt.t, tt.opts...)
+			if !errors.Equal(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			verify.Values(t, "", v.Value(), tt.want)
+		})
+	}
+}
+
+func TestVariantToFloat64(t *testing.T) {
+	f, err := MustVariant(uint16(12)).ToFloat64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", f, float64(12))
+
+	if _, err := MustVariant([]uint16{12}).ToFloat64(); err == nil {
+		t.Fatal("got nil want error")
+	}
+}
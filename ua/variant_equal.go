@@ -0,0 +1,237 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Equal returns true if both variants have the same type, dimensions and
+// value.
+//
+// Values are compared semantically and not by their encoding: node ids
+// are equal if they refer to the same node, times are compared with
+// time.Time.Equal, NaN values are equal and empty arrays are equal to
+// null arrays. A nil variant is equal to a variant with a null value.
+func (m *Variant) Equal(v *Variant) bool {
+	if m == nil || v == nil || m.Type() == TypeIDNull || v.Type() == TypeIDNull {
+		return isNullVariant(m) && isNullVariant(v)
+	}
+	if m.Type() != v.Type() || m.Has(VariantArrayValues) != v.Has(VariantArrayValues) {
+		return false
+	}
+
+	a, b := m.value, v.value
+	if md, vd := m.Dimensions(), v.Dimensions(); len(md) > 1 || len(vd) > 1 {
+		if !equalValue(reflect.ValueOf(md), reflect.ValueOf(vd)) {
+			return false
+		}
+		var err error
+		if a, err = m.MatrixValue(); err != nil {
+			return false
+		}
+		if b, err = v.MatrixValue(); err != nil {
+			return false
+		}
+	}
+	return equalValue(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func isNullVariant(v *Variant) bool {
+	return v == nil || v.Type() == TypeIDNull
+}
+
+// EqualOption configures the comparison of data values.
+type EqualOption func(*equalConfig)
+
+type equalConfig struct {
+	ignoreSourceTimestamp bool
+	ignoreServerTimestamp bool
+}
+
+// IgnoreSourceTimestamp ignores the source timestamps and picoseconds
+// when data values are compared.
+func IgnoreSourceTimestamp() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreSourceTimestamp = true
+	}
+}
+
+// IgnoreServerTimestamp ignores the server timestamps and picoseconds
+// when data values are compared.
+func IgnoreServerTimestamp() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreServerTimestamp = true
+	}
+}
+
+// IgnoreTimestamps ignores both the source and the server timestamps
+// when data values are compared, e.g. to detect whether a monitored
+// item notification contains a new value.
+func IgnoreTimestamps() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreSourceTimestamp = true
+		cfg.ignoreServerTimestamp = true
+	}
+}
+
+// Equal returns true if both data values have equal values, status codes
+// and timestamps. The values are compared with Variant.Equal. Fields which
+// are not set are equal to their zero value, e.g. a data value without
+// a status code is equal to one with StatusOK.
+func (d *DataValue) Equal(v *DataValue, opts ...EqualOption) bool {
+	cfg := &equalConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if d == nil || v == nil {
+		return d == v
+	}
+	if !d.Value.Equal(v.Value) || d.Status != v.Status {
+		return false
+	}
+	if !cfg.ignoreSourceTimestamp && (!d.SourceTimestamp.Equal(v.SourceTimestamp) || d.SourcePicoseconds != v.SourcePicoseconds) {
+		return false
+	}
+	if !cfg.ignoreServerTimestamp && (!d.ServerTimestamp.Equal(v.ServerTimestamp) || d.ServerPicoseconds != v.ServerPicoseconds) {
+		return false
+	}
+	return true
+}
+
+var (
+	nodeIDType         = reflect.TypeOf(&NodeID{})
+	expandedNodeIDType = reflect.TypeOf(&ExpandedNodeID{})
+	variantType        = reflect.TypeOf(&Variant{})
+	dataValueType      = reflect.TypeOf(&DataValue{})
+	localizedTextType  = reflect.TypeOf(&LocalizedText{})
+)
+
+// equalValue compares two values like reflect.DeepEqual but treats
+// empty and nil slices as equal and compares node ids, times and
+// variants semantically.
+func equalValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return isEmptyValue(a) && isEmptyValue(b)
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Type() {
+	case timeType:
+		if a.CanInterface() && b.CanInterface() {
+			return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+		}
+	case nodeIDType, expandedNodeIDType, variantType, dataValueType, localizedTextType:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		if a.CanInterface() && b.CanInterface() {
+			return equalBuiltin(a.Interface(), b.Interface())
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		return equalValue(a.Elem(), b.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		if a.Kind() == reflect.Slice && a.Type().Elem().Kind() == reflect.Uint8 {
+			return bytes.Equal(a.Bytes(), b.Bytes())
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValue(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			bv := b.MapIndex(k)
+			if !bv.IsValid() || !equalValue(a.MapIndex(k), bv) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValue(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		return x == y || math.IsNaN(x) && math.IsNaN(y)
+
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+
+	case reflect.String:
+		return a.String() == b.String()
+
+	default:
+		return false
+	}
+}
+
+// equalBuiltin compares the built-in types which have a semantic
+// equality.
+func equalBuiltin(a, b interface{}) bool {
+	switch x := a.(type) {
+	case *NodeID:
+		return x.String() == b.(*NodeID).String()
+	case *ExpandedNodeID:
+		y := b.(*ExpandedNodeID)
+		return x.NamespaceURI == y.NamespaceURI && x.ServerIndex == y.ServerIndex && equalValue(reflect.ValueOf(x.NodeID), reflect.ValueOf(y.NodeID))
+	case *Variant:
+		return x.Equal(b.(*Variant))
+	case *DataValue:
+		return x.Equal(b.(*DataValue))
+	case *LocalizedText:
+		y := b.(*LocalizedText)
+		return x.Locale == y.Locale && x.Text == y.Text
+	default:
+		return false
+	}
+}
+
+// isEmptyValue returns true for invalid values, nil pointers and
+// interfaces and empty slices.
+func isEmptyValue(v reflect.Value) bool {
+	switch {
+	case !v.IsValid():
+		return true
+	case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+		return v.IsNil()
+	case v.Kind() == reflect.Slice:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
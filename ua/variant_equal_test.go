@@ -0,0 +1,99 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package ua
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVariantEqual(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	decode := func(v *Variant) *Variant {
+		b, err := v.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := new(Variant)
+		if _, err := d.Decode(b); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	matrix, err := NewMatrixVariant([]int32{1, 2, 3, 4, 5, 6}, []int32{2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		a, b *Variant
+		want bool
+	}{
+		{name: "nil", a: nil, b: nil, want: true},
+		{name: "nil and null", a: nil, b: &Variant{}, want: true},
+		{name: "nil and value", a: nil, b: MustVariant(int32(0)), want: false},
+		{name: "scalar", a: MustVariant(int32(1)), b: MustVariant(int32(1)), want: true},
+		{name: "scalar value", a: MustVariant(int32(1)), b: MustVariant(int32(2)), want: false},
+		{name: "scalar type", a: MustVariant(int32(1)), b: MustVariant(int64(1)), want: false},
+		{name: "NaN", a: MustVariant(math.NaN()), b: MustVariant(math.NaN()), want: true},
+		{name: "time zone", a: MustVariant(now), b: MustVariant(now.In(time.FixedZone("x", 3600))), want: true},
+		{name: "scalar and array", a: MustVariant(int32(1)), b: MustVariant([]int32{1}), want: false},
+		{name: "array", a: MustVariant([]string{"a", "b"}), b: decode(MustVariant([]string{"a", "b"})), want: true},
+		{name: "array length", a: MustVariant([]string{"a", "b"}), b: MustVariant([]string{"a"}), want: false},
+		{name: "empty and nil array", a: MustVariant([]int32{}), b: MustVariant([]int32(nil)), want: true},
+		{name: "empty and decoded array", a: MustVariant([]int32(nil)), b: decode(MustVariant([]int32{})), want: true},
+		{name: "matrix", a: matrix, b: MustVariant([][]int32{{1, 2, 3}, {4, 5, 6}}), want: true},
+		{name: "matrix dimensions", a: matrix, b: MustVariant([][]int32{{1, 2}, {3, 4}, {5, 6}}), want: false},
+		{name: "node id encoding", a: MustVariant(NewFourByteNodeID(0, 1)), b: MustVariant(NewNumericNodeID(0, 1)), want: true},
+		{name: "node id", a: MustVariant(NewNumericNodeID(1, 1)), b: MustVariant(NewNumericNodeID(0, 1)), want: false},
+		{name: "localized text", a: MustVariant(&LocalizedText{EncodingMask: LocalizedTextText, Text: "a"}), b: MustVariant(&LocalizedText{Text: "a"}), want: true},
+		{
+			name: "extension object",
+			a:    MustVariant(NewExtensionObject(&ReadValueID{NodeID: NewFourByteNodeID(0, 1), IndexRange: ""})),
+			b:    MustVariant(NewExtensionObject(&ReadValueID{NodeID: NewNumericNodeID(0, 1)})),
+			want: true,
+		},
+		{name: "variant", a: MustVariant(MustVariant(int32(1))), b: MustVariant(MustVariant(int32(1))), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Fatalf("a.Equal(b) got %v want %v", got, tt.want)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.want {
+				t.Fatalf("b.Equal(a) got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataValueEqual(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &DataValue{Value: MustVariant(int32(1)), SourceTimestamp: now, ServerTimestamp: now}
+	b := &DataValue{Value: MustVariant(int32(1)), SourceTimestamp: now.Add(time.Second), ServerTimestamp: now}
+
+	if a.Equal(b) {
+		t.Fatal("got equal data values with different source timestamps")
+	}
+	if !a.Equal(b, IgnoreSourceTimestamp()) {
+		t.Fatal("got different data values with IgnoreSourceTimestamp")
+	}
+
+	b.ServerTimestamp = now.Add(time.Second)
+	if a.Equal(b, IgnoreSourceTimestamp()) {
+		t.Fatal("got equal data values with different server timestamps")
+	}
+	if !a.Equal(b, IgnoreTimestamps()) {
+		t.Fatal("got different data values with IgnoreTimestamps")
+	}
+
+	b.Status = StatusBadNodeIDUnknown
+	if a.Equal(b, IgnoreTimestamps()) {
+		t.Fatal("got equal data values with different status codes")
+	}
+}
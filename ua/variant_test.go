@@ -155,7 +155,7 @@ func TestVariant(t *testing.T) {
 		},
 		{
 			Name:   "GUID",
-			Struct: MustVariant(NewGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63")),
+			Struct: MustVariant(MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63")),
 			Bytes: []byte{
 				// variant encoding mask
 				0x0e,
@@ -421,6 +421,28 @@ func TestVariant(t *testing.T) {
 				0x02, 0x00, 0x00, 0x00,
 			},
 		},
+		{
+			Name:   "[2][3]int32",
+			Struct: MustVariant([][]int32{{1, 2, 3}, {4, 5, 6}}),
+			Bytes: []byte{
+				// variant encoding mask
+				0xc6,
+				// array length
+				0x06, 0x00, 0x00, 0x00,
+				// array values
+				0x01, 0x00, 0x00, 0x00,
+				0x02, 0x00, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x00,
+				0x04, 0x00, 0x00, 0x00,
+				0x05, 0x00, 0x00, 0x00,
+				0x06, 0x00, 0x00, 0x00,
+				// array dimensions length
+				0x02, 0x00, 0x00, 0x00,
+				// array dimensions
+				0x02, 0x00, 0x00, 0x00,
+				0x03, 0x00, 0x00, 0x00,
+			},
+		},
 		{
 			Name: "[3][2][1]uint32",
 			Struct: MustVariant([][][]uint32{
@@ -521,6 +543,124 @@ func TestArray(t *testing.T) {
 		}
 		verify.Values(t, "", v.ArrayDimensions(), []int32{3, 2})
 	})
+	t.Run("matrix", func(t *testing.T) {
+		v := MustVariant([][]int32{{1, 2, 3}, {4, 5, 6}})
+		verify.Values(t, "dimensions", v.Dimensions(), []int32{2, 3})
+		m, err := v.MatrixValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "matrix", m, [][]int32{{1, 2, 3}, {4, 5, 6}})
+	})
+	t.Run("matrix from flat value", func(t *testing.T) {
+		v := MustVariant([]int32{1, 2, 3, 4, 5, 6})
+		v.mask |= VariantArrayDimensions
+		v.arrayDimensionsLength = 2
+		v.arrayDimensions = []int32{2, 3}
+		m, err := v.MatrixValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "matrix", m, [][]int32{{1, 2, 3}, {4, 5, 6}})
+	})
+	t.Run("not a matrix", func(t *testing.T) {
+		v := MustVariant([]int32{1, 2, 3})
+		verify.Values(t, "dimensions", v.Dimensions(), []int32{3})
+		if _, err := v.MatrixValue(); err == nil {
+			t.Fatal("got nil want error")
+		}
+		verify.Values(t, "scalar dimensions", MustVariant(int32(1)).Dimensions(), []int32(nil))
+	})
+	t.Run("matrix round trip", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			value interface{}
+			dims  []int32
+		}{
+			{"2d float", [][]float32{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}, []int32{3, 4}},
+			{"2d int16", [][]int16{{-1, 2}, {3, -4}}, []int32{2, 2}},
+			{"2d string", [][]string{{"a", "b", "c"}, {"d", "e", "f"}}, []int32{2, 3}},
+			{"3d bool", [][][]bool{{{true, false}, {false, true}}, {{true, true}, {false, false}}, {{false, true}, {true, false}}}, []int32{3, 2, 2}},
+			{"3d double", [][][]float64{{{1.5}, {2.5}}}, []int32{1, 2, 1}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				v := MustVariant(tt.value)
+				b, err := v.Encode()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := new(Variant)
+				if _, err := got.Decode(b); err != nil {
+					t.Fatal(err)
+				}
+				verify.Values(t, "dimensions", got.ArrayDimensions(), tt.dims)
+				verify.Values(t, "value", got.Value(), tt.value)
+			})
+		}
+	})
+	t.Run("matrix from flat values", func(t *testing.T) {
+		v, err := NewMatrixVariant([]float32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, []int32{3, 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v.EncodingMask(), byte(TypeIDFloat|VariantArrayValues|VariantArrayDimensions); got != want {
+			t.Fatalf("got mask %#x want %#x", got, want)
+		}
+		want := [][]float32{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}}
+		verify.Values(t, "value", v.Value(), want)
+
+		b, err := v.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := new(Variant)
+		if _, err := got.Decode(b); err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "dimensions", got.ArrayDimensions(), []int32{3, 4})
+		verify.Values(t, "decoded value", got.Value(), want)
+	})
+	t.Run("matrix from flat values errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			values interface{}
+			dims   []int32
+		}{
+			{"length mismatch", []int32{1, 2, 3, 4, 5}, []int32{2, 3}},
+			{"no dimensions", []int32{1}, nil},
+			{"zero dimension", []int32{}, []int32{0, 3}},
+			{"not a slice", int32(1), []int32{1}},
+			{"nested slice", [][]int32{{1}}, []int32{1, 1}},
+			{"byte string", []byte{1, 2}, []int32{1, 2}},
+			{"overflow", []int32{}, []int32{65536, 65536}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := NewMatrixVariant(tt.values, tt.dims); err == nil {
+					t.Fatal("got nil want error")
+				}
+			})
+		}
+	})
+	t.Run("dimensions overflow", func(t *testing.T) {
+		b := []byte{
+			// variant encoding mask
+			0xc7,
+			// array length
+			0x00, 0x00, 0x00, 0x00,
+			// array dimensions length
+			0x02, 0x00, 0x00, 0x00,
+			// array dimensions: 65536 x 65536 overflows int32 to 0
+			0x00, 0x00, 0x01, 0x00,
+			0x00, 0x00, 0x01, 0x00,
+		}
+
+		_, err := Decode(b, MustVariant([]uint32{0}))
+		if got, want := err, errUnbalancedSlice; !errors.Equal(got, want) {
+			t.Fatalf("got error %#v want %#v", got, want)
+		}
+	})
 	t.Run("unbalanced", func(t *testing.T) {
 		b := []byte{
 			// variant encoding mask
@@ -1048,8 +1188,8 @@ func TestVariantValueHelpers(t *testing.T) {
 			fn:   func(v *Variant) interface{} { return v.GUID() },
 		},
 		{
-			v:    NewGUID("abc"),
-			want: NewGUID("abc"),
+			v:    MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"),
+			want: MustParseGUID("72962B91-FA75-4AE6-8D28-B404DC7DAF63"),
 			fn:   func(v *Variant) interface{} { return v.GUID() },
 		},
 
@@ -1216,7 +1356,7 @@ func TestVariantValueHelpers(t *testing.T) {
 
 		// []*GUID
 		{
-			v:    []*GUID{NewGUID("abcd")},
+			v:    []*GUID{NewRandomGUID()},
 			want: (*GUID)(nil),
 			fn:   func(v *Variant) interface{} { return v.GUID() },
 		},
@@ -0,0 +1,171 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package uacert creates application instance certificates for OPC UA
+// clients and servers.
+package uacert
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+// KeySize is the default size of the RSA key in bits.
+const KeySize = 2048
+
+// DefaultValidity is the validity of certificates which are generated
+// by LoadOrGenerateCert.
+const DefaultValidity = 365 * 24 * time.Hour
+
+// GenerateCert creates a self-signed application instance certificate
+// and a new RSA private key and returns both PEM encoded.
+//
+// appURI is added as the URI in the subject alternative name. It must
+// match the ApplicationURI of the application description or servers
+// will reject the connection. hosts contains the host names and IP
+// addresses of the application. The certificate is valid from now
+// for validFor.
+//
+// Specification: Part 6, 6.2.2
+func GenerateCert(appURI string, hosts []string, validFor time.Duration) (certPEM, keyPEM []byte, err error) {
+	return GenerateCertKeySize(appURI, hosts, validFor, KeySize)
+}
+
+// GenerateCertKeySize works like GenerateCert but creates an RSA key
+// with keySize bits which must be 2048, 3072 or 4096.
+func GenerateCertKeySize(appURI string, hosts []string, validFor time.Duration, keySize int) (certPEM, keyPEM []byte, err error) {
+	switch keySize {
+	case 2048, 3072, 4096:
+	default:
+		return nil, nil, errors.Errorf("invalid key size %d", keySize)
+	}
+	if appURI == "" {
+		return nil, nil, errors.New("missing application uri")
+	}
+	uri, err := url.Parse(appURI)
+	if err != nil {
+		return nil, nil, errors.Errorf("invalid application uri: %s", err)
+	}
+	if validFor <= 0 {
+		return nil, nil, errors.Errorf("invalid validity %s", validFor)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to generate private key: %s", err)
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to generate serial number: %s", err)
+	}
+
+	notBefore := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   appURI,
+			Organization: []string{"gopcua"},
+		},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(validFor),
+
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{uri},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to create certificate: %s", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, nil
+}
+
+// LoadOrGenerateCert returns the PEM encoded certificate and private key
+// from certFile and keyFile. If neither file exists a new certificate is
+// created with GenerateCertKeySize and written to the files so that the
+// application keeps its identity across restarts. The files are also
+// replaced if the certificate has expired or was not issued for appURI.
+// The certificate is only kept in memory if both file names are empty.
+func LoadOrGenerateCert(certFile, keyFile, appURI string, hosts []string, validFor time.Duration, keySize int) (certPEM, keyPEM []byte, err error) {
+	if certFile == "" && keyFile == "" {
+		return GenerateCertKeySize(appURI, hosts, validFor, keySize)
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, nil, errors.New("missing certificate or key file")
+	}
+
+	certPEM, certErr := ioutil.ReadFile(certFile)
+	keyPEM, keyErr := ioutil.ReadFile(keyFile)
+	switch {
+	case certErr == nil && keyErr == nil:
+		ok, err := reusable(certPEM, appURI, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			return certPEM, keyPEM, nil
+		}
+	case os.IsNotExist(certErr) && os.IsNotExist(keyErr):
+	case certErr != nil && !os.IsNotExist(certErr):
+		return nil, nil, errors.Errorf("failed to read certificate: %s", certErr)
+	case keyErr != nil && !os.IsNotExist(keyErr):
+		return nil, nil, errors.Errorf("failed to read private key: %s", keyErr)
+	default:
+		return nil, nil, errors.Errorf("only one of %s and %s exists", certFile, keyFile)
+	}
+
+	certPEM, keyPEM, err = GenerateCertKeySize(appURI, hosts, validFor, keySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return nil, nil, errors.Errorf("failed to write private key: %s", err)
+	}
+	if err := ioutil.WriteFile(certFile, certPEM, 0644); err != nil {
+		return nil, nil, errors.Errorf("failed to write certificate: %s", err)
+	}
+	return certPEM, keyPEM, nil
+}
+
+// reusable returns true if the PEM encoded certificate has not expired
+// at now and was issued for appURI.
+func reusable(certPEM []byte, appURI string, now time.Time) (bool, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return false, errors.New("failed to decode certificate")
+	}
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false, errors.Errorf("failed to parse certificate: %s", err)
+	}
+	if now.After(c.NotAfter) {
+		return false, nil
+	}
+	return len(c.URIs) > 0 && c.URIs[0].String() == appURI, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uacert
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+)
+
+func TestGenerateCert(t *testing.T) {
+	certPEM, keyPEM, err := GenerateCert("urn:gopcua:client", []string{"localhost", "127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uris []string
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	verify.Values(t, "uris", uris, []string{"urn:gopcua:client"})
+	verify.Values(t, "dns names", cert.DNSNames, []string{"localhost"})
+	verify.Values(t, "ip addresses", len(cert.IPAddresses), 1)
+	verify.Values(t, "ext key usage", cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+
+	want := x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment
+	if cert.KeyUsage&want != want {
+		t.Fatalf("got key usage %b want %b", cert.KeyUsage, want)
+	}
+	if d := cert.NotAfter.Sub(cert.NotBefore); d != time.Hour {
+		t.Fatalf("got validity %s want 1h", d)
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		t.Fatalf("certificate is not self-signed: %s", err)
+	}
+}
+
+func TestGenerateCertErrors(t *testing.T) {
+	if _, _, err := GenerateCert("", nil, time.Hour); err == nil {
+		t.Fatal("got nil want error for missing uri")
+	}
+	if _, _, err := GenerateCert("urn:gopcua:client", nil, 0); err == nil {
+		t.Fatal("got nil want error for zero validity")
+	}
+}
+
+func TestGenerateCertKeySize(t *testing.T) {
+	_, keyPEM, err := GenerateCertKeySize("urn:gopcua:client", nil, time.Hour, 3072)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := key.N.BitLen(), 3072; got != want {
+		t.Fatalf("got key size %d want %d", got, want)
+	}
+
+	for _, n := range []int{0, 1024, 2047} {
+		if _, _, err := GenerateCertKeySize("urn:gopcua:client", nil, time.Hour, n); err == nil {
+			t.Fatalf("got nil want error for key size %d", n)
+		}
+	}
+}
+
+func TestLoadOrGenerateCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopcua-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM, keyPEM, err := LoadOrGenerateCert(certFile, keyFile, "urn:gopcua:client", nil, time.Hour, KeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Fatalf("got key file mode %s want 0600", fi.Mode().Perm())
+	}
+
+	// the second call must reuse the certificate.
+	certPEM2, keyPEM2, err := LoadOrGenerateCert(certFile, keyFile, "urn:gopcua:client", nil, time.Hour, KeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "cert", certPEM2, certPEM)
+	verify.Values(t, "key", keyPEM2, keyPEM)
+
+	// a certificate for another application uri is replaced.
+	certPEM3, _, err := LoadOrGenerateCert(certFile, keyFile, "urn:gopcua:other", nil, time.Hour, KeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "other uri", certURI(t, certPEM3), "urn:gopcua:other")
+
+	// an expired certificate is replaced.
+	expiredPEM, expiredKeyPEM := expiredCert(t, "urn:gopcua:client")
+	if err := ioutil.WriteFile(certFile, expiredPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, expiredKeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	certPEM4, _, err := LoadOrGenerateCert(certFile, keyFile, "urn:gopcua:client", nil, time.Hour, KeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(certPEM4) == string(expiredPEM) {
+		t.Fatal("expired certificate was not replaced")
+	}
+	stored, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "stored cert", stored, certPEM4)
+
+	if err := os.Remove(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadOrGenerateCert(certFile, keyFile, "urn:gopcua:client", nil, time.Hour, KeySize); err == nil {
+		t.Fatal("got nil want error for missing key file")
+	}
+	if _, _, err := LoadOrGenerateCert(certFile, "", "urn:gopcua:client", nil, time.Hour, KeySize); err == nil {
+		t.Fatal("got nil want error for empty key file name")
+	}
+}
+
+// certURI returns the application uri of the PEM encoded certificate.
+func certURI(t *testing.T, certPEM []byte) string {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.URIs) == 0 {
+		t.Fatal("no uri")
+	}
+	return c.URIs[0].String()
+}
+
+// expiredCert returns a PEM encoded certificate for appURI which
+// expired an hour ago and its private key.
+func expiredCert(t *testing.T, appURI string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, KeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse(appURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: appURI},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     time.Now().Add(-time.Hour),
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
@@ -0,0 +1,301 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uacert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// The errors returned by Verify. They are the status codes of the
+// corresponding certificate validation steps and can be checked with
+// errors.Is, e.g. to implement a trust-on-first-use flow which asks the
+// user to trust a certificate that failed with ErrCertUntrusted.
+//
+// Specification: Part 4, 6.1.3
+var (
+	ErrCertInvalid      = ua.StatusBadCertificateInvalid
+	ErrCertExpired      = ua.StatusBadCertificateTimeInvalid
+	ErrCertUntrusted    = ua.StatusBadCertificateUntrusted
+	ErrCertRevoked      = ua.StatusBadCertificateRevoked
+	ErrCertUsage        = ua.StatusBadCertificateUseNotAllowed
+	ErrURIMismatch      = ua.StatusBadCertificateURIInvalid
+	ErrHostnameMismatch = ua.StatusBadCertificateHostNameInvalid
+)
+
+// VerifyError describes why a certificate was rejected. Err is one of
+// the ErrXXX values.
+type VerifyError struct {
+	Err    error
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return errors.Prefix + e.Err.Error() + ": " + e.Reason
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+func verifyError(err error, format string, args ...interface{}) error {
+	return &VerifyError{Err: err, Reason: fmt.Sprintf(format, args...)}
+}
+
+// VerifyOptions contains the values the certificate is checked against.
+type VerifyOptions struct {
+	// ApplicationURI must be the URI in the subject alternative name
+	// of the certificate. It is not checked if it is empty.
+	ApplicationURI string
+
+	// Hostname must be a DNS name or an IP address in the subject
+	// alternative name of the certificate. It is not checked if it
+	// is empty.
+	Hostname string
+
+	// ExtKeyUsage is the extended key usage the certificate must
+	// allow, e.g. x509.ExtKeyUsageServerAuth for a server certificate.
+	// The zero value x509.ExtKeyUsageAny accepts any usage.
+	ExtKeyUsage x509.ExtKeyUsage
+
+	// Time is the time at which the certificate must be valid.
+	// The current time is used if it is zero.
+	Time time.Time
+}
+
+// Verifier validates the DER encoded certificate of the remote
+// application.
+type Verifier interface {
+	Verify(cert []byte, opts VerifyOptions) error
+}
+
+// TrustList is a Verifier which accepts certificates which are trusted
+// directly or which are issued by a trusted certificate authority.
+//
+// Specification: Part 12, 7.5
+type TrustList struct {
+	// Trusted contains the trusted application instance and CA
+	// certificates.
+	Trusted []*x509.Certificate
+
+	// Issuers contains the CA certificates which are needed to build
+	// a chain to a trusted certificate but which are not trusted
+	// themselves.
+	Issuers []*x509.Certificate
+
+	// CRLs contains the revocation lists of the CAs.
+	CRLs []*x509.RevocationList
+}
+
+// LoadTrustList loads the trusted and issuer certificates and the
+// revocation lists from the files in the given directories. The files
+// can be PEM or DER encoded. Empty directory names are skipped.
+func LoadTrustList(trustedDir, issuersDir, crlDir string) (*TrustList, error) {
+	var err error
+	l := &TrustList{}
+	if l.Trusted, err = loadCertificates(trustedDir); err != nil {
+		return nil, err
+	}
+	if l.Issuers, err = loadCertificates(issuersDir); err != nil {
+		return nil, err
+	}
+	if l.CRLs, err = loadCRLs(crlDir); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func loadCertificates(dir string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	err := readDir(dir, "CERTIFICATE", func(name string, der []byte) error {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return errors.Errorf("invalid certificate %s: %s", name, err)
+		}
+		certs = append(certs, c)
+		return nil
+	})
+	return certs, err
+}
+
+func loadCRLs(dir string) ([]*x509.RevocationList, error) {
+	var crls []*x509.RevocationList
+	err := readDir(dir, "X509 CRL", func(name string, der []byte) error {
+		c, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return errors.Errorf("invalid crl %s: %s", name, err)
+		}
+		crls = append(crls, c)
+		return nil
+	})
+	return crls, err
+}
+
+// readDir calls f with the DER encoded content of every PEM block of
+// the given type or of every file without PEM blocks in dir.
+func readDir(dir, blockType string, f func(name string, der []byte) error) error {
+	if dir == "" {
+		return nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Errorf("failed to read %s: %s", dir, err)
+	}
+	for _, fi := range files {
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		name := filepath.Join(dir, fi.Name())
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return errors.Errorf("failed to read %s: %s", name, err)
+		}
+		if !bytes.Contains(b, []byte("-----BEGIN")) {
+			if err := f(name, b); err != nil {
+				return err
+			}
+			continue
+		}
+		for {
+			var block *pem.Block
+			block, b = pem.Decode(b)
+			if block == nil {
+				break
+			}
+			if block.Type != blockType {
+				continue
+			}
+			if err := f(name, block.Bytes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Verify checks the validity period, the key usage and the issuer chain
+// of the certificate, whether it was revoked and whether it belongs to
+// the application and host in opts.
+//
+// Specification: Part 4, 6.1.3
+func (l *TrustList) Verify(der []byte, opts VerifyOptions) error {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return verifyError(ErrCertInvalid, "%s", err)
+	}
+
+	now := opts.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return verifyError(ErrCertExpired, "certificate is valid from %s to %s", cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+
+	if err := checkUsage(cert, opts.ExtKeyUsage); err != nil {
+		return err
+	}
+
+	chain, err := l.chain(cert, now)
+	if err != nil {
+		return err
+	}
+	if err := l.checkRevoked(chain); err != nil {
+		return err
+	}
+
+	if opts.ApplicationURI != "" {
+		var found bool
+		for _, u := range cert.URIs {
+			if u.String() == opts.ApplicationURI {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return verifyError(ErrURIMismatch, "certificate is not valid for %s", opts.ApplicationURI)
+		}
+	}
+
+	if opts.Hostname != "" {
+		if err := cert.VerifyHostname(opts.Hostname); err != nil {
+			return verifyError(ErrHostnameMismatch, "%s", err)
+		}
+	}
+	return nil
+}
+
+func checkUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) error {
+	// a missing key usage extension allows all usages.
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return verifyError(ErrCertUsage, "certificate does not allow digital signatures")
+	}
+	if usage == x509.ExtKeyUsageAny || len(cert.ExtKeyUsage) == 0 {
+		return nil
+	}
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage || u == x509.ExtKeyUsageAny {
+			return nil
+		}
+	}
+	return verifyError(ErrCertUsage, "certificate does not allow extended key usage %d", usage)
+}
+
+// chain returns the chain from cert to a trusted certificate.
+func (l *TrustList) chain(cert *x509.Certificate, now time.Time) ([]*x509.Certificate, error) {
+	for _, c := range l.Trusted {
+		if c.Equal(cert) {
+			return []*x509.Certificate{cert}, nil
+		}
+	}
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	for _, c := range l.Trusted {
+		roots.AddCert(c)
+	}
+	for _, c := range l.Issuers {
+		intermediates.AddCert(c)
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		if e, ok := err.(x509.CertificateInvalidError); ok && e.Reason == x509.Expired {
+			return nil, verifyError(ErrCertExpired, "%s", err)
+		}
+		return nil, verifyError(ErrCertUntrusted, "%s", err)
+	}
+	return chains[0], nil
+}
+
+// checkRevoked checks every certificate of the chain against the
+// revocation lists of its issuer.
+func (l *TrustList) checkRevoked(chain []*x509.Certificate) error {
+	for i, c := range chain[:len(chain)-1] {
+		issuer := chain[i+1]
+		for _, crl := range l.CRLs {
+			if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) || crl.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			for _, r := range crl.RevokedCertificates {
+				if r.SerialNumber.Cmp(c.SerialNumber) == 0 {
+					return verifyError(ErrCertRevoked, "certificate %s was revoked on %s", c.Subject, r.RevocationTime.Format(time.RFC3339))
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uacert
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+var serial int64
+
+// newTestCert creates a certificate for uri which is signed by parent
+// or self-signed if parent is nil.
+func newTestCert(t *testing.T, uri string, ca bool, parent *testCert, usage x509.KeyUsage) *testCert {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: uri},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              usage,
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+		URIs:                  []*url.URL{u},
+		DNSNames:              []string{"localhost"},
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: c, key: key}
+}
+
+func TestTrustListVerify(t *testing.T) {
+	const appUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	const caUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	self := newTestCert(t, "urn:server:self", false, nil, appUsage)
+	root := newTestCert(t, "urn:ca:root", true, nil, caUsage)
+	inter := newTestCert(t, "urn:ca:inter", true, root, caUsage)
+	leaf := newTestCert(t, "urn:server:leaf", false, inter, appUsage)
+	revoked := newTestCert(t, "urn:server:revoked", false, inter, appUsage)
+	noSign := newTestCert(t, "urn:server:nosign", false, nil, x509.KeyUsageKeyEncipherment)
+
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          time.Now().Add(time.Hour),
+		RevokedCertificates: []pkix.RevokedCertificate{{SerialNumber: revoked.cert.SerialNumber, RevocationTime: time.Now()}},
+	}, inter.cert, inter.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := x509.ParseRevocationList(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &TrustList{
+		Trusted: []*x509.Certificate{self.cert, root.cert, noSign.cert},
+		Issuers: []*x509.Certificate{inter.cert},
+		CRLs:    []*x509.RevocationList{crl},
+	}
+
+	tests := []struct {
+		name string
+		l    *TrustList
+		cert *testCert
+		opts VerifyOptions
+		err  error
+	}{
+		{
+			name: "self-signed",
+			l:    l,
+			cert: self,
+			opts: VerifyOptions{ApplicationURI: "urn:server:self", Hostname: "localhost"},
+		},
+		{
+			name: "issued",
+			l:    l,
+			cert: leaf,
+			opts: VerifyOptions{ApplicationURI: "urn:server:leaf"},
+		},
+		{
+			name: "missing issuer",
+			l:    &TrustList{Trusted: []*x509.Certificate{root.cert}},
+			cert: leaf,
+			err:  ErrCertUntrusted,
+		},
+		{
+			name: "untrusted",
+			l:    &TrustList{},
+			cert: self,
+			err:  ErrCertUntrusted,
+		},
+		{
+			name: "expired",
+			l:    l,
+			cert: self,
+			opts: VerifyOptions{Time: time.Now().Add(2 * time.Hour)},
+			err:  ErrCertExpired,
+		},
+		{
+			name: "revoked",
+			l:    l,
+			cert: revoked,
+			err:  ErrCertRevoked,
+		},
+		{
+			name: "uri mismatch",
+			l:    l,
+			cert: self,
+			opts: VerifyOptions{ApplicationURI: "urn:server:other"},
+			err:  ErrURIMismatch,
+		},
+		{
+			name: "hostname mismatch",
+			l:    l,
+			cert: self,
+			opts: VerifyOptions{Hostname: "example.com"},
+			err:  ErrHostnameMismatch,
+		},
+		{
+			name: "key usage",
+			l:    l,
+			cert: noSign,
+			err:  ErrCertUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.l.Verify(tt.cert.cert.Raw, tt.opts)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("got error %s want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v want %v", err, tt.err)
+			}
+		})
+	}
+
+	if err := l.Verify([]byte("x"), VerifyOptions{}); !errors.Is(err, ErrCertInvalid) {
+		t.Fatalf("got error %v want %v", err, ErrCertInvalid)
+	}
+}
+
+func TestLoadTrustList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopcua-trust")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPEM, _, err := GenerateCert("urn:gopcua:server", []string{"localhost"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+
+	trusted := filepath.Join(dir, "trusted")
+	issuers := filepath.Join(dir, "issuers")
+	for _, d := range []string{trusted, issuers} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(trusted, "server.pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(issuers, "server.der"), block.Bytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := LoadTrustList(trusted, issuers, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(l.Trusted), 1; got != want {
+		t.Fatalf("got %d trusted certificates want %d", got, want)
+	}
+	if got, want := len(l.Issuers), 1; got != want {
+		t.Fatalf("got %d issuer certificates want %d", got, want)
+	}
+	if err := l.Verify(block.Bytes, VerifyOptions{ApplicationURI: "urn:gopcua:server"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadTrustList(filepath.Join(dir, "missing"), "", ""); err == nil {
+		t.Fatal("got nil want error for missing directory")
+	}
+}
@@ -12,8 +12,8 @@ import (
 	"sync"
 	"sync/atomic"
 
-	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/ua"
 )
 
@@ -63,10 +63,14 @@ type Dialer struct {
 	// ClientACK defines the connection parameters requested by the client.
 	// Defaults to DefaultClientACK.
 	ClientACK *Acknowledge
+
+	// Logger logs the events of the connection. Defaults to
+	// logger.Default.
+	Logger logger.Logger
 }
 
 func (d *Dialer) Dial(ctx context.Context, endpoint string) (*Conn, error) {
-	debug.Printf("uacp: connecting to %s", endpoint)
+	logger.OrDefault(d.Logger).Debug("uacp: connecting", "endpoint", endpoint)
 	_, raddr, err := ResolveEndpoint(endpoint)
 	if err != nil {
 		return nil, err
@@ -88,12 +92,19 @@ func (d *Dialer) Dial(ctx context.Context, endpoint string) (*Conn, error) {
 		return nil, err
 	}
 
-	debug.Printf("uacp %d: start HEL/ACK handshake", conn.id)
+	conn.SetLogger(d.Logger)
+
+	conn.log.Debug("uacp: start HEL/ACK handshake")
 	if err := conn.Handshake(endpoint); err != nil {
-		debug.Printf("uacp %d: HEL/ACK handshake failed: %s", conn.id, err)
+		conn.log.Warn("uacp: HEL/ACK handshake failed", "endpoint", endpoint, "err", err)
 		conn.Close()
 		return nil, err
 	}
+	conn.log.Info("uacp: connected", "endpoint", endpoint,
+		"send_buf_size", conn.SendBufSize(),
+		"receive_buf_size", conn.ReceiveBufSize(),
+		"max_message_size", conn.MaxMessageSize(),
+	)
 	return conn, nil
 }
 
@@ -145,7 +156,7 @@ func (l *Listener) Accept(ctx context.Context) (*Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	conn := &Conn{TCPConn: c, id: nextid(), ack: l.ack}
+	conn := newConn(c, l.ack)
 	if err := conn.srvhandshake(l.endpoint); err != nil {
 		c.Close()
 		return nil, err
@@ -170,12 +181,35 @@ func (l *Listener) Endpoint() string {
 
 type Conn struct {
 	*net.TCPConn
-	id  uint32
+	id uint32
+
+	// ack contains the parameters which this end sends in its Hello
+	// or Acknowledge message.
 	ack *Acknowledge
 
+	// limits are the negotiated parameters of the connection from the
+	// point of view of this end. Until the handshake is complete they
+	// are the parameters of ack.
+	limits limits
+
+	// log logs the events of the connection with its id.
+	log logger.Logger
+
 	closeOnce sync.Once
 }
 
+// limits are the negotiated buffer sizes and message limits of a
+// connection. The limits for sending are the receive limits of the
+// other end.
+type limits struct {
+	receiveBufSize     uint32
+	sendBufSize        uint32
+	maxMessageSize     uint32
+	maxChunkCount      uint32
+	maxSendMessageSize uint32
+	maxSendChunkCount  uint32
+}
+
 func NewConn(c *net.TCPConn, ack *Acknowledge) (*Conn, error) {
 	if c == nil {
 		return nil, fmt.Errorf("no connection")
@@ -183,27 +217,70 @@ func NewConn(c *net.TCPConn, ack *Acknowledge) (*Conn, error) {
 	if ack == nil {
 		ack = DefaultClientACK
 	}
-	return &Conn{TCPConn: c, id: nextid(), ack: ack}, nil
+	return newConn(c, ack), nil
+}
+
+func newConn(c *net.TCPConn, ack *Acknowledge) *Conn {
+	conn := &Conn{
+		TCPConn: c,
+		id:      nextid(),
+		ack:     ack,
+		limits: limits{
+			receiveBufSize: ack.ReceiveBufSize,
+			sendBufSize:    ack.SendBufSize,
+			maxMessageSize: ack.MaxMessageSize,
+			maxChunkCount:  ack.MaxChunkCount,
+		},
+	}
+	conn.SetLogger(nil)
+	return conn
 }
 
 func (c *Conn) ID() uint32 {
 	return c.id
 }
 
+// SetLogger sets the logger for the events of the connection. The events
+// contain the id of the connection. A nil logger uses logger.Default.
+// SetLogger must be called before the connection is used.
+func (c *Conn) SetLogger(l logger.Logger) {
+	c.log = logger.With(l, "conn_id", c.id)
+}
+
+// ReceiveBufSize returns the maximum size of a chunk which this end
+// receives.
 func (c *Conn) ReceiveBufSize() uint32 {
-	return c.ack.ReceiveBufSize
+	return c.limits.receiveBufSize
 }
 
+// SendBufSize returns the maximum size of a chunk which this end sends.
+// It is the receive buffer size of the other end.
 func (c *Conn) SendBufSize() uint32 {
-	return c.ack.SendBufSize
+	return c.limits.sendBufSize
 }
 
+// MaxMessageSize returns the maximum size of the body of a message which
+// this end receives. Zero means no limit.
 func (c *Conn) MaxMessageSize() uint32 {
-	return c.ack.MaxMessageSize
+	return c.limits.maxMessageSize
 }
 
+// MaxChunkCount returns the maximum number of chunks of a message which
+// this end receives. Zero means no limit.
 func (c *Conn) MaxChunkCount() uint32 {
-	return c.ack.MaxChunkCount
+	return c.limits.maxChunkCount
+}
+
+// MaxSendMessageSize returns the maximum size of the body of a message
+// which the other end accepts. Zero means no limit.
+func (c *Conn) MaxSendMessageSize() uint32 {
+	return c.limits.maxSendMessageSize
+}
+
+// MaxSendChunkCount returns the maximum number of chunks of a message
+// which the other end accepts. Zero means no limit.
+func (c *Conn) MaxSendChunkCount() uint32 {
+	return c.limits.maxSendChunkCount
 }
 
 func (c *Conn) Close() (err error) {
@@ -213,7 +290,7 @@ func (c *Conn) Close() (err error) {
 }
 
 func (c *Conn) close() error {
-	debug.Printf("uacp %d: close", c.id)
+	c.log.Debug("uacp: close")
 	return c.TCPConn.Close()
 }
 
@@ -246,16 +323,8 @@ func (c *Conn) Handshake(endpoint string) error {
 		if ack.Version != 0 {
 			return errors.Errorf("uacp: invalid version %d", ack.Version)
 		}
-		if ack.MaxChunkCount == 0 {
-			ack.MaxChunkCount = DefaultMaxChunkCount
-			debug.Printf("uacp %d: server has no chunk limit. Using %d", c.id, ack.MaxChunkCount)
-		}
-		if ack.MaxMessageSize == 0 {
-			ack.MaxMessageSize = DefaultMaxMessageSize
-			debug.Printf("uacp %d: server has no message size limit. Using %d", c.id, ack.MaxMessageSize)
-		}
-		c.ack = ack
-		debug.Printf("uacp %d: recv %#v", c.id, ack)
+		c.log.Debug("uacp: recv ACK", "ack", ack)
+		c.limits = c.clientLimits(ack)
 		return nil
 
 	case "ERRF":
@@ -263,7 +332,7 @@ func (c *Conn) Handshake(endpoint string) error {
 		if _, err := errf.Decode(b[hdrlen:]); err != nil {
 			return errors.Errorf("uacp: decode ERR failed: %s", err)
 		}
-		debug.Printf("uacp %d: recv %#v", c.id, errf)
+		c.log.Warn("uacp: recv ERR", "err", errf)
 		return errf
 
 	default:
@@ -293,11 +362,20 @@ func (c *Conn) srvhandshake(endpoint string) error {
 			c.SendError(ua.StatusBadTCPEndpointURLInvalid)
 			return errors.Errorf("uacp: invalid endpoint url %s", hel.EndpointURL)
 		}
-		if err := c.Send("ACKF", c.ack); err != nil {
+		c.log.Debug("uacp: recv HEL", "hello", hel)
+		ack := c.serverACK(hel)
+		if err := c.Send("ACKF", ack); err != nil {
 			c.SendError(ua.StatusBadTCPInternalError)
 			return err
 		}
-		debug.Printf("uacp %d: recv %#v", c.id, hel)
+		c.limits = limits{
+			receiveBufSize:     ack.ReceiveBufSize,
+			sendBufSize:        ack.SendBufSize,
+			maxMessageSize:     ack.MaxMessageSize,
+			maxChunkCount:      ack.MaxChunkCount,
+			maxSendMessageSize: hel.MaxMessageSize,
+			maxSendChunkCount:  hel.MaxChunkCount,
+		}
 		return nil
 
 	case "RHEF":
@@ -310,7 +388,7 @@ func (c *Conn) srvhandshake(endpoint string) error {
 			c.SendError(ua.StatusBadTCPEndpointURLInvalid)
 			return errors.Errorf("uacp: invalid endpoint url %s", rhe.EndpointURL)
 		}
-		debug.Printf("uacp %d: connecting to %s", c.id, rhe.ServerURI)
+		c.log.Debug("uacp: connecting", "server_uri", rhe.ServerURI)
 		c.Close()
 		var dialer net.Dialer
 		c2, err := dialer.DialContext(context.Background(), "tcp", rhe.ServerURI)
@@ -318,7 +396,7 @@ func (c *Conn) srvhandshake(endpoint string) error {
 			return err
 		}
 		c.TCPConn = c2.(*net.TCPConn)
-		debug.Printf("uacp %d: recv %#v", c.id, rhe)
+		c.log.Debug("uacp: recv RHE", "reverse_hello", rhe)
 		return nil
 
 	case "ERRF":
@@ -326,7 +404,7 @@ func (c *Conn) srvhandshake(endpoint string) error {
 		if _, err := errf.Decode(b[hdrlen:]); err != nil {
 			return errors.Errorf("uacp: decode ERR failed: %s", err)
 		}
-		debug.Printf("uacp %d: recv %#v", c.id, errf)
+		c.log.Warn("uacp: recv ERR", "err", errf)
 		return errf
 
 	default:
@@ -335,16 +413,80 @@ func (c *Conn) srvhandshake(endpoint string) error {
 	}
 }
 
+// clientLimits returns the limits of a client connection for the
+// Acknowledge message of the server. The client sends chunks which fit
+// into the receive buffer of the server and messages within the limits
+// of the server. It receives messages within its own limits or, if it
+// has none, within the limits of the server or the defaults.
+//
+// Specification: Part 6, 7.1.2.4
+func (c *Conn) clientLimits(ack *Acknowledge) limits {
+	l := limits{
+		receiveBufSize:     c.ack.ReceiveBufSize,
+		sendBufSize:        revise(c.ack.SendBufSize, ack.ReceiveBufSize),
+		maxMessageSize:     c.ack.MaxMessageSize,
+		maxChunkCount:      c.ack.MaxChunkCount,
+		maxSendMessageSize: ack.MaxMessageSize,
+		maxSendChunkCount:  ack.MaxChunkCount,
+	}
+	if l.maxChunkCount == 0 {
+		l.maxChunkCount = ack.MaxChunkCount
+	}
+	if l.maxChunkCount == 0 {
+		l.maxChunkCount = DefaultMaxChunkCount
+		c.log.Debug("uacp: server has no chunk limit", "max_chunk_count", l.maxChunkCount)
+	}
+	if l.maxMessageSize == 0 {
+		l.maxMessageSize = ack.MaxMessageSize
+	}
+	if l.maxMessageSize == 0 {
+		l.maxMessageSize = DefaultMaxMessageSize
+		c.log.Debug("uacp: server has no message size limit", "max_message_size", l.maxMessageSize)
+	}
+	return l
+}
+
+// serverACK returns the Acknowledge message of the server for the Hello
+// message of a client. The buffer sizes are revised so that the server
+// does not send chunks which are larger than the receive buffer of the
+// client and does not expect chunks which are larger than the send
+// buffer of the client.
+//
+// Specification: Part 6, 7.1.2.4
+func (c *Conn) serverACK(hel *Hello) *Acknowledge {
+	ack := *c.ack
+	ack.SendBufSize = revise(ack.SendBufSize, hel.ReceiveBufSize)
+	ack.ReceiveBufSize = revise(ack.ReceiveBufSize, hel.SendBufSize)
+	return &ack
+}
+
+// revise returns the buffer size of the other end if it is smaller than
+// the buffer size of this end. A buffer size of zero is ignored.
+func revise(size, other uint32) uint32 {
+	if other > 0 && other < size {
+		return other
+	}
+	return size
+}
+
 // hdrlen is the size of the uacp header
 const hdrlen = 8
 
 // Receive reads a full UACP message from the underlying connection.
-// The size of b must be at least ReceiveBufSize. Otherwise,
-// the function returns an error.
 func (c *Conn) Receive() ([]byte, error) {
-	// TODO(kung-foo): allow user-specified buffer
-	// TODO(kung-foo): sync.Pool
-	b := make([]byte, c.ack.ReceiveBufSize)
+	return c.ReceiveBuf(nil)
+}
+
+// ReceiveBuf reads a full UACP message from the underlying connection
+// into b so that the caller can reuse its buffers. If the capacity of b
+// is less than ReceiveBufSize a new buffer is allocated. The returned
+// message references b.
+func (c *Conn) ReceiveBuf(b []byte) ([]byte, error) {
+	size := c.limits.receiveBufSize
+	if uint32(cap(b)) < size {
+		b = make([]byte, size)
+	}
+	b = b[:size]
 
 	if _, err := io.ReadFull(c, b[:hdrlen]); err != nil {
 		// todo(fs): do not wrap this error since it hides io.EOF
@@ -357,8 +499,8 @@ func (c *Conn) Receive() ([]byte, error) {
 		return nil, errors.Errorf("uacp: header decode failed: %s", err)
 	}
 
-	if h.MessageSize > c.ack.ReceiveBufSize {
-		return nil, errors.Errorf("uacp: message too large: %d > %d bytes", h.MessageSize, c.ack.ReceiveBufSize)
+	if h.MessageSize > size {
+		return nil, errors.Errorf("uacp: message too large: %d > %d bytes", h.MessageSize, size)
 	}
 
 	if _, err := io.ReadFull(c, b[hdrlen:h.MessageSize]); err != nil {
@@ -367,7 +509,7 @@ func (c *Conn) Receive() ([]byte, error) {
 		return nil, err
 	}
 
-	debug.Printf("uacp %d: recv %s%c with %d bytes", c.id, h.MessageType, h.ChunkType, h.MessageSize)
+	c.log.Debug("uacp: recv", "type", h.MessageType, "chunk", string(h.ChunkType), "size", h.MessageSize)
 
 	if h.MessageType == "ERR" {
 		errf := new(Error)
@@ -395,8 +537,8 @@ func (c *Conn) Send(typ string, msg interface{}) error {
 		MessageSize: uint32(len(body) + hdrlen),
 	}
 
-	if h.MessageSize > c.ack.SendBufSize {
-		return errors.Errorf("send packet too large: %d > %d bytes", h.MessageSize, c.ack.SendBufSize)
+	if h.MessageSize > c.limits.sendBufSize {
+		return errors.Errorf("send packet too large: %d > %d bytes", h.MessageSize, c.limits.sendBufSize)
 	}
 
 	hdr, err := h.Encode()
@@ -408,7 +550,7 @@ func (c *Conn) Send(typ string, msg interface{}) error {
 	if _, err := c.Write(b); err != nil {
 		return errors.Errorf("write failed: %s", err)
 	}
-	debug.Printf("uacp %d: sent %s with %d bytes", c.id, typ, len(b))
+	c.log.Debug("uacp: sent", "type", typ, "size", len(b))
 
 	return nil
 }
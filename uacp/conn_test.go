@@ -185,3 +185,121 @@ NEXT:
 	got = got[:n]
 	verify.Values(t, "", got, want)
 }
+
+func TestReverseListener(t *testing.T) {
+	ln, err := ListenReverse("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	rhe := &ReverseHello{
+		ServerURI:   "urn:gopcua:server",
+		EndpointURL: "opc.tcp://127.0.0.1:4840/foo/bar",
+	}
+	sendErr := make(chan error, 1)
+	go func() {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			sendErr <- err
+			return
+		}
+		conn, err := NewConn(c.(*net.TCPConn), DefaultServerACK)
+		if err != nil {
+			sendErr <- err
+			return
+		}
+		sendErr <- conn.Send("RHEF", rhe)
+	}()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := <-sendErr; err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ReceiveReverseHello()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "", got, rhe)
+}
+
+func TestHandshakeLimits(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := "opc.tcp://" + l.Addr().String()
+	l.Close()
+
+	srvACK := &Acknowledge{
+		ReceiveBufSize: 16384,
+		SendBufSize:    32768,
+		MaxMessageSize: 1 * MB,
+		MaxChunkCount:  100,
+	}
+	ln, err := Listen(ep, srvACK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srvConn := make(chan *Conn, 1)
+	acceptErr := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept(ctx)
+		if err != nil {
+			acceptErr <- err
+			return
+		}
+		srvConn <- c
+	}()
+
+	d := &Dialer{
+		ClientACK: &Acknowledge{
+			ReceiveBufSize: 8192,
+			SendBufSize:    65535,
+			MaxMessageSize: 64 * KB,
+			MaxChunkCount:  10,
+		},
+	}
+	cli, err := d.Dial(ctx, ep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var srv *Conn
+	select {
+	case srv = <-srvConn:
+		defer srv.Close()
+	case err := <-acceptErr:
+		t.Fatalf("accept fail: %v", err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	limits := func(c *Conn) []uint32 {
+		return []uint32{c.ReceiveBufSize(), c.SendBufSize(), c.MaxMessageSize(), c.MaxChunkCount(), c.MaxSendMessageSize(), c.MaxSendChunkCount()}
+	}
+	verify.Values(t, "client", limits(cli), []uint32{8192, 16384, 64 * KB, 10, 1 * MB, 100})
+	verify.Values(t, "server", limits(srv), []uint32{16384, 8192, 1 * MB, 100, 64 * KB, 10})
+
+	// the chunks of the client fit into the receive buffer of the server
+	msg := &Message{Data: make([]byte, 10000)}
+	if err := cli.Send("MSGF", msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Receive(); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Send("MSGF", msg); err == nil {
+		t.Fatal("server sent a chunk larger than the receive buffer of the client")
+	}
+}
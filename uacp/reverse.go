@@ -0,0 +1,86 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uacp
+
+import (
+	"net"
+	"strings"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// ReverseListener accepts the connections of servers which connect to
+// the client with a ReverseHello message instead of waiting for the
+// client to connect.
+//
+// Specification: Part 6, 7.1.3
+type ReverseListener struct {
+	l *net.TCPListener
+}
+
+// ListenReverse listens for reverse connections on addr which is either
+// a "host:port" address or an endpoint in "opc.tcp://<addr[:port]>/path"
+// format.
+func ListenReverse(addr string) (*ReverseListener, error) {
+	var laddr *net.TCPAddr
+	var err error
+	if strings.HasPrefix(addr, "opc.tcp://") {
+		_, laddr, err = ResolveEndpoint(addr)
+	} else {
+		laddr, err = net.ResolveTCPAddr("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.ListenTCP("tcp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &ReverseListener{l: l}, nil
+}
+
+// Accept accepts the next server connection. The caller must read the
+// ReverseHello message with ReceiveReverseHello before the HEL/ACK
+// handshake.
+func (l *ReverseListener) Accept() (*Conn, error) {
+	c, err := l.l.AcceptTCP()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c, nil)
+}
+
+// Close closes the ReverseListener.
+func (l *ReverseListener) Close() error {
+	return l.l.Close()
+}
+
+// Addr returns the listener's network address.
+func (l *ReverseListener) Addr() net.Addr {
+	return l.l.Addr()
+}
+
+// ReceiveReverseHello reads the ReverseHello message which the server
+// sends after it has opened the connection.
+func (c *Conn) ReceiveReverseHello() (*ReverseHello, error) {
+	b, err := c.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	msgtyp := string(b[:4])
+	if msgtyp != "RHEF" {
+		c.SendError(ua.StatusBadTCPMessageTypeInvalid)
+		return nil, errors.Errorf("invalid reverse hello packet %q", msgtyp)
+	}
+	rhe := new(ReverseHello)
+	if _, err := rhe.Decode(b[hdrlen:]); err != nil {
+		c.SendError(ua.StatusBadTCPInternalError)
+		return nil, errors.Errorf("uacp: decode RHE failed: %s", err)
+	}
+	c.log.Debug("uacp: recv RHE", "reverse_hello", rhe)
+	return rhe, nil
+}
@@ -0,0 +1,20 @@
+module github.com/zzylovesll/myOpcUa/uaotel
+
+go 1.19
+
+require (
+	github.com/zzylovesll/myOpcUa v0.0.0
+	go.opentelemetry.io/otel v1.16.0
+	go.opentelemetry.io/otel/sdk v1.16.0
+	go.opentelemetry.io/otel/trace v1.16.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/pkg/errors v0.8.1 // indirect
+	go.opentelemetry.io/otel/metric v1.16.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+)
+
+replace github.com/zzylovesll/myOpcUa => ../
@@ -0,0 +1,74 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+// Package uaotel traces the requests of an OPC/UA client with
+// OpenTelemetry.
+//
+// It is a separate module so that only applications which use
+// OpenTelemetry depend on it:
+//
+//	c := opcua.NewClient(endpoint, uaotel.WithTracer(otel.Tracer("opcua")))
+package uaotel
+
+import (
+	"context"
+
+	"github.com/zzylovesll/myOpcUa"
+	"github.com/zzylovesll/myOpcUa/uasc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer sets an OpenTelemetry tracer which starts a client span for
+// every service call of the client. See opcua.WithTracer for the names
+// and the attributes of the spans.
+func WithTracer(t trace.Tracer) opcua.Option {
+	return opcua.WithTracer(Tracer(t))
+}
+
+// Tracer returns a uasc.Tracer which starts the spans of the requests
+// as client spans with the OpenTelemetry tracer t.
+func Tracer(t trace.Tracer) uasc.Tracer {
+	return tracer{t}
+}
+
+type tracer struct {
+	t trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, name string) (context.Context, uasc.Span) {
+	ctx, s := t.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, span{s}
+}
+
+type span struct {
+	s trace.Span
+}
+
+func (s span) SetAttributes(kv ...interface{}) {
+	attrs := make([]attribute.KeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		k, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		switch v := kv[i+1].(type) {
+		case int64:
+			attrs = append(attrs, attribute.Int64(k, v))
+		case string:
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+	s.s.SetAttributes(attrs...)
+}
+
+func (s span) SetError(err error) {
+	s.s.RecordError(err)
+	s.s.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) End() {
+	s.s.End()
+}
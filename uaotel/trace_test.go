@@ -0,0 +1,117 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uaotel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa"
+	"github.com/zzylovesll/myOpcUa/id"
+	"github.com/zzylovesll/myOpcUa/server"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uasc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, trace.Tracer) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	return sr, tp.Tracer("uaotel")
+}
+
+func TestWithTracer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := fmt.Sprintf("opc.tcp://%s", l.Addr())
+	l.Close()
+
+	srv := server.New(endpoint)
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	sr, tr := newRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := opcua.NewClient(endpoint, WithTracer(tr), opcua.AutoReconnect(false))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, parent := tr.Start(ctx, "caller")
+	_, err = c.ReadWithContext(ctx, &ua.ReadRequest{NodesToRead: []*ua.ReadValueID{
+		{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_CurrentTime), AttributeID: ua.AttributeIDValue},
+		{NodeID: ua.NewNumericNodeID(0, id.Server_ServerStatus_State), AttributeID: ua.AttributeIDValue},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	var read sdktrace.ReadOnlySpan
+	for _, s := range sr.Ended() {
+		if s.Name() == "Read" {
+			read = s
+		}
+	}
+	if read == nil {
+		t.Fatal("no Read span")
+	}
+	if got, want := read.SpanKind(), trace.SpanKindClient; got != want {
+		t.Fatalf("got span kind %v want %v", got, want)
+	}
+	if got, want := read.Parent().SpanID(), parent.SpanContext().SpanID(); got != want {
+		t.Fatalf("got parent %v want %v", got, want)
+	}
+	want := map[attribute.Key]attribute.Value{
+		uasc.AttrService:    attribute.StringValue("Read"),
+		uasc.AttrNodeCount:  attribute.Int64Value(2),
+		uasc.AttrStatusCode: attribute.Int64Value(int64(ua.StatusOK)),
+	}
+	got := map[attribute.Key]attribute.Value{}
+	for _, kv := range read.Attributes() {
+		got[kv.Key] = kv.Value
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got attribute %s=%v want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+	if got := read.Status().Code; got != codes.Unset {
+		t.Fatalf("got status %v want unset", got)
+	}
+}
+
+func TestSpanError(t *testing.T) {
+	sr, tr := newRecorder()
+	_, s := Tracer(tr).Start(context.Background(), "Write")
+	s.SetError(ua.StatusBadNodeIDUnknown)
+	s.End()
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans want 1", len(spans))
+	}
+	st := spans[0].Status()
+	if st.Code != codes.Error || st.Description != ua.StatusBadNodeIDUnknown.Error() {
+		t.Fatalf("got status %v %q want error %q", st.Code, st.Description, ua.StatusBadNodeIDUnknown.Error())
+	}
+	if len(spans[0].Events()) != 1 || spans[0].Events()[0].Name != "exception" {
+		t.Fatalf("got events %v want the recorded error", spans[0].Events())
+	}
+}
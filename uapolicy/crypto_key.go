@@ -38,23 +38,41 @@ ServerSeed		The value of the ServerNonce provided in the OpenSecureChannel respo
 
 */
 
-type derivedKeys struct {
-	signing, encryption, iv []byte
+// DerivedKeys contains the keys derived from the nonces of a secure
+// channel for one direction of the communication.
+type DerivedKeys struct {
+	Signing, Encryption, IV []byte
 }
 
-func generateKeys(hmac *HMAC, seed []byte, signingLength, encryptingLength, encryptingBlockSize int) *derivedKeys {
+// DeriveKeys derives the signing key, the encryption key and the
+// initialization vector from the secret of the HMAC and the seed with the
+// P_SHA pseudo-random function. See Part 6, 6.7.5
+func DeriveKeys(hmac *HMAC, seed []byte, signingLength, encryptingLength, encryptingBlockSize int) *DerivedKeys {
+	p := PSHA(hmac, seed, signingLength+encryptingLength+encryptingBlockSize)
+	return &DerivedKeys{
+		Signing:    p[:signingLength],
+		Encryption: p[signingLength : signingLength+encryptingLength],
+		IV:         p[signingLength+encryptingLength:],
+	}
+}
+
+// PSHA returns length bytes of the P_SHA pseudo-random function for the
+// secret of the HMAC and the seed as defined in RFC 5246, section 5:
+//
+//	A(0) = seed
+//	A(i) = HMAC(secret, A(i-1))
+//	P_SHA(secret, seed) = HMAC(secret, A(1) + seed) + HMAC(secret, A(2) + seed) + ...
+func PSHA(hmac *HMAC, seed []byte, length int) []byte {
 	var p []byte
 	a, _ := hmac.Signature(seed)
-	for len(p) < signingLength+encryptingLength+encryptingBlockSize {
-		input := append(a, seed...)
+	for len(p) < length {
+		// build the input in a new slice so that a is never modified
+		input := make([]byte, 0, len(a)+len(seed))
+		input = append(input, a...)
+		input = append(input, seed...)
 		h, _ := hmac.Signature(input)
 		p = append(p, h...)
 		a, _ = hmac.Signature(a)
 	}
-
-	return &derivedKeys{
-		signing:    p[:signingLength],
-		encryption: p[signingLength : signingLength+encryptingLength],
-		iv:         p[signingLength+encryptingLength : signingLength+encryptingLength+encryptingBlockSize],
-	}
+	return p[:length]
 }
@@ -12,6 +12,11 @@ import (
 	"github.com/zzylovesll/myOpcUa/ua"
 )
 
+// pssOptions are the options for RSA-PSS signatures. The salt length is
+// the size of the hash and MGF1 uses the same hash as the signature.
+// See Part 7, SecurityPolicy Aes256-Sha256-RsaPss
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
 type RSAPSS struct {
 	Hash       crypto.Hash
 	PublicKey  *rsa.PublicKey
@@ -31,7 +36,7 @@ func (s *RSAPSS) Signature(msg []byte) ([]byte, error) {
 	}
 	hashed := h.Sum(nil)
 
-	return rsa.SignPSS(rng, s.PrivateKey, s.Hash, hashed[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	return rsa.SignPSS(rng, s.PrivateKey, s.Hash, hashed[:], pssOptions)
 }
 
 func (s *RSAPSS) Verify(msg, signature []byte) error {
@@ -44,5 +49,5 @@ func (s *RSAPSS) Verify(msg, signature []byte) error {
 		return err
 	}
 	hashed := h.Sum(nil)
-	return rsa.VerifyPSS(s.PublicKey, s.Hash, hashed[:], signature, nil)
+	return rsa.VerifyPSS(s.PublicKey, s.Hash, hashed[:], signature, pssOptions)
 }
@@ -62,16 +62,16 @@ func newAes128Sha256RsaOaepSymmetric(localNonce []byte, remoteNonce []byte) (*En
 	localHmac := &HMAC{Hash: crypto.SHA256, Secret: localNonce}
 	remoteHmac := &HMAC{Hash: crypto.SHA256, Secret: remoteNonce}
 
-	localKeys := generateKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
-	remoteKeys := generateKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	localKeys := DeriveKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	remoteKeys := DeriveKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
 
 	return &EncryptionAlgorithm{
 		blockSize:             AESBlockSize,
 		plainttextBlockSize:   AESBlockSize - AESMinPadding,
-		encrypt:               &AES{KeyLength: 128, IV: remoteKeys.iv, Secret: remoteKeys.encryption}, // AES128-CBC
-		decrypt:               &AES{KeyLength: 128, IV: localKeys.iv, Secret: localKeys.encryption},   // AES128-CBC
-		signature:             &HMAC{Hash: crypto.SHA256, Secret: remoteKeys.signing},                 // HMAC-SHA2-256
-		verifySignature:       &HMAC{Hash: crypto.SHA256, Secret: localKeys.signing},                  // HMAC-SHA2-256
+		encrypt:               &AES{KeyLength: 128, IV: remoteKeys.IV, Secret: remoteKeys.Encryption}, // AES128-CBC
+		decrypt:               &AES{KeyLength: 128, IV: localKeys.IV, Secret: localKeys.Encryption},   // AES128-CBC
+		signature:             &HMAC{Hash: crypto.SHA256, Secret: remoteKeys.Signing},                 // HMAC-SHA2-256
+		verifySignature:       &HMAC{Hash: crypto.SHA256, Secret: localKeys.Signing},                  // HMAC-SHA2-256
 		signatureLength:       256 / 8,
 		remoteSignatureLength: 256 / 8,
 		encryptionURI:         "http://www.w3.org/2001/04/xmlenc#aes128-cbc",
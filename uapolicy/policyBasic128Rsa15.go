@@ -51,16 +51,16 @@ func newBasic128Rsa15Symmetric(localNonce []byte, remoteNonce []byte) (*Encrypti
 	localHmac := &HMAC{Hash: crypto.SHA1, Secret: localNonce}
 	remoteHmac := &HMAC{Hash: crypto.SHA1, Secret: remoteNonce}
 
-	localKeys := generateKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
-	remoteKeys := generateKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	localKeys := DeriveKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	remoteKeys := DeriveKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
 
 	return &EncryptionAlgorithm{
 		blockSize:             AESBlockSize,
 		plainttextBlockSize:   AESBlockSize - AESMinPadding,
-		encrypt:               &AES{KeyLength: 128, IV: remoteKeys.iv, Secret: remoteKeys.encryption}, // AES128-CBC
-		decrypt:               &AES{KeyLength: 128, IV: localKeys.iv, Secret: localKeys.encryption},   // AES128-CBC
-		signature:             &HMAC{Hash: crypto.SHA1, Secret: remoteKeys.signing},                   // HMAC-SHA1
-		verifySignature:       &HMAC{Hash: crypto.SHA1, Secret: localKeys.signing},                    // HMAC-SHA1
+		encrypt:               &AES{KeyLength: 128, IV: remoteKeys.IV, Secret: remoteKeys.Encryption}, // AES128-CBC
+		decrypt:               &AES{KeyLength: 128, IV: localKeys.IV, Secret: localKeys.Encryption},   // AES128-CBC
+		signature:             &HMAC{Hash: crypto.SHA1, Secret: remoteKeys.Signing},                   // HMAC-SHA1
+		verifySignature:       &HMAC{Hash: crypto.SHA1, Secret: localKeys.Signing},                    // HMAC-SHA1
 		signatureLength:       160 / 8,
 		remoteSignatureLength: 160 / 8,
 		encryptionURI:         "http://www.w3.org/2001/04/xmlenc#aes128-cbc",
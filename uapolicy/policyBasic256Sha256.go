@@ -64,16 +64,16 @@ func newBasic256Rsa256Symmetric(localNonce []byte, remoteNonce []byte) (*Encrypt
 	localHmac := &HMAC{Hash: crypto.SHA256, Secret: localNonce}
 	remoteHmac := &HMAC{Hash: crypto.SHA256, Secret: remoteNonce}
 
-	localKeys := generateKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
-	remoteKeys := generateKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	localKeys := DeriveKeys(localHmac, remoteNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
+	remoteKeys := DeriveKeys(remoteHmac, localNonce, signatureKeyLength, encryptionKeyLength, encryptionBlockSize)
 
 	return &EncryptionAlgorithm{
 		blockSize:             AESBlockSize,
 		plainttextBlockSize:   AESBlockSize - AESMinPadding,
-		encrypt:               &AES{KeyLength: 256, IV: remoteKeys.iv, Secret: remoteKeys.encryption}, // AES256-CBC
-		decrypt:               &AES{KeyLength: 256, IV: localKeys.iv, Secret: localKeys.encryption},   // AES256-CBC
-		signature:             &HMAC{Hash: crypto.SHA256, Secret: remoteKeys.signing},                 // HMAC-SHA2-256
-		verifySignature:       &HMAC{Hash: crypto.SHA256, Secret: localKeys.signing},                  // HMAC-SHA2-256
+		encrypt:               &AES{KeyLength: 256, IV: remoteKeys.IV, Secret: remoteKeys.Encryption}, // AES256-CBC
+		decrypt:               &AES{KeyLength: 256, IV: localKeys.IV, Secret: localKeys.Encryption},   // AES256-CBC
+		signature:             &HMAC{Hash: crypto.SHA256, Secret: remoteKeys.Signing},                 // HMAC-SHA2-256
+		verifySignature:       &HMAC{Hash: crypto.SHA256, Secret: localKeys.Signing},                  // HMAC-SHA2-256
 		signatureLength:       256 / 8,
 		remoteSignatureLength: 256 / 8,
 		encryptionURI:         "http://www.w3.org/2001/04/xmlenc#aes256-cbc",
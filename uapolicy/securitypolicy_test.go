@@ -9,6 +9,11 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -41,14 +46,14 @@ func TestGenerateKeysLength(t *testing.T) {
 	}
 
 	hmac := &HMAC{Hash: crypto.SHA256, Secret: remoteNonce}
-	keys := generateKeys(hmac, localNonce, 32, 32, 16)
-	if len(keys.signing) != 32 {
+	keys := DeriveKeys(hmac, localNonce, 32, 32, 16)
+	if len(keys.Signing) != 32 {
 		t.Errorf("Signing Key Invalid Length\n")
 	}
-	if len(keys.encryption) != 32 {
+	if len(keys.Encryption) != 32 {
 		t.Errorf("Encryption Key Invalid Length\n")
 	}
-	if len(keys.iv) != 16 {
+	if len(keys.IV) != 16 {
 		t.Errorf("Encryption IV Invalid Length\n")
 	}
 }
@@ -57,43 +62,336 @@ func TestGenerateKeys(t *testing.T) {
 	localNonce := []byte("\xEE\x51\x68\x84\x0E\x07\xF3\x94\x5B\x6D\xB7\x3A\x41\x3E\xC2\x5C")
 	remoteNonce := []byte("\x9B\x0F\x5B\xF8\x5E\x32\xFB\x37\x01\x43\x69\xB3\x14\xDE\x7A\xE7")
 
-	localKeys := &derivedKeys{
-		signing:    []byte("\xCB\xFB\x77\x42\x44\xB1\x03\xB3\xB5\x2C\x10\x7C\xA3\xAE\x80\xD4"),
-		encryption: []byte("\x00\x52\xB6\x82\xB2\x2C\x75\x54\x71\xDB\xF7\xC9\x8F\x88\x39\xFA"),
-		iv:         []byte("\xF8\x97\xF4\x13\xCC\xC7\xB8\x19\xE5\x45\xC7\xAE\xC3\x5D\x9D\x77"),
+	localKeys := &DerivedKeys{
+		Signing:    []byte("\xCB\xFB\x77\x42\x44\xB1\x03\xB3\xB5\x2C\x10\x7C\xA3\xAE\x80\xD4"),
+		Encryption: []byte("\x00\x52\xB6\x82\xB2\x2C\x75\x54\x71\xDB\xF7\xC9\x8F\x88\x39\xFA"),
+		IV:         []byte("\xF8\x97\xF4\x13\xCC\xC7\xB8\x19\xE5\x45\xC7\xAE\xC3\x5D\x9D\x77"),
 	}
 
-	remoteKeys := &derivedKeys{
-		signing:    []byte("\x9E\x0A\xA9\x20\xED\x7E\xC2\x18\x6D\xB8\x19\x95\x8C\xD9\x0F\xA5"),
-		encryption: []byte("\x9C\x11\xEA\x7D\xAA\xD8\x7B\xBC\x94\x47\xCB\x1C\x06\xB5\xC6\x4B"),
-		iv:         []byte("\x09\xAA\x4F\x50\x15\x4D\x69\xC5\x0B\x3B\x78\x7F\xD8\x54\x36\x45"),
+	remoteKeys := &DerivedKeys{
+		Signing:    []byte("\x9E\x0A\xA9\x20\xED\x7E\xC2\x18\x6D\xB8\x19\x95\x8C\xD9\x0F\xA5"),
+		Encryption: []byte("\x9C\x11\xEA\x7D\xAA\xD8\x7B\xBC\x94\x47\xCB\x1C\x06\xB5\xC6\x4B"),
+		IV:         []byte("\x09\xAA\x4F\x50\x15\x4D\x69\xC5\x0B\x3B\x78\x7F\xD8\x54\x36\x45"),
 	}
 
 	localHmac := &HMAC{Hash: crypto.SHA1, Secret: localNonce}
-	keys := generateKeys(localHmac, remoteNonce, 16, 16, 16)
-	if got, want := keys.signing, localKeys.signing; !bytes.Equal(got, want) {
+	keys := DeriveKeys(localHmac, remoteNonce, 16, 16, 16)
+	if got, want := keys.Signing, localKeys.Signing; !bytes.Equal(got, want) {
 		t.Errorf("local signing key generation failed:\ngot %#v want %#v\n", got, want)
 	}
-	if got, want := keys.encryption, localKeys.encryption; !bytes.Equal(got, want) {
+	if got, want := keys.Encryption, localKeys.Encryption; !bytes.Equal(got, want) {
 		t.Errorf("local encryption key generation failed:\ngot %#v want %#v\n", got, want)
 	}
-	if got, want := keys.iv, localKeys.iv; !bytes.Equal(got, want) {
+	if got, want := keys.IV, localKeys.IV; !bytes.Equal(got, want) {
 		t.Errorf("local iv key generation failed:\ngot %#v want %#v\n", got, want)
 	}
 
 	remoteHmac := &HMAC{Hash: crypto.SHA1, Secret: remoteNonce}
-	keys = generateKeys(remoteHmac, localNonce, 16, 16, 16)
-	if got, want := keys.signing, remoteKeys.signing; !bytes.Equal(got, want) {
+	keys = DeriveKeys(remoteHmac, localNonce, 16, 16, 16)
+	if got, want := keys.Signing, remoteKeys.Signing; !bytes.Equal(got, want) {
 		t.Errorf("remote signing key generation failed:\ngot %#v want %#v\n", got, want)
 	}
-	if got, want := keys.encryption, remoteKeys.encryption; !bytes.Equal(got, want) {
+	if got, want := keys.Encryption, remoteKeys.Encryption; !bytes.Equal(got, want) {
 		t.Errorf("remote encryption key generation failed:\ngot %#v want %#v\n", got, want)
 	}
-	if got, want := keys.iv, remoteKeys.iv; !bytes.Equal(got, want) {
+	if got, want := keys.IV, remoteKeys.IV; !bytes.Equal(got, want) {
 		t.Errorf("remote iv key generation failed:\ngot %#v want %#v\n", got, want)
 	}
 }
 
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestPSHA(t *testing.T) {
+	tests := []struct {
+		name   string
+		hash   crypto.Hash
+		secret []byte
+		seed   []byte
+		want   []byte
+	}{
+		{
+			// TLS 1.2 PRF test vector for P_SHA256 with the label
+			// "test label" prepended to the seed.
+			name:   "P_SHA256",
+			hash:   crypto.SHA256,
+			secret: mustHex("9bbe436ba940f017b17652849a71db35"),
+			seed:   append([]byte("test label"), mustHex("a0ba9f936cda311827a6f796ffd5198c")...),
+			want: mustHex("e3f229ba727be17b8d122620557cd453c2aab21d07c3d495329b52d4e61edb5a" +
+				"6b301791e90d35c9c9a46b4e14baf9af0fa022f7077def17abfd3797c0564bab" +
+				"4fbc91666e9def9b97fce34f796789baa48082d122ee42c5a72e5a5110fff701" +
+				"87347b66"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HMAC{Hash: tt.hash, Secret: tt.secret}
+			if got := PSHA(h, tt.seed, len(tt.want)); !bytes.Equal(got, tt.want) {
+				t.Fatalf("got %x want %x", got, tt.want)
+			}
+			// shorter outputs are a prefix of the longer ones
+			if got := PSHA(h, tt.seed, 17); !bytes.Equal(got, tt.want[:17]) {
+				t.Fatalf("got %x want %x", got, tt.want[:17])
+			}
+		})
+	}
+}
+
+// TestDeriveKeysBasic256Sha256Regression checks the keys against keys
+// which have been recorded from DeriveKeys so that they are not an
+// independent test vector. P_SHA256 is checked with the TLS 1.2 test
+// vector in TestPSHA and the keys are checked to be the consecutive
+// parts of its key stream.
+func TestDeriveKeysBasic256Sha256Regression(t *testing.T) {
+	clientNonce := mustHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	serverNonce := mustHex("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f")
+
+	// the client keys are derived with the server nonce as secret and the
+	// client nonce as seed and vice versa. See Part 6, 6.7.5
+	clientKeys := &DerivedKeys{
+		Signing:    mustHex("dd585db0c102dd1a4c1ed4dd195606dec3f7a1c789afca78f9479ed3a5d668af"),
+		Encryption: mustHex("ce49cb8f1c65a827f412c48e71c9f9cb3b5c2ee2fc2e4b3bd46d4098b5e45475"),
+		IV:         mustHex("a77832c6215b6e7ab85f2e668be7aeff"),
+	}
+	serverKeys := &DerivedKeys{
+		Signing:    mustHex("b72593c43fee5fafa0256cd6bb904ff40c066a225db95f66dd744e20858a2220"),
+		Encryption: mustHex("ddf75067e3d76ac714c08e24eabd85ff425d7f5fb25e6e083b94b174e29db89b"),
+		IV:         mustHex("c513e9172274d5ed54e52a3552901ae0"),
+	}
+
+	got := DeriveKeys(&HMAC{Hash: crypto.SHA256, Secret: serverNonce}, clientNonce, 32, 32, AESBlockSize)
+	verify.Values(t, "client keys", got, clientKeys)
+	got = DeriveKeys(&HMAC{Hash: crypto.SHA256, Secret: clientNonce}, serverNonce, 32, 32, AESBlockSize)
+	verify.Values(t, "server keys", got, serverKeys)
+
+	// the signing key, the encryption key and the IV are the consecutive
+	// parts of the key stream.
+	stream := PSHA(&HMAC{Hash: crypto.SHA256, Secret: serverNonce}, clientNonce, 32+32+AESBlockSize)
+	verify.Values(t, "client key stream", clientKeys, &DerivedKeys{
+		Signing:    stream[:32],
+		Encryption: stream[32:64],
+		IV:         stream[64:],
+	})
+
+	// the client signs and encrypts with the client keys and the server
+	// verifies and decrypts with them.
+	client, err := Symmetric(ua.SecurityPolicyURIBasic256Sha256, clientNonce, serverNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := Symmetric(ua.SecurityPolicyURIBasic256Sha256, serverNonce, clientNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("0123456789abcdef0123456789abcdef")
+	sig, err := client.Signature(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := (&HMAC{Hash: crypto.SHA256, Secret: clientKeys.Signing}).Signature(msg)
+	if !bytes.Equal(sig, want) {
+		t.Fatalf("client signature got %x want %x", sig, want)
+	}
+	if err := server.VerifySignature(msg, sig); err != nil {
+		t.Fatalf("server cannot verify client signature: %v", err)
+	}
+
+	ciphertext, err := client.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = (&AES{KeyLength: 256, IV: clientKeys.IV, Secret: clientKeys.Encryption}).Encrypt(msg)
+	if !bytes.Equal(ciphertext, want) {
+		t.Fatalf("client ciphertext got %x want %x", ciphertext, want)
+	}
+	plaintext, err := server.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, msg) {
+		t.Fatalf("server plaintext got %x want %x", plaintext, msg)
+	}
+}
+
+// The Aes policies derive their keys with P_SHA256 like Basic256Sha256
+// and are checked with the keys of TestDeriveKeysBasic256Sha256Regression.
+// Aes256Sha256RsaPss uses the same key lengths and Aes128Sha256RsaOaep
+// uses a 16 byte encryption key so that its keys are a prefix of the
+// same key stream.
+func TestDeriveKeysAes(t *testing.T) {
+	clientNonce := mustHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	serverNonce := mustHex("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f")
+	signing := mustHex("dd585db0c102dd1a4c1ed4dd195606dec3f7a1c789afca78f9479ed3a5d668af")
+	encryption := mustHex("ce49cb8f1c65a827f412c48e71c9f9cb3b5c2ee2fc2e4b3bd46d4098b5e45475")
+	iv := mustHex("a77832c6215b6e7ab85f2e668be7aeff")
+
+	tests := []struct {
+		uri string
+		enc *AES
+	}{
+		{ua.SecurityPolicyURIAes256Sha256RsaPss, &AES{KeyLength: 256, IV: iv, Secret: encryption}},
+		{ua.SecurityPolicyURIAes128Sha256RsaOaep, &AES{KeyLength: 128, IV: encryption[16:], Secret: encryption[:16]}},
+	}
+	msg := []byte("0123456789abcdef0123456789abcdef")
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			client, err := Symmetric(tt.uri, clientNonce, serverNonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := client.Signature(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := (&HMAC{Hash: crypto.SHA256, Secret: signing}).Signature(msg)
+			if !bytes.Equal(sig, want) {
+				t.Fatalf("signature got %x want %x", sig, want)
+			}
+
+			ciphertext, err := client.Encrypt(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ = tt.enc.Encrypt(msg)
+			if !bytes.Equal(ciphertext, want) {
+				t.Fatalf("ciphertext got %x want %x", ciphertext, want)
+			}
+		})
+	}
+}
+
+func TestRSAOAEPKeySizes(t *testing.T) {
+	for _, bits := range []int{2048, 3072} {
+		key, err := generatePrivateKey(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range []crypto.Hash{crypto.SHA1, crypto.SHA256} {
+			enc := &RSAOAEP{Hash: h, PublicKey: &key.PublicKey}
+			dec := &RSAOAEP{Hash: h, PrivateKey: key}
+
+			// the maximum plaintext of a block is the key size minus
+			// twice the hash size minus two.
+			block := key.PublicKey.Size() - 2*h.Size() - 2
+			for _, n := range []int{1, block - 1, block, block + 1, 3 * block} {
+				msg := make([]byte, n)
+				if _, err := rand.Read(msg); err != nil {
+					t.Fatal(err)
+				}
+				c, err := enc.Encrypt(msg)
+				if err != nil {
+					t.Fatalf("%d bits %s %d bytes: encrypt: %v", bits, h, n, err)
+				}
+				if got, want := len(c), ((n+block-1)/block)*key.PublicKey.Size(); got != want {
+					t.Fatalf("%d bits %s %d bytes: got %d ciphertext bytes want %d", bits, h, n, got, want)
+				}
+				p, err := dec.Decrypt(c)
+				if err != nil {
+					t.Fatalf("%d bits %s %d bytes: decrypt: %v", bits, h, n, err)
+				}
+				if !bytes.Equal(p, msg) {
+					t.Fatalf("%d bits %s %d bytes: plaintext mismatch", bits, h, n)
+				}
+			}
+		}
+	}
+}
+
+func TestRSAPSS(t *testing.T) {
+	key, err := generatePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pss := &RSAPSS{Hash: crypto.SHA256, PrivateKey: key, PublicKey: &key.PublicKey}
+	msg := []byte("hello world")
+
+	sig, err := pss.Signature(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pss.Verify(msg, sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := pss.Verify([]byte("hello World"), sig); err == nil {
+		t.Fatal("signature of a different message accepted")
+	}
+
+	// signatures with a salt length other than the hash size are
+	// rejected.
+	h := crypto.SHA256.New()
+	h.Write(msg)
+	sig, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, h.Sum(nil), &rsa.PSSOptions{SaltLength: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pss.Verify(msg, sig); err == nil {
+		t.Fatal("signature with salt length 20 accepted")
+	}
+}
+
+// TestAsymmetricReferenceVectors checks the asymmetric algorithms of the
+// Aes policies with a signature and ciphertexts which have been created
+// with OpenSSL by testdata/rsa/generate.sh.
+func TestAsymmetricReferenceVectors(t *testing.T) {
+	read := func(name string) []byte {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "rsa", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	block, _ := pem.Decode(read("key.pem"))
+	if block == nil {
+		t.Fatal("no key in key.pem")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := k.(*rsa.PrivateKey)
+	msg := read("message.txt")
+
+	tests := []struct {
+		uri        string
+		signature  []byte
+		ciphertext []byte
+	}{
+		// RSA-PSS-SHA2-256 and RSA-OAEP-SHA2-256
+		{ua.SecurityPolicyURIAes256Sha256RsaPss, read("pss-sha256.sig"), read("oaep-sha256.bin")},
+		// RSA-OAEP with SHA1
+		{ua.SecurityPolicyURIAes128Sha256RsaOaep, nil, read("oaep-sha1.bin")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			algo, err := Asymmetric(tt.uri, key, &key.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.signature != nil {
+				if err := algo.VerifySignature(msg, tt.signature); err != nil {
+					t.Fatalf("reference signature rejected: %v", err)
+				}
+				if err := algo.VerifySignature(append(msg, '.'), tt.signature); err == nil {
+					t.Fatal("reference signature accepted for a different message")
+				}
+			}
+			got, err := algo.Decrypt(tt.ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, msg) {
+				t.Fatalf("got plaintext %q want %q", got, msg)
+			}
+		})
+	}
+}
+
 // Test all supported encryption algorithms.  Because the majority of the algorithms
 // use randomization, the ciphertext will be different on every run even if we used
 // the same keys.  This makes testing against known byte slices impossible.
@@ -0,0 +1,23 @@
+#!/bin/sh
+# Generates the RSA reference vectors of TestAsymmetricReferenceVectors
+# with OpenSSL. The key is a test key which is only generated if it does
+# not exist.
+set -e
+
+[ -f key.pem ] || openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out key.pem
+printf 'OPC UA asymmetric reference vector' > message.txt
+
+# RSA-PSS-SHA2-256 with MGF1-SHA2-256 and a salt of 32 bytes
+openssl dgst -sha256 -sign key.pem \
+	-sigopt rsa_padding_mode:pss -sigopt rsa_pss_saltlen:32 -sigopt rsa_mgf1_md:sha256 \
+	-out pss-sha256.sig message.txt
+
+# RSA-OAEP-SHA2-256 with MGF1-SHA2-256
+openssl pkeyutl -encrypt -inkey key.pem \
+	-pkeyopt rsa_padding_mode:oaep -pkeyopt rsa_oaep_md:sha256 -pkeyopt rsa_mgf1_md:sha256 \
+	-in message.txt -out oaep-sha256.bin
+
+# RSA-OAEP with SHA1
+openssl pkeyutl -encrypt -inkey key.pem \
+	-pkeyopt rsa_padding_mode:oaep -pkeyopt rsa_oaep_md:sha1 -pkeyopt rsa_mgf1_md:sha1 \
+	-in message.txt -out oaep-sha1.bin
@@ -8,6 +8,7 @@ import (
 	"crypto/rsa"
 	"time"
 
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/ua"
 )
 
@@ -65,6 +66,13 @@ type Config struct {
 	// ignored if AutoReconnect is set to false.
 	ReconnectInterval time.Duration
 
+	// ReconnectMaxInterval enables an exponential backoff for the reconnection
+	// attempts. The interval between two attempts starts with ReconnectInterval
+	// and is doubled after every failed attempt up to ReconnectMaxInterval.
+	// If ReconnectMaxInterval is not larger than ReconnectInterval then every
+	// attempt is made after ReconnectInterval.
+	ReconnectMaxInterval time.Duration
+
 	// Lifetime is the requested lifetime, in milliseconds, for the new SecurityToken when the
 	// SecureChannel works as client. It specifies when the Client expects to renew the SecureChannel
 	// by calling the OpenSecureChannel Service again. If a SecureChannel is not renewed, then all
@@ -76,6 +84,37 @@ type Config struct {
 	// RequestTimeout is timeout duration for all synchronous requests over SecureChannel.
 	// If the Server doesn't respond within RequestTimeout time, Client returns StatusBadTimeout
 	RequestTimeout time.Duration
+
+	// ReturnDiagnostics is the ReturnDiagnostics mask of the RequestHeader
+	// which selects the diagnostic infos the server returns in the
+	// responses. See the ua.ServiceLevel* and ua.OperationLevel* flags.
+	ReturnDiagnostics uint32
+
+	// DecodeLimits are the limits for decoding the responses. The zero
+	// fields use the value of ua.DefaultDecodeLimits.
+	DecodeLimits ua.DecodeLimits
+
+	// Namespaces returns the namespace array of the server which
+	// resolves the types of the extension objects in the responses that
+	// are registered with ua.RegisterExtensionObjectURI. It may be nil.
+	Namespaces func() []string
+
+	// RenewalFunc is called after every attempt to renew the SecurityToken
+	// of the SecureChannel. It is called synchronously and must not block.
+	RenewalFunc func(RenewalEvent)
+
+	// RequestTracer is called after every request with the request, the
+	// response and the duration of the request. It is called
+	// synchronously from the caller of the request and must not block.
+	RequestTracer func(RequestInfo)
+
+	// Tracer starts a span for every request which is a child of the
+	// span in the context of the request.
+	Tracer Tracer
+
+	// Logger logs the events of the SecureChannel, e.g. when it is opened
+	// or its security token is renewed. Defaults to logger.Default.
+	Logger logger.Logger
 }
 
 // SessionConfig is a set of common configurations used in Session.
@@ -144,4 +183,13 @@ type SessionConfig struct {
 	// PolicyURI to use when encrypting secrets for the User Identity Token
 	// Could be different from the secure channel's policy
 	AuthPolicyURI string
+
+	// AuthIssuedToken is the unencrypted token data of an
+	// IssuedIdentityToken, e.g. a JWT.
+	AuthIssuedToken []byte
+
+	// AuthPrivateKey is the private key of the user certificate of an
+	// X509IdentityToken which creates the UserTokenSignature. The key
+	// of the application instance certificate is used if it is nil.
+	AuthPrivateKey *rsa.PrivateKey
 }
@@ -47,6 +47,10 @@ func (m *MessageHeader) Decode(b []byte) (int, error) {
 type MessageChunk struct {
 	*MessageHeader
 	Data []byte
+
+	// buf is the receive buffer of the chunk which is returned to the
+	// buffer pool of the secure channel after the message was decoded.
+	buf []byte
 }
 
 func (m *MessageChunk) Decode(b []byte) (int, error) {
@@ -120,6 +124,12 @@ func (m *Message) Encode() ([]byte, error) {
 	return chunks[0], nil
 }
 
+// chunkHeaderSize is the size of the message header, the symmetric
+// security header and the sequence header of a MSG or CLO chunk.
+const chunkHeaderSize = 24
+
+// EncodeChunks encodes the message into chunks whose body is at most
+// maxBodySize bytes. OpenSecureChannel messages are not split.
 func (m *Message) EncodeChunks(maxBodySize uint32) ([][]byte, error) {
 	dataBody := ua.NewBuffer(nil)
 	dataBody.WriteStruct(m.TypeID)
@@ -129,9 +139,6 @@ func (m *Message) EncodeChunks(maxBodySize uint32) ([][]byte, error) {
 		return nil, dataBody.Error()
 	}
 
-	nrChunks := uint32(dataBody.Len())/(maxBodySize) + 1
-	chunks := make([][]byte, nrChunks)
-
 	switch m.Header.MessageType {
 	case "OPN":
 		partialHeader := ua.NewBuffer(nil)
@@ -151,9 +158,19 @@ func (m *Message) EncodeChunks(maxBodySize uint32) ([][]byte, error) {
 		return [][]byte{buf.Bytes()}, buf.Error()
 
 	case "CLO", "MSG":
+		if maxBodySize == 0 {
+			return nil, errors.Errorf("chunk size too small")
+		}
+
+		// the final chunk contains at least one byte of the body
+		nrChunks := (uint32(dataBody.Len()) + maxBodySize - 1) / maxBodySize
+		if nrChunks == 0 {
+			nrChunks = 1
+		}
+		chunks := make([][]byte, nrChunks)
 
 		for i := uint32(0); i < nrChunks-1; i++ {
-			m.Header.MessageSize = maxBodySize + 24
+			m.Header.MessageSize = maxBodySize + chunkHeaderSize
 			m.Header.ChunkType = ChunkTypeIntermediate
 			chunk := ua.NewBuffer(nil)
 			chunk.WriteStruct(m.Header)
@@ -168,7 +185,7 @@ func (m *Message) EncodeChunks(maxBodySize uint32) ([][]byte, error) {
 		}
 
 		m.Header.ChunkType = ChunkTypeFinal
-		m.Header.MessageSize = uint32(24 + dataBody.Len())
+		m.Header.MessageSize = uint32(chunkHeaderSize + dataBody.Len())
 		chunk := ua.NewBuffer(nil)
 		chunk.WriteStruct(m.Header)
 		chunk.WriteStruct(m.SymmetricSecurityHeader)
@@ -184,3 +201,27 @@ func (m *Message) EncodeChunks(maxBodySize uint32) ([][]byte, error) {
 		return nil, errors.Errorf("invalid message type %q", m.Header.MessageType)
 	}
 }
+
+// EncodeAbort encodes the final chunk of a message which has been sent
+// only partially. The receiver discards the chunks of the message which
+// it has received so far.
+//
+// Specification: Part 6, 6.7.3
+func (m *Message) EncodeAbort(code ua.StatusCode, reason string) ([]byte, error) {
+	if m.Header.MessageType != MessageTypeMessage {
+		return nil, errors.Errorf("invalid message type %q", m.Header.MessageType)
+	}
+	body, err := (&MessageAbort{ErrorCode: uint32(code), Reason: reason}).Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	m.Header.ChunkType = ChunkTypeError
+	m.Header.MessageSize = uint32(24 + len(body))
+	chunk := ua.NewBuffer(nil)
+	chunk.WriteStruct(m.Header)
+	chunk.WriteStruct(m.SymmetricSecurityHeader)
+	chunk.WriteStruct(m.SequenceHeader)
+	chunk.Write(body)
+	return chunk.Bytes(), chunk.Error()
+}
@@ -5,6 +5,9 @@
 package uasc
 
 import (
+	"bytes"
+	"encoding/binary"
+	"math"
 	"testing"
 	"time"
 
@@ -25,7 +28,6 @@ func TestMessage(t *testing.T) {
 				}
 				instance := &channelInstance{
 					sc:              s,
-					sequenceNumber:  0,
 					securityTokenID: 0,
 				}
 				m := instance.newMessage(
@@ -124,7 +126,6 @@ func TestMessage(t *testing.T) {
 				}
 				instance := &channelInstance{
 					sc:              s,
-					sequenceNumber:  0,
 					securityTokenID: 0,
 				}
 				m := instance.newMessage(
@@ -195,7 +196,6 @@ func TestMessage(t *testing.T) {
 				}
 				instance := &channelInstance{
 					sc:              s,
-					sequenceNumber:  0,
 					securityTokenID: 0,
 				}
 				m := instance.newMessage(
@@ -248,3 +248,73 @@ func TestMessage(t *testing.T) {
 	}
 	RunCodecTest(t, cases)
 }
+
+func TestEncodeChunks(t *testing.T) {
+	newMessage := func() *Message {
+		return &Message{
+			MessageHeader: &MessageHeader{
+				Header:                  NewHeader(MessageTypeMessage, ChunkTypeFinal, 1),
+				SymmetricSecurityHeader: NewSymmetricSecurityHeader(1),
+				SequenceHeader:          NewSequenceHeader(1, 1),
+			},
+			TypeID: ua.NewFourByteExpandedNodeID(0, id.WriteRequest_Encoding_DefaultBinary),
+			Service: &ua.WriteRequest{
+				RequestHeader: &ua.RequestHeader{AuthenticationToken: ua.NewTwoByteNodeID(0), AdditionalHeader: ua.NewExtensionObject(nil)},
+				NodesToWrite: []*ua.WriteValue{{
+					NodeID: ua.NewNumericNodeID(1, 2),
+					Value:  &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(make([]byte, 100))},
+				}},
+			},
+		}
+	}
+
+	single, err := newMessage().EncodeChunks(math.MaxUint32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := single[0][chunkHeaderSize:]
+
+	tests := []struct {
+		maxBodySize uint32
+		chunks      int
+	}{
+		{uint32(len(body)), 1},
+		{uint32(len(body)) - 1, 2},
+		{uint32(len(body)) / 2, 2},
+		{32, (len(body) + 31) / 32},
+		{1, len(body)},
+	}
+	for _, tt := range tests {
+		chunks, err := newMessage().EncodeChunks(tt.maxBodySize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(chunks), tt.chunks; got != want {
+			t.Fatalf("max body size %d: got %d chunks want %d", tt.maxBodySize, got, want)
+		}
+		var got []byte
+		for i, c := range chunks {
+			want := byte(ChunkTypeIntermediate)
+			if i == len(chunks)-1 {
+				want = ChunkTypeFinal
+			}
+			if c[3] != want {
+				t.Fatalf("max body size %d: chunk %d has type %c want %c", tt.maxBodySize, i, c[3], want)
+			}
+			if size := binary.LittleEndian.Uint32(c[4:]); int(size) != len(c) {
+				t.Fatalf("max body size %d: chunk %d has size %d want %d", tt.maxBodySize, i, size, len(c))
+			}
+			if len(c)-chunkHeaderSize > int(tt.maxBodySize) {
+				t.Fatalf("max body size %d: chunk %d has %d bytes", tt.maxBodySize, i, len(c)-chunkHeaderSize)
+			}
+			got = append(got, c[chunkHeaderSize:]...)
+		}
+		if !bytes.Equal(got, body) {
+			t.Fatalf("max body size %d: got body %x want %x", tt.maxBodySize, got, body)
+		}
+	}
+
+	if _, err := newMessage().EncodeChunks(0); err == nil {
+		t.Fatal("got no error for a max body size of 0")
+	}
+}
@@ -11,12 +11,13 @@ import (
 	"encoding/binary"
 	"io"
 	"math"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/zzylovesll/myOpcUa/debug"
 	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/logger"
 	"github.com/zzylovesll/myOpcUa/ua"
 	"github.com/zzylovesll/myOpcUa/uacp"
 	"github.com/zzylovesll/myOpcUa/uapolicy"
@@ -27,11 +28,71 @@ const (
 	MaxTimeout      = math.MaxUint32 * time.Millisecond
 )
 
+// https://reference.opcfoundation.org/v104/Core/docs/Part4/5.5.2/#5.5.2.1
+const (
+	// RenewAfter is the fraction of the lifetime of a security token
+	// after which the channel is renewed. Clients should request a new
+	// SecurityToken after 75 % of its lifetime has elapsed. This should
+	// ensure that clients will receive the new SecurityToken before the
+	// old one actually expires.
+	RenewAfter = 0.75
+
+	// ExpireAfter is the fraction of the lifetime of a security token
+	// after which messages secured with it are rejected. Clients should
+	// accept Messages secured by an expired SecurityToken for up to 25 %
+	// of the token lifetime.
+	ExpireAfter = 1.25
+)
+
+// renewalDelay returns the time after which a security token with the
+// given lifetime is renewed.
+func renewalDelay(lifetime time.Duration) time.Duration {
+	return time.Duration(float64(lifetime) * RenewAfter)
+}
+
+// expirationDelay returns the time after which a security token with
+// the given lifetime expires.
+func expirationDelay(lifetime time.Duration) time.Duration {
+	return time.Duration(float64(lifetime) * ExpireAfter)
+}
+
+// renewalRetries is the number of times a failed renewal is retried
+// before the security token expires.
+const renewalRetries = 3
+
+// renewalRetryDelay returns the time between two attempts to renew a
+// security token with the given lifetime. The retries are spread over
+// the remaining lifetime of the token after the first attempt.
+func renewalRetryDelay(lifetime time.Duration) time.Duration {
+	return (lifetime - renewalDelay(lifetime)) / (renewalRetries + 1)
+}
+
+// RenewalEvent describes an attempt to renew the security token of a
+// secure channel.
+type RenewalEvent struct {
+	// ChannelID is the id of the secure channel.
+	ChannelID uint32
+
+	// TokenID is the id of the new security token or 0 if the renewal
+	// failed.
+	TokenID uint32
+
+	// Lifetime is the lifetime of the new security token.
+	Lifetime time.Duration
+
+	// Err is the error of a failed renewal. A failed renewal is retried
+	// until the current security token expires.
+	Err error
+}
+
 type response struct {
 	ReqID uint32
 	SCID  uint32
 	V     interface{}
 	Err   error
+
+	// Size is the number of bytes of the chunks of the response.
+	Size int
 }
 
 type conditionLocker struct {
@@ -90,6 +151,14 @@ type SecureChannel struct {
 	requestID   uint32
 	requestIDMu sync.Mutex
 
+	// sequenceNumber is the sequence number of the last sent chunk. It is
+	// shared between all security tokens of the channel.
+	sequenceNumber uint32
+
+	// sendMu ensures that the chunks of a message are sent with
+	// consecutive sequence numbers.
+	sendMu sync.Mutex
+
 	// instances maps secure channel IDs to a list to channel states
 	instances      map[uint32][]*channelInstance
 	activeInstance *channelInstance
@@ -108,6 +177,11 @@ type SecureChannel struct {
 	chunks   map[uint32][]*MessageChunk
 	chunksMu sync.Mutex
 
+	// buffers is the pool of the buffers for receiving and merging
+	// chunks. The decoded messages do not reference the buffers so
+	// that they can be reused as soon as a message has been decoded.
+	buffers sync.Pool
+
 	// openingInstance is a temporary var that allows the dispatcher know how to handle a open channel request
 	// note: we only allow a single "open" request in flight at any point in time. The mutex is held for the entire
 	// duration of the "open" request.
@@ -117,6 +191,9 @@ type SecureChannel struct {
 	// errorCh receive dispatcher errors
 	errCh chan<- error
 
+	// log logs the events of the channel with the id of the connection.
+	log logger.Logger
+
 	closeOnce sync.Once
 }
 
@@ -157,6 +234,7 @@ func NewSecureChannel(endpoint string, c *uacp.Conn, cfg *Config, errCh chan<- e
 		instances:    make(map[uint32][]*channelInstance),
 		chunks:       make(map[uint32][]*MessageChunk),
 		handlers:     make(map[uint32]chan *response),
+		log:          logger.With(cfg.Logger, "conn_id", c.ID()),
 	}
 
 	return s, nil
@@ -171,6 +249,29 @@ func (s *SecureChannel) getActiveChannelInstance() (*channelInstance, error) {
 	return s.activeInstance, nil
 }
 
+// Lifetime returns the lifetime of the current security token. It is
+// the lifetime granted by the server or the requested lifetime if that
+// is shorter. The channel is renewed after RenewAfter of the lifetime.
+func (s *SecureChannel) Lifetime() time.Duration {
+	s.instancesMu.Lock()
+	defer s.instancesMu.Unlock()
+	if s.activeInstance == nil {
+		return 0
+	}
+	return s.activeInstance.revisedLifetime
+}
+
+// TokenID returns the id of the current security token. The id changes
+// when the channel is renewed.
+func (s *SecureChannel) TokenID() uint32 {
+	s.instancesMu.Lock()
+	defer s.instancesMu.Unlock()
+	if s.activeInstance == nil {
+		return 0
+	}
+	return s.activeInstance.securityTokenID
+}
+
 func (s *SecureChannel) dispatcher() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -203,15 +304,17 @@ func (s *SecureChannel) dispatcher() {
 			}
 
 			if resp.Err != nil {
-				debug.Printf("uasc %d/%d: err: %v", s.c.ID(), resp.ReqID, resp.Err)
+				s.log.Debug("uasc: recv error", "request_id", resp.ReqID, "err", resp.Err)
 			} else {
-				debug.Printf("uasc %d/%d: recv %T", s.c.ID(), resp.ReqID, resp.V)
+				s.log.Debug("uasc: recv", "request_id", resp.ReqID, "type", reflect.TypeOf(resp.V))
 			}
 
 			ch, ok := s.popHandler(resp.ReqID)
 
 			if !ok {
-				debug.Printf("uasc %d/%d: no handler for %T", s.c.ID(), resp.ReqID, resp.V)
+				// the caller of a cancelled or timed out request
+				// no longer waits for the response.
+				s.log.Debug("uasc: no handler. dropping response", "request_id", resp.ReqID, "type", reflect.TypeOf(resp.V))
 				continue
 			}
 
@@ -220,12 +323,12 @@ func (s *SecureChannel) dispatcher() {
 				s.rcvLocker.lock()
 			}
 
-			debug.Printf("uasc %d/%d: sending %T to handler", s.c.ID(), resp.ReqID, resp.V)
+			s.log.Debug("uasc: sending response to handler", "request_id", resp.ReqID, "type", reflect.TypeOf(resp.V))
 			select {
 			case ch <- resp:
 			default:
 				// this should never happen since the chan is of size one
-				debug.Printf("uasc %d/%d: unexpected state. channel write should always succeed.", s.c.ID(), resp.ReqID)
+				s.log.Error("uasc: unexpected state. channel write should always succeed", "request_id", resp.ReqID)
 			}
 
 			s.rcvLocker.waitIfLock()
@@ -245,7 +348,7 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 		default:
 			chunk, err := s.readChunk()
 			if err == io.EOF {
-				debug.Printf("uasc %d: readChunk EOF", s.c.ID())
+				s.log.Debug("uasc: readChunk EOF")
 				return &response{Err: err}
 			}
 
@@ -261,7 +364,7 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 				SCID:  chunk.MessageHeader.Header.SecureChannelID,
 			}
 
-			debug.Printf("uasc %d/%d: recv %s%c with %d bytes", s.c.ID(), reqID, hdr.MessageType, hdr.ChunkType, hdr.MessageSize)
+			s.log.Debug("uasc: recv chunk", "request_id", reqID, "type", hdr.MessageType, "chunk", string(hdr.ChunkType), "size", hdr.MessageSize)
 
 			s.chunksMu.Lock()
 
@@ -272,7 +375,7 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 
 				msga := new(MessageAbort)
 				if _, err := msga.Decode(chunk.Data); err != nil {
-					debug.Printf("uasc %d/%d: invalid MSGA chunk. %s", s.c.ID(), reqID, err)
+					s.log.Warn("uasc: invalid MSGA chunk", "request_id", reqID, "err", err)
 					resp.Err = ua.StatusBadDecodingError
 					return resp
 				}
@@ -281,10 +384,10 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 
 			case 'C':
 				s.chunks[reqID] = append(s.chunks[reqID], chunk)
-				if n := len(s.chunks[reqID]); uint32(n) > s.c.MaxChunkCount() {
+				if n, max := len(s.chunks[reqID]), s.c.MaxChunkCount(); max > 0 && uint32(n) > max {
 					delete(s.chunks, reqID)
 					s.chunksMu.Unlock()
-					resp.Err = errors.Errorf("too many chunks: %d > %d", n, s.c.MaxChunkCount())
+					resp.Err = errors.Wrapf(ua.StatusBadResponseTooLarge, "too many chunks: %d > %d", n, max)
 					return resp
 				}
 				s.chunksMu.Unlock()
@@ -297,14 +400,18 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 
 			s.chunksMu.Unlock()
 
-			b, err := mergeChunks(all)
+			for _, c := range all {
+				resp.Size += int(c.Header.MessageSize)
+			}
+
+			b, merged, err := s.mergeChunks(all)
 			if err != nil {
 				resp.Err = err
 				return resp
 			}
 
-			if uint32(len(b)) > s.c.MaxMessageSize() {
-				resp.Err = errors.Errorf("message too large: %d > %d", uint32(len(b)), s.c.MaxMessageSize())
+			if max := s.c.MaxMessageSize(); max > 0 && uint32(len(b)) > max {
+				resp.Err = errors.Wrapf(ua.StatusBadResponseTooLarge, "message too large: %d > %d", len(b), max)
 				return resp
 			}
 
@@ -315,8 +422,14 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 			// and subsequently remove it and the TypeID from all service
 			// structs and tests. We also need to add a deadline to all
 			// handlers and check them periodically to time them out.
-			_, svc, err := ua.DecodeService(b)
+			var namespaces []string
+			if s.cfg.Namespaces != nil {
+				namespaces = s.cfg.Namespaces()
+			}
+			typeID, svc, err := ua.DecodeServiceWithNamespaces(b, &s.cfg.DecodeLimits, namespaces)
+			s.releaseChunks(all, merged)
 			if err != nil {
+				s.log.Warn("uasc: cannot decode response", "request_id", reqID, "type_id", typeID, "err", err)
 				resp.Err = err
 				return resp
 			}
@@ -339,7 +452,8 @@ func (s *SecureChannel) receive(ctx context.Context) *response {
 
 func (s *SecureChannel) readChunk() (*MessageChunk, error) {
 	// read a full message from the underlying conn.
-	b, err := s.c.Receive()
+	buf := s.getBuffer(int(s.c.ReceiveBufSize()))
+	b, err := s.c.ReceiveBuf(buf)
 	if err == io.EOF || len(b) == 0 {
 		return nil, io.EOF
 	}
@@ -379,7 +493,7 @@ func (s *SecureChannel) readChunk() (*MessageChunk, error) {
 
 		if m.SecurityPolicyURI != ua.SecurityPolicyURINone {
 			s.cfg.RemoteCertificate = m.AsymmetricSecurityHeader.SenderCertificate
-			debug.Printf("uasc %d: setting securityPolicy to %s", s.c.ID(), m.SecurityPolicyURI)
+			s.log.Debug("uasc: setting security policy", "policy", m.SecurityPolicyURI)
 		}
 
 		s.cfg.SecurityPolicyURI = m.SecurityPolicyURI
@@ -404,6 +518,7 @@ func (s *SecureChannel) readChunk() (*MessageChunk, error) {
 		return nil, errors.Errorf("sechan: decode sequence header failed: %s", err)
 	}
 	m.Data = m.Data[n:]
+	m.buf = buf
 
 	return m, nil
 }
@@ -420,6 +535,17 @@ func (s *SecureChannel) verifyAndDecrypt(m *MessageChunk, b []byte, instance *ch
 		return nil, errors.Errorf("sechan: unable to find instance for SecureChannelID=%d", m.MessageHeader.SecureChannelID)
 	}
 
+	// use the security token the message was secured with. Responses to
+	// requests which were sent before a renewal can still use the old
+	// token until it expires.
+	if m.SymmetricSecurityHeader != nil {
+		for _, instance := range instances {
+			if instance.securityTokenID == m.SymmetricSecurityHeader.TokenID {
+				return instance.verifyAndDecrypt(m, b)
+			}
+		}
+	}
+
 	var (
 		err      error
 		verified []byte
@@ -429,7 +555,7 @@ func (s *SecureChannel) verifyAndDecrypt(m *MessageChunk, b []byte, instance *ch
 		if verified, err = instances[i].verifyAndDecrypt(m, b); err == nil {
 			return verified, nil
 		}
-		debug.Printf("uasc %d: attempting an older channel state...", s.c.ID())
+		s.log.Debug("uasc: attempting an older channel state")
 	}
 
 	return nil, err
@@ -448,7 +574,7 @@ func (s *SecureChannel) getInstancesBySecureChannelID(id uint32) []*channelInsta
 	cpy := make([]*channelInstance, len(instances))
 	copy(cpy, instances)
 
-	return instances
+	return cpy
 }
 
 func (s *SecureChannel) LocalEndpoint() string {
@@ -506,25 +632,22 @@ func (s *SecureChannel) open(ctx context.Context, instance *channelInstance, req
 	s.openingInstance = newChannelInstance(s)
 
 	if requestType == ua.SecurityTokenRequestTypeRenew {
-		// TODO: lock? sequenceNumber++?
-		// this seems racy. if another request goes out while the other open request is in flight then won't an error
-		// be raised on the server? can the sequenceNumber be as "global" as the request ID?
-		s.openingInstance.sequenceNumber = instance.sequenceNumber
 		s.openingInstance.secureChannelID = instance.secureChannelID
 	}
 
 	// trigger cleanup after we are all done
 	defer func() {
 		if s.openingInstance == nil || s.openingInstance.state != channelActive {
-			debug.Printf("uasc %d: failed to open a new secure channel", s.c.ID())
+			s.log.Warn("uasc: failed to open a new secure channel")
 		}
 		s.openingInstance = nil
 	}()
 
 	reqID := s.nextRequestID()
 
+	// OpenSecureChannel messages are encrypted in all security modes.
 	s.openingInstance.algo = algo
-	s.openingInstance.SetMaximumBodySize(int(s.c.SendBufSize()))
+	s.openingInstance.maxBodySize = MaxBodySize(algo, int(s.c.SendBufSize()), true)
 
 	localNonce, err := algo.MakeNonce()
 	if err != nil {
@@ -574,9 +697,20 @@ func (s *SecureChannel) handleOpenSecureChannelResponse(resp *ua.OpenSecureChann
 		s.openingInstance,
 	)
 
+	msg := "uasc: secure channel opened"
+	if s.activeInstance != nil {
+		msg = "uasc: security token renewed"
+	}
 	s.activeInstance = instance
 
-	debug.Printf("uasc %d: received security token. channelID=%d tokenID=%d createdAt=%s lifetime=%s", s.c.ID(), instance.secureChannelID, instance.securityTokenID, instance.createdAt.Format(time.RFC3339), instance.revisedLifetime)
+	s.log.Info(msg,
+		"channel_id", instance.secureChannelID,
+		"token_id", instance.securityTokenID,
+		"created_at", instance.createdAt.Format(time.RFC3339),
+		"lifetime", instance.revisedLifetime,
+		"policy", s.cfg.SecurityPolicyURI,
+		"mode", s.cfg.SecurityMode,
+	)
 
 	go s.scheduleRenewal(instance)
 	go s.scheduleExpiration(instance)
@@ -585,45 +719,77 @@ func (s *SecureChannel) handleOpenSecureChannelResponse(resp *ua.OpenSecureChann
 }
 
 func (s *SecureChannel) scheduleRenewal(instance *channelInstance) {
-	// https://reference.opcfoundation.org/v104/Core/docs/Part4/5.5.2/#5.5.2.1
-	// Clients should request a new SecurityToken after 75 % of its lifetime has elapsed. This should ensure that
-	// clients will receive the new SecurityToken before the old one actually expire
-	const renewAfter = 0.75
-	when := time.Second * time.Duration(instance.revisedLifetime.Seconds()*renewAfter)
+	when := renewalDelay(instance.revisedLifetime)
 
-	debug.Printf("uasc %d: security token is refreshed at %s (%s). channelID=%d tokenID=%d", s.c.ID(), time.Now().UTC().Add(when).Format(time.RFC3339), when, instance.secureChannelID, instance.securityTokenID)
+	s.log.Debug("uasc: scheduled security token renewal",
+		"at", time.Now().UTC().Add(when).Format(time.RFC3339),
+		"in", when,
+		"channel_id", instance.secureChannelID,
+		"token_id", instance.securityTokenID,
+	)
 
 	t := time.NewTimer(when)
 	defer t.Stop()
 
+	// a failed renewal is retried until the security token expires.
+	// Only then the channel is considered broken.
+	var err error
+	for i := 0; i <= renewalRetries; i++ {
+		select {
+		case <-s.closing:
+			return
+		case <-t.C:
+		}
+
+		// the token has already been renewed with Renew
+		if active, _ := s.getActiveChannelInstance(); active != instance {
+			return
+		}
+
+		if err = s.renew(context.Background(), instance); err == nil {
+			return
+		}
+		s.log.Warn("uasc: renewing security token failed", "channel_id", instance.secureChannelID, "token_id", instance.securityTokenID, "err", err)
+		t.Reset(renewalRetryDelay(instance.revisedLifetime))
+	}
+
 	select {
 	case <-s.closing:
-		return
-	case <-t.C:
+	case s.errCh <- err:
+	default:
 	}
-
-	// TODO: where should this error go?
-	_ = s.renew(instance)
 }
 
-func (s *SecureChannel) renew(instance *channelInstance) error {
+func (s *SecureChannel) renew(ctx context.Context, instance *channelInstance) error {
 	// lock ensure no one else renews this at the same time
 	s.reqLocker.lock()
 	defer s.reqLocker.unlock()
 	s.pendingReq.Wait()
-	instance.Lock()
-	defer instance.Unlock()
 
-	return s.open(context.Background(), instance, ua.SecurityTokenRequestTypeRenew)
+	err := s.open(ctx, instance, ua.SecurityTokenRequestTypeRenew)
+
+	if f := s.cfg.RenewalFunc; f != nil {
+		ev := RenewalEvent{ChannelID: instance.secureChannelID, Err: err}
+		if err == nil {
+			if active, err := s.getActiveChannelInstance(); err == nil {
+				ev.TokenID, ev.Lifetime = active.securityTokenID, active.revisedLifetime
+			}
+		}
+		f(ev)
+	}
+	return err
 }
 
 func (s *SecureChannel) scheduleExpiration(instance *channelInstance) {
-	// https://reference.opcfoundation.org/v104/Core/docs/Part4/5.5.2/#5.5.2.1
-	// Clients should accept Messages secured by an expired SecurityToken for up to 25 % of the token lifetime.
-	const expireAfter = 1.25
-	when := instance.createdAt.Add(time.Second * time.Duration(instance.revisedLifetime.Seconds()*expireAfter))
-
-	debug.Printf("uasc %d: security token expires at %s. channelID=%d tokenID=%d", s.c.ID(), when.UTC().Format(time.RFC3339), instance.secureChannelID, instance.securityTokenID)
+	// use the local time instead of the server time of the security
+	// token since the clocks of the client and the server can differ.
+	when := time.Now().Add(expirationDelay(instance.revisedLifetime))
+
+	s.log.Debug("uasc: scheduled security token expiration",
+		"at", when.UTC().Format(time.RFC3339),
+		"channel_id", instance.secureChannelID,
+		"token_id", instance.securityTokenID,
+	)
 
 	t := time.NewTimer(time.Until(when))
 	defer t.Stop()
@@ -637,20 +803,26 @@ func (s *SecureChannel) scheduleExpiration(instance *channelInstance) {
 	s.instancesMu.Lock()
 	defer s.instancesMu.Unlock()
 
-	oldInstances := s.instances[instance.securityTokenID]
+	// keep the token if it could not be renewed. The channel is
+	// closed in that case.
+	if instance == s.activeInstance {
+		return
+	}
 
-	s.instances[instance.securityTokenID] = []*channelInstance{}
+	oldInstances := s.instances[instance.secureChannelID]
+
+	s.instances[instance.secureChannelID] = []*channelInstance{}
 
 	for _, oldInstance := range oldInstances {
 		if oldInstance.secureChannelID != instance.secureChannelID {
 			// something has gone horribly wrong!
-			debug.Printf("uasc %d: secureChannelID mismatch during scheduleExpiration!", s.c.ID())
+			s.log.Error("uasc: secure channel id mismatch during scheduleExpiration", "channel_id", instance.secureChannelID, "other_channel_id", oldInstance.secureChannelID)
 		}
 		if oldInstance.securityTokenID == instance.securityTokenID {
 			continue
 		}
-		s.instances[instance.securityTokenID] = append(
-			s.instances[instance.securityTokenID],
+		s.instances[instance.secureChannelID] = append(
+			s.instances[instance.secureChannelID],
 			oldInstance,
 		)
 	}
@@ -663,12 +835,45 @@ func (s *SecureChannel) sendRequestWithTimeout(
 	instance *channelInstance,
 	authToken *ua.NodeID,
 	timeout time.Duration,
-	h func(interface{}) error) error {
+	h func(interface{}) error) (err error) {
+
+	if s.cfg.Tracer != nil {
+		var end func(error)
+		ctx, end = s.startSpan(ctx, req)
+		defer func() { end(err) }()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// the timeout hint tells the server how long the caller waits for
+	// the response so that the server can give up on the request, too.
+	if deadline, ok := ctx.Deadline(); ok {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return context.DeadlineExceeded
+		}
+		if d < timeout {
+			timeout = d
+		}
+	}
+
+	var (
+		sent, received int
+		res            interface{}
+	)
+	if s.cfg.RequestTracer != nil {
+		start := time.Now()
+		defer func() {
+			s.traceRequest(req, reqID, start, sent, received, res, err)
+		}()
+	}
 
 	s.pendingReq.Add(1)
 	respRequired := h != nil
 
-	ch, err := s.sendAsyncWithTimeout(ctx, req, reqID, instance, authToken, respRequired, timeout)
+	ch, sent, err := s.sendAsyncWithTimeout(ctx, req, reqID, instance, authToken, respRequired, timeout)
 	s.pendingReq.Done()
 	if err != nil {
 		return err
@@ -690,6 +895,7 @@ func (s *SecureChannel) sendRequestWithTimeout(
 		s.popHandler(reqID)
 		return io.EOF
 	case resp := <-ch:
+		received, res = resp.Size, resp.V
 		if resp.Err != nil {
 			if resp.V != nil {
 				_ = h(resp.V) // ignore result because resp.Err takes precedence
@@ -703,6 +909,14 @@ func (s *SecureChannel) sendRequestWithTimeout(
 	}
 }
 
+// PendingRequests returns the number of requests which wait for a
+// response.
+func (s *SecureChannel) PendingRequests() int {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	return len(s.handlers)
+}
+
 func (s *SecureChannel) popHandler(reqID uint32) (chan *response, bool) {
 	s.handlersMu.Lock()
 	defer s.handlersMu.Unlock()
@@ -714,13 +928,14 @@ func (s *SecureChannel) popHandler(reqID uint32) (chan *response, bool) {
 	return ch, ok
 }
 
+// Renew renews the security token of the channel.
 func (s *SecureChannel) Renew(ctx context.Context) error {
 	instance, err := s.getActiveChannelInstance()
 	if err != nil {
 		return err
 	}
 
-	return s.renew(instance)
+	return s.renew(ctx, instance)
 }
 
 // SendRequest sends the service request and calls h with the response.
@@ -732,8 +947,15 @@ func (s *SecureChannel) SendRequest(req ua.Request, authToken *ua.NodeID, h func
 
 // Note: This method will be replaced by the non "WithContext()" version
 // of this method.
+//
+// The deadline of ctx is the timeout of the request. Requests with a
+// context without a deadline use the RequestTimeout of the config.
 func (s *SecureChannel) SendRequestWithContext(ctx context.Context, req ua.Request, authToken *ua.NodeID, h func(interface{}) error) error {
-	return s.SendRequestWithTimeoutWithContext(ctx, req, authToken, s.cfg.RequestTimeout, h)
+	timeout := s.cfg.RequestTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return s.SendRequestWithTimeoutWithContext(ctx, req, authToken, timeout, h)
 }
 
 // Deprecated: Starting with v0.5 this method will require a context
@@ -746,12 +968,13 @@ func (s *SecureChannel) SendRequestWithTimeout(req ua.Request, authToken *ua.Nod
 // of this method.
 func (s *SecureChannel) SendRequestWithTimeoutWithContext(ctx context.Context, req ua.Request, authToken *ua.NodeID, timeout time.Duration, h func(interface{}) error) error {
 	s.reqLocker.waitIfLock()
-	active, err := s.getActiveChannelInstance()
-	if err != nil {
+	if _, err := s.getActiveChannelInstance(); err != nil {
 		return err
 	}
 
-	return s.sendRequestWithTimeout(ctx, req, s.nextRequestID(), active, authToken, timeout, h)
+	// the security token is selected when the request is sent so that
+	// requests which overlap with a renewal use the current token.
+	return s.sendRequestWithTimeout(ctx, req, s.nextRequestID(), nil, authToken, timeout, h)
 }
 
 func (s *SecureChannel) sendAsyncWithTimeout(
@@ -762,14 +985,24 @@ func (s *SecureChannel) sendAsyncWithTimeout(
 	authToken *ua.NodeID,
 	respRequired bool,
 	timeout time.Duration,
-) (<-chan *response, error) {
+) (<-chan *response, int, error) {
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	if instance == nil {
+		var err error
+		if instance, err = s.getActiveChannelInstance(); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	instance.Lock()
 	defer instance.Unlock()
 
 	m, err := instance.newRequestMessage(req, reqID, authToken, timeout)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var resp chan *response
@@ -782,47 +1015,94 @@ func (s *SecureChannel) sendAsyncWithTimeout(
 
 		if s.handlers[reqID] != nil {
 			s.handlersMu.Unlock()
-			return nil, errors.Errorf("error: duplicate handler registration for request id %d", reqID)
+			return nil, 0, errors.Errorf("error: duplicate handler registration for request id %d", reqID)
 		}
 
 		s.handlers[reqID] = resp
 		s.handlersMu.Unlock()
 	}
 
+	// sent is the number of bytes which have been written.
+	var sent int
+
+	// the handler must not outlive a request which was not sent since
+	// nobody would receive the response.
+	fail := func(err error) (<-chan *response, int, error) {
+		if respRequired {
+			s.popHandler(reqID)
+		}
+		return nil, sent, err
+	}
+
 	chunks, err := m.EncodeChunks(instance.maxBodySize)
 	if err != nil {
-		return nil, err
+		return fail(err)
+	}
+	if err := CheckSendLimits(s.c, chunks, ua.StatusBadRequestTooLarge); err != nil {
+		return fail(err)
 	}
 
 	for i, chunk := range chunks {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			if i > 0 {
+				s.sendAbort(instance, m, reqID)
+			}
+			return fail(ctx.Err())
 		default:
 		}
 		if i > 0 { // fix sequence number on subsequent chunks
-			number := instance.nextSequenceNumber()
+			number := s.nextSequenceNumber()
 			binary.LittleEndian.PutUint32(chunk[16:], uint32(number))
 		}
 
 		chunk, err = instance.signAndEncrypt(m, chunk)
 		if err != nil {
-			return nil, err
+			return fail(err)
 		}
 
 		// send the message
 		var n int
 		if n, err = s.c.Write(chunk); err != nil {
-			return nil, err
+			return fail(err)
 		}
 
+		sent += n
 		atomic.AddUint64(&instance.bytesSent, uint64(n))
 		atomic.AddUint32(&instance.messagesSent, 1)
 
-		debug.Printf("uasc %d/%d: send %T with %d bytes", s.c.ID(), reqID, req, len(chunk))
+		s.log.Debug("uasc: send", "request_id", reqID, "type", reflect.TypeOf(req), "size", len(chunk))
 	}
 
-	return resp, nil
+	return resp, sent, nil
+}
+
+// sendAbort sends the abort chunk for a request which was cancelled after
+// some of its chunks have been sent. sendMu must be held.
+func (s *SecureChannel) sendAbort(instance *channelInstance, m *Message, reqID uint32) {
+	m.SequenceHeader.SequenceNumber = s.nextSequenceNumber()
+	b, err := m.EncodeAbort(ua.StatusBadRequestCancelledByClient, "")
+	if err == nil {
+		b, err = instance.signAndEncrypt(m, b)
+	}
+	if err == nil {
+		_, err = s.c.Write(b)
+	}
+	if err != nil {
+		s.log.Warn("uasc: failed to abort request", "request_id", reqID, "err", err)
+		return
+	}
+	s.log.Debug("uasc: sent abort", "request_id", reqID)
+}
+
+// nextSequenceNumber returns the sequence number for the next chunk.
+// sendMu must be held.
+func (s *SecureChannel) nextSequenceNumber() uint32 {
+	s.sequenceNumber++
+	if s.sequenceNumber > math.MaxUint32-1023 {
+		s.sequenceNumber = 1
+	}
+	return s.sequenceNumber
 }
 
 func (s *SecureChannel) nextRequestID() uint32 {
@@ -847,7 +1127,7 @@ func (s *SecureChannel) Close() (err error) {
 }
 
 func (s *SecureChannel) close() error {
-	debug.Printf("uasc %d: Close()", s.c.ID())
+	s.log.Debug("uasc: close")
 
 	defer func() {
 		close(s.closing)
@@ -877,22 +1157,57 @@ func (s *SecureChannel) timeNow() time.Time {
 	return time.Now()
 }
 
-func mergeChunks(chunks []*MessageChunk) ([]byte, error) {
+// mergeChunks returns the data of the chunks of a message without
+// duplicate chunks. The data of multiple chunks is merged into a buffer
+// from the pool which is also returned.
+func (s *SecureChannel) mergeChunks(chunks []*MessageChunk) (b, merged []byte, err error) {
 	if len(chunks) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 	if len(chunks) == 1 {
-		return chunks[0].Data, nil
+		return chunks[0].Data, nil, nil
+	}
+
+	var n int
+	for _, c := range chunks {
+		n += len(c.Data)
 	}
+	merged = s.getBuffer(n)[:0]
 
-	var b []byte
 	var seqnr uint32
 	for _, c := range chunks {
 		if c.SequenceHeader.SequenceNumber == seqnr {
 			continue // duplicate chunk
 		}
 		seqnr = c.SequenceHeader.SequenceNumber
-		b = append(b, c.Data...)
+		merged = append(merged, c.Data...)
 	}
-	return b, nil
+	return merged, merged, nil
+}
+
+// getBuffer returns a buffer with n bytes from the pool or a new buffer
+// if the pool has no buffer which is large enough.
+func (s *SecureChannel) getBuffer(n int) []byte {
+	if b, ok := s.buffers.Get().(*[]byte); ok && cap(*b) >= n {
+		return (*b)[:n]
+	}
+	return make([]byte, n)
+}
+
+// releaseChunks returns the buffers of the chunks of a decoded message
+// and the buffer of the merged chunks to the pool.
+func (s *SecureChannel) releaseChunks(chunks []*MessageChunk, merged []byte) {
+	for _, c := range chunks {
+		if c.buf != nil {
+			s.putBuffer(c.buf)
+			c.buf = nil
+		}
+	}
+	if merged != nil {
+		s.putBuffer(merged)
+	}
+}
+
+func (s *SecureChannel) putBuffer(b []byte) {
+	s.buffers.Put(&b)
 }
@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"encoding/binary"
 
+	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/ua"
 	"github.com/zzylovesll/myOpcUa/uapolicy"
 )
@@ -65,13 +66,25 @@ func (s *SecureChannel) VerifySessionSignature(cert, nonce, signature []byte) er
 
 // EncryptUserPassword issues a new signature for the client to send in ActivateSessionRequest
 func (s *SecureChannel) EncryptUserPassword(policyURI, password string, cert, nonce []byte) ([]byte, string, error) {
+	return s.EncryptUserTokenData(policyURI, []byte(password), cert, nonce)
+}
+
+// EncryptUserTokenData encrypts the password of a UserNameIdentityToken or
+// the token data of an IssuedIdentityToken with the server certificate and
+// nonce and returns the encrypted data and the encryption algorithm.
+//
+// Specification: Part 4, 7.36.2.2
+func (s *SecureChannel) EncryptUserTokenData(policyURI string, data, cert, nonce []byte) ([]byte, string, error) {
 	// If the User ID Token's policy was null, then default to the secure channel's policy
 	if policyURI == "" {
 		policyURI = s.cfg.SecurityPolicyURI
 	}
 
 	if policyURI == ua.SecurityPolicyURINone {
-		return []byte(password), "", nil
+		return data, "", nil
+	}
+	if len(cert) == 0 {
+		return nil, "", errors.Errorf("server certificate required to encrypt the user token with %s", policyURI)
 	}
 
 	remoteX509Cert, err := x509.ParseCertificate(cert)
@@ -85,25 +98,43 @@ func (s *SecureChannel) EncryptUserPassword(policyURI, password string, cert, no
 		return nil, "", err
 	}
 
-	l := len(password) + len(nonce)
+	l := len(data) + len(nonce)
 	secret := make([]byte, 4)
 	binary.LittleEndian.PutUint32(secret, uint32(l))
-	secret = append(secret, []byte(password)...)
+	secret = append(secret, data...)
 	secret = append(secret, nonce...)
-	pass, err := enc.Encrypt(secret)
+	b, err := enc.Encrypt(secret)
 	if err != nil {
 		return nil, "", err
 	}
-	passAlg := enc.EncryptionURI()
-
-	return pass, passAlg, nil
+	return b, enc.EncryptionURI(), nil
 }
 
 // NewUserTokenSignature issues a new signature for the client to send in ActivateSessionRequest
+// with the private key of the application instance certificate.
 func (s *SecureChannel) NewUserTokenSignature(policyURI string, cert, nonce []byte) ([]byte, string, error) {
+	return s.NewUserTokenSignatureWithKey(policyURI, s.cfg.LocalKey, cert, nonce)
+}
+
+// NewUserTokenSignatureWithKey signs the server certificate and nonce with the
+// private key of the user certificate of an X509IdentityToken.
+//
+// Specification: Part 4, 7.36.5
+func (s *SecureChannel) NewUserTokenSignatureWithKey(policyURI string, key *rsa.PrivateKey, cert, nonce []byte) ([]byte, string, error) {
+	// If the User ID Token's policy was null, then default to the secure channel's policy
+	if policyURI == "" {
+		policyURI = s.cfg.SecurityPolicyURI
+	}
+
 	if policyURI == ua.SecurityPolicyURINone {
 		return nil, "", nil
 	}
+	if key == nil {
+		return nil, "", errors.New("private key required to sign the user token")
+	}
+	if len(cert) == 0 {
+		return nil, "", errors.Errorf("server certificate required to sign the user token with %s", policyURI)
+	}
 
 	remoteX509Cert, err := x509.ParseCertificate(cert)
 	if err != nil {
@@ -111,7 +142,7 @@ func (s *SecureChannel) NewUserTokenSignature(policyURI string, cert, nonce []by
 	}
 	remoteKey := remoteX509Cert.PublicKey.(*rsa.PublicKey)
 
-	enc, err := uapolicy.Asymmetric(policyURI, s.cfg.LocalKey, remoteKey)
+	enc, err := uapolicy.Asymmetric(policyURI, key, remoteKey)
 	if err != nil {
 		return nil, "", err
 	}
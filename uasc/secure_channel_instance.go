@@ -6,13 +6,13 @@ package uasc
 
 import (
 	"encoding/binary"
-	"math"
 	"sync"
 	"time"
 
 	"github.com/zzylovesll/myOpcUa/errors"
 	"github.com/zzylovesll/myOpcUa/id"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
 	"github.com/zzylovesll/myOpcUa/uapolicy"
 )
 
@@ -31,7 +31,6 @@ type channelInstance struct {
 	revisedLifetime time.Duration
 	secureChannelID uint32
 	securityTokenID uint32
-	sequenceNumber  uint32
 	algo            *uapolicy.EncryptionAlgorithm
 	maxBodySize     uint32
 
@@ -48,16 +47,6 @@ func newChannelInstance(sc *SecureChannel) *channelInstance {
 	}
 }
 
-func (c *channelInstance) nextSequenceNumber() uint32 {
-	// lock must be held
-	c.sequenceNumber++
-	if c.sequenceNumber > math.MaxUint32-1023 {
-		c.sequenceNumber = 1
-	}
-
-	return c.sequenceNumber
-}
-
 func (c *channelInstance) newRequestMessage(req ua.Request, reqID uint32, authToken *ua.NodeID, timeout time.Duration) (*Message, error) {
 	typeID := ua.ServiceTypeID(req)
 	if typeID == 0 {
@@ -71,20 +60,31 @@ func (c *channelInstance) newRequestMessage(req ua.Request, reqID uint32, authTo
 		AuthenticationToken: authToken,
 		Timestamp:           c.sc.timeNow(),
 		RequestHandle:       reqID, // TODO: can I cheat like this?
+		ReturnDiagnostics:   c.sc.cfg.ReturnDiagnostics,
 	}
 
-	if timeout > 0 && timeout < c.sc.cfg.RequestTimeout {
-		timeout = c.sc.cfg.RequestTimeout
-	}
+	// a hint of zero means no timeout. Round up what is left of a
+	// deadline so that the server does not wait forever.
 	reqHdr.TimeoutHint = uint32(timeout / time.Millisecond)
+	if reqHdr.TimeoutHint == 0 && timeout > 0 {
+		reqHdr.TimeoutHint = 1
+	}
 	req.SetHeader(reqHdr)
 
+	// fail with an error instead of a panic or a broken message when a
+	// required field is nil.
+	if v, ok := req.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	// encode the message
 	return c.newMessage(req, typeID, reqID), nil
 }
 
 func (c *channelInstance) newMessage(srv interface{}, typeID uint16, requestID uint32) *Message {
-	sequenceNumber := c.nextSequenceNumber()
+	sequenceNumber := c.sc.nextSequenceNumber()
 
 	switch typeID {
 	case id.OpenSecureChannelRequest_Encoding_DefaultBinary, id.OpenSecureChannelResponse_Encoding_DefaultBinary:
@@ -132,25 +132,18 @@ func (c *channelInstance) newMessage(srv interface{}, typeID uint16, requestID u
 }
 
 func (c *channelInstance) SetMaximumBodySize(chunkSize int) {
-	sequenceHeaderSize := 8
-	headerSize := 12
-	symmetricAlgorithmHeader := 4
-
-	// this is the formula proposed by OPCUA - source node-opcua
-	maxBodySize :=
-		c.algo.PlaintextBlockSize()*
-			((chunkSize-headerSize-symmetricAlgorithmHeader-c.algo.SignatureLength()-1)/c.algo.BlockSize()) -
-			sequenceHeaderSize
-	c.maxBodySize = uint32(maxBodySize)
-
-	// this is the formula proposed by ERN - source node-opcua
-	// maxBlock := (chunkSize - headerSize) / c.algo.BlockSize()
-	// c.maxBodySize = c.algo.PlaintextBlockSize()*maxBlock - sequenceHeaderSize - c.algo.SignatureLength() - 1
+	c.maxBodySize = MaxBodySize(c.algo, chunkSize, c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt)
 }
 
-// signAndEncrypt encrypts the message bytes stored in b and returns the
-// data signed and encrypted per the security policy information from the
-// secure channel.
+// encrypted returns true if the chunks with or without an asymmetric
+// security header are encrypted. OpenSecureChannel messages are always
+// encrypted unless the security mode is None.
+func (c *channelInstance) encrypted(asymmetric bool) bool {
+	return c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt || asymmetric
+}
+
+// signAndEncrypt signs and encrypts the chunk b of the message per the
+// security mode of the secure channel.
 func (c *channelInstance) signAndEncrypt(m *Message, b []byte) ([]byte, error) {
 	// Nothing to do
 	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeNone {
@@ -160,39 +153,117 @@ func (c *channelInstance) signAndEncrypt(m *Message, b []byte) ([]byte, error) {
 	isAsymmetric := m.MessageHeader.AsymmetricSecurityHeader != nil
 
 	var headerLength int
-
 	if isAsymmetric {
 		headerLength = 12 + m.AsymmetricSecurityHeader.Len()
 	} else {
 		headerLength = 12 + m.SymmetricSecurityHeader.Len()
 	}
 
+	b, err := SignAndEncrypt(c.algo, b, headerLength, c.encrypted(isAsymmetric))
+	if err != nil {
+		return nil, err
+	}
+	m.Header.MessageSize = uint32(len(b))
+	return b, nil
+}
+
+func (c *channelInstance) verifyAndDecrypt(m *MessageChunk, r []byte) ([]byte, error) {
+	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeNone {
+		return m.Data, nil
+	}
+
+	isAsymmetric := m.AsymmetricSecurityHeader != nil
+
+	headerLength := 12
+	if isAsymmetric {
+		headerLength += m.AsymmetricSecurityHeader.Len()
+	} else {
+		headerLength += m.SymmetricSecurityHeader.Len()
+	}
+
+	return VerifyAndDecrypt(c.algo, r, headerLength, c.encrypted(isAsymmetric))
+}
+
+// MaxBodySize returns the maximum size of the body of a symmetric chunk
+// with the chunk size. Encrypted chunks contain whole cipher text blocks
+// and a padding. Chunks which are only signed or neither signed nor
+// encrypted contain neither, so that the body fills the chunk up to the
+// signature.
+//
+// See Part 6, 6.7.2.
+func MaxBodySize(algo *uapolicy.EncryptionAlgorithm, chunkSize int, encrypted bool) uint32 {
+	const (
+		headerSize               = 12
+		symmetricAlgorithmHeader = 4
+		sequenceHeaderSize       = 8
+	)
+	var n int
+	if encrypted {
+		// the chunk contains whole cipher text blocks with the sequence
+		// header, the body, the padding size byte and the signature.
+		n = algo.PlaintextBlockSize()*((chunkSize-headerSize-symmetricAlgorithmHeader)/algo.BlockSize()) - sequenceHeaderSize - algo.SignatureLength() - 1
+	} else {
+		n = chunkSize - headerSize - symmetricAlgorithmHeader - algo.SignatureLength() - sequenceHeaderSize
+	}
+	if n < 0 {
+		n = 0
+	}
+	return uint32(n)
+}
+
+// CheckSendLimits returns an error which wraps status if a message with
+// the chunks exceeds the MaxMessageSize or the MaxChunkCount which the
+// other end of the connection has sent in its Hello or Acknowledge
+// message since it would reject the message.
+func CheckSendLimits(c *uacp.Conn, chunks [][]byte, status ua.StatusCode) error {
+	if max := c.MaxSendChunkCount(); max > 0 && uint32(len(chunks)) > max {
+		return errors.Wrapf(status, "too many chunks: %d > %d", len(chunks), max)
+	}
+	if max := c.MaxSendMessageSize(); max > 0 {
+		var size int
+		for _, c := range chunks {
+			size += len(c) - chunkHeaderSize
+		}
+		if uint32(size) > max {
+			return errors.Wrapf(status, "message too large: %d > %d", size, max)
+		}
+	}
+	return nil
+}
+
+// SignAndEncrypt signs the chunk b whose message and security headers
+// are headerLength bytes long and returns the signed chunk. If encrypt
+// is true the chunk is padded and everything after the security header
+// is encrypted. The message size in the header is updated.
+//
+// See Part 6, 6.7.2.
+func SignAndEncrypt(algo *uapolicy.EncryptionAlgorithm, b []byte, headerLength int, encrypt bool) ([]byte, error) {
 	var encryptedLength int
-	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt || isAsymmetric {
-		plaintextBlockSize := c.algo.PlaintextBlockSize()
-		paddingLength := plaintextBlockSize - ((len(b[headerLength:]) + c.algo.SignatureLength() + 1) % plaintextBlockSize)
+	if encrypt {
+		plaintextBlockSize := algo.PlaintextBlockSize()
+		paddingLength := (plaintextBlockSize - (len(b[headerLength:])+algo.SignatureLength()+1)%plaintextBlockSize) % plaintextBlockSize
 
 		for i := 0; i <= paddingLength; i++ {
 			b = append(b, byte(paddingLength))
 		}
-		encryptedLength = ((len(b[headerLength:]) + c.algo.SignatureLength()) / plaintextBlockSize) * c.algo.BlockSize()
-	} else { // MessageSecurityModeSign
-		encryptedLength = len(b[headerLength:]) + c.algo.SignatureLength()
+		encryptedLength = ((len(b[headerLength:]) + algo.SignatureLength()) / plaintextBlockSize) * algo.BlockSize()
+	} else {
+		// chunks which are only signed have no padding
+		encryptedLength = len(b[headerLength:]) + algo.SignatureLength()
 	}
 
 	// Fix header size to account for signing / encryption
 	binary.LittleEndian.PutUint32(b[4:], uint32(headerLength+encryptedLength))
-	m.Header.MessageSize = uint32(headerLength + encryptedLength)
 
-	signature, err := c.algo.Signature(b)
+	signature, err := algo.Signature(b)
 	if err != nil {
 		return nil, ua.StatusBadSecurityChecksFailed
 	}
 
 	b = append(b, signature...)
 	p := b[headerLength:]
-	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt || isAsymmetric {
-		p, err = c.algo.Encrypt(p)
+	if encrypt {
+		p, err = algo.Encrypt(p)
 		if err != nil {
 			return nil, ua.StatusBadSecurityChecksFailed
 		}
@@ -200,45 +271,43 @@ func (c *channelInstance) signAndEncrypt(m *Message, b []byte) ([]byte, error) {
 	return append(b[:headerLength], p...), nil
 }
 
-func (c *channelInstance) verifyAndDecrypt(m *MessageChunk, r []byte) ([]byte, error) {
-	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeNone {
-		return m.Data, nil
-	}
-
-	isAsymmetric := m.AsymmetricSecurityHeader != nil
-
-	headerLength := 12
-
-	if isAsymmetric {
-		headerLength += m.AsymmetricSecurityHeader.Len()
-	} else {
-		headerLength += m.SymmetricSecurityHeader.Len()
+// VerifyAndDecrypt decrypts the chunk r whose message and security
+// headers are headerLength bytes long if decrypt is true and verifies its
+// signature. It returns the data after the security header without the
+// padding and the signature. r is not modified.
+//
+// See Part 6, 6.7.2.
+func VerifyAndDecrypt(algo *uapolicy.EncryptionAlgorithm, r []byte, headerLength int, decrypt bool) ([]byte, error) {
+	if len(r) < headerLength {
+		return nil, ua.StatusBadSecurityChecksFailed
 	}
 
 	b := make([]byte, len(r))
 	copy(b, r)
 
-	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt || isAsymmetric {
-		p, err := c.algo.Decrypt(b[headerLength:])
+	if decrypt {
+		p, err := algo.Decrypt(b[headerLength:])
 		if err != nil {
 			return nil, ua.StatusBadSecurityChecksFailed
 		}
 		b = append(b[:headerLength], p...)
 	}
 
-	signature := b[len(b)-c.algo.RemoteSignatureLength():]
-	messageToVerify := b[:len(b)-c.algo.RemoteSignatureLength()]
-
-	if err := c.algo.VerifySignature(messageToVerify, signature); err != nil {
+	n := algo.RemoteSignatureLength()
+	if len(b)-n < headerLength {
+		return nil, ua.StatusBadSecurityChecksFailed
+	}
+	messageToVerify, signature := b[:len(b)-n], b[len(b)-n:]
+	if err := algo.VerifySignature(messageToVerify, signature); err != nil {
 		return nil, ua.StatusBadSecurityChecksFailed
 	}
 
 	var paddingLength int
-	if c.sc.cfg.SecurityMode == ua.MessageSecurityModeSignAndEncrypt || isAsymmetric {
+	if decrypt {
 		paddingLength = int(messageToVerify[len(messageToVerify)-1]) + 1
 	}
-
-	b = messageToVerify[headerLength : len(messageToVerify)-paddingLength]
-
-	return b, nil
+	if len(messageToVerify)-paddingLength < headerLength {
+		return nil, ua.StatusBadSecurityChecksFailed
+	}
+	return messageToVerify[headerLength : len(messageToVerify)-paddingLength], nil
 }
@@ -0,0 +1,220 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uasc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
+)
+
+// signOnlyInstances returns the channel instances of the client and the
+// server of a secure channel with the security mode Sign.
+func signOnlyInstances(t *testing.T, policyURI string, clientNonce, serverNonce []byte) (client, server *channelInstance) {
+	t.Helper()
+	instance := func(local, remote []byte) *channelInstance {
+		algo, err := uapolicy.Symmetric(policyURI, local, remote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := &SecureChannel{cfg: &Config{SecurityPolicyURI: policyURI, SecurityMode: ua.MessageSecurityModeSign}}
+		c := newChannelInstance(sc)
+		c.algo = algo
+		return c
+	}
+	return instance(clientNonce, serverNonce), instance(serverNonce, clientNonce)
+}
+
+// signOnlyMessage returns a request message with a body of n bytes.
+func signOnlyMessage(n int) *Message {
+	return &Message{
+		MessageHeader: &MessageHeader{
+			Header:                  NewHeader(MessageTypeMessage, ChunkTypeFinal, 7),
+			SymmetricSecurityHeader: NewSymmetricSecurityHeader(3),
+			SequenceHeader:          NewSequenceHeader(11, 5),
+		},
+		TypeID: ua.NewFourByteExpandedNodeID(0, ua.ServiceTypeID(&ua.WriteRequest{})),
+		Service: &ua.WriteRequest{
+			RequestHeader: &ua.RequestHeader{
+				AuthenticationToken: ua.NewTwoByteNodeID(0),
+				Timestamp:           time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				RequestHandle:       5,
+				AdditionalHeader:    ua.NewExtensionObject(nil),
+			},
+			NodesToWrite: []*ua.WriteValue{{
+				NodeID:      ua.NewNumericNodeID(1, 42),
+				AttributeID: ua.AttributeIDValue,
+				Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(bytes.Repeat([]byte{0xab}, n))},
+			}},
+		},
+	}
+}
+
+func TestSignOnlyRoundTrip(t *testing.T) {
+	policies := []string{
+		ua.SecurityPolicyURIBasic128Rsa15,
+		ua.SecurityPolicyURIBasic256,
+		ua.SecurityPolicyURIBasic256Sha256,
+		ua.SecurityPolicyURIAes128Sha256RsaOaep,
+		ua.SecurityPolicyURIAes256Sha256RsaPss,
+	}
+	const chunkSize = 8192
+
+	for _, uri := range policies {
+		t.Run(uri, func(t *testing.T) {
+			clientNonce := bytes.Repeat([]byte{1}, 32)
+			serverNonce := bytes.Repeat([]byte{2}, 32)
+			client, server := signOnlyInstances(t, uri, clientNonce, serverNonce)
+			client.SetMaximumBodySize(chunkSize)
+
+			// the signed chunks fill the chunk size since they do not
+			// need a padding.
+			if got, want := int(client.maxBodySize), chunkSize-16-8-client.algo.SignatureLength(); got != want {
+				t.Fatalf("got max body size %d want %d", got, want)
+			}
+
+			m := signOnlyMessage(3 * chunkSize)
+			chunks, err := m.EncodeChunks(client.maxBodySize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) < 4 {
+				t.Fatalf("got %d chunks want at least 4", len(chunks))
+			}
+
+			var body []byte
+			for i, chunk := range chunks {
+				plain := append([]byte(nil), chunk...)
+				b, err := client.signAndEncrypt(m, chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(b) > chunkSize {
+					t.Fatalf("chunk %d: got %d bytes want at most %d", i, len(b), chunkSize)
+				}
+				if got, want := int(binary.LittleEndian.Uint32(b[4:])), len(b); got != want {
+					t.Fatalf("chunk %d: got message size %d want %d", i, got, want)
+				}
+
+				// the chunk is the plain text followed by the signature
+				sigLen := client.algo.SignatureLength()
+				if got, want := len(b), len(plain)+sigLen; got != want {
+					t.Fatalf("chunk %d: got %d bytes want %d bytes without padding", i, got, want)
+				}
+				if !bytes.Equal(b[8:len(b)-sigLen], plain[8:]) {
+					t.Fatalf("chunk %d: body is not plain text", i)
+				}
+
+				mc := new(MessageChunk)
+				if _, err := mc.Decode(b); err != nil {
+					t.Fatal(err)
+				}
+				data, err := server.verifyAndDecrypt(mc, b)
+				if err != nil {
+					t.Fatalf("chunk %d: %v", i, err)
+				}
+				if !bytes.Equal(data, plain[16:]) {
+					t.Fatalf("chunk %d: got different data after verification", i)
+				}
+				sh := new(SequenceHeader)
+				n, err := sh.Decode(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sh.RequestID != 5 {
+					t.Fatalf("chunk %d: got request id %d want 5", i, sh.RequestID)
+				}
+				body = append(body, data[n:]...)
+
+				// a modified chunk fails the verification
+				b[len(b)/2] ^= 0xff
+				if _, err := server.verifyAndDecrypt(mc, b); !errors.Is(err, ua.StatusBadSecurityChecksFailed) {
+					t.Fatalf("chunk %d: got error %v for a modified chunk want %v", i, err, ua.StatusBadSecurityChecksFailed)
+				}
+			}
+
+			_, svc, err := ua.DecodeService(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := svc.(*ua.WriteRequest).NodesToWrite[0].Value.Value.Value().([]byte)
+			if len(got) != 3*chunkSize {
+				t.Fatalf("got %d bytes want %d", len(got), 3*chunkSize)
+			}
+		})
+	}
+}
+
+func TestSignOnlyReference(t *testing.T) {
+	// The reference chunk has been computed with the cryptography of
+	// .NET by testdata/signonly/Program.cs which encodes the chunk and
+	// derives the keys as specified in Part 6, 5.2, 6.7.2 and 6.7.5
+	// without any code of this module.
+	want := mustHex("4d5347468200000007000000030000000b00000005000000" +
+		"0100a10200008000c44a19c1d5010500000000000000ffffffff000000000000" +
+		"00010000000201002a0000000d000000ffffffff010f10000000abababababab" +
+		"abababababababababab" +
+		"7e3626424d10eea4095f36787c632774d572f65dc7887b99d54dba43d8074598")
+
+	clientNonce := make([]byte, 32)
+	serverNonce := make([]byte, 32)
+	for i := range clientNonce {
+		clientNonce[i] = byte(i)
+		serverNonce[i] = byte(0xff - i)
+	}
+	client, server := signOnlyInstances(t, ua.SecurityPolicyURIBasic256Sha256, clientNonce, serverNonce)
+	client.SetMaximumBodySize(8192)
+
+	m := signOnlyMessage(16)
+	chunks, err := m.EncodeChunks(client.maxBodySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("got %d chunks want 1", len(chunks))
+	}
+
+	got, err := client.signAndEncrypt(m, chunks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got\n%x\nwant\n%x", got, want)
+	}
+
+	// the server accepts the reference chunk
+	mc := new(MessageChunk)
+	if _, err := mc.Decode(want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.verifyAndDecrypt(mc, want); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAndDecryptShortChunk(t *testing.T) {
+	client, _ := signOnlyInstances(t, ua.SecurityPolicyURIBasic256Sha256, make([]byte, 32), make([]byte, 32))
+	for _, n := range []int{0, 10, 16, 16 + client.algo.RemoteSignatureLength() - 1} {
+		for _, decrypt := range []bool{false, true} {
+			if _, err := VerifyAndDecrypt(client.algo, make([]byte, n), 16, decrypt); !errors.Is(err, ua.StatusBadSecurityChecksFailed) {
+				t.Fatalf("%d bytes decrypt=%v: got error %v want %v", n, decrypt, err, ua.StatusBadSecurityChecksFailed)
+			}
+		}
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -1,12 +1,25 @@
 package uasc
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
 	"math"
+	"net"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/zzylovesll/myOpcUa/id"
 	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacert"
+	"github.com/zzylovesll/myOpcUa/uacp"
+	"github.com/zzylovesll/myOpcUa/uapolicy"
 
 	"github.com/pascaldekloe/goe/verify"
 )
@@ -65,16 +78,15 @@ func TestNewRequestMessage(t *testing.T) {
 			name: "subsequent-request",
 			sechan: buildSecureChannel(
 				&SecureChannel{
-					cfg:       &Config{},
-					requestID: 555,
+					cfg:            &Config{},
+					requestID:      555,
+					sequenceNumber: 777,
 					// reqhdr: &ua.RequestHeader{
 					// 	RequestHandle: 444,
 					// },
 					time: fixedTime,
 				},
-				&channelInstance{
-					sequenceNumber: 777,
-				},
+				nil,
 			),
 			req: &ua.ReadRequest{},
 			m: &Message{
@@ -103,13 +115,12 @@ func TestNewRequestMessage(t *testing.T) {
 			name: "counter-rollover",
 			sechan: buildSecureChannel(
 				&SecureChannel{
-					cfg:       &Config{},
-					requestID: math.MaxUint32,
-					time:      fixedTime,
-				},
-				&channelInstance{
+					cfg:            &Config{},
+					requestID:      math.MaxUint32,
 					sequenceNumber: math.MaxUint32 - 1023,
-				}),
+					time:           fixedTime,
+				},
+				nil),
 			req: &ua.ReadRequest{},
 			m: &Message{
 				MessageHeader: &MessageHeader{
@@ -145,3 +156,381 @@ func TestNewRequestMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRequestMessageValidate(t *testing.T) {
+	sc := &SecureChannel{cfg: &Config{}, time: time.Now}
+	sc.activeInstance = newChannelInstance(sc)
+
+	_, err := sc.activeInstance.newRequestMessage(&ua.BrowseRequest{}, sc.nextRequestID(), nil, 0)
+	if got, want := fmt.Sprint(err), "opcua: BrowseRequest.View is nil"; got != want {
+		t.Fatalf("got error %q want %q", got, want)
+	}
+
+	req := &ua.BrowseRequest{View: &ua.ViewDescription{ViewID: ua.NewTwoByteNodeID(0)}}
+	if _, err := sc.activeInstance.newRequestMessage(req, sc.nextRequestID(), nil, 0); err != nil {
+		t.Fatalf("got error %v want nil", err)
+	}
+}
+
+func TestNewRequestMessageTimeoutHint(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		hint    uint32
+	}{
+		{0, 0},
+		{500 * time.Microsecond, 1},
+		{time.Millisecond, 1},
+		{1500 * time.Millisecond, 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeout.String(), func(t *testing.T) {
+			sc := &SecureChannel{cfg: &Config{}, time: time.Now}
+			sc.activeInstance = newChannelInstance(sc)
+
+			req := &ua.ReadRequest{}
+			if _, err := sc.activeInstance.newRequestMessage(req, sc.nextRequestID(), nil, tt.timeout); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := req.RequestHeader.TimeoutHint, tt.hint; got != want {
+				t.Fatalf("got timeout hint %d want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRenewalDelay(t *testing.T) {
+	tests := []struct {
+		lifetime, renew, expire time.Duration
+	}{
+		{time.Hour, 45 * time.Minute, 75 * time.Minute},
+		{2 * time.Second, 1500 * time.Millisecond, 2500 * time.Millisecond},
+		{time.Second, 750 * time.Millisecond, 1250 * time.Millisecond},
+		{100 * time.Millisecond, 75 * time.Millisecond, 125 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := renewalDelay(tt.lifetime); got != tt.renew {
+			t.Errorf("renewalDelay(%s) got %s want %s", tt.lifetime, got, tt.renew)
+		}
+		if got := expirationDelay(tt.lifetime); got != tt.expire {
+			t.Errorf("expirationDelay(%s) got %s want %s", tt.lifetime, got, tt.expire)
+		}
+
+		// all retries happen before the token expires
+		if last := tt.renew + renewalRetries*renewalRetryDelay(tt.lifetime); last >= tt.lifetime {
+			t.Errorf("last renewal of %s at %s after the token expired", tt.lifetime, last)
+		}
+	}
+}
+
+func TestNewUserTokenSignatureWithKey(t *testing.T) {
+	certPEM, _, err := uacert.GenerateCert("urn:gopcua:server", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	serverCert := block.Bytes
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+
+	userKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &SecureChannel{cfg: &Config{SecurityPolicyURI: ua.SecurityPolicyURIBasic256Sha256}}
+
+	// an empty policy uses the policy of the secure channel
+	sig, alg, err := s.NewUserTokenSignatureWithKey("", userKey, serverCert, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := uapolicy.Asymmetric(ua.SecurityPolicyURIBasic256Sha256, nil, &userKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "algorithm", alg, enc.SignatureURI())
+	if err := enc.VerifySignature(append(append([]byte{}, serverCert...), nonce...), sig); err != nil {
+		t.Fatalf("invalid signature: %s", err)
+	}
+
+	sig, alg, err = s.NewUserTokenSignatureWithKey(ua.SecurityPolicyURINone, userKey, serverCert, nonce)
+	if err != nil || sig != nil || alg != "" {
+		t.Fatalf("got %v %q %v want no signature for policy None", sig, alg, err)
+	}
+
+	if _, _, err := s.NewUserTokenSignatureWithKey("", nil, serverCert, nonce); err == nil {
+		t.Fatal("got nil want error for missing key")
+	}
+}
+
+func TestEncryptUserTokenData(t *testing.T) {
+	certPEM, keyPEM, err := uacert.GenerateCert("urn:gopcua:server", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	serverCert := block.Bytes
+	block, _ = pem.Decode(keyPEM)
+	serverKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+	token := []byte("header.payload.signature")
+
+	s := &SecureChannel{cfg: &Config{SecurityPolicyURI: ua.SecurityPolicyURINone}}
+
+	b, alg, err := s.EncryptUserTokenData(ua.SecurityPolicyURIBasic256Sha256, token, serverCert, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := uapolicy.Asymmetric(ua.SecurityPolicyURIBasic256Sha256, serverKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "algorithm", alg, dec.EncryptionURI())
+	plain, err := dec.Decrypt(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := binary.LittleEndian.Uint32(plain)
+	verify.Values(t, "token", plain[4:4+int(n)], append(append([]byte{}, token...), nonce...))
+
+	// an empty policy uses the policy of the secure channel
+	b, alg, err = s.EncryptUserTokenData("", token, serverCert, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify.Values(t, "plain token", b, token)
+	verify.Values(t, "plain algorithm", alg, "")
+
+	if _, _, err := s.EncryptUserTokenData(ua.SecurityPolicyURIBasic256Sha256, token, nil, nonce); err == nil {
+		t.Fatal("got nil want error for missing certificate")
+	}
+}
+
+// startFakeServer accepts one connection with security policy None and
+// answers every request with the response of h. h is called in its own
+// goroutine so that it can delay the response.
+func startFakeServer(t *testing.T, h func(req ua.Request) ua.Response) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "opc.tcp://" + l.Addr().String()
+	l.Close()
+
+	ln, err := uacp.Listen(endpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		c, err := ln.Accept(context.Background())
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		var mu sync.Mutex
+		var seq uint32
+		send := func(typ string, reqID uint32, res interface{}, typeID uint16) {
+			mu.Lock()
+			defer mu.Unlock()
+			seq++
+			hdr := &MessageHeader{
+				Header:                  NewHeader(typ, ChunkTypeFinal, 1),
+				SymmetricSecurityHeader: NewSymmetricSecurityHeader(1),
+				SequenceHeader:          NewSequenceHeader(seq, reqID),
+			}
+			if typ == MessageTypeOpenSecureChannel {
+				hdr.SymmetricSecurityHeader = nil
+				hdr.AsymmetricSecurityHeader = NewAsymmetricSecurityHeader(ua.SecurityPolicyURINone, nil, nil)
+			}
+			b, err := (&Message{MessageHeader: hdr, TypeID: ua.NewFourByteExpandedNodeID(0, typeID), Service: res}).Encode()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			c.Write(b)
+		}
+
+		for {
+			b, err := c.Receive()
+			if err != nil {
+				return
+			}
+			m := new(Message)
+			if _, err := m.Decode(b); err != nil {
+				continue
+			}
+			reqID := m.SequenceHeader.RequestID
+			switch req := m.Service.(type) {
+			case *ua.OpenSecureChannelRequest:
+				send(MessageTypeOpenSecureChannel, reqID, &ua.OpenSecureChannelResponse{
+					ResponseHeader: fakeResponseHeader(req.RequestHeader),
+					SecurityToken:  &ua.ChannelSecurityToken{ChannelID: 1, TokenID: 1, CreatedAt: time.Now(), RevisedLifetime: req.RequestedLifetime},
+					ServerNonce:    []byte{},
+				}, id.OpenSecureChannelResponse_Encoding_DefaultBinary)
+			case *ua.CloseSecureChannelRequest:
+				return
+			case ua.Request:
+				go func() {
+					res := h(req)
+					send(MessageTypeMessage, reqID, res, ua.ServiceTypeID(res))
+				}()
+			}
+		}
+	}()
+	return endpoint
+}
+
+func fakeResponseHeader(req *ua.RequestHeader) *ua.ResponseHeader {
+	return &ua.ResponseHeader{
+		Timestamp:          time.Now(),
+		RequestHandle:      req.RequestHandle,
+		ServiceDiagnostics: &ua.DiagnosticInfo{},
+		AdditionalHeader:   ua.NewExtensionObject(nil),
+	}
+}
+
+func TestSendRequestCancelled(t *testing.T) {
+	release := make(chan struct{})
+	hints := make(chan uint32, 1)
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		r := req.(*ua.ReadRequest)
+		if r.MaxAge == 1 {
+			// the slow request
+			hints <- r.RequestHeader.TimeoutHint
+			<-release
+		}
+		return &ua.ReadResponse{
+			ResponseHeader: fakeResponseHeader(r.RequestHeader),
+			Results:        []*ua.DataValue{{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(r.MaxAge)}},
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := uacp.Dial(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		SecurityPolicyURI: ua.SecurityPolicyURINone,
+		Lifetime:          uint32(time.Hour / time.Millisecond),
+		RequestTimeout:    10 * time.Second,
+	}
+	s, err := NewSecureChannel(endpoint, c, cfg, make(chan error, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	handlers := func() int {
+		s.handlersMu.Lock()
+		defer s.handlersMu.Unlock()
+		return len(s.handlers)
+	}
+	read := func(ctx context.Context, maxAge float64) (float64, error) {
+		var v float64
+		err := s.SendRequestWithContext(ctx, &ua.ReadRequest{MaxAge: maxAge}, nil, func(res interface{}) error {
+			v = res.(*ua.ReadResponse).Results[0].Value.Value().(float64)
+			return nil
+		})
+		return v, err
+	}
+
+	goroutines := runtime.NumGoroutine()
+
+	rctx, rcancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer rcancel()
+	if _, err := read(rctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("got error %v want %v", err, context.DeadlineExceeded)
+	}
+	if hint := <-hints; hint == 0 || hint > 200 {
+		t.Fatalf("got timeout hint %d want at most 200ms", hint)
+	}
+	if got := handlers(); got != 0 {
+		t.Fatalf("got %d handlers after cancel want 0", got)
+	}
+
+	// the late response is dropped and not delivered to the next request.
+	close(release)
+	for i := 0; i < 3; i++ {
+		v, err := read(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verify.Values(t, "", v, 2.0)
+	}
+	if got := handlers(); got != 0 {
+		t.Fatalf("got %d handlers want 0", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d goroutines want %d", runtime.NumGoroutine(), goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReceiveBufferReuse(t *testing.T) {
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		r := req.(*ua.ReadRequest)
+		b := make([]byte, 64)
+		for i := range b {
+			b[i] = byte(r.MaxAge)
+		}
+		return &ua.ReadResponse{
+			ResponseHeader: fakeResponseHeader(r.RequestHeader),
+			Results:        []*ua.DataValue{{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(b)}},
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := uacp.Dial(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		SecurityPolicyURI: ua.SecurityPolicyURINone,
+		Lifetime:          uint32(time.Hour / time.Millisecond),
+		RequestTimeout:    10 * time.Second,
+	}
+	s, err := NewSecureChannel(endpoint, c, cfg, make(chan error, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// the values of earlier responses must not change when the receive
+	// buffers are reused for later responses.
+	var got [][]byte
+	for i := 1; i <= 10; i++ {
+		err := s.SendRequestWithContext(ctx, &ua.ReadRequest{MaxAge: float64(i)}, nil, func(res interface{}) error {
+			got = append(got, res.(*ua.ReadResponse).Results[0].Value.Value().([]byte))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, b := range got {
+		for _, v := range b {
+			if int(v) != i+1 {
+				t.Fatalf("response %d: got byte %d want %d", i+1, v, i+1)
+			}
+		}
+	}
+}
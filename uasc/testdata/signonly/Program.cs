@@ -0,0 +1,86 @@
+// Computes a Basic256Sha256 Sign-only MSG chunk as specified in
+// OPC UA Part 6, 6.7.2 (message chunk layout), 6.7.5 (key derivation
+// with P_SHA256) and 5.2 (binary encoding) with the cryptography of
+// .NET. It does not use any code of the Go package.
+using System;
+using System.IO;
+using System.Security.Cryptography;
+
+static class Program
+{
+    static byte[] PSHA256(byte[] secret, byte[] seed, int length)
+    {
+        using var h = new HMACSHA256(secret);
+        var result = new MemoryStream();
+        var a = seed;
+        while (result.Length < length)
+        {
+            a = h.ComputeHash(a);
+            var input = new byte[a.Length + seed.Length];
+            a.CopyTo(input, 0);
+            seed.CopyTo(input, a.Length);
+            var p = h.ComputeHash(input);
+            result.Write(p, 0, p.Length);
+        }
+        var b = result.ToArray();
+        Array.Resize(ref b, length);
+        return b;
+    }
+
+    static void Main()
+    {
+        var clientNonce = new byte[32];
+        var serverNonce = new byte[32];
+        for (int i = 0; i < 32; i++)
+        {
+            clientNonce[i] = (byte)i;
+            serverNonce[i] = (byte)(0xff - i);
+        }
+
+        // the client signing key is the first 32 bytes of
+        // P_SHA256(ServerNonce, ClientNonce).
+        var signingKey = PSHA256(serverNonce, clientNonce, 32);
+
+        // WriteRequest with a single WriteValue whose value is a
+        // ByteString of 16 bytes 0xab.
+        var body = new MemoryStream();
+        var w = new BinaryWriter(body);
+        w.Write((byte)0x01); w.Write((byte)0x00); w.Write((ushort)673); // TypeId i=673 (WriteRequest_Encoding_DefaultBinary) as FourByte
+        w.Write((byte)0x00); w.Write((byte)0x00);                         // AuthenticationToken i=0 as TwoByte
+        w.Write(new DateTime(2020, 1, 2, 3, 4, 5, DateTimeKind.Utc).ToFileTimeUtc()); // Timestamp
+        w.Write((uint)5);                                                 // RequestHandle
+        w.Write((uint)0);                                                 // ReturnDiagnostics
+        w.Write(-1);                                                      // AuditEntryId null
+        w.Write((uint)0);                                                 // TimeoutHint
+        w.Write((byte)0x00); w.Write((byte)0x00); w.Write((byte)0x00);    // AdditionalHeader null extension object
+        w.Write(1);                                                       // NodesToWrite length
+        w.Write((byte)0x02); w.Write((ushort)1); w.Write((uint)42);       // NodeId ns=1;i=42 as Numeric
+        w.Write((uint)13);                                                // AttributeId Value
+        w.Write(-1);                                                      // IndexRange null
+        w.Write((byte)0x01);                                              // DataValue mask: Value
+        w.Write((byte)15);                                                // Variant ByteString
+        w.Write(16);
+        for (int i = 0; i < 16; i++) w.Write((byte)0xab);
+        w.Flush();
+        var bodyBytes = body.ToArray();
+
+        var chunk = new MemoryStream();
+        var c = new BinaryWriter(chunk);
+        int size = 8 + 4 + 4 + 8 + bodyBytes.Length + 32;
+        c.Write(new[] { (byte)'M', (byte)'S', (byte)'G', (byte)'F' });
+        c.Write((uint)size);
+        c.Write((uint)7);   // SecureChannelId
+        c.Write((uint)3);   // TokenId
+        c.Write((uint)11);  // SequenceNumber
+        c.Write((uint)5);   // RequestId
+        c.Write(bodyBytes);
+        c.Flush();
+        var plain = chunk.ToArray();
+
+        using var hmac = new HMACSHA256(signingKey);
+        var sig = hmac.ComputeHash(plain);
+        c.Write(sig);
+        c.Flush();
+        Console.WriteLine(Convert.ToHexString(chunk.ToArray()).ToLowerInvariant());
+    }
+}
@@ -0,0 +1,197 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uasc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// RequestInfo describes a request and its response for the RequestTracer
+// of the secure channel.
+type RequestInfo struct {
+	// Request is the request. Its type identifies the service.
+	Request ua.Request
+
+	// Response is the response or nil if no response was received,
+	// e.g. because the request timed out.
+	Response ua.Response
+
+	// RequestHandle is the request handle of the request header.
+	RequestHandle uint32
+
+	// Duration is the time from sending the request until the response
+	// was received or the request failed.
+	Duration time.Duration
+
+	// Status is the service result of the response or the status code
+	// of the error of the request.
+	Status ua.StatusCode
+
+	// Err is the error of the request.
+	Err error
+
+	// BytesSent is the number of bytes of the chunks of the request.
+	BytesSent int
+
+	// BytesReceived is the number of bytes of the chunks of the response.
+	BytesReceived int
+}
+
+// Service returns the name of the service of the request, e.g. "Read"
+// for a ReadRequest.
+func (i RequestInfo) Service() string {
+	return ServiceName(i.Request)
+}
+
+// ServiceName returns the name of the service of a request, e.g. "Read"
+// for a ReadRequest.
+func ServiceName(req ua.Request) string {
+	name := fmt.Sprintf("%T", req)
+	if n := strings.LastIndex(name, "."); n >= 0 {
+		name = name[n+1:]
+	}
+	return strings.TrimSuffix(name, "Request")
+}
+
+// Tracer starts a span for every request of the secure channel. It is
+// modeled after the tracer of OpenTelemetry. The uaotel module provides
+// the implementation for an OpenTelemetry trace.Tracer.
+type Tracer interface {
+	// Start starts a span with the name of the service as a child of the
+	// span in ctx, if any, and returns the context with the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the span of a request.
+type Span interface {
+	// SetAttributes sets the attributes of the span. The keys are strings
+	// and the values are strings or int64 values.
+	SetAttributes(kv ...interface{})
+
+	// SetError records the error of a failed request and sets the status
+	// of the span to error.
+	SetError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// Span attributes which are set for every request.
+const (
+	// AttrService is the name of the service, e.g. "Read".
+	AttrService = "opcua.service"
+
+	// AttrNodeCount is the number of nodes of the request. It is only
+	// set for services which operate on a list of nodes.
+	AttrNodeCount = "opcua.node_count"
+
+	// AttrStatusCode is the status code of the request.
+	AttrStatusCode = "opcua.status_code"
+)
+
+// startSpan starts the span of a request with the tracer and returns the
+// context with the span and a function which ends the span with the error
+// of the request.
+func (s *SecureChannel) startSpan(ctx context.Context, req ua.Request) (context.Context, func(err error)) {
+	name := ServiceName(req)
+	ctx, span := s.cfg.Tracer.Start(ctx, name)
+	kv := []interface{}{AttrService, name}
+	if n, ok := nodeCount(req); ok {
+		kv = append(kv, AttrNodeCount, int64(n))
+	}
+	span.SetAttributes(kv...)
+	return ctx, func(err error) {
+		span.SetAttributes(AttrStatusCode, int64(requestStatus(err)))
+		if err != nil {
+			span.SetError(err)
+		}
+		span.End()
+	}
+}
+
+// nodeCount returns the number of nodes of a request for the services
+// which operate on a list of nodes.
+func nodeCount(req ua.Request) (int, bool) {
+	switch r := req.(type) {
+	case *ua.ReadRequest:
+		return len(r.NodesToRead), true
+	case *ua.WriteRequest:
+		return len(r.NodesToWrite), true
+	case *ua.BrowseRequest:
+		return len(r.NodesToBrowse), true
+	case *ua.BrowseNextRequest:
+		return len(r.ContinuationPoints), true
+	case *ua.TranslateBrowsePathsToNodeIDsRequest:
+		return len(r.BrowsePaths), true
+	case *ua.CallRequest:
+		return len(r.MethodsToCall), true
+	case *ua.HistoryReadRequest:
+		return len(r.NodesToRead), true
+	case *ua.HistoryUpdateRequest:
+		return len(r.HistoryUpdateDetails), true
+	case *ua.RegisterNodesRequest:
+		return len(r.NodesToRegister), true
+	case *ua.UnregisterNodesRequest:
+		return len(r.NodesToUnregister), true
+	case *ua.AddNodesRequest:
+		return len(r.NodesToAdd), true
+	case *ua.DeleteNodesRequest:
+		return len(r.NodesToDelete), true
+	case *ua.CreateMonitoredItemsRequest:
+		return len(r.ItemsToCreate), true
+	case *ua.ModifyMonitoredItemsRequest:
+		return len(r.ItemsToModify), true
+	case *ua.DeleteMonitoredItemsRequest:
+		return len(r.MonitoredItemIDs), true
+	}
+	return 0, false
+}
+
+// traceRequest calls the RequestTracer for a request which was sent at
+// start.
+func (s *SecureChannel) traceRequest(req ua.Request, reqID uint32, start time.Time, sent, received int, res interface{}, err error) {
+	info := RequestInfo{
+		Request:       req,
+		RequestHandle: reqID,
+		Duration:      time.Since(start),
+		Status:        requestStatus(err),
+		Err:           err,
+		BytesSent:     sent,
+		BytesReceived: received,
+	}
+	if r, ok := res.(ua.Response); ok {
+		info.Response = r
+	}
+	if h := req.Header(); h != nil {
+		info.RequestHandle = h.RequestHandle
+	}
+	s.cfg.RequestTracer(info)
+}
+
+// requestStatus returns the status code for the error of a request.
+func requestStatus(err error) ua.StatusCode {
+	switch {
+	case err == nil:
+		return ua.StatusOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return ua.StatusBadTimeout
+	case errors.Is(err, context.Canceled):
+		return ua.StatusBadRequestCancelledByClient
+	case err == io.EOF:
+		return ua.StatusBadConnectionClosed
+	}
+	var code ua.StatusCode
+	if errors.As(err, &code) {
+		return code
+	}
+	return ua.StatusBadUnexpectedError
+}
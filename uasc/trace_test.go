@@ -0,0 +1,203 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package uasc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/ua"
+	"github.com/zzylovesll/myOpcUa/uacp"
+)
+
+func TestRequestTracer(t *testing.T) {
+	release := make(chan struct{})
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		r := req.(*ua.ReadRequest)
+		if r.MaxAge == 1 {
+			<-release
+		}
+		return &ua.ReadResponse{
+			ResponseHeader: fakeResponseHeader(r.RequestHeader),
+			Results:        []*ua.DataValue{{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(r.MaxAge)}},
+		}
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := uacp.Dial(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := make(chan RequestInfo, 10)
+	cfg := &Config{
+		SecurityPolicyURI: ua.SecurityPolicyURINone,
+		Lifetime:          uint32(time.Hour / time.Millisecond),
+		RequestTimeout:    10 * time.Second,
+		RequestTracer:     func(info RequestInfo) { infos <- info },
+	}
+	s, err := NewSecureChannel(endpoint, c, cfg, make(chan error, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if info := <-infos; info.Service() != "OpenSecureChannel" || info.Status != ua.StatusOK {
+		t.Fatalf("got %s with status %s want OpenSecureChannel with status %s", info.Service(), info.Status, ua.StatusOK)
+	}
+
+	read := func(ctx context.Context, maxAge float64) error {
+		return s.SendRequestWithContext(ctx, &ua.ReadRequest{MaxAge: maxAge}, nil, func(interface{}) error { return nil })
+	}
+
+	if err := read(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	info := <-infos
+	if got, want := info.Service(), "Read"; got != want {
+		t.Fatalf("got service %q want %q", got, want)
+	}
+	if info.Status != ua.StatusOK || info.Err != nil {
+		t.Fatalf("got status %s and error %v want %s", info.Status, info.Err, ua.StatusOK)
+	}
+	if _, ok := info.Response.(*ua.ReadResponse); !ok {
+		t.Fatalf("got response %T want *ua.ReadResponse", info.Response)
+	}
+	if info.RequestHandle != info.Request.Header().RequestHandle || info.RequestHandle == 0 {
+		t.Fatalf("got request handle %d want %d", info.RequestHandle, info.Request.Header().RequestHandle)
+	}
+	if info.BytesSent == 0 || info.BytesReceived == 0 || info.Duration <= 0 {
+		t.Fatalf("got %d bytes sent, %d bytes received in %v", info.BytesSent, info.BytesReceived, info.Duration)
+	}
+
+	// the slow request times out and has no response.
+	rctx, rcancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer rcancel()
+	if err := read(rctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("got error %v want %v", err, context.DeadlineExceeded)
+	}
+	info = <-infos
+	if info.Status != ua.StatusBadTimeout || info.Err != context.DeadlineExceeded {
+		t.Fatalf("got status %s and error %v want %s", info.Status, info.Err, ua.StatusBadTimeout)
+	}
+	if info.Response != nil || info.BytesReceived != 0 || info.BytesSent == 0 {
+		t.Fatalf("got response %T with %d bytes sent, %d bytes received", info.Response, info.BytesSent, info.BytesReceived)
+	}
+}
+
+type spanKey struct{}
+
+// testSpan records the attributes, the error and the parent of a span.
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(kv ...interface{}) {
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.attrs[kv[i].(string)] = kv[i+1]
+	}
+}
+
+func (s *testSpan) SetError(err error) { s.err = err }
+func (s *testSpan) End()               { s.ended = true }
+
+type testTracer struct {
+	spans chan *testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*testSpan)
+	s := &testSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	t.spans <- s
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestTracer(t *testing.T) {
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		r := req.(*ua.ReadRequest)
+		h := fakeResponseHeader(r.RequestHeader)
+		if r.MaxAge == 1 {
+			h.ServiceResult = ua.StatusBadNodeIDUnknown
+		}
+		return &ua.ReadResponse{ResponseHeader: h}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := uacp.Dial(ctx, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := &testTracer{spans: make(chan *testSpan, 10)}
+	cfg := &Config{
+		SecurityPolicyURI: ua.SecurityPolicyURINone,
+		Lifetime:          uint32(time.Hour / time.Millisecond),
+		RequestTimeout:    10 * time.Second,
+		Tracer:            tracer,
+	}
+	s, err := NewSecureChannel(endpoint, c, cfg, make(chan error, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if span := <-tracer.spans; span.name != "OpenSecureChannel" || !span.ended {
+		t.Fatalf("got span %q ended=%v want OpenSecureChannel", span.name, span.ended)
+	}
+
+	parent := &testSpan{name: "caller"}
+	pctx := context.WithValue(ctx, spanKey{}, parent)
+	read := func(maxAge float64) error {
+		req := &ua.ReadRequest{MaxAge: maxAge, NodesToRead: []*ua.ReadValueID{
+			{NodeID: ua.NewNumericNodeID(0, 1), DataEncoding: &ua.QualifiedName{}},
+			{NodeID: ua.NewNumericNodeID(0, 2), DataEncoding: &ua.QualifiedName{}},
+			{NodeID: ua.NewNumericNodeID(0, 3), DataEncoding: &ua.QualifiedName{}},
+		}}
+		return s.SendRequestWithContext(pctx, req, nil, func(interface{}) error { return nil })
+	}
+
+	if err := read(2); err != nil {
+		t.Fatal(err)
+	}
+	span := <-tracer.spans
+	if span.name != "Read" || span.parent != parent || !span.ended {
+		t.Fatalf("got span %q with parent %v ended=%v want Read with parent caller", span.name, span.parent, span.ended)
+	}
+	want := map[string]interface{}{
+		AttrService:    "Read",
+		AttrNodeCount:  int64(3),
+		AttrStatusCode: int64(ua.StatusOK),
+	}
+	for k, v := range want {
+		if span.attrs[k] != v {
+			t.Fatalf("got attribute %s=%v want %v", k, span.attrs[k], v)
+		}
+	}
+	if span.err != nil {
+		t.Fatalf("got error %v want nil", span.err)
+	}
+
+	if err := read(1); err != ua.StatusBadNodeIDUnknown {
+		t.Fatalf("got error %v want %v", err, ua.StatusBadNodeIDUnknown)
+	}
+	span = <-tracer.spans
+	if got, want := span.attrs[AttrStatusCode], int64(ua.StatusBadNodeIDUnknown); got != want {
+		t.Fatalf("got status code %v want %v", got, want)
+	}
+	if span.err != ua.StatusBadNodeIDUnknown || !span.ended {
+		t.Fatalf("got error %v ended=%v want %v", span.err, span.ended, ua.StatusBadNodeIDUnknown)
+	}
+}
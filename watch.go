@@ -0,0 +1,155 @@
+// Copyright 2018-2020 opcua authors. All rights reserved.
+// Use of this source code is governed by a MIT-style license that can be
+// found in the LICENSE file.
+
+package opcua
+
+import (
+	"context"
+	"time"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/stats"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+// WatchEvent is a value change of a node which is watched with
+// Client.Watch.
+type WatchEvent struct {
+	// NodeID is the node of the value. It is nil for errors of the
+	// subscription.
+	NodeID *ua.NodeID
+
+	// Value is the decoded value of the node.
+	Value interface{}
+
+	// Status is the status of the value.
+	Status ua.StatusCode
+
+	// SourceTimestamp is the time of the value change at the source.
+	SourceTimestamp time.Time
+
+	// ServerTimestamp is the time the server received the value.
+	ServerTimestamp time.Time
+
+	// Error is set if the subscription reported an error instead of a
+	// value, e.g. because the connection to the server was lost.
+	Error error
+}
+
+// Watch creates a subscription with the publishing interval which
+// monitors the values of the nodes and returns the channel with the value
+// changes. The subscription is cancelled and the channel is closed when
+// ctx is done. The value of every node is sent once after the node has
+// been added and then whenever it changes.
+//
+// Watch returns an error and does not create a subscription if one of
+// the nodes cannot be monitored.
+func (c *Client) Watch(ctx context.Context, nodeIDs []*ua.NodeID, interval time.Duration) (<-chan *WatchEvent, error) {
+	stats.Client().Add("Watch", 1)
+
+	if len(nodeIDs) == 0 {
+		return nil, errors.Errorf("no nodes to watch")
+	}
+
+	notifs := make(chan *PublishNotificationData, len(nodeIDs))
+	sub, err := c.SubscribeWithContext(ctx, &SubscriptionParameters{Interval: interval}, notifs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ua.MonitoredItemCreateRequest, len(nodeIDs))
+	for i, n := range nodeIDs {
+		items[i] = NewMonitoredItemCreateRequestWithDefaults(n, ua.AttributeIDValue, uint32(i))
+	}
+	res, err := sub.MonitorWithContext(ctx, ua.TimestampsToReturnBoth, items...)
+	if err == nil {
+		for i, r := range res.Results {
+			if r.StatusCode != ua.StatusOK {
+				err = errors.Wrapf(r.StatusCode, "cannot watch %s", nodeIDs[i])
+				break
+			}
+		}
+	}
+	if err != nil {
+		sub.Cancel(context.Background())
+		return nil, err
+	}
+
+	ch := make(chan *WatchEvent)
+	go c.watch(ctx, sub, nodeIDs, notifs, ch)
+	return ch, nil
+}
+
+// watch sends the value changes of the subscription to ch until ctx is
+// done. Then it cancels the subscription and closes ch.
+func (c *Client) watch(ctx context.Context, sub *Subscription, nodeIDs []*ua.NodeID, notifs chan *PublishNotificationData, ch chan<- *WatchEvent) {
+	defer close(ch)
+	defer func() {
+		// drain the notifications which are sent while the
+		// subscription is cancelled so that the publish loop
+		// does not block.
+		done := make(chan struct{})
+		go func() {
+			sub.Cancel(context.Background())
+			close(done)
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case <-notifs:
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-notifs:
+			for _, ev := range watchEvents(nodeIDs, n) {
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- ev:
+				}
+			}
+		}
+	}
+}
+
+// watchEvents returns the events for the notification of a subscription
+// whose monitored items have the index of the node as client handle.
+func watchEvents(nodeIDs []*ua.NodeID, n *PublishNotificationData) []*WatchEvent {
+	if n.Error != nil {
+		return []*WatchEvent{{Error: n.Error}}
+	}
+
+	switch x := n.Value.(type) {
+	case *ua.DataChangeNotification:
+		var evs []*WatchEvent
+		for _, item := range x.MonitoredItems {
+			if item == nil || int(item.ClientHandle) >= len(nodeIDs) {
+				continue
+			}
+			ev := &WatchEvent{NodeID: nodeIDs[item.ClientHandle]}
+			if dv := item.Value; dv != nil {
+				if dv.Value != nil {
+					ev.Value = dv.Value.Value()
+				}
+				ev.Status = dv.Status
+				ev.SourceTimestamp = dv.SourceTimestamp
+				ev.ServerTimestamp = dv.ServerTimestamp
+			}
+			evs = append(evs, ev)
+		}
+		return evs
+
+	case *ua.StatusChangeNotification:
+		if x.Status != ua.StatusOK {
+			return []*WatchEvent{{Error: x.Status}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package opcua
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pascaldekloe/goe/verify"
+
+	"github.com/zzylovesll/myOpcUa/errors"
+	"github.com/zzylovesll/myOpcUa/ua"
+)
+
+func TestWatchEvents(t *testing.T) {
+	a, b := ua.NewStringNodeID(1, "a"), ua.NewStringNodeID(1, "b")
+	nodeIDs := []*ua.NodeID{a, b}
+	now := time.Now()
+	errLost := errors.New("lost")
+
+	tests := []struct {
+		name string
+		n    *PublishNotificationData
+		want []*WatchEvent
+	}{
+		{
+			name: "data change",
+			n: &PublishNotificationData{Value: &ua.DataChangeNotification{
+				MonitoredItems: []*ua.MonitoredItemNotification{
+					{ClientHandle: 1, Value: &ua.DataValue{Value: ua.MustVariant(int32(5)), Status: ua.StatusOK, SourceTimestamp: now, ServerTimestamp: now}},
+					{ClientHandle: 0, Value: &ua.DataValue{Status: ua.StatusBadNodeIDUnknown}},
+					{ClientHandle: 2, Value: &ua.DataValue{Value: ua.MustVariant(int32(6))}},
+				},
+			}},
+			want: []*WatchEvent{
+				{NodeID: b, Value: int32(5), Status: ua.StatusOK, SourceTimestamp: now, ServerTimestamp: now},
+				{NodeID: a, Status: ua.StatusBadNodeIDUnknown},
+			},
+		},
+		{
+			name: "error",
+			n:    &PublishNotificationData{Error: errLost},
+			want: []*WatchEvent{{Error: errLost}},
+		},
+		{
+			name: "status change",
+			n:    &PublishNotificationData{Value: &ua.StatusChangeNotification{Status: ua.StatusBadTimeout}},
+			want: []*WatchEvent{{Error: ua.StatusBadTimeout}},
+		},
+		{
+			name: "events",
+			n:    &PublishNotificationData{Value: &ua.EventNotificationList{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify.Values(t, "", watchEvents(nodeIDs, tt.n), tt.want)
+		})
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	deleted := make(chan []uint32, 1)
+	endpoint := startFakeServer(t, func(req ua.Request) ua.Response {
+		h := fakeResponseHeader(req.Header())
+		switch req := req.(type) {
+		case *ua.CreateSubscriptionRequest:
+			return &ua.CreateSubscriptionResponse{
+				ResponseHeader:            h,
+				SubscriptionID:            7,
+				RevisedPublishingInterval: req.RequestedPublishingInterval,
+				RevisedLifetimeCount:      req.RequestedLifetimeCount,
+				RevisedMaxKeepAliveCount:  req.RequestedMaxKeepAliveCount,
+			}
+		case *ua.CreateMonitoredItemsRequest:
+			res := &ua.CreateMonitoredItemsResponse{ResponseHeader: h}
+			for i := range req.ItemsToCreate {
+				res.Results = append(res.Results, &ua.MonitoredItemCreateResult{
+					StatusCode:      ua.StatusOK,
+					MonitoredItemID: uint32(i + 1),
+					FilterResult:    ua.NewExtensionObject(nil),
+				})
+			}
+			return res
+		case *ua.DeleteSubscriptionsRequest:
+			deleted <- req.SubscriptionIDs
+			res := &ua.DeleteSubscriptionsResponse{ResponseHeader: h}
+			for range req.SubscriptionIDs {
+				res.Results = append(res.Results, ua.StatusOK)
+			}
+			return res
+		}
+		return nil
+	})
+
+	c := NewClient(endpoint, AutoReconnect(false))
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := c.Watch(ctx, []*ua.NodeID{ua.NewNumericNodeID(1, 1)}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case ids := <-deleted:
+		verify.Values(t, "deleted subscriptions", ids, []uint32{7})
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not deleted")
+	}
+
+	timeout := time.After(5 * time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-ch:
+			closed = !ok
+		case <-timeout:
+			t.Fatal("channel not closed")
+		}
+	}
+
+	// the goroutine of the watch returns after it has closed the
+	// channel.
+	for i := 0; watchRunning(); i++ {
+		if i == 100 {
+			t.Fatal("watch goroutine still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// watchRunning returns true if a goroutine runs Client.watch.
+func watchRunning() bool {
+	buf := make([]byte, 1<<20)
+	return strings.Contains(string(buf[:runtime.Stack(buf, true)]), "(*Client).watch(")
+}